@@ -4,12 +4,15 @@ import (
 	"log"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 
 	"vitess.io/vitess/go/cmd/rulesctl/common"
 	"vitess.io/vitess/go/vt/vttablet/tabletserver/planbuilder"
 	vtrules "vitess.io/vitess/go/vt/vttablet/tabletserver/rules"
+
+	querypb "vitess.io/vitess/go/vt/proto/query"
 )
 
 var (
@@ -22,6 +25,10 @@ var (
 	addOptQueryRE           string
 	addOptLeadingCommentRE  string
 	addOptTrailingCommentRE string
+	addOptMaxQPS            int
+	addOptMaxConcurrency    int
+	addOptMaxExecutionTime  time.Duration
+	addOptWorkload          string
 	// TODO: other stuff, bind vars etc
 )
 
@@ -54,6 +61,31 @@ func runAdd(cmd *cobra.Command, args []string) {
 		}
 	}
 
+	if addOptMaxQPS > 0 {
+		rule.SetMaxQPS(addOptMaxQPS)
+	}
+	if addOptMaxConcurrency > 0 {
+		rule.SetMaxConcurrency(addOptMaxConcurrency)
+	}
+	if addOptMaxExecutionTime > 0 {
+		rule.SetMaxExecutionTime(addOptMaxExecutionTime)
+	}
+	if addOptWorkload != "" {
+		workload, ok := querypb.ExecuteOptions_Workload_value[strings.ToUpper(addOptWorkload)]
+		if !ok || workload == int32(querypb.ExecuteOptions_UNSPECIFIED) {
+			log.Fatalf("Unknown workload '%v'", addOptWorkload)
+		}
+		rule.SetWorkload(querypb.ExecuteOptions_Workload(workload))
+	}
+	switch {
+	case ruleAction == vtrules.QRRateLimit && addOptMaxQPS <= 0:
+		log.Fatalf("Action rate-limit requires --max-qps")
+	case ruleAction == vtrules.QRConcurrencyLimit && addOptMaxConcurrency <= 0:
+		log.Fatalf("Action concurrency-limit requires --max-concurrency")
+	case ruleAction == vtrules.QRRewrite && addOptMaxExecutionTime <= 0 && addOptWorkload == "":
+		log.Fatalf("Action rewrite requires --max-execution-time or --workload")
+	}
+
 	var rules *vtrules.Rules
 	_, err := os.Stat(configFile)
 	if os.IsNotExist(err) {
@@ -103,6 +135,12 @@ func mkAction() vtrules.Action {
 		return vtrules.QRFail
 	case "fail_retry":
 		return vtrules.QRFailRetry
+	case "rate-limit":
+		return vtrules.QRRateLimit
+	case "concurrency-limit":
+		return vtrules.QRConcurrencyLimit
+	case "rewrite":
+		return vtrules.QRRewrite
 	case "continue":
 		return vtrules.QRContinue
 	default:
@@ -139,7 +177,7 @@ func Add() *cobra.Command {
 		&addOptAction,
 		"action", "a",
 		"",
-		"What action should be taken when this rule is matched {continue, fail, fail-retry} (required)")
+		"What action should be taken when this rule is matched {continue, fail, fail-retry, rate-limit, concurrency-limit, rewrite} (required)")
 	addCmd.Flags().StringSliceVarP(
 		&addOptPlans,
 		"plan", "p",
@@ -165,6 +203,26 @@ func Add() *cobra.Command {
 		"trailing-comment", "r",
 		"",
 		"A regexp that will be applied to comments after a SQL statement")
+	addCmd.Flags().IntVar(
+		&addOptMaxQPS,
+		"max-qps",
+		0,
+		"The queries per second let through by the rate-limit action")
+	addCmd.Flags().IntVar(
+		&addOptMaxConcurrency,
+		"max-concurrency",
+		0,
+		"The concurrent queries let through by the concurrency-limit action")
+	addCmd.Flags().DurationVar(
+		&addOptMaxExecutionTime,
+		"max-execution-time",
+		0,
+		"Caps the execution time of the queries matching this rule")
+	addCmd.Flags().StringVar(
+		&addOptWorkload,
+		"workload",
+		"",
+		"Forces the workload of the queries matching this rule {oltp, olap, dba}")
 
 	for _, f := range []string{"name", "action"} {
 		addCmd.MarkFlagRequired(f)
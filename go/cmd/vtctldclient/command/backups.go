@@ -60,6 +60,19 @@ If no replica-type tablet can be found, the backup can be taken on the primary i
 		Args:                  cobra.ExactArgs(1),
 		RunE:                  commandGetBackups,
 	}
+	// PruneBackups makes a PruneBackups gRPC call to a vtctld.
+	PruneBackups = &cobra.Command{
+		Use:   "PruneBackups [--keep-last <n>] [--keep-daily-days <days>] [--prune-incomplete] [--dry-run] <keyspace/shard>",
+		Short: "Removes the backups of the given shard which are not retained by the given policy.",
+		Long: `Removes the backups of the given shard which are not retained by the given policy.
+
+At least one of --keep-last and --keep-daily-days is required.
+The most recent full backup, along with the incremental backups taken after it, is always kept.
+So are the full and incremental backups any retained incremental backup needs in order to be restored.`,
+		DisableFlagsInUseLine: true,
+		Args:                  cobra.ExactArgs(1),
+		RunE:                  commandPruneBackups,
+	}
 	// RemoveBackup makes a RemoveBackup gRPC call to a vtctld.
 	RemoveBackup = &cobra.Command{
 		Use:                   "RemoveBackup <keyspace/shard> <backup name>",
@@ -68,10 +81,27 @@ If no replica-type tablet can be found, the backup can be taken on the primary i
 		Args:                  cobra.ExactArgs(2),
 		RunE:                  commandRemoveBackup,
 	}
+	// ValidateBackup makes a ValidateBackup gRPC call to a vtctld.
+	ValidateBackup = &cobra.Command{
+		Use:   "ValidateBackup [--test-restore --scratch-tablet-uid <uid> --scratch-mysql-port <port>] <keyspace/shard> <backup name>",
+		Short: "Reads every file of the given backup from the BackupStorage used by vtctld, and checks them against the MANIFEST.",
+		Long: `Reads every file of the given backup from the BackupStorage used by vtctld, and checks them against the MANIFEST.
+
+With --test-restore, the backup is then restored into a scratch mysqld started on the vtctld host, which requires
+mysqld to be installed there. The scratch mysqld is torn down once done.`,
+		DisableFlagsInUseLine: true,
+		Args:                  cobra.ExactArgs(2),
+		RunE:                  commandValidateBackup,
+	}
 	// RestoreFromBackup makes a RestoreFromBackup gRPC call to a vtctld.
 	RestoreFromBackup = &cobra.Command{
-		Use:                   "RestoreFromBackup [--backup-timestamp|-t <YYYY-mm-DD.HHMMSS>] <tablet_alias>",
-		Short:                 "Stops mysqld on the specified tablet and restores the data from either the latest backup or closest before `backup-timestamp`.",
+		Use:   "RestoreFromBackup [--backup-timestamp|-t <YYYY-mm-DD.HHMMSS>] [--restore-to-timestamp <RFC3339 time>] <tablet_alias>",
+		Short: "Stops mysqld on the specified tablet and restores the data from either the latest backup or closest before `backup-timestamp`.",
+		Long: `Stops mysqld on the specified tablet and restores the data from either the latest backup or closest before --backup-timestamp.
+
+With --restore-to-timestamp, a point in time recovery is run instead: the data is restored from a full backup followed by
+incremental backups, whose binary logs are applied up to the last transaction committed at or before the given time.
+Replication is not restarted on the tablet.`,
 		DisableFlagsInUseLine: true,
 		Args:                  cobra.ExactArgs(1),
 		RunE:                  commandRestoreFromBackup,
@@ -191,6 +221,46 @@ func commandGetBackups(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+var pruneBackupsOptions = struct {
+	KeepLast        uint32
+	KeepDailyDays   uint32
+	PruneIncomplete bool
+	DryRun          bool
+}{}
+
+func commandPruneBackups(cmd *cobra.Command, args []string) error {
+	keyspace, shard, err := topoproto.ParseKeyspaceShard(cmd.Flags().Arg(0))
+	if err != nil {
+		return err
+	}
+
+	if pruneBackupsOptions.KeepLast == 0 && pruneBackupsOptions.KeepDailyDays == 0 {
+		return fmt.Errorf("at least one of --keep-last or --keep-daily-days must be specified when calling the PruneBackups command")
+	}
+
+	cli.FinishedParsing(cmd)
+
+	resp, err := client.PruneBackups(commandCtx, &vtctldatapb.PruneBackupsRequest{
+		Keyspace:        keyspace,
+		Shard:           shard,
+		KeepLast:        pruneBackupsOptions.KeepLast,
+		KeepDailyDays:   pruneBackupsOptions.KeepDailyDays,
+		PruneIncomplete: pruneBackupsOptions.PruneIncomplete,
+		DryRun:          pruneBackupsOptions.DryRun,
+	})
+	if err != nil {
+		return err
+	}
+
+	data, err := cli.MarshalJSON(resp)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%s\n", data)
+	return nil
+}
+
 func commandRemoveBackup(cmd *cobra.Command, args []string) error {
 	keyspace, shard, err := topoproto.ParseKeyspaceShard(cmd.Flags().Arg(0))
 	if err != nil {
@@ -209,8 +279,46 @@ func commandRemoveBackup(cmd *cobra.Command, args []string) error {
 	return err
 }
 
+var validateBackupOptions = struct {
+	TestRestore      bool
+	ScratchTabletUID uint32
+	ScratchMysqlPort int32
+}{}
+
+func commandValidateBackup(cmd *cobra.Command, args []string) error {
+	keyspace, shard, err := topoproto.ParseKeyspaceShard(cmd.Flags().Arg(0))
+	if err != nil {
+		return err
+	}
+
+	name := cmd.Flags().Arg(1)
+
+	cli.FinishedParsing(cmd)
+
+	resp, err := client.ValidateBackup(commandCtx, &vtctldatapb.ValidateBackupRequest{
+		Keyspace:         keyspace,
+		Shard:            shard,
+		Name:             name,
+		TestRestore:      validateBackupOptions.TestRestore,
+		ScratchTabletUid: validateBackupOptions.ScratchTabletUID,
+		ScratchMysqlPort: validateBackupOptions.ScratchMysqlPort,
+	})
+	if err != nil {
+		return err
+	}
+
+	data, err := cli.MarshalJSON(resp)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%s\n", data)
+	return nil
+}
+
 var restoreFromBackupOptions = struct {
-	BackupTimestamp string
+	BackupTimestamp    string
+	RestoreToTimestamp string
 }{}
 
 func commandRestoreFromBackup(cmd *cobra.Command, args []string) error {
@@ -232,6 +340,15 @@ func commandRestoreFromBackup(cmd *cobra.Command, args []string) error {
 		req.BackupTime = protoutil.TimeToProto(t)
 	}
 
+	if restoreFromBackupOptions.RestoreToTimestamp != "" {
+		t, err := time.Parse(time.RFC3339, restoreFromBackupOptions.RestoreToTimestamp)
+		if err != nil {
+			return err
+		}
+
+		req.RestoreToTimestamp = protoutil.TimeToProto(t)
+	}
+
 	cli.FinishedParsing(cmd)
 
 	stream, err := client.RestoreFromBackup(commandCtx, req)
@@ -265,8 +382,20 @@ func init() {
 	GetBackups.Flags().BoolVarP(&getBackupsOptions.OutputJSON, "json", "j", false, "Output backup info in JSON format rather than a list of backups.")
 	Root.AddCommand(GetBackups)
 
+	PruneBackups.Flags().Uint32Var(&pruneBackupsOptions.KeepLast, "keep-last", 0, "Keep the N most recent backups.")
+	PruneBackups.Flags().Uint32Var(&pruneBackupsOptions.KeepDailyDays, "keep-daily-days", 0, "Keep the most recent backup of each of the last N days (in UTC), today included.")
+	PruneBackups.Flags().BoolVar(&pruneBackupsOptions.PruneIncomplete, "prune-incomplete", false, "Also remove backups without a readable MANIFEST, if they are older than the most recent complete backup.")
+	PruneBackups.Flags().BoolVar(&pruneBackupsOptions.DryRun, "dry-run", false, "Only report the backups which would be removed.")
+	Root.AddCommand(PruneBackups)
+
 	Root.AddCommand(RemoveBackup)
 
 	RestoreFromBackup.Flags().StringVarP(&restoreFromBackupOptions.BackupTimestamp, "backup-timestamp", "t", "", "Use the backup taken at, or closest before, this timestamp. Omit to use the latest backup. Timestamp format is \"YYYY-mm-DD.HHMMSS\".")
+	RestoreFromBackup.Flags().StringVar(&restoreFromBackupOptions.RestoreToTimestamp, "restore-to-timestamp", "", "Run a point in time recovery up to the last transaction committed at or before this time, using a full backup followed by incremental backups. Timestamp format is RFC3339, e.g. \"2023-06-01T10:15:00Z\".")
 	Root.AddCommand(RestoreFromBackup)
+
+	ValidateBackup.Flags().BoolVar(&validateBackupOptions.TestRestore, "test-restore", false, "Restore the backup into a scratch mysqld on the vtctld host once all its files were read.")
+	ValidateBackup.Flags().Uint32Var(&validateBackupOptions.ScratchTabletUID, "scratch-tablet-uid", 0, "Uid of the tablet directory the scratch mysqld is initialized in, which must not exist yet. Required with --test-restore.")
+	ValidateBackup.Flags().Int32Var(&validateBackupOptions.ScratchMysqlPort, "scratch-mysql-port", 0, "Port the scratch mysqld listens on. Required with --test-restore.")
+	Root.AddCommand(ValidateBackup)
 }
@@ -0,0 +1,114 @@
+/*
+Copyright 2023 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package command
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"vitess.io/vitess/go/cmd/vtctldclient/cli"
+	"vitess.io/vitess/go/json2"
+	"vitess.io/vitess/go/vt/topo/topoproto"
+
+	vtctldatapb "vitess.io/vitess/go/vt/proto/vtctldata"
+)
+
+var (
+	// Materialize is the base command for all actions related to Materialize.
+	Materialize = &cobra.Command{
+		Use:                   "Materialize --workflow <workflow> --target-keyspace <keyspace> [command] [command-flags]",
+		Short:                 "Perform commands related to materializing query results from the source keyspace into tables in the target keyspace.",
+		DisableFlagsInUseLine: true,
+		Aliases:               []string{"materialize"},
+	}
+
+	// MaterializeCreate makes a MaterializeCreate gRPC call to a vtctld.
+	MaterializeCreate = &cobra.Command{
+		Use:                   "create",
+		Short:                 "Create and run a Materialize VReplication workflow.",
+		Example:               `vtctldclient --server=localhost:15999 Materialize --workflow product_sales --target-keyspace commerce create --source-keyspace commerce --table-settings '[{"target_table": "sales_by_sku", "create_ddl": "create table sales_by_sku (sku varbinary(128) not null primary key, orders bigint, revenue bigint)", "source_expression": "select sku, count(*) as orders, sum(price) as revenue from corder group by sku"}]' --cells zone1 --cells zone2 --tablet-types replica`,
+		SilenceUsage:          true,
+		DisableFlagsInUseLine: true,
+		Aliases:               []string{"Create"},
+		Args:                  cobra.NoArgs,
+		PreRunE:               validateVReplicationCreateFlags,
+		RunE:                  commandMaterializeCreate,
+	}
+
+	materializeCreateOptions = struct {
+		SourceKeyspace string
+		TableSettings  string
+	}{}
+)
+
+func commandMaterializeCreate(cmd *cobra.Command, args []string) error {
+	cli.FinishedParsing(cmd)
+
+	// The table settings are provided as a JSON array, which we unmarshal
+	// into the repeated field of the MaterializeSettings message.
+	settings := &vtctldatapb.MaterializeSettings{}
+	if err := json2.Unmarshal([]byte(fmt.Sprintf(`{"table_settings": %s}`, materializeCreateOptions.TableSettings)), settings); err != nil {
+		return fmt.Errorf("invalid table settings: %w", err)
+	}
+	if len(settings.TableSettings) == 0 {
+		return fmt.Errorf("no table settings provided")
+	}
+
+	tabletTypes, err := parseTabletTypesFlag(vreplicationCreateOptions.TabletTypes)
+	if err != nil {
+		return err
+	}
+
+	settings.Workflow = vreplicationOptions.Workflow
+	settings.SourceKeyspace = materializeCreateOptions.SourceKeyspace
+	settings.TargetKeyspace = vreplicationOptions.TargetKeyspace
+	settings.StopAfterCopy = vreplicationCreateOptions.StopAfterCopy
+	settings.Cell = strings.Join(vreplicationCreateOptions.Cells, ",")
+	settings.TabletTypes = strings.Join(topoproto.MakeStringTypeList(tabletTypes), ",")
+	settings.OnDdl = vreplicationCreateOptions.OnDDL
+	settings.DeferSecondaryKeys = vreplicationCreateOptions.DeferSecondaryKeys
+
+	_, err = client.MaterializeCreate(commandCtx, &vtctldatapb.MaterializeCreateRequest{
+		Settings: settings,
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Materialization workflow %s successfully created in the %s keyspace. Use show to view the status.\n",
+		vreplicationOptions.Workflow, vreplicationOptions.TargetKeyspace)
+
+	return nil
+}
+
+func init() {
+	addVReplicationFlags(Materialize)
+
+	addVReplicationCreateFlags(MaterializeCreate)
+	MaterializeCreate.Flags().StringVar(&materializeCreateOptions.SourceKeyspace, "source-keyspace", "", "Keyspace where the tables queried in the 'source_expression' values within table-settings live (required)")
+	MaterializeCreate.MarkFlagRequired("source-keyspace")
+	MaterializeCreate.Flags().StringVar(&materializeCreateOptions.TableSettings, "table-settings", "", "A JSON array defining what tables to materialize using what select statements (required)")
+	MaterializeCreate.MarkFlagRequired("table-settings")
+	Materialize.AddCommand(MaterializeCreate)
+
+	Materialize.AddCommand(newWorkflowShowCommand("Materialize"))
+	Materialize.AddCommand(newWorkflowCancelCommand("Materialize"))
+
+	Root.AddCommand(Materialize)
+}
@@ -0,0 +1,211 @@
+/*
+Copyright 2023 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package command
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"vitess.io/vitess/go/cmd/vtctldclient/cli"
+	"vitess.io/vitess/go/protoutil"
+	"vitess.io/vitess/go/sqltypes"
+	"vitess.io/vitess/go/vt/schema"
+	"vitess.io/vitess/go/vt/sqlparser"
+	"vitess.io/vitess/go/vt/wrangler"
+
+	vtctldatapb "vitess.io/vitess/go/vt/proto/vtctldata"
+)
+
+var (
+	// OnlineDDL is the base command for all actions related to online DDL
+	// migrations.
+	OnlineDDL = &cobra.Command{
+		Use:                   "OnlineDDL <cmd> <keyspace> [args]",
+		Short:                 "Operates on online DDL (schema migrations).",
+		DisableFlagsInUseLine: true,
+		Aliases:               []string{"onlineddl"},
+	}
+
+	// OnlineDDLShow makes a GetSchemaMigrations gRPC call to a vtctld.
+	OnlineDDLShow = &cobra.Command{
+		Use:   "show <keyspace> [<uuid>|<migration-context>|<status>|all|recent]",
+		Short: "Display information about online DDL operations.",
+		Example: `OnlineDDL show test_keyspace 82fa54ac_e83e_11ea_96b7_f875a4d24e90
+OnlineDDL show test_keyspace all
+OnlineDDL show --order descending test_keyspace all
+OnlineDDL show --limit 10 test_keyspace all
+OnlineDDL show --skip 5 --limit 10 test_keyspace all
+OnlineDDL show test_keyspace running
+OnlineDDL show test_keyspace complete
+OnlineDDL show test_keyspace failed`,
+		DisableFlagsInUseLine: true,
+		Args:                  cobra.RangeArgs(1, 2),
+		RunE:                  commandOnlineDDLShow,
+	}
+
+	onlineDDLShowArgs = struct {
+		OrderBy string
+		Limit   uint64
+		Skip    uint64
+	}{}
+)
+
+// newOnlineDDLActionCommand returns a subcommand which issues the given
+// ALTER VITESS_MIGRATION action on a migration in the keyspace. If
+// allSupported is set, the action may also be applied to all migrations.
+func newOnlineDDLActionCommand(action string, short string, allSupported bool) *cobra.Command {
+	use := fmt.Sprintf("%s <keyspace> <uuid>", action)
+	example := fmt.Sprintf("OnlineDDL %s test_keyspace 82fa54ac_e83e_11ea_96b7_f875a4d24e90", action)
+	if allSupported {
+		use = fmt.Sprintf("%s <keyspace> <uuid|all>", action)
+		example = fmt.Sprintf("%s\nOnlineDDL %s test_keyspace all", example, action)
+	}
+	return &cobra.Command{
+		Use:                   use,
+		Short:                 short,
+		Example:               example,
+		DisableFlagsInUseLine: true,
+		Args:                  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return commandOnlineDDLAction(cmd, action, allSupported)
+		},
+	}
+}
+
+// generateOnlineDDLQuery returns the ALTER VITESS_MIGRATION statement which
+// applies the given action to the migration identified by arg.
+func generateOnlineDDLQuery(action string, arg string, allSupported bool) (string, error) {
+	switch arg {
+	case "":
+		return "", fmt.Errorf("UUID|all required")
+	case "all":
+		if !allSupported {
+			return "", fmt.Errorf("'all' not supported for '%s' command", action)
+		}
+		return fmt.Sprintf(`alter vitess_migration %s all`, action), nil
+	default:
+		if !schema.IsOnlineDDLUUID(arg) {
+			return "", fmt.Errorf("%s is not a valid UUID", arg)
+		}
+		query := `alter vitess_migration %a ` + action
+		return sqlparser.ParseAndBind(query, sqltypes.StringBindVariable(arg))
+	}
+}
+
+func commandOnlineDDLAction(cmd *cobra.Command, action string, allSupported bool) error {
+	keyspace := cmd.Flags().Arg(0)
+	query, err := generateOnlineDDLQuery(action, strings.ToLower(cmd.Flags().Arg(1)), allSupported)
+	if err != nil {
+		return err
+	}
+
+	cli.FinishedParsing(cmd)
+
+	resp, err := client.ApplySchema(commandCtx, &vtctldatapb.ApplySchemaRequest{
+		Keyspace:            keyspace,
+		Sql:                 []string{query},
+		SkipPreflight:       true,
+		WaitReplicasTimeout: protoutil.DurationToProto(wrangler.DefaultWaitReplicasTimeout),
+	})
+	if err != nil {
+		return err
+	}
+
+	data, err := cli.MarshalJSON(resp)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%s\n", data)
+
+	return nil
+}
+
+func commandOnlineDDLShow(cmd *cobra.Command, args []string) error {
+	var order bool
+	switch strings.ToLower(onlineDDLShowArgs.OrderBy) {
+	case "desc", "descending":
+		order = true
+	case "asc", "ascending", "":
+		order = false
+	default:
+		return fmt.Errorf("invalid ordering %s; expected ascending or descending", onlineDDLShowArgs.OrderBy)
+	}
+
+	req := &vtctldatapb.GetSchemaMigrationsRequest{
+		Keyspace:        cmd.Flags().Arg(0),
+		OrderDescending: order,
+		Limit:           onlineDDLShowArgs.Limit,
+		Skip:            onlineDDLShowArgs.Skip,
+	}
+
+	switch arg := cmd.Flags().Arg(1); arg {
+	case "", "all":
+	case "recent":
+		req.Recent = protoutil.DurationToProto(7 * 24 * time.Hour)
+	case
+		string(schema.OnlineDDLStatusCancelled),
+		string(schema.OnlineDDLStatusQueued),
+		string(schema.OnlineDDLStatusReady),
+		string(schema.OnlineDDLStatusRunning),
+		string(schema.OnlineDDLStatusComplete),
+		string(schema.OnlineDDLStatusFailed):
+		req.Status = arg
+	default:
+		if schema.IsOnlineDDLUUID(arg) {
+			req.Uuid = arg
+		} else {
+			req.MigrationContext = arg
+		}
+	}
+
+	cli.FinishedParsing(cmd)
+
+	resp, err := client.GetSchemaMigrations(commandCtx, req)
+	if err != nil {
+		return err
+	}
+
+	data, err := cli.MarshalJSON(resp)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%s\n", data)
+
+	return nil
+}
+
+func init() {
+	OnlineDDLShow.Flags().StringVar(&onlineDDLShowArgs.OrderBy, "order", "ascending", "Sort the results by `id` property of the Schema migration.")
+	OnlineDDLShow.Flags().Uint64Var(&onlineDDLShowArgs.Limit, "limit", 0, "Limit number of rows returned in output.")
+	OnlineDDLShow.Flags().Uint64Var(&onlineDDLShowArgs.Skip, "skip", 0, "Skip specified number of rows returned in output.")
+	OnlineDDL.AddCommand(OnlineDDLShow)
+
+	OnlineDDL.AddCommand(newOnlineDDLActionCommand("cancel", "Cancel one or all migrations, terminating any running ones as needed.", true))
+	OnlineDDL.AddCommand(newOnlineDDLActionCommand("cleanup", "Mark a given schema migration ready for artifact cleanup.", false))
+	OnlineDDL.AddCommand(newOnlineDDLActionCommand("complete", "Complete one or all migrations executed with --postpone-completion.", true))
+	OnlineDDL.AddCommand(newOnlineDDLActionCommand("launch", "Launch one or all migrations executed with --postpone-launch.", true))
+	OnlineDDL.AddCommand(newOnlineDDLActionCommand("retry", "Mark a given schema migration for retry.", false))
+	OnlineDDL.AddCommand(newOnlineDDLActionCommand("throttle", "Throttle one or all migrations.", true))
+	OnlineDDL.AddCommand(newOnlineDDLActionCommand("unthrottle", "Unthrottle one or all migrations.", true))
+
+	Root.AddCommand(OnlineDDL)
+}
@@ -0,0 +1,104 @@
+/*
+Copyright 2023 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package command
+
+import (
+	"github.com/spf13/cobra"
+
+	"vitess.io/vitess/go/cmd/vtctldclient/cli"
+	"vitess.io/vitess/go/vt/vtctl/workflow"
+
+	vtctldatapb "vitess.io/vitess/go/vt/proto/vtctldata"
+)
+
+var (
+	// Reshard is the base command for all actions related to Reshard.
+	Reshard = &cobra.Command{
+		Use:                   "Reshard --workflow <workflow> --target-keyspace <keyspace> [command] [command-flags]",
+		Short:                 "Perform commands related to resharding a keyspace.",
+		DisableFlagsInUseLine: true,
+		Aliases:               []string{"reshard"},
+	}
+
+	// ReshardCreate makes a ReshardCreate gRPC call to a vtctld.
+	ReshardCreate = &cobra.Command{
+		Use:                   "create",
+		Short:                 "Create and optionally run a Reshard VReplication workflow.",
+		Example:               `vtctldclient --server=localhost:15999 Reshard --workflow cust2cust --target-keyspace customer create --source-shards="0" --target-shards="-80,80-" --cells zone1 --cells zone2 --tablet-types replica`,
+		SilenceUsage:          true,
+		DisableFlagsInUseLine: true,
+		Aliases:               []string{"Create"},
+		Args:                  cobra.NoArgs,
+		PreRunE:               validateVReplicationCreateFlags,
+		RunE:                  commandReshardCreate,
+	}
+
+	reshardCreateOptions = struct {
+		SourceShards   []string
+		TargetShards   []string
+		SkipSchemaCopy bool
+	}{}
+)
+
+func commandReshardCreate(cmd *cobra.Command, args []string) error {
+	cli.FinishedParsing(cmd)
+
+	tabletTypes, err := parseTabletTypesFlag(vreplicationCreateOptions.TabletTypes)
+	if err != nil {
+		return err
+	}
+
+	resp, err := client.ReshardCreate(commandCtx, &vtctldatapb.ReshardCreateRequest{
+		Workflow:           vreplicationOptions.Workflow,
+		Keyspace:           vreplicationOptions.TargetKeyspace,
+		SourceShards:       reshardCreateOptions.SourceShards,
+		TargetShards:       reshardCreateOptions.TargetShards,
+		Cells:              vreplicationCreateOptions.Cells,
+		TabletTypes:        tabletTypes,
+		SkipSchemaCopy:     reshardCreateOptions.SkipSchemaCopy,
+		OnDdl:              vreplicationCreateOptions.OnDDL,
+		StopAfterCopy:      vreplicationCreateOptions.StopAfterCopy,
+		DeferSecondaryKeys: vreplicationCreateOptions.DeferSecondaryKeys,
+		AutoStart:          vreplicationCreateOptions.AutoStart,
+	})
+	if err != nil {
+		return err
+	}
+
+	return printWorkflowStatus(resp)
+}
+
+func init() {
+	addVReplicationFlags(Reshard)
+
+	addVReplicationCreateFlags(ReshardCreate)
+	ReshardCreate.Flags().StringSliceVar(&reshardCreateOptions.SourceShards, "source-shards", nil, "Source shards (required)")
+	ReshardCreate.MarkFlagRequired("source-shards")
+	ReshardCreate.Flags().StringSliceVar(&reshardCreateOptions.TargetShards, "target-shards", nil, "Target shards (required)")
+	ReshardCreate.MarkFlagRequired("target-shards")
+	ReshardCreate.Flags().BoolVar(&reshardCreateOptions.SkipSchemaCopy, "skip-schema-copy", false, "Skip copying the schema from the source shards to the target shards")
+	Reshard.AddCommand(ReshardCreate)
+
+	Reshard.AddCommand(newWorkflowShowCommand("Reshard"))
+	Reshard.AddCommand(newWorkflowStatusCommand("Reshard"))
+	Reshard.AddCommand(newWorkflowSwitchTrafficCommand("Reshard", workflow.DirectionForward))
+	Reshard.AddCommand(newWorkflowSwitchTrafficCommand("Reshard", workflow.DirectionBackward))
+	Reshard.AddCommand(newWorkflowCompleteCommand("Reshard"))
+	Reshard.AddCommand(newWorkflowCancelCommand("Reshard"))
+
+	Root.AddCommand(Reshard)
+}
@@ -0,0 +1,292 @@
+/*
+Copyright 2023 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package command
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/spf13/cobra"
+
+	"vitess.io/vitess/go/cmd/vtctldclient/cli"
+	"vitess.io/vitess/go/vt/topo/topoproto"
+
+	tabletmanagerdatapb "vitess.io/vitess/go/vt/proto/tabletmanagerdata"
+	vtctldatapb "vitess.io/vitess/go/vt/proto/vtctldata"
+)
+
+var (
+	// VDiff is the base command for all actions related to VDiff.
+	VDiff = &cobra.Command{
+		Use:                   "VDiff --workflow <workflow> --target-keyspace <keyspace> [command] [command-flags]",
+		Short:                 "Perform commands related to diffing tables involved in a VReplication workflow between the source and target.",
+		DisableFlagsInUseLine: true,
+		Aliases:               []string{"vdiff"},
+	}
+
+	// VDiffCreate makes a VDiffCreate gRPC call to a vtctld.
+	VDiffCreate = &cobra.Command{
+		Use:                   "create",
+		Short:                 "Create and run a VDiff to compare the tables involved in a VReplication workflow between the source and target.",
+		Example:               `vtctldclient --server=localhost:15999 VDiff --workflow commerce2customer --target-keyspace customer create --tables customer,corder`,
+		SilenceUsage:          true,
+		DisableFlagsInUseLine: true,
+		Aliases:               []string{"Create"},
+		Args:                  cobra.NoArgs,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if vdiffCreateOptions.UUID != "" {
+				if _, err := uuid.Parse(vdiffCreateOptions.UUID); err != nil {
+					return fmt.Errorf("invalid UUID provided: %v", err)
+				}
+			}
+			for i, tabletType := range vdiffCreateOptions.TabletTypes {
+				vdiffCreateOptions.TabletTypes[i] = strings.ToUpper(strings.TrimSpace(tabletType))
+				if _, err := topoproto.ParseTabletType(vdiffCreateOptions.TabletTypes[i]); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+		RunE: commandVDiffCreate,
+	}
+
+	// VDiffShow makes a VDiffShow gRPC call to a vtctld.
+	VDiffShow = &cobra.Command{
+		Use:                   "show <uuid|last|all>",
+		Short:                 "Show the status of a VDiff.",
+		Example:               `vtctldclient --server=localhost:15999 VDiff --workflow commerce2customer --target-keyspace customer show last`,
+		DisableFlagsInUseLine: true,
+		Aliases:               []string{"Show"},
+		Args:                  cobra.ExactArgs(1),
+		PreRunE:               validateVDiffShowArg,
+		RunE:                  commandVDiffShow,
+	}
+
+	// VDiffStop makes a VDiffStop gRPC call to a vtctld.
+	VDiffStop = &cobra.Command{
+		Use:                   "stop <uuid>",
+		Short:                 "Stop a running VDiff.",
+		Example:               `vtctldclient --server=localhost:15999 VDiff --workflow commerce2customer --target-keyspace customer stop a037a9e2-5628-11ee-8c99-0242ac120002`,
+		DisableFlagsInUseLine: true,
+		Aliases:               []string{"Stop"},
+		Args:                  cobra.ExactArgs(1),
+		PreRunE:               validateVDiffUUIDArg,
+		RunE:                  commandVDiffStop,
+	}
+
+	// VDiffResume makes a VDiffResume gRPC call to a vtctld.
+	VDiffResume = &cobra.Command{
+		Use:                   "resume <uuid>",
+		Short:                 "Resume a VDiff.",
+		Example:               `vtctldclient --server=localhost:15999 VDiff --workflow commerce2customer --target-keyspace customer resume a037a9e2-5628-11ee-8c99-0242ac120002`,
+		DisableFlagsInUseLine: true,
+		Aliases:               []string{"Resume"},
+		Args:                  cobra.ExactArgs(1),
+		PreRunE:               validateVDiffUUIDArg,
+		RunE:                  commandVDiffResume,
+	}
+
+	// VDiffDelete makes a VDiffDelete gRPC call to a vtctld.
+	VDiffDelete = &cobra.Command{
+		Use:                   "delete <uuid|all>",
+		Short:                 "Delete VDiffs.",
+		Example:               `vtctldclient --server=localhost:15999 VDiff --workflow commerce2customer --target-keyspace customer delete all`,
+		DisableFlagsInUseLine: true,
+		Aliases:               []string{"Delete"},
+		Args:                  cobra.ExactArgs(1),
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if strings.ToLower(args[0]) == "all" {
+				return nil
+			}
+			return validateVDiffUUIDArg(cmd, args)
+		},
+		RunE: commandVDiffDelete,
+	}
+
+	vdiffCreateOptions = struct {
+		UUID                        string
+		SourceCells                 []string
+		TargetCells                 []string
+		TabletTypes                 []string
+		Tables                      []string
+		Limit                       int64
+		FilteredReplicationWaitTime time.Duration
+		DebugQuery                  bool
+		OnlyPKs                     bool
+		UpdateTableStats            bool
+		MaxExtraRowsToCompare       int64
+		AutoRetry                   bool
+	}{}
+)
+
+func validateVDiffUUIDArg(cmd *cobra.Command, args []string) error {
+	if _, err := uuid.Parse(args[0]); err != nil {
+		return fmt.Errorf("invalid UUID provided: %v", err)
+	}
+	return nil
+}
+
+func validateVDiffShowArg(cmd *cobra.Command, args []string) error {
+	switch strings.ToLower(args[0]) {
+	case "last", "all":
+		return nil
+	default:
+		return validateVDiffUUIDArg(cmd, args)
+	}
+}
+
+func commandVDiffCreate(cmd *cobra.Command, args []string) error {
+	cli.FinishedParsing(cmd)
+
+	resp, err := client.VDiffCreate(commandCtx, &vtctldatapb.VDiffCreateRequest{
+		Workflow:       vreplicationOptions.Workflow,
+		TargetKeyspace: vreplicationOptions.TargetKeyspace,
+		Uuid:           vdiffCreateOptions.UUID,
+		Options: &tabletmanagerdatapb.VDiffOptions{
+			PickerOptions: &tabletmanagerdatapb.VDiffPickerOptions{
+				TabletTypes: strings.Join(vdiffCreateOptions.TabletTypes, ","),
+				SourceCell:  strings.Join(vdiffCreateOptions.SourceCells, ","),
+				TargetCell:  strings.Join(vdiffCreateOptions.TargetCells, ","),
+			},
+			CoreOptions: &tabletmanagerdatapb.VDiffCoreOptions{
+				Tables:                strings.Join(vdiffCreateOptions.Tables, ","),
+				AutoRetry:             vdiffCreateOptions.AutoRetry,
+				MaxRows:               vdiffCreateOptions.Limit,
+				TimeoutSeconds:        int64(vdiffCreateOptions.FilteredReplicationWaitTime.Seconds()),
+				MaxExtraRowsToCompare: vdiffCreateOptions.MaxExtraRowsToCompare,
+				UpdateTableStats:      vdiffCreateOptions.UpdateTableStats,
+			},
+			ReportOptions: &tabletmanagerdatapb.VDiffReportOptions{
+				OnlyPks:    vdiffCreateOptions.OnlyPKs,
+				DebugQuery: vdiffCreateOptions.DebugQuery,
+				Format:     "json",
+			},
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	data, err := cli.MarshalJSON(resp)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%s\n", data)
+
+	return nil
+}
+
+func commandVDiffShow(cmd *cobra.Command, args []string) error {
+	cli.FinishedParsing(cmd)
+
+	resp, err := client.VDiffShow(commandCtx, &vtctldatapb.VDiffShowRequest{
+		Workflow:       vreplicationOptions.Workflow,
+		TargetKeyspace: vreplicationOptions.TargetKeyspace,
+		Arg:            strings.ToLower(cmd.Flags().Arg(0)),
+	})
+	if err != nil {
+		return err
+	}
+
+	data, err := cli.MarshalJSON(resp)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%s\n", data)
+
+	return nil
+}
+
+func commandVDiffStop(cmd *cobra.Command, args []string) error {
+	cli.FinishedParsing(cmd)
+
+	_, err := client.VDiffStop(commandCtx, &vtctldatapb.VDiffStopRequest{
+		Workflow:       vreplicationOptions.Workflow,
+		TargetKeyspace: vreplicationOptions.TargetKeyspace,
+		Uuid:           cmd.Flags().Arg(0),
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("VDiff %s stopped.\n", cmd.Flags().Arg(0))
+
+	return nil
+}
+
+func commandVDiffResume(cmd *cobra.Command, args []string) error {
+	cli.FinishedParsing(cmd)
+
+	_, err := client.VDiffResume(commandCtx, &vtctldatapb.VDiffResumeRequest{
+		Workflow:       vreplicationOptions.Workflow,
+		TargetKeyspace: vreplicationOptions.TargetKeyspace,
+		Uuid:           cmd.Flags().Arg(0),
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("VDiff %s resumed.\n", cmd.Flags().Arg(0))
+
+	return nil
+}
+
+func commandVDiffDelete(cmd *cobra.Command, args []string) error {
+	cli.FinishedParsing(cmd)
+
+	_, err := client.VDiffDelete(commandCtx, &vtctldatapb.VDiffDeleteRequest{
+		Workflow:       vreplicationOptions.Workflow,
+		TargetKeyspace: vreplicationOptions.TargetKeyspace,
+		Arg:            strings.ToLower(cmd.Flags().Arg(0)),
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("VDiff(s) %s deleted.\n", cmd.Flags().Arg(0))
+
+	return nil
+}
+
+func init() {
+	addVReplicationFlags(VDiff)
+
+	VDiffCreate.Flags().StringVar(&vdiffCreateOptions.UUID, "uuid", "", "Provide a UUID to use for the VDiff; one is generated if not provided")
+	VDiffCreate.Flags().StringSliceVar(&vdiffCreateOptions.SourceCells, "source-cells", nil, "The source cell(s) to compare from; default is any available cell")
+	VDiffCreate.Flags().StringSliceVar(&vdiffCreateOptions.TargetCells, "target-cells", nil, "The target cell(s) to compare with; default is any available cell")
+	VDiffCreate.Flags().StringSliceVar(&vdiffCreateOptions.TabletTypes, "tablet-types", []string{"RDONLY", "REPLICA", "PRIMARY"}, "Tablet types to use on the source and target")
+	VDiffCreate.Flags().StringSliceVar(&vdiffCreateOptions.Tables, "tables", nil, "Only run vdiff for these tables in the workflow")
+	VDiffCreate.Flags().Int64Var(&vdiffCreateOptions.Limit, "limit", 1<<63-1, "Max rows to stop comparing after")
+	VDiffCreate.Flags().DurationVar(&vdiffCreateOptions.FilteredReplicationWaitTime, "filtered-replication-wait-time", 30*time.Second, "Specifies the maximum time to wait, in seconds, for replication to catch up when syncing tablet streams")
+	VDiffCreate.Flags().BoolVar(&vdiffCreateOptions.DebugQuery, "debug-query", false, "Adds a mysql query to the report that can be used for further debugging")
+	VDiffCreate.Flags().BoolVar(&vdiffCreateOptions.OnlyPKs, "only-pks", false, "When reporting missing rows, only show primary keys in the report")
+	VDiffCreate.Flags().BoolVar(&vdiffCreateOptions.UpdateTableStats, "update-table-stats", false, "Update the table statistics, using ANALYZE TABLE, on each table involved in the VDiff during initialization")
+	VDiffCreate.Flags().Int64Var(&vdiffCreateOptions.MaxExtraRowsToCompare, "max-extra-rows-to-compare", 1000, "If there are collation differences between the source and target, you can have rows that are identical but simply returned in a different order from MySQL. We will do a second pass to compare the rows for any actual differences in this case and this flag allows you to control the resources used for this operation.")
+	VDiffCreate.Flags().BoolVar(&vdiffCreateOptions.AutoRetry, "auto-retry", true, "Should this vdiff automatically retry and continue in case of recoverable errors")
+	VDiff.AddCommand(VDiffCreate)
+
+	VDiff.AddCommand(VDiffShow)
+	VDiff.AddCommand(VDiffStop)
+	VDiff.AddCommand(VDiffResume)
+	VDiff.AddCommand(VDiffDelete)
+
+	Root.AddCommand(VDiff)
+}
@@ -0,0 +1,366 @@
+/*
+Copyright 2023 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package command
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"vitess.io/vitess/go/cmd/vtctldclient/cli"
+	"vitess.io/vitess/go/protoutil"
+	"vitess.io/vitess/go/vt/topo/topoproto"
+	"vitess.io/vitess/go/vt/vtctl/workflow"
+
+	binlogdatapb "vitess.io/vitess/go/vt/proto/binlogdata"
+	topodatapb "vitess.io/vitess/go/vt/proto/topodata"
+	vtctldatapb "vitess.io/vitess/go/vt/proto/vtctldata"
+)
+
+// The commands in this file are shared by the MoveTables, Reshard and
+// Materialize commands, which all operate on a single vreplication workflow
+// identified by the --workflow and --target-keyspace flags of the parent
+// command.
+
+var (
+	vreplicationOptions = struct {
+		Workflow       string
+		TargetKeyspace string
+	}{}
+
+	vreplicationCreateOptions = struct {
+		Cells              []string
+		TabletTypes        []string
+		OnDDL              string
+		StopAfterCopy      bool
+		DeferSecondaryKeys bool
+		AutoStart          bool
+	}{}
+
+	switchTrafficOptions = struct {
+		Cells                    []string
+		TabletTypes              []string
+		MaxReplicationLagAllowed time.Duration
+		EnableReverseReplication bool
+		Timeout                  time.Duration
+		DryRun                   bool
+	}{}
+
+	workflowCompleteOptions = struct {
+		KeepData         bool
+		KeepRoutingRules bool
+		RenameTables     bool
+		DryRun           bool
+	}{}
+
+	workflowCancelOptions = struct {
+		KeepData         bool
+		KeepRoutingRules bool
+		DryRun           bool
+	}{}
+)
+
+// addVReplicationFlags adds the flags identifying the workflow to the given
+// parent command.
+func addVReplicationFlags(cmd *cobra.Command) {
+	cmd.PersistentFlags().StringVarP(&vreplicationOptions.Workflow, "workflow", "w", "", "The workflow you want to perform the command on (required)")
+	cmd.MarkPersistentFlagRequired("workflow")
+	cmd.PersistentFlags().StringVar(&vreplicationOptions.TargetKeyspace, "target-keyspace", "", "Target keyspace for this workflow (required)")
+	cmd.MarkPersistentFlagRequired("target-keyspace")
+}
+
+// addVReplicationCreateFlags adds the flags common to all of the workflow
+// create subcommands.
+func addVReplicationCreateFlags(cmd *cobra.Command) {
+	cmd.Flags().StringSliceVarP(&vreplicationCreateOptions.Cells, "cells", "c", nil, "Cells and/or CellAliases to copy table data from")
+	cmd.Flags().StringSliceVar(&vreplicationCreateOptions.TabletTypes, "tablet-types", nil, "Source tablet types to replicate table data from (e.g. PRIMARY,REPLICA,RDONLY)")
+	cmd.Flags().StringVar(&vreplicationCreateOptions.OnDDL, "on-ddl", binlogdatapb.OnDDLAction_IGNORE.String(), "What to do when DDL is encountered in the VReplication stream. Possible values are IGNORE, STOP, EXEC, and EXEC_IGNORE")
+	cmd.Flags().BoolVar(&vreplicationCreateOptions.StopAfterCopy, "stop-after-copy", false, "Stop the workflow after it's finished copying the existing rows and before it starts replicating changes")
+	cmd.Flags().BoolVar(&vreplicationCreateOptions.DeferSecondaryKeys, "defer-secondary-keys", false, "Defer secondary index creation for a table until after it has been copied")
+	cmd.Flags().BoolVar(&vreplicationCreateOptions.AutoStart, "auto-start", true, "Start the workflow after creating it")
+}
+
+// validateVReplicationCreateFlags validates the values of the flags added by
+// addVReplicationCreateFlags, normalizing them in place.
+func validateVReplicationCreateFlags(cmd *cobra.Command, args []string) error {
+	for i, cell := range vreplicationCreateOptions.Cells {
+		vreplicationCreateOptions.Cells[i] = strings.TrimSpace(cell)
+	}
+	if _, err := parseTabletTypesFlag(vreplicationCreateOptions.TabletTypes); err != nil {
+		return err
+	}
+	vreplicationCreateOptions.OnDDL = strings.ToUpper(vreplicationCreateOptions.OnDDL)
+	if _, ok := binlogdatapb.OnDDLAction_value[vreplicationCreateOptions.OnDDL]; !ok {
+		return fmt.Errorf("invalid on-ddl value: %s", vreplicationCreateOptions.OnDDL)
+	}
+	return nil
+}
+
+// parseTabletTypesFlag parses the values of a --tablet-types flag.
+func parseTabletTypesFlag(values []string) ([]topodatapb.TabletType, error) {
+	var tabletTypes []topodatapb.TabletType
+	for _, value := range values {
+		tabletType, err := topoproto.ParseTabletType(strings.TrimSpace(value))
+		if err != nil {
+			return nil, err
+		}
+		tabletTypes = append(tabletTypes, tabletType)
+	}
+	return tabletTypes, nil
+}
+
+// newWorkflowShowCommand returns a subcommand that shows the full definition
+// of the workflow.
+func newWorkflowShowCommand(parent string) *cobra.Command {
+	return &cobra.Command{
+		Use:                   "show",
+		Short:                 fmt.Sprintf("Show the details for a %s VReplication workflow", parent),
+		Example:               fmt.Sprintf(`vtctldclient --server=localhost:15999 %s --workflow commerce2customer --target-keyspace customer show`, parent),
+		DisableFlagsInUseLine: true,
+		Aliases:               []string{"Show"},
+		Args:                  cobra.NoArgs,
+		RunE:                  commandWorkflowShow,
+	}
+}
+
+func commandWorkflowShow(cmd *cobra.Command, args []string) error {
+	cli.FinishedParsing(cmd)
+
+	resp, err := client.GetWorkflows(commandCtx, &vtctldatapb.GetWorkflowsRequest{
+		Keyspace: vreplicationOptions.TargetKeyspace,
+		Workflow: vreplicationOptions.Workflow,
+	})
+	if err != nil {
+		return err
+	}
+	if len(resp.Workflows) == 0 {
+		return fmt.Errorf("workflow %s not found in keyspace %s", vreplicationOptions.Workflow, vreplicationOptions.TargetKeyspace)
+	}
+
+	data, err := cli.MarshalJSON(resp.Workflows[0])
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%s\n", data)
+
+	return nil
+}
+
+// newWorkflowStatusCommand returns a subcommand that shows the copy progress
+// and the traffic switching state of the workflow.
+func newWorkflowStatusCommand(parent string) *cobra.Command {
+	return &cobra.Command{
+		Use:                   "status",
+		Short:                 fmt.Sprintf("Show the current status for a %s VReplication workflow", parent),
+		Example:               fmt.Sprintf(`vtctldclient --server=localhost:15999 %s --workflow commerce2customer --target-keyspace customer status`, parent),
+		DisableFlagsInUseLine: true,
+		Aliases:               []string{"Status", "progress", "Progress"},
+		Args:                  cobra.NoArgs,
+		RunE:                  commandWorkflowStatus,
+	}
+}
+
+func commandWorkflowStatus(cmd *cobra.Command, args []string) error {
+	cli.FinishedParsing(cmd)
+
+	resp, err := client.WorkflowStatus(commandCtx, &vtctldatapb.WorkflowStatusRequest{
+		Keyspace: vreplicationOptions.TargetKeyspace,
+		Workflow: vreplicationOptions.Workflow,
+	})
+	if err != nil {
+		return err
+	}
+
+	return printWorkflowStatus(resp)
+}
+
+func printWorkflowStatus(resp *vtctldatapb.WorkflowStatusResponse) error {
+	// Sort the inner stream slices for deterministic output.
+	for _, shardStreams := range resp.ShardStreams {
+		sort.Slice(shardStreams.Streams, func(i, j int) bool {
+			return shardStreams.Streams[i].Id < shardStreams.Streams[j].Id
+		})
+	}
+
+	data, err := cli.MarshalJSON(resp)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%s\n", data)
+
+	return nil
+}
+
+// newWorkflowSwitchTrafficCommand returns a subcommand that switches traffic
+// for the workflow in the given direction.
+func newWorkflowSwitchTrafficCommand(parent string, direction workflow.TrafficSwitchDirection) *cobra.Command {
+	use, short := "switchtraffic", fmt.Sprintf("Switch traffic for a %s VReplication workflow", parent)
+	aliases := []string{"SwitchTraffic"}
+	if direction == workflow.DirectionBackward {
+		use, short = "reversetraffic", fmt.Sprintf("Reverse traffic for a %s VReplication workflow", parent)
+		aliases = []string{"ReverseTraffic"}
+	}
+	cmd := &cobra.Command{
+		Use:                   use,
+		Short:                 short,
+		Example:               fmt.Sprintf(`vtctldclient --server=localhost:15999 %s --workflow commerce2customer --target-keyspace customer %s --tablet-types "replica,rdonly"`, parent, use),
+		DisableFlagsInUseLine: true,
+		Aliases:               aliases,
+		Args:                  cobra.NoArgs,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			for i, cell := range switchTrafficOptions.Cells {
+				switchTrafficOptions.Cells[i] = strings.TrimSpace(cell)
+			}
+			_, err := parseTabletTypesFlag(switchTrafficOptions.TabletTypes)
+			return err
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return commandWorkflowSwitchTraffic(cmd, direction)
+		},
+	}
+	cmd.Flags().StringSliceVarP(&switchTrafficOptions.Cells, "cells", "c", nil, "Cells and/or CellAliases to switch traffic in")
+	cmd.Flags().StringSliceVar(&switchTrafficOptions.TabletTypes, "tablet-types", nil, "Tablet types to switch traffic for (default all of PRIMARY,REPLICA,RDONLY)")
+	cmd.Flags().DurationVar(&switchTrafficOptions.MaxReplicationLagAllowed, "max-replication-lag-allowed", 30*time.Second, "Allow traffic to be switched only if VReplication lag is below this")
+	cmd.Flags().BoolVar(&switchTrafficOptions.EnableReverseReplication, "enable-reverse-replication", true, "Setup replication going back to the original source keyspace to support rolling back the traffic cutover")
+	cmd.Flags().DurationVar(&switchTrafficOptions.Timeout, "timeout", 30*time.Second, "Specifies the maximum time to wait, in seconds, for VReplication to catch up on primary tablets. The traffic switch will be cancelled on timeout.")
+	cmd.Flags().BoolVar(&switchTrafficOptions.DryRun, "dry-run", false, "Print the actions that would be taken and report any known errors that would have occurred")
+	return cmd
+}
+
+func commandWorkflowSwitchTraffic(cmd *cobra.Command, direction workflow.TrafficSwitchDirection) error {
+	cli.FinishedParsing(cmd)
+
+	tabletTypes, err := parseTabletTypesFlag(switchTrafficOptions.TabletTypes)
+	if err != nil {
+		return err
+	}
+
+	resp, err := client.WorkflowSwitchTraffic(commandCtx, &vtctldatapb.WorkflowSwitchTrafficRequest{
+		Keyspace:                 vreplicationOptions.TargetKeyspace,
+		Workflow:                 vreplicationOptions.Workflow,
+		Cells:                    switchTrafficOptions.Cells,
+		TabletTypes:              tabletTypes,
+		MaxReplicationLagAllowed: protoutil.DurationToProto(switchTrafficOptions.MaxReplicationLagAllowed),
+		EnableReverseReplication: switchTrafficOptions.EnableReverseReplication,
+		Direction:                int32(direction),
+		Timeout:                  protoutil.DurationToProto(switchTrafficOptions.Timeout),
+		DryRun:                   switchTrafficOptions.DryRun,
+	})
+	if err != nil {
+		return err
+	}
+
+	data, err := cli.MarshalJSON(resp)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%s\n", data)
+
+	return nil
+}
+
+// newWorkflowCompleteCommand returns a subcommand that completes the
+// workflow once all of its traffic has been switched.
+func newWorkflowCompleteCommand(parent string) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:                   "complete",
+		Short:                 fmt.Sprintf("Complete a %s VReplication workflow", parent),
+		Example:               fmt.Sprintf(`vtctldclient --server=localhost:15999 %s --workflow commerce2customer --target-keyspace customer complete`, parent),
+		DisableFlagsInUseLine: true,
+		Aliases:               []string{"Complete"},
+		Args:                  cobra.NoArgs,
+		RunE:                  commandWorkflowComplete,
+	}
+	cmd.Flags().BoolVar(&workflowCompleteOptions.KeepData, "keep-data", false, "Keep the original source table data that was copied by the workflow")
+	cmd.Flags().BoolVar(&workflowCompleteOptions.KeepRoutingRules, "keep-routing-rules", false, "Keep the routing rules in place that direct table traffic from the source keyspace to the target keyspace of the workflow")
+	cmd.Flags().BoolVar(&workflowCompleteOptions.RenameTables, "rename-tables", false, "Keep the original source table data that was copied by the workflow, but rename each table to '_<tablename>_old'")
+	cmd.Flags().BoolVar(&workflowCompleteOptions.DryRun, "dry-run", false, "Print the actions that would be taken and report any known errors that would have occurred")
+	return cmd
+}
+
+func commandWorkflowComplete(cmd *cobra.Command, args []string) error {
+	cli.FinishedParsing(cmd)
+
+	resp, err := client.WorkflowComplete(commandCtx, &vtctldatapb.WorkflowCompleteRequest{
+		Keyspace:         vreplicationOptions.TargetKeyspace,
+		Workflow:         vreplicationOptions.Workflow,
+		KeepData:         workflowCompleteOptions.KeepData,
+		KeepRoutingRules: workflowCompleteOptions.KeepRoutingRules,
+		RenameTables:     workflowCompleteOptions.RenameTables,
+		DryRun:           workflowCompleteOptions.DryRun,
+	})
+	if err != nil {
+		return err
+	}
+
+	data, err := cli.MarshalJSON(resp)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%s\n", data)
+
+	return nil
+}
+
+// newWorkflowCancelCommand returns a subcommand that cancels the workflow
+// before any of its traffic has been switched.
+func newWorkflowCancelCommand(parent string) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:                   "cancel",
+		Short:                 fmt.Sprintf("Cancel a %s VReplication workflow", parent),
+		Example:               fmt.Sprintf(`vtctldclient --server=localhost:15999 %s --workflow commerce2customer --target-keyspace customer cancel`, parent),
+		DisableFlagsInUseLine: true,
+		Aliases:               []string{"Cancel"},
+		Args:                  cobra.NoArgs,
+		RunE:                  commandWorkflowCancel,
+	}
+	cmd.Flags().BoolVar(&workflowCancelOptions.KeepData, "keep-data", false, "Keep the partially copied table data from the workflow in the target keyspace")
+	cmd.Flags().BoolVar(&workflowCancelOptions.KeepRoutingRules, "keep-routing-rules", false, "Keep the routing rules created for the workflow")
+	cmd.Flags().BoolVar(&workflowCancelOptions.DryRun, "dry-run", false, "Print the actions that would be taken and report any known errors that would have occurred")
+	return cmd
+}
+
+func commandWorkflowCancel(cmd *cobra.Command, args []string) error {
+	cli.FinishedParsing(cmd)
+
+	resp, err := client.WorkflowDelete(commandCtx, &vtctldatapb.WorkflowDeleteRequest{
+		Keyspace:         vreplicationOptions.TargetKeyspace,
+		Workflow:         vreplicationOptions.Workflow,
+		KeepData:         workflowCancelOptions.KeepData,
+		KeepRoutingRules: workflowCancelOptions.KeepRoutingRules,
+		DryRun:           workflowCancelOptions.DryRun,
+	})
+	if err != nil {
+		return err
+	}
+
+	data, err := cli.MarshalJSON(resp)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%s\n", data)
+
+	return nil
+}
@@ -322,6 +322,19 @@ func NewMariaDBGTIDEvent(f BinlogFormat, s *FakeBinlogStream, gtid MariadbGTID,
 	return NewMariadbBinlogEvent(ev)
 }
 
+// NewMySQL56GTIDEvent returns a MySQL 5.6 GTID event.
+func NewMySQL56GTIDEvent(f BinlogFormat, s *FakeBinlogStream, gtid Mysql56GTID) BinlogEvent {
+	length := 1 + // flags
+		16 + // SID
+		8 // GNO
+	data := make([]byte, length)
+	copy(data[1:17], gtid.Server[:])
+	binary.LittleEndian.PutUint64(data[17:25], uint64(gtid.Sequence))
+
+	ev := s.Packetize(f, eGTIDEvent, 0, data)
+	return NewMysql56BinlogEvent(ev)
+}
+
 // NewTableMapEvent returns a TableMap event.
 // Only works with post_header_length=8.
 func NewTableMapEvent(f BinlogFormat, s *FakeBinlogStream, tableID uint64, tm *TableMap) BinlogEvent {
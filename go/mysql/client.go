@@ -173,7 +173,7 @@ func Connect(ctx context.Context, params *ConnParams) (*Conn, error) {
 // Ping implements mysql ping command.
 func (c *Conn) Ping() error {
 	// This is a new command, need to reset the sequence.
-	c.sequence = 0
+	c.resetSequence()
 	data, pos := c.startEphemeralPacketWithHeader(1)
 	data[pos] = ComPing
 
@@ -228,6 +228,15 @@ func (c *Conn) clientHandshake(params *ConnParams) error {
 		c.Capabilities = capabilities & (CapabilityClientDeprecateEOF)
 	}
 
+	// Protocol compression, if the server supports the algorithm we asked for.
+	if params.Compression != "" {
+		if err := ValidCompressionAlgorithm(params.Compression); err != nil {
+			return NewSQLError(CRUnknownError, SSUnknownSQLState, "%v", err)
+		}
+		c.Capabilities |= capabilities & compressionCapability(params.Compression)
+		c.zstdCompressionLevel = DefaultZstdCompressionLevel
+	}
+
 	charset, err := collations.Local().ParseConnectionCharset(params.Charset)
 	if err != nil {
 		return err
@@ -310,6 +319,9 @@ func (c *Conn) clientHandshake(params *ConnParams) error {
 		return err
 	}
 
+	// The packets following the OK packet use the compressed protocol, if negotiated.
+	c.startCompression()
+
 	// If the server didn't support DbName in its handshake, set
 	// it now. This is what the 'mysql' client does.
 	if capabilities&CapabilityClientConnectWithDB == 0 && params.DbName != "" {
@@ -483,7 +495,9 @@ func (c *Conn) writeSSLRequest(capabilities uint32, characterSet uint8, params *
 		// CapabilityClientSessionTrack, we also support it.
 		c.Capabilities&CapabilityClientSessionTrack |
 		// Pass-through ClientFoundRows flag.
-		CapabilityClientFoundRows&uint32(params.Flags)
+		CapabilityClientFoundRows&uint32(params.Flags) |
+		// Protocol compression, if negotiated.
+		c.Capabilities&(CapabilityClientCompress|CapabilityClientZstdCompressionAlgorithm)
 
 	length :=
 		4 + // Client capability flags.
@@ -541,7 +555,9 @@ func (c *Conn) writeHandshakeResponse41(capabilities uint32, scrambledPassword [
 		CapabilityClientFoundRows&uint32(params.Flags) |
 		// If the server supported
 		// CapabilityClientSessionTrack, we also support it.
-		c.Capabilities&CapabilityClientSessionTrack
+		c.Capabilities&CapabilityClientSessionTrack |
+		// Protocol compression, if negotiated.
+		c.Capabilities&(CapabilityClientCompress|CapabilityClientZstdCompressionAlgorithm)
 
 	// FIXME(alainjobart) add multi statement.
 
@@ -568,6 +584,11 @@ func (c *Conn) writeHandshakeResponse41(capabilities uint32, scrambledPassword [
 		length++
 	}
 
+	// The zstd compression level.
+	if c.Capabilities&CapabilityClientZstdCompressionAlgorithm != 0 {
+		length++
+	}
+
 	data, pos := c.startEphemeralPacketWithHeader(length)
 
 	// Client capability flags.
@@ -604,6 +625,12 @@ func (c *Conn) writeHandshakeResponse41(capabilities uint32, scrambledPassword [
 	// Assume native client during response
 	pos = writeNullString(data, pos, string(c.authPluginName))
 
+	// The zstd compression level comes last, after the connection
+	// attributes, that we don't send.
+	if c.Capabilities&CapabilityClientZstdCompressionAlgorithm != 0 {
+		pos = writeByte(data, pos, byte(c.zstdCompressionLevel))
+	}
+
 	// Sanity-check the length.
 	if pos != len(data) {
 		return NewSQLError(CRMalformedPacket, SSUnknownSQLState, "writeHandshakeResponse41: only packed %v bytes, out of %v allocated", pos, len(data))
@@ -615,6 +642,70 @@ func (c *Conn) writeHandshakeResponse41(capabilities uint32, scrambledPassword [
 	return nil
 }
 
+// ChangeUser authenticates the connection as params.Uname with COM_CHANGE_USER,
+// and uses params.DbName as the new default database. The server resets the
+// session if it succeeds, and closes the connection if it fails.
+// Returns a SQLError.
+func (c *Conn) ChangeUser(params *ConnParams) error {
+	charset, err := collations.Local().ParseConnectionCharset(params.Charset)
+	if err != nil {
+		return err
+	}
+
+	// Answer with the auth method we ended up using during the handshake.
+	var scrambledPassword []byte
+	switch c.authPluginName {
+	case CachingSha2Password:
+		scrambledPassword = ScrambleCachingSha2Password(c.salt, []byte(params.Pass))
+	case MysqlClearPassword:
+		scrambledPassword = append([]byte(params.Pass), 0)
+	default:
+		scrambledPassword = ScrambleMysqlNativePassword(c.salt, []byte(params.Pass))
+	}
+
+	// This is a new command, need to reset the sequence.
+	c.resetSequence()
+	if err := c.writeComChangeUser(scrambledPassword, charset, params); err != nil {
+		return err
+	}
+	if err := c.handleAuthResponse(params); err != nil {
+		return err
+	}
+	c.schemaName = params.DbName
+	return nil
+}
+
+// writeComChangeUser writes the COM_CHANGE_USER packet.
+// Returns a SQLError.
+func (c *Conn) writeComChangeUser(scrambledPassword []byte, characterSet uint8, params *ConnParams) error {
+	length :=
+		1 + // ComChangeUser
+			lenNullString(params.Uname) +
+			1 + len(scrambledPassword) +
+			lenNullString(params.DbName) +
+			2 + // Character set.
+			lenNullString(string(c.authPluginName))
+
+	data, pos := c.startEphemeralPacketWithHeader(length)
+	pos = writeByte(data, pos, ComChangeUser)
+	pos = writeNullString(data, pos, params.Uname)
+	pos = writeByte(data, pos, byte(len(scrambledPassword)))
+	pos += copy(data[pos:], scrambledPassword)
+	pos = writeNullString(data, pos, params.DbName)
+	pos = writeUint16(data, pos, uint16(characterSet))
+	pos = writeNullString(data, pos, string(c.authPluginName))
+
+	// Sanity-check the length.
+	if pos != len(data) {
+		return NewSQLError(CRMalformedPacket, SSUnknownSQLState, "writeComChangeUser: only packed %v bytes, out of %v allocated", pos, len(data))
+	}
+
+	if err := c.writeEphemeralPacket(); err != nil {
+		return NewSQLError(CRServerGone, SSUnknownSQLState, "cannot send ComChangeUser: %v", err)
+	}
+	return nil
+}
+
 // handleAuthResponse parses server's response after client sends the password for authentication
 // and handles next steps for AuthSwitchRequestPacket and AuthMoreDataPacket.
 func (c *Conn) handleAuthResponse(params *ConnParams) error {
@@ -0,0 +1,349 @@
+/*
+Copyright 2023 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mysql
+
+import (
+	"bytes"
+	"io"
+	"sync"
+
+	"github.com/klauspost/compress/zlib"
+	"github.com/klauspost/compress/zstd"
+
+	"vitess.io/vitess/go/stats"
+	vtrpcpb "vitess.io/vitess/go/vt/proto/vtrpc"
+	"vitess.io/vitess/go/vt/vterrors"
+)
+
+// This file contains the compressed client/server protocol. It is negotiated
+// during the handshake with CapabilityClientCompress (zlib) or
+// CapabilityClientZstdCompressionAlgorithm (zstd), and is used for every
+// packet after the handshake's final OK packet.
+// See: https://dev.mysql.com/doc/dev/mysql-server/latest/page_protocol_basic_compression.html
+//
+// Compressed packets wrap the regular packet stream. Each one has a 7 byte header:
+// - 3 bytes: length of the payload
+// - 1 byte: compressed sequence id, which is separate from the regular packet sequence
+// - 3 bytes: length of the payload before compression, or 0 if it was sent uncompressed
+// and the payload holds one or more regular packets, or pieces of them.
+
+// Protocol compression algorithms.
+const (
+	CompressionZlib = "zlib"
+	CompressionZstd = "zstd"
+)
+
+const (
+	// compressedPacketHeaderSize is the size of the header of compressed packets.
+	compressedPacketHeaderSize = 7
+
+	// minCompressLength is the size under which payloads are sent uncompressed,
+	// as compressing them isn't worth it. This is MIN_COMPRESS_LENGTH in MySQL.
+	minCompressLength = 50
+
+	// maxCompressedPayloadSize is the amount of uncompressed data we put in a
+	// single compressed packet. Bigger writes are split in several compressed packets.
+	maxCompressedPayloadSize = connBufferSize
+
+	// DefaultZstdCompressionLevel is the zstd compression level used when none is specified,
+	// the same default as the MySQL client.
+	DefaultZstdCompressionLevel = 3
+)
+
+// compressionBytes counts the bytes of the connections that use protocol compression,
+// before (raw) and after (compressed) compression.
+var compressionBytes = stats.NewCountersWithMultiLabels(
+	"MysqlProtocolCompressionBytes",
+	"Bytes sent and received by MySQL connections using protocol compression, before (raw) and after (compressed) compression",
+	[]string{"Algorithm", "Direction", "Type"})
+
+var zlibWriters = sync.Pool{New: func() any {
+	w, _ := zlib.NewWriterLevel(nil, zlib.DefaultCompression)
+	return w
+}}
+
+var zlibReaders sync.Pool
+
+var (
+	zstdEncodersMu sync.Mutex
+	zstdEncoders   = map[zstd.EncoderLevel]*zstd.Encoder{}
+)
+
+// zstdEncoder returns an encoder for the given MySQL zstd compression level.
+// Encoders are shared between connections, as EncodeAll can be called concurrently.
+func zstdEncoder(level int) (*zstd.Encoder, error) {
+	encoderLevel := zstd.EncoderLevelFromZstd(level)
+
+	zstdEncodersMu.Lock()
+	defer zstdEncodersMu.Unlock()
+	if enc, ok := zstdEncoders[encoderLevel]; ok {
+		return enc, nil
+	}
+	enc, err := zstd.NewWriter(nil, zstd.WithEncoderLevel(encoderLevel))
+	if err != nil {
+		return nil, err
+	}
+	zstdEncoders[encoderLevel] = enc
+	return enc, nil
+}
+
+// ValidCompressionAlgorithm returns an error if algorithm isn't a supported protocol compression algorithm.
+func ValidCompressionAlgorithm(algorithm string) error {
+	switch algorithm {
+	case CompressionZlib, CompressionZstd:
+		return nil
+	}
+	return vterrors.Errorf(vtrpcpb.Code_INVALID_ARGUMENT, "unsupported protocol compression algorithm: %s", algorithm)
+}
+
+// compressionCapability returns the capability flag used to negotiate the given algorithm.
+func compressionCapability(algorithm string) uint32 {
+	switch algorithm {
+	case CompressionZlib:
+		return CapabilityClientCompress
+	case CompressionZstd:
+		return CapabilityClientZstdCompressionAlgorithm
+	}
+	return 0
+}
+
+// compressedConn implements the compressed packet framing for a Conn.
+// The regular packet code reads from and writes to it, and it reads and writes
+// compressed packets from and to the underlying connection.
+type compressedConn struct {
+	c         *Conn
+	algorithm string
+	zstdLevel int
+
+	// sequence is the sequence id of the compressed packets. It is reset
+	// at the start of each command, like the regular packet sequence.
+	sequence uint8
+
+	header [compressedPacketHeaderSize]byte
+
+	// readBuf holds the decompressed data of the last compressed packet we read,
+	// and readPos is how much of it has been consumed already.
+	readBuf []byte
+	readPos int
+	// payload and decompressed are the scratch buffers for reading compressed packets.
+	payload      []byte
+	decompressed []byte
+
+	// pending holds the written data that hasn't been sent yet.
+	pending []byte
+	// frame is the scratch buffer for building compressed packets.
+	frame bytes.Buffer
+	// scratch is the scratch buffer for zstd compression.
+	scratch []byte
+}
+
+// newCompressedConn returns the compressed packet framing for the given algorithm.
+func newCompressedConn(c *Conn, algorithm string, zstdLevel int) *compressedConn {
+	return &compressedConn{
+		c:         c,
+		algorithm: algorithm,
+		zstdLevel: zstdLevel,
+	}
+}
+
+// Read implements io.Reader. It returns the decompressed data, reading the
+// next compressed packet when all the data of the previous one was consumed.
+func (cc *compressedConn) Read(p []byte) (int, error) {
+	for cc.readPos == len(cc.readBuf) {
+		if err := cc.readCompressedPacket(); err != nil {
+			return 0, err
+		}
+	}
+	n := copy(p, cc.readBuf[cc.readPos:])
+	cc.readPos += n
+	return n, nil
+}
+
+func (cc *compressedConn) readCompressedPacket() error {
+	r := cc.c.bufferedReader
+	if r == nil {
+		return cc.readCompressedPacketFrom(cc.c.conn)
+	}
+	return cc.readCompressedPacketFrom(r)
+}
+
+func (cc *compressedConn) readCompressedPacketFrom(r io.Reader) error {
+	if _, err := io.ReadFull(r, cc.header[:]); err != nil {
+		// Propagate io.EOF as is, like readHeaderFrom does.
+		return err
+	}
+
+	length := int(uint32(cc.header[0]) | uint32(cc.header[1])<<8 | uint32(cc.header[2])<<16)
+	sequence := cc.header[3]
+	uncompressedLength := int(uint32(cc.header[4]) | uint32(cc.header[5])<<8 | uint32(cc.header[6])<<16)
+	if sequence != cc.sequence {
+		return vterrors.Errorf(vtrpcpb.Code_INTERNAL, "invalid compressed packet sequence, expected %v got %v", cc.sequence, sequence)
+	}
+	cc.sequence++
+
+	if cap(cc.payload) < length {
+		cc.payload = make([]byte, length)
+	}
+	payload := cc.payload[:length]
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return vterrors.Wrapf(err, "io.ReadFull(compressed packet body of length %v) failed", length)
+	}
+	compressionBytes.Add([]string{cc.algorithm, "In", "Compressed"}, int64(compressedPacketHeaderSize+length))
+
+	cc.readPos = 0
+	if uncompressedLength == 0 {
+		// The payload was sent uncompressed.
+		cc.readBuf = payload
+		compressionBytes.Add([]string{cc.algorithm, "In", "Raw"}, int64(length))
+		return nil
+	}
+
+	data, err := cc.decompress(payload, uncompressedLength)
+	if err != nil {
+		return vterrors.Wrapf(err, "cannot decompress %v packet", cc.algorithm)
+	}
+	if len(data) != uncompressedLength {
+		return vterrors.Errorf(vtrpcpb.Code_INTERNAL, "invalid %v packet: decompressed %v bytes, expected %v", cc.algorithm, len(data), uncompressedLength)
+	}
+	cc.readBuf = data
+	compressionBytes.Add([]string{cc.algorithm, "In", "Raw"}, int64(uncompressedLength))
+	return nil
+}
+
+func (cc *compressedConn) decompress(payload []byte, uncompressedLength int) ([]byte, error) {
+	// Read copies the data out, so the buffer can be reused for the next compressed packet.
+	if cap(cc.decompressed) < uncompressedLength {
+		cc.decompressed = make([]byte, 0, uncompressedLength)
+	}
+	dst := cc.decompressed[:0]
+
+	if cc.algorithm == CompressionZstd {
+		data, err := zstdDecoder.DecodeAll(payload, dst)
+		cc.decompressed = data
+		return data, err
+	}
+
+	var zr io.ReadCloser
+	var err error
+	if pooled := zlibReaders.Get(); pooled != nil {
+		zr = pooled.(io.ReadCloser)
+		err = zr.(zlib.Resetter).Reset(bytes.NewReader(payload), nil)
+	} else {
+		zr, err = zlib.NewReader(bytes.NewReader(payload))
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer zlibReaders.Put(zr)
+
+	buf := bytes.NewBuffer(dst)
+	// Read one more byte than expected, so we can detect invalid packets.
+	_, err = io.CopyN(buf, zr, int64(uncompressedLength)+1)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	cc.decompressed = buf.Bytes()
+	return cc.decompressed, nil
+}
+
+// Write implements io.Writer. The data is buffered, and sent in compressed
+// packets of up to maxCompressedPayloadSize bytes.
+func (cc *compressedConn) Write(p []byte) (int, error) {
+	written := len(p)
+	for len(cc.pending)+len(p) >= maxCompressedPayloadSize {
+		n := maxCompressedPayloadSize - len(cc.pending)
+		cc.pending = append(cc.pending, p[:n]...)
+		p = p[n:]
+		if err := cc.Flush(); err != nil {
+			return 0, err
+		}
+	}
+	cc.pending = append(cc.pending, p...)
+	return written, nil
+}
+
+// Flush sends all the buffered data. It must be called with the same locking as Write.
+func (cc *compressedConn) Flush() error {
+	if len(cc.pending) == 0 {
+		return nil
+	}
+	defer func() {
+		cc.pending = cc.pending[:0]
+	}()
+
+	cc.frame.Reset()
+	cc.frame.Write(cc.header[:])
+
+	uncompressedLength := 0
+	if len(cc.pending) >= minCompressLength {
+		if err := cc.compress(&cc.frame, cc.pending); err != nil {
+			return vterrors.Wrapf(err, "cannot compress %v packet", cc.algorithm)
+		}
+		uncompressedLength = len(cc.pending)
+	}
+	if uncompressedLength == 0 || cc.frame.Len()-compressedPacketHeaderSize >= len(cc.pending) {
+		// Compressing was not worth it, send the payload as is.
+		cc.frame.Truncate(compressedPacketHeaderSize)
+		cc.frame.Write(cc.pending)
+		uncompressedLength = 0
+	}
+
+	frame := cc.frame.Bytes()
+	length := len(frame) - compressedPacketHeaderSize
+	frame[0] = byte(length)
+	frame[1] = byte(length >> 8)
+	frame[2] = byte(length >> 16)
+	frame[3] = cc.sequence
+	frame[4] = byte(uncompressedLength)
+	frame[5] = byte(uncompressedLength >> 8)
+	frame[6] = byte(uncompressedLength >> 16)
+	cc.sequence++
+
+	w := io.Writer(cc.c.conn)
+	if cc.c.bufferedWriter != nil {
+		w = cc.c.bufferedWriter
+	}
+	if n, err := w.Write(frame); err != nil {
+		return vterrors.Wrapf(err, "Write(compressed packet) failed")
+	} else if n != len(frame) {
+		return vterrors.Errorf(vtrpcpb.Code_INTERNAL, "Write(compressed packet) returned a short write: %v < %v", n, len(frame))
+	}
+
+	compressionBytes.Add([]string{cc.algorithm, "Out", "Raw"}, int64(len(cc.pending)))
+	compressionBytes.Add([]string{cc.algorithm, "Out", "Compressed"}, int64(len(frame)))
+	return nil
+}
+
+func (cc *compressedConn) compress(dst *bytes.Buffer, data []byte) error {
+	if cc.algorithm == CompressionZstd {
+		enc, err := zstdEncoder(cc.zstdLevel)
+		if err != nil {
+			return err
+		}
+		cc.scratch = enc.EncodeAll(data, cc.scratch[:0])
+		dst.Write(cc.scratch)
+		return nil
+	}
+
+	zw := zlibWriters.Get().(*zlib.Writer)
+	defer zlibWriters.Put(zw)
+	zw.Reset(dst)
+	if _, err := zw.Write(data); err != nil {
+		return err
+	}
+	return zw.Close()
+}
@@ -0,0 +1,139 @@
+/*
+Copyright 2023 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mysql
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"vitess.io/vitess/go/test/utils"
+)
+
+// newCompressionTestListener starts a listener supporting the given compression algorithms.
+func newCompressionTestListener(t *testing.T, th *testHandler, algorithms ...string) *ConnParams {
+	authServer := NewAuthServerStatic("", "", 0)
+	authServer.entries["user1"] = []*AuthServerStaticEntry{{
+		Password: "password1",
+	}}
+	t.Cleanup(authServer.close)
+
+	l, err := NewListener("tcp", "127.0.0.1:", authServer, th, 0, 0, false, false, 0)
+	require.NoError(t, err)
+	t.Cleanup(l.Close)
+	l.CompressionAlgorithms = algorithms
+	go l.Accept()
+
+	return &ConnParams{
+		Host:  l.Addr().(*net.TCPAddr).IP.String(),
+		Port:  l.Addr().(*net.TCPAddr).Port,
+		Uname: "user1",
+		Pass:  "password1",
+	}
+}
+
+func TestCompressedConnection(t *testing.T) {
+	for _, algorithm := range []string{CompressionZlib, CompressionZstd} {
+		t.Run(algorithm, func(t *testing.T) {
+			th := &testHandler{}
+			params := newCompressionTestListener(t, th, CompressionZlib, CompressionZstd)
+			params.Compression = algorithm
+
+			ctx := context.Background()
+			conn, err := Connect(ctx, params)
+			require.NoError(t, err)
+			defer conn.Close()
+
+			require.NotNil(t, conn.compression)
+			assert.Equal(t, algorithm, conn.compression.algorithm)
+
+			want := algorithm
+			if algorithm == CompressionZstd {
+				want = fmt.Sprintf("zstd:%v", DefaultZstdCompressionLevel)
+			}
+			result, err := conn.ExecuteFetch("compression echo", 10000, true)
+			require.NoError(t, err)
+			assert.Equal(t, want, result.Rows[0][0].ToString())
+
+			rawOut := compressionBytes.Counts()[algorithm+".Out.Raw"]
+
+			result, err = conn.ExecuteFetch("select rows", 10000, true)
+			require.NoError(t, err)
+			utils.MustMatch(t, selectRowsResult, result)
+
+			// Small packets are sent uncompressed, within compressed packets.
+			require.NoError(t, conn.Ping())
+
+			// Queries and results bigger than a compressed packet are split
+			// in several compressed packets, whether they compress well or not.
+			letters := make([]byte, 3*maxCompressedPayloadSize)
+			for i := range letters {
+				letters[i] = byte('a' + rand.Intn(26))
+			}
+			for _, query := range []string{
+				benchmarkQueryPrefix + strings.Repeat("x", 3*maxCompressedPayloadSize),
+				benchmarkQueryPrefix + string(letters),
+			} {
+				result, err = conn.ExecuteFetch(query, 10000, true)
+				require.NoError(t, err)
+				require.Len(t, result.Rows, 1)
+				assert.Equal(t, query, result.Rows[0][0].ToString())
+			}
+
+			assert.Greater(t, compressionBytes.Counts()[algorithm+".Out.Raw"], rawOut+int64(6*maxCompressedPayloadSize))
+
+			// Send a ComQuit to avoid the error message on the server side.
+			conn.writeComQuit()
+		})
+	}
+}
+
+func TestCompressionNotSupportedByServer(t *testing.T) {
+	th := &testHandler{}
+	params := newCompressionTestListener(t, th, CompressionZstd)
+	params.Compression = CompressionZlib
+
+	conn, err := Connect(context.Background(), params)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	// The server doesn't support zlib, so the connection isn't compressed.
+	assert.Nil(t, conn.compression)
+	result, err := conn.ExecuteFetch("compression echo", 10000, true)
+	require.NoError(t, err)
+	assert.Equal(t, "OFF", result.Rows[0][0].ToString())
+
+	result, err = conn.ExecuteFetch("select rows", 10000, true)
+	require.NoError(t, err)
+	utils.MustMatch(t, selectRowsResult, result)
+
+	conn.writeComQuit()
+}
+
+func TestCompressionInvalidAlgorithm(t *testing.T) {
+	params := newCompressionTestListener(t, &testHandler{}, CompressionZlib)
+	params.Compression = "lz4"
+
+	_, err := Connect(context.Background(), params)
+	assert.ErrorContains(t, err, "unsupported protocol compression algorithm: lz4")
+}
@@ -84,7 +84,9 @@ type Conn struct {
 	// fields, this is set to an empty array (but not nil).
 	fields []*querypb.Field
 
-	// salt is sent by the server during initial handshake to be used for authentication
+	// salt is sent by the server during initial handshake to be used for authentication.
+	// It is replaced by the one of the last auth switch request, if any, and is
+	// used again by COM_CHANGE_USER.
 	salt []byte
 
 	// authPluginName is the name of server's authentication plugin.
@@ -103,6 +105,20 @@ type Conn struct {
 	// avoid maps indexed by ConnectionID for instance.
 	ClientData any
 
+	// SessionStateChanges are the session state changes to report to the
+	// client in the OK packet ending the current statement, if it
+	// negotiated CapabilityClientSessionTrack. They are set by the handler,
+	// and reset once reported, and before each command. It is only used
+	// for server-side connections.
+	SessionStateChanges SessionStateChanges
+
+	// QueryAttributes are the query attributes the client sent along with
+	// the current COM_QUERY or COM_STMT_EXECUTE command, if it negotiated
+	// CapabilityClientQueryAttributes. NULL attributes are not included.
+	// It is only used for server-side connections, and is reset before
+	// each command.
+	QueryAttributes map[string]string
+
 	// conn is the underlying network connection.
 	// Calling Close() on the Conn will close this connection.
 	// If there are any ongoing reads or writes, they may get interrupted.
@@ -144,6 +160,14 @@ type Conn struct {
 	// Buffered writing has a timer which flushes on inactivity.
 	bufferedWriter *bufio.Writer
 
+	// compression is set when the connection uses the compressed protocol.
+	// It sits between the packet code and the bufferedReader / bufferedWriter.
+	compression *compressedConn
+
+	// zstdCompressionLevel is the compression level negotiated
+	// during the handshake, when the connection uses zstd.
+	zstdCompressionLevel int
+
 	// PrepareData is the map to use a prepared statement.
 	PrepareData map[uint32]*PrepareData
 
@@ -155,8 +179,9 @@ type Conn struct {
 	// the client and the server, and currently in use.
 	// It is set during the initial handshake.
 	//
-	// It is only used for CapabilityClientDeprecateEOF
-	// and CapabilityClientFoundRows.
+	// It is only used for CapabilityClientDeprecateEOF,
+	// CapabilityClientFoundRows, and the protocol compression
+	// flags CapabilityClientCompress and CapabilityClientZstdCompressionAlgorithm.
 	Capabilities uint32
 
 	// closed is set to true when Close() is called on the connection.
@@ -332,6 +357,11 @@ func (c *Conn) endWriterBuffering() error {
 	}()
 
 	c.stopFlushTimer()
+	if c.compression != nil {
+		if err := c.compression.Flush(); err != nil {
+			return err
+		}
+	}
 	return c.bufferedWriter.Flush()
 }
 
@@ -351,12 +381,19 @@ func (c *Conn) returnReader() {
 func (c *Conn) getWriter() (w io.Writer, unget func()) {
 	c.bufMu.Lock()
 	if c.bufferedWriter != nil {
-		return c.bufferedWriter, func() {
+		w = c.bufferedWriter
+		if c.compression != nil {
+			w = c.compression
+		}
+		return w, func() {
 			c.startFlushTimer()
 			c.bufMu.Unlock()
 		}
 	}
 	c.bufMu.Unlock()
+	if c.compression != nil {
+		return c.compression, func() {}
+	}
 	return c.conn, func() {}
 }
 
@@ -371,6 +408,9 @@ func (c *Conn) startFlushTimer() {
 			return
 		}
 		c.stopFlushTimer()
+		if c.compression != nil {
+			c.compression.Flush()
+		}
 		c.bufferedWriter.Flush()
 	})
 }
@@ -386,6 +426,9 @@ func (c *Conn) stopFlushTimer() {
 // getReader returns reader for connection. It can be *bufio.Reader or net.Conn
 // depending on which buffer size was passed to newServerConn.
 func (c *Conn) getReader() io.Reader {
+	if c.compression != nil {
+		return c.compression
+	}
 	if c.bufferedReader != nil {
 		return c.bufferedReader
 	}
@@ -413,7 +456,12 @@ func (c *Conn) readHeaderFrom(r io.Reader) (int, error) {
 	}
 
 	sequence := uint8(c.header[3])
-	if sequence != c.sequence {
+	if c.compression != nil {
+		// Implementations don't agree on the sequence of the packets wrapped in
+		// compressed packets, so we follow the peer. The sequence of the compressed
+		// packets themselves is checked by the compressedConn.
+		c.sequence = sequence
+	} else if sequence != c.sequence {
 		return 0, vterrors.Errorf(vtrpcpb.Code_INTERNAL, "invalid sequence, expected %v got %v", c.sequence, sequence)
 	}
 
@@ -663,12 +711,58 @@ func (c *Conn) writePacket(data []byte) error {
 				}
 				c.sequence++
 			}
-			return nil
+			return c.flushCompression()
 		}
 		index += toBeSent
 	}
 }
 
+// flushCompression sends the data buffered by the compressed protocol,
+// unless buffered writing is on: the data is then sent with the
+// rest of the buffered data, by endWriterBuffering or the flush timer.
+// bufferedWriter is only set and cleared by the goroutine writing
+// packets, so we don't need bufMu to check it here.
+func (c *Conn) flushCompression() error {
+	if c.compression == nil || c.bufferedWriter != nil {
+		return nil
+	}
+	return c.compression.Flush()
+}
+
+// startCompression starts using the compressed protocol for all the
+// following packets, if it was negotiated during the handshake.
+// It is called once the handshake is done.
+func (c *Conn) startCompression() {
+	switch {
+	case c.Capabilities&CapabilityClientCompress != 0:
+		c.compression = newCompressedConn(c, CompressionZlib, 0)
+	case c.Capabilities&CapabilityClientZstdCompressionAlgorithm != 0:
+		c.compression = newCompressedConn(c, CompressionZstd, c.zstdCompressionLevel)
+	}
+}
+
+// takeSessionStateChanges returns the session state changes to report in
+// the OK packet ending the given result, and resets them. The GTIDs
+// default to the session state changes of the result.
+func (c *Conn) takeSessionStateChanges(qr *sqltypes.Result) SessionStateChanges {
+	changes := c.SessionStateChanges
+	c.SessionStateChanges = SessionStateChanges{}
+	if changes.GTIDs == "" {
+		changes.GTIDs = qr.SessionStateChanges
+	}
+	return changes
+}
+
+// resetSequence resets the sequence of the packets, and of the
+// compressed packets if the connection is compressed. It must be
+// called at the start of each command.
+func (c *Conn) resetSequence() {
+	c.sequence = 0
+	if c.compression != nil {
+		c.compression.sequence = 0
+	}
+}
+
 func (c *Conn) startEphemeralPacketWithHeader(length int) ([]byte, int) {
 	if c.currentEphemeralPolicy != ephemeralUnused {
 		panic("startEphemeralPacketWithHeader cannot be used while a packet is already started.")
@@ -717,7 +811,7 @@ func (c *Conn) recycleWritePacket() {
 // Returns SQLError(CRServerGone) if it can't.
 func (c *Conn) writeComQuit() error {
 	// This is a new command, need to reset the sequence.
-	c.sequence = 0
+	c.resetSequence()
 
 	data, pos := c.startEphemeralPacketWithHeader(1)
 	data[pos] = ComQuit
@@ -789,16 +883,16 @@ func (c *Conn) writeOKPacketWithHeader(packetOk *PacketOK, headerType byte) erro
 	// assuming CapabilityClientProtocol41
 	length += 4 // status_flags + warnings
 
-	var gtidData []byte
+	statusFlags := packetOk.statusFlags
+	var sessionStateData []byte
 	if c.Capabilities&CapabilityClientSessionTrack == CapabilityClientSessionTrack {
 		length += lenEncStringSize(packetOk.info) // info
-		if packetOk.statusFlags&ServerSessionStateChanged == ServerSessionStateChanged {
-			gtidData = getLenEncString([]byte(packetOk.sessionStateData))
-			gtidData = append([]byte{0x00}, gtidData...)
-			gtidData = getLenEncString(gtidData)
-			gtidData = append([]byte{0x03}, gtidData...)
-			gtidData = append(getLenEncInt(uint64(len(gtidData))), gtidData...)
-			length += len(gtidData)
+		if !packetOk.sessionState.IsEmpty() {
+			statusFlags |= ServerSessionStateChanged
+		}
+		if statusFlags&ServerSessionStateChanged == ServerSessionStateChanged {
+			sessionStateData = packetOk.sessionState.encode()
+			length += len(sessionStateData)
 		}
 	} else {
 		length += len(packetOk.info) // info
@@ -809,12 +903,12 @@ func (c *Conn) writeOKPacketWithHeader(packetOk *PacketOK, headerType byte) erro
 	data.writeByte(headerType) // header - OK or EOF
 	data.writeLenEncInt(packetOk.affectedRows)
 	data.writeLenEncInt(packetOk.lastInsertID)
-	data.writeUint16(packetOk.statusFlags)
+	data.writeUint16(statusFlags)
 	data.writeUint16(packetOk.warnings)
 	if c.Capabilities&CapabilityClientSessionTrack == CapabilityClientSessionTrack {
 		data.writeLenEncString(packetOk.info)
-		if packetOk.statusFlags&ServerSessionStateChanged == ServerSessionStateChanged {
-			data.writeEOFString(string(gtidData))
+		if statusFlags&ServerSessionStateChanged == ServerSessionStateChanged {
+			data.writeEOFString(string(sessionStateData))
 		}
 	} else {
 		data.writeEOFString(packetOk.info)
@@ -923,7 +1017,7 @@ func (c *Conn) writeEOFPacket(flags uint16, warnings uint16) error {
 // handleNextCommand is called in the server loop to process
 // incoming packets.
 func (c *Conn) handleNextCommand(handler Handler) bool {
-	c.sequence = 0
+	c.resetSequence()
 	data, err := c.readEphemeralPacket()
 	if err != nil {
 		// Don't log EOF errors. They cause too much spam.
@@ -939,6 +1033,8 @@ func (c *Conn) handleNextCommand(handler Handler) bool {
 	if c.IsMarkedForClose() {
 		return false
 	}
+	c.QueryAttributes = nil
+	c.SessionStateChanges = SessionStateChanges{}
 
 	switch data[0] {
 	case ComQuit:
@@ -972,6 +1068,8 @@ func (c *Conn) handleNextCommand(handler Handler) bool {
 	case ComResetConnection:
 		c.handleComResetConnection(handler)
 		return true
+	case ComChangeUser:
+		return c.handleComChangeUser(handler, data)
 	case ComFieldList:
 		c.recycleReadPacket()
 		if !c.writeErrorAndLog(ERUnknownComError, SSNetError, "command handling not implemented yet: %v", data[0]) {
@@ -1072,6 +1170,60 @@ func (c *Conn) handleComResetConnection(handler Handler) {
 	}
 }
 
+// handleComChangeUser authenticates the connection as another user. Like
+// in MySQL, the session is reset when it succeeds, and the connection is
+// closed when it fails.
+func (c *Conn) handleComChangeUser(handler Handler, data []byte) (kontinue bool) {
+	user, authMethod, _, schemaName, characterSet, err := c.parseComChangeUser(data)
+	c.recycleReadPacket()
+	if err != nil {
+		log.Errorf("Cannot parse COM_CHANGE_USER packet from %s: %v", c, err)
+		c.writeErrorPacketFromError(err)
+		return false
+	}
+
+	// The client scrambled its password with the salt of an earlier
+	// authentication, so its response could be replayed. It is ignored,
+	// and the client has to authenticate again with a fresh salt.
+	userData, ok := c.listener.authenticate(c, user, authMethod, nil)
+	if !ok {
+		return false
+	}
+
+	if c.User != "" {
+		connCountPerUser.Add(c.User, -1)
+	}
+	c.User = user
+	c.UserData = userData
+	if c.User != "" {
+		connCountPerUser.Add(c.User, 1)
+	}
+	if characterSet != 0 {
+		c.CharacterSet = characterSet
+	}
+
+	// Start over with a new session for the new user.
+	handler.ComChangeUser(c)
+	c.PrepareData = make(map[uint32]*PrepareData)
+
+	c.schemaName = schemaName
+	if c.schemaName != "" {
+		err = handler.ComQuery(c, "use "+sqlescape.EscapeID(c.schemaName), func(result *sqltypes.Result) error {
+			return nil
+		})
+		if err != nil {
+			c.writeErrorPacketFromError(err)
+			return false
+		}
+	}
+
+	if err := c.writeOKPacket(&PacketOK{statusFlags: c.StatusFlags}); err != nil {
+		log.Errorf("Error writing ComChangeUser OK packet to %s: %v", c, err)
+		return false
+	}
+	return true
+}
+
 func (c *Conn) handleComStmtReset(data []byte) bool {
 	stmtID, ok := c.parseComStmtReset(data)
 	c.recycleReadPacket()
@@ -1142,8 +1294,9 @@ func (c *Conn) handleComStmtExecute(handler Handler, data []byte) (kontinue bool
 		}
 	}()
 	queryStart := time.Now()
-	stmtID, _, err := c.parseComStmtExecute(c.PrepareData, data)
+	stmtID, _, attributes, err := c.parseComStmtExecute(c.PrepareData, data)
 	c.recycleReadPacket()
+	c.QueryAttributes = attributes
 
 	if stmtID != uint32(0) {
 		defer func() {
@@ -1174,12 +1327,12 @@ func (c *Conn) handleComStmtExecute(handler Handler, data []byte) (kontinue bool
 				sendFinished = true
 				// We should not send any more packets after this.
 				ok := PacketOK{
-					affectedRows:     qr.RowsAffected,
-					lastInsertID:     qr.InsertID,
-					statusFlags:      c.StatusFlags,
-					warnings:         0,
-					info:             "",
-					sessionStateData: qr.SessionStateChanges,
+					affectedRows: qr.RowsAffected,
+					lastInsertID: qr.InsertID,
+					statusFlags:  c.StatusFlags,
+					warnings:     0,
+					info:         "",
+					sessionState: c.takeSessionStateChanges(qr),
 				}
 				return c.writeOKPacket(&ok)
 			}
@@ -1360,11 +1513,14 @@ func (c *Conn) handleComQuery(handler Handler, data []byte) (kontinue bool) {
 	}()
 
 	queryStart := time.Now()
-	query := c.parseComQuery(data)
+	query, attributes, err := c.parseComQuery(data)
 	c.recycleReadPacket()
+	if err != nil {
+		return c.writeErrorPacketFromErrorAndLog(err)
+	}
+	c.QueryAttributes = attributes
 
 	var queries []string
-	var err error
 	if c.Capabilities&CapabilityClientMultiStatements != 0 {
 		queries, err = splitStatementFunction(query)
 		if err != nil {
@@ -1422,12 +1578,12 @@ func (c *Conn) execQuery(query string, handler Handler, more bool) execResult {
 				// to extract the affected rows and last insert id from the result
 				// struct here since clients expect it.
 				ok := PacketOK{
-					affectedRows:     qr.RowsAffected,
-					lastInsertID:     qr.InsertID,
-					statusFlags:      flag,
-					warnings:         handler.WarningCount(c),
-					info:             "",
-					sessionStateData: qr.SessionStateChanges,
+					affectedRows: qr.RowsAffected,
+					lastInsertID: qr.InsertID,
+					statusFlags:  flag,
+					warnings:     handler.WarningCount(c),
+					info:         "",
+					sessionState: c.takeSessionStateChanges(qr),
 				}
 				return c.writeOKPacket(&ok)
 			}
@@ -1523,8 +1679,9 @@ type PacketOK struct {
 	warnings     uint16
 	info         string
 
-	// at the moment, we only store GTID information in this field
-	sessionStateData string
+	// sessionState holds the session state changes, with
+	// CapabilityClientSessionTrack.
+	sessionState SessionStateChanges
 }
 
 func (c *Conn) parseOKPacket(in []byte) (*PacketOK, error) {
@@ -1576,44 +1733,11 @@ func (c *Conn) parseOKPacket(in []byte) (*PacketOK, error) {
 	if c.Capabilities&uint32(CapabilityClientSessionTrack) == CapabilityClientSessionTrack {
 		// session tracking
 		if statusFlags&ServerSessionStateChanged == ServerSessionStateChanged {
-			length, ok := data.readLenEncInt()
-			if !ok || length == 0 {
-				// In case we have no more data or a zero length string, there's no additional information so
-				// we can return the packet.
-				return packetOK, nil
-			}
-
-			// Alright, now we need to read each sub packet from the session state change.
-			for {
-				sscType, ok := data.readByte()
-				if !ok {
-					// We're done, there's no more session state parts in the packet.
-					break
-				}
-				sessionLen, ok := data.readLenEncInt()
-				if !ok {
-					return fail("invalid OK packet session state change length for type %v", sscType)
-				}
-
-				if sscType != SessionTrackGtids {
-					// Still need to increase the pointer here to indicate we're consuming
-					// but otherwise ignoring the rest of this packet
-					data.pos = data.pos + int(sessionLen)
-					continue
-				}
-
-				// read (and ignore for now) the GTIDS encoding specification code: 1 byte
-				_, ok = data.readByte()
-				if !ok {
-					return fail("invalid OK packet gtids type: %v", data)
-				}
-
-				gtids, ok := data.readLenEncString()
-				if !ok {
-					return fail("invalid OK packet gtids: %v", data)
-				}
-				packetOK.sessionStateData = gtids
+			sessionState, ok := parseSessionStateChanges(data)
+			if !ok {
+				return fail("invalid OK packet session state change: %v", data)
 			}
+			packetOK.sessionState = sessionState
 		}
 	}
 
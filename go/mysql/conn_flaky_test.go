@@ -258,12 +258,12 @@ func TestBasicPackets(t *testing.T) {
 	sConn.Capabilities |= CapabilityClientSessionTrack
 	cConn.Capabilities |= CapabilityClientSessionTrack
 	ok := PacketOK{
-		affectedRows:     23,
-		lastInsertID:     45,
-		statusFlags:      67 | ServerSessionStateChanged,
-		warnings:         89,
-		info:             "",
-		sessionStateData: "foo-bar",
+		affectedRows: 23,
+		lastInsertID: 45,
+		statusFlags:  67 | ServerSessionStateChanged,
+		warnings:     89,
+		info:         "",
+		sessionState: SessionStateChanges{GTIDs: "foo-bar"},
 	}
 	err = sConn.writeOKPacket(&ok)
 	require.NoError(err)
@@ -279,7 +279,34 @@ func TestBasicPackets(t *testing.T) {
 	assert.EqualValues(45, packetOk.lastInsertID)
 	assert.EqualValues(ServerSessionStateChanged, packetOk.statusFlags&ServerSessionStateChanged)
 	assert.EqualValues(89, packetOk.warnings)
-	assert.EqualValues("foo-bar", packetOk.sessionStateData)
+	assert.EqualValues("foo-bar", packetOk.sessionState.GTIDs)
+
+	// Write OK packet with all the session state changes, read it, compare.
+	// The ServerSessionStateChanged flag is set as needed.
+	ok = PacketOK{
+		affectedRows: 1,
+		statusFlags:  2,
+		sessionState: SessionStateChanges{
+			SystemVariables: map[string]string{
+				"autocommit": "OFF",
+				"sql_mode":   "ANSI_QUOTES",
+			},
+			Schema: "ks",
+			GTIDs:  "foo-bar",
+		},
+	}
+	err = sConn.writeOKPacket(&ok)
+	require.NoError(err)
+
+	data, err = cConn.ReadPacket()
+	require.NoError(err)
+	require.NotEmpty(data)
+
+	packetOk, err = cConn.parseOKPacket(data)
+	require.NoError(err)
+	assert.EqualValues(1, packetOk.affectedRows)
+	assert.EqualValues(ServerSessionStateChanged, packetOk.statusFlags&ServerSessionStateChanged)
+	assert.Equal(ok.sessionState, packetOk.sessionState)
 
 	// Write OK packet with EOF header, read it, compare.
 	ok = PacketOK{
@@ -361,7 +388,7 @@ func TestOkPackets(t *testing.T) {
 	}, {
 		dataIn: `
 00000000  FE 00 00 22 40 00 00                              |.....|`,
-		dataOut: `00000000  00 00 00 22 40 00 00 00  04 03 02 00 00           |..."@........|`,
+		dataOut: `00000000  00 00 00 22 40 00 00 00  00                       |..."@.....|`,
 		cc:      CapabilityClientProtocol41 | CapabilityClientTransactions | CapabilityClientSessionTrack | CapabilityClientDeprecateEOF,
 	}, {
 		dataIn: `
@@ -371,22 +398,22 @@ func TestOkPackets(t *testing.T) {
 00000030  61 3a 32                                          |a:2|`,
 		cc: CapabilityClientProtocol41 | CapabilityClientTransactions | CapabilityClientSessionTrack,
 	}, {
-		dataIn:  `00000000  00 00 00 02 40 00 00 00  07 01 05 04 74 65 73 74  |....@.......test|`,
-		dataOut: `00000000  00 00 00 02 40 00 00 00  04 03 02 00 00  |....@........|`,
-		cc:      CapabilityClientProtocol41 | CapabilityClientTransactions | CapabilityClientSessionTrack,
+		dataIn: `00000000  00 00 00 02 40 00 00 00  07 01 05 04 74 65 73 74  |....@.......test|`,
+		cc:     CapabilityClientProtocol41 | CapabilityClientTransactions | CapabilityClientSessionTrack,
 	}, {
 		dataIn: `
 00000000  00 00 00 00 40 00 00 00  14 00 0f 0a 61 75 74 6f  |....@.......auto|
 00000010  63 6f 6d 6d 69 74 03 4f  46 46 02 01 31           |commit.OFF..1|`,
 		dataOut: `
-00000000  00 00 00 00 40 00 00 00  04 03 02 00 00           |....@........|`,
+00000000  00 00 00 00 40 00 00 00  11 00 0f 0a 61 75 74 6f  |....@.......auto|
+00000010  63 6f 6d 6d 69 74 03 4f  46 46                    |commit.OFF|`,
 		cc: CapabilityClientProtocol41 | CapabilityClientTransactions | CapabilityClientSessionTrack,
 	}, {
 		dataIn: `
 00000000  00 00 00 00 40 00 00 00  0a 01 05 04 74 65 73 74  |....@.......test|
 00000010  02 01 31                                          |..1|`,
 		dataOut: `
-00000000  00 00 00 00 40 00 00 00  04 03 02 00 00           |....@........|`,
+00000000  00 00 00 00 40 00 00 00  07 01 05 04 74 65 73 74  |....@.......test|`,
 		cc: CapabilityClientProtocol41 | CapabilityClientTransactions | CapabilityClientSessionTrack,
 	}, {
 		dataIn: `0000   00 00 00 03 40 00 00 00 fc 56 04 03   |....@....V..|
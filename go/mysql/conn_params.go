@@ -32,6 +32,11 @@ type ConnParams struct {
 	Flags      uint64 `json:"flags"`
 	Flavor     string `json:"flavor,omitempty"`
 
+	// Compression is the protocol compression algorithm to ask for,
+	// CompressionZlib or CompressionZstd. The connection is only
+	// compressed if the server supports the algorithm.
+	Compression string `json:"compression,omitempty"`
+
 	// The following SSL flags control the SSL behavior.
 	//
 	// Not setting this value implies preferred mode unless
@@ -83,8 +83,9 @@ const (
 	// CLIENT_NO_SCHEMA 1 << 4
 	// Do not permit database.table.column. We do permit it.
 
-	// CLIENT_COMPRESS 1 << 5
-	// We do not support compression. CPU is usually our bottleneck.
+	// CapabilityClientCompress is CLIENT_COMPRESS.
+	// Use the compressed protocol, with zlib.
+	CapabilityClientCompress = 1 << 5
 
 	// CLIENT_ODBC 1 << 6
 	// No special behavior since 3.22.
@@ -155,6 +156,18 @@ const (
 	// CapabilityClientDeprecateEOF is CLIENT_DEPRECATE_EOF
 	// Expects an OK (instead of EOF) after the resultset rows of a Text Resultset.
 	CapabilityClientDeprecateEOF = 1 << 24
+
+	// CLIENT_OPTIONAL_RESULTSET_METADATA 1 << 25
+	// Not supported.
+
+	// CapabilityClientZstdCompressionAlgorithm is CLIENT_ZSTD_COMPRESSION_ALGORITHM
+	// Use the compressed protocol, with zstd. The handshake response has
+	// the compression level the client wants as its last byte.
+	CapabilityClientZstdCompressionAlgorithm = 1 << 26
+
+	// CapabilityClientQueryAttributes is CLIENT_QUERY_ATTRIBUTES
+	// COM_QUERY and COM_STMT_EXECUTE may carry key/value query attributes.
+	CapabilityClientQueryAttributes = 1 << 27
 )
 
 // Status flags. They are returned by the server in a few cases.
@@ -216,6 +229,9 @@ const (
 	// ComPing is COM_PING.
 	ComPing = 0x0e
 
+	// ComChangeUser is COM_CHANGE_USER.
+	ComChangeUser = 0x11
+
 	// ComBinlogDump is COM_BINLOG_DUMP.
 	ComBinlogDump = 0x12
 
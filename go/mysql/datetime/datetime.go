@@ -237,6 +237,15 @@ func (d Date) Day() int {
 	return int(d.day)
 }
 
+// LastDay returns the date for the last day of the month of this date.
+// Dates with a zero month have no last day and return a zero Date.
+func (d Date) LastDay() Date {
+	if d.month == 0 {
+		return Date{}
+	}
+	return Date{year: d.year, month: d.month, day: uint8(daysIn(time.Month(d.month), d.Year()))}
+}
+
 func (d Date) Hash(h *vthash.Hasher) {
 	h.Write16(d.year)
 	h.Write8(d.month)
@@ -556,6 +565,19 @@ func (dt DateTime) toSeconds() int {
 	return (dt.Date.Day()-1)*secondsPerDay + dt.Time.toSeconds()
 }
 
+// ToSeconds returns the number of seconds elapsed since MySQL's absolute
+// day zero, together with the remaining nanoseconds. For a DateTime with a
+// zero date, only its (possibly negative) time is taken into account and
+// both returned values carry the sign of the time.
+func (dt DateTime) ToSeconds() (int64, int) {
+	sec := int64(dt.Date.DayNumber())*secondsPerDay + int64(dt.Time.toSeconds())
+	nsec := dt.Time.Nanosecond()
+	if dt.Time.Neg() {
+		nsec = -nsec
+	}
+	return sec, nsec
+}
+
 func (dt *DateTime) addInterval(itv *Interval) bool {
 	switch {
 	case itv.unit.HasTimeParts():
@@ -641,6 +663,36 @@ func (dt *DateTime) addInterval(itv *Interval) bool {
 	}
 }
 
+// NewTimeFromSeconds returns the Time that corresponds to the given
+// number of seconds, as done by MySQL's SEC_TO_TIME. Values outside
+// of the range of TIME are clamped to -838:59:59 or 838:59:59.
+func NewTimeFromSeconds(seconds decimal.Decimal) Time {
+	neg := seconds.Sign() < 0
+	seconds = seconds.Abs()
+
+	sec, frac := seconds.QuoRem(decimal.New(1, 0), 0)
+	s, ok := sec.Int64()
+	if !ok || s > maxTimeSeconds || s == maxTimeSeconds && !frac.IsZero() {
+		t := Time{hour: 838, minute: 59, second: 59}
+		if neg {
+			t.hour |= negMask
+		}
+		return t
+	}
+	nsec, _ := frac.Mul(decimal.New(1e9, 0)).Int64()
+
+	t := Time{
+		hour:       uint16(s / secondsPerHour),
+		minute:     uint8((s / secondsPerMinute) % secondsPerMinute),
+		second:     uint8(s % secondsPerMinute),
+		nanosecond: uint32(nsec),
+	}
+	if neg && !t.IsZero() {
+		t.hour |= negMask
+	}
+	return t
+}
+
 func NewDateFromStd(t time.Time) Date {
 	year, month, day := t.Date()
 	return Date{
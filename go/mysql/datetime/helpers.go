@@ -288,4 +288,8 @@ const (
 	secondsPerMinute = 60
 	secondsPerHour   = 60 * secondsPerMinute
 	secondsPerDay    = 24 * secondsPerHour
+
+	// maxTimeSeconds is the number of seconds in 838:59:59,
+	// the largest value that can be stored in a TIME.
+	maxTimeSeconds = 838*secondsPerHour + 59*secondsPerMinute + 59
 )
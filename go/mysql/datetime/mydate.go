@@ -81,3 +81,21 @@ func mysqlDateFromDayNumber(daynr int) (uint16, uint8, uint8) {
 
 	panic("unreachable: yday is too large?")
 }
+
+// DayNumber returns the absolute day number for this date, the same
+// value that MySQL's TO_DAYS returns. See mysqlDayNumber for the caveats
+// of this numbering scheme.
+func (d Date) DayNumber() int {
+	return mysqlDayNumber(d.Year(), d.Month(), d.Day())
+}
+
+// NewDateFromDayNumber returns the date for the given absolute day number,
+// the same value that MySQL's FROM_DAYS returns. Day numbers outside the
+// range of valid dates result in a zero date.
+func NewDateFromDayNumber(daynr int) Date {
+	if daynr > maxDay {
+		return Date{}
+	}
+	year, month, day := mysqlDateFromDayNumber(daynr)
+	return Date{year: year, month: month, day: day}
+}
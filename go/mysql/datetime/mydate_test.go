@@ -57,3 +57,15 @@ func TestDayNumberFields(t *testing.T) {
 		assert.Equalf(t, tc[0], mysqlDayNumber(tc[1], tc[2], tc[3]), "date %d-%d-%d", tc[1], tc[2], tc[3])
 	}
 }
+
+func TestDayNumberRoundTrip(t *testing.T) {
+	d, ok := ParseDate("2007-10-07")
+	require.True(t, ok)
+	assert.Equal(t, 733321, d.DayNumber())
+	assert.Equal(t, "2007-10-07", string(NewDateFromDayNumber(733321).Format()))
+
+	assert.Equal(t, "2000-07-03", string(NewDateFromDayNumber(730669).Format()))
+	assert.Equal(t, "0000-00-00", string(NewDateFromDayNumber(365).Format()))
+	assert.Equal(t, "9999-12-31", string(NewDateFromDayNumber(maxDay).Format()))
+	assert.Equal(t, "0000-00-00", string(NewDateFromDayNumber(maxDay+1).Format()))
+}
@@ -58,6 +58,7 @@ func TestParseDate(t *testing.T) {
 		{input: "990000", output: date{1999, 1, 1}, err: true},
 		{input: "070523", output: date{2007, 5, 23}},
 		{input: "071332", err: true},
+		{input: "2003-00-05", err: true},
 		{input: "2022", err: true},
 	}
 
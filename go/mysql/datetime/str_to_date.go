@@ -0,0 +1,384 @@
+/*
+Copyright 2023 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package datetime
+
+var longDayNames = []string{
+	"Sunday",
+	"Monday",
+	"Tuesday",
+	"Wednesday",
+	"Thursday",
+	"Friday",
+	"Saturday",
+}
+
+var longMonthNames = []string{
+	"January",
+	"February",
+	"March",
+	"April",
+	"May",
+	"June",
+	"July",
+	"August",
+	"September",
+	"October",
+	"November",
+	"December",
+}
+
+// StrToDateParts returns which parts of a temporal value are filled in
+// when parsing with the given STR_TO_DATE format: the date, the time,
+// and the fractional seconds. MySQL uses this to decide whether the
+// result of STR_TO_DATE is a DATE, a TIME or a DATETIME.
+func StrToDateParts(format string) (date, time, frac bool) {
+	for i := 0; i < len(format)-1; i++ {
+		if format[i] != '%' {
+			continue
+		}
+		i++
+		switch format[i] {
+		case 'H', 'h', 'I', 'i', 'k', 'l', 'p', 'r', 'S', 's', 'T':
+			time = true
+		case 'f':
+			time = true
+			frac = true
+		case 'a', 'b', 'c', 'D', 'd', 'e', 'j', 'M', 'm', 'U', 'u', 'V', 'v', 'W', 'w', 'X', 'x', 'Y', 'y':
+			date = true
+		}
+	}
+	return
+}
+
+// StrToDate parses str with the given format string, following the
+// semantics of MySQL's STR_TO_DATE. These are much more lenient than the
+// ones of Strftime.Parse: whitespace in the input is skipped before every
+// format item, numeric fields consume up to their maximum number of digits,
+// parsing stops as soon as the input is exhausted (leaving the remaining
+// fields at zero) and trailing input after the format is ignored.
+//
+// The resulting DateTime can have zero or out-of-range date parts (e.g.
+// 2023-02-30), since it's up to the caller to reject these depending
+// on the current sql_mode.
+func StrToDate(str, format string) (DateTime, bool) {
+	var sd strToDate
+	sd.weekNumber = -1
+	sd.weekYear = -1
+
+	if _, ok := sd.parse(str, format); !ok {
+		return DateTime{}, false
+	}
+	return sd.toDateTime()
+}
+
+type strToDate struct {
+	year, month, day          int
+	hour, minute, second      int
+	nsec                      int
+	yearday, weekday, daypart int
+
+	weekNumber     int
+	weekYear       int
+	weekYearSunday bool
+	sundayFirst    bool
+	strictWeek     bool
+	usaTime        bool
+}
+
+func (sd *strToDate) parse(val, format string) (string, bool) {
+	var ok bool
+	for len(format) > 0 {
+		for len(val) > 0 && isSpace(val[0]) {
+			val = val[1:]
+		}
+		if len(val) == 0 {
+			break
+		}
+
+		if format[0] != '%' || len(format) == 1 {
+			if !isSpace(format[0]) {
+				if val[0] != format[0] {
+					return "", false
+				}
+				val = val[1:]
+			}
+			format = format[1:]
+			continue
+		}
+
+		spec := format[1]
+		format = format[2:]
+
+		switch spec {
+		case 'Y':
+			var n int
+			sd.year, n, val, ok = strToDateNum(val, 4)
+			if n <= 2 {
+				sd.year = year2000(sd.year)
+			}
+		case 'y':
+			sd.year, _, val, ok = strToDateNum(val, 2)
+			sd.year = year2000(sd.year)
+		case 'm', 'c':
+			sd.month, _, val, ok = strToDateNum(val, 2)
+		case 'M':
+			sd.month, val, ok = strToDateWord(longMonthNames, val)
+			sd.month++
+		case 'b':
+			sd.month, val, ok = strToDateWord(shortMonthNames, val)
+			sd.month++
+		case 'd', 'e':
+			sd.day, _, val, ok = strToDateNum(val, 2)
+		case 'D':
+			sd.day, _, val, ok = strToDateNum(val, 2)
+			// skip the 'st', 'nd', 'rd' or 'th' suffix
+			if len(val) > 2 {
+				val = val[2:]
+			} else {
+				val = ""
+			}
+		case 'h', 'I', 'l':
+			sd.usaTime = true
+			sd.hour, _, val, ok = strToDateNum(val, 2)
+		case 'k', 'H':
+			sd.hour, _, val, ok = strToDateNum(val, 2)
+		case 'i':
+			sd.minute, _, val, ok = strToDateNum(val, 2)
+		case 's', 'S':
+			sd.second, _, val, ok = strToDateNum(val, 2)
+		case 'f':
+			var n int
+			sd.nsec, n, val, ok = strToDateNum(val, 6)
+			for ; n < 9; n++ {
+				sd.nsec *= 10
+			}
+		case 'p':
+			if len(val) < 2 || !sd.usaTime {
+				return "", false
+			}
+			switch {
+			case match(val[:2], "PM"):
+				sd.daypart = 12
+			case match(val[:2], "AM"):
+				sd.daypart = 0
+			default:
+				return "", false
+			}
+			val = val[2:]
+			ok = true
+		case 'W':
+			sd.weekday, val, ok = strToDateWord(longDayNames, val)
+			if sd.weekday == 0 {
+				sd.weekday = 7
+			}
+		case 'a':
+			sd.weekday, val, ok = strToDateWord(shortDayNames, val)
+			if sd.weekday == 0 {
+				sd.weekday = 7
+			}
+		case 'w':
+			sd.weekday, _, val, ok = strToDateNum(val, 1)
+			if sd.weekday > 6 {
+				return "", false
+			}
+			// %w uses the same 1 to 7 scale as %W
+			if sd.weekday == 0 {
+				sd.weekday = 7
+			}
+		case 'j':
+			sd.yearday, _, val, ok = strToDateNum(val, 3)
+		case 'U', 'u', 'V', 'v':
+			sd.sundayFirst = spec == 'U' || spec == 'V'
+			sd.strictWeek = spec == 'V' || spec == 'v'
+			sd.weekNumber, _, val, ok = strToDateNum(val, 2)
+			if sd.weekNumber > 53 || sd.strictWeek && sd.weekNumber == 0 {
+				return "", false
+			}
+		case 'X', 'x':
+			sd.weekYearSunday = spec == 'X'
+			sd.weekYear, _, val, ok = strToDateNum(val, 4)
+		case 'r':
+			// MySQL parses this sub-pattern with its own state, so the
+			// AM/PM marker is validated but does not affect the hour.
+			sub := strToDate{weekNumber: -1, weekYear: -1}
+			val, ok = sub.parse(val, "%I:%i:%S %p")
+			sd.hour, sd.minute, sd.second = sub.hour, sub.minute, sub.second
+		case 'T':
+			val, ok = sd.parse(val, "%H:%i:%S")
+		case '.':
+			for len(val) > 0 && isSeparator(val[0]) {
+				val = val[1:]
+			}
+			ok = true
+		case '@':
+			for len(val) > 0 && isAlpha(val[0]) {
+				val = val[1:]
+			}
+			ok = true
+		case '#':
+			for len(val) > 0 && isDigit(val, 0) {
+				val = val[1:]
+			}
+			ok = true
+		default:
+			return "", false
+		}
+		if !ok {
+			return "", false
+		}
+	}
+	return val, true
+}
+
+func (sd *strToDate) toDateTime() (DateTime, bool) {
+	if sd.usaTime {
+		if sd.hour < 1 || sd.hour > 12 {
+			return DateTime{}, false
+		}
+		sd.hour = sd.hour%12 + sd.daypart
+	}
+
+	if sd.yearday > 0 {
+		d := NewDateFromDayNumber(mysqlDayNumber(sd.year, 1, 1) + sd.yearday - 1)
+		if d.IsZero() {
+			return DateTime{}, false
+		}
+		sd.year, sd.month, sd.day = d.Year(), d.Month(), d.Day()
+	}
+
+	if sd.weekNumber >= 0 && sd.weekday > 0 {
+		// %V and %v require %X and %x respectively,
+		// while %U and %u must be used with %Y instead.
+		if sd.strictWeek && (sd.weekYear < 0 || sd.weekYearSunday != sd.sundayFirst) {
+			return DateTime{}, false
+		}
+		if !sd.strictWeek && sd.weekYear >= 0 {
+			return DateTime{}, false
+		}
+
+		year := sd.year
+		if sd.strictWeek {
+			year = sd.weekYear
+		}
+
+		days := mysqlDayNumber(year, 1, 1)
+		weekdayFirst := mysqlWeekday(days, sd.sundayFirst)
+
+		if sd.sundayFirst {
+			if weekdayFirst != 0 {
+				days += 7
+			}
+			days += -weekdayFirst + (sd.weekNumber-1)*7 + sd.weekday%7
+		} else {
+			if weekdayFirst > 3 {
+				days += 7
+			}
+			days += -weekdayFirst + (sd.weekNumber-1)*7 + sd.weekday - 1
+		}
+
+		d := NewDateFromDayNumber(days)
+		if d.IsZero() {
+			return DateTime{}, false
+		}
+		sd.year, sd.month, sd.day = d.Year(), d.Month(), d.Day()
+	}
+
+	if sd.year > 9999 || sd.month > 12 || sd.day > 31 || sd.hour > 23 || sd.minute > 59 || sd.second > 59 {
+		return DateTime{}, false
+	}
+
+	return DateTime{
+		Date: Date{
+			year:  uint16(sd.year),
+			month: uint8(sd.month),
+			day:   uint8(sd.day),
+		},
+		Time: Time{
+			hour:       uint16(sd.hour),
+			minute:     uint8(sd.minute),
+			second:     uint8(sd.second),
+			nanosecond: uint32(sd.nsec),
+		},
+	}, true
+}
+
+// mysqlWeekday returns the day of the week for an absolute day number
+// as returned by mysqlDayNumber, starting from Monday (or Sunday if
+// sundayFirst is set) as 0.
+func mysqlWeekday(daynr int, sundayFirst bool) int {
+	if sundayFirst {
+		daynr++
+	}
+	return (daynr + 5) % 7
+}
+
+// year2000 converts a two-digit year into a full year, with the
+// same cutoff that MySQL uses: 00-69 is 2000-2069 and 70-99 is 1970-1999.
+func year2000(year int) int {
+	if year < 70 {
+		return year + 2000
+	}
+	if year < 100 {
+		return year + 1900
+	}
+	return year
+}
+
+// strToDateNum parses a number of at most max digits at the start of s,
+// returning the number, how many digits it had and the remaining input.
+func strToDateNum(s string, max int) (int, int, string, bool) {
+	var n, x int
+	for n < max && isDigit(s, n) {
+		x = x*10 + int(s[n]-'0')
+		n++
+	}
+	return x, n, s[n:], n > 0
+}
+
+// strToDateWord matches the word at the start of s against the names
+// in tab, case-insensitively. Like MySQL, an unambiguous prefix of a
+// name is accepted as well. It returns the index of the matching name
+// and the remaining input.
+func strToDateWord(tab []string, s string) (int, string, bool) {
+	n := 0
+	for n < len(s) && isAlpha(s[n]) {
+		n++
+	}
+	word := s[:n]
+
+	found := -1
+	for i, name := range tab {
+		if len(word) > len(name) || !match(word, name[:len(word)]) {
+			continue
+		}
+		if len(word) == len(name) {
+			return i, s[n:], true
+		}
+		if found >= 0 {
+			return 0, s, false
+		}
+		found = i
+	}
+	if found < 0 || n == 0 {
+		return 0, s, false
+	}
+	return found, s[n:], true
+}
+
+func isAlpha(b byte) bool {
+	return 'a' <= b && b <= 'z' || 'A' <= b && b <= 'Z'
+}
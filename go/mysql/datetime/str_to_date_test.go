@@ -0,0 +1,90 @@
+/*
+Copyright 2023 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package datetime
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStrToDate(t *testing.T) {
+	tests := []struct {
+		input  string
+		format string
+		output string
+		err    bool
+	}{
+		{input: "01,5,2013", format: "%d,%m,%Y", output: "2013-05-01 00:00:00.000000"},
+		{input: "May 1, 2013", format: "%M %d,%Y", output: "2013-05-01 00:00:00.000000"},
+		{input: "a09:30:17", format: "a%h:%i:%s", output: "0000-00-00 09:30:17.000000"},
+		{input: "a09:30:17", format: "%h:%i:%s", err: true},
+		{input: "09:30:17a", format: "%h:%i:%s", output: "0000-00-00 09:30:17.000000"},
+		{input: "abc", format: "abc", output: "0000-00-00 00:00:00.000000"},
+		{input: "9", format: "%m", output: "0000-09-00 00:00:00.000000"},
+		{input: "9", format: "%s", output: "0000-00-00 00:00:09.000000"},
+		{input: "200442 Monday", format: "%X%V %W", output: "2004-10-18 00:00:00.000000"},
+		{input: "200442 Monday", format: "%Y%V %W", err: true},
+		{input: "10:30 PM", format: "%h:%i %p", output: "0000-00-00 22:30:00.000000"},
+		{input: "12:30 am", format: "%h:%i %p", output: "0000-00-00 00:30:00.000000"},
+		{input: "13:30 PM", format: "%h:%i %p", err: true},
+		{input: "10:30 PM", format: "%H:%i %p", err: true},
+		{input: "2023-060", format: "%Y-%j", output: "2023-03-01 00:00:00.000000"},
+		{input: "12.5", format: "%s.%f", output: "0000-00-00 00:00:12.500000"},
+		{input: "Fri, 12 Jan 24", format: "%a, %d %b %y", output: "2024-01-12 00:00:00.000000"},
+		{input: "1st Feb 99", format: "%D %b %Y", output: "1999-02-01 00:00:00.000000"},
+		{input: "2023  -  7 -  4", format: "%Y-%c-%e", output: "2023-07-04 00:00:00.000000"},
+		{input: "2023/07/04 10:11:12", format: "%Y%.%m%.%d %T", output: "2023-07-04 10:11:12.000000"},
+		{input: "2023-02-30", format: "%Y-%m-%d", output: "2023-02-30 00:00:00.000000"},
+		{input: "2023-13-01", format: "%Y-%m-%d", err: true},
+		{input: "Sept 1 2023", format: "%M %d %Y", output: "2023-09-01 00:00:00.000000"},
+		{input: "Ju 1 2023", format: "%M %d %Y", err: true},
+		{input: "100%", format: "%s%%", err: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.input+"/"+test.format, func(t *testing.T) {
+			got, ok := StrToDate(test.input, test.format)
+			if test.err {
+				assert.False(t, ok)
+				return
+			}
+			assert.True(t, ok)
+			assert.Equal(t, test.output, string(got.Format(6)))
+		})
+	}
+}
+
+func TestStrToDateParts(t *testing.T) {
+	tests := []struct {
+		format           string
+		date, time, frac bool
+	}{
+		{format: "%Y-%m-%d", date: true},
+		{format: "%H:%i:%s", time: true},
+		{format: "%Y-%m-%d %T", date: true, time: true},
+		{format: "%s.%f", time: true, frac: true},
+		{format: "abc"},
+	}
+
+	for _, test := range tests {
+		date, time, frac := StrToDateParts(test.format)
+		assert.Equal(t, test.date, date, test.format)
+		assert.Equal(t, test.time, time, test.format)
+		assert.Equal(t, test.frac, frac, test.format)
+	}
+}
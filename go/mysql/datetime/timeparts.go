@@ -55,7 +55,7 @@ func (tp *timeparts) toDateTime(prec int) (DateTime, int, bool) {
 			tp.day = 1
 		}
 	}
-	if tp.day < 1 || tp.day > daysIn(time.Month(tp.month), tp.year) {
+	if tp.month < 1 || tp.month > 12 || tp.day < 1 || tp.day > daysIn(time.Month(tp.month), tp.year) {
 		return DateTime{}, 0, false
 	}
 
@@ -191,3 +191,85 @@ func testSSLConnectionBasics(t *testing.T, params *ConnParams) {
 	// Send a ComQuit to avoid the error message on the server side.
 	conn.writeComQuit()
 }
+
+// TestChangeUser authenticates a connection as another user with COM_CHANGE_USER.
+func TestChangeUser(t *testing.T) {
+	for _, authMethod := range []AuthMethodDescription{MysqlNativePassword, MysqlClearPassword} {
+		t.Run(string(authMethod), func(t *testing.T) {
+			th := &testHandler{}
+
+			authServer := NewAuthServerStaticWithAuthMethodDescription("", "", 0, authMethod)
+			authServer.entries["user1"] = []*AuthServerStaticEntry{
+				{Password: "password1", UserData: "userData1"},
+			}
+			authServer.entries["user2"] = []*AuthServerStaticEntry{
+				{Password: "password2", UserData: "userData2"},
+			}
+			defer authServer.close()
+
+			l, err := NewListener("tcp", "127.0.0.1:", authServer, th, 0, 0, false, false, 0)
+			require.NoError(t, err, "NewListener failed: %v", err)
+			defer l.Close()
+			l.AllowClearTextWithoutTLS.Store(true)
+			host := l.Addr().(*net.TCPAddr).IP.String()
+			port := l.Addr().(*net.TCPAddr).Port
+			go func() {
+				l.Accept()
+			}()
+
+			params := &ConnParams{
+				Host:    host,
+				Port:    port,
+				Uname:   "user1",
+				Pass:    "password1",
+				SslMode: vttls.Disabled,
+			}
+
+			ctx := context.Background()
+			conn, err := Connect(ctx, params)
+			require.NoError(t, err, "unexpected connection error: %v", err)
+			defer conn.Close()
+
+			result, err := conn.ExecuteFetch("userData echo", 10000, true)
+			require.NoError(t, err, "ExecuteFetch failed: %v", err)
+			assert.Equal(t, "user1", result.Rows[0][0].ToString())
+			assert.Equal(t, "userData1", result.Rows[0][1].ToString())
+
+			// Change to user2, with a default database.
+			salt := conn.salt
+			err = conn.ChangeUser(&ConnParams{Uname: "user2", Pass: "password2", DbName: "db2"})
+			require.NoError(t, err, "ChangeUser failed: %v", err)
+			assert.Equal(t, "user2", conn.User)
+			if authMethod == MysqlNativePassword {
+				// The password was scrambled again with a fresh salt.
+				assert.NotEqual(t, salt, conn.salt)
+			}
+
+			result, err = conn.ExecuteFetch("userData echo", 10000, true)
+			require.NoError(t, err, "ExecuteFetch failed: %v", err)
+			assert.Equal(t, "user2", result.Rows[0][0].ToString())
+			assert.Equal(t, "userData2", result.Rows[0][1].ToString())
+
+			result, err = conn.ExecuteFetch("schema echo", 10000, true)
+			require.NoError(t, err, "ExecuteFetch failed: %v", err)
+			assert.Equal(t, "db2", result.Rows[0][0].ToString())
+
+			// And back to user1.
+			salt = conn.salt
+			err = conn.ChangeUser(params)
+			require.NoError(t, err, "ChangeUser failed: %v", err)
+			if authMethod == MysqlNativePassword {
+				assert.NotEqual(t, salt, conn.salt)
+			}
+			result, err = conn.ExecuteFetch("userData echo", 10000, true)
+			require.NoError(t, err, "ExecuteFetch failed: %v", err)
+			assert.Equal(t, "user1", result.Rows[0][0].ToString())
+
+			// A wrong password is rejected, and the server closes the connection.
+			err = conn.ChangeUser(&ConnParams{Uname: "user2", Pass: "bad"})
+			assert.ErrorContains(t, err, "Access denied for user 'user2'")
+			_, err = conn.ExecuteFetch("select rows", 10000, true)
+			assert.Error(t, err)
+		})
+	}
+}
@@ -105,6 +105,11 @@ func (jp *Path) String() string {
 	return b.String()
 }
 
+// IsRoot returns whether jp is the path to the whole document, `$`.
+func (jp *Path) IsRoot() bool {
+	return jp.kind == jpDocumentRoot && jp.next == nil
+}
+
 func (jp *Path) ContainsWildcards() bool {
 	for jp != nil {
 		switch jp.kind {
@@ -218,39 +223,80 @@ func (jp *Path) Match(doc *Value, wrap bool, match func(value *Value)) {
 	m.value(jp, doc)
 }
 
-func (jp *Path) transform(v *Value, t func(pp *Path, vv *Value)) {
-	if v == nil {
-		return
-	}
-	if jp.next == nil {
-		t(jp, v)
-		return
-	}
+// transform applies the transformation t with the given value at the location
+// of jp inside v, and returns the resulting document. The result is v itself,
+// modified in place, unless the transformation replaces v as a whole.
+func (jp *Path) transform(t Transformation, v, value *Value) *Value {
 	switch jp.kind {
 	case jpDocumentRoot:
-		jp.next.transform(v, t)
+		if jp.next == nil {
+			if t == Set || t == Replace {
+				return value
+			}
+			return v
+		}
+		return jp.next.transform(t, v, value)
 	case jpMember:
-		if obj, ok := v.Object(); ok {
-			jp.next.transform(obj.Get(jp.name), t)
+		obj, ok := v.Object()
+		if !ok {
+			return v
 		}
-	case jpArrayLocation:
-		if ary, ok := v.Array(); ok {
-			from, to := jp.arrayOffsets(ary)
-			if from != to {
-				panic("range in transformation path expression")
+		if jp.next == nil {
+			if t == Remove {
+				obj.Del(jp.name)
+			} else {
+				obj.Set(jp.name, value, t)
 			}
-			if from >= 0 && from < len(ary) {
-				jp.next.transform(ary[from], t)
-			}
-		} else if jp.offset0 == 0 || jp.offset0 == -1 {
+			return v
+		}
+		if child := obj.Get(jp.name); child != nil {
+			obj.Set(jp.name, jp.next.transform(t, child, value), Replace)
+		}
+		return v
+	case jpArrayLocation:
+		ary, ok := v.Array()
+		if !ok {
 			/*
 				If the path is evaluated against a value that is not an array,
 				the result of the evaluation is the same as if the value had been
 				wrapped in a single-element array:
 			*/
-			jp.next.transform(v, t)
+			idx := int(jp.offset0)
+			if idx < 0 {
+				idx = 1 + idx
+			}
+			switch {
+			case idx == 0 && jp.next == nil:
+				if t == Set || t == Replace {
+					return value
+				}
+			case idx == 0:
+				return jp.next.transform(t, v, value)
+			case idx > 0 && jp.next == nil:
+				if t == Set || t == Insert {
+					return NewArray([]*Value{v, value})
+				}
+			}
+			return v
+		}
+
+		from, to := jp.arrayOffsets(ary)
+		if from != to {
+			panic("range in transformation path expression")
 		}
-	case jpMemberAny, jpArrayLocationAny, jpAny:
+		if jp.next == nil {
+			if t == Remove {
+				v.DelArrayItem(from)
+			} else {
+				v.SetArrayItem(from, value, t)
+			}
+			return v
+		}
+		if from >= 0 && from < len(ary) {
+			ary[from] = jp.next.transform(t, ary[from], value)
+		}
+		return v
+	default:
 		panic("wildcard in transformation path expression")
 	}
 }
@@ -264,38 +310,151 @@ const (
 	Remove
 )
 
-func ApplyTransform(t Transformation, doc *Value, paths []*Path, values []*Value) error {
+// ApplyTransform applies the transformation t to doc for each one of the
+// given paths, in order, and returns the resulting document. The document is
+// modified in place, so callers must Clone it first if it is shared. The values
+// must be unchanged during the lifetime of the resulting document.
+func ApplyTransform(t Transformation, doc *Value, paths []*Path, values []*Value) (*Value, error) {
 	if t != Remove && len(paths) != len(values) {
 		panic("missing Values for transformation")
 	}
 	for i, p := range paths {
-		transform := func(pp *Path, vv *Value) {
-			switch pp.kind {
-			case jpArrayLocation:
-				if ary, ok := vv.Array(); ok {
-					from, to := pp.arrayOffsets(ary)
-					if from != to {
-						return
-					}
-					if t == Remove {
-						vv.DelArrayItem(from)
-					} else {
-						vv.SetArrayItem(from, values[i], t)
-					}
+		var value *Value
+		if t != Remove {
+			value = values[i]
+		}
+		doc = p.transform(t, doc, value)
+	}
+	return doc, nil
+}
+
+type walker struct {
+	legs  []Path
+	visit func(path *Path, value *Value)
+}
+
+func (w *walker) path() *Path {
+	root := &Path{kind: jpDocumentRoot}
+	cur := root
+	for _, leg := range w.legs {
+		leg := leg
+		cur = cur.push(&leg)
+	}
+	return root
+}
+
+func (w *walker) pushMember(name string) {
+	w.legs = append(w.legs, Path{kind: jpMember, name: name})
+}
+
+func (w *walker) pushArrayLocation(n int) {
+	w.legs = append(w.legs, Path{kind: jpArrayLocation, offset0: int32(n)})
+}
+
+func (w *walker) pop() {
+	w.legs = w.legs[:len(w.legs)-1]
+}
+
+func (w *walker) all(v *Value) {
+	w.visit(w.path(), v)
+
+	if obj, ok := v.Object(); ok {
+		obj.Visit(func(key string, v *Value) {
+			w.pushMember(key)
+			w.all(v)
+			w.pop()
+		})
+	}
+	if ary, ok := v.Array(); ok {
+		for n, v := range ary {
+			w.pushArrayLocation(n)
+			w.all(v)
+			w.pop()
+		}
+	}
+}
+
+func (w *walker) any(p *Path, v *Value) {
+	w.value(p, v)
+
+	if obj, ok := v.Object(); ok {
+		obj.Visit(func(key string, v *Value) {
+			w.pushMember(key)
+			w.any(p, v)
+			w.pop()
+		})
+	}
+	if ary, ok := v.Array(); ok {
+		for n, v := range ary {
+			w.pushArrayLocation(n)
+			w.any(p, v)
+			w.pop()
+		}
+	}
+}
+
+func (w *walker) value(p *Path, v *Value) {
+	if v == nil {
+		return
+	}
+	if p == nil {
+		w.all(v)
+		return
+	}
+	switch p.kind {
+	case jpDocumentRoot:
+		w.value(p.next, v)
+	case jpAny:
+		w.any(p.next, v)
+	case jpMember:
+		if obj, ok := v.Object(); ok {
+			w.pushMember(p.name)
+			w.value(p.next, obj.Get(p.name))
+			w.pop()
+		}
+	case jpMemberAny:
+		if obj, ok := v.Object(); ok {
+			obj.Visit(func(key string, v *Value) {
+				w.pushMember(key)
+				w.value(p.next, v)
+				w.pop()
+			})
+		}
+	case jpArrayLocation:
+		if ary, ok := v.Array(); ok {
+			from, to := p.arrayOffsets(ary)
+			if from >= 0 && from < len(ary) {
+				if to >= len(ary) {
+					to = len(ary) - 1
 				}
-			case jpMember:
-				if obj, ok := vv.Object(); ok {
-					if t == Remove {
-						obj.Del(pp.name)
-					} else {
-						obj.Set(pp.name, values[i], t)
-					}
+				for n := from; n <= to; n++ {
+					w.pushArrayLocation(n)
+					w.value(p.next, ary[n])
+					w.pop()
 				}
 			}
+		} else if p.offset0 == 0 || p.offset0 == -1 {
+			w.value(p.next, v)
+		}
+	case jpArrayLocationAny:
+		if ary, ok := v.Array(); ok {
+			for n, v := range ary {
+				w.pushArrayLocation(n)
+				w.value(p.next, v)
+				w.pop()
+			}
 		}
-		p.transform(doc, transform)
 	}
-	return nil
+}
+
+// Walk calls visit for every value in doc that is matched by jp, and for every
+// value nested inside of the matches, in document order. Each value is passed
+// together with its concrete path, which never contains wildcards. Values that
+// are matched more than once by jp are visited more than once. A nil path walks
+// the whole document.
+func (jp *Path) Walk(doc *Value, visit func(path *Path, value *Value)) {
+	w := walker{visit: visit}
+	w.value(jp, doc)
 }
 
 func MatchPath(rawJSON, rawPath []byte, match func(value *Value)) error {
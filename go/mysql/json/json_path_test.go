@@ -172,6 +172,27 @@ func TestTransformations(t *testing.T) {
 			Paths:    []string{`$[2]`, `$[1].b[1]`, `$[1].b[1]`},
 			Expected: `["a", {"b": [true]}]`,
 		},
+		{
+			T:        Set,
+			Document: Document1,
+			Paths:    []string{`$[2][10]`, `$[0][1]`, `$[0][0]`},
+			Values:   []string{"30", "1", "2"},
+			Expected: `[[2, 1], {"b": [true, false]}, [10, 20, 30]]`,
+		},
+		{
+			T:        Insert,
+			Document: `{"a": 1}`,
+			Paths:    []string{`$`, `$.a[0]`, `$.a[last]`, `$.b`, `$.c.d`},
+			Values:   []string{"1", "2", "3", "4", "5"},
+			Expected: `{"a": 1, "b": 4}`,
+		},
+		{
+			T:        Replace,
+			Document: `{"a": 1}`,
+			Paths:    []string{`$.a[1]`, `$.b`, `$`},
+			Values:   []string{"2", "3", `[true]`},
+			Expected: `[true]`,
+		},
 	}
 
 	for _, tc := range cases {
@@ -187,7 +208,7 @@ func TestTransformations(t *testing.T) {
 			values = append(values, json(t, v))
 		}
 
-		err := ApplyTransform(tc.T, doc, paths, values)
+		doc, err := ApplyTransform(tc.T, doc, paths, values)
 		if err != nil {
 			t.Fatal(err)
 		}
@@ -198,3 +219,29 @@ func TestTransformations(t *testing.T) {
 		}
 	}
 }
+
+func TestWalk(t *testing.T) {
+	const Document1 = `{"a": [1, {"b": "x"}], "c": {"d": true}}`
+
+	cases := []struct {
+		JP       string
+		Expected []string
+	}{
+		{`$`, []string{`$`, `$.a`, `$.a[0]`, `$.a[1]`, `$.a[1].b`, `$.c`, `$.c.d`}},
+		{`$.a[1]`, []string{`$.a[1]`, `$.a[1].b`}},
+		{`$.*`, []string{`$.a`, `$.a[0]`, `$.a[1]`, `$.a[1].b`, `$.c`, `$.c.d`}},
+		{`$**.b`, []string{`$.a[1].b`}},
+		{`$.c[0]`, []string{`$.c`, `$.c.d`}},
+		{`$.x`, nil},
+	}
+
+	for _, tc := range cases {
+		var walked []string
+		path(t, tc.JP).Walk(json(t, Document1), func(p *Path, _ *Value) {
+			walked = append(walked, p.String())
+		})
+		if !slices.Equal(tc.Expected, walked) {
+			t.Errorf("'%s' = %v (expected %v)", tc.JP, walked, tc.Expected)
+		}
+	}
+}
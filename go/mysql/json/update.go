@@ -72,18 +72,16 @@ func (v *Value) SetArrayItem(idx int, value *Value, t Transformation) {
 		return
 	}
 	switch t {
-	case Insert:
+	case Set, Replace:
 		if idx < len(v.a) {
+			v.a[idx] = value
 			return
 		}
-		fallthrough
-	case Set:
-		for idx >= len(v.a) {
-			v.a = append(v.a, ValueNull)
-		}
 	}
-	if idx < len(v.a) {
-		v.a[idx] = value
+	// Like MySQL, setting or inserting past the end of the array
+	// appends the value instead of padding the array.
+	if t != Replace && idx >= len(v.a) {
+		v.a = append(v.a, value)
 	}
 }
 
@@ -96,3 +94,24 @@ func (v *Value) DelArrayItem(n int) {
 	}
 	v.a = append(v.a[:n], v.a[n+1:]...)
 }
+
+// Clone returns a deep copy of v. Only arrays and objects are copied,
+// since scalar values are never modified in place.
+func (v *Value) Clone() *Value {
+	switch v.t {
+	case TypeObject:
+		kvs := make([]kv, 0, len(v.o.kvs))
+		for _, e := range v.o.kvs {
+			kvs = append(kvs, kv{e.k, e.v.Clone()})
+		}
+		return &Value{o: Object{kvs: kvs}, t: TypeObject}
+	case TypeArray:
+		ary := make([]*Value, 0, len(v.a))
+		for _, e := range v.a {
+			ary = append(ary, e.Clone())
+		}
+		return &Value{a: ary, t: TypeArray}
+	default:
+		return v
+	}
+}
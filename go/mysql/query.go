@@ -40,7 +40,7 @@ import (
 // Returns SQLError(CRServerGone) if it can't.
 func (c *Conn) WriteComQuery(query string) error {
 	// This is a new command, need to reset the sequence.
-	c.sequence = 0
+	c.resetSequence()
 
 	data, pos := c.startEphemeralPacketWithHeader(len(query) + 1)
 	data[pos] = ComQuery
@@ -363,7 +363,7 @@ func (c *Conn) ReadQueryResult(maxrows int, wantfields bool) (*sqltypes.Result,
 		return &sqltypes.Result{
 			RowsAffected:        packetOk.affectedRows,
 			InsertID:            packetOk.lastInsertID,
-			SessionStateChanges: packetOk.sessionStateData,
+			SessionStateChanges: packetOk.sessionState.GTIDs,
 			StatusFlags:         packetOk.statusFlags,
 			Info:                packetOk.info,
 		}, more, warnings, nil
@@ -444,7 +444,7 @@ func (c *Conn) ReadQueryResult(maxrows int, wantfields bool) (*sqltypes.Result,
 				}
 				warnings = packetOk.warnings
 				more = (packetOk.statusFlags & ServerMoreResultsExists) != 0
-				result.SessionStateChanges = packetOk.sessionStateData
+				result.SessionStateChanges = packetOk.sessionState.GTIDs
 				result.StatusFlags = packetOk.statusFlags
 				result.Info = packetOk.info
 			}
@@ -529,8 +529,64 @@ func (c *Conn) readComQueryResponse() (int, *PacketOK, error) {
 // Server side methods.
 //
 
-func (c *Conn) parseComQuery(data []byte) string {
-	return string(data[1:])
+// parameterCountAvailable is the COM_STMT_EXECUTE flag telling the
+// parameter count is sent, so query attributes can be attached to
+// statements without parameters.
+const parameterCountAvailable = 0x08
+
+func (c *Conn) parseComQuery(data []byte) (string, map[string]string, error) {
+	if c.Capabilities&CapabilityClientQueryAttributes == 0 {
+		return string(data[1:]), nil, nil
+	}
+
+	// With CapabilityClientQueryAttributes, the query is preceded by
+	// the query attributes, sent like the parameters of COM_STMT_EXECUTE.
+	payload := data[1:]
+	count, pos, ok := readLenEncInt(payload, 0)
+	if !ok {
+		return "", nil, NewSQLError(CRMalformedPacket, SSUnknownSQLState, "reading query attribute count failed")
+	}
+	// parameter_set_count, always 1.
+	_, pos, ok = readLenEncInt(payload, pos)
+	if !ok {
+		return "", nil, NewSQLError(CRMalformedPacket, SSUnknownSQLState, "reading query attribute set count failed")
+	}
+	if count > uint64(len(payload)) {
+		return "", nil, NewSQLError(CRMalformedPacket, SSUnknownSQLState, "invalid query attribute count: %v", count)
+	}
+
+	var attributes map[string]string
+	if count > 0 {
+		var bitMap []byte
+		bitMap, pos, ok = readBytes(payload, pos, (int(count)+7)/8)
+		if !ok {
+			return "", nil, NewSQLError(CRMalformedPacket, SSUnknownSQLState, "reading NULL-bitmap failed")
+		}
+		// new_params_bind_flag, always 1.
+		_, pos, ok = readByte(payload, pos)
+		if !ok {
+			return "", nil, NewSQLError(CRMalformedPacket, SSUnknownSQLState, "reading new parameters bound flag failed")
+		}
+
+		names := make([]string, count)
+		types := make([]querypb.Type, count)
+		var err error
+		for i := range names {
+			types[i], pos, err = parseStmtParamType(payload, pos)
+			if err != nil {
+				return "", nil, err
+			}
+			names[i], pos, ok = readLenEncString(payload, pos)
+			if !ok {
+				return "", nil, NewSQLError(CRMalformedPacket, SSUnknownSQLState, "reading query attribute name failed")
+			}
+		}
+		attributes, pos, err = c.parseQueryAttributeValues(payload, pos, bitMap, 0, names, types)
+		if err != nil {
+			return "", nil, err
+		}
+	}
+	return string(payload[pos:]), attributes, nil
 }
 
 func (c *Conn) parseComSetOption(data []byte) (uint16, bool) {
@@ -542,7 +598,10 @@ func (c *Conn) parseComPrepare(data []byte) string {
 	return string(data[1:])
 }
 
-func (c *Conn) parseComStmtExecute(prepareData map[uint32]*PrepareData, data []byte) (uint32, byte, error) {
+// parseComStmtExecute parses a COM_STMT_EXECUTE packet, and sets the
+// parameters of the prepared statement. It returns the statement ID,
+// the cursor type flags, and the query attributes if any.
+func (c *Conn) parseComStmtExecute(prepareData map[uint32]*PrepareData, data []byte) (uint32, byte, map[string]string, error) {
 	pos := 0
 	payload := data[1:]
 	bitMap := make([]byte, 0)
@@ -550,56 +609,77 @@ func (c *Conn) parseComStmtExecute(prepareData map[uint32]*PrepareData, data []b
 	// statement ID
 	stmtID, pos, ok := readUint32(payload, 0)
 	if !ok {
-		return 0, 0, NewSQLError(CRMalformedPacket, SSUnknownSQLState, "reading statement ID failed")
+		return 0, 0, nil, NewSQLError(CRMalformedPacket, SSUnknownSQLState, "reading statement ID failed")
 	}
 	prepare, ok := prepareData[stmtID]
 	if !ok {
-		return 0, 0, NewSQLError(CRCommandsOutOfSync, SSUnknownSQLState, "statement ID is not found from record")
+		return 0, 0, nil, NewSQLError(CRCommandsOutOfSync, SSUnknownSQLState, "statement ID is not found from record")
 	}
 
 	// cursor type flags
 	cursorType, pos, ok := readByte(payload, pos)
 	if !ok {
-		return stmtID, 0, NewSQLError(CRMalformedPacket, SSUnknownSQLState, "reading cursor type flags failed")
+		return stmtID, 0, nil, NewSQLError(CRMalformedPacket, SSUnknownSQLState, "reading cursor type flags failed")
 	}
 
 	// iteration count
 	iterCount, pos, ok := readUint32(payload, pos)
 	if !ok {
-		return stmtID, 0, NewSQLError(CRMalformedPacket, SSUnknownSQLState, "reading iteration count failed")
+		return stmtID, 0, nil, NewSQLError(CRMalformedPacket, SSUnknownSQLState, "reading iteration count failed")
 	}
 	if iterCount != uint32(1) {
-		return stmtID, 0, NewSQLError(CRMalformedPacket, SSUnknownSQLState, "iteration count is not equal to 1")
+		return stmtID, 0, nil, NewSQLError(CRMalformedPacket, SSUnknownSQLState, "iteration count is not equal to 1")
 	}
 
-	if prepare.ParamsCount > 0 {
-		bitMap, pos, ok = readBytes(payload, pos, (int(prepare.ParamsCount)+7)/8)
+	// With CapabilityClientQueryAttributes, the parameter count includes
+	// the query attributes, which are sent after the parameters.
+	paramsCount := int(prepare.ParamsCount)
+	attributesCount := 0
+	queryAttributes := c.Capabilities&CapabilityClientQueryAttributes != 0
+	if queryAttributes && (paramsCount > 0 || cursorType&parameterCountAvailable != 0) {
+		var count uint64
+		count, pos, ok = readLenEncInt(payload, pos)
 		if !ok {
-			return stmtID, 0, NewSQLError(CRMalformedPacket, SSUnknownSQLState, "reading NULL-bitmap failed")
+			return stmtID, 0, nil, NewSQLError(CRMalformedPacket, SSUnknownSQLState, "reading parameter count failed")
+		}
+		if count < uint64(paramsCount) || count > uint64(len(payload)) {
+			return stmtID, 0, nil, NewSQLError(CRMalformedPacket, SSUnknownSQLState, "invalid parameter count: %v", count)
 		}
+		attributesCount = int(count) - paramsCount
 	}
 
+	if paramsCount+attributesCount > 0 {
+		bitMap, pos, ok = readBytes(payload, pos, (paramsCount+attributesCount+7)/8)
+		if !ok {
+			return stmtID, 0, nil, NewSQLError(CRMalformedPacket, SSUnknownSQLState, "reading NULL-bitmap failed")
+		}
+	}
+
+	var attributeNames []string
+	var attributeTypes []querypb.Type
 	newParamsBoundFlag, pos, ok := readByte(payload, pos)
 	if ok && newParamsBoundFlag == 0x01 {
-		var mysqlType, flags byte
-		for i := uint16(0); i < prepare.ParamsCount; i++ {
-			mysqlType, pos, ok = readByte(payload, pos)
-			if !ok {
-				return stmtID, 0, NewSQLError(CRMalformedPacket, SSUnknownSQLState, "reading parameter type failed")
+		var valType querypb.Type
+		var name string
+		var err error
+		for i := 0; i < paramsCount+attributesCount; i++ {
+			valType, pos, err = parseStmtParamType(payload, pos)
+			if err != nil {
+				return stmtID, 0, nil, err
 			}
-
-			flags, pos, ok = readByte(payload, pos)
-			if !ok {
-				return stmtID, 0, NewSQLError(CRMalformedPacket, SSUnknownSQLState, "reading parameter flags failed")
+			if queryAttributes {
+				name, pos, ok = readLenEncString(payload, pos)
+				if !ok {
+					return stmtID, 0, nil, NewSQLError(CRMalformedPacket, SSUnknownSQLState, "reading parameter name failed")
+				}
 			}
 
-			// convert MySQL type to internal type.
-			valType, err := sqltypes.MySQLToType(int64(mysqlType), int64(flags))
-			if err != nil {
-				return stmtID, 0, NewSQLError(CRMalformedPacket, SSUnknownSQLState, "MySQLToType(%v,%v) failed: %v", mysqlType, flags, err)
+			if i < paramsCount {
+				prepare.ParamsType[i] = int32(valType)
+			} else {
+				attributeNames = append(attributeNames, name)
+				attributeTypes = append(attributeTypes, valType)
 			}
-
-			prepare.ParamsType[i] = int32(valType)
 		}
 	}
 
@@ -618,13 +698,66 @@ func (c *Conn) parseComStmtExecute(prepareData map[uint32]*PrepareData, data []b
 			val, pos, ok = c.parseStmtArgs(payload, querypb.Type(prepare.ParamsType[i]), pos)
 		}
 		if !ok {
-			return stmtID, 0, NewSQLError(CRMalformedPacket, SSUnknownSQLState, "decoding parameter value failed: %v", prepare.ParamsType[i])
+			return stmtID, 0, nil, NewSQLError(CRMalformedPacket, SSUnknownSQLState, "decoding parameter value failed: %v", prepare.ParamsType[i])
 		}
 
 		prepare.BindVars[parameterID] = sqltypes.ValueBindVariable(val)
 	}
 
-	return stmtID, cursorType, nil
+	// The types of the query attributes are only known if they were
+	// sent with this packet. Otherwise, their values can't be decoded,
+	// and are ignored.
+	var attributes map[string]string
+	if len(attributeNames) > 0 {
+		var err error
+		attributes, _, err = c.parseQueryAttributeValues(payload, pos, bitMap, paramsCount, attributeNames, attributeTypes)
+		if err != nil {
+			return stmtID, 0, nil, err
+		}
+	}
+
+	return stmtID, cursorType, attributes, nil
+}
+
+// parseStmtParamType reads the type and flags of a parameter or a
+// query attribute, and converts them to the internal type.
+func parseStmtParamType(payload []byte, pos int) (querypb.Type, int, error) {
+	mysqlType, pos, ok := readByte(payload, pos)
+	if !ok {
+		return 0, 0, NewSQLError(CRMalformedPacket, SSUnknownSQLState, "reading parameter type failed")
+	}
+
+	flags, pos, ok := readByte(payload, pos)
+	if !ok {
+		return 0, 0, NewSQLError(CRMalformedPacket, SSUnknownSQLState, "reading parameter flags failed")
+	}
+
+	// convert MySQL type to internal type.
+	valType, err := sqltypes.MySQLToType(int64(mysqlType), int64(flags))
+	if err != nil {
+		return 0, 0, NewSQLError(CRMalformedPacket, SSUnknownSQLState, "MySQLToType(%v,%v) failed: %v", mysqlType, flags, err)
+	}
+	return valType, pos, nil
+}
+
+// parseQueryAttributeValues decodes the values of the query attributes
+// described by names and types. first is the position of the first
+// query attribute in the NULL-bitmap. NULL query attributes are skipped.
+func (c *Conn) parseQueryAttributeValues(payload []byte, pos int, bitMap []byte, first int, names []string, types []querypb.Type) (map[string]string, int, error) {
+	attributes := make(map[string]string, len(names))
+	for i, name := range names {
+		if (bitMap[(first+i)/8] & (1 << uint((first+i)%8))) > 0 {
+			continue
+		}
+		var val sqltypes.Value
+		var ok bool
+		val, pos, ok = c.parseStmtArgs(payload, types[i], pos)
+		if !ok {
+			return nil, 0, NewSQLError(CRMalformedPacket, SSUnknownSQLState, "decoding query attribute %v failed: %v", name, types[i])
+		}
+		attributes[name] = val.ToString()
+	}
+	return attributes, pos, nil
 }
 
 func (c *Conn) parseStmtArgs(data []byte, typ querypb.Type, pos int) (sqltypes.Value, int, bool) {
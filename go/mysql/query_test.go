@@ -242,7 +242,7 @@ func TestComStmtExecute(t *testing.T) {
 	// This is simulated packets for `select * from test_table where id = ?`
 	data := []byte{23, 18, 0, 0, 0, 128, 1, 0, 0, 0, 0, 1, 1, 128, 1}
 
-	stmtID, _, err := sConn.parseComStmtExecute(cConn.PrepareData, data)
+	stmtID, _, _, err := sConn.parseComStmtExecute(cConn.PrepareData, data)
 	require.NoError(t, err, "parseComStmtExeute failed: %v", err)
 	require.Equal(t, uint32(18), stmtID, "Parsed incorrect values")
 
@@ -286,7 +286,7 @@ func TestComStmtExecuteUpdStmt(t *testing.T) {
 		0x35, 0x36, 0x37, 0x38, 0x0c, 0xe9, 0x9f, 0xa9, 0xe5, 0x86, 0xac, 0xe7, 0x9c, 0x9f, 0xe8, 0xb5,
 		0x9e, 0x03, 0x66, 0x6f, 0x6f, 0x07, 0x66, 0x6f, 0x6f, 0x2c, 0x62, 0x61, 0x72}
 
-	stmtID, _, err := sConn.parseComStmtExecute(prepareDataMap, data[4:]) // first 4 are header
+	stmtID, _, _, err := sConn.parseComStmtExecute(prepareDataMap, data[4:]) // first 4 are header
 	require.NoError(t, err)
 	require.EqualValues(t, 1, stmtID)
 
@@ -325,6 +325,120 @@ func TestComStmtExecuteUpdStmt(t *testing.T) {
 	assert.EqualValues(t, querypb.Type_CHAR, prepData.ParamsType[28], "got: %s", querypb.Type(prepData.ParamsType[28]))
 }
 
+func TestComQueryAttributes(t *testing.T) {
+	listener, sConn, cConn := createSocketPair(t)
+	defer func() {
+		listener.Close()
+		sConn.Close()
+		cConn.Close()
+	}()
+
+	// Without CapabilityClientQueryAttributes, the payload is the query.
+	query, attributes, err := sConn.parseComQuery(append([]byte{ComQuery}, "select 1"...))
+	require.NoError(t, err)
+	assert.Equal(t, "select 1", query)
+	assert.Nil(t, attributes)
+
+	sConn.Capabilities |= CapabilityClientQueryAttributes
+	data := []byte{
+		ComQuery,
+		0x03,       // parameter count
+		0x01,       // parameter set count
+		0x02,       // NULL-bitmap: the second attribute is NULL
+		0x01,       // new parameters bound flag
+		0xfd, 0x00, // VAR_STRING
+		0x08, 't', 'r', 'a', 'c', 'e', '_', 'i', 'd',
+		0x06, 0x00, // NULL
+		0x01, 'n',
+		0x08, 0x00, // LONGLONG
+		0x04, 'p', 'r', 'i', 'o',
+		0x03, 'a', 'b', 'c',
+		0x05, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+	}
+	data = append(data, "select 1"...)
+	query, attributes, err = sConn.parseComQuery(data)
+	require.NoError(t, err)
+	assert.Equal(t, "select 1", query)
+	assert.Equal(t, map[string]string{"trace_id": "abc", "prio": "5"}, attributes)
+
+	// Clients always send the counts, even without query attributes.
+	query, attributes, err = sConn.parseComQuery(append([]byte{ComQuery, 0x00, 0x01}, "select 1"...))
+	require.NoError(t, err)
+	assert.Equal(t, "select 1", query)
+	assert.Nil(t, attributes)
+
+	_, _, err = sConn.parseComQuery([]byte{ComQuery, 0x01, 0x01, 0x00, 0x01, 0xfd, 0x00, 0x08, 't'})
+	assert.ErrorContains(t, err, "reading query attribute name failed")
+}
+
+func TestComStmtExecuteQueryAttributes(t *testing.T) {
+	listener, sConn, cConn := createSocketPair(t)
+	defer func() {
+		listener.Close()
+		sConn.Close()
+		cConn.Close()
+	}()
+	sConn.Capabilities |= CapabilityClientQueryAttributes
+
+	prepareDataMap := map[uint32]*PrepareData{
+		18: {
+			StatementID: 18,
+			ParamsCount: 1,
+			ParamsType:  make([]int32, 1),
+			BindVars:    map[string]*querypb.BindVariable{},
+		},
+		19: {
+			StatementID: 19,
+			BindVars:    map[string]*querypb.BindVariable{},
+		},
+	}
+
+	// One parameter, followed by one query attribute.
+	data := []byte{
+		ComStmtExecute,
+		18, 0, 0, 0, // statement ID
+		0x00,       // cursor type
+		1, 0, 0, 0, // iteration count
+		0x02,       // parameter count
+		0x00,       // NULL-bitmap
+		0x01,       // new parameters bound flag
+		0x08, 0x00, // LONGLONG
+		0x00,       // unnamed parameter
+		0xfd, 0x00, // VAR_STRING
+		0x08, 'w', 'o', 'r', 'k', 'l', 'o', 'a', 'd',
+		0x2a, 0, 0, 0, 0, 0, 0, 0,
+		0x05, 'b', 'a', 't', 'c', 'h',
+	}
+	stmtID, _, attributes, err := sConn.parseComStmtExecute(prepareDataMap, data)
+	require.NoError(t, err)
+	require.EqualValues(t, 18, stmtID)
+	assert.Equal(t, map[string]string{"workload": "batch"}, attributes)
+	assert.Equal(t, sqltypes.Int64BindVariable(42), prepareDataMap[18].BindVars["v1"])
+
+	// A statement without parameters, with query attributes.
+	data = []byte{
+		ComStmtExecute,
+		19, 0, 0, 0, // statement ID
+		parameterCountAvailable, // cursor type
+		1, 0, 0, 0,              // iteration count
+		0x01,       // parameter count
+		0x00,       // NULL-bitmap
+		0x01,       // new parameters bound flag
+		0xfd, 0x00, // VAR_STRING
+		0x02, 'i', 'd',
+		0x01, 'x',
+	}
+	stmtID, _, attributes, err = sConn.parseComStmtExecute(prepareDataMap, data)
+	require.NoError(t, err)
+	require.EqualValues(t, 19, stmtID)
+	assert.Equal(t, map[string]string{"id": "x"}, attributes)
+
+	// The parameter count can't be lower than the number of parameters.
+	data = []byte{ComStmtExecute, 18, 0, 0, 0, 0x00, 1, 0, 0, 0, 0x00}
+	_, _, _, err = sConn.parseComStmtExecute(prepareDataMap, data)
+	assert.ErrorContains(t, err, "invalid parameter count: 0")
+}
+
 func TestComStmtClose(t *testing.T) {
 	listener, sConn, cConn := createSocketPair(t)
 	defer func() {
@@ -32,7 +32,7 @@ const (
 // See http://dev.mysql.com/doc/internals/en/com-binlog-dump.html for syntax.
 // Returns a SQLError.
 func (c *Conn) WriteComBinlogDump(serverID uint32, binlogFilename string, binlogPos uint32, flags uint16) error {
-	c.sequence = 0
+	c.resetSequence()
 	length := 1 + // ComBinlogDump
 		4 + // binlog-pos
 		2 + // flags
@@ -73,7 +73,7 @@ func (c *Conn) AnalyzeSemiSyncAckRequest(buf []byte) (strippedBuf []byte, ackReq
 // Only works with MySQL 5.6+ (and not MariaDB).
 // See http://dev.mysql.com/doc/internals/en/com-binlog-dump-gtid.html for syntax.
 func (c *Conn) WriteComBinlogDumpGTID(serverID uint32, binlogFilename string, binlogPos uint64, flags uint16, gtidSet []byte) error {
-	c.sequence = 0
+	c.resetSequence()
 	length := 1 + // ComBinlogDumpGTID
 		2 + // flags
 		4 + // server-id
@@ -101,7 +101,7 @@ func (c *Conn) WriteComBinlogDumpGTID(serverID uint32, binlogFilename string, bi
 // the source has tagged with a SEMI_SYNC_ACK_REQ
 // see https://dev.mysql.com/doc/internals/en/semi-sync-ack-packet.html
 func (c *Conn) SendSemiSyncAck(binlogFilename string, binlogPos uint64) error {
-	c.sequence = 0
+	c.resetSequence()
 	length := 1 + // ComSemiSyncAck
 		8 + // binlog-pos
 		len(binlogFilename) // binlog-filename
@@ -96,6 +96,22 @@ from %s.schemacopy
 where table_schema = database()
 order by table_name, ordinal_position`
 
+	// fetchUniqueKeyColumns are the columns we fetch for the primary and unique keys of tables
+	fetchUniqueKeyColumns = "table_name, index_name, column_name"
+
+	// FetchUpdatedUniqueKeys queries fetches the primary and unique keys of updated tables
+	FetchUpdatedUniqueKeys = `select ` + fetchUniqueKeyColumns + `
+from information_schema.statistics
+where table_schema = database() and non_unique = 0 and
+	table_name in ::tableNames
+order by table_name, index_name, seq_in_index`
+
+	// FetchUniqueKeys queries fetches the primary and unique keys of all tables
+	FetchUniqueKeys = `select ` + fetchUniqueKeyColumns + `
+from information_schema.statistics
+where table_schema = database() and non_unique = 0
+order by table_name, index_name, seq_in_index`
+
 	// GetColumnNamesQueryPatternForTable is used for mocking queries in unit tests
 	GetColumnNamesQueryPatternForTable = `SELECT COLUMN_NAME.*TABLE_NAME.*%s.*`
 )
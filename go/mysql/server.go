@@ -130,6 +130,11 @@ type Handler interface {
 	WarningCount(c *Conn) uint16
 
 	ComResetConnection(c *Conn)
+
+	// ComChangeUser is called when a connection was authenticated as
+	// another user with COM_CHANGE_USER, which has to reset the session.
+	// c.User and c.UserData are the ones of the new user.
+	ComChangeUser(c *Conn)
 }
 
 // UnimplementedHandler implemnts all of the optional callbacks so as to satisy
@@ -142,6 +147,7 @@ func (UnimplementedHandler) NewConnection(*Conn)      {}
 func (UnimplementedHandler) ConnectionReady(*Conn)    {}
 func (UnimplementedHandler) ConnectionClosed(*Conn)   {}
 func (UnimplementedHandler) ComResetConnection(*Conn) {}
+func (UnimplementedHandler) ComChangeUser(*Conn)      {}
 
 // Listener is the MySQL server protocol listener.
 type Listener struct {
@@ -178,6 +184,11 @@ type Listener struct {
 	// beyond which a warning is logged to identify the slow connection
 	SlowConnectWarnThreshold atomic.Int64
 
+	// CompressionAlgorithms are the protocol compression algorithms
+	// we will advertise, CompressionZlib and / or CompressionZstd.
+	// Clients that ask for one of them get a compressed connection.
+	CompressionAlgorithms []string
+
 	// The following parameters are changed by the Accept routine.
 
 	// Incrementing ID for connection id.
@@ -431,62 +442,9 @@ func (l *Listener) handle(conn net.Conn, connectionID uint32, acceptTime time.Ti
 		defer connCountByTLSVer.Add(versionNoTLS, -1)
 	}
 
-	// See what auth method the AuthServer wants to use for that user.
-	negotiatedAuthMethod, err := negotiateAuthMethod(c, l.authServer, user, clientAuthMethod)
-
-	// We need to send down an additional packet if we either have no negotiated method
-	// at all or incomplete authentication data.
-	//
-	// The latter case happens for example for MySQL 8.0 clients until 8.0.25 who advertise
-	// support for caching_sha2_password by default but with no plugin data.
-	if err != nil || len(clientAuthResponse) == 0 {
-		// If we have no negotiated method yet, we pick the first one
-		// we know about ourselves as that's the last resort option we have here.
-		if err != nil {
-			// The client will disconnect if it doesn't understand
-			// the first auth method that we send, so we only have to send the
-			// first one that we allow for the user.
-			for _, m := range l.authServer.AuthMethods() {
-				if m.HandleUser(c, user) {
-					negotiatedAuthMethod = m
-					break
-				}
-			}
-		}
-
-		if negotiatedAuthMethod == nil {
-			c.writeErrorPacket(CRServerHandshakeErr, SSUnknownSQLState, "No authentication methods available for authentication.")
-			return
-		}
-
-		if !l.AllowClearTextWithoutTLS.Load() && !c.TLSEnabled() && !negotiatedAuthMethod.AllowClearTextWithoutTLS() {
-			c.writeErrorPacket(CRServerHandshakeErr, SSUnknownSQLState, "Cannot use clear text authentication over non-SSL connections.")
-			return
-		}
-
-		serverAuthPluginData, err = negotiatedAuthMethod.AuthPluginData()
-		if err != nil {
-			log.Errorf("Error generating auth switch packet for %s: %v", c, err)
-			return
-		}
-
-		if err := c.writeAuthSwitchRequest(string(negotiatedAuthMethod.Name()), serverAuthPluginData); err != nil {
-			log.Errorf("Error writing auth switch packet for %s: %v", c, err)
-			return
-		}
-
-		clientAuthResponse, err = c.readEphemeralPacket()
-		if err != nil {
-			log.Errorf("Error reading auth switch response for %s: %v", c, err)
-			return
-		}
-		c.recycleReadPacket()
-	}
-
-	userData, err := negotiatedAuthMethod.HandleAuthPluginData(c, user, serverAuthPluginData, clientAuthResponse, conn.RemoteAddr())
-	if err != nil {
-		log.Warningf("Error authenticating user %s using: %s", user, negotiatedAuthMethod.Name())
-		c.writeErrorPacketFromError(err)
+	c.salt = serverAuthPluginData
+	userData, ok := l.authenticate(c, user, clientAuthMethod, clientAuthResponse)
+	if !ok {
 		return
 	}
 
@@ -495,8 +453,13 @@ func (l *Listener) handle(conn net.Conn, connectionID uint32, acceptTime time.Ti
 
 	if c.User != "" {
 		connCountPerUser.Add(c.User, 1)
-		defer connCountPerUser.Add(c.User, -1)
 	}
+	defer func() {
+		// The user may have changed with COM_CHANGE_USER since.
+		if c.User != "" {
+			connCountPerUser.Add(c.User, -1)
+		}
+	}()
 
 	// Set initial db name.
 	if c.schemaName != "" {
@@ -515,6 +478,9 @@ func (l *Listener) handle(conn net.Conn, connectionID uint32, acceptTime time.Ti
 		return
 	}
 
+	// The packets following the OK packet use the compressed protocol, if negotiated.
+	c.startCompression()
+
 	// Record how long we took to establish the connection
 	timings.Record(connectTimingKey, acceptTime)
 
@@ -551,6 +517,81 @@ func (l *Listener) Shutdown() {
 	}
 }
 
+// authenticate authenticates user on the connection, and returns its user data.
+// clientAuthMethod and clientAuthResponse are what the client sent in response
+// to c.salt. If clientAuthResponse is empty, the client is sent an auth switch
+// request with new auth plugin data, which becomes c.salt. It is used by the
+// initial handshake and by COM_CHANGE_USER.
+// If authentication fails, it writes the error packet, if any, and returns false.
+func (l *Listener) authenticate(c *Conn, user string, clientAuthMethod AuthMethodDescription, clientAuthResponse []byte) (Getter, bool) {
+	serverAuthPluginData := c.salt
+
+	// See what auth method the AuthServer wants to use for that user.
+	negotiatedAuthMethod, err := negotiateAuthMethod(c, l.authServer, user, clientAuthMethod)
+
+	// We need to send down an additional packet if we either have no negotiated method
+	// at all or incomplete authentication data.
+	//
+	// The latter case happens for example for MySQL 8.0 clients until 8.0.25 who advertise
+	// support for caching_sha2_password by default but with no plugin data.
+	if err != nil || len(clientAuthResponse) == 0 {
+		// If we have no negotiated method yet, we pick the first one
+		// we know about ourselves as that's the last resort option we have here.
+		if err != nil {
+			// The client will disconnect if it doesn't understand
+			// the first auth method that we send, so we only have to send the
+			// first one that we allow for the user.
+			for _, m := range l.authServer.AuthMethods() {
+				if m.HandleUser(c, user) {
+					negotiatedAuthMethod = m
+					break
+				}
+			}
+		}
+
+		if negotiatedAuthMethod == nil {
+			c.writeErrorPacket(CRServerHandshakeErr, SSUnknownSQLState, "No authentication methods available for authentication.")
+			return nil, false
+		}
+
+		if !l.AllowClearTextWithoutTLS.Load() && !c.TLSEnabled() && !negotiatedAuthMethod.AllowClearTextWithoutTLS() {
+			c.writeErrorPacket(CRServerHandshakeErr, SSUnknownSQLState, "Cannot use clear text authentication over non-SSL connections.")
+			return nil, false
+		}
+
+		serverAuthPluginData, err = negotiatedAuthMethod.AuthPluginData()
+		if err != nil {
+			log.Errorf("Error generating auth switch packet for %s: %v", c, err)
+			return nil, false
+		}
+
+		if err := c.writeAuthSwitchRequest(string(negotiatedAuthMethod.Name()), serverAuthPluginData); err != nil {
+			log.Errorf("Error writing auth switch packet for %s: %v", c, err)
+			return nil, false
+		}
+		if len(serverAuthPluginData) > 0 {
+			// The client scrambles the password of a later
+			// COM_CHANGE_USER with the latest salt it got.
+			c.salt = serverAuthPluginData
+		}
+
+		clientAuthResponse, err = c.readEphemeralPacket()
+		if err != nil {
+			log.Errorf("Error reading auth switch response for %s: %v", c, err)
+			return nil, false
+		}
+		c.recycleReadPacket()
+	}
+
+	userData, err := negotiatedAuthMethod.HandleAuthPluginData(c, user, serverAuthPluginData, clientAuthResponse, c.RemoteAddr())
+	if err != nil {
+		log.Warningf("Error authenticating user %s using: %s", user, negotiatedAuthMethod.Name())
+		c.writeErrorPacketFromError(err)
+		return nil, false
+	}
+	return userData, true
+}
+
 // writeHandshakeV10 writes the Initial Handshake Packet, server side.
 // It returns the salt data.
 func (c *Conn) writeHandshakeV10(serverVersion string, authServer AuthServer, enableTLS bool) ([]byte, error) {
@@ -566,7 +607,10 @@ func (c *Conn) writeHandshakeV10(serverVersion string, authServer AuthServer, en
 		CapabilityClientPluginAuth |
 		CapabilityClientPluginAuthLenencClientData |
 		CapabilityClientDeprecateEOF |
-		CapabilityClientConnAttr
+		CapabilityClientConnAttr |
+		CapabilityClientQueryAttributes |
+		CapabilityClientSessionTrack |
+		c.listener.compressionCapabilities()
 	if enableTLS {
 		capabilities |= CapabilityClientSSL
 	}
@@ -687,7 +731,7 @@ func (l *Listener) parseClientHandshakePacket(c *Conn, firstTime bool, data []by
 	// later in the protocol. If we re-received the handshake packet
 	// after SSL negotiation, do not overwrite capabilities.
 	if firstTime {
-		c.Capabilities = clientFlags & (CapabilityClientDeprecateEOF | CapabilityClientFoundRows)
+		c.Capabilities = clientFlags & (CapabilityClientDeprecateEOF | CapabilityClientFoundRows | CapabilityClientQueryAttributes | CapabilityClientSessionTrack)
 	}
 
 	// set connection capability for executing multi statements
@@ -786,15 +830,87 @@ func (l *Listener) parseClientHandshakePacket(c *Conn, firstTime bool, data []by
 	}
 
 	// Decode connection attributes send by the client
+	attrsRead := true
 	if clientFlags&CapabilityClientConnAttr != 0 {
-		if _, _, err := parseConnAttrs(data, pos); err != nil {
+		var err error
+		if _, pos, err = parseConnAttrs(data, pos); err != nil {
 			log.Warningf("Decode connection attributes send by the client: %v", err)
+			attrsRead = false
+		}
+	}
+
+	// Protocol compression. zlib wins if the client asks for both.
+	compression := clientFlags & l.compressionCapabilities()
+	switch {
+	case compression&CapabilityClientCompress != 0:
+		c.Capabilities |= CapabilityClientCompress
+	case compression&CapabilityClientZstdCompressionAlgorithm != 0:
+		c.Capabilities |= CapabilityClientZstdCompressionAlgorithm
+		// The zstd compression level comes last. We can't find it
+		// if we couldn't parse the connection attributes.
+		c.zstdCompressionLevel = DefaultZstdCompressionLevel
+		if level, _, ok := readByte(data, pos); ok && attrsRead {
+			if level < 1 || level > 22 {
+				return "", "", nil, vterrors.Errorf(vtrpc.Code_INVALID_ARGUMENT, "parseClientHandshakePacket: invalid zstd compression level %v", level)
+			}
+			c.zstdCompressionLevel = int(level)
 		}
 	}
 
 	return username, AuthMethodDescription(authMethod), authResponse, nil
 }
 
+// compressionCapabilities returns the capability flags of the
+// protocol compression algorithms supported by the listener.
+func (l *Listener) compressionCapabilities() uint32 {
+	var capabilities uint32
+	for _, algorithm := range l.CompressionAlgorithms {
+		capabilities |= compressionCapability(algorithm)
+	}
+	return capabilities
+}
+
+// parseComChangeUser parses the COM_CHANGE_USER packet sent by the client.
+// Returns the username, auth method, auth data, db name, character set, error.
+// Like for the handshake, we only support clients using the protocol 4.1 and
+// CapabilityClientSecureConnection, so the auth data is length encoded.
+// The original data is not pointed at, and can be freed.
+func (c *Conn) parseComChangeUser(data []byte) (string, AuthMethodDescription, []byte, string, collations.ID, error) {
+	pos := 1
+
+	username, pos, ok := readNullString(data, pos)
+	if !ok {
+		return "", "", nil, "", 0, NewSQLError(CRMalformedPacket, SSUnknownSQLState, "parseComChangeUser: can't read username")
+	}
+
+	l, pos, ok := readByte(data, pos)
+	if !ok {
+		return "", "", nil, "", 0, NewSQLError(CRMalformedPacket, SSUnknownSQLState, "parseComChangeUser: can't read auth-response length")
+	}
+	authResponse, pos, ok := readBytesCopy(data, pos, int(l))
+	if !ok {
+		return "", "", nil, "", 0, NewSQLError(CRMalformedPacket, SSUnknownSQLState, "parseComChangeUser: can't read auth-response")
+	}
+
+	dbname, pos, ok := readNullString(data, pos)
+	if !ok {
+		return "", "", nil, "", 0, NewSQLError(CRMalformedPacket, SSUnknownSQLState, "parseComChangeUser: can't read dbname")
+	}
+
+	// The rest is optional: old clients stop here.
+	authMethod := MysqlNativePassword
+	characterSet, pos, ok := readUint16(data, pos)
+	if !ok {
+		return username, authMethod, authResponse, dbname, 0, nil
+	}
+	if authMethodStr, _, ok := readNullString(data, pos); ok && authMethodStr != "" {
+		authMethod = AuthMethodDescription(authMethodStr)
+	}
+
+	// We ignore the connection attributes, like the handshake does.
+	return username, authMethod, authResponse, dbname, collations.ID(characterSet), nil
+}
+
 func parseConnAttrs(data []byte, pos int) (map[string]string, int, error) {
 	var attrLen uint64
 
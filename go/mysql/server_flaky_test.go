@@ -164,6 +164,27 @@ func (th *testHandler) ComQuery(c *Conn, query string, callback func(*sqltypes.R
 				},
 			},
 		})
+	case "compression echo":
+		value := "OFF"
+		if c.compression != nil {
+			value = c.compression.algorithm
+			if value == CompressionZstd {
+				value = fmt.Sprintf("%v:%v", value, c.zstdCompressionLevel)
+			}
+		}
+		callback(&sqltypes.Result{
+			Fields: []*querypb.Field{
+				{
+					Name: "compression",
+					Type: querypb.Type_VARCHAR,
+				},
+			},
+			Rows: [][]sqltypes.Value{
+				{
+					sqltypes.MakeTrusted(querypb.Type_VARCHAR, []byte(value)),
+				},
+			},
+		})
 	case "userData echo":
 		callback(&sqltypes.Result{
 			Fields: []*querypb.Field{
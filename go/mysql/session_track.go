@@ -0,0 +1,129 @@
+/*
+Copyright 2023 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mysql
+
+import (
+	"sort"
+)
+
+// SessionStateChanges are the changes of the session state reported in the
+// OK packets, with CapabilityClientSessionTrack.
+// See https://dev.mysql.com/doc/dev/mysql-server/latest/page_protocol_basic_ok_packet.html
+type SessionStateChanges struct {
+	// SystemVariables are the system variables that were changed, with
+	// their new value.
+	SystemVariables map[string]string
+
+	// Schema is the new default schema, if it changed.
+	Schema string
+
+	// GTIDs are the GTIDs of the transactions that were committed.
+	GTIDs string
+}
+
+// IsEmpty returns true if there are no session state changes.
+func (s *SessionStateChanges) IsEmpty() bool {
+	return len(s.SystemVariables) == 0 && s.Schema == "" && s.GTIDs == ""
+}
+
+// encode returns the session state information of an OK packet,
+// prefixed with its length.
+func (s *SessionStateChanges) encode() []byte {
+	var data []byte
+	appendChange := func(sscType uint8, value []byte) {
+		data = append(data, sscType)
+		data = append(data, getLenEncString(value)...)
+	}
+
+	// Sort the system variables, so the packets are deterministic.
+	names := make([]string, 0, len(s.SystemVariables))
+	for name := range s.SystemVariables {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		value := getLenEncString([]byte(name))
+		value = append(value, getLenEncString([]byte(s.SystemVariables[name]))...)
+		appendChange(SessionTrackSystemVariables, value)
+	}
+	if s.Schema != "" {
+		appendChange(SessionTrackSchema, getLenEncString([]byte(s.Schema)))
+	}
+	if s.GTIDs != "" {
+		// The GTIDs are preceded by their encoding specification, which
+		// is always 0 for the string representation.
+		appendChange(SessionTrackGtids, append([]byte{0x00}, getLenEncString([]byte(s.GTIDs))...))
+	}
+	return getLenEncString(data)
+}
+
+// parseSessionStateChanges parses the session state information of an OK packet.
+// Unknown session state changes are ignored.
+func parseSessionStateChanges(data *coder) (SessionStateChanges, bool) {
+	var s SessionStateChanges
+	length, ok := data.readLenEncInt()
+	if !ok || length == 0 {
+		// In case we have no more data or a zero length string, there's no
+		// additional information.
+		return s, true
+	}
+	end := data.pos + int(length)
+	if end > len(data.data) {
+		return s, false
+	}
+
+	for data.pos < end {
+		sscType, ok := data.readByte()
+		if !ok {
+			return s, false
+		}
+		value, ok := data.readLenEncString()
+		if !ok {
+			return s, false
+		}
+		change := &coder{data: []byte(value)}
+
+		switch sscType {
+		case SessionTrackSystemVariables:
+			name, ok := change.readLenEncString()
+			if !ok {
+				return s, false
+			}
+			val, ok := change.readLenEncString()
+			if !ok {
+				return s, false
+			}
+			if s.SystemVariables == nil {
+				s.SystemVariables = make(map[string]string)
+			}
+			s.SystemVariables[name] = val
+		case SessionTrackSchema:
+			if s.Schema, ok = change.readLenEncString(); !ok {
+				return s, false
+			}
+		case SessionTrackGtids:
+			// Skip the GTIDs encoding specification.
+			if _, ok = change.readByte(); !ok {
+				return s, false
+			}
+			if s.GTIDs, ok = change.readLenEncString(); !ok {
+				return s, false
+			}
+		}
+	}
+	return s, true
+}
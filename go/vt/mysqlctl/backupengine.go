@@ -120,6 +120,9 @@ type RestoreParams struct {
 	// RestoreToPos hints that a point in time recovery is requested, to recover up to the specific given pos.
 	// When empty, the restore is a normal from full backup
 	RestoreToPos mysql.Position
+	// RestoreToTimestamp hints that a point in time recovery is requested, to recover up to the last
+	// transaction committed at or before the given time. It is mutually exclusive with RestoreToPos.
+	RestoreToTimestamp time.Time
 	// When DryRun is set, no restore actually takes place; but some of its steps are validated.
 	DryRun bool
 	// Stats let's restore engines report detailed restore timings.
@@ -139,13 +142,14 @@ func (p *RestoreParams) Copy() RestoreParams {
 		Shard:               p.Shard,
 		StartTime:           p.StartTime,
 		RestoreToPos:        p.RestoreToPos,
+		RestoreToTimestamp:  p.RestoreToTimestamp,
 		DryRun:              p.DryRun,
 		Stats:               p.Stats,
 	}
 }
 
 func (p *RestoreParams) IsIncrementalRecovery() bool {
-	return !p.RestoreToPos.IsZero()
+	return !p.RestoreToPos.IsZero() || !p.RestoreToTimestamp.IsZero()
 }
 
 // RestoreEngine is the interface to restore a backup with a given engine.
@@ -282,6 +286,14 @@ type BackupManifest struct {
 
 	// UpgradeSafe indicates whether the backup is safe to use for an upgrade to a newer MySQL version
 	UpgradeSafe bool
+
+	// EncryptionKeyProvider is the name of the KeyProvider holding the key
+	// the backup files are encrypted with. It is empty if the backup is not encrypted.
+	EncryptionKeyProvider string `json:",omitempty"`
+
+	// EncryptionKeyID identifies the key the backup files are encrypted
+	// with, within EncryptionKeyProvider.
+	EncryptionKeyID string `json:",omitempty"`
 }
 
 func (m *BackupManifest) HashKey() string {
@@ -453,6 +465,17 @@ func FindBackupToRestore(ctx context.Context, params RestoreParams, bhs []backup
 					// this is the most recent backup which is <= desired position
 					return index
 				}
+			case !params.RestoreToTimestamp.IsZero():
+				// restore to specific timestamp
+				finishedTime, err := bm.finishedTime()
+				if err != nil {
+					params.Logger.Warningf("Restore: skipping backup %v/%v with invalid time %v: %v", backupDir, bh.Name(), bm.FinishedTime, err)
+					continue
+				}
+				if !finishedTime.After(params.RestoreToTimestamp) {
+					// this is the most recent backup which completed at or before the desired time
+					return index
+				}
 			default:
 				// restore latest full backup
 				params.Logger.Infof("Restore: found latest backup %v %v to restore", bh.Directory(), bh.Name())
@@ -475,6 +498,15 @@ func FindBackupToRestore(ctx context.Context, params RestoreParams, bhs []backup
 	restorePath := &RestorePath{
 		manifestHandleMap: manifestHandleMap,
 	}
+	if !params.RestoreToTimestamp.IsZero() {
+		// restore to a timestamp (using incremental backups): the path ends with the first incremental
+		// backup taken after the desired time, whose binary logs are only partially applied.
+		restorePath.manifests, err = FindPITRToTimestampPath(params.RestoreToTimestamp, manifests)
+		if err != nil {
+			return nil, err
+		}
+		return restorePath, nil
+	}
 	if params.RestoreToPos.IsZero() {
 		// restoring from a single full backup:
 		restorePath.Add(manifests[0])
@@ -0,0 +1,152 @@
+/*
+Copyright 2023 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mysqlctl
+
+import (
+	"context"
+	"time"
+
+	"vitess.io/vitess/go/vt/logutil"
+	"vitess.io/vitess/go/vt/mysqlctl/backupstorage"
+)
+
+// BackupRetentionPolicy describes which backups of a shard are kept when
+// pruning. The most recent full backup, along with the incremental backups
+// taken after it, is always kept. So are the backups any kept incremental
+// backup needs in order to be restored.
+type BackupRetentionPolicy struct {
+	// KeepLast is the number of most recent backups to keep.
+	KeepLast int
+
+	// KeepDailyDays keeps the most recent backup of each of the last
+	// KeepDailyDays days (in UTC), today included.
+	KeepDailyDays int
+
+	// PruneIncomplete allows pruning backups without a readable MANIFEST,
+	// as long as they are older than the most recent complete backup.
+	// Newer ones may still be in progress, and are always kept.
+	PruneIncomplete bool
+}
+
+type retentionCandidate struct {
+	bh         backupstorage.BackupHandle
+	manifest   *BackupManifest
+	backupTime time.Time
+}
+
+// FindBackupsToPrune returns the backups of the given list, which is sorted
+// oldest first as returned by BackupStorage.ListBackups, that are not retained
+// by the policy. Nothing is pruned if the list has no complete backup.
+func FindBackupsToPrune(ctx context.Context, logger logutil.Logger, policy BackupRetentionPolicy, bhs []backupstorage.BackupHandle, now time.Time) []backupstorage.BackupHandle {
+	candidates := make([]*retentionCandidate, len(bhs))
+	var manifests []*BackupManifest
+	manifestHandleMap := NewManifestHandleMap()
+	lastComplete := -1
+	for i, bh := range bhs {
+		candidates[i] = &retentionCandidate{bh: bh}
+		bm, err := GetBackupManifest(ctx, bh)
+		if err != nil {
+			logger.Warningf("Possibly incomplete backup %v in directory %v on BackupStorage: can't read MANIFEST: %v", bh.Name(), bh.Directory(), err)
+			continue
+		}
+		candidates[i].manifest = bm
+		candidates[i].backupTime = getBackupTime(bh, bm)
+		if bm.Position.GTIDSet != nil {
+			manifests = append(manifests, bm)
+			manifestHandleMap.Map(bm, bh)
+		}
+		lastComplete = i
+	}
+	if lastComplete < 0 {
+		return nil
+	}
+
+	keep := make(map[string]bool, len(bhs))
+	keepWithChain := func(c *retentionCandidate) {
+		keep[c.bh.Name()] = true
+		if !c.manifest.Incremental || c.manifest.Position.GTIDSet == nil {
+			return
+		}
+		restorePath, err := FindPITRPath(c.manifest.Position.GTIDSet, manifests)
+		if err != nil {
+			logger.Warningf("Cannot find the backups incremental backup %v builds on: %v", c.bh.Name(), err)
+			return
+		}
+		for _, bh := range manifestHandleMap.Handles(restorePath) {
+			if bh != nil {
+				keep[bh.Name()] = true
+			}
+		}
+	}
+
+	// Always keep the most recent full backup, and the incremental backups
+	// taken after it.
+	for i := lastComplete; i >= 0; i-- {
+		if c := candidates[i]; c.manifest != nil && !c.manifest.Incremental {
+			for _, c := range candidates[i:] {
+				if c.manifest != nil {
+					keepWithChain(c)
+				}
+			}
+			break
+		}
+	}
+
+	dailyCutoff := now.UTC().Truncate(24*time.Hour).AddDate(0, 0, 1-policy.KeepDailyDays)
+	keptDays := make(map[time.Time]bool, policy.KeepDailyDays)
+	keptLast := 0
+	for i := lastComplete; i >= 0; i-- {
+		c := candidates[i]
+		if c.manifest == nil {
+			continue
+		}
+		if keptLast < policy.KeepLast {
+			keptLast++
+			keepWithChain(c)
+		}
+		if policy.KeepDailyDays > 0 && !c.backupTime.IsZero() {
+			day := c.backupTime.UTC().Truncate(24 * time.Hour)
+			if !day.Before(dailyCutoff) && !keptDays[day] {
+				keptDays[day] = true
+				keepWithChain(c)
+			}
+		}
+	}
+
+	var prune []backupstorage.BackupHandle
+	for i, c := range candidates {
+		switch {
+		case keep[c.bh.Name()]:
+		case c.manifest == nil && (!policy.PruneIncomplete || i > lastComplete):
+		default:
+			prune = append(prune, c.bh)
+		}
+	}
+	return prune
+}
+
+// getBackupTime returns when the backup was taken, based on its MANIFEST or,
+// failing that, its name. It returns the zero time if neither can be parsed.
+func getBackupTime(bh backupstorage.BackupHandle, bm *BackupManifest) time.Time {
+	if t, err := time.Parse(time.RFC3339, bm.BackupTime); err == nil {
+		return t
+	}
+	if t, _, err := ParseBackupName(bh.Directory(), bh.Name()); err == nil && t != nil {
+		return *t
+	}
+	return time.Time{}
+}
@@ -0,0 +1,137 @@
+/*
+Copyright 2023 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mysqlctl
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"vitess.io/vitess/go/mysql"
+	"vitess.io/vitess/go/vt/logutil"
+	"vitess.io/vitess/go/vt/mysqlctl/backupstorage"
+)
+
+// newFakeBackupHandle returns a backup handle holding the given files. A nil
+// manifest stands for an incomplete backup.
+func newFakeBackupHandle(t *testing.T, name string, manifest any, files map[string][]byte) *FakeBackupHandle {
+	if manifest != nil {
+		data, err := json.Marshal(manifest)
+		require.NoError(t, err)
+		files[backupManifestFileName] = data
+	}
+	return &FakeBackupHandle{
+		Dir:   "ks/0",
+		NameV: name,
+		ReadFileReturnF: func(ctx context.Context, filename string) (io.ReadCloser, error) {
+			data, ok := files[filename]
+			if !ok {
+				return nil, fmt.Errorf("no file %v in backup %v", filename, name)
+			}
+			return io.NopCloser(bytes.NewReader(data)), nil
+		},
+	}
+}
+
+func TestFindBackupsToPrune(t *testing.T) {
+	const uuid = "16b1039f-22b6-11ed-b765-0a43f95f28a3"
+	manifest := func(backupTime string, fromPos string, pos string) *BackupManifest {
+		bm := &BackupManifest{
+			BackupMethod: builtinBackupEngineName,
+			BackupTime:   backupTime,
+			Incremental:  fromPos != "",
+		}
+		var err error
+		bm.Position, err = mysql.DecodePosition(fmt.Sprintf("MySQL56/%s:%s", uuid, pos))
+		require.NoError(t, err)
+		if fromPos != "" {
+			bm.FromPosition, err = mysql.DecodePosition(fmt.Sprintf("MySQL56/%s:%s", uuid, fromPos))
+			require.NoError(t, err)
+		}
+		return bm
+	}
+	backup := func(name string, bm *BackupManifest) backupstorage.BackupHandle {
+		if bm == nil {
+			return newFakeBackupHandle(t, name, nil, map[string][]byte{})
+		}
+		return newFakeBackupHandle(t, name, bm, map[string][]byte{})
+	}
+
+	bhs := []backupstorage.BackupHandle{
+		backup("full1", manifest("2023-06-01T10:00:00Z", "", "1-100")),
+		backup("incr1", manifest("2023-06-01T12:00:00Z", "1-100", "1-150")),
+		backup("full2", manifest("2023-06-03T10:00:00Z", "", "1-200")),
+		backup("failed", nil),
+		backup("full3", manifest("2023-06-07T10:00:00Z", "", "1-300")),
+		backup("incr2", manifest("2023-06-08T10:00:00Z", "1-300", "1-350")),
+		backup("incr3", manifest("2023-06-09T10:00:00Z", "1-350", "1-400")),
+		backup("inprogress", nil),
+	}
+	now := time.Date(2023, 6, 10, 12, 0, 0, 0, time.UTC)
+
+	testcases := []struct {
+		name   string
+		policy BackupRetentionPolicy
+		pruned []string
+	}{{
+		name:   "latest full backup and its incremental backups",
+		pruned: []string{"full1", "incr1", "full2"},
+	}, {
+		name:   "prune incomplete",
+		policy: BackupRetentionPolicy{PruneIncomplete: true},
+		pruned: []string{"full1", "incr1", "full2", "failed"},
+	}, {
+		name:   "keep last",
+		policy: BackupRetentionPolicy{KeepLast: 4},
+		pruned: []string{"full1", "incr1"},
+	}, {
+		name:   "keep daily",
+		policy: BackupRetentionPolicy{KeepDailyDays: 8},
+		pruned: []string{"full1", "incr1"},
+	}, {
+		name:   "keep daily, with the chain of an incremental backup",
+		policy: BackupRetentionPolicy{KeepDailyDays: 10},
+	}, {
+		name:   "keep daily, today only",
+		policy: BackupRetentionPolicy{KeepDailyDays: 1},
+		pruned: []string{"full1", "incr1", "full2"},
+	}}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			var pruned []string
+			for _, bh := range FindBackupsToPrune(context.Background(), logutil.NewMemoryLogger(), tc.policy, bhs, now) {
+				pruned = append(pruned, bh.Name())
+			}
+			assert.Equal(t, tc.pruned, pruned)
+		})
+	}
+
+	t.Run("no complete backup", func(t *testing.T) {
+		prune := FindBackupsToPrune(context.Background(), logutil.NewMemoryLogger(), BackupRetentionPolicy{PruneIncomplete: true}, []backupstorage.BackupHandle{
+			backup("failed1", nil),
+			backup("failed2", nil),
+		}, now)
+		assert.Empty(t, prune)
+	})
+}
@@ -0,0 +1,182 @@
+/*
+Copyright 2023 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mysqlctl
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"vitess.io/vitess/go/mysql"
+	"vitess.io/vitess/go/vt/logutil"
+	"vitess.io/vitess/go/vt/mysqlctl/backupstorage"
+	"vitess.io/vitess/go/vt/proto/vtrpc"
+	"vitess.io/vitess/go/vt/vterrors"
+)
+
+// BackupValidation is the result of ValidateBackup.
+type BackupValidation struct {
+	// Manifest is the MANIFEST of the validated backup.
+	Manifest *BackupManifest
+
+	// FilesChecked is the number of files read in full from the backup.
+	FilesChecked int
+
+	// BytesChecked is the total number of bytes read from the backup.
+	BytesChecked int64
+
+	// HashesChecked is true if the MANIFEST records the hash of every
+	// file, which is only the case for the builtin backup engine.
+	HashesChecked bool
+}
+
+// ValidateBackup reads every file of the given backup, and checks it against
+// the hashes recorded in its MANIFEST when there are any. It also makes sure
+// the key of an encrypted backup is available.
+func ValidateBackup(ctx context.Context, bh backupstorage.BackupHandle) (*BackupValidation, error) {
+	manifest, err := GetBackupManifest(ctx, bh)
+	if err != nil {
+		return nil, vterrors.Wrapf(err, "can't get MANIFEST of backup %v", bh.Name())
+	}
+	if _, err := getBackupEncryption(ctx, manifest); err != nil {
+		return nil, vterrors.Wrapf(err, "can't get encryption key of backup %v", bh.Name())
+	}
+
+	validation := &BackupValidation{Manifest: manifest}
+	switch manifest.BackupMethod {
+	case builtinBackupEngineName, "":
+		var bm builtinBackupManifest
+		if err := getBackupManifestInto(ctx, bh, &bm); err != nil {
+			return nil, err
+		}
+		for i, fe := range bm.FileEntries {
+			name := fmt.Sprintf("%v", i)
+			hash, err := validation.readFile(ctx, bh, name)
+			if err != nil {
+				return nil, err
+			}
+			if hash != fe.Hash {
+				return nil, vterrors.Errorf(vtrpc.Code_DATA_LOSS, "hash mismatch for %v (%v) in backup %v, got %v expected %v", name, fe.Name, bh.Name(), hash, fe.Hash)
+			}
+		}
+		validation.HashesChecked = true
+	case xtrabackupEngineName:
+		var bm xtraBackupManifest
+		if err := getBackupManifestInto(ctx, bh, &bm); err != nil {
+			return nil, err
+		}
+		names := []string{bm.FileName}
+		if bm.NumStripes > 1 {
+			names = names[:0]
+			for i := 0; i < int(bm.NumStripes); i++ {
+				names = append(names, stripeFileName(bm.FileName, i))
+			}
+		}
+		for _, name := range names {
+			if _, err := validation.readFile(ctx, bh, name); err != nil {
+				return nil, err
+			}
+		}
+	default:
+		return nil, vterrors.Errorf(vtrpc.Code_UNIMPLEMENTED, "can't validate backup %v created with %q engine", bh.Name(), manifest.BackupMethod)
+	}
+	return validation, nil
+}
+
+// readFile reads the given file of the backup in full, and returns its hash.
+func (v *BackupValidation) readFile(ctx context.Context, bh backupstorage.BackupHandle, name string) (string, error) {
+	source, err := bh.ReadFile(ctx, name)
+	if err != nil {
+		return "", vterrors.Wrapf(err, "can't open file %v of backup %v", name, bh.Name())
+	}
+	defer source.Close()
+
+	br := newBackupReader(name, 0, source)
+	n, err := io.Copy(io.Discard, br)
+	if err != nil {
+		return "", vterrors.Wrapf(err, "can't read file %v of backup %v", name, bh.Name())
+	}
+	v.FilesChecked++
+	v.BytesChecked += n
+	return br.HashString(), nil
+}
+
+// RestoreToScratch restores the given backup of keyspace/shard into a scratch
+// mysqld, in order to make sure it can actually be restored. The mysqld is
+// initialized in the tablet directory of tabletUID, which must not exist yet,
+// and listens on mysqlPort. It is shut down and its directory removed once
+// done. The position the backup was restored to is returned.
+func RestoreToScratch(ctx context.Context, logger logutil.Logger, keyspace, shard string, manifest *BackupManifest, tabletUID uint32, mysqlPort int) (mysql.Position, error) {
+	tabletDir := TabletDir(tabletUID)
+	if _, err := os.Stat(tabletDir); !os.IsNotExist(err) {
+		return mysql.Position{}, vterrors.Errorf(vtrpc.Code_FAILED_PRECONDITION, "scratch tablet directory %v already exists", tabletDir)
+	}
+	defer func() {
+		if err := os.RemoveAll(tabletDir); err != nil {
+			logger.Warningf("Failed to remove scratch tablet directory %v: %v", tabletDir, err)
+		}
+	}()
+
+	mysqld, mycnf, err := CreateMysqldAndMycnf(tabletUID, "", mysqlPort)
+	if err != nil {
+		return mysql.Position{}, vterrors.Wrap(err, "failed to initialize scratch mysql config")
+	}
+	defer mysqld.Close()
+	if err := mysqld.Init(ctx, mycnf, ""); err != nil {
+		return mysql.Position{}, vterrors.Wrap(err, "failed to initialize scratch mysqld")
+	}
+	defer func() {
+		// The restore may have timed out, so don't use its context.
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		if err := mysqld.Shutdown(shutdownCtx, mycnf, true); err != nil {
+			logger.Warningf("Failed to shutdown scratch mysqld: %v", err)
+		}
+	}()
+
+	params := RestoreParams{
+		Cnf:                 mycnf,
+		Mysqld:              mysqld,
+		Logger:              logger,
+		Concurrency:         4,
+		DeleteBeforeRestore: true,
+		DbName:              "vt_" + keyspace,
+		Keyspace:            keyspace,
+		Shard:               shard,
+	}
+	if manifest.Incremental {
+		params.RestoreToPos = manifest.Position
+	} else {
+		params.StartTime, err = time.Parse(time.RFC3339, manifest.BackupTime)
+		if err != nil {
+			return mysql.Position{}, vterrors.Wrapf(err, "invalid backup time %v", manifest.BackupTime)
+		}
+	}
+	if _, err := Restore(ctx, params); err != nil {
+		return mysql.Position{}, vterrors.Wrap(err, "test restore failed")
+	}
+	pos, err := mysqld.PrimaryPosition()
+	if err != nil {
+		return mysql.Position{}, vterrors.Wrap(err, "can't get position of the restored mysqld")
+	}
+	if !pos.AtLeast(manifest.Position) {
+		return mysql.Position{}, vterrors.Errorf(vtrpc.Code_DATA_LOSS, "test restore stopped at position %v, before the backup position %v", pos, manifest.Position)
+	}
+	return pos, nil
+}
@@ -0,0 +1,113 @@
+/*
+Copyright 2023 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mysqlctl
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"hash/crc32"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateBackup(t *testing.T) {
+	ctx := context.Background()
+	crc := func(data string) string {
+		h := crc32.NewIEEE()
+		h.Write([]byte(data))
+		return hex.EncodeToString(h.Sum(nil))
+	}
+	builtinManifest := func(hashes ...string) *builtinBackupManifest {
+		bm := &builtinBackupManifest{BackupManifest: BackupManifest{BackupMethod: builtinBackupEngineName}}
+		for i, hash := range hashes {
+			bm.FileEntries = append(bm.FileEntries, FileEntry{Base: backupData, Name: fmt.Sprintf("file%d", i), Hash: hash})
+		}
+		return bm
+	}
+
+	t.Run("builtin", func(t *testing.T) {
+		bh := newFakeBackupHandle(t, "backup", builtinManifest(crc("hello"), crc("world!")), map[string][]byte{
+			"0": []byte("hello"),
+			"1": []byte("world!"),
+		})
+		validation, err := ValidateBackup(ctx, bh)
+		require.NoError(t, err)
+		assert.Equal(t, 2, validation.FilesChecked)
+		assert.Equal(t, int64(11), validation.BytesChecked)
+		assert.True(t, validation.HashesChecked)
+		assert.Equal(t, builtinBackupEngineName, validation.Manifest.BackupMethod)
+	})
+
+	t.Run("hash mismatch", func(t *testing.T) {
+		bh := newFakeBackupHandle(t, "backup", builtinManifest(crc("hello"), crc("world!")), map[string][]byte{
+			"0": []byte("hello"),
+			"1": []byte("world?"),
+		})
+		_, err := ValidateBackup(ctx, bh)
+		assert.ErrorContains(t, err, "hash mismatch for 1 (file1) in backup backup")
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		bh := newFakeBackupHandle(t, "backup", builtinManifest(crc("hello"), crc("world!")), map[string][]byte{
+			"0": []byte("hello"),
+		})
+		_, err := ValidateBackup(ctx, bh)
+		assert.ErrorContains(t, err, "can't open file 1 of backup backup")
+	})
+
+	t.Run("missing MANIFEST", func(t *testing.T) {
+		bh := newFakeBackupHandle(t, "backup", nil, map[string][]byte{})
+		_, err := ValidateBackup(ctx, bh)
+		assert.ErrorContains(t, err, "can't get MANIFEST of backup backup")
+	})
+
+	t.Run("unknown encryption key", func(t *testing.T) {
+		bm := builtinManifest()
+		bm.EncryptionKeyProvider = "vault"
+		bm.EncryptionKeyID = "key1"
+		bh := newFakeBackupHandle(t, "backup", bm, map[string][]byte{})
+		_, err := ValidateBackup(ctx, bh)
+		assert.ErrorContains(t, err, `unknown backup encryption KeyProvider "vault"`)
+	})
+
+	t.Run("xtrabackup stripes", func(t *testing.T) {
+		bm := &xtraBackupManifest{
+			BackupManifest: BackupManifest{BackupMethod: xtrabackupEngineName},
+			FileName:       "backup.xbstream.gz",
+			NumStripes:     3,
+		}
+		bh := newFakeBackupHandle(t, "backup", bm, map[string][]byte{
+			"backup.xbstream.gz-000": []byte("a"),
+			"backup.xbstream.gz-001": []byte("bb"),
+			"backup.xbstream.gz-002": []byte("ccc"),
+		})
+		validation, err := ValidateBackup(ctx, bh)
+		require.NoError(t, err)
+		assert.Equal(t, 3, validation.FilesChecked)
+		assert.Equal(t, int64(6), validation.BytesChecked)
+		assert.False(t, validation.HashesChecked)
+	})
+
+	t.Run("unknown engine", func(t *testing.T) {
+		bh := newFakeBackupHandle(t, "backup", &BackupManifest{BackupMethod: "mysqlshell"}, map[string][]byte{})
+		_, err := ValidateBackup(ctx, bh)
+		assert.ErrorContains(t, err, `can't validate backup backup created with "mysqlshell" engine`)
+	})
+}
@@ -17,10 +17,16 @@ limitations under the License.
 package mysqlctl
 
 import (
+	"bufio"
+	"bytes"
 	"context"
+	"encoding/binary"
 	"fmt"
+	"io"
+	"os"
 	"sort"
 	"strings"
+	"time"
 
 	"vitess.io/vitess/go/mysql"
 	"vitess.io/vitess/go/vt/proto/vtrpc"
@@ -244,3 +250,163 @@ func FindPITRPath(restoreToGTIDSet mysql.GTIDSet, manifests [](*BackupManifest))
 	}
 	return shortestPath, nil
 }
+
+// FindPITRToTimestampPath evaluates the shortest path to recover to restoreToTimestamp. Like FindPITRPath,
+// the path is composed of a full backup followed by zero or more incremental backups. The full backup must
+// have completed at or before restoreToTimestamp, and the path ends with the first incremental backup taken
+// after restoreToTimestamp, whose binary logs hold the last transactions to apply. Transactions which started
+// after restoreToTimestamp are skipped while applying them.
+func FindPITRToTimestampPath(restoreToTimestamp time.Time, manifests [](*BackupManifest)) (shortestPath [](*BackupManifest), err error) {
+	var lastIncremental *BackupManifest
+	var lastIncrementalTime time.Time
+	for _, manifest := range manifests {
+		if manifest == nil || !manifest.Incremental {
+			continue
+		}
+		backupTime, err := time.Parse(time.RFC3339, manifest.BackupTime)
+		if err != nil {
+			continue
+		}
+		if !backupTime.After(restoreToTimestamp) {
+			continue
+		}
+		if lastIncremental == nil || backupTime.Before(lastIncrementalTime) {
+			lastIncremental, lastIncrementalTime = manifest, backupTime
+		}
+	}
+	if lastIncremental == nil {
+		return nil, vterrors.Errorf(vtrpc.Code_FAILED_PRECONDITION, "no incremental backup found after %v, binary logs up to that time are not backed up", restoreToTimestamp.Format(time.RFC3339))
+	}
+
+	// Only full backups completed at or before the desired time are eligible, and there is no point
+	// in considering incremental backups taken after lastIncremental.
+	eligibleManifests := make([](*BackupManifest), 0, len(manifests))
+	for _, manifest := range manifests {
+		if manifest == nil {
+			continue
+		}
+		if manifest.Incremental {
+			backupTime, err := time.Parse(time.RFC3339, manifest.BackupTime)
+			if err == nil && !backupTime.After(lastIncrementalTime) {
+				eligibleManifests = append(eligibleManifests, manifest)
+			}
+			continue
+		}
+		if finishedTime, err := manifest.finishedTime(); err == nil && !finishedTime.After(restoreToTimestamp) {
+			eligibleManifests = append(eligibleManifests, manifest)
+		}
+	}
+	return FindPITRPath(lastIncremental.Position.GTIDSet, eligibleManifests)
+}
+
+// finishedTime returns the time at which the backup finished, or the time at which it was taken when the
+// former is unknown.
+func (m *BackupManifest) finishedTime() (time.Time, error) {
+	if m.FinishedTime != "" {
+		return time.Parse(time.RFC3339, m.FinishedTime)
+	}
+	return time.Parse(time.RFC3339, m.BackupTime)
+}
+
+// readBinlogFileGTIDsUpToTimestamp is like readBinlogGTIDsUpToTimestamp, for the binary log file at the given path.
+func readBinlogFileGTIDsUpToTimestamp(binlogFile string, restoreToTimestamp time.Time) (gtids mysql.Mysql56GTIDSet, reachedTimestamp bool, err error) {
+	f, err := os.Open(binlogFile)
+	if err != nil {
+		return nil, false, err
+	}
+	defer f.Close()
+	return readBinlogGTIDsUpToTimestamp(f, restoreToTimestamp)
+}
+
+// readBinlogGTIDsUpToTimestamp reads a binary log file, and returns the GTIDs of its transactions which were
+// committed at or before restoreToTimestamp. A transaction's commit time is the timestamp of the event which ends
+// it: the XID event, the COMMIT query, or the statement itself for DDL. Reading stops at the first transaction
+// committed after that time, in which case reachedTimestamp is true.
+func readBinlogGTIDsUpToTimestamp(r io.Reader, restoreToTimestamp time.Time) (gtids mysql.Mysql56GTIDSet, reachedTimestamp bool, err error) {
+	br := bufio.NewReader(r)
+	magic := make([]byte, len(mysql.BinglogMagicNumber))
+	if _, err := io.ReadFull(br, magic); err != nil {
+		return nil, false, vterrors.Wrapf(err, "can't read binary log header")
+	}
+	if !bytes.Equal(magic, mysql.BinglogMagicNumber) {
+		return nil, false, vterrors.Errorf(vtrpc.Code_INVALID_ARGUMENT, "not a binary log file")
+	}
+
+	gtids = mysql.Mysql56GTIDSet{}
+	var format mysql.BinlogFormat
+	// pending is the GTID of the transaction being read, until its commit event is found.
+	var pending mysql.GTID
+	inTransaction := false
+	commit := func(ev mysql.BinlogEvent) bool {
+		if time.Unix(int64(ev.Timestamp()), 0).After(restoreToTimestamp) {
+			return false
+		}
+		gtids = gtids.AddGTID(pending).(mysql.Mysql56GTIDSet)
+		pending = nil
+		inTransaction = false
+		return true
+	}
+	header := make([]byte, mysql.BinlogFixedHeaderLen)
+	for {
+		if _, err := io.ReadFull(br, header); err != nil {
+			if err == io.EOF {
+				return gtids, false, nil
+			}
+			return nil, false, vterrors.Wrapf(err, "can't read binary log event header")
+		}
+		length := binary.LittleEndian.Uint32(header[9:13])
+		if length < mysql.BinlogFixedHeaderLen {
+			return nil, false, vterrors.Errorf(vtrpc.Code_INVALID_ARGUMENT, "invalid binary log event length %v", length)
+		}
+		buf := make([]byte, length)
+		copy(buf, header)
+		if _, err := io.ReadFull(br, buf[mysql.BinlogFixedHeaderLen:]); err != nil {
+			return nil, false, vterrors.Wrapf(err, "can't read binary log event")
+		}
+
+		ev := mysql.NewMysql56BinlogEvent(buf)
+		if ev.IsFormatDescription() {
+			if format, err = ev.Format(); err != nil {
+				return nil, false, vterrors.Wrapf(err, "can't parse binary log format description")
+			}
+			continue
+		}
+		if format.IsZero() {
+			if ev.IsGTID() {
+				return nil, false, vterrors.Errorf(vtrpc.Code_INVALID_ARGUMENT, "binary log GTID event before the format description")
+			}
+			continue
+		}
+		if ev, _, err = ev.StripChecksum(format); err != nil {
+			return nil, false, vterrors.Wrapf(err, "can't strip checksum from binary log event")
+		}
+		switch {
+		case ev.IsGTID():
+			if pending, _, err = ev.GTID(format); err != nil {
+				return nil, false, vterrors.Wrapf(err, "can't parse binary log GTID event")
+			}
+			inTransaction = false
+		case pending == nil:
+			// Events which are not part of a GTID transaction have no bearing on the restore position.
+		case ev.IsXID():
+			if !commit(ev) {
+				return gtids, true, nil
+			}
+		case ev.IsQuery():
+			q, err := ev.Query(format)
+			if err != nil {
+				return nil, false, vterrors.Wrapf(err, "can't parse binary log query event")
+			}
+			switch {
+			case strings.EqualFold(q.SQL, "BEGIN"):
+				inTransaction = true
+			case inTransaction && !strings.EqualFold(q.SQL, "COMMIT"):
+				// A statement within the transaction, with statement based replication.
+			default:
+				if !commit(ev) {
+					return gtids, true, nil
+				}
+			}
+		}
+	}
+}
@@ -21,9 +21,11 @@ limitations under the License.
 package mysqlctl
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -582,3 +584,138 @@ func TestFindPITRPath(t *testing.T) {
 		})
 	}
 }
+
+func TestFindPITRToTimestampPath(t *testing.T) {
+	generatePosition := func(posRange string) mysql.Position {
+		return mysql.MustParsePosition(mysql.Mysql56FlavorID, fmt.Sprintf("16b1039f-22b6-11ed-b765-0a43f95f28a3:%s", posRange))
+	}
+	fullManifest := func(backupPos string, backupTime string, finishedTime string) *BackupManifest {
+		return &BackupManifest{
+			Position:     generatePosition(backupPos),
+			BackupTime:   backupTime,
+			FinishedTime: finishedTime,
+		}
+	}
+	incrementalManifest := func(backupPos string, backupFromPos string, backupTime string) *BackupManifest {
+		return &BackupManifest{
+			Position:     generatePosition(backupPos),
+			FromPosition: generatePosition(backupFromPos),
+			Incremental:  true,
+			BackupTime:   backupTime,
+		}
+	}
+	manifests := []*BackupManifest{
+		fullManifest("1-50", "2023-06-01T10:00:00Z", "2023-06-01T10:30:00Z"),
+		incrementalManifest("1-60", "1-50", "2023-06-01T12:00:00Z"),
+		incrementalManifest("1-70", "1-60", "2023-06-01T14:00:00Z"),
+		fullManifest("1-75", "2023-06-01T15:00:00Z", "2023-06-01T16:00:00Z"),
+		incrementalManifest("1-80", "1-70", "2023-06-01T16:00:00Z"),
+		incrementalManifest("1-90", "1-80", "2023-06-01T18:00:00Z"),
+	}
+	tt := []struct {
+		name        string
+		timestamp   string
+		expectPath  []*BackupManifest
+		expectError string
+	}{
+		{
+			name:       "first incremental",
+			timestamp:  "2023-06-01T11:00:00Z",
+			expectPath: manifests[0:2],
+		},
+		{
+			name:       "at incremental backup time",
+			timestamp:  "2023-06-01T12:00:00Z",
+			expectPath: manifests[0:3],
+		},
+		{
+			name:       "full backup still running",
+			timestamp:  "2023-06-01T15:30:00Z",
+			expectPath: []*BackupManifest{manifests[0], manifests[1], manifests[2], manifests[4]},
+		},
+		{
+			name:       "after second full backup",
+			timestamp:  "2023-06-01T17:00:00Z",
+			expectPath: manifests[3:6],
+		},
+		{
+			name:        "after last incremental",
+			timestamp:   "2023-06-01T18:00:00Z",
+			expectError: "no incremental backup found after 2023-06-01T18:00:00Z",
+		},
+		{
+			name:        "before first full backup",
+			timestamp:   "2023-06-01T10:10:00Z",
+			expectError: "no full backup found before GTID",
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			timestamp, err := time.Parse(time.RFC3339, tc.timestamp)
+			require.NoError(t, err)
+			path, err := FindPITRToTimestampPath(timestamp, manifests)
+			if tc.expectError != "" {
+				assert.ErrorContains(t, err, tc.expectError)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expectPath, path)
+		})
+	}
+}
+
+func TestReadBinlogGTIDsUpToTimestamp(t *testing.T) {
+	sid, err := mysql.ParseSID("16b1039f-22b6-11ed-b765-0a43f95f28a3")
+	require.NoError(t, err)
+	f := mysql.NewMySQL56BinlogFormat()
+	s := mysql.NewFakeBinlogStream()
+	s.Timestamp = 1000
+
+	binlog := bytes.NewBuffer(mysql.BinglogMagicNumber)
+	binlog.Write(mysql.NewFormatDescriptionEvent(f, s).Bytes())
+	// Each transaction starts 5 seconds before it is committed. Transaction 3 ends with a COMMIT
+	// statement rather than a XID event, and transaction 4 is a DDL.
+	for seq := int64(1); seq <= 5; seq++ {
+		binlog.Write(mysql.NewMySQL56GTIDEvent(f, s, mysql.Mysql56GTID{Server: sid, Sequence: seq}).Bytes())
+		if seq != 4 {
+			binlog.Write(mysql.NewQueryEvent(f, s, mysql.Query{Database: "vt_test", SQL: "BEGIN"}).Bytes())
+			binlog.Write(mysql.NewQueryEvent(f, s, mysql.Query{Database: "vt_test", SQL: "insert into t values (1)"}).Bytes())
+		}
+		s.Timestamp += 5
+		switch seq {
+		case 3:
+			binlog.Write(mysql.NewQueryEvent(f, s, mysql.Query{Database: "vt_test", SQL: "COMMIT"}).Bytes())
+		case 4:
+			binlog.Write(mysql.NewQueryEvent(f, s, mysql.Query{Database: "vt_test", SQL: "create table t2 (id int)"}).Bytes())
+		default:
+			binlog.Write(mysql.NewXIDEvent(f, s).Bytes())
+		}
+		s.Timestamp += 5
+	}
+
+	tt := []struct {
+		timestamp        int64
+		expectGTIDs      string
+		reachedTimestamp bool
+	}{
+		{timestamp: 1000, expectGTIDs: "", reachedTimestamp: true},
+		{timestamp: 1005, expectGTIDs: "16b1039f-22b6-11ed-b765-0a43f95f28a3:1", reachedTimestamp: true},
+		{timestamp: 1012, expectGTIDs: "16b1039f-22b6-11ed-b765-0a43f95f28a3:1", reachedTimestamp: true},
+		{timestamp: 1025, expectGTIDs: "16b1039f-22b6-11ed-b765-0a43f95f28a3:1-3", reachedTimestamp: true},
+		{timestamp: 1035, expectGTIDs: "16b1039f-22b6-11ed-b765-0a43f95f28a3:1-4", reachedTimestamp: true},
+		{timestamp: 1045, expectGTIDs: "16b1039f-22b6-11ed-b765-0a43f95f28a3:1-5"},
+	}
+	for _, tc := range tt {
+		t.Run(fmt.Sprintf("%d", tc.timestamp), func(t *testing.T) {
+			gtids, reachedTimestamp, err := readBinlogGTIDsUpToTimestamp(bytes.NewReader(binlog.Bytes()), time.Unix(tc.timestamp, 0))
+			require.NoError(t, err)
+			assert.Equal(t, tc.expectGTIDs, gtids.String())
+			assert.Equal(t, tc.reachedTimestamp, reachedTimestamp)
+		})
+	}
+
+	_, _, err = readBinlogGTIDsUpToTimestamp(bytes.NewReader([]byte("not a binlog")), time.Unix(1000, 0))
+	assert.ErrorContains(t, err, "not a binary log file")
+	_, _, err = readBinlogGTIDsUpToTimestamp(bytes.NewReader(binlog.Bytes()[:binlog.Len()-3]), time.Unix(2000, 0))
+	assert.ErrorContains(t, err, "can't read binary log event")
+}
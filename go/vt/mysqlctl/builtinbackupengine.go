@@ -547,6 +547,11 @@ func (be *BuiltinBackupEngine) backupFiles(
 	}
 	params.Logger.Infof("found %v files to backup", len(fes))
 
+	enc, err := newBackupEncryption(ctx)
+	if err != nil {
+		return err
+	}
+
 	// Backup with the provided concurrency.
 	sema := semaphore.NewWeighted(int64(params.Concurrency))
 	wg := sync.WaitGroup{}
@@ -582,7 +587,7 @@ func (be *BuiltinBackupEngine) backupFiles(
 
 			// Backup the individual file.
 			name := fmt.Sprintf("%v", i)
-			bh.RecordError(be.backupFile(ctx, params, bh, fe, name))
+			bh.RecordError(be.backupFile(ctx, params, bh, fe, name, enc))
 		}(i)
 	}
 
@@ -636,6 +641,7 @@ func (be *BuiltinBackupEngine) backupFiles(
 		CompressionEngine:    CompressionEngineName,
 		ExternalDecompressor: ManifestExternalDecompressorCmd,
 	}
+	enc.setManifest(&bm.BackupManifest)
 	data, err := json.MarshalIndent(bm, "", "  ")
 	if err != nil {
 		return vterrors.Wrapf(err, "cannot JSON encode %v", backupManifestFileName)
@@ -731,7 +737,7 @@ func (bp *backupPipe) ReportProgress(period time.Duration, logger logutil.Logger
 }
 
 // backupFile backs up an individual file.
-func (be *BuiltinBackupEngine) backupFile(ctx context.Context, params BackupParams, bh backupstorage.BackupHandle, fe *FileEntry, name string) (finalErr error) {
+func (be *BuiltinBackupEngine) backupFile(ctx context.Context, params BackupParams, bh backupstorage.BackupHandle, fe *FileEntry, name string, enc *backupEncryption) (finalErr error) {
 	// Open the source file for reading.
 	openSourceAt := time.Now()
 	source, err := fe.open(params.Cnf, true)
@@ -787,6 +793,19 @@ func (be *BuiltinBackupEngine) backupFile(ctx context.Context, params BackupPara
 	var reader io.Reader = br
 	var writer io.Writer = bw
 
+	// Create the encryption pipe, if necessary. It comes after compression,
+	// as encrypted data doesn't compress.
+	var encryptor io.WriteCloser
+	if enc != nil {
+		encryptor, err = enc.newEncryptor(writer)
+		if err != nil {
+			return vterrors.Wrap(err, "can't create encryptor")
+		}
+
+		encryptStats := params.Stats.Scope(stats.Operation("Encryptor:Write"))
+		writer = ioutil.NewMeteredWriter(encryptor, encryptStats.TimedIncrementBytes)
+	}
+
 	// Create the gzip compression pipe, if necessary.
 	var compressor io.WriteCloser
 	if backupStorageCompress {
@@ -823,6 +842,13 @@ func (be *BuiltinBackupEngine) backupFile(ctx context.Context, params BackupPara
 		params.Stats.Scope(stats.Operation("Compressor:Close")).TimedIncrement(time.Since(closeCompressorAt))
 	}
 
+	// Close the encryptor to write the last chunk.
+	if encryptor != nil {
+		if err = encryptor.Close(); err != nil {
+			return vterrors.Wrap(err, "cannot close encryptor")
+		}
+	}
+
 	// Close the backupPipe to finish writing on destination.
 	if err = bw.Close(); err != nil {
 		return vterrors.Wrapf(err, "cannot flush destination: %v", name)
@@ -864,13 +890,33 @@ func (be *BuiltinBackupEngine) executeRestoreIncrementalBackup(ctx context.Conte
 	if !ok {
 		return vterrors.Errorf(vtrpc.Code_UNIMPLEMENTED, "expected: Mysqld")
 	}
+	reachedTimestamp := false
 	for _, fe := range bm.FileEntries {
 		fe.ParentPath = createdDir
 		binlogFile, err := fe.fullPath(params.Cnf)
 		if err != nil {
 			return vterrors.Wrap(err, "failed to restore file")
 		}
-		if err := mysqld.ApplyBinlogFile(ctx, binlogFile, params.RestoreToPos); err != nil {
+		restoreToPos := params.RestoreToPos
+		if !params.RestoreToTimestamp.IsZero() {
+			if reachedTimestamp {
+				// All transactions of the remaining files were committed after the desired time.
+				defer os.Remove(binlogFile)
+				continue
+			}
+			var gtids mysql.Mysql56GTIDSet
+			gtids, reachedTimestamp, err = readBinlogFileGTIDsUpToTimestamp(binlogFile, params.RestoreToTimestamp)
+			if err != nil {
+				return vterrors.Wrapf(err, "failed to read binlog file %v", binlogFile)
+			}
+			if len(gtids) == 0 {
+				defer os.Remove(binlogFile)
+				params.Logger.Infof("Skipped binlog file %v: no transaction committed at or before %v", binlogFile, params.RestoreToTimestamp.Format(time.RFC3339))
+				continue
+			}
+			restoreToPos = mysql.Position{GTIDSet: gtids}
+		}
+		if err := mysqld.ApplyBinlogFile(ctx, binlogFile, restoreToPos); err != nil {
 			return vterrors.Wrapf(err, "failed to apply binlog file %v", binlogFile)
 		}
 		defer os.Remove(binlogFile)
@@ -926,6 +972,11 @@ func (be *BuiltinBackupEngine) restoreFiles(ctx context.Context, params RestoreP
 		}()
 	}
 
+	enc, err := getBackupEncryption(ctx, &bm.BackupManifest)
+	if err != nil {
+		return "", err
+	}
+
 	if bm.Incremental {
 		createdDir, err = os.MkdirTemp("", "restore-incremental-*")
 		if err != nil {
@@ -970,7 +1021,7 @@ func (be *BuiltinBackupEngine) restoreFiles(ctx context.Context, params RestoreP
 			// And restore the file.
 			name := fmt.Sprintf("%v", i)
 			params.Logger.Infof("Copying file %v: %v", name, fe.Name)
-			err := be.restoreFile(ctx, params, bh, fe, bm, name)
+			err := be.restoreFile(ctx, params, bh, fe, bm, name, enc)
 			if err != nil {
 				rec.RecordError(vterrors.Wrapf(err, "can't restore file %v to %v", name, fe.Name))
 			}
@@ -981,7 +1032,7 @@ func (be *BuiltinBackupEngine) restoreFiles(ctx context.Context, params RestoreP
 }
 
 // restoreFile restores an individual file.
-func (be *BuiltinBackupEngine) restoreFile(ctx context.Context, params RestoreParams, bh backupstorage.BackupHandle, fe *FileEntry, bm builtinBackupManifest, name string) (finalErr error) {
+func (be *BuiltinBackupEngine) restoreFile(ctx context.Context, params RestoreParams, bh backupstorage.BackupHandle, fe *FileEntry, bm builtinBackupManifest, name string, enc *backupEncryption) (finalErr error) {
 	// Open the source file for reading.
 	openSourceAt := time.Now()
 	source, err := bh.ReadFile(ctx, name)
@@ -1029,6 +1080,12 @@ func (be *BuiltinBackupEngine) restoreFile(ctx context.Context, params RestorePa
 
 	bufferedDest := bufio.NewWriterSize(timedDest, int(builtinBackupFileWriteBufferSize))
 
+	// Create the decrypter if needed.
+	if enc != nil {
+		decryptStats := params.Stats.Scope(stats.Operation("Decryptor:Read"))
+		reader = ioutil.NewMeteredReader(enc.newDecryptor(reader), decryptStats.TimedIncrementBytes)
+	}
+
 	// Create the uncompresser if needed.
 	if !bm.SkipCompress {
 		var decompressor io.ReadCloser
@@ -0,0 +1,422 @@
+/*
+Copyright 2023 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mysqlctl
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/pflag"
+
+	"vitess.io/vitess/go/vt/servenv"
+	"vitess.io/vitess/go/vt/vterrors"
+
+	vtrpcpb "vitess.io/vitess/go/vt/proto/vtrpc"
+)
+
+// Backup files are encrypted with AES-GCM, in chunks, so that they can be
+// streamed. An encrypted stream is laid out as:
+//
+//	magic (8 bytes) | nonce prefix (12 bytes) | chunk | chunk | ...
+//
+// where each chunk is a 4 bytes big endian header followed by the sealed
+// chunk. The header holds the length of the sealed chunk, and its high bit
+// flags the last chunk of the stream. The nonce of a chunk is the nonce
+// prefix XORed with the chunk index, and the flag is authenticated as
+// additional data, so that chunks can be neither reordered nor dropped,
+// and the stream can't be truncated.
+const (
+	// FileKeyProviderName is the name of the KeyProvider reading the keys from
+	// the file set with --backup-encryption-key-file.
+	FileKeyProviderName = "file"
+
+	encryptionMagic       = "VTBKENC1"
+	encryptionChunkSize   = 64 * 1024
+	encryptionLastChunk   = uint32(1) << 31
+	encryptionChunkHeader = 4
+)
+
+var (
+	// BackupEncryptionKeyProvider is the name of the KeyProvider the keys
+	// encrypting new backups come from. Backups are not encrypted when empty.
+	BackupEncryptionKeyProvider string
+
+	backupEncryptionKeyFile string
+
+	errEncryptedBackupCorrupted = errors.New("encrypted backup file is corrupted")
+	errEncryptedBackupTruncated = errors.New("encrypted backup file is truncated")
+)
+
+// KeyProvider provides the keys backups are encrypted with. Keys are
+// identified by an ID, which is recorded in the MANIFEST of the backups,
+// so that the key of a backup can be found again at restore time.
+type KeyProvider interface {
+	// CurrentKeyID returns the ID of the key new backups are encrypted with.
+	CurrentKeyID(ctx context.Context) (string, error)
+
+	// Key returns the key with the given ID. It is 16, 24 or 32 bytes
+	// long, to select AES-128, AES-192 or AES-256.
+	Key(ctx context.Context, keyID string) ([]byte, error)
+}
+
+// KeyProviderMap contains the registered implementations of KeyProvider.
+var KeyProviderMap = make(map[string]KeyProvider)
+
+func init() {
+	for _, cmd := range []string{"vtbackup", "vtcombo", "vtctld", "vttablet", "vttestserver"} {
+		servenv.OnParseFor(cmd, registerBackupEncryptionFlags)
+	}
+
+	KeyProviderMap[FileKeyProviderName] = &fileKeyProvider{}
+}
+
+func registerBackupEncryptionFlags(fs *pflag.FlagSet) {
+	fs.StringVar(&BackupEncryptionKeyProvider, "backup-encryption-key-provider", BackupEncryptionKeyProvider, "key provider the keys encrypting new backups come from. Backups are not encrypted if empty. Supported values are 'file'.")
+	fs.StringVar(&backupEncryptionKeyFile, "backup-encryption-key-file", backupEncryptionKeyFile, "file holding the backup encryption keys of the 'file' key provider, one '<key id> <base64 encoded key>' per line. The first key encrypts new backups, the other ones are kept to restore older backups.")
+}
+
+// fileKeyProvider reads the keys from --backup-encryption-key-file. The
+// file is read every time a key is needed, so that keys can be rotated
+// without restarting.
+type fileKeyProvider struct{}
+
+var _ KeyProvider = (*fileKeyProvider)(nil)
+
+// CurrentKeyID is part of the KeyProvider interface.
+func (fkp *fileKeyProvider) CurrentKeyID(ctx context.Context) (string, error) {
+	keyIDs, _, err := readKeyFile(backupEncryptionKeyFile)
+	if err != nil {
+		return "", err
+	}
+	if len(keyIDs) == 0 {
+		return "", fmt.Errorf("no key in backup encryption key file %v", backupEncryptionKeyFile)
+	}
+	return keyIDs[0], nil
+}
+
+// Key is part of the KeyProvider interface.
+func (fkp *fileKeyProvider) Key(ctx context.Context, keyID string) ([]byte, error) {
+	_, keys, err := readKeyFile(backupEncryptionKeyFile)
+	if err != nil {
+		return nil, err
+	}
+	key, ok := keys[keyID]
+	if !ok {
+		return nil, vterrors.Errorf(vtrpcpb.Code_NOT_FOUND, "no key %q in backup encryption key file %v", keyID, backupEncryptionKeyFile)
+	}
+	return key, nil
+}
+
+// readKeyFile returns the key IDs in the order of the file, and the keys by ID.
+func readKeyFile(path string) ([]string, map[string][]byte, error) {
+	if path == "" {
+		return nil, nil, errors.New("--backup-encryption-key-file is required by the 'file' key provider")
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, vterrors.Wrapf(err, "cannot read backup encryption key file %v", path)
+	}
+	var keyIDs []string
+	keys := make(map[string][]byte)
+	for i, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			return nil, nil, fmt.Errorf("line %d of backup encryption key file %v: want '<key id> <base64 encoded key>'", i+1, path)
+		}
+		key, err := base64.StdEncoding.DecodeString(fields[1])
+		if err != nil {
+			return nil, nil, fmt.Errorf("line %d of backup encryption key file %v: invalid base64 key: %v", i+1, path, err)
+		}
+		if _, ok := keys[fields[0]]; ok {
+			return nil, nil, fmt.Errorf("line %d of backup encryption key file %v: duplicate key id %q", i+1, path, fields[0])
+		}
+		keyIDs = append(keyIDs, fields[0])
+		keys[fields[0]] = key
+	}
+	return keyIDs, keys, nil
+}
+
+func getKeyProvider(name string) (KeyProvider, error) {
+	kp, ok := KeyProviderMap[name]
+	if !ok {
+		return nil, vterrors.Errorf(vtrpcpb.Code_NOT_FOUND, "unknown backup encryption KeyProvider %q", name)
+	}
+	return kp, nil
+}
+
+// backupEncryption is the encryption of the files of a backup.
+type backupEncryption struct {
+	keyProvider string
+	keyID       string
+	aead        cipher.AEAD
+}
+
+// newBackupEncryption returns the encryption of a new backup, using the
+// current key of --backup-encryption-key-provider. It returns nil if
+// backups are not encrypted.
+func newBackupEncryption(ctx context.Context) (*backupEncryption, error) {
+	if BackupEncryptionKeyProvider == "" {
+		return nil, nil
+	}
+	kp, err := getKeyProvider(BackupEncryptionKeyProvider)
+	if err != nil {
+		return nil, err
+	}
+	keyID, err := kp.CurrentKeyID(ctx)
+	if err != nil {
+		return nil, vterrors.Wrap(err, "cannot get the current backup encryption key")
+	}
+	return buildBackupEncryption(ctx, kp, BackupEncryptionKeyProvider, keyID)
+}
+
+// getBackupEncryption returns the encryption of an existing backup, from its
+// MANIFEST. It returns nil if the backup is not encrypted.
+func getBackupEncryption(ctx context.Context, bm *BackupManifest) (*backupEncryption, error) {
+	if bm.EncryptionKeyID == "" {
+		return nil, nil
+	}
+	kp, err := getKeyProvider(bm.EncryptionKeyProvider)
+	if err != nil {
+		return nil, err
+	}
+	return buildBackupEncryption(ctx, kp, bm.EncryptionKeyProvider, bm.EncryptionKeyID)
+}
+
+func buildBackupEncryption(ctx context.Context, kp KeyProvider, keyProvider, keyID string) (*backupEncryption, error) {
+	key, err := kp.Key(ctx, keyID)
+	if err != nil {
+		return nil, vterrors.Wrapf(err, "cannot get backup encryption key %q", keyID)
+	}
+	aead, err := newEncryptionAEAD(key)
+	if err != nil {
+		return nil, vterrors.Wrapf(err, "invalid backup encryption key %q", keyID)
+	}
+	return &backupEncryption{keyProvider: keyProvider, keyID: keyID, aead: aead}, nil
+}
+
+func newEncryptionAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// setManifest records the key of the backup in its MANIFEST.
+func (enc *backupEncryption) setManifest(bm *BackupManifest) {
+	if enc == nil {
+		return
+	}
+	bm.EncryptionKeyProvider = enc.keyProvider
+	bm.EncryptionKeyID = enc.keyID
+}
+
+// newEncryptor returns a writer encrypting what is written to it into w.
+// It must be closed to write the last chunk, and does not close w.
+func (enc *backupEncryption) newEncryptor(w io.Writer) (io.WriteCloser, error) {
+	nonce := make([]byte, enc.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, vterrors.Wrap(err, "cannot generate backup encryption nonce")
+	}
+	if _, err := w.Write(append([]byte(encryptionMagic), nonce...)); err != nil {
+		return nil, err
+	}
+	return &encryptor{
+		w:      w,
+		aead:   enc.aead,
+		nonce:  nonce,
+		buf:    make([]byte, 0, encryptionChunkSize),
+		sealed: make([]byte, encryptionChunkHeader, encryptionChunkHeader+encryptionChunkSize+enc.aead.Overhead()),
+	}, nil
+}
+
+// newDecryptor returns a reader decrypting what is read from r.
+func (enc *backupEncryption) newDecryptor(r io.Reader) io.Reader {
+	return &decryptor{
+		r:    bufio.NewReader(r),
+		aead: enc.aead,
+	}
+}
+
+// chunkNonce returns the nonce of the chunk at the given index.
+func chunkNonce(nonce, chunkNonce []byte, index uint64) []byte {
+	chunkNonce = append(chunkNonce[:0], nonce...)
+	tail := chunkNonce[len(chunkNonce)-8:]
+	binary.BigEndian.PutUint64(tail, binary.BigEndian.Uint64(tail)^index)
+	return chunkNonce
+}
+
+func chunkAdditionalData(last bool) []byte {
+	if last {
+		return []byte{1}
+	}
+	return []byte{0}
+}
+
+type encryptor struct {
+	w      io.Writer
+	aead   cipher.AEAD
+	nonce  []byte
+	index  uint64
+	buf    []byte
+	sealed []byte
+	closed bool
+}
+
+// Write is part of the io.Writer interface.
+func (e *encryptor) Write(p []byte) (int, error) {
+	if e.closed {
+		return 0, errors.New("write to a closed encryptor")
+	}
+	written := 0
+	for len(p) > 0 {
+		n := copy(e.buf[len(e.buf):cap(e.buf)], p)
+		e.buf = e.buf[:len(e.buf)+n]
+		p = p[n:]
+		written += n
+		if len(e.buf) == cap(e.buf) {
+			if err := e.writeChunk(false); err != nil {
+				return written, err
+			}
+		}
+	}
+	return written, nil
+}
+
+// Close is part of the io.Closer interface.
+func (e *encryptor) Close() error {
+	if e.closed {
+		return nil
+	}
+	e.closed = true
+	return e.writeChunk(true)
+}
+
+func (e *encryptor) writeChunk(last bool) error {
+	var nonce [12]byte
+	e.sealed = e.aead.Seal(e.sealed[:encryptionChunkHeader], chunkNonce(e.nonce, nonce[:0], e.index), e.buf, chunkAdditionalData(last))
+	header := uint32(len(e.sealed) - encryptionChunkHeader)
+	if last {
+		header |= encryptionLastChunk
+	}
+	binary.BigEndian.PutUint32(e.sealed, header)
+	e.index++
+	e.buf = e.buf[:0]
+	_, err := e.w.Write(e.sealed)
+	return err
+}
+
+type decryptor struct {
+	r     *bufio.Reader
+	aead  cipher.AEAD
+	nonce []byte
+	index uint64
+	plain []byte
+	buf   []byte
+	done  bool
+	err   error
+}
+
+// Read is part of the io.Reader interface.
+func (d *decryptor) Read(p []byte) (int, error) {
+	for len(d.plain) == 0 {
+		if d.err != nil {
+			return 0, d.err
+		}
+		if d.done {
+			d.err = d.readEnd()
+			continue
+		}
+		d.err = d.readChunk()
+	}
+	n := copy(p, d.plain)
+	d.plain = d.plain[n:]
+	return n, nil
+}
+
+func (d *decryptor) readChunk() error {
+	if d.nonce == nil {
+		header := make([]byte, len(encryptionMagic)+d.aead.NonceSize())
+		if _, err := io.ReadFull(d.r, header); err != nil {
+			return d.readError(err)
+		}
+		if !bytes.Equal(header[:len(encryptionMagic)], []byte(encryptionMagic)) {
+			return fmt.Errorf("%w: not an encrypted backup file", errEncryptedBackupCorrupted)
+		}
+		d.nonce = header[len(encryptionMagic):]
+	}
+
+	var header [encryptionChunkHeader]byte
+	if _, err := io.ReadFull(d.r, header[:]); err != nil {
+		return d.readError(err)
+	}
+	length := binary.BigEndian.Uint32(header[:])
+	last := length&encryptionLastChunk != 0
+	length &^= encryptionLastChunk
+	if length > uint32(encryptionChunkSize+d.aead.Overhead()) {
+		return fmt.Errorf("%w: chunk of %d bytes", errEncryptedBackupCorrupted, length)
+	}
+	if cap(d.buf) < int(length) {
+		d.buf = make([]byte, length)
+	}
+	d.buf = d.buf[:length]
+	if _, err := io.ReadFull(d.r, d.buf); err != nil {
+		return d.readError(err)
+	}
+	var nonce [12]byte
+	plain, err := d.aead.Open(d.buf[:0], chunkNonce(d.nonce, nonce[:0], d.index), d.buf, chunkAdditionalData(last))
+	if err != nil {
+		return fmt.Errorf("%w: %v", errEncryptedBackupCorrupted, err)
+	}
+	d.index++
+	d.plain = plain
+	d.done = last
+	return nil
+}
+
+// readEnd checks there is nothing after the last chunk.
+func (d *decryptor) readEnd() error {
+	if _, err := d.r.ReadByte(); err != io.EOF {
+		if err != nil {
+			return err
+		}
+		return fmt.Errorf("%w: data after the last chunk", errEncryptedBackupCorrupted)
+	}
+	return io.EOF
+}
+
+func (d *decryptor) readError(err error) error {
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return errEncryptedBackupTruncated
+	}
+	return err
+}
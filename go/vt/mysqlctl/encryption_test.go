@@ -0,0 +1,222 @@
+/*
+Copyright 2023 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mysqlctl
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeKeyFile writes the given keys to a key file, with the IDs key1, key2, etc.
+func writeKeyFile(t *testing.T, keys ...[]byte) string {
+	var keyIDs []string
+	for i := range keys {
+		keyIDs = append(keyIDs, fmt.Sprintf("key%d", i+1))
+	}
+	return writeKeyFileWithIDs(t, keyIDs, keys)
+}
+
+func writeKeyFileWithIDs(t *testing.T, keyIDs []string, keys [][]byte) string {
+	content := "# backup encryption keys\n\n"
+	for i, key := range keys {
+		content += fmt.Sprintf("%s %s\n", keyIDs[i], base64.StdEncoding.EncodeToString(key))
+	}
+	keyFile := path.Join(t.TempDir(), "keys")
+	require.NoError(t, os.WriteFile(keyFile, []byte(content), 0600))
+	return keyFile
+}
+
+func newTestKey(t *testing.T) []byte {
+	key := make([]byte, 32)
+	_, err := rand.Read(key)
+	require.NoError(t, err)
+	return key
+}
+
+func setBackupEncryptionFlags(t *testing.T, keyProvider, keyFile string) {
+	oldKeyProvider, oldKeyFile := BackupEncryptionKeyProvider, backupEncryptionKeyFile
+	BackupEncryptionKeyProvider, backupEncryptionKeyFile = keyProvider, keyFile
+	t.Cleanup(func() {
+		BackupEncryptionKeyProvider, backupEncryptionKeyFile = oldKeyProvider, oldKeyFile
+	})
+}
+
+func encryptTestData(t *testing.T, enc *backupEncryption, data []byte) []byte {
+	var buf bytes.Buffer
+	encryptor, err := enc.newEncryptor(&buf)
+	require.NoError(t, err)
+	// Write in uneven pieces, to cross the chunk boundaries.
+	for len(data) > 0 {
+		n := len(data)
+		if n > 10000 {
+			n = 10000
+		}
+		_, err = encryptor.Write(data[:n])
+		require.NoError(t, err)
+		data = data[n:]
+	}
+	require.NoError(t, encryptor.Close())
+	return buf.Bytes()
+}
+
+func TestBackupEncryptionRoundTrip(t *testing.T) {
+	setBackupEncryptionFlags(t, FileKeyProviderName, writeKeyFile(t, newTestKey(t)))
+	enc, err := newBackupEncryption(context.Background())
+	require.NoError(t, err)
+	require.NotNil(t, enc)
+
+	for _, size := range []int{0, 1, encryptionChunkSize - 1, encryptionChunkSize, 3*encryptionChunkSize + 17} {
+		t.Run(fmt.Sprintf("size %d", size), func(t *testing.T) {
+			data := make([]byte, size)
+			_, err := rand.Read(data)
+			require.NoError(t, err)
+
+			encrypted := encryptTestData(t, enc, data)
+			assert.False(t, size > 0 && bytes.Contains(encrypted, data))
+
+			decrypted, err := io.ReadAll(enc.newDecryptor(bytes.NewReader(encrypted)))
+			require.NoError(t, err)
+			assert.Equal(t, len(data), len(decrypted))
+			assert.True(t, bytes.Equal(data, decrypted))
+		})
+	}
+}
+
+func TestBackupEncryptionTampering(t *testing.T) {
+	setBackupEncryptionFlags(t, FileKeyProviderName, writeKeyFile(t, newTestKey(t)))
+	enc, err := newBackupEncryption(context.Background())
+	require.NoError(t, err)
+
+	data := bytes.Repeat([]byte("vitess"), encryptionChunkSize/2)
+	encrypted := encryptTestData(t, enc, data)
+	headerSize := len(encryptionMagic) + enc.aead.NonceSize()
+	firstChunkEnd := headerSize + encryptionChunkHeader + encryptionChunkSize + enc.aead.Overhead()
+
+	decrypt := func(encrypted []byte) error {
+		_, err := io.ReadAll(enc.newDecryptor(bytes.NewReader(encrypted)))
+		return err
+	}
+
+	flipped := bytes.Clone(encrypted)
+	flipped[len(flipped)-1] ^= 1
+	assert.ErrorIs(t, decrypt(flipped), errEncryptedBackupCorrupted)
+
+	// Dropping the last chunk must be detected.
+	assert.ErrorIs(t, decrypt(encrypted[:firstChunkEnd]), errEncryptedBackupTruncated)
+	assert.ErrorIs(t, decrypt(encrypted[:len(encrypted)-1]), errEncryptedBackupTruncated)
+	assert.ErrorIs(t, decrypt(append(bytes.Clone(encrypted), 0)), errEncryptedBackupCorrupted)
+	assert.ErrorIs(t, decrypt(data), errEncryptedBackupCorrupted)
+
+	// Decrypting with another key fails.
+	setBackupEncryptionFlags(t, FileKeyProviderName, writeKeyFile(t, newTestKey(t)))
+	other, err := buildBackupEncryption(context.Background(), KeyProviderMap[FileKeyProviderName], FileKeyProviderName, "key1")
+	require.NoError(t, err)
+	_, err = io.ReadAll(other.newDecryptor(bytes.NewReader(encrypted)))
+	assert.ErrorIs(t, err, errEncryptedBackupCorrupted)
+}
+
+func TestBackupEncryptionKeyRotation(t *testing.T) {
+	oldKey, newKey := newTestKey(t), newTestKey(t)
+	setBackupEncryptionFlags(t, FileKeyProviderName, writeKeyFile(t, oldKey))
+	ctx := context.Background()
+
+	enc, err := newBackupEncryption(ctx)
+	require.NoError(t, err)
+	var bm BackupManifest
+	enc.setManifest(&bm)
+	assert.Equal(t, FileKeyProviderName, bm.EncryptionKeyProvider)
+	assert.Equal(t, "key1", bm.EncryptionKeyID)
+	encrypted := encryptTestData(t, enc, []byte("some data"))
+
+	// The new key is put first, the old one is kept to restore older backups.
+	keyFile := writeKeyFileWithIDs(t, []string{"key2", "key1"}, [][]byte{newKey, oldKey})
+	setBackupEncryptionFlags(t, "", keyFile)
+
+	// Restores find the key from the MANIFEST, even with encryption disabled for new backups.
+	enc, err = getBackupEncryption(ctx, &bm)
+	require.NoError(t, err)
+	decrypted, err := io.ReadAll(enc.newDecryptor(bytes.NewReader(encrypted)))
+	require.NoError(t, err)
+	assert.Equal(t, "some data", string(decrypted))
+
+	enc, err = newBackupEncryption(ctx)
+	require.NoError(t, err)
+	assert.Nil(t, enc)
+
+	setBackupEncryptionFlags(t, FileKeyProviderName, keyFile)
+	enc, err = newBackupEncryption(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "key2", enc.keyID)
+
+	enc, err = getBackupEncryption(ctx, &BackupManifest{})
+	require.NoError(t, err)
+	assert.Nil(t, enc)
+	_, err = getBackupEncryption(ctx, &BackupManifest{EncryptionKeyProvider: FileKeyProviderName, EncryptionKeyID: "nope"})
+	assert.ErrorContains(t, err, `no key "nope"`)
+	_, err = getBackupEncryption(ctx, &BackupManifest{EncryptionKeyProvider: "vault", EncryptionKeyID: "key1"})
+	assert.ErrorContains(t, err, `unknown backup encryption KeyProvider "vault"`)
+}
+
+func TestReadKeyFile(t *testing.T) {
+	keyFile := path.Join(t.TempDir(), "keys")
+	testcases := []struct {
+		content string
+		keyIDs  []string
+		err     string
+	}{{
+		content: "# comment\nk1 AAAAAAAAAAAAAAAAAAAAAA==\n\n  k2   AAAAAAAAAAAAAAAAAAAAAA==  \n",
+		keyIDs:  []string{"k1", "k2"},
+	}, {
+		content: "k1\n",
+		err:     "line 1 of backup encryption key file " + keyFile + ": want '<key id> <base64 encoded key>'",
+	}, {
+		content: "k1 !!!\n",
+		err:     "invalid base64 key",
+	}, {
+		content: "k1 AAAA\nk1 AAAA\n",
+		err:     `line 2 of backup encryption key file ` + keyFile + `: duplicate key id "k1"`,
+	}}
+	for _, tc := range testcases {
+		require.NoError(t, os.WriteFile(keyFile, []byte(tc.content), 0600))
+		keyIDs, keys, err := readKeyFile(keyFile)
+		if tc.err != "" {
+			assert.ErrorContains(t, err, tc.err)
+			continue
+		}
+		require.NoError(t, err)
+		assert.Equal(t, tc.keyIDs, keyIDs)
+		assert.Len(t, keys, len(tc.keyIDs))
+	}
+
+	_, _, err := readKeyFile("")
+	assert.ErrorContains(t, err, "--backup-encryption-key-file is required")
+
+	// Keys of an invalid size are rejected when building the cipher.
+	setBackupEncryptionFlags(t, FileKeyProviderName, writeKeyFile(t, []byte("short")))
+	_, err = newBackupEncryption(context.Background())
+	assert.ErrorContains(t, err, `invalid backup encryption key "key1"`)
+}
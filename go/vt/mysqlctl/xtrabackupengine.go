@@ -222,13 +222,18 @@ func (be *XtrabackupEngine) executeFullBackup(ctx context.Context, params Backup
 	params.Logger.Infof("backup file name: %s", backupFileName)
 	numStripes := int(xtrabackupStripes)
 
+	enc, err := newBackupEncryption(ctx)
+	if err != nil {
+		return false, err
+	}
+
 	// Perform backups in a separate function, so deferred calls to Close() are
 	// all done before we continue to write the MANIFEST. This ensures that we
 	// do not write the MANIFEST unless all files were closed successfully,
 	// maintaining the contract that a MANIFEST file should only exist if the
 	// backup was created successfully.
 	params.Logger.Infof("Starting backup with %v stripe(s)", numStripes)
-	replicationPosition, err := be.backupFiles(ctx, params, bh, backupFileName, numStripes, flavor)
+	replicationPosition, err := be.backupFiles(ctx, params, bh, backupFileName, numStripes, flavor, enc)
 	if err != nil {
 		return false, err
 	}
@@ -271,6 +276,7 @@ func (be *XtrabackupEngine) executeFullBackup(ctx context.Context, params Backup
 		CompressionEngine:    CompressionEngineName,
 		ExternalDecompressor: ManifestExternalDecompressorCmd,
 	}
+	enc.setManifest(&bm.BackupManifest)
 
 	data, err := json.MarshalIndent(bm, "", "  ")
 	if err != nil {
@@ -291,6 +297,7 @@ func (be *XtrabackupEngine) backupFiles(
 	backupFileName string,
 	numStripes int,
 	flavor string,
+	enc *backupEncryption,
 ) (replicationPosition mysql.Position, finalErr error) {
 
 	backupProgram := path.Join(xtrabackupEnginePath, xtrabackupBinaryName)
@@ -364,11 +371,24 @@ func (be *XtrabackupEngine) backupFiles(
 	destWriters := []io.Writer{}
 	destBuffers := []*bufio.Writer{}
 	destCompressors := []io.WriteCloser{}
+	destEncryptors := []io.WriteCloser{}
 	for _, file := range destFiles {
 		buffer := bufio.NewWriterSize(file, writerBufferSize)
 		destBuffers = append(destBuffers, buffer)
 		writer := io.Writer(buffer)
 
+		// Create the encryption pipe, if necessary. It comes after compression,
+		// as encrypted data doesn't compress.
+		if enc != nil {
+			encryptor, err := enc.newEncryptor(writer)
+			if err != nil {
+				return replicationPosition, vterrors.Wrap(err, "can't create encryptor")
+			}
+
+			writer = encryptor
+			destEncryptors = append(destEncryptors, encryptor)
+		}
+
 		// Create the gzip compression pipe, if necessary.
 		if backupStorageCompress {
 			var compressor io.WriteCloser
@@ -446,6 +466,13 @@ func (be *XtrabackupEngine) backupFiles(
 		}
 	}
 
+	// Close encryptor to write the last chunk to the buffer.
+	for _, encryptor := range destEncryptors {
+		if err := encryptor.Close(); err != nil {
+			return replicationPosition, vterrors.Wrap(err, "cannot close encryptor")
+		}
+	}
+
 	// Flush the buffer to finish writing on destination.
 	for _, buffer := range destBuffers {
 		if err = buffer.Flush(); err != nil {
@@ -618,6 +645,11 @@ func (be *XtrabackupEngine) extractFiles(ctx context.Context, logger logutil.Log
 		baseFileName = be.backupFileName()
 	}
 
+	enc, err := getBackupEncryption(ctx, &bm.BackupManifest)
+	if err != nil {
+		return err
+	}
+
 	logger.Infof("backup file name: %s", baseFileName)
 	// Open the source files for reading.
 	srcFiles, err := readStripeFiles(ctx, bh, baseFileName, int(bm.NumStripes), logger)
@@ -635,6 +667,11 @@ func (be *XtrabackupEngine) extractFiles(ctx context.Context, logger logutil.Log
 	for _, file := range srcFiles {
 		reader := io.Reader(file)
 
+		// Create the decryptor if needed.
+		if enc != nil {
+			reader = enc.newDecryptor(reader)
+		}
+
 		// Create the decompressor if needed.
 		if compressed {
 			var decompressor io.ReadCloser
@@ -63,6 +63,7 @@ const (
 	StmtExecute
 	StmtDeallocate
 	StmtKill
+	StmtRequeue
 )
 
 // ASTToStatementType returns a StatementType from an AST stmt
@@ -126,6 +127,8 @@ func ASTToStatementType(stmt Statement) StatementType {
 		return StmtDeallocate
 	case *Kill:
 		return StmtKill
+	case *Requeue:
+		return StmtRequeue
 	default:
 		return StmtUnknown
 	}
@@ -134,7 +137,7 @@ func ASTToStatementType(stmt Statement) StatementType {
 // CanNormalize takes Statement and returns if the statement can be normalized.
 func CanNormalize(stmt Statement) bool {
 	switch stmt.(type) {
-	case *Select, *Union, *Insert, *Update, *Delete, *Set, *CallProc, *Stream: // TODO: we could merge this logic into ASTrewriter
+	case *Select, *Union, *Insert, *Update, *Delete, *Set, *CallProc, *Stream, *Requeue: // TODO: we could merge this logic into ASTrewriter
 		return true
 	}
 	return false
@@ -201,6 +204,8 @@ func Preview(sql string) StatementType {
 		return StmtStream
 	case "vstream":
 		return StmtVStream
+	case "requeue":
+		return StmtRequeue
 	case "revert":
 		return StmtRevert
 	case "insert":
@@ -321,6 +326,8 @@ func (s StatementType) String() string {
 		return "DEALLOCATE PREPARE"
 	case StmtKill:
 		return "KILL"
+	case StmtRequeue:
+		return "REQUEUE"
 	default:
 		return "UNKNOWN"
 	}
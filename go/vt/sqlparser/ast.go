@@ -316,6 +316,14 @@ type (
 		Table      TableName
 	}
 
+	// Requeue represents a REQUEUE statement, which reschedules
+	// the dead letters of a message table for delivery.
+	Requeue struct {
+		Comments *ParsedComments
+		Table    TableName
+		Where    *Where
+	}
+
 	// Insert represents an INSERT or REPLACE statement.
 	// Per the MySQL docs, http://dev.mysql.com/doc/refman/5.7/en/replace.html
 	// Replace is the counterpart to `INSERT IGNORE`, and works exactly like a
@@ -709,6 +717,7 @@ func (*Union) iStatement()               {}
 func (*Select) iStatement()              {}
 func (*Stream) iStatement()              {}
 func (*VStream) iStatement()             {}
+func (*Requeue) iStatement()             {}
 func (*Insert) iStatement()              {}
 func (*Update) iStatement()              {}
 func (*Delete) iStatement()              {}
@@ -1357,6 +1366,11 @@ func (node *VStream) SetComments(comments Comments) {
 	node.Comments = comments.Parsed()
 }
 
+// SetComments for Requeue
+func (node *Requeue) SetComments(comments Comments) {
+	node.Comments = comments.Parsed()
+}
+
 // GetParsedComments implements Commented interface.
 func (node *RenameTable) GetParsedComments() *ParsedComments {
 	// irrelevant
@@ -1434,6 +1448,11 @@ func (node *VStream) GetParsedComments() *ParsedComments {
 	return node.Comments
 }
 
+// GetParsedComments implements Requeue.
+func (node *Requeue) GetParsedComments() *ParsedComments {
+	return node.Comments
+}
+
 // GetToTables implements the DDLStatement interface
 func (node *RenameTable) GetToTables() TableNames {
 	var toTables TableNames
@@ -2983,6 +3002,19 @@ type (
 		Arg Expr
 	}
 
+	// JSONArrayAgg represents a call to JSON_ARRAYAGG
+	// For more information, see https://dev.mysql.com/doc/refman/8.0/en/aggregate-functions.html#function_json-arrayagg
+	JSONArrayAgg struct {
+		Expr Expr
+	}
+
+	// JSONObjectAgg represents a call to JSON_OBJECTAGG
+	// For more information, see https://dev.mysql.com/doc/refman/8.0/en/aggregate-functions.html#function_json-objectagg
+	JSONObjectAgg struct {
+		Key   Expr
+		Value Expr
+	}
+
 	// RegexpInstrExpr represents REGEXP_INSTR()
 	// For more information, see https://dev.mysql.com/doc/refman/8.0/en/regexp.html#function_regexp-instr
 	RegexpInstrExpr struct {
@@ -3225,6 +3257,8 @@ func (*CountStar) iExpr()                          {}
 func (*Count) iExpr()                              {}
 func (*GroupConcatExpr) iExpr()                    {}
 func (*AnyValue) iExpr()                           {}
+func (*JSONArrayAgg) iExpr()                       {}
+func (*JSONObjectAgg) iExpr()                      {}
 func (*BitAnd) iExpr()                             {}
 func (*BitOr) iExpr()                              {}
 func (*BitXor) iExpr()                             {}
@@ -3355,6 +3389,8 @@ func (varP *VarPop) GetArg() Expr               { return varP.Arg }
 func (varS *VarSamp) GetArg() Expr              { return varS.Arg }
 func (variance *Variance) GetArg() Expr         { return variance.Arg }
 func (av *AnyValue) GetArg() Expr               { return av.Arg }
+func (jaa *JSONArrayAgg) GetArg() Expr          { return jaa.Expr }
+func (joa *JSONObjectAgg) GetArg() Expr         { return joa.Key }
 
 func (sum *Sum) GetArgs() Exprs                   { return Exprs{sum.Arg} }
 func (min *Min) GetArgs() Exprs                   { return Exprs{min.Arg} }
@@ -3374,6 +3410,8 @@ func (varP *VarPop) GetArgs() Exprs               { return Exprs{varP.Arg} }
 func (varS *VarSamp) GetArgs() Exprs              { return Exprs{varS.Arg} }
 func (variance *Variance) GetArgs() Exprs         { return Exprs{variance.Arg} }
 func (av *AnyValue) GetArgs() Exprs               { return Exprs{av.Arg} }
+func (jaa *JSONArrayAgg) GetArgs() Exprs          { return Exprs{jaa.Expr} }
+func (joa *JSONObjectAgg) GetArgs() Exprs         { return Exprs{joa.Key, joa.Value} }
 
 func (sum *Sum) IsDistinct() bool                   { return sum.Distinct }
 func (min *Min) IsDistinct() bool                   { return min.Distinct }
@@ -3407,6 +3445,8 @@ func (*VarPop) AggrName() string          { return "var_pop" }
 func (*VarSamp) AggrName() string         { return "var_samp" }
 func (*Variance) AggrName() string        { return "variance" }
 func (*AnyValue) AggrName() string        { return "any_value" }
+func (*JSONArrayAgg) AggrName() string    { return "json_arrayagg" }
+func (*JSONObjectAgg) AggrName() string   { return "json_objectagg" }
 
 // Exprs represents a list of value expressions.
 // It's not a valid expression because it's not parenthesized.
@@ -233,6 +233,8 @@ func CloneSQLNode(in SQLNode) SQLNode {
 		return CloneRefOfIntroducerExpr(in)
 	case *IsExpr:
 		return CloneRefOfIsExpr(in)
+	case *JSONArrayAgg:
+		return CloneRefOfJSONArrayAgg(in)
 	case *JSONArrayExpr:
 		return CloneRefOfJSONArrayExpr(in)
 	case *JSONAttributesExpr:
@@ -245,6 +247,8 @@ func CloneSQLNode(in SQLNode) SQLNode {
 		return CloneRefOfJSONExtractExpr(in)
 	case *JSONKeysExpr:
 		return CloneRefOfJSONKeysExpr(in)
+	case *JSONObjectAgg:
+		return CloneRefOfJSONObjectAgg(in)
 	case *JSONObjectExpr:
 		return CloneRefOfJSONObjectExpr(in)
 	case *JSONObjectParam:
@@ -419,6 +423,8 @@ func CloneSQLNode(in SQLNode) SQLNode {
 		return CloneRefOfRenameTable(in)
 	case *RenameTableName:
 		return CloneRefOfRenameTableName(in)
+	case *Requeue:
+		return CloneRefOfRequeue(in)
 	case *RevertMigration:
 		return CloneRefOfRevertMigration(in)
 	case *Rollback:
@@ -1690,6 +1696,16 @@ func CloneRefOfIsExpr(n *IsExpr) *IsExpr {
 	return &out
 }
 
+// CloneRefOfJSONArrayAgg creates a deep clone of the input.
+func CloneRefOfJSONArrayAgg(n *JSONArrayAgg) *JSONArrayAgg {
+	if n == nil {
+		return nil
+	}
+	out := *n
+	out.Expr = CloneExpr(n.Expr)
+	return &out
+}
+
 // CloneRefOfJSONArrayExpr creates a deep clone of the input.
 func CloneRefOfJSONArrayExpr(n *JSONArrayExpr) *JSONArrayExpr {
 	if n == nil {
@@ -1757,6 +1773,17 @@ func CloneRefOfJSONKeysExpr(n *JSONKeysExpr) *JSONKeysExpr {
 	return &out
 }
 
+// CloneRefOfJSONObjectAgg creates a deep clone of the input.
+func CloneRefOfJSONObjectAgg(n *JSONObjectAgg) *JSONObjectAgg {
+	if n == nil {
+		return nil
+	}
+	out := *n
+	out.Key = CloneExpr(n.Key)
+	out.Value = CloneExpr(n.Value)
+	return &out
+}
+
 // CloneRefOfJSONObjectExpr creates a deep clone of the input.
 func CloneRefOfJSONObjectExpr(n *JSONObjectExpr) *JSONObjectExpr {
 	if n == nil {
@@ -2674,6 +2701,18 @@ func CloneRefOfRenameTableName(n *RenameTableName) *RenameTableName {
 	return &out
 }
 
+// CloneRefOfRequeue creates a deep clone of the input.
+func CloneRefOfRequeue(n *Requeue) *Requeue {
+	if n == nil {
+		return nil
+	}
+	out := *n
+	out.Comments = CloneRefOfParsedComments(n.Comments)
+	out.Table = CloneTableName(n.Table)
+	out.Where = CloneRefOfWhere(n.Where)
+	return &out
+}
+
 // CloneRefOfRevertMigration creates a deep clone of the input.
 func CloneRefOfRevertMigration(n *RevertMigration) *RevertMigration {
 	if n == nil {
@@ -3446,6 +3485,10 @@ func CloneAggrFunc(in AggrFunc) AggrFunc {
 		return CloneRefOfCountStar(in)
 	case *GroupConcatExpr:
 		return CloneRefOfGroupConcatExpr(in)
+	case *JSONArrayAgg:
+		return CloneRefOfJSONArrayAgg(in)
+	case *JSONObjectAgg:
+		return CloneRefOfJSONObjectAgg(in)
 	case *Max:
 		return CloneRefOfMax(in)
 	case *Min:
@@ -3892,6 +3935,8 @@ func CloneExpr(in Expr) Expr {
 		return CloneRefOfIntroducerExpr(in)
 	case *IsExpr:
 		return CloneRefOfIsExpr(in)
+	case *JSONArrayAgg:
+		return CloneRefOfJSONArrayAgg(in)
 	case *JSONArrayExpr:
 		return CloneRefOfJSONArrayExpr(in)
 	case *JSONAttributesExpr:
@@ -3904,6 +3949,8 @@ func CloneExpr(in Expr) Expr {
 		return CloneRefOfJSONExtractExpr(in)
 	case *JSONKeysExpr:
 		return CloneRefOfJSONKeysExpr(in)
+	case *JSONObjectAgg:
+		return CloneRefOfJSONObjectAgg(in)
 	case *JSONObjectExpr:
 		return CloneRefOfJSONObjectExpr(in)
 	case *JSONOverlapsExpr:
@@ -4190,6 +4237,8 @@ func CloneStatement(in Statement) Statement {
 		return CloneRefOfRelease(in)
 	case *RenameTable:
 		return CloneRefOfRenameTable(in)
+	case *Requeue:
+		return CloneRefOfRequeue(in)
 	case *RevertMigration:
 		return CloneRefOfRevertMigration(in)
 	case *Rollback:
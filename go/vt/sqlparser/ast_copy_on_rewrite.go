@@ -232,6 +232,8 @@ func (c *cow) copyOnRewriteSQLNode(n SQLNode, parent SQLNode) (out SQLNode, chan
 		return c.copyOnRewriteRefOfIntroducerExpr(n, parent)
 	case *IsExpr:
 		return c.copyOnRewriteRefOfIsExpr(n, parent)
+	case *JSONArrayAgg:
+		return c.copyOnRewriteRefOfJSONArrayAgg(n, parent)
 	case *JSONArrayExpr:
 		return c.copyOnRewriteRefOfJSONArrayExpr(n, parent)
 	case *JSONAttributesExpr:
@@ -244,6 +246,8 @@ func (c *cow) copyOnRewriteSQLNode(n SQLNode, parent SQLNode) (out SQLNode, chan
 		return c.copyOnRewriteRefOfJSONExtractExpr(n, parent)
 	case *JSONKeysExpr:
 		return c.copyOnRewriteRefOfJSONKeysExpr(n, parent)
+	case *JSONObjectAgg:
+		return c.copyOnRewriteRefOfJSONObjectAgg(n, parent)
 	case *JSONObjectExpr:
 		return c.copyOnRewriteRefOfJSONObjectExpr(n, parent)
 	case *JSONObjectParam:
@@ -418,6 +422,8 @@ func (c *cow) copyOnRewriteSQLNode(n SQLNode, parent SQLNode) (out SQLNode, chan
 		return c.copyOnRewriteRefOfRenameTable(n, parent)
 	case *RenameTableName:
 		return c.copyOnRewriteRefOfRenameTableName(n, parent)
+	case *Requeue:
+		return c.copyOnRewriteRefOfRequeue(n, parent)
 	case *RevertMigration:
 		return c.copyOnRewriteRefOfRevertMigration(n, parent)
 	case *Rollback:
@@ -2959,6 +2965,28 @@ func (c *cow) copyOnRewriteRefOfIsExpr(n *IsExpr, parent SQLNode) (out SQLNode,
 	}
 	return
 }
+func (c *cow) copyOnRewriteRefOfJSONArrayAgg(n *JSONArrayAgg, parent SQLNode) (out SQLNode, changed bool) {
+	if n == nil || c.cursor.stop {
+		return n, false
+	}
+	out = n
+	if c.pre == nil || c.pre(n, parent) {
+		_Expr, changedExpr := c.copyOnRewriteExpr(n.Expr, n)
+		if changedExpr {
+			res := *n
+			res.Expr, _ = _Expr.(Expr)
+			out = &res
+			if c.cloned != nil {
+				c.cloned(n, out)
+			}
+			changed = true
+		}
+	}
+	if c.post != nil {
+		out, changed = c.postVisit(out, parent, changed)
+	}
+	return
+}
 func (c *cow) copyOnRewriteRefOfJSONArrayExpr(n *JSONArrayExpr, parent SQLNode) (out SQLNode, changed bool) {
 	if n == nil || c.cursor.stop {
 		return n, false
@@ -3129,6 +3157,30 @@ func (c *cow) copyOnRewriteRefOfJSONKeysExpr(n *JSONKeysExpr, parent SQLNode) (o
 	}
 	return
 }
+func (c *cow) copyOnRewriteRefOfJSONObjectAgg(n *JSONObjectAgg, parent SQLNode) (out SQLNode, changed bool) {
+	if n == nil || c.cursor.stop {
+		return n, false
+	}
+	out = n
+	if c.pre == nil || c.pre(n, parent) {
+		_Key, changedKey := c.copyOnRewriteExpr(n.Key, n)
+		_Value, changedValue := c.copyOnRewriteExpr(n.Value, n)
+		if changedKey || changedValue {
+			res := *n
+			res.Key, _ = _Key.(Expr)
+			res.Value, _ = _Value.(Expr)
+			out = &res
+			if c.cloned != nil {
+				c.cloned(n, out)
+			}
+			changed = true
+		}
+	}
+	if c.post != nil {
+		out, changed = c.postVisit(out, parent, changed)
+	}
+	return
+}
 func (c *cow) copyOnRewriteRefOfJSONObjectExpr(n *JSONObjectExpr, parent SQLNode) (out SQLNode, changed bool) {
 	if n == nil || c.cursor.stop {
 		return n, false
@@ -5045,6 +5097,32 @@ func (c *cow) copyOnRewriteRefOfRenameTableName(n *RenameTableName, parent SQLNo
 	}
 	return
 }
+func (c *cow) copyOnRewriteRefOfRequeue(n *Requeue, parent SQLNode) (out SQLNode, changed bool) {
+	if n == nil || c.cursor.stop {
+		return n, false
+	}
+	out = n
+	if c.pre == nil || c.pre(n, parent) {
+		_Comments, changedComments := c.copyOnRewriteRefOfParsedComments(n.Comments, n)
+		_Table, changedTable := c.copyOnRewriteTableName(n.Table, n)
+		_Where, changedWhere := c.copyOnRewriteRefOfWhere(n.Where, n)
+		if changedComments || changedTable || changedWhere {
+			res := *n
+			res.Comments, _ = _Comments.(*ParsedComments)
+			res.Table, _ = _Table.(TableName)
+			res.Where, _ = _Where.(*Where)
+			out = &res
+			if c.cloned != nil {
+				c.cloned(n, out)
+			}
+			changed = true
+		}
+	}
+	if c.post != nil {
+		out, changed = c.postVisit(out, parent, changed)
+	}
+	return
+}
 func (c *cow) copyOnRewriteRefOfRevertMigration(n *RevertMigration, parent SQLNode) (out SQLNode, changed bool) {
 	if n == nil || c.cursor.stop {
 		return n, false
@@ -6659,6 +6737,10 @@ func (c *cow) copyOnRewriteAggrFunc(n AggrFunc, parent SQLNode) (out SQLNode, ch
 		return c.copyOnRewriteRefOfCountStar(n, parent)
 	case *GroupConcatExpr:
 		return c.copyOnRewriteRefOfGroupConcatExpr(n, parent)
+	case *JSONArrayAgg:
+		return c.copyOnRewriteRefOfJSONArrayAgg(n, parent)
+	case *JSONObjectAgg:
+		return c.copyOnRewriteRefOfJSONObjectAgg(n, parent)
 	case *Max:
 		return c.copyOnRewriteRefOfMax(n, parent)
 	case *Min:
@@ -7089,6 +7171,8 @@ func (c *cow) copyOnRewriteExpr(n Expr, parent SQLNode) (out SQLNode, changed bo
 		return c.copyOnRewriteRefOfIntroducerExpr(n, parent)
 	case *IsExpr:
 		return c.copyOnRewriteRefOfIsExpr(n, parent)
+	case *JSONArrayAgg:
+		return c.copyOnRewriteRefOfJSONArrayAgg(n, parent)
 	case *JSONArrayExpr:
 		return c.copyOnRewriteRefOfJSONArrayExpr(n, parent)
 	case *JSONAttributesExpr:
@@ -7101,6 +7185,8 @@ func (c *cow) copyOnRewriteExpr(n Expr, parent SQLNode) (out SQLNode, changed bo
 		return c.copyOnRewriteRefOfJSONExtractExpr(n, parent)
 	case *JSONKeysExpr:
 		return c.copyOnRewriteRefOfJSONKeysExpr(n, parent)
+	case *JSONObjectAgg:
+		return c.copyOnRewriteRefOfJSONObjectAgg(n, parent)
 	case *JSONObjectExpr:
 		return c.copyOnRewriteRefOfJSONObjectExpr(n, parent)
 	case *JSONOverlapsExpr:
@@ -7375,6 +7461,8 @@ func (c *cow) copyOnRewriteStatement(n Statement, parent SQLNode) (out SQLNode,
 		return c.copyOnRewriteRefOfRelease(n, parent)
 	case *RenameTable:
 		return c.copyOnRewriteRefOfRenameTable(n, parent)
+	case *Requeue:
+		return c.copyOnRewriteRefOfRequeue(n, parent)
 	case *RevertMigration:
 		return c.copyOnRewriteRefOfRevertMigration(n, parent)
 	case *Rollback:
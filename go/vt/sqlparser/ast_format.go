@@ -74,6 +74,9 @@ func (node *CommentOnly) Format(buf *TrackedBuffer) {
 
 // Format formats the node.
 func (node *Union) Format(buf *TrackedBuffer) {
+	if node.With != nil {
+		buf.astPrintf(node, "%v", node.With)
+	}
 	if requiresParen(node.Left) {
 		buf.astPrintf(node, "(%v)", node.Left)
 	} else {
@@ -109,6 +112,12 @@ func (node *Stream) Format(buf *TrackedBuffer) {
 		node.Comments, node.SelectExpr, node.Table)
 }
 
+// Format formats the node.
+func (node *Requeue) Format(buf *TrackedBuffer) {
+	buf.astPrintf(node, "requeue %v%v%v",
+		node.Comments, node.Table, node.Where)
+}
+
 // Format formats the node.
 func (node *Insert) Format(buf *TrackedBuffer) {
 	switch node.Action {
@@ -2682,6 +2691,14 @@ func (node *AnyValue) Format(buf *TrackedBuffer) {
 	buf.astPrintf(node, "any_value(%v)", node.Arg)
 }
 
+func (node *JSONArrayAgg) Format(buf *TrackedBuffer) {
+	buf.astPrintf(node, "json_arrayagg(%v)", node.Expr)
+}
+
+func (node *JSONObjectAgg) Format(buf *TrackedBuffer) {
+	buf.astPrintf(node, "json_objectagg(%v, %v)", node.Key, node.Value)
+}
+
 func (node *Avg) Format(buf *TrackedBuffer) {
 	buf.WriteString("avg(")
 	if node.Distinct {
@@ -86,6 +86,9 @@ func (node *CommentOnly) formatFast(buf *TrackedBuffer) {
 
 // formatFast formats the node.
 func (node *Union) formatFast(buf *TrackedBuffer) {
+	if node.With != nil {
+		node.With.formatFast(buf)
+	}
 	if requiresParen(node.Left) {
 		buf.WriteByte('(')
 		node.Left.formatFast(buf)
@@ -135,6 +138,15 @@ func (node *Stream) formatFast(buf *TrackedBuffer) {
 
 }
 
+// formatFast formats the node.
+func (node *Requeue) formatFast(buf *TrackedBuffer) {
+	buf.WriteString("requeue ")
+	node.Comments.formatFast(buf)
+	node.Table.formatFast(buf)
+	node.Where.formatFast(buf)
+
+}
+
 // formatFast formats the node.
 func (node *Insert) formatFast(buf *TrackedBuffer) {
 	switch node.Action {
@@ -3542,6 +3554,20 @@ func (node *AnyValue) formatFast(buf *TrackedBuffer) {
 	buf.WriteByte(')')
 }
 
+func (node *JSONArrayAgg) formatFast(buf *TrackedBuffer) {
+	buf.WriteString("json_arrayagg(")
+	buf.printExpr(node, node.Expr, true)
+	buf.WriteByte(')')
+}
+
+func (node *JSONObjectAgg) formatFast(buf *TrackedBuffer) {
+	buf.WriteString("json_objectagg(")
+	buf.printExpr(node, node.Key, true)
+	buf.WriteString(", ")
+	buf.printExpr(node, node.Value, true)
+	buf.WriteByte(')')
+}
+
 func (node *Avg) formatFast(buf *TrackedBuffer) {
 	buf.WriteString("avg(")
 	if node.Distinct {
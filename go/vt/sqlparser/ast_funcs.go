@@ -1925,6 +1925,8 @@ func (ty ShowCommandType) ToString() string {
 		return VGtidExecGlobalStr
 	case VitessMigrations:
 		return VitessMigrationsStr
+	case VitessQueryDigests:
+		return VitessQueryDigestsStr
 	case VitessReplicationStatus:
 		return VitessReplicationStatusStr
 	case VitessShards:
@@ -2091,6 +2093,14 @@ func ContainsAggregation(e SQLNode) bool {
 	return hasAggregates
 }
 
+// CTEs returns the common table expressions defined by the WITH clause, in declaration order
+func (node *With) CTEs() []*CommonTableExpr {
+	if node == nil {
+		return nil
+	}
+	return node.ctes
+}
+
 // GetFirstSelect gets the first select statement
 func GetFirstSelect(selStmt SelectStatement) *Select {
 	if selStmt == nil {
@@ -53,6 +53,9 @@ const (
 	DirectiveConsolidator = "CONSOLIDATOR"
 	// DirectiveWorkloadName specifies the name of the client application workload issuing the query.
 	DirectiveWorkloadName = "WORKLOAD_NAME"
+	// DirectiveInsertSelectBatchSize sets the number of rows inserted at a time by an INSERT ... SELECT,
+	// which streams the rows of the select instead of loading them all into memory first.
+	DirectiveInsertSelectBatchSize = "INSERT_SELECT_BATCH_SIZE"
 	// DirectivePriority specifies the priority of a workload. It should be an integer between 0 and MaxPriorityValue,
 	// where 0 is the highest priority, and MaxPriorityValue is the lowest one.
 	DirectivePriority = "PRIORITY"
@@ -224,6 +227,21 @@ type CommentDirectives struct {
 	m map[string]string
 }
 
+// NewCommentDirectives returns the execution directives given as a map of
+// names to values, such as the query attributes sent by MySQL clients.
+// Names are case-insensitive, like the ones of the comment directives.
+// It returns nil if there aren't any.
+func NewCommentDirectives(directives map[string]string) *CommentDirectives {
+	if len(directives) == 0 {
+		return nil
+	}
+	d := &CommentDirectives{m: make(map[string]string, len(directives))}
+	for directive, val := range directives {
+		d.m[strings.ToLower(directive)] = val
+	}
+	return d
+}
+
 // Directives parses the comment list for any execution directives
 // of the form:
 //
@@ -397,8 +415,12 @@ func GetPriorityFromStatement(statement Statement) (string, error) {
 		return "", nil
 	}
 
-	directives := commentedStatement.GetParsedComments().Directives()
-	priority, ok := directives.GetString(DirectivePriority, "")
+	return commentedStatement.GetParsedComments().Directives().Priority()
+}
+
+// Priority gets the priority from the directives, using DirectivePriority
+func (d *CommentDirectives) Priority() (string, error) {
+	priority, ok := d.GetString(DirectivePriority, "")
 	if !ok || priority == "" {
 		return "", nil
 	}
@@ -423,8 +445,12 @@ func Consolidator(stmt Statement) querypb.ExecuteOptions_Consolidator {
 	if comments == nil {
 		return querypb.ExecuteOptions_CONSOLIDATOR_UNSPECIFIED
 	}
-	directives := comments.Directives()
-	strv, isSet := directives.GetString(DirectiveConsolidator, "")
+	return comments.Directives().Consolidator()
+}
+
+// Consolidator returns the consolidator option of the directives.
+func (d *CommentDirectives) Consolidator() querypb.ExecuteOptions_Consolidator {
+	strv, isSet := d.GetString(DirectiveConsolidator, "")
 	if !isSet {
 		return querypb.ExecuteOptions_CONSOLIDATOR_UNSPECIFIED
 	}
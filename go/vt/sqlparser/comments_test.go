@@ -561,3 +561,22 @@ func TestGetPriorityFromStatement(t *testing.T) {
 		})
 	}
 }
+
+func TestNewCommentDirectives(t *testing.T) {
+	assert.Nil(t, NewCommentDirectives(nil))
+
+	directives := NewCommentDirectives(map[string]string{
+		"allow_scatter": "1",
+		"Workload_Name": "batch",
+		"CONSOLIDATOR":  "enabled",
+		"priority":      "200",
+	})
+	assert.True(t, directives.IsSet(DirectiveAllowScatter))
+	assert.False(t, directives.IsSet(DirectiveIgnoreMaxMemoryRows))
+	workloadName, ok := directives.GetString(DirectiveWorkloadName, "")
+	assert.True(t, ok)
+	assert.Equal(t, "batch", workloadName)
+	assert.Equal(t, querypb.ExecuteOptions_CONSOLIDATOR_ENABLED, directives.Consolidator())
+	_, err := directives.Priority()
+	assert.ErrorIs(t, err, ErrInvalidPriority)
+}
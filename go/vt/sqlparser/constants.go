@@ -301,6 +301,7 @@ const (
 	VGtidExecGlobalStr         = " global vgtid_executed"
 	KeyspaceStr                = " keyspaces"
 	VitessMigrationsStr        = " vitess_migrations"
+	VitessQueryDigestsStr      = " vitess_query_digests"
 	VitessReplicationStatusStr = " vitess_replication_status"
 	VitessShardsStr            = " vitess_shards"
 	VitessTabletsStr           = " vitess_tablets"
@@ -839,6 +840,7 @@ const (
 	VariableSession
 	VGtidExecGlobal
 	VitessMigrations
+	VitessQueryDigests
 	VitessReplicationStatus
 	VitessShards
 	VitessTablets
@@ -356,6 +356,7 @@ var keywords = []keyword{
 	{"json_array", JSON_ARRAY},
 	{"json_array_append", JSON_ARRAY_APPEND},
 	{"json_array_insert", JSON_ARRAY_INSERT},
+	{"json_arrayagg", JSON_ARRAYAGG},
 	{"json_contains", JSON_CONTAINS},
 	{"json_contains_path", JSON_CONTAINS_PATH},
 	{"json_depth", JSON_DEPTH},
@@ -367,6 +368,7 @@ var keywords = []keyword{
 	{"json_merge_patch", JSON_MERGE_PATCH},
 	{"json_merge_preserve", JSON_MERGE_PRESERVE},
 	{"json_object", JSON_OBJECT},
+	{"json_objectagg", JSON_OBJECTAGG},
 	{"json_overlaps", JSON_OVERLAPS},
 	{"json_pretty", JSON_PRETTY},
 	{"json_remove", JSON_REMOVE},
@@ -537,6 +539,7 @@ var keywords = []keyword{
 	{"repeat", UNUSED},
 	{"repeatable", REPEATABLE},
 	{"replace", REPLACE},
+	{"requeue", REQUEUE},
 	{"require", UNUSED},
 	{"resignal", UNUSED},
 	{"respect", RESPECT},
@@ -751,6 +754,7 @@ var keywords = []keyword{
 	{"vitess_metadata", VITESS_METADATA},
 	{"vitess_migration", VITESS_MIGRATION},
 	{"vitess_migrations", VITESS_MIGRATIONS},
+	{"vitess_query_digests", VITESS_QUERY_DIGESTS},
 	{"vitess_replication_status", VITESS_REPLICATION_STATUS},
 	{"vitess_shards", VITESS_SHARDS},
 	{"vitess_tablets", VITESS_TABLETS},
@@ -499,6 +499,9 @@ var (
 	}, {
 		input:  "WITH topsales2003 AS (SELECT salesRepEmployeeNumber employeeNumber, SUM(quantityOrdered * priceEach) sales FROM orders INNER JOIN orderdetails USING (orderNumber) INNER JOIN customers USING (customerNumber) WHERE YEAR(shippedDate) = 2003 AND status = 'Shipped' GROUP BY salesRepEmployeeNumber ORDER BY sales DESC LIMIT 5)SELECT employeeNumber, firstName, lastName, sales FROM employees JOIN topsales2003 USING (employeeNumber)",
 		output: "with topsales2003 as (select salesRepEmployeeNumber as employeeNumber, sum(quantityOrdered * priceEach) as sales from orders join orderdetails using (orderNumber) join customers using (customerNumber) where YEAR(shippedDate) = 2003 and `status` = 'Shipped' group by salesRepEmployeeNumber order by sales desc limit 5) select employeeNumber, firstName, lastName, sales from employees join topsales2003 using (employeeNumber)",
+	}, {
+		input:  "WITH x AS (SELECT id FROM t) SELECT id FROM x UNION SELECT id FROM x",
+		output: "with x as (select id from t) select id from x union select id from x",
 	}, {
 		input: "select 1 from t",
 	}, {
@@ -2333,6 +2336,12 @@ var (
 		output: "show keyspaces like '%'",
 	}, {
 		input: "show vitess_metadata variables",
+	}, {
+		input: "show vitess_query_digests",
+	}, {
+		input: "show vitess_query_digests like '%from user%'",
+	}, {
+		input: "show vitess_query_digests where Keyspace = 'ks'",
 	}, {
 		input: "show vitess_replication_status",
 	}, {
@@ -2667,6 +2676,13 @@ var (
 		input: "stream /* comment */ * from t",
 	}, {
 		input: "vstream * from t",
+	}, {
+		input: "requeue msg",
+	}, {
+		input: "requeue /* comment */ ks.msg where id in (1, 2)",
+	}, {
+		input:  "REQUEUE msg WHERE time_created < 1000",
+		output: "requeue msg where time_created < 1000",
 	}, {
 		input: "begin",
 	}, {
@@ -3598,6 +3614,15 @@ var (
 		input: "select var_samp(a) from products",
 	}, {
 		input: "select variance(a) from products",
+	}, {
+		input:  "select JSON_ARRAYAGG(a) from products group by b",
+		output: "select json_arrayagg(a) from products group by b",
+	}, {
+		input:  "select JSON_OBJECTAGG(a, b + 1) from products",
+		output: "select json_objectagg(a, b + 1) from products",
+	}, {
+		input:  "select json_arrayagg, json_objectagg from products",
+		output: "select `json_arrayagg`, `json_objectagg` from products",
 	}, {
 		input:  "SELECT FORMAT_BYTES(512), FORMAT_BYTES(18446644073709551615), FORMAT_BYTES(@j), FORMAT_BYTES('asd'), FORMAT_BYTES(TRIM('str'))",
 		output: "select format_bytes(512), format_bytes(18446644073709551615), format_bytes(@j), format_bytes('asd'), format_bytes(trim('str')) from dual",
@@ -0,0 +1,54 @@
+/*
+Copyright 2023 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sqlparser
+
+// QueryDigest returns the digest text of the statement: the statement with
+// its values abstracted away, so that all the queries of the same shape have
+// the same digest text, whether they were normalized or not.
+// Literals and bind variables are replaced by '?', lists of values by '(...)',
+// and only the first row of a multi-row VALUES clause is kept.
+func QueryDigest(stmt Statement) string {
+	buf := NewTrackedBuffer(formatDigest)
+	buf.Myprintf("%v", stmt)
+	return buf.String()
+}
+
+func formatDigest(buf *TrackedBuffer, node SQLNode) {
+	switch node := node.(type) {
+	case *Literal, *Argument:
+		buf.WriteByte('?')
+	case ListArg:
+		buf.WriteString("(...)")
+	case ValTuple:
+		for _, expr := range node {
+			switch expr.(type) {
+			case *Literal, *Argument, *NullVal:
+			default:
+				node.Format(buf)
+				return
+			}
+		}
+		buf.WriteString("(...)")
+	case Values:
+		buf.Myprintf("values %v", node[0])
+		if len(node) > 1 {
+			buf.WriteString(" /* , ... */")
+		}
+	default:
+		node.Format(buf)
+	}
+}
@@ -0,0 +1,68 @@
+/*
+Copyright 2023 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sqlparser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueryDigest(t *testing.T) {
+	testcases := []struct {
+		in  string
+		out string
+	}{{
+		in:  "select a, b from t where id = 1 and name = 'foo'",
+		out: "select a, b from t where id = ? and `name` = ?",
+	}, {
+		in:  "select a, b from t where id = :id and name = :vtg1",
+		out: "select a, b from t where id = ? and `name` = ?",
+	}, {
+		in:  "select * from t where id in (1, 2, 3) limit 10",
+		out: "select * from t where id in (...) limit ?",
+	}, {
+		in:  "select * from t where id in ::vtg1",
+		out: "select * from t where id in (...)",
+	}, {
+		in:  "select * from t where (a, b) in ((1, 2), (3, 4))",
+		out: "select * from t where (a, b) in ((...), (...))",
+	}, {
+		in:  "select * from t where (a, b) = (c, 1)",
+		out: "select * from t where (a, b) = (c, ?)",
+	}, {
+		in:  "insert into t(a, b) values (1, 'x')",
+		out: "insert into t(a, b) values (...)",
+	}, {
+		in:  "insert into t(a, b) values (1, 'x'), (2, 'y'), (3, 'z')",
+		out: "insert into t(a, b) values (...) /* , ... */",
+	}, {
+		in:  "insert into t(a, b) values (1, now()), (2, now())",
+		out: "insert into t(a, b) values (?, now()) /* , ... */",
+	}, {
+		in:  "update t set a = a + 1 where b is null",
+		out: "update t set a = a + ? where b is null",
+	}}
+	for _, tc := range testcases {
+		t.Run(tc.in, func(t *testing.T) {
+			stmt, err := Parse(tc.in)
+			require.NoError(t, err)
+			assert.Equal(t, tc.out, QueryDigest(stmt))
+		})
+	}
+}
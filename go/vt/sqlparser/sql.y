@@ -227,7 +227,7 @@ func markBindVariable(yylex yyLexer, bvar string) {
 
 %token LEX_ERROR
 %left <str> UNION
-%token <str> SELECT STREAM VSTREAM INSERT UPDATE DELETE FROM WHERE GROUP HAVING ORDER BY LIMIT OFFSET FOR
+%token <str> SELECT STREAM VSTREAM REQUEUE INSERT UPDATE DELETE FROM WHERE GROUP HAVING ORDER BY LIMIT OFFSET FOR
 %token <str> ALL DISTINCT AS EXISTS ASC DESC INTO DUPLICATE DEFAULT SET LOCK UNLOCK KEYS DO CALL
 %token <str> DISTINCTROW PARSER GENERATED ALWAYS
 %token <str> OUTFILE S3 DATA LOAD LINES TERMINATED ESCAPED ENCLOSED
@@ -330,7 +330,7 @@ func markBindVariable(yylex yyLexer, bvar string) {
 // SHOW tokens
 %token <str> CODE COLLATION COLUMNS DATABASES ENGINES EVENT EXTENDED FIELDS FULL FUNCTION GTID_EXECUTED
 %token <str> KEYSPACES OPEN PLUGINS PRIVILEGES PROCESSLIST SCHEMAS TABLES TRIGGERS USER
-%token <str> VGTID_EXECUTED VITESS_KEYSPACES VITESS_METADATA VITESS_MIGRATIONS VITESS_REPLICATION_STATUS VITESS_SHARDS VITESS_TABLETS VITESS_TARGET VSCHEMA VITESS_THROTTLED_APPS
+%token <str> VGTID_EXECUTED VITESS_KEYSPACES VITESS_METADATA VITESS_MIGRATIONS VITESS_QUERY_DIGESTS VITESS_REPLICATION_STATUS VITESS_SHARDS VITESS_TABLETS VITESS_TARGET VSCHEMA VITESS_THROTTLED_APPS
 
 // SET tokens
 %token <str> NAMES GLOBAL SESSION ISOLATION LEVEL READ WRITE ONLY REPEATABLE COMMITTED UNCOMMITTED SERIALIZABLE
@@ -351,7 +351,7 @@ func markBindVariable(yylex yyLexer, bvar string) {
 %token <str> JSON_ARRAY JSON_OBJECT JSON_QUOTE
 %token <str> JSON_DEPTH JSON_TYPE JSON_LENGTH JSON_VALID
 %token <str> JSON_ARRAY_APPEND JSON_ARRAY_INSERT JSON_INSERT JSON_MERGE JSON_MERGE_PATCH JSON_MERGE_PRESERVE JSON_REMOVE JSON_REPLACE JSON_SET JSON_UNQUOTE
-%token <str> COUNT AVG MAX MIN SUM GROUP_CONCAT BIT_AND BIT_OR BIT_XOR STD STDDEV STDDEV_POP STDDEV_SAMP VAR_POP VAR_SAMP VARIANCE ANY_VALUE
+%token <str> COUNT AVG MAX MIN SUM GROUP_CONCAT BIT_AND BIT_OR BIT_XOR STD STDDEV STDDEV_POP STDDEV_SAMP VAR_POP VAR_SAMP VARIANCE ANY_VALUE JSON_ARRAYAGG JSON_OBJECTAGG
 %token <str> REGEXP_INSTR REGEXP_LIKE REGEXP_REPLACE REGEXP_SUBSTR
 %token <str> ExtractValue UpdateXML
 %token <str> GET_LOCK RELEASE_LOCK RELEASE_ALL_LOCKS IS_FREE_LOCK IS_USED_LOCK
@@ -403,7 +403,7 @@ func markBindVariable(yylex yyLexer, bvar string) {
 %type <statement> command kill_statement
 %type <statement> explain_statement explainable_statement vexplain_statement
 %type <statement> prepare_statement execute_statement deallocate_statement
-%type <statement> stream_statement vstream_statement insert_statement update_statement delete_statement set_statement set_transaction_statement
+%type <statement> stream_statement vstream_statement requeue_statement insert_statement update_statement delete_statement set_statement set_transaction_statement
 %type <statement> create_statement alter_statement rename_statement drop_statement truncate_statement flush_statement do_statement
 %type <selStmt> select_statement select_stmt_with_into query_expression_parens query_expression query_expression_body query_primary
 %type <with> with_clause_opt with_clause
@@ -619,6 +619,7 @@ command:
   }
 | stream_statement
 | vstream_statement
+| requeue_statement
 | insert_statement
 | update_statement
 | delete_statement
@@ -894,6 +895,12 @@ vstream_statement:
     $$ = &VStream{Comments: Comments($2).Parsed(), SelectExpr: $3, Table: $5, Where: NewWhere(WhereClause, $6), Limit: $7}
   }
 
+requeue_statement:
+  REQUEUE comment_opt table_name where_expression_opt
+  {
+    $$ = &Requeue{Comments: Comments($2).Parsed(), Table: $3, Where: NewWhere(WhereClause, $4)}
+  }
+
 // query_primary is an unparenthesized SELECT with no order by clause or beyond.
 query_primary:
 //  1         2            3              4                    5             6                7           8            9           10
@@ -4104,6 +4111,10 @@ show_statement:
   {
     $$ = &ShowThrottledApps{}
   }
+| SHOW VITESS_QUERY_DIGESTS like_or_where_opt
+  {
+    $$ = &Show{&ShowBasic{Command: VitessQueryDigests, Filter: $3}}
+  }
 | SHOW VITESS_REPLICATION_STATUS like_opt
   {
     $$ = &Show{&ShowBasic{Command: VitessReplicationStatus, Filter: $3}}
@@ -5989,6 +6000,14 @@ UTC_DATE func_paren_opt
   {
     $$ = &AnyValue{Arg:$3}
   }
+| JSON_ARRAYAGG openb expression closeb
+  {
+    $$ = &JSONArrayAgg{Expr:$3}
+  }
+| JSON_OBJECTAGG openb expression ',' expression closeb
+  {
+    $$ = &JSONObjectAgg{Key:$3, Value:$5}
+  }
 | TIMESTAMPADD openb timestampadd_interval ',' expression ',' expression closeb
   {
     $$ = &IntervalDateExpr{Syntax: IntervalDateExprTimestampadd, Date: $7, Interval: $5, Unit: $3}
@@ -8206,6 +8225,7 @@ non_reserved_keyword:
 | JSON_ARRAY %prec FUNCTION_CALL_NON_KEYWORD
 | JSON_ARRAY_APPEND %prec FUNCTION_CALL_NON_KEYWORD
 | JSON_ARRAY_INSERT %prec FUNCTION_CALL_NON_KEYWORD
+| JSON_ARRAYAGG %prec FUNCTION_CALL_NON_KEYWORD
 | JSON_CONTAINS %prec FUNCTION_CALL_NON_KEYWORD
 | JSON_CONTAINS_PATH %prec FUNCTION_CALL_NON_KEYWORD
 | JSON_DEPTH %prec FUNCTION_CALL_NON_KEYWORD
@@ -8216,6 +8236,7 @@ non_reserved_keyword:
 | JSON_MERGE_PATCH %prec FUNCTION_CALL_NON_KEYWORD
 | JSON_MERGE_PRESERVE %prec FUNCTION_CALL_NON_KEYWORD
 | JSON_OBJECT %prec FUNCTION_CALL_NON_KEYWORD
+| JSON_OBJECTAGG %prec FUNCTION_CALL_NON_KEYWORD
 | JSON_OVERLAPS %prec FUNCTION_CALL_NON_KEYWORD
 | JSON_PRETTY %prec FUNCTION_CALL_NON_KEYWORD
 | JSON_QUOTE %prec FUNCTION_CALL_NON_KEYWORD
@@ -8338,6 +8359,7 @@ non_reserved_keyword:
 | REORGANIZE
 | REPAIR
 | REPEATABLE
+| REQUEUE
 | RESTRICT
 | REQUIRE_ROW_FORMAT
 | RESOURCE
@@ -8495,6 +8517,7 @@ non_reserved_keyword:
 | VITESS_METADATA
 | VITESS_MIGRATION
 | VITESS_MIGRATIONS
+| VITESS_QUERY_DIGESTS
 | VITESS_REPLICATION_STATUS
 | VITESS_SHARDS
 | VITESS_TABLETS
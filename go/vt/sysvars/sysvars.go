@@ -51,6 +51,10 @@ const (
 	SCLower
 )
 
+// DefaultSQLMode is the default value of sql_mode in MySQL 8.0. It is
+// assumed for sessions that have not set a sql_mode of their own.
+const DefaultSQLMode = "ONLY_FULL_GROUP_BY,STRICT_TRANS_TABLES,NO_ZERO_IN_DATE,NO_ZERO_DATE,ERROR_FOR_DIVISION_BY_ZERO,NO_ENGINE_SUBSTITUTION"
+
 // System Settings
 var (
 	on      = "1"
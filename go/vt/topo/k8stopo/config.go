@@ -0,0 +1,46 @@
+/*
+Copyright 2023 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package k8stopo
+
+import (
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+const (
+	// Path components
+	locksPath     = "locks"
+	electionsPath = "elections"
+
+	// rootLabel is set on all the objects of a topo root, to the hash
+	// of the root. Several roots can share a namespace.
+	rootLabel = "topo.vitess.io/root"
+
+	// lockPathAnnotation and lockContentsAnnotation record the topo
+	// path of a lock and the contents it was taken with, on its Lease.
+	lockPathAnnotation     = "topo.vitess.io/lock-path"
+	lockContentsAnnotation = "topo.vitess.io/lock-contents"
+)
+
+// vitessTopoNodes is the custom resource topo files are stored in, see
+// VitessTopoNodes-crd.yaml.
+var vitessTopoNodes = schema.GroupVersionResource{
+	Group:    "topo.vitess.io",
+	Version:  "v1beta1",
+	Resource: "vitesstoponodes",
+}
+
+const vitessTopoNodeKind = "VitessTopoNode"
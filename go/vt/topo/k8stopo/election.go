@@ -0,0 +1,170 @@
+/*
+Copyright 2023 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package k8stopo
+
+import (
+	"context"
+	"path"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"vitess.io/vitess/go/vt/log"
+	"vitess.io/vitess/go/vt/topo"
+)
+
+// NewLeaderParticipation is part of the topo.Server interface
+func (s *Server) NewLeaderParticipation(name, id string) (topo.LeaderParticipation, error) {
+	return &kubernetesLeaderParticipation{
+		s:    s,
+		name: name,
+		id:   id,
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}, nil
+}
+
+// kubernetesLeaderParticipation implements topo.LeaderParticipation.
+//
+// The leader holds the lock of the election path, that is its Lease. The
+// id of the leader is the contents of the lock.
+type kubernetesLeaderParticipation struct {
+	// s is our parent Kubernetes topo Server
+	s *Server
+
+	// name is the name of this LeaderParticipation
+	name string
+
+	// id is the process's current id.
+	id string
+
+	// stop is a channel closed when Stop is called.
+	stop chan struct{}
+
+	// done is a channel closed when we're done processing the Stop
+	done chan struct{}
+}
+
+// WaitForLeadership is part of the topo.LeaderParticipation interface.
+func (mp *kubernetesLeaderParticipation) WaitForLeadership() (context.Context, error) {
+	// If Stop was already called, mp.done is closed, so we are interrupted.
+	select {
+	case <-mp.done:
+		return nil, topo.NewError(topo.Interrupted, "Leadership")
+	default:
+	}
+
+	electionPath := path.Join(electionsPath, mp.name)
+	var ld topo.LockDescriptor
+
+	// We use a cancelable context here. If stop is closed,
+	// we just cancel that context.
+	lockCtx, lockCancel := context.WithCancel(context.Background())
+	go func() {
+		select {
+		case <-mp.s.running:
+			return
+		case <-mp.stop:
+		}
+		if ld != nil {
+			if err := ld.Unlock(context.Background()); err != nil {
+				log.Errorf("failed to unlock electionPath %v: %v", electionPath, err)
+			}
+		}
+		lockCancel()
+		close(mp.done)
+	}()
+
+	// Try to get the primaryship, by getting a lock.
+	var err error
+	ld, err = mp.s.lock(lockCtx, electionPath, mp.id)
+	if err != nil {
+		// It can be that we were interrupted.
+		return nil, err
+	}
+
+	// We got the lock. Return the lockContext. If Stop() is called,
+	// it will cancel the lockCtx, and cancel the returned context.
+	return lockCtx, nil
+}
+
+// Stop is part of the topo.LeaderParticipation interface
+func (mp *kubernetesLeaderParticipation) Stop() {
+	close(mp.stop)
+	<-mp.done
+}
+
+// GetCurrentLeaderID is part of the topo.LeaderParticipation interface
+func (mp *kubernetesLeaderParticipation) GetCurrentLeaderID(ctx context.Context) (string, error) {
+	leasePath := path.Join(mp.s.root, electionsPath, mp.name, locksPath)
+	lease, err := mp.s.leases().Get(ctx, objectName(leasePath), metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		// No Lease, means nobody is the primary.
+		return "", nil
+	}
+	if err != nil {
+		return "", convertError(err, leasePath)
+	}
+	if leaseExpired(lease, time.Now()) {
+		return "", nil
+	}
+	return lease.Annotations[lockContentsAnnotation], nil
+}
+
+// WaitForNewLeader is part of the topo.LeaderParticipation interface.
+// The Lease is polled for changes of leader.
+func (mp *kubernetesLeaderParticipation) WaitForNewLeader(ctx context.Context) (<-chan string, error) {
+	leader, err := mp.GetCurrentLeaderID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	notifications := make(chan string, 8)
+	if leader != "" {
+		notifications <- leader
+	}
+
+	go func() {
+		defer close(notifications)
+
+		ticker := time.NewTicker(lockRetryInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-mp.s.running:
+				return
+			case <-mp.done:
+				return
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			currentLeader, err := mp.GetCurrentLeaderID(ctx)
+			if err != nil || currentLeader == "" || currentLeader == leader {
+				continue
+			}
+			leader = currentLeader
+			select {
+			case notifications <- leader:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return notifications, nil
+}
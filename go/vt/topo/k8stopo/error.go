@@ -0,0 +1,61 @@
+/*
+Copyright 2023 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package k8stopo
+
+import (
+	"context"
+	"errors"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+
+	"vitess.io/vitess/go/vt/topo"
+)
+
+// Errors specific to this package.
+var (
+	// ErrBadResponse is returned from this package if an object returned by
+	// the Kubernetes API server does not hold the data we store in it.
+	ErrBadResponse = errors.New("kubernetes request returned success, but object is missing required data")
+)
+
+// convertError converts a Kubernetes API error into a topo error. All errors
+// are either application-level errors, or context errors.
+func convertError(err error, nodePath string) error {
+	if err == nil {
+		return nil
+	}
+
+	switch {
+	case apierrors.IsNotFound(err):
+		return topo.NewError(topo.NoNode, nodePath)
+	case apierrors.IsAlreadyExists(err):
+		return topo.NewError(topo.NodeExists, nodePath)
+	case apierrors.IsConflict(err):
+		return topo.NewError(topo.BadVersion, nodePath)
+	case apierrors.IsTimeout(err), apierrors.IsServerTimeout(err):
+		return topo.NewError(topo.Timeout, nodePath)
+	}
+
+	switch {
+	case errors.Is(err, context.Canceled):
+		return topo.NewError(topo.Interrupted, nodePath)
+	case errors.Is(err, context.DeadlineExceeded):
+		return topo.NewError(topo.Timeout, nodePath)
+	default:
+		return err
+	}
+}
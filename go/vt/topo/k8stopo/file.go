@@ -0,0 +1,219 @@
+/*
+Copyright 2023 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package k8stopo
+
+import (
+	"context"
+	"encoding/base64"
+	"path"
+	"sort"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"vitess.io/vitess/go/vt/topo"
+)
+
+// Create is part of the topo.Conn interface.
+func (s *Server) Create(ctx context.Context, filePath string, contents []byte) (topo.Version, error) {
+	nodePath := path.Join(s.root, filePath)
+
+	node, err := s.nodes().Create(ctx, s.newNode(nodePath, contents), metav1.CreateOptions{})
+	if err != nil {
+		return nil, convertError(err, nodePath)
+	}
+	return KubernetesVersion(node.GetResourceVersion()), nil
+}
+
+// Update is part of the topo.Conn interface.
+func (s *Server) Update(ctx context.Context, filePath string, contents []byte, version topo.Version) (topo.Version, error) {
+	nodePath := path.Join(s.root, filePath)
+	node := s.newNode(nodePath, contents)
+
+	if version != nil {
+		// The API server only applies the update if the
+		// resourceVersion is still the one we expect.
+		node.SetResourceVersion(string(version.(KubernetesVersion)))
+		updated, err := s.nodes().Update(ctx, node, metav1.UpdateOptions{})
+		if err != nil {
+			return nil, convertError(err, nodePath)
+		}
+		return KubernetesVersion(updated.GetResourceVersion()), nil
+	}
+
+	// No version specified. Custom resources can't be updated without
+	// a resourceVersion, so we create the file if it doesn't exist, and
+	// update the current version otherwise, until one of them sticks.
+	for {
+		current, err := s.nodes().Get(ctx, node.GetName(), metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			created, err := s.nodes().Create(ctx, node, metav1.CreateOptions{})
+			if apierrors.IsAlreadyExists(err) {
+				continue
+			}
+			if err != nil {
+				return nil, convertError(err, nodePath)
+			}
+			return KubernetesVersion(created.GetResourceVersion()), nil
+		}
+		if err != nil {
+			return nil, convertError(err, nodePath)
+		}
+
+		node.SetResourceVersion(current.GetResourceVersion())
+		updated, err := s.nodes().Update(ctx, node, metav1.UpdateOptions{})
+		if apierrors.IsConflict(err) || apierrors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return nil, convertError(err, nodePath)
+		}
+		return KubernetesVersion(updated.GetResourceVersion()), nil
+	}
+}
+
+// Get is part of the topo.Conn interface.
+func (s *Server) Get(ctx context.Context, filePath string) ([]byte, topo.Version, error) {
+	nodePath := path.Join(s.root, filePath)
+
+	node, err := s.nodes().Get(ctx, objectName(nodePath), metav1.GetOptions{})
+	if err != nil {
+		return nil, nil, convertError(err, nodePath)
+	}
+	_, value, err := nodeData(node)
+	if err != nil {
+		return nil, nil, err
+	}
+	return value, KubernetesVersion(node.GetResourceVersion()), nil
+}
+
+// List is part of the topo.Conn interface.
+func (s *Server) List(ctx context.Context, filePathPrefix string) ([]topo.KVInfo, error) {
+	nodePathPrefix := path.Join(s.root, filePathPrefix)
+
+	nodes, err := s.listNodes(ctx)
+	if err != nil {
+		return []topo.KVInfo{}, convertError(err, nodePathPrefix)
+	}
+	var results []topo.KVInfo
+	for _, node := range nodes {
+		if strings.HasPrefix(node.key, nodePathPrefix) {
+			results = append(results, topo.KVInfo{
+				Key:     []byte(node.key),
+				Value:   node.value,
+				Version: node.version,
+			})
+		}
+	}
+	if len(results) == 0 {
+		return []topo.KVInfo{}, topo.NewError(topo.NoNode, nodePathPrefix)
+	}
+	return results, nil
+}
+
+// Delete is part of the topo.Conn interface.
+func (s *Server) Delete(ctx context.Context, filePath string, version topo.Version) error {
+	nodePath := path.Join(s.root, filePath)
+	name := objectName(nodePath)
+
+	var options metav1.DeleteOptions
+	if version != nil {
+		// Check the version first, so we know whether a failed
+		// delete is because the file is gone or was changed. The
+		// preconditions make sure it didn't change in between.
+		current, err := s.nodes().Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return convertError(err, nodePath)
+		}
+		if current.GetResourceVersion() != string(version.(KubernetesVersion)) {
+			return topo.NewError(topo.BadVersion, nodePath)
+		}
+		uid, resourceVersion := current.GetUID(), current.GetResourceVersion()
+		options.Preconditions = &metav1.Preconditions{UID: &uid, ResourceVersion: &resourceVersion}
+	}
+	if err := s.nodes().Delete(ctx, name, options); err != nil {
+		return convertError(err, nodePath)
+	}
+	return nil
+}
+
+// node is the content of a VitessTopoNode object.
+type node struct {
+	key     string
+	value   []byte
+	version KubernetesVersion
+}
+
+// listNodes returns all the files of this root, sorted by path.
+func (s *Server) listNodes(ctx context.Context) ([]node, error) {
+	list, err := s.nodes().List(ctx, metav1.ListOptions{LabelSelector: s.rootSelector})
+	if err != nil {
+		return nil, err
+	}
+	nodes := make([]node, 0, len(list.Items))
+	for i := range list.Items {
+		key, value, err := nodeData(&list.Items[i])
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, node{
+			key:     key,
+			value:   value,
+			version: KubernetesVersion(list.Items[i].GetResourceVersion()),
+		})
+	}
+	sort.Slice(nodes, func(i, j int) bool {
+		return nodes[i].key < nodes[j].key
+	})
+	return nodes, nil
+}
+
+// newNode returns the VitessTopoNode object holding the given file.
+func (s *Server) newNode(nodePath string, contents []byte) *unstructured.Unstructured {
+	u := &unstructured.Unstructured{Object: map[string]any{
+		"data": map[string]any{
+			"key":   nodePath,
+			"value": base64.StdEncoding.EncodeToString(contents),
+		},
+	}}
+	u.SetAPIVersion(vitessTopoNodes.GroupVersion().String())
+	u.SetKind(vitessTopoNodeKind)
+	u.SetName(objectName(nodePath))
+	u.SetNamespace(s.namespace)
+	u.SetLabels(map[string]string{rootLabel: hashOf(s.root)})
+	return u
+}
+
+// nodeData returns the path and contents of the file held by the given
+// VitessTopoNode object.
+func nodeData(u *unstructured.Unstructured) (string, []byte, error) {
+	key, found, err := unstructured.NestedString(u.Object, "data", "key")
+	if err != nil || !found {
+		return "", nil, ErrBadResponse
+	}
+	encoded, _, err := unstructured.NestedString(u.Object, "data", "value")
+	if err != nil {
+		return "", nil, ErrBadResponse
+	}
+	value, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", nil, ErrBadResponse
+	}
+	return key, value, nil
+}
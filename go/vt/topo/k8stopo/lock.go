@@ -0,0 +1,315 @@
+/*
+Copyright 2023 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package k8stopo
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path"
+	"sync"
+	"time"
+
+	"github.com/spf13/pflag"
+	coordinationv1 "k8s.io/api/coordination/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"vitess.io/vitess/go/vt/log"
+	"vitess.io/vitess/go/vt/proto/vtrpc"
+	"vitess.io/vitess/go/vt/servenv"
+	"vitess.io/vitess/go/vt/topo"
+	"vitess.io/vitess/go/vt/vterrors"
+)
+
+var (
+	leaseDuration = 30 * time.Second
+
+	// lockRetryInterval is how often a held lock is checked for
+	// release, while waiting for it.
+	lockRetryInterval = 100 * time.Millisecond
+)
+
+func init() {
+	for _, cmd := range topo.FlagBinaries {
+		servenv.OnParseFor(cmd, registerK8sTopoLockFlags)
+	}
+}
+
+func registerK8sTopoLockFlags(fs *pflag.FlagSet) {
+	fs.DurationVar(&leaseDuration, "topo_k8s_lease_duration", leaseDuration, "lease duration for locks and leader election. The lock holder renews the Lease three times per duration, and other processes take it over once it expires.")
+}
+
+// kubernetesLockDescriptor implements topo.LockDescriptor.
+type kubernetesLockDescriptor struct {
+	s         *Server
+	name      string
+	leasePath string
+	holder    string
+
+	// cancel stops renewing the Lease, done is closed once it's stopped.
+	cancel context.CancelFunc
+	done   chan struct{}
+
+	mu  sync.Mutex
+	err error
+}
+
+// TryLock is part of the topo.Conn interface.
+func (s *Server) TryLock(ctx context.Context, dirPath, contents string) (topo.LockDescriptor, error) {
+	// We list the directory first to make sure it exists.
+	if _, err := s.ListDir(ctx, dirPath, false /*full*/); err != nil {
+		return nil, convertError(err, dirPath)
+	}
+
+	leasePath := path.Join(s.root, dirPath, locksPath)
+	holder, err := newHolderIdentity()
+	if err != nil {
+		return nil, err
+	}
+	acquired, err := s.tryAcquireLease(ctx, leasePath, holder, contents)
+	if err != nil {
+		return nil, err
+	}
+	if !acquired {
+		return nil, topo.NewError(topo.NodeExists, fmt.Sprintf("lock already exists at path %s", dirPath))
+	}
+	return s.newLockDescriptor(leasePath, holder), nil
+}
+
+// Lock is part of the topo.Conn interface.
+func (s *Server) Lock(ctx context.Context, dirPath, contents string) (topo.LockDescriptor, error) {
+	// We list the directory first to make sure it exists.
+	if _, err := s.ListDir(ctx, dirPath, false /*full*/); err != nil {
+		return nil, convertError(err, dirPath)
+	}
+
+	return s.lock(ctx, dirPath, contents)
+}
+
+// lock is used by both Lock() and leader election. It waits until the
+// Lease of nodePath is free, or has expired, and takes it.
+func (s *Server) lock(ctx context.Context, nodePath, contents string) (topo.LockDescriptor, error) {
+	leasePath := path.Join(s.root, nodePath, locksPath)
+	holder, err := newHolderIdentity()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		acquired, err := s.tryAcquireLease(ctx, leasePath, holder, contents)
+		if err != nil {
+			return nil, err
+		}
+		if acquired {
+			return s.newLockDescriptor(leasePath, holder), nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, convertError(ctx.Err(), leasePath)
+		case <-time.After(lockRetryInterval):
+		}
+	}
+}
+
+// tryAcquireLease takes the Lease of leasePath for holder, if it doesn't
+// exist or has expired. It returns false if someone else holds it.
+func (s *Server) tryAcquireLease(ctx context.Context, leasePath, holder, contents string) (bool, error) {
+	name := objectName(leasePath)
+	now := metav1.NewMicroTime(time.Now())
+
+	lease, err := s.leases().Get(ctx, name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		lease = &coordinationv1.Lease{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: s.namespace,
+				Labels:    map[string]string{rootLabel: hashOf(s.root)},
+			},
+		}
+		setLeaseHolder(lease, leasePath, holder, contents, now)
+		_, err = s.leases().Create(ctx, lease, metav1.CreateOptions{})
+		if apierrors.IsAlreadyExists(err) {
+			// Someone else just took it.
+			return false, nil
+		}
+		if err != nil {
+			return false, convertError(err, leasePath)
+		}
+		return true, nil
+	}
+	if err != nil {
+		return false, convertError(err, leasePath)
+	}
+	if !leaseExpired(lease, now.Time) {
+		return false, nil
+	}
+
+	// The holder didn't renew the Lease in time, take it over. The
+	// resourceVersion makes sure nobody else did in between.
+	log.Infof("Taking over expired lock %v from %v", leasePath, holderOf(lease))
+	setLeaseHolder(lease, leasePath, holder, contents, now)
+	_, err = s.leases().Update(ctx, lease, metav1.UpdateOptions{})
+	if apierrors.IsConflict(err) || apierrors.IsNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, convertError(err, leasePath)
+	}
+	return true, nil
+}
+
+// newLockDescriptor returns the descriptor of a Lease we just took, and
+// starts renewing it.
+func (s *Server) newLockDescriptor(leasePath, holder string) *kubernetesLockDescriptor {
+	ctx, cancel := context.WithCancel(context.Background())
+	ld := &kubernetesLockDescriptor{
+		s:         s,
+		name:      objectName(leasePath),
+		leasePath: leasePath,
+		holder:    holder,
+		cancel:    cancel,
+		done:      make(chan struct{}),
+	}
+	go ld.renew(ctx)
+	return ld
+}
+
+// renew renews the Lease until the context is canceled, or the Lease is lost.
+func (ld *kubernetesLockDescriptor) renew(ctx context.Context) {
+	defer close(ld.done)
+
+	ticker := time.NewTicker(leaseDuration / 3)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ld.s.running:
+			return
+		case <-ticker.C:
+		}
+
+		lease, err := ld.get(ctx)
+		if err == nil {
+			lease.Spec.RenewTime = &metav1.MicroTime{Time: time.Now()}
+			_, err = ld.s.leases().Update(ctx, lease, metav1.UpdateOptions{})
+			err = convertError(err, ld.leasePath)
+		}
+		switch {
+		case err == nil, ctx.Err() != nil:
+		case topo.IsErrType(err, topo.BadVersion):
+			// We'll find out who changed it next time.
+		case topo.IsErrType(err, topo.NoNode), vterrors.Code(err) == vtrpc.Code_FAILED_PRECONDITION:
+			log.Errorf("Lost lock %v: %v", ld.leasePath, err)
+			ld.mu.Lock()
+			ld.err = err
+			ld.mu.Unlock()
+			return
+		default:
+			log.Warningf("Failed to renew lock %v: %v", ld.leasePath, err)
+		}
+	}
+}
+
+// get returns the Lease, if we still hold it.
+func (ld *kubernetesLockDescriptor) get(ctx context.Context) (*coordinationv1.Lease, error) {
+	lease, err := ld.s.leases().Get(ctx, ld.name, metav1.GetOptions{})
+	if err != nil {
+		return nil, convertError(err, ld.leasePath)
+	}
+	if holder := holderOf(lease); holder != ld.holder {
+		return nil, vterrors.Errorf(vtrpc.Code_FAILED_PRECONDITION, "lock %v was taken over by %v", ld.leasePath, holder)
+	}
+	return lease, nil
+}
+
+// Check is part of the topo.LockDescriptor interface.
+func (ld *kubernetesLockDescriptor) Check(ctx context.Context) error {
+	ld.mu.Lock()
+	err := ld.err
+	ld.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	_, err = ld.get(ctx)
+	return err
+}
+
+// Unlock is part of the topo.LockDescriptor interface.
+func (ld *kubernetesLockDescriptor) Unlock(ctx context.Context) error {
+	ld.cancel()
+	<-ld.done
+
+	lease, err := ld.get(ctx)
+	if err != nil {
+		return err
+	}
+	// The preconditions make sure the Lease wasn't taken over in between.
+	uid, resourceVersion := lease.UID, lease.ResourceVersion
+	err = ld.s.leases().Delete(ctx, ld.name, metav1.DeleteOptions{
+		Preconditions: &metav1.Preconditions{UID: &uid, ResourceVersion: &resourceVersion},
+	})
+	return convertError(err, ld.leasePath)
+}
+
+// setLeaseHolder makes holder the holder of the Lease, as of now.
+func setLeaseHolder(lease *coordinationv1.Lease, leasePath, holder, contents string, now metav1.MicroTime) {
+	durationSeconds := int32(leaseDuration / time.Second)
+	lease.Annotations = map[string]string{
+		lockPathAnnotation:     leasePath,
+		lockContentsAnnotation: contents,
+	}
+	lease.Spec = coordinationv1.LeaseSpec{
+		HolderIdentity:       &holder,
+		LeaseDurationSeconds: &durationSeconds,
+		AcquireTime:          &now,
+		RenewTime:            &now,
+	}
+}
+
+// holderOf returns the holder of the Lease.
+func holderOf(lease *coordinationv1.Lease) string {
+	if lease.Spec.HolderIdentity == nil {
+		return ""
+	}
+	return *lease.Spec.HolderIdentity
+}
+
+// leaseExpired returns true if the holder of the Lease didn't renew it in time.
+func leaseExpired(lease *coordinationv1.Lease, now time.Time) bool {
+	if lease.Spec.RenewTime == nil || lease.Spec.LeaseDurationSeconds == nil {
+		return true
+	}
+	return lease.Spec.RenewTime.Add(time.Duration(*lease.Spec.LeaseDurationSeconds) * time.Second).Before(now)
+}
+
+// newHolderIdentity returns a unique identity for a Lease holder.
+func newHolderIdentity() (string, error) {
+	hostname, err := os.Hostname()
+	if err != nil {
+		return "", err
+	}
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hostname + "-" + hex.EncodeToString(b), nil
+}
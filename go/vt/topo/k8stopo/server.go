@@ -0,0 +1,197 @@
+/*
+Copyright 2023 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+/*
+Package k8stopo implements topo.Server with the Kubernetes API server as the
+backend, so Vitess can run in Kubernetes without a separate etcd cluster.
+
+Topo files are stored in VitessTopoNode custom resources, see
+VitessTopoNodes-crd.yaml for their definition. The name of each object is
+the hash of the path of the file it holds, and all objects of a topo root
+carry a label with the hash of the root, so several cells can share a
+namespace. Locks and leader elections use coordination.k8s.io Leases.
+
+The server address is the address of the Kubernetes API server. It overrides
+the one of the kubeconfig given with --topo_k8s_kubeconfig, or of the in-cluster
+configuration when there is no kubeconfig.
+
+We follow these conventions within this package:
+
+  - Call convertError(err) on any errors returned from the Kubernetes client
+    library. Functions defined in this package can be assumed to have already
+    converted errors as necessary.
+*/
+package k8stopo
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"sync"
+
+	"github.com/spf13/pflag"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/dynamic/dynamicinformer"
+	"k8s.io/client-go/kubernetes"
+	coordinationv1client "k8s.io/client-go/kubernetes/typed/coordination/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/clientcmd"
+
+	"vitess.io/vitess/go/vt/servenv"
+	"vitess.io/vitess/go/vt/topo"
+)
+
+var (
+	kubeconfigPath string
+	kubeContext    string
+	namespace      string
+)
+
+// Factory is the Kubernetes topo.Factory implementation.
+type Factory struct{}
+
+// HasGlobalReadOnlyCell is part of the topo.Factory interface.
+func (f Factory) HasGlobalReadOnlyCell(serverAddr, root string) bool {
+	return false
+}
+
+// Create is part of the topo.Factory interface.
+func (f Factory) Create(cell, serverAddr, root string) (topo.Conn, error) {
+	return NewServer(serverAddr, root)
+}
+
+// Server is the implementation of topo.Server for Kubernetes.
+type Server struct {
+	// dynamicClient is used for the VitessTopoNode objects.
+	dynamicClient dynamic.Interface
+
+	// kubeClient is used for the Leases.
+	kubeClient kubernetes.Interface
+
+	// namespace is the namespace all the objects are in.
+	namespace string
+
+	// root is the root path for this client.
+	root string
+
+	// rootSelector selects the objects of this root.
+	rootSelector string
+
+	// nodeInformer caches the VitessTopoNode objects of this root, to
+	// serve watches. It is started by the first watch.
+	nodeInformer      cache.SharedIndexInformer
+	startInformerOnce sync.Once
+
+	running chan struct{}
+}
+
+func init() {
+	for _, cmd := range topo.FlagBinaries {
+		servenv.OnParseFor(cmd, registerK8sTopoFlags)
+	}
+	topo.RegisterFactory("k8s", Factory{})
+}
+
+func registerK8sTopoFlags(fs *pflag.FlagSet) {
+	fs.StringVar(&kubeconfigPath, "topo_k8s_kubeconfig", kubeconfigPath, "path to a valid kubeconfig file to connect to the Kubernetes topo server. The in-cluster configuration is used if empty.")
+	fs.StringVar(&kubeContext, "topo_k8s_context", kubeContext, "the kubeconfig context to use for the Kubernetes topo server")
+	fs.StringVar(&namespace, "topo_k8s_namespace", namespace, "the Kubernetes namespace to store the topo data in. Defaults to the namespace of the kubeconfig context, or of the pod when running in-cluster.")
+}
+
+// NewServer returns a new k8stopo.Server.
+func NewServer(serverAddr, root string) (*Server, error) {
+	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
+	loadingRules.ExplicitPath = kubeconfigPath
+	overrides := &clientcmd.ConfigOverrides{CurrentContext: kubeContext}
+	overrides.ClusterInfo.Server = serverAddr
+	clientConfig := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, overrides)
+
+	config, err := clientConfig.ClientConfig()
+	if err != nil {
+		return nil, err
+	}
+	ns := namespace
+	if ns == "" {
+		if ns, _, err = clientConfig.Namespace(); err != nil {
+			return nil, err
+		}
+	}
+
+	dynamicClient, err := dynamic.NewForConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	kubeClient, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	return NewServerWithClients(dynamicClient, kubeClient, ns, root), nil
+}
+
+// NewServerWithClients returns a new k8stopo.Server using the given clients,
+// and storing its data in the given namespace.
+func NewServerWithClients(dynamicClient dynamic.Interface, kubeClient kubernetes.Interface, namespace, root string) *Server {
+	rootSelector := labels.SelectorFromSet(labels.Set{rootLabel: hashOf(root)}).String()
+	informerFactory := dynamicinformer.NewFilteredDynamicSharedInformerFactory(dynamicClient, 0, namespace, func(options *metav1.ListOptions) {
+		options.LabelSelector = rootSelector
+	})
+	return &Server{
+		dynamicClient: dynamicClient,
+		kubeClient:    kubeClient,
+		namespace:     namespace,
+		root:          root,
+		rootSelector:  rootSelector,
+		nodeInformer:  informerFactory.ForResource(vitessTopoNodes).Informer(),
+		running:       make(chan struct{}),
+	}
+}
+
+// nodes returns the client for the VitessTopoNode objects.
+func (s *Server) nodes() dynamic.ResourceInterface {
+	return s.dynamicClient.Resource(vitessTopoNodes).Namespace(s.namespace)
+}
+
+// leases returns the client for the Leases.
+func (s *Server) leases() coordinationv1client.LeaseInterface {
+	return s.kubeClient.CoordinationV1().Leases(s.namespace)
+}
+
+// startInformer starts the node informer, if it isn't yet.
+func (s *Server) startInformer() {
+	s.startInformerOnce.Do(func() {
+		go s.nodeInformer.Run(s.running)
+	})
+}
+
+// Close implements topo.Server.Close.
+// It stops the informer and all the watches.
+func (s *Server) Close() {
+	close(s.running)
+}
+
+// hashOf returns the hex encoded SHA-256 of the given path, truncated to
+// 160 bits to fit in a label value. Topo paths can't be used as they are in
+// object names or label values.
+func hashOf(p string) string {
+	h := sha256.Sum256([]byte(p))
+	return hex.EncodeToString(h[:20])
+}
+
+// objectName returns the name of the object holding the given topo path.
+func objectName(nodePath string) string {
+	return "vt-" + hashOf(nodePath)
+}
@@ -0,0 +1,196 @@
+/*
+Copyright 2023 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package k8stopo
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+
+	"vitess.io/vitess/go/vt/topo"
+	"vitess.io/vitess/go/vt/topo/test"
+
+	topodatapb "vitess.io/vitess/go/vt/proto/topodata"
+)
+
+const testNamespace = "vitess"
+
+// fakeFactory is a topo.Factory creating servers on fake clientsets.
+type fakeFactory struct {
+	dynamicClient *dynamicfake.FakeDynamicClient
+	kubeClient    *kubefake.Clientset
+}
+
+func newFakeFactory() *fakeFactory {
+	f := &fakeFactory{
+		dynamicClient: dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+			vitessTopoNodes: vitessTopoNodeKind + "List",
+		}),
+		kubeClient: kubefake.NewSimpleClientset(),
+	}
+	var resourceVersion atomic.Int64
+	emulateResourceVersions(&f.dynamicClient.Fake, f.dynamicClient.Tracker(), &resourceVersion)
+	emulateResourceVersions(&f.kubeClient.Fake, f.kubeClient.Tracker(), &resourceVersion)
+	return f
+}
+
+// HasGlobalReadOnlyCell is part of the topo.Factory interface.
+func (f *fakeFactory) HasGlobalReadOnlyCell(serverAddr, root string) bool {
+	return false
+}
+
+// Create is part of the topo.Factory interface.
+func (f *fakeFactory) Create(cell, serverAddr, root string) (topo.Conn, error) {
+	return NewServerWithClients(f.dynamicClient, f.kubeClient, testNamespace, root), nil
+}
+
+// emulateResourceVersions makes a fake clientset set the resourceVersion of
+// the objects it writes, and reject updates of another resourceVersion, like
+// the API server does.
+func emulateResourceVersions(fake *k8stesting.Fake, tracker k8stesting.ObjectTracker, resourceVersion *atomic.Int64) {
+	fake.PrependReactor("*", "*", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		gvr, ns := action.GetResource(), action.GetNamespace()
+		switch action := action.(type) {
+		case k8stesting.CreateActionImpl:
+			obj := action.GetObject()
+			accessor, err := meta.Accessor(obj)
+			if err != nil {
+				return true, nil, err
+			}
+			accessor.SetResourceVersion(fmt.Sprintf("%d", resourceVersion.Add(1)))
+			return true, obj, tracker.Create(gvr, obj, ns)
+		case k8stesting.UpdateActionImpl:
+			obj := action.GetObject()
+			accessor, err := meta.Accessor(obj)
+			if err != nil {
+				return true, nil, err
+			}
+			existing, err := tracker.Get(gvr, ns, accessor.GetName())
+			if err != nil {
+				return true, nil, err
+			}
+			existingAccessor, err := meta.Accessor(existing)
+			if err != nil {
+				return true, nil, err
+			}
+			if accessor.GetResourceVersion() != existingAccessor.GetResourceVersion() {
+				return true, nil, apierrors.NewConflict(gvr.GroupResource(), accessor.GetName(), fmt.Errorf("the object has been modified"))
+			}
+			accessor.SetResourceVersion(fmt.Sprintf("%d", resourceVersion.Add(1)))
+			return true, obj, tracker.Update(gvr, obj, ns)
+		}
+		return false, nil, nil
+	})
+}
+
+// newTestServer returns a topo.Server with a global cell, and a
+// test.LocalCellName cell, on fake clientsets.
+func newTestServer(t *testing.T) *topo.Server {
+	ts, err := topo.NewWithFactory(newFakeFactory(), "fake", "/vitess/global")
+	require.NoError(t, err)
+	err = ts.CreateCellInfo(context.Background(), test.LocalCellName, &topodatapb.CellInfo{
+		ServerAddress: "fake",
+		Root:          path.Join("/vitess", test.LocalCellName),
+	})
+	require.NoError(t, err)
+	return ts
+}
+
+func TestK8sTopo(t *testing.T) {
+	// Run the TopoServerTestSuite tests.
+	test.TopoServerTestSuite(t, func() *topo.Server {
+		return newTestServer(t)
+	}, []string{})
+}
+
+func TestLockTakeOverExpiredLease(t *testing.T) {
+	ctx := context.Background()
+	conn, err := newFakeFactory().Create(topo.GlobalCell, "fake", "/vitess/global")
+	require.NoError(t, err)
+	defer conn.Close()
+	s := conn.(*Server)
+
+	_, err = conn.Create(ctx, "/keyspaces/ks/Keyspace", []byte("ks"))
+	require.NoError(t, err)
+	ld1, err := conn.Lock(ctx, "/keyspaces/ks", "first")
+	require.NoError(t, err)
+
+	// The first holder stops renewing the lease, and it expires.
+	kld1 := ld1.(*kubernetesLockDescriptor)
+	kld1.cancel()
+	<-kld1.done
+	lease, err := s.leases().Get(ctx, kld1.name, metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "first", lease.Annotations[lockContentsAnnotation])
+	lease.Spec.RenewTime = &metav1.MicroTime{Time: time.Now().Add(-2 * leaseDuration)}
+	_, err = s.leases().Update(ctx, lease, metav1.UpdateOptions{})
+	require.NoError(t, err)
+
+	lockCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	ld2, err := conn.Lock(lockCtx, "/keyspaces/ks", "second")
+	require.NoError(t, err)
+
+	assert.ErrorContains(t, ld1.Check(ctx), "was taken over")
+	assert.Error(t, ld1.Unlock(ctx))
+	assert.NoError(t, ld2.Check(ctx))
+	assert.NoError(t, ld2.Unlock(ctx))
+}
+
+func TestNodeWatcherStopWithFullChannel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	w := &nodeWatcher{
+		ctx:           ctx,
+		notifications: make(chan *topo.WatchDataRecursive, 1),
+	}
+	w.send(&topo.WatchDataRecursive{Path: "/first"})
+
+	// Nobody reads the channel: once the context is canceled, neither
+	// send nor stop may block.
+	cancel()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		w.send(&topo.WatchDataRecursive{Path: "/second"})
+		w.stop(&topo.WatchDataRecursive{WatchData: topo.WatchData{Err: topo.NewError(topo.Interrupted, "/")}})
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("nodeWatcher blocked on a full channel after the context was canceled")
+	}
+
+	wd, ok := <-w.notifications
+	require.True(t, ok)
+	assert.Equal(t, "/first", wd.Path)
+	_, ok = <-w.notifications
+	assert.False(t, ok)
+}
@@ -0,0 +1,244 @@
+/*
+Copyright 2023 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package k8stopo
+
+import (
+	"context"
+	"path"
+	"strings"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/tools/cache"
+
+	"vitess.io/vitess/go/vt/log"
+	"vitess.io/vitess/go/vt/topo"
+)
+
+// Watch is part of the topo.Conn interface.
+func (s *Server) Watch(ctx context.Context, filePath string) (*topo.WatchData, <-chan *topo.WatchData, error) {
+	nodePath := path.Join(s.root, filePath)
+
+	// Get the initial version of the file from the API server, the
+	// informer may not have caught up with recent changes yet.
+	initialCtx, initialCancel := context.WithTimeout(ctx, topo.RemoteOperationTimeout)
+	defer initialCancel()
+	contents, version, err := s.Get(initialCtx, filePath)
+	if err != nil {
+		return nil, nil, err
+	}
+	wd := &topo.WatchData{
+		Contents: contents,
+		Version:  version,
+	}
+
+	changes, err := s.watch(ctx, nodePath, func(key string) bool {
+		return key == nodePath
+	}, true /* stopOnDelete */)
+	if err != nil {
+		return nil, nil, err
+	}
+	notifications := make(chan *topo.WatchData, 10)
+	go func() {
+		defer close(notifications)
+		for wd := range changes {
+			// Once the watch is canceled, the caller may have stopped
+			// reading: drop the notifications that don't fit, until the
+			// watch closes its channel.
+			select {
+			case notifications <- &wd.WatchData:
+			default:
+				select {
+				case notifications <- &wd.WatchData:
+				case <-ctx.Done():
+				}
+			}
+		}
+	}()
+	return wd, notifications, nil
+}
+
+// WatchRecursive is part of the topo.Conn interface.
+func (s *Server) WatchRecursive(ctx context.Context, dirpath string) ([]*topo.WatchDataRecursive, <-chan *topo.WatchDataRecursive, error) {
+	nodePath := path.Join(s.root, dirpath)
+	if !strings.HasSuffix(nodePath, "/") {
+		nodePath = nodePath + "/"
+	}
+
+	// Get the initial version of the files from the API server.
+	initialCtx, initialCancel := context.WithTimeout(ctx, topo.RemoteOperationTimeout)
+	defer initialCancel()
+	nodes, err := s.listNodes(initialCtx)
+	if err != nil {
+		return nil, nil, convertError(err, nodePath)
+	}
+	var initialwd []*topo.WatchDataRecursive
+	for _, node := range nodes {
+		if strings.HasPrefix(node.key, nodePath) {
+			initialwd = append(initialwd, &topo.WatchDataRecursive{
+				Path: node.key,
+				WatchData: topo.WatchData{
+					Contents: node.value,
+					Version:  node.version,
+				},
+			})
+		}
+	}
+
+	notifications, err := s.watch(ctx, nodePath, func(key string) bool {
+		return strings.HasPrefix(key, nodePath)
+	}, false /* stopOnDelete */)
+	if err != nil {
+		return nil, nil, err
+	}
+	return initialwd, notifications, nil
+}
+
+// watch sends the changes of the files matching the given function, as
+// seen by the node informer, to the returned channel, until the context
+// is canceled or, if stopOnDelete is set, one of the files is deleted.
+// The changes start with the current version of the files, which can
+// be older than what the caller already got from the API server.
+func (s *Server) watch(ctx context.Context, nodePath string, match func(key string) bool, stopOnDelete bool) (<-chan *topo.WatchDataRecursive, error) {
+	watchCtx, watchCancel := context.WithCancel(ctx)
+	w := &nodeWatcher{
+		ctx:           watchCtx,
+		notifications: make(chan *topo.WatchDataRecursive, 10),
+	}
+
+	onChange := func(obj any) {
+		u, ok := obj.(*unstructured.Unstructured)
+		if !ok {
+			return
+		}
+		key, value, err := nodeData(u)
+		if err != nil {
+			log.Warningf("Skipping bad VitessTopoNode %v: %v", u.GetName(), err)
+			return
+		}
+		if match(key) {
+			w.send(&topo.WatchDataRecursive{
+				Path: key,
+				WatchData: topo.WatchData{
+					Contents: value,
+					Version:  KubernetesVersion(u.GetResourceVersion()),
+				},
+			})
+		}
+	}
+	onDelete := func(obj any) {
+		if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+			obj = tombstone.Obj
+		}
+		u, ok := obj.(*unstructured.Unstructured)
+		if !ok {
+			return
+		}
+		key, _, err := nodeData(u)
+		if err != nil || !match(key) {
+			return
+		}
+		wd := &topo.WatchDataRecursive{
+			Path: key,
+			WatchData: topo.WatchData{
+				Err: topo.NewError(topo.NoNode, key),
+			},
+		}
+		if !stopOnDelete {
+			w.send(wd)
+			return
+		}
+		// Node is gone, send a final notice.
+		w.stop(wd)
+		watchCancel()
+	}
+
+	s.startInformer()
+	registration, err := s.nodeInformer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: onChange,
+		UpdateFunc: func(_, obj any) {
+			onChange(obj)
+		},
+		DeleteFunc: onDelete,
+	})
+	if err != nil {
+		watchCancel()
+		return nil, err
+	}
+
+	go func() {
+		defer watchCancel()
+		select {
+		case <-s.running:
+		case <-watchCtx.Done():
+		}
+		if err := s.nodeInformer.RemoveEventHandler(registration); err != nil {
+			log.Warningf("Failed to remove the event handler of the watch on %v: %v", nodePath, err)
+		}
+		// This includes context cancellation errors.
+		w.stop(&topo.WatchDataRecursive{
+			WatchData: topo.WatchData{Err: topo.NewError(topo.Interrupted, nodePath)},
+		})
+	}()
+	return w.notifications, nil
+}
+
+// nodeWatcher sends the changes of a watch to its channel. The informer
+// calls the event handlers of a watch from a single goroutine, and the
+// channel is closed once the watch is over.
+type nodeWatcher struct {
+	ctx           context.Context
+	notifications chan *topo.WatchDataRecursive
+
+	mu     sync.Mutex
+	closed bool
+}
+
+// send sends a change, unless the watch is over.
+func (w *nodeWatcher) send(wd *topo.WatchDataRecursive) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return
+	}
+	select {
+	case w.notifications <- wd:
+	case <-w.ctx.Done():
+	}
+}
+
+// stop sends the final notification and closes the channel, unless the
+// watch is already over. The final notification is dropped if the channel
+// is full and the context is canceled, so a caller that stopped reading
+// cannot block the watch forever.
+func (w *nodeWatcher) stop(wd *topo.WatchDataRecursive) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return
+	}
+	select {
+	case w.notifications <- wd:
+	default:
+		select {
+		case w.notifications <- wd:
+		case <-w.ctx.Done():
+		}
+	}
+	close(w.notifications)
+	w.closed = true
+}
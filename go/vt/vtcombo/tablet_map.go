@@ -482,9 +482,9 @@ func (itc *internalTabletConn) Begin(
 }
 
 // Commit is part of queryservice.QueryService
-func (itc *internalTabletConn) Commit(ctx context.Context, target *querypb.Target, transactionID int64) (int64, error) {
-	rID, err := itc.tablet.qsc.QueryService().Commit(ctx, target, transactionID)
-	return rID, tabletconn.ErrorFromGRPC(vterrors.ToGRPC(err))
+func (itc *internalTabletConn) Commit(ctx context.Context, target *querypb.Target, transactionID int64) (queryservice.CommitState, error) {
+	state, err := itc.tablet.qsc.QueryService().Commit(ctx, target, transactionID)
+	return state, tabletconn.ErrorFromGRPC(vterrors.ToGRPC(err))
 }
 
 // Rollback is part of queryservice.QueryService
@@ -34,10 +34,12 @@ import (
 	"google.golang.org/protobuf/proto"
 
 	"vitess.io/vitess/go/event"
+	"vitess.io/vitess/go/mysql"
 	"vitess.io/vitess/go/netutil"
 	"vitess.io/vitess/go/protoutil"
 	"vitess.io/vitess/go/sets"
 	"vitess.io/vitess/go/sqlescape"
+	"vitess.io/vitess/go/sqltypes"
 	"vitess.io/vitess/go/trace"
 	"vitess.io/vitess/go/vt/callerid"
 	"vitess.io/vitess/go/vt/concurrency"
@@ -60,6 +62,7 @@ import (
 	"vitess.io/vitess/go/vt/vtctl/schematools"
 	"vitess.io/vitess/go/vt/vtctl/workflow"
 	"vitess.io/vitess/go/vt/vterrors"
+	"vitess.io/vitess/go/vt/vttablet/tabletserver/throttle/base"
 	"vitess.io/vitess/go/vt/vttablet/tmclient"
 
 	logutilpb "vitess.io/vitess/go/vt/proto/logutil"
@@ -1443,6 +1446,103 @@ func (s *VtctldServer) GetSchema(ctx context.Context, req *vtctldatapb.GetSchema
 	}, nil
 }
 
+// GetSchemaMigrations is part of the vtctlservicepb.VtctldServer interface.
+func (s *VtctldServer) GetSchemaMigrations(ctx context.Context, req *vtctldatapb.GetSchemaMigrationsRequest) (resp *vtctldatapb.GetSchemaMigrationsResponse, err error) {
+	span, ctx := trace.NewSpan(ctx, "VtctldServer.GetSchemaMigrations")
+	defer span.Finish()
+
+	defer panicHandler(&err)
+
+	span.Annotate("keyspace", req.Keyspace)
+	span.Annotate("uuid", req.Uuid)
+	span.Annotate("migration_context", req.MigrationContext)
+	span.Annotate("status", req.Status)
+	span.Annotate("order_descending", req.OrderDescending)
+	span.Annotate("limit", req.Limit)
+	span.Annotate("skip", req.Skip)
+
+	var condition string
+	switch {
+	case req.Uuid != "":
+		if !schema.IsOnlineDDLUUID(req.Uuid) {
+			return nil, vterrors.Errorf(vtrpc.Code_INVALID_ARGUMENT, "%s is not a valid online DDL UUID", req.Uuid)
+		}
+		condition, err = sqlparser.ParseAndBind("migration_uuid=%a", sqltypes.StringBindVariable(req.Uuid))
+	case req.MigrationContext != "":
+		condition, err = sqlparser.ParseAndBind("migration_context=%a", sqltypes.StringBindVariable(req.MigrationContext))
+	case req.Status != "":
+		condition, err = sqlparser.ParseAndBind("migration_status=%a", sqltypes.StringBindVariable(req.Status))
+	case req.Recent != nil:
+		var recent time.Duration
+		recent, _, err = protoutil.DurationFromProto(req.Recent)
+		condition = fmt.Sprintf("requested_timestamp > now() - interval %d second", int64(recent.Seconds()))
+	default:
+		condition = "migration_uuid like '%'"
+	}
+	if err != nil {
+		return nil, vterrors.Wrap(err, "failed to generate the schema migrations query")
+	}
+
+	order := "ASC"
+	if req.OrderDescending {
+		order = "DESC"
+	}
+	skipLimit := ""
+	if req.Limit > 0 {
+		skipLimit = fmt.Sprintf("LIMIT %v,%v", req.Skip, req.Limit)
+	}
+	query := fmt.Sprintf("select * from _vt.schema_migrations where %s order by `id` %s %s", condition, order, skipLimit)
+
+	shards, err := s.ts.FindAllShardsInKeyspace(ctx, req.Keyspace)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		m       sync.Mutex
+		wg      sync.WaitGroup
+		rec     concurrency.AllErrorRecorder
+		results = make(map[string]*sqltypes.Result, len(shards))
+	)
+	for _, shard := range shards {
+		if shard.PrimaryAlias == nil {
+			rec.RecordError(vterrors.Errorf(vtrpc.Code_FAILED_PRECONDITION, "shard %s/%s has no primary", req.Keyspace, shard.ShardName()))
+			continue
+		}
+
+		wg.Add(1)
+		go func(alias *topodatapb.TabletAlias) {
+			defer wg.Done()
+
+			ti, err := s.ts.GetTablet(ctx, alias)
+			if err != nil {
+				rec.RecordError(err)
+				return
+			}
+			qr, err := s.tmc.ExecuteFetchAsDba(ctx, ti.Tablet, false, &tabletmanagerdatapb.ExecuteFetchAsDbaRequest{
+				Query:   []byte(query),
+				MaxRows: 10000,
+			})
+			if err != nil {
+				rec.RecordError(vterrors.Wrapf(err, "ExecuteFetchAsDba(%v)", topoproto.TabletAliasString(alias)))
+				return
+			}
+
+			m.Lock()
+			defer m.Unlock()
+			results[topoproto.TabletAliasString(alias)] = sqltypes.Proto3ToResult(qr)
+		}(shard.PrimaryAlias)
+	}
+	wg.Wait()
+	if rec.HasErrors() {
+		return nil, rec.Error()
+	}
+
+	return &vtctldatapb.GetSchemaMigrationsResponse{
+		Result: sqltypes.ResultToProto3(combineTabletResults(results)),
+	}, nil
+}
+
 // GetShard is part of the vtctlservicepb.VtctldServer interface.
 func (s *VtctldServer) GetShard(ctx context.Context, req *vtctldatapb.GetShardRequest) (resp *vtctldatapb.GetShardResponse, err error) {
 	span, ctx := trace.NewSpan(ctx, "VtctldServer.GetShard")
@@ -1562,6 +1662,18 @@ func (s *VtctldServer) UpdateThrottlerConfig(ctx context.Context, req *vtctldata
 	if req.CheckAsCheckSelf && req.CheckAsCheckShard {
 		return nil, fmt.Errorf("--check-as-check-self and --check-as-check-shard are mutually exclusive")
 	}
+	metricName := base.DefaultMetricName
+	if req.MetricName != "" {
+		if metricName, err = base.ParseMetricName(req.MetricName); err != nil {
+			return nil, err
+		}
+	}
+	if len(req.AppCheckedMetrics) > 0 && req.AppName == "" {
+		return nil, fmt.Errorf("--app-metrics requires --app-name")
+	}
+	if _, err := base.ParseMetricNames(req.AppCheckedMetrics); err != nil {
+		return nil, err
+	}
 
 	update := func(throttlerConfig *topodatapb.ThrottlerConfig) *topodatapb.ThrottlerConfig {
 		if throttlerConfig == nil {
@@ -1570,7 +1682,21 @@ func (s *VtctldServer) UpdateThrottlerConfig(ctx context.Context, req *vtctldata
 		if throttlerConfig.ThrottledApps == nil {
 			throttlerConfig.ThrottledApps = make(map[string]*topodatapb.ThrottledAppRule)
 		}
-		if req.CustomQuerySet {
+		if throttlerConfig.MetricThresholds == nil {
+			throttlerConfig.MetricThresholds = make(map[string]float64)
+		}
+		if throttlerConfig.AppCheckedMetrics == nil {
+			throttlerConfig.AppCheckedMetrics = make(map[string]*topodatapb.ThrottlerConfig_MetricNames)
+		}
+		if metricName != base.DefaultMetricName {
+			// the threshold applies to a specific metric. We only allow positive values
+			if req.CustomQuerySet {
+				throttlerConfig.CustomQuery = req.CustomQuery
+			}
+			if req.Threshold > 0 {
+				throttlerConfig.MetricThresholds[metricName.String()] = req.Threshold
+			}
+		} else if req.CustomQuerySet {
 			// custom query provided
 			throttlerConfig.CustomQuery = req.CustomQuery
 			throttlerConfig.Threshold = req.Threshold // allowed to be zero/negative because who knows what kind of custom query this is
@@ -1595,6 +1721,14 @@ func (s *VtctldServer) UpdateThrottlerConfig(ctx context.Context, req *vtctldata
 		if req.ThrottledApp != nil && req.ThrottledApp.Name != "" {
 			throttlerConfig.ThrottledApps[req.ThrottledApp.Name] = req.ThrottledApp
 		}
+		if req.AppName != "" {
+			if len(req.AppCheckedMetrics) == 0 {
+				// back to checking the default metric only
+				delete(throttlerConfig.AppCheckedMetrics, req.AppName)
+			} else {
+				throttlerConfig.AppCheckedMetrics[req.AppName] = &topodatapb.ThrottlerConfig_MetricNames{Names: req.AppCheckedMetrics}
+			}
+		}
 		return throttlerConfig
 	}
 
@@ -1963,6 +2097,7 @@ func (s *VtctldServer) GetWorkflows(ctx context.Context, req *vtctldatapb.GetWor
 	defer panicHandler(&err)
 
 	span.Annotate("keyspace", req.Keyspace)
+	span.Annotate("workflow", req.Workflow)
 	span.Annotate("active_only", req.ActiveOnly)
 
 	resp, err = s.ws.GetWorkflows(ctx, req)
@@ -2247,6 +2382,42 @@ func (s *VtctldServer) InitShardPrimaryLocked(
 	return nil
 }
 
+// MaterializeCreate is part of the vtctlservicepb.VtctldServer interface.
+func (s *VtctldServer) MaterializeCreate(ctx context.Context, req *vtctldatapb.MaterializeCreateRequest) (resp *vtctldatapb.MaterializeCreateResponse, err error) {
+	span, ctx := trace.NewSpan(ctx, "VtctldServer.MaterializeCreate")
+	defer span.Finish()
+
+	defer panicHandler(&err)
+
+	span.Annotate("workflow", req.GetSettings().GetWorkflow())
+	span.Annotate("source_keyspace", req.GetSettings().GetSourceKeyspace())
+	span.Annotate("target_keyspace", req.GetSettings().GetTargetKeyspace())
+	span.Annotate("cells", req.GetSettings().GetCell())
+	span.Annotate("tablet_types", req.GetSettings().GetTabletTypes())
+
+	resp, err = s.ws.MaterializeCreate(ctx, req)
+	return resp, err
+}
+
+// MoveTablesCreate is part of the vtctlservicepb.VtctldServer interface.
+func (s *VtctldServer) MoveTablesCreate(ctx context.Context, req *vtctldatapb.MoveTablesCreateRequest) (resp *vtctldatapb.WorkflowStatusResponse, err error) {
+	span, ctx := trace.NewSpan(ctx, "VtctldServer.MoveTablesCreate")
+	defer span.Finish()
+
+	defer panicHandler(&err)
+
+	span.Annotate("workflow", req.Workflow)
+	span.Annotate("source_keyspace", req.SourceKeyspace)
+	span.Annotate("target_keyspace", req.TargetKeyspace)
+	span.Annotate("cells", req.Cells)
+	span.Annotate("tablet_types", req.TabletTypes)
+	span.Annotate("on_ddl", req.OnDdl)
+	span.Annotate("auto_start", req.AutoStart)
+
+	resp, err = s.ws.MoveTablesCreate(ctx, req)
+	return resp, err
+}
+
 // PingTablet is part of the vtctlservicepb.VtctldServer interface.
 func (s *VtctldServer) PingTablet(ctx context.Context, req *vtctldatapb.PingTabletRequest) (resp *vtctldatapb.PingTabletResponse, err error) {
 	span, ctx := trace.NewSpan(ctx, "VtctldServer.PingTablet")
@@ -2337,6 +2508,69 @@ func (s *VtctldServer) PlannedReparentShard(ctx context.Context, req *vtctldatap
 	return resp, err
 }
 
+// PruneBackups is part of the vtctlservicepb.VtctldServer interface.
+func (s *VtctldServer) PruneBackups(ctx context.Context, req *vtctldatapb.PruneBackupsRequest) (resp *vtctldatapb.PruneBackupsResponse, err error) {
+	span, ctx := trace.NewSpan(ctx, "VtctldServer.PruneBackups")
+	defer span.Finish()
+
+	defer panicHandler(&err)
+
+	bucket := filepath.Join(req.Keyspace, req.Shard)
+
+	span.Annotate("keyspace", req.Keyspace)
+	span.Annotate("shard", req.Shard)
+	span.Annotate("bucket", bucket)
+	span.Annotate("keep_last", req.KeepLast)
+	span.Annotate("keep_daily_days", req.KeepDailyDays)
+	span.Annotate("prune_incomplete", req.PruneIncomplete)
+	span.Annotate("dry_run", req.DryRun)
+
+	// Without a retention, every backup but the most recent one would be pruned.
+	if req.KeepLast == 0 && req.KeepDailyDays == 0 {
+		err = vterrors.Errorf(vtrpc.Code_INVALID_ARGUMENT, "at least one of keep_last or keep_daily_days is required")
+		return nil, err
+	}
+
+	bs, err := backupstorage.GetBackupStorage()
+	if err != nil {
+		return nil, err
+	}
+	defer bs.Close()
+
+	bhs, err := bs.ListBackups(ctx, bucket)
+	if err != nil {
+		return nil, err
+	}
+
+	policy := mysqlctl.BackupRetentionPolicy{
+		KeepLast:        int(req.KeepLast),
+		KeepDailyDays:   int(req.KeepDailyDays),
+		PruneIncomplete: req.PruneIncomplete,
+	}
+	prune := mysqlctl.FindBackupsToPrune(ctx, logutil.NewConsoleLogger(), policy, bhs, time.Now())
+
+	pruned := make(map[string]bool, len(prune))
+	resp = &vtctldatapb.PruneBackupsResponse{}
+	for _, bh := range prune {
+		if !req.DryRun {
+			log.Infof("Removing backup %v/%v", bucket, bh.Name())
+			if err = bs.RemoveBackup(ctx, bucket, bh.Name()); err != nil {
+				err = fmt.Errorf("cannot remove backup %v/%v: %w", bucket, bh.Name(), err)
+				return nil, err
+			}
+		}
+		pruned[bh.Name()] = true
+		resp.PrunedBackups = append(resp.PrunedBackups, bh.Name())
+	}
+	for _, bh := range bhs {
+		if !pruned[bh.Name()] {
+			resp.KeptBackups = append(resp.KeptBackups, bh.Name())
+		}
+	}
+
+	return resp, nil
+}
+
 // RebuildKeyspaceGraph is part of the vtctlservicepb.VtctldServer interface.
 func (s *VtctldServer) RebuildKeyspaceGraph(ctx context.Context, req *vtctldatapb.RebuildKeyspaceGraphRequest) (resp *vtctldatapb.RebuildKeyspaceGraphResponse, err error) {
 	span, ctx := trace.NewSpan(ctx, "VtctldServer.RebuildKeyspaceGraph")
@@ -2706,6 +2940,26 @@ func (s *VtctldServer) ReparentTablet(ctx context.Context, req *vtctldatapb.Repa
 	}, nil
 }
 
+// ReshardCreate is part of the vtctlservicepb.VtctldServer interface.
+func (s *VtctldServer) ReshardCreate(ctx context.Context, req *vtctldatapb.ReshardCreateRequest) (resp *vtctldatapb.WorkflowStatusResponse, err error) {
+	span, ctx := trace.NewSpan(ctx, "VtctldServer.ReshardCreate")
+	defer span.Finish()
+
+	defer panicHandler(&err)
+
+	span.Annotate("keyspace", req.Keyspace)
+	span.Annotate("workflow", req.Workflow)
+	span.Annotate("source_shards", req.SourceShards)
+	span.Annotate("target_shards", req.TargetShards)
+	span.Annotate("cells", req.Cells)
+	span.Annotate("tablet_types", req.TabletTypes)
+	span.Annotate("on_ddl", req.OnDdl)
+	span.Annotate("auto_start", req.AutoStart)
+
+	resp, err = s.ws.ReshardCreate(ctx, req)
+	return resp, err
+}
+
 func (s *VtctldServer) RestoreFromBackup(req *vtctldatapb.RestoreFromBackupRequest, stream vtctlservicepb.Vtctld_RestoreFromBackupServer) (err error) {
 	span, ctx := trace.NewSpan(stream.Context(), "VtctldServer.RestoreFromBackup")
 	defer span.Finish()
@@ -2717,6 +2971,10 @@ func (s *VtctldServer) RestoreFromBackup(req *vtctldatapb.RestoreFromBackupReque
 	if !backupTime.IsZero() {
 		span.Annotate("backup_timestamp", backupTime.Format(mysqlctl.BackupTimestampFormat))
 	}
+	restoreToTimestamp := protoutil.TimeFromProto(req.RestoreToTimestamp)
+	if !restoreToTimestamp.IsZero() {
+		span.Annotate("restore_to_timestamp", restoreToTimestamp.Format(time.RFC3339))
+	}
 
 	ti, err := s.ts.GetTablet(ctx, req.TabletAlias)
 	if err != nil {
@@ -2727,9 +2985,10 @@ func (s *VtctldServer) RestoreFromBackup(req *vtctldatapb.RestoreFromBackupReque
 	span.Annotate("shard", ti.Shard)
 
 	r := &tabletmanagerdatapb.RestoreFromBackupRequest{
-		BackupTime:   req.BackupTime,
-		RestoreToPos: req.RestoreToPos,
-		DryRun:       req.DryRun,
+		BackupTime:         req.BackupTime,
+		RestoreToPos:       req.RestoreToPos,
+		RestoreToTimestamp: req.RestoreToTimestamp,
+		DryRun:             req.DryRun,
 	}
 	logStream, err := s.tmc.RestoreFromBackup(ctx, ti.Tablet, r)
 	if err != nil {
@@ -2758,7 +3017,7 @@ func (s *VtctldServer) RestoreFromBackup(req *vtctldatapb.RestoreFromBackupReque
 			if mysqlctl.DisableActiveReparents {
 				return nil
 			}
-			if req.RestoreToPos != "" && !req.DryRun {
+			if (req.RestoreToPos != "" || !restoreToTimestamp.IsZero()) && !req.DryRun {
 				// point in time recovery. Do not restore replication
 				return nil
 			}
@@ -3725,6 +3984,71 @@ func (s *VtctldServer) Validate(ctx context.Context, req *vtctldatapb.ValidateRe
 	return resp, err
 }
 
+// ValidateBackup is part of the vtctlservicepb.VtctldServer interface.
+func (s *VtctldServer) ValidateBackup(ctx context.Context, req *vtctldatapb.ValidateBackupRequest) (resp *vtctldatapb.ValidateBackupResponse, err error) {
+	span, ctx := trace.NewSpan(ctx, "VtctldServer.ValidateBackup")
+	defer span.Finish()
+
+	defer panicHandler(&err)
+
+	bucket := filepath.Join(req.Keyspace, req.Shard)
+
+	span.Annotate("keyspace", req.Keyspace)
+	span.Annotate("shard", req.Shard)
+	span.Annotate("bucket", bucket)
+	span.Annotate("backup_name", req.Name)
+	span.Annotate("test_restore", req.TestRestore)
+
+	if req.TestRestore && (req.ScratchTabletUid == 0 || req.ScratchMysqlPort == 0) {
+		err = vterrors.Errorf(vtrpc.Code_INVALID_ARGUMENT, "scratch tablet uid and mysql port are required for a test restore")
+		return nil, err
+	}
+
+	bs, err := backupstorage.GetBackupStorage()
+	if err != nil {
+		return nil, err
+	}
+	defer bs.Close()
+
+	bhs, err := bs.ListBackups(ctx, bucket)
+	if err != nil {
+		return nil, err
+	}
+
+	var bh backupstorage.BackupHandle
+	for _, h := range bhs {
+		if h.Name() == req.Name {
+			bh = h
+			break
+		}
+	}
+	if bh == nil {
+		err = vterrors.Errorf(vtrpc.Code_NOT_FOUND, "backup %v not found in %v", req.Name, bucket)
+		return nil, err
+	}
+
+	validation, err := mysqlctl.ValidateBackup(ctx, bh)
+	if err != nil {
+		return nil, err
+	}
+
+	resp = &vtctldatapb.ValidateBackupResponse{
+		FilesChecked:  uint32(validation.FilesChecked),
+		BytesChecked:  uint64(validation.BytesChecked),
+		HashesChecked: validation.HashesChecked,
+	}
+
+	if req.TestRestore {
+		pos, err := mysqlctl.RestoreToScratch(ctx, logutil.NewConsoleLogger(), req.Keyspace, req.Shard, validation.Manifest, req.ScratchTabletUid, int(req.ScratchMysqlPort))
+		if err != nil {
+			return nil, err
+		}
+		resp.RestoredPosition = mysql.EncodePosition(pos)
+	}
+
+	return resp, nil
+}
+
 // ValidateKeyspace is part of the vtctlservicepb.VtctldServer interface.
 func (s *VtctldServer) ValidateKeyspace(ctx context.Context, req *vtctldatapb.ValidateKeyspaceRequest) (resp *vtctldatapb.ValidateKeyspaceResponse, err error) {
 	span, ctx := trace.NewSpan(ctx, "VtctldServer.ValidateKeyspace")
@@ -4346,6 +4670,148 @@ func (s *VtctldServer) ValidateVSchema(ctx context.Context, req *vtctldatapb.Val
 	return resp, err
 }
 
+// VDiffCreate is part of the vtctlservicepb.VtctldServer interface.
+func (s *VtctldServer) VDiffCreate(ctx context.Context, req *vtctldatapb.VDiffCreateRequest) (resp *vtctldatapb.VDiffCreateResponse, err error) {
+	span, ctx := trace.NewSpan(ctx, "VtctldServer.VDiffCreate")
+	defer span.Finish()
+
+	defer panicHandler(&err)
+
+	span.Annotate("keyspace", req.TargetKeyspace)
+	span.Annotate("workflow", req.Workflow)
+	span.Annotate("uuid", req.Uuid)
+
+	resp, err = s.ws.VDiffCreate(ctx, req)
+	return resp, err
+}
+
+// VDiffDelete is part of the vtctlservicepb.VtctldServer interface.
+func (s *VtctldServer) VDiffDelete(ctx context.Context, req *vtctldatapb.VDiffDeleteRequest) (resp *vtctldatapb.VDiffDeleteResponse, err error) {
+	span, ctx := trace.NewSpan(ctx, "VtctldServer.VDiffDelete")
+	defer span.Finish()
+
+	defer panicHandler(&err)
+
+	span.Annotate("keyspace", req.TargetKeyspace)
+	span.Annotate("workflow", req.Workflow)
+	span.Annotate("argument", req.Arg)
+
+	resp, err = s.ws.VDiffDelete(ctx, req)
+	return resp, err
+}
+
+// VDiffResume is part of the vtctlservicepb.VtctldServer interface.
+func (s *VtctldServer) VDiffResume(ctx context.Context, req *vtctldatapb.VDiffResumeRequest) (resp *vtctldatapb.VDiffResumeResponse, err error) {
+	span, ctx := trace.NewSpan(ctx, "VtctldServer.VDiffResume")
+	defer span.Finish()
+
+	defer panicHandler(&err)
+
+	span.Annotate("keyspace", req.TargetKeyspace)
+	span.Annotate("workflow", req.Workflow)
+	span.Annotate("uuid", req.Uuid)
+
+	resp, err = s.ws.VDiffResume(ctx, req)
+	return resp, err
+}
+
+// VDiffShow is part of the vtctlservicepb.VtctldServer interface.
+func (s *VtctldServer) VDiffShow(ctx context.Context, req *vtctldatapb.VDiffShowRequest) (resp *vtctldatapb.VDiffShowResponse, err error) {
+	span, ctx := trace.NewSpan(ctx, "VtctldServer.VDiffShow")
+	defer span.Finish()
+
+	defer panicHandler(&err)
+
+	span.Annotate("keyspace", req.TargetKeyspace)
+	span.Annotate("workflow", req.Workflow)
+	span.Annotate("argument", req.Arg)
+
+	resp, err = s.ws.VDiffShow(ctx, req)
+	return resp, err
+}
+
+// VDiffStop is part of the vtctlservicepb.VtctldServer interface.
+func (s *VtctldServer) VDiffStop(ctx context.Context, req *vtctldatapb.VDiffStopRequest) (resp *vtctldatapb.VDiffStopResponse, err error) {
+	span, ctx := trace.NewSpan(ctx, "VtctldServer.VDiffStop")
+	defer span.Finish()
+
+	defer panicHandler(&err)
+
+	span.Annotate("keyspace", req.TargetKeyspace)
+	span.Annotate("workflow", req.Workflow)
+	span.Annotate("uuid", req.Uuid)
+
+	resp, err = s.ws.VDiffStop(ctx, req)
+	return resp, err
+}
+
+// WorkflowComplete is part of the vtctlservicepb.VtctldServer interface.
+func (s *VtctldServer) WorkflowComplete(ctx context.Context, req *vtctldatapb.WorkflowCompleteRequest) (resp *vtctldatapb.WorkflowCompleteResponse, err error) {
+	span, ctx := trace.NewSpan(ctx, "VtctldServer.WorkflowComplete")
+	defer span.Finish()
+
+	defer panicHandler(&err)
+
+	span.Annotate("keyspace", req.Keyspace)
+	span.Annotate("workflow", req.Workflow)
+	span.Annotate("keep_data", req.KeepData)
+	span.Annotate("keep_routing_rules", req.KeepRoutingRules)
+	span.Annotate("rename_tables", req.RenameTables)
+	span.Annotate("dry_run", req.DryRun)
+
+	resp, err = s.ws.WorkflowComplete(ctx, req)
+	return resp, err
+}
+
+// WorkflowDelete is part of the vtctlservicepb.VtctldServer interface.
+func (s *VtctldServer) WorkflowDelete(ctx context.Context, req *vtctldatapb.WorkflowDeleteRequest) (resp *vtctldatapb.WorkflowDeleteResponse, err error) {
+	span, ctx := trace.NewSpan(ctx, "VtctldServer.WorkflowDelete")
+	defer span.Finish()
+
+	defer panicHandler(&err)
+
+	span.Annotate("keyspace", req.Keyspace)
+	span.Annotate("workflow", req.Workflow)
+	span.Annotate("keep_data", req.KeepData)
+	span.Annotate("keep_routing_rules", req.KeepRoutingRules)
+	span.Annotate("dry_run", req.DryRun)
+
+	resp, err = s.ws.WorkflowDelete(ctx, req)
+	return resp, err
+}
+
+// WorkflowStatus is part of the vtctlservicepb.VtctldServer interface.
+func (s *VtctldServer) WorkflowStatus(ctx context.Context, req *vtctldatapb.WorkflowStatusRequest) (resp *vtctldatapb.WorkflowStatusResponse, err error) {
+	span, ctx := trace.NewSpan(ctx, "VtctldServer.WorkflowStatus")
+	defer span.Finish()
+
+	defer panicHandler(&err)
+
+	span.Annotate("keyspace", req.Keyspace)
+	span.Annotate("workflow", req.Workflow)
+
+	resp, err = s.ws.WorkflowStatus(ctx, req)
+	return resp, err
+}
+
+// WorkflowSwitchTraffic is part of the vtctlservicepb.VtctldServer interface.
+func (s *VtctldServer) WorkflowSwitchTraffic(ctx context.Context, req *vtctldatapb.WorkflowSwitchTrafficRequest) (resp *vtctldatapb.WorkflowSwitchTrafficResponse, err error) {
+	span, ctx := trace.NewSpan(ctx, "VtctldServer.WorkflowSwitchTraffic")
+	defer span.Finish()
+
+	defer panicHandler(&err)
+
+	span.Annotate("keyspace", req.Keyspace)
+	span.Annotate("workflow", req.Workflow)
+	span.Annotate("cells", req.Cells)
+	span.Annotate("tablet_types", req.TabletTypes)
+	span.Annotate("direction", req.Direction)
+	span.Annotate("dry_run", req.DryRun)
+
+	resp, err = s.ws.WorkflowSwitchTraffic(ctx, req)
+	return resp, err
+}
+
 // WorkflowUpdate is part of the vtctlservicepb.VtctldServer interface.
 func (s *VtctldServer) WorkflowUpdate(ctx context.Context, req *vtctldatapb.WorkflowUpdateRequest) (resp *vtctldatapb.WorkflowUpdateResponse, err error) {
 	span, ctx := trace.NewSpan(ctx, "VtctldServer.WorkflowUpdate")
@@ -4474,3 +4940,25 @@ func (s *VtctldServer) diffVersion(ctx context.Context, primaryVersion string, p
 		er.RecordError(fmt.Errorf("primary %v version %v is different than replica %v version %v", topoproto.TabletAliasString(primaryAlias), primaryVersion, topoproto.TabletAliasString(alias), replicaVersion))
 	}
 }
+
+// combineTabletResults merges the per-tablet results of the same query into a
+// single result, prefixing each row with the alias of the tablet it came from.
+func combineTabletResults(results map[string]*sqltypes.Result) *sqltypes.Result {
+	qr := &sqltypes.Result{}
+	aliases := make([]string, 0, len(results))
+	for alias := range results {
+		aliases = append(aliases, alias)
+	}
+	sort.Strings(aliases)
+
+	for _, alias := range aliases {
+		result := results[alias]
+		if qr.Fields == nil {
+			qr.Fields = append([]*querypb.Field{{Name: "Tablet", Type: sqltypes.VarBinary}}, result.Fields...)
+		}
+		for _, row := range result.Rows {
+			qr.Rows = append(qr.Rows, append([]sqltypes.Value{sqltypes.NewVarBinary(alias)}, row...))
+		}
+	}
+	return qr
+}
@@ -18,10 +18,12 @@ package grpcvtctldserver
 
 import (
 	"context"
+	"encoding/base64"
 	"errors"
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
 	"sort"
 	"testing"
 	"time"
@@ -39,6 +41,7 @@ import (
 	hk "vitess.io/vitess/go/vt/hook"
 	"vitess.io/vitess/go/vt/logutil"
 	"vitess.io/vitess/go/vt/mysqlctl/backupstorage"
+	"vitess.io/vitess/go/vt/servenv"
 	"vitess.io/vitess/go/vt/topo"
 	"vitess.io/vitess/go/vt/topo/memorytopo"
 	"vitess.io/vitess/go/vt/topo/topoproto"
@@ -4806,6 +4809,63 @@ func TestGetSchema(t *testing.T) {
 	}
 }
 
+func TestGetSchemaMigrations(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	ts := memorytopo.NewServer("zone1")
+	testutil.AddTablets(ctx, t, ts, &testutil.AddTabletOptions{
+		AlsoSetShardPrimary: true,
+	}, &topodatapb.Tablet{
+		Alias:    &topodatapb.TabletAlias{Cell: "zone1", Uid: 100},
+		Keyspace: "testkeyspace",
+		Shard:    "-80",
+		Type:     topodatapb.TabletType_PRIMARY,
+	}, &topodatapb.Tablet{
+		Alias:    &topodatapb.TabletAlias{Cell: "zone1", Uid: 200},
+		Keyspace: "testkeyspace",
+		Shard:    "80-",
+		Type:     topodatapb.TabletType_PRIMARY,
+	})
+
+	fields := sqltypes.MakeTestFields("migration_uuid|migration_status", "varchar|varchar")
+	tmc := &testutil.TabletManagerClient{
+		ExecuteFetchAsDbaResults: map[string]struct {
+			Response *querypb.QueryResult
+			Error    error
+		}{
+			"zone1-0000000100": {
+				Response: sqltypes.ResultToProto3(sqltypes.MakeTestResult(fields, "uuid1|running")),
+			},
+			"zone1-0000000200": {
+				Response: sqltypes.ResultToProto3(sqltypes.MakeTestResult(fields, "uuid1|complete")),
+			},
+		},
+	}
+
+	vtctld := testutil.NewVtctldServerWithTabletManagerClient(t, ts, tmc, func(ts *topo.Server) vtctlservicepb.VtctldServer {
+		return NewVtctldServer(ts)
+	})
+
+	resp, err := vtctld.GetSchemaMigrations(ctx, &vtctldatapb.GetSchemaMigrationsRequest{
+		Keyspace: "testkeyspace",
+	})
+	require.NoError(t, err)
+
+	expected := sqltypes.MakeTestResult(sqltypes.MakeTestFields("Tablet|migration_uuid|migration_status", "varbinary|varchar|varchar"),
+		"zone1-0000000100|uuid1|running",
+		"zone1-0000000200|uuid1|complete",
+	)
+	assert.Equal(t, expected.Fields, sqltypes.Proto3ToResult(resp.Result).Fields)
+	assert.Equal(t, expected.Rows, sqltypes.Proto3ToResult(resp.Result).Rows)
+
+	_, err = vtctld.GetSchemaMigrations(ctx, &vtctldatapb.GetSchemaMigrationsRequest{
+		Keyspace: "testkeyspace",
+		Uuid:     "not-a-uuid",
+	})
+	assert.Error(t, err)
+}
+
 func TestGetShard(t *testing.T) {
 	t.Parallel()
 
@@ -6374,6 +6434,82 @@ func TestPlannedReparentShard(t *testing.T) {
 	}
 }
 
+func TestPruneBackups(t *testing.T) {
+	ctx := context.Background()
+	ts := memorytopo.NewServer()
+	vtctld := testutil.NewVtctldServerWithTabletManagerClient(t, ts, nil, func(ts *topo.Server) vtctlservicepb.VtctldServer {
+		return NewVtctldServer(ts)
+	})
+
+	setup := func() {
+		testutil.BackupStorage.Backups = map[string][]string{
+			"testkeyspace/-": {"backup1", "backup2", "backup3", "backup4"},
+		}
+		testutil.BackupStorage.Files = map[string][]byte{
+			"testkeyspace/-/backup1/MANIFEST": []byte(`{"BackupMethod": "builtin"}`),
+			"testkeyspace/-/backup2/MANIFEST": []byte(`{"BackupMethod": "builtin"}`),
+			"testkeyspace/-/backup3/MANIFEST": []byte(`{"BackupMethod": "builtin"}`),
+		}
+	}
+	defer func() { testutil.BackupStorage.Files = map[string][]byte{} }()
+
+	t.Run("dry run", func(t *testing.T) {
+		setup()
+		resp, err := vtctld.PruneBackups(ctx, &vtctldatapb.PruneBackupsRequest{
+			Keyspace: "testkeyspace",
+			Shard:    "-",
+			KeepLast: 2,
+			DryRun:   true,
+		})
+		require.NoError(t, err)
+		utils.MustMatch(t, &vtctldatapb.PruneBackupsResponse{
+			PrunedBackups: []string{"backup1"},
+			KeptBackups:   []string{"backup2", "backup3", "backup4"},
+		}, resp)
+		assert.Len(t, testutil.BackupStorage.Backups["testkeyspace/-"], 4)
+	})
+
+	t.Run("ok", func(t *testing.T) {
+		setup()
+		resp, err := vtctld.PruneBackups(ctx, &vtctldatapb.PruneBackupsRequest{
+			Keyspace:        "testkeyspace",
+			Shard:           "-",
+			KeepLast:        1,
+			PruneIncomplete: true,
+		})
+		require.NoError(t, err)
+		utils.MustMatch(t, &vtctldatapb.PruneBackupsResponse{
+			PrunedBackups: []string{"backup1", "backup2"},
+			KeptBackups:   []string{"backup3", "backup4"},
+		}, resp)
+		assert.Equal(t, []string{"backup3", "backup4"}, testutil.BackupStorage.Backups["testkeyspace/-"])
+	})
+
+	t.Run("listbackups error", func(t *testing.T) {
+		setup()
+		testutil.BackupStorage.ListBackupsError = assert.AnError
+		defer func() { testutil.BackupStorage.ListBackupsError = nil }()
+
+		_, err := vtctld.PruneBackups(ctx, &vtctldatapb.PruneBackupsRequest{
+			Keyspace: "testkeyspace",
+			Shard:    "-",
+			KeepLast: 1,
+		})
+		assert.Error(t, err)
+	})
+
+	t.Run("no retention", func(t *testing.T) {
+		setup()
+		_, err := vtctld.PruneBackups(ctx, &vtctldatapb.PruneBackupsRequest{
+			Keyspace:        "testkeyspace",
+			Shard:           "-",
+			PruneIncomplete: true,
+		})
+		assert.ErrorContains(t, err, "at least one of keep_last or keep_daily_days is required")
+		assert.Len(t, testutil.BackupStorage.Backups["testkeyspace/-"], 4)
+	})
+}
+
 func TestRebuildKeyspaceGraph(t *testing.T) {
 	t.Parallel()
 
@@ -8318,6 +8454,45 @@ func TestRestoreFromBackup(t *testing.T) {
 				assert.Equal(t, 3, len(responses), "expected 3 messages from restorefrombackupclient stream")
 			},
 		},
+		{
+			name: "point in time recovery to timestamp does not restore replication",
+			ts:   memorytopo.NewServer("zone1"),
+			tmc: &testutil.TabletManagerClient{
+				RestoreFromBackupResults: map[string]struct {
+					Events        []*logutilpb.Event
+					EventInterval time.Duration
+					EventJitter   time.Duration
+					ErrorAfter    time.Duration
+				}{
+					"zone1-0000000100": {
+						Events: []*logutilpb.Event{{}, {}},
+					},
+				},
+				// SetReplicationSource fails if called.
+			},
+			tablets: []*topodatapb.Tablet{
+				{
+					Alias: &topodatapb.TabletAlias{
+						Cell: "zone1",
+						Uid:  100,
+					},
+					Keyspace: "ks",
+					Shard:    "-",
+					Type:     topodatapb.TabletType_REPLICA,
+				},
+			},
+			req: &vtctldatapb.RestoreFromBackupRequest{
+				TabletAlias: &topodatapb.TabletAlias{
+					Cell: "zone1",
+					Uid:  100,
+				},
+				RestoreToTimestamp: protoutil.TimeToProto(time.Date(2023, 6, 1, 10, 15, 0, 0, time.UTC)),
+			},
+			assertion: func(t *testing.T, responses []*vtctldatapb.RestoreFromBackupResponse, err error) {
+				assert.ErrorIs(t, err, io.EOF, "expected Recv loop to end with io.EOF")
+				assert.Equal(t, 2, len(responses), "expected 2 messages from restorefrombackupclient stream")
+			},
+		},
 		{
 			name: "no such tablet",
 			ts:   memorytopo.NewServer("zone1"),
@@ -10927,6 +11102,107 @@ func TestValidate(t *testing.T) {
 	}, resp)
 }
 
+func TestValidateBackup(t *testing.T) {
+	ctx := context.Background()
+	ts := memorytopo.NewServer()
+	vtctld := testutil.NewVtctldServerWithTabletManagerClient(t, ts, nil, func(ts *topo.Server) vtctlservicepb.VtctldServer {
+		return NewVtctldServer(ts)
+	})
+
+	testutil.BackupStorage.Backups = map[string][]string{
+		"testkeyspace/-": {"backup1", "backup2"},
+	}
+	testutil.BackupStorage.Files = map[string][]byte{
+		"testkeyspace/-/backup1/MANIFEST": []byte(`{"BackupMethod": "builtin", "FileEntries": [{"Base": "Data", "Name": "t.ibd", "Hash": "3610a686"}]}`),
+		"testkeyspace/-/backup1/0":        []byte("hello"),
+		"testkeyspace/-/backup2/MANIFEST": []byte(`{"BackupMethod": "builtin", "FileEntries": [{"Base": "Data", "Name": "t.ibd", "Hash": "3610a686"}]}`),
+		"testkeyspace/-/backup2/0":        []byte("hellO"),
+	}
+	defer func() { testutil.BackupStorage.Files = map[string][]byte{} }()
+
+	resp, err := vtctld.ValidateBackup(ctx, &vtctldatapb.ValidateBackupRequest{
+		Keyspace: "testkeyspace",
+		Shard:    "-",
+		Name:     "backup1",
+	})
+	require.NoError(t, err)
+	utils.MustMatch(t, &vtctldatapb.ValidateBackupResponse{
+		FilesChecked:  1,
+		BytesChecked:  5,
+		HashesChecked: true,
+	}, resp)
+
+	_, err = vtctld.ValidateBackup(ctx, &vtctldatapb.ValidateBackupRequest{
+		Keyspace: "testkeyspace",
+		Shard:    "-",
+		Name:     "backup2",
+	})
+	assert.ErrorContains(t, err, "hash mismatch")
+
+	_, err = vtctld.ValidateBackup(ctx, &vtctldatapb.ValidateBackupRequest{
+		Keyspace: "testkeyspace",
+		Shard:    "-",
+		Name:     "backup3",
+	})
+	assert.ErrorContains(t, err, "backup backup3 not found in testkeyspace/-")
+
+	_, err = vtctld.ValidateBackup(ctx, &vtctldatapb.ValidateBackupRequest{
+		Keyspace:    "testkeyspace",
+		Shard:       "-",
+		Name:        "backup1",
+		TestRestore: true,
+	})
+	assert.ErrorContains(t, err, "scratch tablet uid and mysql port are required for a test restore")
+}
+
+func TestValidateBackupEncrypted(t *testing.T) {
+	ctx := context.Background()
+	ts := memorytopo.NewServer()
+	vtctld := testutil.NewVtctldServerWithTabletManagerClient(t, ts, nil, func(ts *topo.Server) vtctlservicepb.VtctldServer {
+		return NewVtctldServer(ts)
+	})
+
+	testutil.BackupStorage.Backups = map[string][]string{
+		"testkeyspace/-": {"backup1"},
+	}
+	testutil.BackupStorage.Files = map[string][]byte{
+		"testkeyspace/-/backup1/MANIFEST": []byte(`{"BackupMethod": "builtin", "EncryptionKeyProvider": "file", "EncryptionKeyID": "key1", "FileEntries": [{"Base": "Data", "Name": "t.ibd", "Hash": "3610a686"}]}`),
+		"testkeyspace/-/backup1/0":        []byte("hello"),
+	}
+	defer func() { testutil.BackupStorage.Files = map[string][]byte{} }()
+
+	req := &vtctldatapb.ValidateBackupRequest{
+		Keyspace: "testkeyspace",
+		Shard:    "-",
+		Name:     "backup1",
+	}
+	_, err := vtctld.ValidateBackup(ctx, req)
+	assert.ErrorContains(t, err, "can't get encryption key of backup backup1")
+
+	keyFile := filepath.Join(t.TempDir(), "keys")
+	key := base64.StdEncoding.EncodeToString([]byte("0123456789abcdef0123456789abcdef"))
+	require.NoError(t, os.WriteFile(keyFile, []byte("key1 "+key+"\n"), 0600))
+
+	// The key file is given to vtctld the way it is given to vttablet. Building the
+	// flag set resets the flags to their defaults, so the test storage is set again.
+	fs := servenv.GetFlagSetFor("vtctld")
+	require.NoError(t, fs.Parse([]string{
+		"--backup_storage_implementation", testutil.BackupStorageImplementation,
+		"--backup-encryption-key-file", keyFile,
+	}))
+	defer func() {
+		require.NoError(t, fs.Set("backup-encryption-key-file", ""))
+	}()
+
+	resp, err := vtctld.ValidateBackup(ctx, req)
+	require.NoError(t, err)
+	utils.MustMatch(t, &vtctldatapb.ValidateBackupResponse{
+		FilesChecked:  1,
+		BytesChecked:  5,
+		HashesChecked: true,
+	}, resp)
+}
+
 func TestValidateSchemaKeyspace(t *testing.T) {
 	ctx := context.Background()
 	ts := memorytopo.NewServer("zone1", "zone2", "zone3")
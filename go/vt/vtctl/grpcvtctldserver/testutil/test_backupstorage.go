@@ -17,8 +17,11 @@ limitations under the License.
 package testutil
 
 import (
+	"bytes"
 	"context"
 	"fmt"
+	"io"
+	"path"
 	"sort"
 
 	"vitess.io/vitess/go/vt/mysqlctl/backupstorage"
@@ -30,6 +33,9 @@ type backupStorage struct {
 	// Backups is a mapping of directory to list of backup names stored in that
 	// directory.
 	Backups map[string][]string
+	// Files is a mapping of "<directory>/<backup name>/<file name>" to the
+	// contents of the files stored in backups.
+	Files map[string][]byte
 	// ListBackupsError is returned from ListBackups when it is non-nil.
 	ListBackupsError error
 }
@@ -92,6 +98,16 @@ type backupHandle struct {
 func (bh *backupHandle) Directory() string { return bh.directory }
 func (bh *backupHandle) Name() string      { return bh.name }
 
+// ReadFile is part of the backupstorage.BackupHandle interface.
+func (bh *backupHandle) ReadFile(ctx context.Context, filename string) (io.ReadCloser, error) {
+	data, ok := BackupStorage.Files[path.Join(bh.directory, bh.name, filename)]
+	if !ok {
+		return nil, fmt.Errorf("no file %s in backup %s/%s in testutil.BackupStorage", filename, bh.directory, bh.name)
+	}
+
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
 // handlesByName implements the sort interface for backup handles by Name().
 type handlesByName []backupstorage.BackupHandle
 
@@ -109,6 +125,7 @@ const BackupStorageImplementation = "grpcvtctldserver.testutil"
 // state.
 var BackupStorage = &backupStorage{
 	Backups: map[string][]string{},
+	Files:   map[string][]byte{},
 }
 
 func init() {
@@ -281,6 +281,11 @@ func (client *localVtctldClient) GetSchema(ctx context.Context, in *vtctldatapb.
 	return client.s.GetSchema(ctx, in)
 }
 
+// GetSchemaMigrations is part of the vtctlservicepb.VtctldClient interface.
+func (client *localVtctldClient) GetSchemaMigrations(ctx context.Context, in *vtctldatapb.GetSchemaMigrationsRequest, opts ...grpc.CallOption) (*vtctldatapb.GetSchemaMigrationsResponse, error) {
+	return client.s.GetSchemaMigrations(ctx, in)
+}
+
 // GetShard is part of the vtctlservicepb.VtctldClient interface.
 func (client *localVtctldClient) GetShard(ctx context.Context, in *vtctldatapb.GetShardRequest, opts ...grpc.CallOption) (*vtctldatapb.GetShardResponse, error) {
 	return client.s.GetShard(ctx, in)
@@ -346,6 +351,16 @@ func (client *localVtctldClient) InitShardPrimary(ctx context.Context, in *vtctl
 	return client.s.InitShardPrimary(ctx, in)
 }
 
+// MaterializeCreate is part of the vtctlservicepb.VtctldClient interface.
+func (client *localVtctldClient) MaterializeCreate(ctx context.Context, in *vtctldatapb.MaterializeCreateRequest, opts ...grpc.CallOption) (*vtctldatapb.MaterializeCreateResponse, error) {
+	return client.s.MaterializeCreate(ctx, in)
+}
+
+// MoveTablesCreate is part of the vtctlservicepb.VtctldClient interface.
+func (client *localVtctldClient) MoveTablesCreate(ctx context.Context, in *vtctldatapb.MoveTablesCreateRequest, opts ...grpc.CallOption) (*vtctldatapb.WorkflowStatusResponse, error) {
+	return client.s.MoveTablesCreate(ctx, in)
+}
+
 // PingTablet is part of the vtctlservicepb.VtctldClient interface.
 func (client *localVtctldClient) PingTablet(ctx context.Context, in *vtctldatapb.PingTabletRequest, opts ...grpc.CallOption) (*vtctldatapb.PingTabletResponse, error) {
 	return client.s.PingTablet(ctx, in)
@@ -356,6 +371,11 @@ func (client *localVtctldClient) PlannedReparentShard(ctx context.Context, in *v
 	return client.s.PlannedReparentShard(ctx, in)
 }
 
+// PruneBackups is part of the vtctlservicepb.VtctldClient interface.
+func (client *localVtctldClient) PruneBackups(ctx context.Context, in *vtctldatapb.PruneBackupsRequest, opts ...grpc.CallOption) (*vtctldatapb.PruneBackupsResponse, error) {
+	return client.s.PruneBackups(ctx, in)
+}
+
 // RebuildKeyspaceGraph is part of the vtctlservicepb.VtctldClient interface.
 func (client *localVtctldClient) RebuildKeyspaceGraph(ctx context.Context, in *vtctldatapb.RebuildKeyspaceGraphRequest, opts ...grpc.CallOption) (*vtctldatapb.RebuildKeyspaceGraphResponse, error) {
 	return client.s.RebuildKeyspaceGraph(ctx, in)
@@ -411,6 +431,11 @@ func (client *localVtctldClient) ReparentTablet(ctx context.Context, in *vtctlda
 	return client.s.ReparentTablet(ctx, in)
 }
 
+// ReshardCreate is part of the vtctlservicepb.VtctldClient interface.
+func (client *localVtctldClient) ReshardCreate(ctx context.Context, in *vtctldatapb.ReshardCreateRequest, opts ...grpc.CallOption) (*vtctldatapb.WorkflowStatusResponse, error) {
+	return client.s.ReshardCreate(ctx, in)
+}
+
 type restoreFromBackupStreamAdapter struct {
 	*grpcshim.BidiStream
 	ch chan *vtctldatapb.RestoreFromBackupResponse
@@ -552,11 +577,41 @@ func (client *localVtctldClient) UpdateThrottlerConfig(ctx context.Context, in *
 	return client.s.UpdateThrottlerConfig(ctx, in)
 }
 
+// VDiffCreate is part of the vtctlservicepb.VtctldClient interface.
+func (client *localVtctldClient) VDiffCreate(ctx context.Context, in *vtctldatapb.VDiffCreateRequest, opts ...grpc.CallOption) (*vtctldatapb.VDiffCreateResponse, error) {
+	return client.s.VDiffCreate(ctx, in)
+}
+
+// VDiffDelete is part of the vtctlservicepb.VtctldClient interface.
+func (client *localVtctldClient) VDiffDelete(ctx context.Context, in *vtctldatapb.VDiffDeleteRequest, opts ...grpc.CallOption) (*vtctldatapb.VDiffDeleteResponse, error) {
+	return client.s.VDiffDelete(ctx, in)
+}
+
+// VDiffResume is part of the vtctlservicepb.VtctldClient interface.
+func (client *localVtctldClient) VDiffResume(ctx context.Context, in *vtctldatapb.VDiffResumeRequest, opts ...grpc.CallOption) (*vtctldatapb.VDiffResumeResponse, error) {
+	return client.s.VDiffResume(ctx, in)
+}
+
+// VDiffShow is part of the vtctlservicepb.VtctldClient interface.
+func (client *localVtctldClient) VDiffShow(ctx context.Context, in *vtctldatapb.VDiffShowRequest, opts ...grpc.CallOption) (*vtctldatapb.VDiffShowResponse, error) {
+	return client.s.VDiffShow(ctx, in)
+}
+
+// VDiffStop is part of the vtctlservicepb.VtctldClient interface.
+func (client *localVtctldClient) VDiffStop(ctx context.Context, in *vtctldatapb.VDiffStopRequest, opts ...grpc.CallOption) (*vtctldatapb.VDiffStopResponse, error) {
+	return client.s.VDiffStop(ctx, in)
+}
+
 // Validate is part of the vtctlservicepb.VtctldClient interface.
 func (client *localVtctldClient) Validate(ctx context.Context, in *vtctldatapb.ValidateRequest, opts ...grpc.CallOption) (*vtctldatapb.ValidateResponse, error) {
 	return client.s.Validate(ctx, in)
 }
 
+// ValidateBackup is part of the vtctlservicepb.VtctldClient interface.
+func (client *localVtctldClient) ValidateBackup(ctx context.Context, in *vtctldatapb.ValidateBackupRequest, opts ...grpc.CallOption) (*vtctldatapb.ValidateBackupResponse, error) {
+	return client.s.ValidateBackup(ctx, in)
+}
+
 // ValidateKeyspace is part of the vtctlservicepb.VtctldClient interface.
 func (client *localVtctldClient) ValidateKeyspace(ctx context.Context, in *vtctldatapb.ValidateKeyspaceRequest, opts ...grpc.CallOption) (*vtctldatapb.ValidateKeyspaceResponse, error) {
 	return client.s.ValidateKeyspace(ctx, in)
@@ -587,6 +642,26 @@ func (client *localVtctldClient) ValidateVersionShard(ctx context.Context, in *v
 	return client.s.ValidateVersionShard(ctx, in)
 }
 
+// WorkflowComplete is part of the vtctlservicepb.VtctldClient interface.
+func (client *localVtctldClient) WorkflowComplete(ctx context.Context, in *vtctldatapb.WorkflowCompleteRequest, opts ...grpc.CallOption) (*vtctldatapb.WorkflowCompleteResponse, error) {
+	return client.s.WorkflowComplete(ctx, in)
+}
+
+// WorkflowDelete is part of the vtctlservicepb.VtctldClient interface.
+func (client *localVtctldClient) WorkflowDelete(ctx context.Context, in *vtctldatapb.WorkflowDeleteRequest, opts ...grpc.CallOption) (*vtctldatapb.WorkflowDeleteResponse, error) {
+	return client.s.WorkflowDelete(ctx, in)
+}
+
+// WorkflowStatus is part of the vtctlservicepb.VtctldClient interface.
+func (client *localVtctldClient) WorkflowStatus(ctx context.Context, in *vtctldatapb.WorkflowStatusRequest, opts ...grpc.CallOption) (*vtctldatapb.WorkflowStatusResponse, error) {
+	return client.s.WorkflowStatus(ctx, in)
+}
+
+// WorkflowSwitchTraffic is part of the vtctlservicepb.VtctldClient interface.
+func (client *localVtctldClient) WorkflowSwitchTraffic(ctx context.Context, in *vtctldatapb.WorkflowSwitchTrafficRequest, opts ...grpc.CallOption) (*vtctldatapb.WorkflowSwitchTrafficResponse, error) {
+	return client.s.WorkflowSwitchTraffic(ctx, in)
+}
+
 // WorkflowUpdate is part of the vtctlservicepb.VtctldClient interface.
 func (client *localVtctldClient) WorkflowUpdate(ctx context.Context, in *vtctldatapb.WorkflowUpdateRequest, opts ...grpc.CallOption) (*vtctldatapb.WorkflowUpdateResponse, error) {
 	return client.s.WorkflowUpdate(ctx, in)
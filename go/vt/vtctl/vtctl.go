@@ -702,7 +702,7 @@ var commands = []commandGroup{
 			{
 				name:   "UpdateThrottlerConfig",
 				method: commandUpdateThrottlerConfig,
-				params: "[--enable|--disable] [--threshold=<float64>] [--custom-query=<query>] [--check-as-check-self|--check-as-check-shard] [--throttle-app=<name>] [--throttle-app-ratio=<float, range [0..1]>] [--throttle-app-duration=<duration>] <keyspace>",
+				params: "[--enable|--disable] [--metric-name=<name>] [--threshold=<float64>] [--custom-query=<query>] [--check-as-check-self|--check-as-check-shard] [--throttle-app=<name>] [--throttle-app-ratio=<float, range [0..1]>] [--throttle-app-duration=<duration>] [--app-name=<name> --app-metrics=<metrics>] <keyspace>",
 				help:   "Update the table throttler configuration for all cells and tablets of a given keyspace",
 			},
 			{
@@ -3551,13 +3551,16 @@ func commandGetSrvKeyspace(ctx context.Context, wr *wrangler.Wrangler, subFlags
 func commandUpdateThrottlerConfig(ctx context.Context, wr *wrangler.Wrangler, subFlags *pflag.FlagSet, args []string) (err error) {
 	enable := subFlags.Bool("enable", false, "Enable the throttler")
 	disable := subFlags.Bool("disable", false, "Disable the throttler")
-	threshold := subFlags.Float64("threshold", 0, "threshold for the either default check (replication lag seconds) or custom check")
+	metricName := subFlags.String("metric-name", "", "name of the metric that --threshold applies to, e.g. 'lag', 'threads_running', 'history_list_length', 'loadavg' or 'custom'. Empty for the default metric")
+	threshold := subFlags.Float64("threshold", 0, "threshold for the either default check (replication lag seconds) or custom check, or for the metric given in --metric-name")
 	customQuery := subFlags.String("custom-query", "", "custom throttler check query")
 	checkAsCheckSelf := subFlags.Bool("check-as-check-self", false, "/throttler/check requests behave as is /throttler/check-self was called")
 	checkAsCheckShard := subFlags.Bool("check-as-check-shard", false, "use standard behavior for /throttler/check requests")
 	throttledApp := subFlags.String("throttle-app", "", "an app name to throttle")
 	throttledAppRatio := subFlags.Float64("throttle-app-ratio", throttle.DefaultThrottleRatio, "ratio to throttle app (app specififed in --throttled-app)")
 	throttledAppDuration := subFlags.Duration("throttle-app-duration", throttle.DefaultAppThrottleDuration, "duration after which throttled app rule expires (app specified in --throttled-app)")
+	appName := subFlags.String("app-name", "", "an app name whose checked metrics are set by --app-metrics")
+	appCheckedMetrics := subFlags.StringSlice("app-metrics", nil, "comma separated metrics the app in --app-name is gated on. Empty to only check the default metric")
 	if err := subFlags.Parse(args); err != nil {
 		return err
 	}
@@ -3578,6 +3581,9 @@ func commandUpdateThrottlerConfig(ctx context.Context, wr *wrangler.Wrangler, su
 	if subFlags.Changed("throttle-app-duration") && *throttledApp == "" {
 		return fmt.Errorf("--throttle-app-duration requires --throttle-app")
 	}
+	if subFlags.Changed("app-metrics") && *appName == "" {
+		return fmt.Errorf("--app-metrics requires --app-name")
+	}
 
 	keyspace := subFlags.Arg(0)
 
@@ -3590,6 +3596,9 @@ func commandUpdateThrottlerConfig(ctx context.Context, wr *wrangler.Wrangler, su
 		Threshold:         *threshold,
 		CheckAsCheckSelf:  *checkAsCheckSelf,
 		CheckAsCheckShard: *checkAsCheckShard,
+		MetricName:        *metricName,
+		AppName:           *appName,
+		AppCheckedMetrics: *appCheckedMetrics,
 	}
 	if *throttledApp != "" {
 		req.ThrottledApp = &topodatapb.ThrottledAppRule{
@@ -0,0 +1,394 @@
+/*
+Copyright 2023 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workflow
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"google.golang.org/protobuf/encoding/prototext"
+
+	"vitess.io/vitess/go/sqltypes"
+	"vitess.io/vitess/go/vt/mysqlctl/tmutils"
+	"vitess.io/vitess/go/vt/sqlparser"
+	"vitess.io/vitess/go/vt/topo"
+	"vitess.io/vitess/go/vt/topo/memorytopo"
+	"vitess.io/vitess/go/vt/vttablet/tmclient"
+
+	binlogdatapb "vitess.io/vitess/go/vt/proto/binlogdata"
+	querypb "vitess.io/vitess/go/vt/proto/query"
+	tabletmanagerdatapb "vitess.io/vitess/go/vt/proto/tabletmanagerdata"
+	topodatapb "vitess.io/vitess/go/vt/proto/topodata"
+	vtctldatapb "vitess.io/vitess/go/vt/proto/vtctldata"
+)
+
+const (
+	insertPrefix = `/insert into _vt.vreplication\(workflow, source, pos, max_tps, max_replication_lag, cell, tablet_types, time_updated, transaction_timestamp, state, db_name, workflow_type, workflow_sub_type, defer_secondary_keys\) values `
+	eol          = "$"
+)
+
+type queryResult struct {
+	query  string
+	result *querypb.QueryResult
+}
+
+type testMaterializerEnv struct {
+	ws       *Server
+	ms       *vtctldatapb.MaterializeSettings
+	sources  []string
+	targets  []string
+	tablets  map[int]*topodatapb.Tablet
+	topoServ *topo.Server
+	cell     string
+	tmc      *testMaterializerTMClient
+}
+
+//----------------------------------------------
+// testMaterializerEnv
+
+func newTestMaterializerEnv(t *testing.T, ms *vtctldatapb.MaterializeSettings, sources, targets []string) *testMaterializerEnv {
+	t.Helper()
+	env := &testMaterializerEnv{
+		ms:       ms,
+		sources:  sources,
+		targets:  targets,
+		tablets:  make(map[int]*topodatapb.Tablet),
+		topoServ: memorytopo.NewServer("cell"),
+		cell:     "cell",
+		tmc:      newTestMaterializerTMClient(),
+	}
+	env.ws = NewServer(env.topoServ, env.tmc)
+	tabletID := 100
+	for _, shard := range sources {
+		_ = env.addTablet(tabletID, env.ms.SourceKeyspace, shard, topodatapb.TabletType_PRIMARY)
+		tabletID += 10
+	}
+	if ms.SourceKeyspace != ms.TargetKeyspace {
+		tabletID = 200
+		for _, shard := range targets {
+			_ = env.addTablet(tabletID, env.ms.TargetKeyspace, shard, topodatapb.TabletType_PRIMARY)
+			tabletID += 10
+		}
+	}
+
+	for _, ts := range ms.TableSettings {
+		tableName := ts.TargetTable
+		table, err := sqlparser.TableFromStatement(ts.SourceExpression)
+		if err == nil {
+			tableName = table.Name.String()
+		}
+		env.tmc.schema[ms.SourceKeyspace+"."+tableName] = &tabletmanagerdatapb.SchemaDefinition{
+			TableDefinitions: []*tabletmanagerdatapb.TableDefinition{{
+				Name:   tableName,
+				Schema: fmt.Sprintf("%s_schema", tableName),
+			}},
+		}
+		env.tmc.schema[ms.TargetKeyspace+"."+ts.TargetTable] = &tabletmanagerdatapb.SchemaDefinition{
+			TableDefinitions: []*tabletmanagerdatapb.TableDefinition{{
+				Name:   ts.TargetTable,
+				Schema: fmt.Sprintf("%s_schema", ts.TargetTable),
+			}},
+		}
+	}
+	if ms.Workflow != "" {
+		env.expectValidation()
+	}
+	return env
+}
+
+func (env *testMaterializerEnv) expectValidation() {
+	for _, tablet := range env.tablets {
+		tabletID := int(tablet.Alias.Uid)
+		if tabletID < 200 {
+			continue
+		}
+		// s.validateNewWorkflow
+		env.tmc.expectVRQuery(tabletID, fmt.Sprintf("select 1 from _vt.vreplication where db_name='vt_%s' and workflow='%s'", env.ms.TargetKeyspace, env.ms.Workflow), &sqltypes.Result{})
+	}
+}
+
+// expectWorkflowStatus registers the queries that WorkflowStatus runs on a
+// target primary, which the create RPCs call once the streams exist. The
+// stream is reported with the given id and binlog source, and without any
+// copy in progress.
+func (env *testMaterializerEnv) expectWorkflowStatus(tabletID int, id int64, bls *binlogdatapb.BinlogSource) {
+	env.expectBuildTargets(tabletID, id, bls, "")
+	// s.getCopyProgress
+	env.tmc.expectVRQuery(tabletID, fmt.Sprintf("select distinct table_name from _vt.copy_state cs, _vt.vreplication vr where vr.id = cs.vrepl_id and vr.id = %d", id), &sqltypes.Result{})
+	// s.GetWorkflows
+	env.tmc.expectVRQuery(tabletID, "/select id, workflow, source, pos, stop_pos", &sqltypes.Result{})
+}
+
+// expectBuildTargets registers the query that BuildTargets runs on a target
+// primary, reporting a single stream with the given id, binlog source and
+// message.
+func (env *testMaterializerEnv) expectBuildTargets(tabletID int, id int64, bls *binlogdatapb.BinlogSource, message string) {
+	env.tmc.expectVRQuery(tabletID,
+		fmt.Sprintf("select id, source, message, cell, tablet_types, workflow_type, workflow_sub_type, defer_secondary_keys from _vt.vreplication where workflow='%s' and db_name='vt_%s'", env.ms.Workflow, env.ms.TargetKeyspace),
+		sqltypes.MakeTestResult(sqltypes.MakeTestFields(
+			"id|source|message|cell|tablet_types|workflow_type|workflow_sub_type|defer_secondary_keys",
+			"int64|varchar|varchar|varchar|varchar|int64|int64|int64"),
+			fmt.Sprintf("%d|%s|%s|||1|0|0", id, encodeBinlogSource(bls), message),
+		),
+	)
+}
+
+// expectGetWorkflows registers the queries that GetWorkflows runs on a
+// target primary, reporting a single up to date stream with the given id,
+// binlog source and state, and the given tables still being copied.
+func (env *testMaterializerEnv) expectGetWorkflows(tabletID int, id int64, bls *binlogdatapb.BinlogSource, state string, copying ...string) {
+	env.tmc.expectVRQuery(tabletID, "/select id, workflow, source, pos, stop_pos",
+		sqltypes.MakeTestResult(sqltypes.MakeTestFields(
+			"id|workflow|source|pos|stop_pos|max_replication_lag|state|db_name|time_updated|transaction_timestamp|message|tags|workflow_type|workflow_sub_type",
+			"int64|varchar|varchar|varchar|varchar|int64|varchar|varchar|int64|int64|varchar|varchar|int64|int64"),
+			fmt.Sprintf("%d|%s|%s|MySQL56/9d10e6ec-07a0-11ee-ae73-8e53f4cf3083:1-97||0|%s|vt_%s|%d|0|||1|0",
+				id, env.ms.Workflow, encodeBinlogSource(bls), state, env.ms.TargetKeyspace, time.Now().Unix()),
+		),
+	)
+	copyStates := sqltypes.MakeTestResult(sqltypes.MakeTestFields("table_name|lastpk", "varchar|varbinary"))
+	for _, table := range copying {
+		copyStates.Rows = append(copyStates.Rows, []sqltypes.Value{sqltypes.NewVarChar(table), sqltypes.NewVarBinary("")})
+	}
+	// s.getWorkflowCopyStates
+	env.tmc.expectVRQuery(tabletID, fmt.Sprintf("/select table_name, lastpk from _vt.copy_state where vrepl_id = %d", id), copyStates)
+	// fetchStreamLogs
+	env.tmc.expectVRQuery(tabletID, fmt.Sprintf("select id from _vt.vreplication where db_name = 'vt_%s' and workflow = '%s'", env.ms.TargetKeyspace, env.ms.Workflow),
+		sqltypes.MakeTestResult(sqltypes.MakeTestFields("id", "int64"), fmt.Sprintf("%d", id)))
+	env.tmc.expectVRQuery(tabletID, fmt.Sprintf("/select id, vrepl_id, type, state, message, created_at, updated_at, `count` from _vt.vreplication_log where vrepl_id = %d", id), &sqltypes.Result{})
+}
+
+// encodeBinlogSource returns the text encoding of the binlog source, as
+// stored in the source column of _vt.vreplication.
+func encodeBinlogSource(bls *binlogdatapb.BinlogSource) string {
+	source, err := prototext.Marshal(bls)
+	if err != nil {
+		panic(err)
+	}
+	return string(source)
+}
+
+func (env *testMaterializerEnv) close() {
+	for _, t := range env.tablets {
+		env.deleteTablet(t)
+	}
+}
+
+func (env *testMaterializerEnv) addTablet(id int, keyspace, shard string, tabletType topodatapb.TabletType) *topodatapb.Tablet {
+	tablet := &topodatapb.Tablet{
+		Alias: &topodatapb.TabletAlias{
+			Cell: env.cell,
+			Uid:  uint32(id),
+		},
+		Keyspace: keyspace,
+		Shard:    shard,
+		KeyRange: &topodatapb.KeyRange{},
+		Type:     tabletType,
+		PortMap: map[string]int32{
+			"test": int32(id),
+		},
+	}
+	env.tablets[id] = tablet
+	if err := env.topoServ.InitTablet(context.Background(), tablet, false /* allowPrimaryOverride */, true /* createShardAndKeyspace */, false /* allowUpdate */); err != nil {
+		panic(err)
+	}
+	if tabletType == topodatapb.TabletType_PRIMARY {
+		_, err := env.topoServ.UpdateShardFields(context.Background(), keyspace, shard, func(si *topo.ShardInfo) error {
+			si.PrimaryAlias = tablet.Alias
+			return nil
+		})
+		if err != nil {
+			panic(err)
+		}
+	}
+	return tablet
+}
+
+func (env *testMaterializerEnv) deleteTablet(tablet *topodatapb.Tablet) {
+	env.topoServ.DeleteTablet(context.Background(), tablet.Alias)
+	delete(env.tablets, int(tablet.Alias.Uid))
+}
+
+//----------------------------------------------
+// testMaterializerTMClient
+
+type testMaterializerTMClient struct {
+	tmclient.TabletManagerClient
+	schema map[string]*tabletmanagerdatapb.SchemaDefinition
+
+	mu              sync.Mutex
+	vrQueries       map[int][]*queryResult
+	getSchemaCounts map[string]int
+	muSchemaCount   sync.Mutex
+
+	// vdiffRequests records the VDiff requests sent to each tablet, and
+	// vdiffResponse is returned for all of them.
+	vdiffRequests map[int][]*tabletmanagerdatapb.VDiffRequest
+	vdiffResponse *tabletmanagerdatapb.VDiffResponse
+}
+
+func newTestMaterializerTMClient() *testMaterializerTMClient {
+	return &testMaterializerTMClient{
+		schema:          make(map[string]*tabletmanagerdatapb.SchemaDefinition),
+		vrQueries:       make(map[int][]*queryResult),
+		getSchemaCounts: make(map[string]int),
+		vdiffRequests:   make(map[int][]*tabletmanagerdatapb.VDiffRequest),
+	}
+}
+
+func (tmc *testMaterializerTMClient) schemaRequested(uid uint32) {
+	tmc.muSchemaCount.Lock()
+	defer tmc.muSchemaCount.Unlock()
+	key := strconv.Itoa(int(uid))
+	n, ok := tmc.getSchemaCounts[key]
+	if !ok {
+		tmc.getSchemaCounts[key] = 1
+	} else {
+		tmc.getSchemaCounts[key] = n + 1
+	}
+}
+
+func (tmc *testMaterializerTMClient) getSchemaRequestCount(uid uint32) int {
+	tmc.muSchemaCount.Lock()
+	defer tmc.muSchemaCount.Unlock()
+	key := strconv.Itoa(int(uid))
+	return tmc.getSchemaCounts[key]
+}
+
+func (tmc *testMaterializerTMClient) GetSchema(ctx context.Context, tablet *topodatapb.Tablet, request *tabletmanagerdatapb.GetSchemaRequest) (*tabletmanagerdatapb.SchemaDefinition, error) {
+	tmc.schemaRequested(tablet.Alias.Uid)
+	schemaDefn := &tabletmanagerdatapb.SchemaDefinition{}
+	for _, table := range request.Tables {
+		if table == "/.*/" {
+			// Special case of all tables in keyspace.
+			for key, tableDefn := range tmc.schema {
+				if strings.HasPrefix(key, tablet.Keyspace+".") {
+					schemaDefn.TableDefinitions = append(schemaDefn.TableDefinitions, tableDefn.TableDefinitions...)
+				}
+			}
+			break
+		}
+
+		key := tablet.Keyspace + "." + table
+		tableDefn := tmc.schema[key]
+		if tableDefn == nil {
+			continue
+		}
+		schemaDefn.TableDefinitions = append(schemaDefn.TableDefinitions, tableDefn.TableDefinitions...)
+	}
+	return schemaDefn, nil
+}
+
+func (tmc *testMaterializerTMClient) expectVRQuery(tabletID int, query string, result *sqltypes.Result) {
+	tmc.mu.Lock()
+	defer tmc.mu.Unlock()
+
+	tmc.vrQueries[tabletID] = append(tmc.vrQueries[tabletID], &queryResult{
+		query:  query,
+		result: sqltypes.ResultToProto3(result),
+	})
+}
+
+func (tmc *testMaterializerTMClient) VReplicationExec(ctx context.Context, tablet *topodatapb.Tablet, query string) (*querypb.QueryResult, error) {
+	tmc.mu.Lock()
+	defer tmc.mu.Unlock()
+
+	qrs := tmc.vrQueries[int(tablet.Alias.Uid)]
+	if len(qrs) == 0 {
+		return nil, fmt.Errorf("tablet %v does not expect any more queries: %s", tablet, query)
+	}
+	matched := false
+	if qrs[0].query[0] == '/' {
+		matched = regexp.MustCompile(qrs[0].query[1:]).MatchString(query)
+	} else {
+		matched = query == qrs[0].query
+	}
+	if !matched {
+		return nil, fmt.Errorf("tablet %v:\nunexpected query\n%s\nwant:\n%s", tablet, query, qrs[0].query)
+	}
+	tmc.vrQueries[int(tablet.Alias.Uid)] = qrs[1:]
+	return qrs[0].result, nil
+}
+
+func (tmc *testMaterializerTMClient) ExecuteFetchAsDba(ctx context.Context, tablet *topodatapb.Tablet, usePool bool, req *tabletmanagerdatapb.ExecuteFetchAsDbaRequest) (*querypb.QueryResult, error) {
+	// Reuse VReplicationExec
+	return tmc.VReplicationExec(ctx, tablet, string(req.Query))
+}
+
+func (tmc *testMaterializerTMClient) ExecuteFetchAsApp(ctx context.Context, tablet *topodatapb.Tablet, usePool bool, req *tabletmanagerdatapb.ExecuteFetchAsAppRequest) (*querypb.QueryResult, error) {
+	// Reuse VReplicationExec
+	return tmc.VReplicationExec(ctx, tablet, string(req.Query))
+}
+
+func (tmc *testMaterializerTMClient) ExecuteFetchAsAllPrivs(ctx context.Context, tablet *topodatapb.Tablet, req *tabletmanagerdatapb.ExecuteFetchAsAllPrivsRequest) (*querypb.QueryResult, error) {
+	// Only used for the best effort maintenance of the copy_state table,
+	// which runs in the background, so it is not checked against the
+	// expected queries.
+	return &querypb.QueryResult{}, nil
+}
+
+func (tmc *testMaterializerTMClient) VDiff(ctx context.Context, tablet *topodatapb.Tablet, req *tabletmanagerdatapb.VDiffRequest) (*tabletmanagerdatapb.VDiffResponse, error) {
+	tmc.mu.Lock()
+	defer tmc.mu.Unlock()
+
+	tmc.vdiffRequests[int(tablet.Alias.Uid)] = append(tmc.vdiffRequests[int(tablet.Alias.Uid)], req)
+	return tmc.vdiffResponse, nil
+}
+
+func (tmc *testMaterializerTMClient) RefreshState(ctx context.Context, tablet *topodatapb.Tablet) error {
+	return nil
+}
+
+func (tmc *testMaterializerTMClient) verifyQueries(t *testing.T) {
+	t.Helper()
+
+	tmc.mu.Lock()
+	defer tmc.mu.Unlock()
+
+	for tabletID, qrs := range tmc.vrQueries {
+		if len(qrs) != 0 {
+			var list []string
+			for _, qr := range qrs {
+				list = append(list, qr.query)
+			}
+			t.Errorf("tablet %v: found queries that were expected but never got executed by the test: %v", tabletID, list)
+		}
+	}
+}
+
+// Note: ONLY breaks up change.SQL into individual statements and executes it. Does NOT fully implement ApplySchema.
+func (tmc *testMaterializerTMClient) ApplySchema(ctx context.Context, tablet *topodatapb.Tablet, change *tmutils.SchemaChange) (*tabletmanagerdatapb.SchemaChangeResult, error) {
+	stmts := strings.Split(change.SQL, ";")
+
+	for _, stmt := range stmts {
+		_, err := tmc.ExecuteFetchAsDba(ctx, tablet, false, &tabletmanagerdatapb.ExecuteFetchAsDbaRequest{
+			Query:        []byte(stmt),
+			MaxRows:      0,
+			ReloadSchema: true,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return nil, nil
+}
@@ -0,0 +1,447 @@
+/*
+Copyright 2023 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workflow
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"text/template"
+
+	"google.golang.org/protobuf/encoding/prototext"
+
+	"vitess.io/vitess/go/sqltypes"
+	"vitess.io/vitess/go/trace"
+	"vitess.io/vitess/go/vt/binlog/binlogplayer"
+	"vitess.io/vitess/go/vt/concurrency"
+	"vitess.io/vitess/go/vt/key"
+	"vitess.io/vitess/go/vt/log"
+	"vitess.io/vitess/go/vt/mysqlctl/tmutils"
+	"vitess.io/vitess/go/vt/schema"
+	"vitess.io/vitess/go/vt/topo"
+	"vitess.io/vitess/go/vt/topo/topoproto"
+	"vitess.io/vitess/go/vt/topotools"
+	"vitess.io/vitess/go/vt/vtctl/schematools"
+	"vitess.io/vitess/go/vt/vterrors"
+	"vitess.io/vitess/go/vt/vtgate/vindexes"
+	"vitess.io/vitess/go/vt/vttablet/tabletmanager/vreplication"
+
+	binlogdatapb "vitess.io/vitess/go/vt/proto/binlogdata"
+	tabletmanagerdatapb "vitess.io/vitess/go/vt/proto/tabletmanagerdata"
+	topodatapb "vitess.io/vitess/go/vt/proto/topodata"
+	vschemapb "vitess.io/vitess/go/vt/proto/vschema"
+	vtctldatapb "vitess.io/vitess/go/vt/proto/vtctldata"
+)
+
+type resharder struct {
+	s                  *Server
+	keyspace           string
+	workflow           string
+	sourceShards       []*topo.ShardInfo
+	sourcePrimaries    map[string]*topo.TabletInfo
+	targetShards       []*topo.ShardInfo
+	targetPrimaries    map[string]*topo.TabletInfo
+	vschema            *vschemapb.Keyspace
+	refStreams         map[string]*refStream
+	cell               string //single cell or cellsAlias or comma-separated list of cells/cellsAliases
+	tabletTypes        string
+	stopAfterCopy      bool
+	onDDL              string
+	deferSecondaryKeys bool
+}
+
+type refStream struct {
+	workflow    string
+	bls         *binlogdatapb.BinlogSource
+	cell        string
+	tabletTypes string
+}
+
+// ReshardCreate is part of the vtctlservicepb.VtctldServer interface.
+// It creates a Reshard workflow that moves the data of the given source
+// shards into the given target shards of the same keyspace.
+func (s *Server) ReshardCreate(ctx context.Context, req *vtctldatapb.ReshardCreateRequest) (*vtctldatapb.WorkflowStatusResponse, error) {
+	span, ctx := trace.NewSpan(ctx, "workflow.Server.ReshardCreate")
+	defer span.Finish()
+
+	span.Annotate("keyspace", req.Keyspace)
+	span.Annotate("workflow", req.Workflow)
+	span.Annotate("source_shards", req.SourceShards)
+	span.Annotate("target_shards", req.TargetShards)
+	span.Annotate("cells", req.Cells)
+	span.Annotate("tablet_types", req.TabletTypes)
+	span.Annotate("on_ddl", req.OnDdl)
+
+	keyspace := req.Keyspace
+	cells := strings.Join(req.Cells, ",")
+	tabletTypes := strings.Join(topoproto.MakeStringTypeList(req.TabletTypes), ",")
+	if err := s.validateNewWorkflow(ctx, keyspace, req.Workflow); err != nil {
+		return nil, err
+	}
+	if err := s.ts.ValidateSrvKeyspace(ctx, keyspace, cells); err != nil {
+		err2 := vterrors.Wrapf(err, "SrvKeyspace for keyspace %s is corrupt for cell(s) %s", keyspace, cells)
+		log.Errorf("%v", err2)
+		return nil, err2
+	}
+
+	rs, err := s.buildResharder(ctx, keyspace, req.Workflow, req.SourceShards, req.TargetShards, cells, tabletTypes)
+	if err != nil {
+		return nil, vterrors.Wrap(err, "buildResharder")
+	}
+	rs.onDDL = req.OnDdl
+	rs.stopAfterCopy = req.StopAfterCopy
+	rs.deferSecondaryKeys = req.DeferSecondaryKeys
+	if !req.SkipSchemaCopy {
+		if err := rs.copySchema(ctx); err != nil {
+			return nil, vterrors.Wrap(err, "copySchema")
+		}
+	}
+	if err := rs.createStreams(ctx); err != nil {
+		return nil, vterrors.Wrap(err, "createStreams")
+	}
+
+	if req.AutoStart {
+		if err := rs.startStreams(ctx); err != nil {
+			return nil, vterrors.Wrap(err, "startStreams")
+		}
+	} else {
+		log.Infof("Streams will not be started since --auto-start is set to false")
+	}
+
+	return s.WorkflowStatus(ctx, &vtctldatapb.WorkflowStatusRequest{
+		Keyspace: keyspace,
+		Workflow: req.Workflow,
+	})
+}
+
+func (s *Server) buildResharder(ctx context.Context, keyspace, workflow string, sources, targets []string, cell, tabletTypes string) (*resharder, error) {
+	rs := &resharder{
+		s:               s,
+		keyspace:        keyspace,
+		workflow:        workflow,
+		sourcePrimaries: make(map[string]*topo.TabletInfo),
+		targetPrimaries: make(map[string]*topo.TabletInfo),
+		cell:            cell,
+		tabletTypes:     tabletTypes,
+	}
+	for _, shard := range sources {
+		si, err := s.ts.GetShard(ctx, keyspace, shard)
+		if err != nil {
+			return nil, vterrors.Wrapf(err, "GetShard(%s) failed", shard)
+		}
+		if !si.IsPrimaryServing {
+			return nil, fmt.Errorf("source shard %v is not in serving state", shard)
+		}
+		rs.sourceShards = append(rs.sourceShards, si)
+		primary, err := s.ts.GetTablet(ctx, si.PrimaryAlias)
+		if err != nil {
+			return nil, vterrors.Wrapf(err, "GetTablet(%s) failed", si.PrimaryAlias)
+		}
+		rs.sourcePrimaries[si.ShardName()] = primary
+	}
+	for _, shard := range targets {
+		si, err := s.ts.GetShard(ctx, keyspace, shard)
+		if err != nil {
+			return nil, vterrors.Wrapf(err, "GetShard(%s) failed", shard)
+		}
+		if si.IsPrimaryServing {
+			return nil, fmt.Errorf("target shard %v is in serving state", shard)
+		}
+		rs.targetShards = append(rs.targetShards, si)
+		primary, err := s.ts.GetTablet(ctx, si.PrimaryAlias)
+		if err != nil {
+			return nil, vterrors.Wrapf(err, "GetTablet(%s) failed", si.PrimaryAlias)
+		}
+		rs.targetPrimaries[si.ShardName()] = primary
+	}
+	if err := topotools.ValidateForReshard(rs.sourceShards, rs.targetShards); err != nil {
+		return nil, vterrors.Wrap(err, "ValidateForReshard")
+	}
+	if err := rs.validateTargets(ctx); err != nil {
+		return nil, vterrors.Wrap(err, "validateTargets")
+	}
+
+	vschema, err := s.ts.GetVSchema(ctx, keyspace)
+	if err != nil {
+		return nil, vterrors.Wrap(err, "GetVSchema")
+	}
+	rs.vschema = vschema
+
+	if err := rs.readRefStreams(ctx); err != nil {
+		return nil, vterrors.Wrap(err, "readRefStreams")
+	}
+	return rs, nil
+}
+
+func (rs *resharder) validateTargets(ctx context.Context) error {
+	err := rs.forAll(rs.targetShards, func(target *topo.ShardInfo) error {
+		targetPrimary := rs.targetPrimaries[target.ShardName()]
+		query := fmt.Sprintf("select 1 from _vt.vreplication where db_name=%s", encodeString(targetPrimary.DbName()))
+		p3qr, err := rs.s.tmc.VReplicationExec(ctx, targetPrimary.Tablet, query)
+		if err != nil {
+			return vterrors.Wrapf(err, "VReplicationExec(%v, %s)", targetPrimary.Tablet, query)
+		}
+		if len(p3qr.Rows) != 0 {
+			return errors.New("some streams already exist in the target shards, please clean them up and retry the command")
+		}
+		return nil
+	})
+	return err
+}
+
+func (rs *resharder) readRefStreams(ctx context.Context) error {
+	var mu sync.Mutex
+	err := rs.forAll(rs.sourceShards, func(source *topo.ShardInfo) error {
+		sourcePrimary := rs.sourcePrimaries[source.ShardName()]
+
+		query := fmt.Sprintf("select workflow, source, cell, tablet_types from _vt.vreplication where db_name=%s and message != 'FROZEN'", encodeString(sourcePrimary.DbName()))
+		p3qr, err := rs.s.tmc.VReplicationExec(ctx, sourcePrimary.Tablet, query)
+		if err != nil {
+			return vterrors.Wrapf(err, "VReplicationExec(%v, %s)", sourcePrimary.Tablet, query)
+		}
+		qr := sqltypes.Proto3ToResult(p3qr)
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		mustCreate := false
+		var ref map[string]bool
+		if rs.refStreams == nil {
+			rs.refStreams = make(map[string]*refStream)
+			mustCreate = true
+		} else {
+			// Copy the ref streams for comparison.
+			ref = make(map[string]bool, len(rs.refStreams))
+			for k := range rs.refStreams {
+				ref[k] = true
+			}
+		}
+		for _, row := range qr.Rows {
+
+			workflow := row[0].ToString()
+			if workflow == "" {
+				return fmt.Errorf("VReplication streams must have named workflows for migration: shard: %s:%s", source.Keyspace(), source.ShardName())
+			}
+			var bls binlogdatapb.BinlogSource
+			rowBytes, err := row[1].ToBytes()
+			if err != nil {
+				return err
+			}
+			if err := prototext.Unmarshal(rowBytes, &bls); err != nil {
+				return vterrors.Wrapf(err, "prototext.Unmarshal: %v", row)
+			}
+			isReference, err := rs.blsIsReference(&bls)
+			if err != nil {
+				return vterrors.Wrap(err, "blsIsReference")
+			}
+			if !isReference {
+				continue
+			}
+			key := fmt.Sprintf("%s:%s:%s", workflow, bls.Keyspace, bls.Shard)
+			if mustCreate {
+				rs.refStreams[key] = &refStream{
+					workflow:    workflow,
+					bls:         &bls,
+					cell:        row[2].ToString(),
+					tabletTypes: row[3].ToString(),
+				}
+			} else {
+				if !ref[key] {
+					return fmt.Errorf("streams are mismatched across source shards for workflow: %s", workflow)
+				}
+				delete(ref, key)
+			}
+		}
+		if len(ref) != 0 {
+			return fmt.Errorf("streams are mismatched across source shards: %v", ref)
+		}
+		return nil
+	})
+	return err
+}
+
+// blsIsReference is partially copied from streamMigrater.templatize.
+// It reuses the constants from that function also.
+func (rs *resharder) blsIsReference(bls *binlogdatapb.BinlogSource) (bool, error) {
+	streamType := StreamTypeUnknown
+	for _, rule := range bls.Filter.Rules {
+		typ, err := rs.identifyRuleType(rule)
+		if err != nil {
+			return false, err
+		}
+
+		switch typ {
+		case StreamTypeSharded:
+			if streamType == StreamTypeReference {
+				return false, fmt.Errorf("cannot reshard streams with a mix of reference and sharded tables: %v", bls)
+			}
+			streamType = StreamTypeSharded
+		case StreamTypeReference:
+			if streamType == StreamTypeSharded {
+				return false, fmt.Errorf("cannot reshard streams with a mix of reference and sharded tables: %v", bls)
+			}
+			streamType = StreamTypeReference
+		}
+	}
+	return streamType == StreamTypeReference, nil
+}
+
+func (rs *resharder) identifyRuleType(rule *binlogdatapb.Rule) (StreamType, error) {
+	vtable, ok := rs.vschema.Tables[rule.Match]
+	if !ok && !schema.IsInternalOperationTableName(rule.Match) {
+		return 0, fmt.Errorf("table %v not found in vschema", rule.Match)
+	}
+	if vtable != nil && vtable.Type == vindexes.TypeReference {
+		return StreamTypeReference, nil
+	}
+	// In this case, 'sharded' means that it's not a reference
+	// table. We don't care about any other subtleties.
+	return StreamTypeSharded, nil
+}
+
+func (rs *resharder) copySchema(ctx context.Context) error {
+	oneSource := rs.sourceShards[0].PrimaryAlias
+	err := rs.forAll(rs.targetShards, func(target *topo.ShardInfo) error {
+		return rs.copySchemaToShard(ctx, oneSource, target)
+	})
+	return err
+}
+
+// copySchemaToShard copies the full schema of the given source tablet to the
+// primary of the target shard, unless the two already match.
+func (rs *resharder) copySchemaToShard(ctx context.Context, sourceTabletAlias *topodatapb.TabletAlias, target *topo.ShardInfo) error {
+	tables := []string{"/.*"}
+	diffs, err := schematools.CompareSchemas(ctx, rs.s.ts, rs.s.tmc, sourceTabletAlias, target.PrimaryAlias, tables, nil, false)
+	if err != nil {
+		return vterrors.Wrapf(err, "schemas could not be compared between %v and %v", sourceTabletAlias, target.PrimaryAlias)
+	}
+	if diffs == nil {
+		// The target already has the same schema as the source.
+		return nil
+	}
+
+	sourceSd, err := schematools.GetSchema(ctx, rs.s.ts, rs.s.tmc, sourceTabletAlias, &tabletmanagerdatapb.GetSchemaRequest{Tables: tables})
+	if err != nil {
+		return vterrors.Wrapf(err, "GetSchema(%v) failed", sourceTabletAlias)
+	}
+	targetPrimary := rs.targetPrimaries[target.ShardName()]
+	for _, createSQL := range tmutils.SchemaDefinitionToSQLStrings(sourceSd) {
+		buf := &strings.Builder{}
+		tmpl := template.Must(template.New("").Parse(createSQL))
+		if err := tmpl.Execute(buf, map[string]string{"DatabaseName": targetPrimary.DbName()}); err != nil {
+			return err
+		}
+		// Replication must be enabled since the statements are only applied on the primary.
+		if _, err := rs.s.tmc.ApplySchema(ctx, targetPrimary.Tablet, &tmutils.SchemaChange{
+			SQL:              buf.String(),
+			AllowReplication: true,
+			SQLMode:          vreplication.SQLMode,
+		}); err != nil {
+			return vterrors.Wrapf(err, "creating a table on %v failed: some tables probably already exist on the target and differ from the source", target.PrimaryAlias)
+		}
+	}
+	return nil
+}
+
+func (rs *resharder) createStreams(ctx context.Context) error {
+	var excludeRules []*binlogdatapb.Rule
+	for tableName, table := range rs.vschema.Tables {
+		if table.Type == vindexes.TypeReference {
+			excludeRules = append(excludeRules, &binlogdatapb.Rule{
+				Match:  tableName,
+				Filter: "exclude",
+			})
+		}
+	}
+
+	err := rs.forAll(rs.targetShards, func(target *topo.ShardInfo) error {
+		targetPrimary := rs.targetPrimaries[target.ShardName()]
+
+		ig := vreplication.NewInsertGenerator(binlogplayer.BlpStopped, targetPrimary.DbName())
+
+		// copy excludeRules to prevent data race.
+		copyExcludeRules := append([]*binlogdatapb.Rule(nil), excludeRules...)
+		for _, source := range rs.sourceShards {
+			if !key.KeyRangeIntersect(target.KeyRange, source.KeyRange) {
+				continue
+			}
+			filter := &binlogdatapb.Filter{
+				Rules: append(copyExcludeRules, &binlogdatapb.Rule{
+					Match:  "/.*",
+					Filter: key.KeyRangeString(target.KeyRange),
+				}),
+			}
+			bls := &binlogdatapb.BinlogSource{
+				Keyspace:      rs.keyspace,
+				Shard:         source.ShardName(),
+				Filter:        filter,
+				StopAfterCopy: rs.stopAfterCopy,
+				OnDdl:         binlogdatapb.OnDDLAction(binlogdatapb.OnDDLAction_value[rs.onDDL]),
+			}
+			ig.AddRow(rs.workflow, bls, "", rs.cell, rs.tabletTypes,
+				binlogdatapb.VReplicationWorkflowType_Reshard,
+				binlogdatapb.VReplicationWorkflowSubType_None,
+				rs.deferSecondaryKeys)
+		}
+
+		for _, rstream := range rs.refStreams {
+			ig.AddRow(rstream.workflow, rstream.bls, "", rstream.cell, rstream.tabletTypes,
+				//todo: fix based on original stream
+				binlogdatapb.VReplicationWorkflowType_Reshard,
+				binlogdatapb.VReplicationWorkflowSubType_None,
+				rs.deferSecondaryKeys)
+		}
+		query := ig.String()
+		if _, err := rs.s.tmc.VReplicationExec(ctx, targetPrimary.Tablet, query); err != nil {
+			return vterrors.Wrapf(err, "VReplicationExec(%v, %s)", targetPrimary.Tablet, query)
+		}
+		return nil
+	})
+
+	return err
+}
+
+func (rs *resharder) startStreams(ctx context.Context) error {
+	err := rs.forAll(rs.targetShards, func(target *topo.ShardInfo) error {
+		targetPrimary := rs.targetPrimaries[target.ShardName()]
+		query := fmt.Sprintf("update _vt.vreplication set state='Running' where db_name=%s", encodeString(targetPrimary.DbName()))
+		if _, err := rs.s.tmc.VReplicationExec(ctx, targetPrimary.Tablet, query); err != nil {
+			return vterrors.Wrapf(err, "VReplicationExec(%v, %s)", targetPrimary.Tablet, query)
+		}
+		return nil
+	})
+	return err
+}
+
+func (rs *resharder) forAll(shards []*topo.ShardInfo, f func(*topo.ShardInfo) error) error {
+	var wg sync.WaitGroup
+	allErrors := &concurrency.AllErrorRecorder{}
+	for _, shard := range shards {
+		wg.Add(1)
+		go func(shard *topo.ShardInfo) {
+			defer wg.Done()
+
+			if err := f(shard); err != nil {
+				allErrors.RecordError(err)
+			}
+		}(shard)
+	}
+	wg.Wait()
+	return allErrors.AggrError(vterrors.Aggregate)
+}
@@ -20,29 +20,39 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"reflect"
 	"sort"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/google/uuid"
 	"google.golang.org/protobuf/encoding/prototext"
 
+	"vitess.io/vitess/go/protoutil"
 	"vitess.io/vitess/go/sets"
 	"vitess.io/vitess/go/sqltypes"
 	"vitess.io/vitess/go/trace"
 	"vitess.io/vitess/go/vt/concurrency"
+	"vitess.io/vitess/go/vt/discovery"
 	"vitess.io/vitess/go/vt/key"
 	"vitess.io/vitess/go/vt/log"
+	"vitess.io/vitess/go/vt/logutil"
 	"vitess.io/vitess/go/vt/topo"
+	"vitess.io/vitess/go/vt/topotools"
 	"vitess.io/vitess/go/vt/vtctl/workflow/vexec"
 	"vitess.io/vitess/go/vt/vterrors"
 	"vitess.io/vitess/go/vt/vtgate/evalengine"
+	"vitess.io/vitess/go/vt/vtgate/vindexes"
+	"vitess.io/vitess/go/vt/vttablet/tabletmanager/vdiff"
 	"vitess.io/vitess/go/vt/vttablet/tmclient"
 
 	binlogdatapb "vitess.io/vitess/go/vt/proto/binlogdata"
 	querypb "vitess.io/vitess/go/vt/proto/query"
+	tabletmanagerdatapb "vitess.io/vitess/go/vt/proto/tabletmanagerdata"
 	topodatapb "vitess.io/vitess/go/vt/proto/topodata"
 	vtctldatapb "vitess.io/vitess/go/vt/proto/vtctldata"
+	vtrpcpb "vitess.io/vitess/go/vt/proto/vtrpc"
 	"vitess.io/vitess/go/vt/proto/vttime"
 )
 
@@ -60,6 +70,24 @@ var (
 	ErrMultipleTargetKeyspaces = errors.New("multiple target keyspaces for a single workflow")
 )
 
+const (
+	cannotSwitchError               = "workflow has errors"
+	cannotSwitchCopyIncomplete      = "copy is still in progress"
+	cannotSwitchHighLag             = "replication lag %ds is higher than allowed lag %ds"
+	cannotSwitchFailedTabletRefresh = "could not refresh all of the tablets involved in the operation:\n%s"
+	cannotSwitchFrozen              = "workflow is frozen"
+
+	// ErrWorkflowNotFullySwitched is returned when trying to complete a
+	// workflow whose read and write traffic has not been fully switched.
+	ErrWorkflowNotFullySwitched = "cannot complete workflow because you have not yet switched all read and write traffic"
+	// ErrWorkflowPartiallySwitched is returned when trying to delete a
+	// workflow whose read or write traffic has already been switched.
+	ErrWorkflowPartiallySwitched = "cannot cancel workflow because you have already switched some or all read and write traffic"
+
+	defaultSwitchTrafficTimeout     = 30 * time.Second
+	defaultMaxReplicationLagAllowed = 30 * time.Second
+)
+
 // Server provides an API to work with Vitess workflows, like vreplication
 // workflows (MoveTables, Reshard, etc) and schema migration workflows.
 //
@@ -276,6 +304,7 @@ func (s *Server) GetWorkflows(ctx context.Context, req *vtctldatapb.GetWorkflows
 	defer span.Finish()
 
 	span.Annotate("keyspace", req.Keyspace)
+	span.Annotate("workflow", req.Workflow)
 	span.Annotate("active_only", req.ActiveOnly)
 
 	where := ""
@@ -305,7 +334,7 @@ func (s *Server) GetWorkflows(ctx context.Context, req *vtctldatapb.GetWorkflows
 		where,
 	)
 
-	vx := vexec.NewVExec(req.Keyspace, "", s.ts, s.tmc)
+	vx := vexec.NewVExec(req.Keyspace, req.Workflow, s.ts, s.tmc)
 	results, err := vx.QueryContext(ctx, query)
 	if err != nil {
 		return nil, err
@@ -798,3 +827,1320 @@ func (s *Server) WorkflowUpdate(ctx context.Context, req *vtctldatapb.WorkflowUp
 	response.Details = details
 	return response, nil
 }
+
+// WorkflowStatus is part of the vtctlservicepb.VtctldServer interface.
+// It returns the copy progress and the state of each stream of the given
+// workflow, along with a summary of its traffic switching state.
+func (s *Server) WorkflowStatus(ctx context.Context, req *vtctldatapb.WorkflowStatusRequest) (*vtctldatapb.WorkflowStatusResponse, error) {
+	span, ctx := trace.NewSpan(ctx, "workflow.Server.WorkflowStatus")
+	defer span.Finish()
+
+	span.Annotate("keyspace", req.Keyspace)
+	span.Annotate("workflow", req.Workflow)
+
+	ts, state, err := s.getWorkflowState(ctx, req.Keyspace, req.Workflow)
+	if err != nil {
+		return nil, err
+	}
+	if ts == nil {
+		return nil, vterrors.Errorf(vtrpcpb.Code_NOT_FOUND, "workflow %s not found in keyspace %s", req.Workflow, req.Keyspace)
+	}
+
+	copyProgress, err := s.getCopyProgress(ctx, ts)
+	if err != nil {
+		return nil, err
+	}
+	resp := &vtctldatapb.WorkflowStatusResponse{
+		TableCopyState: make(map[string]*vtctldatapb.WorkflowStatusResponse_TableCopyState, len(copyProgress)),
+		ShardStreams:   make(map[string]*vtctldatapb.WorkflowStatusResponse_ShardStreams),
+		TrafficState:   state.String(),
+	}
+	for table, progress := range copyProgress {
+		resp.TableCopyState[table] = &vtctldatapb.WorkflowStatusResponse_TableCopyState{
+			RowsCopied:      progress.TargetRowCount,
+			RowsTotal:       progress.SourceRowCount,
+			RowsPercentage:  percentage(progress.TargetRowCount, progress.SourceRowCount),
+			BytesCopied:     progress.TargetTableSize,
+			BytesTotal:      progress.SourceTableSize,
+			BytesPercentage: percentage(progress.TargetTableSize, progress.SourceTableSize),
+		}
+	}
+
+	workflows, err := s.GetWorkflows(ctx, &vtctldatapb.GetWorkflowsRequest{
+		Keyspace: req.Keyspace,
+		Workflow: req.Workflow,
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, wf := range workflows.Workflows {
+		for shardStreamKey, shardStream := range wf.ShardStreams {
+			states := &vtctldatapb.WorkflowStatusResponse_ShardStreams{}
+			for _, stream := range shardStream.Streams {
+				var info []string
+				if stream.Message != "" {
+					info = append(info, stream.Message)
+				}
+				if stream.TimeUpdated != nil && stream.TransactionTimestamp != nil && stream.TransactionTimestamp.Seconds > 0 {
+					info = append(info, fmt.Sprintf("VStream Lag: %ds", stream.TimeUpdated.Seconds-stream.TransactionTimestamp.Seconds))
+					info = append(info, fmt.Sprintf("Tx time: %s", time.Unix(stream.TransactionTimestamp.Seconds, 0).UTC().Format(time.ANSIC)))
+				}
+				var sourceShard string
+				if stream.BinlogSource != nil {
+					sourceShard = fmt.Sprintf("%s/%s", stream.BinlogSource.Keyspace, stream.BinlogSource.Shard)
+				}
+				states.Streams = append(states.Streams, &vtctldatapb.WorkflowStatusResponse_ShardStreamState{
+					Id:          int32(stream.Id),
+					Tablet:      stream.Tablet,
+					SourceShard: sourceShard,
+					Position:    stream.Position,
+					Status:      stream.State,
+					Info:        strings.Join(info, "; "),
+				})
+			}
+			resp.ShardStreams[shardStreamKey] = states
+		}
+	}
+
+	return resp, nil
+}
+
+// percentage returns part as a percentage of total, or 0 if total is 0.
+func percentage(part, total int64) float32 {
+	if total <= 0 {
+		return 0
+	}
+	return float32(float64(part) * 100 / float64(total))
+}
+
+// tableCopyProgress stores the row counts and disk sizes of the source and
+// target tables.
+type tableCopyProgress struct {
+	TargetRowCount, TargetTableSize int64
+	SourceRowCount, SourceTableSize int64
+}
+
+// copyProgress stores the tableCopyProgress for all tables still being copied.
+type copyProgress map[string]*tableCopyProgress
+
+// getCopyProgress returns the progress of all tables still being copied in
+// the workflow.
+func (s *Server) getCopyProgress(ctx context.Context, ts *trafficSwitcher) (copyProgress, error) {
+	getTablesQuery := "select distinct table_name from _vt.copy_state cs, _vt.vreplication vr where vr.id = cs.vrepl_id and vr.id = %d"
+	getRowCountQuery := "select table_name, table_rows, data_length from information_schema.tables where table_schema = %s and table_name in (%s)"
+	tables := make(map[string]bool)
+	const MaxRows = 1000
+	sourcePrimaries := make(map[*topodatapb.TabletAlias]bool)
+	for _, target := range ts.targets {
+		for id, bls := range target.Sources {
+			query := fmt.Sprintf(getTablesQuery, id)
+			p3qr, err := s.tmc.ExecuteFetchAsDba(ctx, target.GetPrimary().Tablet, true, &tabletmanagerdatapb.ExecuteFetchAsDbaRequest{
+				Query:   []byte(query),
+				MaxRows: MaxRows,
+			})
+			if err != nil {
+				return nil, err
+			}
+			if len(p3qr.Rows) < 1 {
+				continue
+			}
+			qr := sqltypes.Proto3ToResult(p3qr)
+			for i := 0; i < len(p3qr.Rows); i++ {
+				tables[qr.Rows[i][0].ToString()] = true
+			}
+			sourcesi, err := s.ts.GetShard(ctx, bls.Keyspace, bls.Shard)
+			if err != nil {
+				return nil, err
+			}
+			found := false
+			for existingSource := range sourcePrimaries {
+				if existingSource.Uid == sourcesi.PrimaryAlias.Uid {
+					found = true
+				}
+			}
+			if !found {
+				sourcePrimaries[sourcesi.PrimaryAlias] = true
+			}
+		}
+	}
+	if len(tables) == 0 {
+		return nil, nil
+	}
+	var tableList []string
+	targetRowCounts := make(map[string]int64)
+	sourceRowCounts := make(map[string]int64)
+	targetTableSizes := make(map[string]int64)
+	sourceTableSizes := make(map[string]int64)
+
+	for table := range tables {
+		tableList = append(tableList, encodeString(table))
+		targetRowCounts[table] = 0
+		sourceRowCounts[table] = 0
+		targetTableSizes[table] = 0
+		sourceTableSizes[table] = 0
+	}
+
+	getTableMetrics := func(tablet *topodatapb.Tablet, query string, rowCounts, tableSizes map[string]int64) error {
+		p3qr, err := s.tmc.ExecuteFetchAsDba(ctx, tablet, true, &tabletmanagerdatapb.ExecuteFetchAsDbaRequest{
+			Query:   []byte(query),
+			MaxRows: uint64(len(tables)),
+		})
+		if err != nil {
+			return err
+		}
+		qr := sqltypes.Proto3ToResult(p3qr)
+		for i := 0; i < len(qr.Rows); i++ {
+			table := qr.Rows[i][0].ToString()
+			rowCount, err := evalengine.ToInt64(qr.Rows[i][1])
+			if err != nil {
+				return err
+			}
+			tableSize, err := evalengine.ToInt64(qr.Rows[i][2])
+			if err != nil {
+				return err
+			}
+			rowCounts[table] += rowCount
+			tableSizes[table] += tableSize
+		}
+		return nil
+	}
+	sourceDbName := ""
+	for _, tsSource := range ts.sources {
+		sourceDbName = tsSource.GetPrimary().DbName()
+		break
+	}
+	if sourceDbName == "" {
+		return nil, fmt.Errorf("no sources found for workflow %s.%s", ts.targetKeyspace, ts.workflow)
+	}
+	targetDbName := ""
+	for _, tsTarget := range ts.targets {
+		targetDbName = tsTarget.GetPrimary().DbName()
+		break
+	}
+	if targetDbName == "" {
+		return nil, fmt.Errorf("workflow %s.%s is incorrectly configured", ts.targetKeyspace, ts.workflow)
+	}
+	sort.Strings(tableList) // sort list for repeatability for mocking in tests
+	tablesStr := strings.Join(tableList, ",")
+	query := fmt.Sprintf(getRowCountQuery, encodeString(targetDbName), tablesStr)
+	for _, target := range ts.targets {
+		tablet := target.GetPrimary().Tablet
+		if err := getTableMetrics(tablet, query, targetRowCounts, targetTableSizes); err != nil {
+			return nil, err
+		}
+	}
+
+	query = fmt.Sprintf(getRowCountQuery, encodeString(sourceDbName), tablesStr)
+	for source := range sourcePrimaries {
+		ti, err := s.ts.GetTablet(ctx, source)
+		if err != nil {
+			return nil, err
+		}
+		if err := getTableMetrics(ti.Tablet, query, sourceRowCounts, sourceTableSizes); err != nil {
+			return nil, err
+		}
+	}
+
+	progress := copyProgress{}
+	for table, rowCount := range targetRowCounts {
+		progress[table] = &tableCopyProgress{
+			TargetRowCount:  rowCount,
+			TargetTableSize: targetTableSizes[table],
+			SourceRowCount:  sourceRowCounts[table],
+			SourceTableSize: sourceTableSizes[table],
+		}
+	}
+	return progress, nil
+}
+
+// WorkflowSwitchTraffic is part of the vtctlservicepb.VtctldServer interface.
+// It switches read and/or write traffic for the given workflow, in the
+// direction requested.
+func (s *Server) WorkflowSwitchTraffic(ctx context.Context, req *vtctldatapb.WorkflowSwitchTrafficRequest) (*vtctldatapb.WorkflowSwitchTrafficResponse, error) {
+	span, ctx := trace.NewSpan(ctx, "workflow.Server.WorkflowSwitchTraffic")
+	defer span.Finish()
+
+	span.Annotate("keyspace", req.Keyspace)
+	span.Annotate("workflow", req.Workflow)
+	span.Annotate("cells", req.Cells)
+	span.Annotate("tablet_types", req.TabletTypes)
+	span.Annotate("direction", req.Direction)
+	span.Annotate("enable_reverse_replication", req.EnableReverseReplication)
+	span.Annotate("dry_run", req.DryRun)
+
+	var (
+		dryRunResults                     []string
+		rdDryRunResults, wrDryRunResults  *[]string
+		hasReplica, hasRdonly, hasPrimary bool
+	)
+	timeout, set, err := protoutil.DurationFromProto(req.Timeout)
+	if err != nil {
+		return nil, vterrors.Wrapf(err, "unable to parse Timeout into a valid duration")
+	}
+	if !set {
+		timeout = defaultSwitchTrafficTimeout
+	}
+	maxReplicationLagAllowed, set, err := protoutil.DurationFromProto(req.MaxReplicationLagAllowed)
+	if err != nil {
+		return nil, vterrors.Wrapf(err, "unable to parse MaxReplicationLagAllowed into a valid duration")
+	}
+	if !set {
+		maxReplicationLagAllowed = defaultMaxReplicationLagAllowed
+	}
+	direction := TrafficSwitchDirection(req.Direction)
+	if direction != DirectionForward && direction != DirectionBackward {
+		return nil, vterrors.Errorf(vtrpcpb.Code_INVALID_ARGUMENT, "invalid traffic switching direction: %d", req.Direction)
+	}
+
+	ts, startState, err := s.getWorkflowState(ctx, req.Keyspace, req.Workflow)
+	if err != nil {
+		return nil, err
+	}
+	if ts == nil {
+		return nil, vterrors.Errorf(vtrpcpb.Code_NOT_FOUND, "workflow %s not found in keyspace %s", req.Workflow, req.Keyspace)
+	}
+
+	reason, err := s.canSwitch(ctx, ts, startState, direction, int64(maxReplicationLagAllowed.Seconds()))
+	if err != nil {
+		return nil, err
+	}
+	if reason != "" {
+		return nil, vterrors.Errorf(vtrpcpb.Code_FAILED_PRECONDITION, "cannot switch traffic for workflow %s at this time: %s", startState.Workflow, reason)
+	}
+
+	hasReplica, hasRdonly, hasPrimary, err = parseTabletTypes(req.TabletTypes)
+	if err != nil {
+		return nil, err
+	}
+	if hasReplica || hasRdonly {
+		if rdDryRunResults, err = s.switchReads(ctx, req, ts, startState, direction); err != nil {
+			return nil, err
+		}
+	}
+	if rdDryRunResults != nil {
+		dryRunResults = append(dryRunResults, *rdDryRunResults...)
+	}
+	if hasPrimary {
+		if _, wrDryRunResults, err = s.switchWrites(ctx, req, ts, timeout, false); err != nil {
+			return nil, err
+		}
+	}
+	if wrDryRunResults != nil {
+		dryRunResults = append(dryRunResults, *wrDryRunResults...)
+	}
+
+	cmd := "SwitchTraffic"
+	if direction == DirectionBackward {
+		cmd = "ReverseTraffic"
+	}
+	resp := &vtctldatapb.WorkflowSwitchTrafficResponse{}
+	if req.DryRun {
+		resp.Summary = fmt.Sprintf("%s dry run results for workflow %s.%s at %v", cmd, req.Keyspace, req.Workflow, time.Now().UTC().Format(time.RFC822))
+		resp.DryRunResults = dryRunResults
+		return resp, nil
+	}
+
+	resp.Summary = fmt.Sprintf("%s was successful for workflow %s.%s", cmd, req.Keyspace, req.Workflow)
+	// Reload the state after the switch so that we can report on what changed.
+	_, currentState, err := s.getWorkflowState(ctx, req.Keyspace, req.Workflow)
+	if err != nil {
+		resp.CurrentState = fmt.Sprintf("Error reloading workflow state after switching traffic: %v", err)
+	} else {
+		resp.CurrentState = currentState.String()
+	}
+	resp.StartState = startState.String()
+	return resp, nil
+}
+
+// parseTabletTypes returns which of the tablet types that can have their
+// traffic switched are present in the given list. An empty list means that
+// traffic should be switched for all of them.
+func parseTabletTypes(tabletTypes []topodatapb.TabletType) (hasReplica, hasRdonly, hasPrimary bool, err error) {
+	if len(tabletTypes) == 0 {
+		return true, true, true, nil
+	}
+	for _, tabletType := range tabletTypes {
+		switch tabletType {
+		case topodatapb.TabletType_REPLICA:
+			hasReplica = true
+		case topodatapb.TabletType_RDONLY:
+			hasRdonly = true
+		case topodatapb.TabletType_PRIMARY:
+			hasPrimary = true
+		default:
+			return false, false, false, vterrors.Errorf(vtrpcpb.Code_INVALID_ARGUMENT, "invalid tablet type passed %s", tabletType)
+		}
+	}
+	return hasReplica, hasRdonly, hasPrimary, nil
+}
+
+// canSwitch checks whether the workflow is in a state where traffic can be
+// safely switched, returning a non-empty reason when it cannot.
+func (s *Server) canSwitch(ctx context.Context, ts *trafficSwitcher, state *State, direction TrafficSwitchDirection, maxAllowedReplLagSecs int64) (reason string, err error) {
+	if direction == DirectionForward && state.WritesSwitched ||
+		direction == DirectionBackward && !state.WritesSwitched {
+		log.Infof("writes already switched no need to check lag")
+		return "", nil
+	}
+	keyspace, workflowName := state.TargetKeyspace, state.Workflow
+	if direction == DirectionBackward {
+		keyspace, workflowName = state.SourceKeyspace, ReverseWorkflowName(state.Workflow)
+	}
+	wf, err := s.GetWorkflows(ctx, &vtctldatapb.GetWorkflowsRequest{
+		Keyspace: keyspace,
+		Workflow: workflowName,
+	})
+	if err != nil {
+		return "", err
+	}
+	if len(wf.Workflows) == 0 {
+		return "", vterrors.Errorf(vtrpcpb.Code_NOT_FOUND, "workflow %s not found in keyspace %s", workflowName, keyspace)
+	}
+	for _, shardStream := range wf.Workflows[0].ShardStreams {
+		for _, stream := range shardStream.Streams {
+			if stream.Message == Frozen {
+				return cannotSwitchFrozen, nil
+			}
+			switch stream.State {
+			case "Copying":
+				return cannotSwitchCopyIncomplete, nil
+			case "Error":
+				return cannotSwitchError, nil
+			}
+		}
+	}
+	if wf.Workflows[0].MaxVReplicationLag > maxAllowedReplLagSecs {
+		return fmt.Sprintf(cannotSwitchHighLag, wf.Workflows[0].MaxVReplicationLag, maxAllowedReplLagSecs), nil
+	}
+
+	// Ensure that the tablets on both sides are in good shape as we make this same call in the process
+	// and an error will cause us to backout.
+	refreshErrors := strings.Builder{}
+	var m sync.Mutex
+	var wg sync.WaitGroup
+	rtbsCtx, cancel := context.WithTimeout(ctx, shardTabletRefreshTimeout)
+	defer cancel()
+	refreshTablets := func(shards []*topo.ShardInfo, stype string) {
+		defer wg.Done()
+		for _, si := range shards {
+			if partial, partialDetails, err := topotools.RefreshTabletsByShard(rtbsCtx, s.ts, s.tmc, si, nil, ts.Logger()); err != nil || partial {
+				m.Lock()
+				refreshErrors.WriteString(fmt.Sprintf("failed to successfully refresh all tablets in the %s/%s %s shard (%v):\n  %v\n",
+					si.Keyspace(), si.ShardName(), stype, err, partialDetails))
+				m.Unlock()
+			}
+		}
+	}
+	wg.Add(1)
+	go refreshTablets(ts.SourceShards(), "source")
+	wg.Add(1)
+	go refreshTablets(ts.TargetShards(), "target")
+	wg.Wait()
+	if refreshErrors.Len() > 0 {
+		return fmt.Sprintf(cannotSwitchFailedTabletRefresh, refreshErrors.String()), nil
+	}
+	return "", nil
+}
+
+// switchReads is a generic way of switching read traffic for a workflow.
+func (s *Server) switchReads(ctx context.Context, req *vtctldatapb.WorkflowSwitchTrafficRequest, ts *trafficSwitcher, state *State, direction TrafficSwitchDirection) (*[]string, error) {
+	var roTabletTypes []topodatapb.TabletType
+	if len(req.TabletTypes) == 0 {
+		roTabletTypes = []topodatapb.TabletType{topodatapb.TabletType_REPLICA, topodatapb.TabletType_RDONLY}
+	} else {
+		for _, tt := range req.TabletTypes {
+			if tt != topodatapb.TabletType_PRIMARY {
+				roTabletTypes = append(roTabletTypes, tt)
+			}
+		}
+	}
+
+	log.Infof("Switching reads: %s.%s tablet types: %s, cells: %s, workflow state: %s", ts.targetKeyspace, ts.workflow, roTabletTypes, req.Cells, state.String())
+	var switchReplicas, switchRdonly bool
+	for _, roType := range roTabletTypes {
+		if direction == DirectionBackward && roType == topodatapb.TabletType_REPLICA && len(state.ReplicaCellsSwitched) == 0 {
+			return nil, vterrors.Errorf(vtrpcpb.Code_FAILED_PRECONDITION, "requesting reversal of read traffic for REPLICAs but REPLICA reads have not been switched")
+		}
+		if direction == DirectionBackward && roType == topodatapb.TabletType_RDONLY && len(state.RdonlyCellsSwitched) == 0 {
+			return nil, vterrors.Errorf(vtrpcpb.Code_FAILED_PRECONDITION, "requesting reversal of read traffic for RDONLYs but RDONLY reads have not been switched")
+		}
+		switch roType {
+		case topodatapb.TabletType_REPLICA:
+			switchReplicas = true
+		case topodatapb.TabletType_RDONLY:
+			switchRdonly = true
+		}
+	}
+
+	// If there are no rdonly tablets in the cells ask to switch rdonly tablets as well so that routing rules
+	// are updated for rdonly as well. Otherwise vitess will not know that the workflow has completed and will
+	// incorrectly report that not all reads have been switched. User currently is forced to switch non-existent
+	// rdonly tablets.
+	if switchReplicas && !switchRdonly {
+		rdonlyTabletsExist, err := topotools.DoCellsHaveRdonlyTablets(ctx, s.ts, req.Cells)
+		if err != nil {
+			return nil, err
+		}
+		if !rdonlyTabletsExist {
+			roTabletTypes = append(roTabletTypes, topodatapb.TabletType_RDONLY)
+		}
+	}
+
+	// If journals exist notify user and fail.
+	journalsExist, _, err := ts.checkJournals(ctx)
+	if err != nil {
+		ts.Logger().Errorf("checkJournals failed: %v", err)
+		return nil, err
+	}
+	if journalsExist {
+		log.Infof("Found a previous journal entry for %d", ts.id)
+	}
+	var sw iswitcher
+	if req.DryRun {
+		sw = &switcherDryRun{ts: ts, drLog: NewLogRecorder()}
+	} else {
+		sw = &switcher{ts: ts, ws: s}
+	}
+
+	if err := ts.validate(ctx); err != nil {
+		ts.Logger().Errorf("validate failed: %v", err)
+		return nil, err
+	}
+
+	// For reads, locking the source keyspace is sufficient.
+	ctx, unlock, lockErr := sw.lockKeyspace(ctx, ts.SourceKeyspaceName(), "SwitchReads")
+	if lockErr != nil {
+		ts.Logger().Errorf("LockKeyspace failed: %v", lockErr)
+		return nil, lockErr
+	}
+	defer unlock(&err)
+
+	if ts.MigrationType() == binlogdatapb.MigrationType_TABLES {
+		if ts.isPartialMigration {
+			ts.Logger().Infof("Partial migration, skipping switchTableReads as traffic is all or nothing per shard and overridden for reads AND writes in the ShardRoutingRule created when switching writes.")
+		} else if err := sw.switchTableReads(ctx, req.Cells, roTabletTypes, direction); err != nil {
+			ts.Logger().Errorf("switchTableReads failed: %v", err)
+			return nil, err
+		}
+		return sw.logs(), nil
+	}
+	ts.Logger().Infof("About to switchShardReads: %+v, %+s, %+v", req.Cells, roTabletTypes, direction)
+	if err := sw.switchShardReads(ctx, req.Cells, roTabletTypes, direction); err != nil {
+		ts.Logger().Errorf("switchShardReads failed: %v", err)
+		return nil, err
+	}
+
+	ts.Logger().Infof("switchShardReads Completed: %+v, %+s, %+v", req.Cells, roTabletTypes, direction)
+	if err := s.ts.ValidateSrvKeyspace(ctx, ts.targetKeyspace, strings.Join(req.Cells, ",")); err != nil {
+		err2 := vterrors.Wrapf(err, "after switching shard reads, found SrvKeyspace for %s is corrupt in cell %s",
+			ts.targetKeyspace, strings.Join(req.Cells, ","))
+		ts.Logger().Errorf("%v", err2)
+		return nil, err2
+	}
+	return sw.logs(), nil
+}
+
+// switchWrites is a generic way of migrating write traffic for a workflow.
+func (s *Server) switchWrites(ctx context.Context, req *vtctldatapb.WorkflowSwitchTrafficRequest, ts *trafficSwitcher, timeout time.Duration,
+	cancel bool) (journalID int64, dryRunResults *[]string, err error) {
+
+	var sw iswitcher
+	if req.DryRun {
+		sw = &switcherDryRun{ts: ts, drLog: NewLogRecorder()}
+	} else {
+		sw = &switcher{ts: ts, ws: s}
+	}
+
+	// We reverse writes by using the source_keyspace.workflowname_reverse
+	// workflow spec.
+	if TrafficSwitchDirection(req.Direction) == DirectionBackward {
+		ts, _, err = s.getWorkflowState(ctx, ts.SourceKeyspaceName(), ts.reverseWorkflow)
+		if err != nil {
+			return 0, nil, err
+		}
+		if ts == nil {
+			return 0, nil, vterrors.Errorf(vtrpcpb.Code_NOT_FOUND, "reverse workflow %s not found in keyspace %s",
+				ReverseWorkflowName(req.Workflow), req.Keyspace)
+		}
+		if req.DryRun {
+			sw = &switcherDryRun{ts: ts, drLog: NewLogRecorder()}
+		} else {
+			sw = &switcher{ts: ts, ws: s}
+		}
+	}
+
+	if ts.frozen {
+		ts.Logger().Warningf("Writes have already been switched for workflow %s, nothing to do here", ts.WorkflowName())
+		return 0, sw.logs(), nil
+	}
+
+	if err := ts.validate(ctx); err != nil {
+		ts.Logger().Errorf("validate failed: %v", err)
+		return 0, nil, err
+	}
+
+	if req.EnableReverseReplication {
+		if err := s.areTabletsAvailableToStreamFrom(ctx, ts, ts.TargetKeyspaceName(), ts.TargetShards()); err != nil {
+			return 0, nil, err
+		}
+	}
+
+	// Need to lock both source and target keyspaces.
+	tctx, sourceUnlock, lockErr := sw.lockKeyspace(ctx, ts.SourceKeyspaceName(), "SwitchWrites")
+	if lockErr != nil {
+		ts.Logger().Errorf("LockKeyspace failed: %v", lockErr)
+		return 0, nil, lockErr
+	}
+	ctx = tctx
+	defer sourceUnlock(&err)
+	if ts.TargetKeyspaceName() != ts.SourceKeyspaceName() {
+		tctx, targetUnlock, lockErr := sw.lockKeyspace(ctx, ts.TargetKeyspaceName(), "SwitchWrites")
+		if lockErr != nil {
+			ts.Logger().Errorf("LockKeyspace failed: %v", lockErr)
+			return 0, nil, lockErr
+		}
+		ctx = tctx
+		defer targetUnlock(&err)
+	}
+
+	// If no journals exist, sourceWorkflows will be initialized by sm.MigrateStreams.
+	journalsExist, sourceWorkflows, err := ts.checkJournals(ctx)
+	if err != nil {
+		ts.Logger().Errorf("checkJournals failed: %v", err)
+		return 0, nil, err
+	}
+	if !journalsExist {
+		ts.Logger().Infof("No previous journals were found. Proceeding normally.")
+		sm, err := BuildStreamMigrator(ctx, ts, cancel)
+		if err != nil {
+			ts.Logger().Errorf("buildStreamMigrater failed: %v", err)
+			return 0, nil, err
+		}
+		if cancel {
+			sw.cancelMigration(ctx, sm)
+			return 0, sw.logs(), nil
+		}
+
+		ts.Logger().Infof("Stopping streams")
+		sourceWorkflows, err = sw.stopStreams(ctx, sm)
+		if err != nil {
+			ts.Logger().Errorf("stopStreams failed: %v", err)
+			for key, streams := range sm.Streams() {
+				for _, stream := range streams {
+					ts.Logger().Errorf("stream in stopStreams: key %s shard %s stream %+v", key, stream.BinlogSource.Shard, stream.BinlogSource)
+				}
+			}
+			sw.cancelMigration(ctx, sm)
+			return 0, nil, err
+		}
+
+		ts.Logger().Infof("Stopping source writes")
+		if err := sw.stopSourceWrites(ctx); err != nil {
+			ts.Logger().Errorf("stopSourceWrites failed: %v", err)
+			sw.cancelMigration(ctx, sm)
+			return 0, nil, err
+		}
+
+		if ts.MigrationType() == binlogdatapb.MigrationType_TABLES {
+			ts.Logger().Infof("Executing LOCK TABLES on source tables %d times", lockTablesCycles)
+			// Doing this twice with a pause in-between to catch any writes that may have raced in between
+			// the tablet's deny list check and the first mysqld side table lock.
+			for cnt := 1; cnt <= lockTablesCycles; cnt++ {
+				if err := ts.executeLockTablesOnSource(ctx); err != nil {
+					ts.Logger().Errorf("Failed to execute LOCK TABLES (attempt %d of %d) on sources: %v", cnt, lockTablesCycles, err)
+					sw.cancelMigration(ctx, sm)
+					return 0, nil, err
+				}
+				// No need to UNLOCK the tables as the connection was closed once the locks were acquired
+				// and thus the locks released.
+				time.Sleep(lockTablesCycleDelay)
+			}
+		}
+
+		ts.Logger().Infof("Waiting for streams to catchup")
+		if err := sw.waitForCatchup(ctx, timeout); err != nil {
+			ts.Logger().Errorf("waitForCatchup failed: %v", err)
+			sw.cancelMigration(ctx, sm)
+			return 0, nil, err
+		}
+
+		ts.Logger().Infof("Migrating streams")
+		if err := sw.migrateStreams(ctx, sm); err != nil {
+			ts.Logger().Errorf("migrateStreams failed: %v", err)
+			sw.cancelMigration(ctx, sm)
+			return 0, nil, err
+		}
+
+		ts.Logger().Infof("Resetting sequences")
+		if err := sw.resetSequences(ctx); err != nil {
+			ts.Logger().Errorf("resetSequences failed: %v", err)
+			sw.cancelMigration(ctx, sm)
+			return 0, nil, err
+		}
+
+		ts.Logger().Infof("Creating reverse streams")
+		if err := sw.createReverseVReplication(ctx); err != nil {
+			ts.Logger().Errorf("createReverseVReplication failed: %v", err)
+			sw.cancelMigration(ctx, sm)
+			return 0, nil, err
+		}
+	} else {
+		if cancel {
+			err := vterrors.Errorf(vtrpcpb.Code_FAILED_PRECONDITION, "traffic switching has reached the point of no return, cannot cancel")
+			ts.Logger().Errorf("%v", err)
+			return 0, nil, err
+		}
+		ts.Logger().Infof("Journals were found. Completing the left over steps.")
+		// Need to gather positions in case all journals were not created.
+		if err := ts.gatherPositions(ctx); err != nil {
+			ts.Logger().Errorf("gatherPositions failed: %v", err)
+			return 0, nil, err
+		}
+	}
+
+	// This is the point of no return. Once a journal is created,
+	// traffic can be redirected to target shards.
+	if err := sw.createJournals(ctx, sourceWorkflows); err != nil {
+		ts.Logger().Errorf("createJournals failed: %v", err)
+		return 0, nil, err
+	}
+	if err := sw.allowTargetWrites(ctx); err != nil {
+		ts.Logger().Errorf("allowTargetWrites failed: %v", err)
+		return 0, nil, err
+	}
+	if err := sw.changeRouting(ctx); err != nil {
+		ts.Logger().Errorf("changeRouting failed: %v", err)
+		return 0, nil, err
+	}
+	if err := sw.streamMigraterfinalize(ctx, ts, sourceWorkflows); err != nil {
+		ts.Logger().Errorf("finalize failed: %v", err)
+		return 0, nil, err
+	}
+	if req.EnableReverseReplication {
+		if err := sw.startReverseVReplication(ctx); err != nil {
+			ts.Logger().Errorf("startReverseVReplication failed: %v", err)
+			return 0, nil, err
+		}
+	}
+
+	if err := sw.freezeTargetVReplication(ctx); err != nil {
+		ts.Logger().Errorf("deleteTargetVReplication failed: %v", err)
+		return 0, nil, err
+	}
+
+	return ts.id, sw.logs(), nil
+}
+
+// areTabletsAvailableToStreamFrom checks that there is at least one tablet
+// in each of the given shards that vreplication could stream from.
+func (s *Server) areTabletsAvailableToStreamFrom(ctx context.Context, ts *trafficSwitcher, keyspace string, shards []*topo.ShardInfo) error {
+	var cells []string
+	tabletTypes := ts.optTabletTypes
+	if ts.optCells != "" {
+		cells = strings.Split(ts.optCells, ",")
+	}
+	if tabletTypes == "" {
+		tabletTypes = "primary,replica"
+	}
+
+	var wg sync.WaitGroup
+	allErrors := &concurrency.AllErrorRecorder{}
+	for _, shard := range shards {
+		wg.Add(1)
+		go func(cells []string, keyspace string, shard *topo.ShardInfo) {
+			defer wg.Done()
+			if cells == nil {
+				cells = append(cells, shard.PrimaryAlias.Cell)
+			}
+			tp, err := discovery.NewTabletPicker(ctx, s.ts, cells, shard.PrimaryAlias.Cell, keyspace, shard.ShardName(), tabletTypes, discovery.TabletPickerOptions{})
+			if err != nil {
+				allErrors.RecordError(err)
+				return
+			}
+			tablets := tp.GetMatchingTablets(ctx)
+			if len(tablets) == 0 {
+				allErrors.RecordError(fmt.Errorf("no tablet found to source data in keyspace %s, shard %s", keyspace, shard.ShardName()))
+				return
+			}
+		}(cells, keyspace, shard)
+	}
+
+	wg.Wait()
+	if allErrors.HasErrors() {
+		log.Errorf("%s", allErrors.Error())
+		return allErrors.Error()
+	}
+	return nil
+}
+
+// WorkflowComplete is part of the vtctlservicepb.VtctldServer interface.
+// It cleans up a successful workflow once all of its traffic has been
+// switched: the source tables or shards, the denied tables, the
+// vreplication streams and (optionally) the routing rules.
+func (s *Server) WorkflowComplete(ctx context.Context, req *vtctldatapb.WorkflowCompleteRequest) (*vtctldatapb.WorkflowCompleteResponse, error) {
+	span, ctx := trace.NewSpan(ctx, "workflow.Server.WorkflowComplete")
+	defer span.Finish()
+
+	span.Annotate("keyspace", req.Keyspace)
+	span.Annotate("workflow", req.Workflow)
+	span.Annotate("keep_data", req.KeepData)
+	span.Annotate("keep_routing_rules", req.KeepRoutingRules)
+	span.Annotate("rename_tables", req.RenameTables)
+	span.Annotate("dry_run", req.DryRun)
+
+	ts, state, err := s.getWorkflowState(ctx, req.Keyspace, req.Workflow)
+	if err != nil {
+		return nil, err
+	}
+	if ts == nil {
+		return nil, vterrors.Errorf(vtrpcpb.Code_NOT_FOUND, "workflow %s not found in keyspace %s", req.Workflow, req.Keyspace)
+	}
+	if !state.WritesSwitched || len(state.ReplicaCellsNotSwitched) > 0 || len(state.RdonlyCellsNotSwitched) > 0 {
+		return nil, vterrors.Errorf(vtrpcpb.Code_FAILED_PRECONDITION, ErrWorkflowNotFullySwitched)
+	}
+
+	removalType := DropTable
+	if req.RenameTables {
+		removalType = RenameTable
+	}
+	dryRunResults, err := s.dropSources(ctx, ts, removalType, req.KeepData, req.KeepRoutingRules, false /* force */, req.DryRun)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &vtctldatapb.WorkflowCompleteResponse{}
+	if req.DryRun {
+		resp.Summary = fmt.Sprintf("Complete dry run results for workflow %s.%s at %v", req.Keyspace, req.Workflow, time.Now().UTC().Format(time.RFC822))
+		resp.DryRunResults = *dryRunResults
+	} else {
+		resp.Summary = fmt.Sprintf("Successfully completed the %s workflow in the %s keyspace", req.Workflow, req.Keyspace)
+	}
+	return resp, nil
+}
+
+// WorkflowDelete is part of the vtctlservicepb.VtctldServer interface.
+// It cancels a workflow whose traffic has not yet been switched, deleting
+// the target tables or shards (unless asked to keep the data), the
+// vreplication streams and (optionally) the routing rules.
+func (s *Server) WorkflowDelete(ctx context.Context, req *vtctldatapb.WorkflowDeleteRequest) (*vtctldatapb.WorkflowDeleteResponse, error) {
+	span, ctx := trace.NewSpan(ctx, "workflow.Server.WorkflowDelete")
+	defer span.Finish()
+
+	span.Annotate("keyspace", req.Keyspace)
+	span.Annotate("workflow", req.Workflow)
+	span.Annotate("keep_data", req.KeepData)
+	span.Annotate("keep_routing_rules", req.KeepRoutingRules)
+	span.Annotate("dry_run", req.DryRun)
+
+	ts, state, err := s.getWorkflowState(ctx, req.Keyspace, req.Workflow)
+	if err != nil {
+		return nil, err
+	}
+	if ts == nil {
+		return nil, vterrors.Errorf(vtrpcpb.Code_NOT_FOUND, "workflow %s not found in keyspace %s", req.Workflow, req.Keyspace)
+	}
+	if state.WritesSwitched || len(state.ReplicaCellsSwitched) > 0 || len(state.RdonlyCellsSwitched) > 0 {
+		return nil, vterrors.Errorf(vtrpcpb.Code_FAILED_PRECONDITION, ErrWorkflowPartiallySwitched)
+	}
+
+	dryRunResults, err := s.dropTargets(ctx, ts, req.KeepData, req.KeepRoutingRules, req.DryRun)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &vtctldatapb.WorkflowDeleteResponse{}
+	if req.DryRun {
+		resp.Summary = fmt.Sprintf("Delete dry run results for workflow %s.%s at %v", req.Keyspace, req.Workflow, time.Now().UTC().Format(time.RFC822))
+		resp.DryRunResults = *dryRunResults
+	} else {
+		resp.Summary = fmt.Sprintf("Successfully deleted the %s workflow in the %s keyspace", req.Workflow, req.Keyspace)
+	}
+	return resp, nil
+}
+
+// dropTargets cleans up target tables, shards and denied tables if a
+// MoveTables/Reshard is cancelled.
+func (s *Server) dropTargets(ctx context.Context, ts *trafficSwitcher, keepData, keepRoutingRules, dryRun bool) (_ *[]string, err error) {
+	ts.keepRoutingRules = keepRoutingRules
+	var sw iswitcher
+	if dryRun {
+		sw = &switcherDryRun{ts: ts, drLog: NewLogRecorder()}
+	} else {
+		sw = &switcher{ts: ts, ws: s}
+	}
+	var tctx context.Context
+	tctx, sourceUnlock, lockErr := sw.lockKeyspace(ctx, ts.SourceKeyspaceName(), "DropTargets")
+	if lockErr != nil {
+		ts.Logger().Errorf("Source LockKeyspace failed: %v", lockErr)
+		return nil, lockErr
+	}
+	defer sourceUnlock(&err)
+	ctx = tctx
+	if ts.TargetKeyspaceName() != ts.SourceKeyspaceName() {
+		tctx, targetUnlock, lockErr := sw.lockKeyspace(ctx, ts.TargetKeyspaceName(), "DropTargets")
+		if lockErr != nil {
+			ts.Logger().Errorf("Target LockKeyspace failed: %v", lockErr)
+			return nil, lockErr
+		}
+		defer targetUnlock(&err)
+		ctx = tctx
+	}
+	if !keepData {
+		switch ts.MigrationType() {
+		case binlogdatapb.MigrationType_TABLES:
+			log.Infof("Deleting target tables")
+			if err := sw.removeTargetTables(ctx); err != nil {
+				return nil, err
+			}
+			if err := sw.dropSourceDeniedTables(ctx); err != nil {
+				return nil, err
+			}
+		case binlogdatapb.MigrationType_SHARDS:
+			log.Infof("Removing target shards")
+			if err := sw.dropTargetShards(ctx); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if err := s.dropArtifacts(ctx, keepRoutingRules, sw); err != nil {
+		return nil, err
+	}
+	if err := ts.TopoServer().RebuildSrvVSchema(ctx, nil); err != nil {
+		return nil, err
+	}
+	return sw.logs(), nil
+}
+
+// dropSources cleans up source tables, shards and denied tables after a
+// MoveTables/Reshard is completed.
+func (s *Server) dropSources(ctx context.Context, ts *trafficSwitcher, removalType TableRemovalType, keepData, keepRoutingRules, force, dryRun bool) (_ *[]string, err error) {
+	var sw iswitcher
+	if dryRun {
+		sw = &switcherDryRun{ts: ts, drLog: NewLogRecorder()}
+	} else {
+		sw = &switcher{ts: ts, ws: s}
+	}
+	var tctx context.Context
+	tctx, sourceUnlock, lockErr := sw.lockKeyspace(ctx, ts.SourceKeyspaceName(), "DropSources")
+	if lockErr != nil {
+		ts.Logger().Errorf("Source LockKeyspace failed: %v", lockErr)
+		return nil, lockErr
+	}
+	defer sourceUnlock(&err)
+	ctx = tctx
+	if ts.TargetKeyspaceName() != ts.SourceKeyspaceName() {
+		tctx, targetUnlock, lockErr := sw.lockKeyspace(ctx, ts.TargetKeyspaceName(), "DropSources")
+		if lockErr != nil {
+			ts.Logger().Errorf("Target LockKeyspace failed: %v", lockErr)
+			return nil, lockErr
+		}
+		defer targetUnlock(&err)
+		ctx = tctx
+	}
+	if !force {
+		if err := sw.validateWorkflowHasCompleted(ctx); err != nil {
+			ts.Logger().Errorf("Workflow has not completed, cannot DropSources: %v", err)
+			return nil, err
+		}
+	}
+	if !keepData {
+		switch ts.MigrationType() {
+		case binlogdatapb.MigrationType_TABLES:
+			log.Infof("Deleting tables")
+			if err := sw.removeSourceTables(ctx, removalType); err != nil {
+				return nil, err
+			}
+			if err := sw.dropSourceDeniedTables(ctx); err != nil {
+				return nil, err
+			}
+
+		case binlogdatapb.MigrationType_SHARDS:
+			log.Infof("Removing shards")
+			if err := sw.dropSourceShards(ctx); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if err := s.dropArtifacts(ctx, keepRoutingRules, sw); err != nil {
+		return nil, err
+	}
+	if err := ts.TopoServer().RebuildSrvVSchema(ctx, nil); err != nil {
+		return nil, err
+	}
+
+	return sw.logs(), nil
+}
+
+func (s *Server) dropArtifacts(ctx context.Context, keepRoutingRules bool, sw iswitcher) error {
+	if err := sw.dropSourceReverseVReplicationStreams(ctx); err != nil {
+		return err
+	}
+	if err := sw.dropTargetVReplicationStreams(ctx); err != nil {
+		return err
+	}
+	if !keepRoutingRules {
+		if err := sw.deleteRoutingRules(ctx); err != nil {
+			return err
+		}
+		if err := sw.deleteShardRoutingRules(ctx); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// VDiffCreate is part of the vtctlservicepb.VtctldServer interface.
+// It creates a new VDiff on the target primary tablets of the workflow.
+func (s *Server) VDiffCreate(ctx context.Context, req *vtctldatapb.VDiffCreateRequest) (*vtctldatapb.VDiffCreateResponse, error) {
+	span, ctx := trace.NewSpan(ctx, "workflow.Server.VDiffCreate")
+	defer span.Finish()
+
+	span.Annotate("keyspace", req.TargetKeyspace)
+	span.Annotate("workflow", req.Workflow)
+	span.Annotate("uuid", req.Uuid)
+
+	if req.Uuid == "" {
+		req.Uuid = uuid.New().String()
+	}
+	if _, err := uuid.Parse(req.Uuid); err != nil {
+		return nil, vterrors.Wrapf(err, "invalid VDiff UUID %q", req.Uuid)
+	}
+	if _, err := s.vdiff(ctx, req.TargetKeyspace, req.Workflow, vdiff.CreateAction, "", req.Uuid, req.Options); err != nil {
+		return nil, err
+	}
+	return &vtctldatapb.VDiffCreateResponse{
+		UUID: req.Uuid,
+	}, nil
+}
+
+// VDiffDelete is part of the vtctlservicepb.VtctldServer interface.
+// It deletes the given VDiff(s) from the target primary tablets of the
+// workflow.
+func (s *Server) VDiffDelete(ctx context.Context, req *vtctldatapb.VDiffDeleteRequest) (*vtctldatapb.VDiffDeleteResponse, error) {
+	span, ctx := trace.NewSpan(ctx, "workflow.Server.VDiffDelete")
+	defer span.Finish()
+
+	span.Annotate("keyspace", req.TargetKeyspace)
+	span.Annotate("workflow", req.Workflow)
+	span.Annotate("argument", req.Arg)
+
+	if _, err := s.vdiff(ctx, req.TargetKeyspace, req.Workflow, vdiff.DeleteAction, req.Arg, "", nil); err != nil {
+		return nil, err
+	}
+	return &vtctldatapb.VDiffDeleteResponse{}, nil
+}
+
+// VDiffResume is part of the vtctlservicepb.VtctldServer interface.
+// It resumes the given VDiff on the target primary tablets of the workflow.
+func (s *Server) VDiffResume(ctx context.Context, req *vtctldatapb.VDiffResumeRequest) (*vtctldatapb.VDiffResumeResponse, error) {
+	span, ctx := trace.NewSpan(ctx, "workflow.Server.VDiffResume")
+	defer span.Finish()
+
+	span.Annotate("keyspace", req.TargetKeyspace)
+	span.Annotate("workflow", req.Workflow)
+	span.Annotate("uuid", req.Uuid)
+
+	if _, err := s.vdiff(ctx, req.TargetKeyspace, req.Workflow, vdiff.ResumeAction, "", req.Uuid, nil); err != nil {
+		return nil, err
+	}
+	return &vtctldatapb.VDiffResumeResponse{}, nil
+}
+
+// VDiffShow is part of the vtctlservicepb.VtctldServer interface.
+// It returns the per-tablet reports for the given VDiff(s) of the workflow.
+func (s *Server) VDiffShow(ctx context.Context, req *vtctldatapb.VDiffShowRequest) (*vtctldatapb.VDiffShowResponse, error) {
+	span, ctx := trace.NewSpan(ctx, "workflow.Server.VDiffShow")
+	defer span.Finish()
+
+	span.Annotate("keyspace", req.TargetKeyspace)
+	span.Annotate("workflow", req.Workflow)
+	span.Annotate("argument", req.Arg)
+
+	responses, err := s.vdiff(ctx, req.TargetKeyspace, req.Workflow, vdiff.ShowAction, req.Arg, "", nil)
+	if err != nil {
+		return nil, err
+	}
+	return &vtctldatapb.VDiffShowResponse{
+		TabletResponses: responses,
+	}, nil
+}
+
+// VDiffStop is part of the vtctlservicepb.VtctldServer interface.
+// It stops the given VDiff on the target primary tablets of the workflow.
+func (s *Server) VDiffStop(ctx context.Context, req *vtctldatapb.VDiffStopRequest) (*vtctldatapb.VDiffStopResponse, error) {
+	span, ctx := trace.NewSpan(ctx, "workflow.Server.VDiffStop")
+	defer span.Finish()
+
+	span.Annotate("keyspace", req.TargetKeyspace)
+	span.Annotate("workflow", req.Workflow)
+	span.Annotate("uuid", req.Uuid)
+
+	if _, err := s.vdiff(ctx, req.TargetKeyspace, req.Workflow, vdiff.StopAction, "", req.Uuid, nil); err != nil {
+		return nil, err
+	}
+	return &vtctldatapb.VDiffStopResponse{}, nil
+}
+
+// vdiff sends the given VDiff action to all of the target primary tablets
+// of the workflow, returning their responses keyed by tablet alias.
+func (s *Server) vdiff(ctx context.Context, keyspace, workflowName string, action vdiff.VDiffAction, actionArg, vdiffUUID string,
+	options *tabletmanagerdatapb.VDiffOptions) (map[string]*tabletmanagerdatapb.VDiffResponse, error) {
+	req := &tabletmanagerdatapb.VDiffRequest{
+		Keyspace:  keyspace,
+		Workflow:  workflowName,
+		Action:    string(action),
+		ActionArg: actionArg,
+		Options:   options,
+		VdiffUuid: vdiffUUID,
+	}
+
+	ts, err := s.buildTrafficSwitcher(ctx, keyspace, workflowName)
+	if err != nil {
+		return nil, err
+	}
+	if action == vdiff.CreateAction && ts.frozen {
+		return nil, vterrors.Errorf(vtrpcpb.Code_FAILED_PRECONDITION, "invalid VDiff run: writes have already been switched for workflow %s.%s",
+			keyspace, workflowName)
+	}
+
+	var mu sync.Mutex
+	responses := make(map[string]*tabletmanagerdatapb.VDiffResponse, len(ts.targets))
+	err = ts.ForAllTargets(func(target *MigrationTarget) error {
+		resp, err := s.tmc.VDiff(ctx, target.GetPrimary().Tablet, req)
+		if err != nil {
+			return err
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		responses[target.GetPrimary().AliasString()] = resp
+		return nil
+	})
+	if err != nil {
+		log.Errorf("Error executing VDiff action %s: %v", action, err)
+		return nil, err
+	}
+	return responses, nil
+}
+
+func (s *Server) buildTrafficSwitcher(ctx context.Context, targetKeyspace, workflowName string) (*trafficSwitcher, error) {
+	tgtInfo, err := BuildTargets(ctx, s.ts, s.tmc, targetKeyspace, workflowName)
+	if err != nil {
+		log.Infof("Error building targets: %s", err)
+		return nil, err
+	}
+	targets, frozen, optCells, optTabletTypes := tgtInfo.Targets, tgtInfo.Frozen, tgtInfo.OptCells, tgtInfo.OptTabletTypes
+
+	ts := &trafficSwitcher{
+		ws:              s,
+		logger:          logutil.NewConsoleLogger(),
+		workflow:        workflowName,
+		reverseWorkflow: ReverseWorkflowName(workflowName),
+		id:              HashStreams(targetKeyspace, targets),
+		targets:         targets,
+		sources:         make(map[string]*MigrationSource),
+		targetKeyspace:  targetKeyspace,
+		frozen:          frozen,
+		optCells:        optCells,
+		optTabletTypes:  optTabletTypes,
+		workflowType:    tgtInfo.WorkflowType,
+		workflowSubType: tgtInfo.WorkflowSubType,
+	}
+	log.Infof("Migration ID for workflow %s: %d", workflowName, ts.id)
+	sourceTopo := s.ts
+
+	// Build the sources.
+	for _, target := range targets {
+		for _, bls := range target.Sources {
+			if ts.sourceKeyspace == "" {
+				ts.sourceKeyspace = bls.Keyspace
+				ts.sourceTimeZone = bls.SourceTimeZone
+				ts.targetTimeZone = bls.TargetTimeZone
+				ts.externalCluster = bls.ExternalCluster
+				if ts.externalCluster != "" {
+					externalTopo, err := s.ts.OpenExternalVitessClusterServer(ctx, ts.externalCluster)
+					if err != nil {
+						return nil, err
+					}
+					sourceTopo = externalTopo
+					ts.externalTopo = externalTopo
+				}
+			} else if ts.sourceKeyspace != bls.Keyspace {
+				return nil, fmt.Errorf("source keyspaces are mismatched across streams: %v vs %v", ts.sourceKeyspace, bls.Keyspace)
+			}
+
+			if ts.tables == nil {
+				for _, rule := range bls.Filter.Rules {
+					ts.tables = append(ts.tables, rule.Match)
+				}
+				sort.Strings(ts.tables)
+			} else {
+				var tables []string
+				for _, rule := range bls.Filter.Rules {
+					tables = append(tables, rule.Match)
+				}
+				sort.Strings(tables)
+				if !reflect.DeepEqual(ts.tables, tables) {
+					return nil, fmt.Errorf("table lists are mismatched across streams: %v vs %v", ts.tables, tables)
+				}
+			}
+
+			if _, ok := ts.sources[bls.Shard]; ok {
+				continue
+			}
+			sourcesi, err := sourceTopo.GetShard(ctx, bls.Keyspace, bls.Shard)
+			if err != nil {
+				return nil, err
+			}
+			sourcePrimary, err := sourceTopo.GetTablet(ctx, sourcesi.PrimaryAlias)
+			if err != nil {
+				return nil, err
+			}
+			ts.sources[bls.Shard] = NewMigrationSource(sourcesi, sourcePrimary)
+		}
+	}
+	if ts.sourceKeyspace != ts.targetKeyspace || ts.externalCluster != "" {
+		ts.migrationType = binlogdatapb.MigrationType_TABLES
+	} else {
+		// TODO(sougou): for shard migration, validate that source and target combined
+		// keyranges match.
+		ts.migrationType = binlogdatapb.MigrationType_SHARDS
+		for sourceShard := range ts.sources {
+			if _, ok := ts.targets[sourceShard]; ok {
+				// If shards are overlapping, then this is a table migration.
+				ts.migrationType = binlogdatapb.MigrationType_TABLES
+				break
+			}
+		}
+	}
+	vs, err := sourceTopo.GetVSchema(ctx, ts.sourceKeyspace)
+	if err != nil {
+		return nil, err
+	}
+	ts.sourceKSSchema, err = vindexes.BuildKeyspaceSchema(vs, ts.sourceKeyspace)
+	if err != nil {
+		return nil, err
+	}
+
+	sourceShards, targetShards := ts.getSourceAndTargetShardsNames()
+
+	ts.isPartialMigration, err = ts.isPartialMoveTables(sourceShards, targetShards)
+	if err != nil {
+		return nil, err
+	}
+	if ts.isPartialMigration {
+		log.Infof("Migration is partial, for shards %+v", sourceShards)
+	}
+	return ts, nil
+}
+
+// getWorkflowState returns the traffic switcher and the traffic switching
+// state for the given workflow. Both are nil if the workflow does not exist.
+func (s *Server) getWorkflowState(ctx context.Context, targetKeyspace, workflowName string) (*trafficSwitcher, *State, error) {
+	ts, err := s.buildTrafficSwitcher(ctx, targetKeyspace, workflowName)
+	if err != nil {
+		if errors.Is(err, ErrNoStreams) {
+			return nil, nil, nil
+		}
+		log.Errorf("buildTrafficSwitcher failed: %v", err)
+		return nil, nil, err
+	}
+
+	state := &State{
+		Workflow:           workflowName,
+		SourceKeyspace:     ts.SourceKeyspaceName(),
+		TargetKeyspace:     targetKeyspace,
+		IsPartialMigration: ts.isPartialMigration,
+	}
+
+	var (
+		reverse  bool
+		keyspace string
+	)
+
+	// We reverse writes by using the source_keyspace.workflowname_reverse workflow
+	// spec, so we need to use the source of the reverse workflow, which is the
+	// target of the workflow initiated by the user for checking routing rules.
+	// Similarly we use a target shard of the reverse workflow as the original
+	// source to check if writes have been switched.
+	if strings.HasSuffix(workflowName, "_reverse") {
+		reverse = true
+		keyspace = state.SourceKeyspace
+		workflowName = ReverseWorkflowName(workflowName)
+	} else {
+		keyspace = targetKeyspace
+	}
+	if ts.MigrationType() == binlogdatapb.MigrationType_TABLES {
+		state.WorkflowType = TypeMoveTables
+
+		// We assume a consistent state, so only choose routing rule for one table.
+		if len(ts.Tables()) == 0 {
+			return nil, nil, fmt.Errorf("no tables in workflow %s.%s", keyspace, workflowName)
+		}
+		table := ts.Tables()[0]
+
+		if ts.isPartialMigration { // shard level traffic switching is all or nothing
+			shardRoutingRules, err := s.ts.GetShardRoutingRules(ctx)
+			if err != nil {
+				return nil, nil, err
+			}
+
+			rules := shardRoutingRules.Rules
+			for _, rule := range rules {
+				if rule.ToKeyspace == ts.SourceKeyspaceName() {
+					state.ShardsNotYetSwitched = append(state.ShardsNotYetSwitched, rule.Shard)
+				} else {
+					state.ShardsAlreadySwitched = append(state.ShardsAlreadySwitched, rule.Shard)
+				}
+			}
+		} else {
+			state.RdonlyCellsSwitched, state.RdonlyCellsNotSwitched, err = s.GetCellsWithTableReadsSwitched(ctx, keyspace, table, topodatapb.TabletType_RDONLY)
+			if err != nil {
+				return nil, nil, err
+			}
+
+			state.ReplicaCellsSwitched, state.ReplicaCellsNotSwitched, err = s.GetCellsWithTableReadsSwitched(ctx, keyspace, table, topodatapb.TabletType_REPLICA)
+			if err != nil {
+				return nil, nil, err
+			}
+			globalRules, err := topotools.GetRoutingRules(ctx, ts.TopoServer())
+			if err != nil {
+				return nil, nil, err
+			}
+			for _, table := range ts.Tables() {
+				rr := globalRules[table]
+				// If a rule exists for the table and points to the target keyspace, writes have been switched.
+				if len(rr) > 0 && rr[0] == fmt.Sprintf("%s.%s", keyspace, table) {
+					state.WritesSwitched = true
+					break
+				}
+			}
+		}
+	} else {
+		state.WorkflowType = TypeReshard
+
+		// We assume a consistent state, so only choose one shard.
+		var shard *topo.ShardInfo
+		if reverse {
+			shard = ts.TargetShards()[0]
+		} else {
+			shard = ts.SourceShards()[0]
+		}
+
+		state.RdonlyCellsSwitched, state.RdonlyCellsNotSwitched, err = s.GetCellsWithShardReadsSwitched(ctx, keyspace, shard, topodatapb.TabletType_RDONLY)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		state.ReplicaCellsSwitched, state.ReplicaCellsNotSwitched, err = s.GetCellsWithShardReadsSwitched(ctx, keyspace, shard, topodatapb.TabletType_REPLICA)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if !shard.IsPrimaryServing {
+			state.WritesSwitched = true
+		}
+	}
+
+	return ts, state, nil
+}
@@ -24,15 +24,21 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"google.golang.org/protobuf/encoding/prototext"
+	"google.golang.org/protobuf/proto"
 
 	"vitess.io/vitess/go/sqltypes"
 	"vitess.io/vitess/go/test/utils"
 	"vitess.io/vitess/go/vt/topo/topoproto"
+	"vitess.io/vitess/go/vt/topotools"
+	"vitess.io/vitess/go/vt/vterrors"
 	"vitess.io/vitess/go/vt/vttablet/tmclient"
 
 	binlogdatapb "vitess.io/vitess/go/vt/proto/binlogdata"
 	querypb "vitess.io/vitess/go/vt/proto/query"
+	tabletmanagerdatapb "vitess.io/vitess/go/vt/proto/tabletmanagerdata"
 	topodatapb "vitess.io/vitess/go/vt/proto/topodata"
+	vtctldatapb "vitess.io/vitess/go/vt/proto/vtctldata"
+	vtrpcpb "vitess.io/vitess/go/vt/proto/vtrpc"
 )
 
 type fakeTMC struct {
@@ -163,3 +169,298 @@ func TestCheckReshardingJournalExistsOnTablet(t *testing.T) {
 		})
 	}
 }
+
+// newMoveTablesEnv returns a test environment with a MoveTables workflow
+// that moves t1 from sourceks to targetks, created through MoveTablesCreate
+// so that the vschema and routing rules are the ones it sets up.
+func newMoveTablesEnv(t *testing.T) *testMaterializerEnv {
+	ms := &vtctldatapb.MaterializeSettings{
+		Workflow:       "workflow",
+		SourceKeyspace: "sourceks",
+		TargetKeyspace: "targetks",
+		TableSettings: []*vtctldatapb.TableMaterializeSettings{{
+			TargetTable:      "t1",
+			SourceExpression: "select * from t1",
+		}},
+	}
+	env := newTestMaterializerEnv(t, ms, []string{"0"}, []string{"0"})
+	env.expectMoveTables(insertPrefix, true, "t1")
+	_, err := env.ws.MoveTablesCreate(context.Background(), &vtctldatapb.MoveTablesCreateRequest{
+		Workflow:       "workflow",
+		SourceKeyspace: "sourceks",
+		TargetKeyspace: "targetks",
+		IncludeTables:  []string{"t1"},
+		AutoStart:      true,
+	})
+	require.NoError(t, err)
+	env.tmc.verifyQueries(t)
+	return env
+}
+
+func TestWorkflowStatus(t *testing.T) {
+	ctx := context.Background()
+	env := newMoveTablesEnv(t)
+	defer env.close()
+
+	bls := moveTablesStream("t1")
+	env.expectBuildTargets(200, 1, bls, "")
+	// s.getCopyProgress
+	env.tmc.expectVRQuery(200, "select distinct table_name from _vt.copy_state cs, _vt.vreplication vr where vr.id = cs.vrepl_id and vr.id = 1",
+		sqltypes.MakeTestResult(sqltypes.MakeTestFields("table_name", "varchar"), "t1"))
+	tableMetricsFields := sqltypes.MakeTestFields("table_name|table_rows|data_length", "varchar|int64|int64")
+	env.tmc.expectVRQuery(200, "select table_name, table_rows, data_length from information_schema.tables where table_schema = 'vt_targetks' and table_name in ('t1')",
+		sqltypes.MakeTestResult(tableMetricsFields, "t1|10|1000"))
+	env.tmc.expectVRQuery(100, "select table_name, table_rows, data_length from information_schema.tables where table_schema = 'vt_sourceks' and table_name in ('t1')",
+		sqltypes.MakeTestResult(tableMetricsFields, "t1|40|4000"))
+	env.expectGetWorkflows(200, 1, bls, "Running", "t1")
+
+	resp, err := env.ws.WorkflowStatus(ctx, &vtctldatapb.WorkflowStatusRequest{
+		Keyspace: "targetks",
+		Workflow: "workflow",
+	})
+	require.NoError(t, err)
+	env.tmc.verifyQueries(t)
+
+	require.Equal(t, "Reads Not Switched. Writes Not Switched", resp.TrafficState)
+	utils.MustMatch(t, map[string]*vtctldatapb.WorkflowStatusResponse_TableCopyState{
+		"t1": {
+			RowsCopied:      10,
+			RowsTotal:       40,
+			RowsPercentage:  25,
+			BytesCopied:     1000,
+			BytesTotal:      4000,
+			BytesPercentage: 25,
+		},
+	}, resp.TableCopyState)
+	require.Len(t, resp.ShardStreams, 1)
+	for _, shardStreams := range resp.ShardStreams {
+		require.Len(t, shardStreams.Streams, 1)
+		assert.Equal(t, "Copying", shardStreams.Streams[0].Status)
+		assert.Equal(t, "sourceks/0", shardStreams.Streams[0].SourceShard)
+	}
+
+	// A workflow without any streams does not exist.
+	env.tmc.expectVRQuery(200, "select id, source, message, cell, tablet_types, workflow_type, workflow_sub_type, defer_secondary_keys from _vt.vreplication where workflow='nope' and db_name='vt_targetks'", &sqltypes.Result{})
+	_, err = env.ws.WorkflowStatus(ctx, &vtctldatapb.WorkflowStatusRequest{
+		Keyspace: "targetks",
+		Workflow: "nope",
+	})
+	require.Error(t, err)
+	require.Equal(t, vtrpcpb.Code_NOT_FOUND, vterrors.Code(err))
+	env.tmc.verifyQueries(t)
+}
+
+func TestWorkflowSwitchTrafficReads(t *testing.T) {
+	ctx := context.Background()
+	bls := moveTablesStream("t1")
+	req := &vtctldatapb.WorkflowSwitchTrafficRequest{
+		Keyspace:    "targetks",
+		Workflow:    "workflow",
+		TabletTypes: []topodatapb.TabletType{topodatapb.TabletType_REPLICA, topodatapb.TabletType_RDONLY},
+		Direction:   int32(DirectionForward),
+	}
+	routingRules := func(env *testMaterializerEnv) map[string][]string {
+		rules, err := topotools.GetRoutingRules(ctx, env.topoServ)
+		require.NoError(t, err)
+		return rules
+	}
+
+	t.Run("switch", func(t *testing.T) {
+		env := newMoveTablesEnv(t)
+		defer env.close()
+
+		env.expectBuildTargets(200, 1, bls, "")
+		// s.canSwitch
+		env.expectGetWorkflows(200, 1, bls, "Running")
+		// ts.checkJournals
+		env.tmc.expectVRQuery(100, mzCheckJournal, &sqltypes.Result{})
+		// Reload of the state after the switch.
+		env.expectBuildTargets(200, 1, bls, "")
+
+		resp, err := env.ws.WorkflowSwitchTraffic(ctx, req)
+		require.NoError(t, err)
+		env.tmc.verifyQueries(t)
+
+		require.Equal(t, "Reads Not Switched. Writes Not Switched", resp.StartState)
+		require.Equal(t, "All Reads Switched. Writes Not Switched", resp.CurrentState)
+		require.Empty(t, resp.DryRunResults)
+		rules := routingRules(env)
+		for _, from := range []string{"t1@replica", "targetks.t1@replica", "sourceks.t1@replica", "t1@rdonly", "targetks.t1@rdonly", "sourceks.t1@rdonly"} {
+			require.Equal(t, []string{"targetks.t1"}, rules[from], from)
+		}
+		require.Equal(t, []string{"sourceks.t1"}, rules["t1"])
+	})
+
+	t.Run("dry run", func(t *testing.T) {
+		env := newMoveTablesEnv(t)
+		defer env.close()
+		before := routingRules(env)
+
+		env.expectBuildTargets(200, 1, bls, "")
+		env.expectGetWorkflows(200, 1, bls, "Running")
+		env.tmc.expectVRQuery(100, mzCheckJournal, &sqltypes.Result{})
+
+		dryRunReq := proto.Clone(req).(*vtctldatapb.WorkflowSwitchTrafficRequest)
+		dryRunReq.DryRun = true
+		resp, err := env.ws.WorkflowSwitchTraffic(ctx, dryRunReq)
+		require.NoError(t, err)
+		env.tmc.verifyQueries(t)
+
+		require.NotEmpty(t, resp.DryRunResults)
+		require.Equal(t, before, routingRules(env))
+	})
+
+	t.Run("copy in progress", func(t *testing.T) {
+		env := newMoveTablesEnv(t)
+		defer env.close()
+
+		env.expectBuildTargets(200, 1, bls, "")
+		env.expectGetWorkflows(200, 1, bls, "Running", "t1")
+
+		_, err := env.ws.WorkflowSwitchTraffic(ctx, req)
+		require.Error(t, err)
+		require.Equal(t, vtrpcpb.Code_FAILED_PRECONDITION, vterrors.Code(err))
+		require.Contains(t, err.Error(), cannotSwitchCopyIncomplete)
+		env.tmc.verifyQueries(t)
+	})
+}
+
+func TestWorkflowDelete(t *testing.T) {
+	ctx := context.Background()
+	env := newMoveTablesEnv(t)
+	defer env.close()
+
+	bls := moveTablesStream("t1")
+	env.expectBuildTargets(200, 1, bls, "")
+	// ts.removeTargetTables
+	env.tmc.expectVRQuery(200, "drop table `vt_targetks`.`t1`", &sqltypes.Result{})
+	// ts.dropSourceReverseVReplicationStreams
+	env.tmc.expectVRQuery(100, "delete from _vt.vreplication where db_name = 'vt_sourceks' and workflow = 'workflow_reverse'", &sqltypes.Result{})
+	env.tmc.expectVRQuery(100, "/delete from vd, vdt, vdl using _vt.vdiff as vd", &sqltypes.Result{})
+	// ts.dropTargetVReplicationStreams
+	env.tmc.expectVRQuery(200, "delete from _vt.vreplication where db_name = 'vt_targetks' and workflow = 'workflow'", &sqltypes.Result{})
+	env.tmc.expectVRQuery(200, "/delete from vd, vdt, vdl using _vt.vdiff as vd", &sqltypes.Result{})
+
+	_, err := env.ws.WorkflowDelete(ctx, &vtctldatapb.WorkflowDeleteRequest{
+		Keyspace: "targetks",
+		Workflow: "workflow",
+	})
+	require.NoError(t, err)
+	env.tmc.verifyQueries(t)
+
+	rules, err := topotools.GetRoutingRules(ctx, env.topoServ)
+	require.NoError(t, err)
+	require.Empty(t, rules)
+	vschema, err := env.topoServ.GetVSchema(ctx, "targetks")
+	require.NoError(t, err)
+	require.NotContains(t, vschema.Tables, "t1")
+}
+
+func TestWorkflowComplete(t *testing.T) {
+	ctx := context.Background()
+	env := newMoveTablesEnv(t)
+	defer env.close()
+
+	// Nothing has been switched yet, so the workflow cannot be completed.
+	env.expectBuildTargets(200, 1, moveTablesStream("t1"), "")
+	_, err := env.ws.WorkflowComplete(ctx, &vtctldatapb.WorkflowCompleteRequest{
+		Keyspace: "targetks",
+		Workflow: "workflow",
+	})
+	require.Error(t, err)
+	require.Equal(t, vtrpcpb.Code_FAILED_PRECONDITION, vterrors.Code(err))
+	require.Contains(t, err.Error(), ErrWorkflowNotFullySwitched)
+	env.tmc.verifyQueries(t)
+}
+
+func TestVDiff(t *testing.T) {
+	ctx := context.Background()
+	env := newMoveTablesEnv(t)
+	defer env.close()
+
+	const vdiffUUID = "0e7ffbfd-7f4b-4e8c-a6d6-2a1bb6d3f0a7"
+	bls := moveTablesStream("t1")
+	env.tmc.vdiffResponse = &tabletmanagerdatapb.VDiffResponse{Id: 1, VdiffUuid: vdiffUUID}
+
+	env.expectBuildTargets(200, 1, bls, "")
+	createResp, err := env.ws.VDiffCreate(ctx, &vtctldatapb.VDiffCreateRequest{
+		Workflow:       "workflow",
+		TargetKeyspace: "targetks",
+		Uuid:           vdiffUUID,
+	})
+	require.NoError(t, err)
+	require.Equal(t, vdiffUUID, createResp.UUID)
+
+	env.expectBuildTargets(200, 1, bls, "")
+	showResp, err := env.ws.VDiffShow(ctx, &vtctldatapb.VDiffShowRequest{
+		Workflow:       "workflow",
+		TargetKeyspace: "targetks",
+		Arg:            "last",
+	})
+	require.NoError(t, err)
+	utils.MustMatch(t, map[string]*tabletmanagerdatapb.VDiffResponse{
+		"cell-0000000200": env.tmc.vdiffResponse,
+	}, showResp.TabletResponses)
+
+	env.expectBuildTargets(200, 1, bls, "")
+	_, err = env.ws.VDiffStop(ctx, &vtctldatapb.VDiffStopRequest{
+		Workflow:       "workflow",
+		TargetKeyspace: "targetks",
+		Uuid:           vdiffUUID,
+	})
+	require.NoError(t, err)
+
+	env.expectBuildTargets(200, 1, bls, "")
+	_, err = env.ws.VDiffResume(ctx, &vtctldatapb.VDiffResumeRequest{
+		Workflow:       "workflow",
+		TargetKeyspace: "targetks",
+		Uuid:           vdiffUUID,
+	})
+	require.NoError(t, err)
+
+	env.expectBuildTargets(200, 1, bls, "")
+	_, err = env.ws.VDiffDelete(ctx, &vtctldatapb.VDiffDeleteRequest{
+		Workflow:       "workflow",
+		TargetKeyspace: "targetks",
+		Arg:            "all",
+	})
+	require.NoError(t, err)
+	env.tmc.verifyQueries(t)
+
+	requests := env.tmc.vdiffRequests[200]
+	require.Len(t, requests, 5)
+	for i, want := range []struct {
+		action, arg, uuid string
+	}{
+		{"create", "", vdiffUUID},
+		{"show", "last", ""},
+		{"stop", "", vdiffUUID},
+		{"resume", "", vdiffUUID},
+		{"delete", "all", ""},
+	} {
+		assert.Equal(t, "targetks", requests[i].Keyspace)
+		assert.Equal(t, "workflow", requests[i].Workflow)
+		assert.Equal(t, want.action, requests[i].Action)
+		assert.Equal(t, want.arg, requests[i].ActionArg)
+		assert.Equal(t, want.uuid, requests[i].VdiffUuid)
+	}
+
+	// The UUID is validated before anything is sent to the tablets.
+	_, err = env.ws.VDiffCreate(ctx, &vtctldatapb.VDiffCreateRequest{
+		Workflow:       "workflow",
+		TargetKeyspace: "targetks",
+		Uuid:           "not-a-uuid",
+	})
+	require.ErrorContains(t, err, "invalid VDiff UUID")
+
+	// There is nothing left to diff once writes have been switched.
+	env.expectBuildTargets(200, 1, bls, Frozen)
+	_, err = env.ws.VDiffCreate(ctx, &vtctldatapb.VDiffCreateRequest{
+		Workflow:       "workflow",
+		TargetKeyspace: "targetks",
+	})
+	require.Error(t, err)
+	require.Equal(t, vtrpcpb.Code_FAILED_PRECONDITION, vterrors.Code(err))
+	require.Len(t, env.tmc.vdiffRequests[200], 5)
+	env.tmc.verifyQueries(t)
+}
@@ -16,6 +16,11 @@ limitations under the License.
 
 package workflow
 
+import (
+	"fmt"
+	"strings"
+)
+
 // Type is the type of a workflow.
 type Type string
 
@@ -45,3 +50,57 @@ type State struct {
 	ShardsAlreadySwitched []string
 	ShardsNotYetSwitched  []string
 }
+
+// String returns a human readable summary of the traffic switching state of
+// the workflow.
+func (s *State) String() string {
+	var stateInfo []string
+	if !s.IsPartialMigration { // shard level traffic switching is all or nothing
+		switch {
+		case len(s.RdonlyCellsNotSwitched) == 0 && len(s.ReplicaCellsNotSwitched) == 0 && len(s.ReplicaCellsSwitched) > 0:
+			stateInfo = append(stateInfo, "All Reads Switched")
+		case len(s.RdonlyCellsSwitched) == 0 && len(s.ReplicaCellsSwitched) == 0:
+			stateInfo = append(stateInfo, "Reads Not Switched")
+		default:
+			stateInfo = append(stateInfo, "Reads partially switched")
+			switch {
+			case len(s.ReplicaCellsNotSwitched) == 0:
+				stateInfo = append(stateInfo, "All Replica Reads Switched")
+			case len(s.ReplicaCellsSwitched) == 0:
+				stateInfo = append(stateInfo, "Replica not switched")
+			default:
+				stateInfo = append(stateInfo, "Replica switched in cells: "+strings.Join(s.ReplicaCellsSwitched, ","))
+			}
+			switch {
+			case len(s.RdonlyCellsNotSwitched) == 0:
+				stateInfo = append(stateInfo, "All Rdonly Reads Switched")
+			case len(s.RdonlyCellsSwitched) == 0:
+				stateInfo = append(stateInfo, "Rdonly not switched")
+			default:
+				stateInfo = append(stateInfo, "Rdonly switched in cells: "+strings.Join(s.RdonlyCellsSwitched, ","))
+			}
+		}
+	}
+	switch {
+	case s.WritesSwitched:
+		stateInfo = append(stateInfo, "Writes Switched")
+	case s.IsPartialMigration:
+		// For partial migrations, the traffic switching is all or nothing
+		// at the shard level, so reads are effectively switched on the
+		// shard when writes are switched.
+		switch {
+		case len(s.ShardsAlreadySwitched) > 0 && len(s.ShardsNotYetSwitched) > 0:
+			stateInfo = append(stateInfo, fmt.Sprintf("Reads partially switched, for shards: %s", strings.Join(s.ShardsAlreadySwitched, ",")))
+			stateInfo = append(stateInfo, fmt.Sprintf("Writes partially switched, for shards: %s", strings.Join(s.ShardsAlreadySwitched, ",")))
+		case len(s.ShardsAlreadySwitched) == 0:
+			stateInfo = append(stateInfo, "Reads Not Switched")
+			stateInfo = append(stateInfo, "Writes Not Switched")
+		default:
+			stateInfo = append(stateInfo, "All Reads Switched")
+			stateInfo = append(stateInfo, "All Writes Switched")
+		}
+	default:
+		stateInfo = append(stateInfo, "Writes Not Switched")
+	}
+	return strings.Join(stateInfo, ". ")
+}
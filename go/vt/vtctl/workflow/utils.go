@@ -0,0 +1,276 @@
+/*
+Copyright 2023 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workflow
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"vitess.io/vitess/go/mysql"
+	"vitess.io/vitess/go/vt/concurrency"
+	"vitess.io/vitess/go/vt/log"
+	"vitess.io/vitess/go/vt/topo"
+	"vitess.io/vitess/go/vt/topo/topoproto"
+	"vitess.io/vitess/go/vt/vterrors"
+
+	binlogdatapb "vitess.io/vitess/go/vt/proto/binlogdata"
+	querypb "vitess.io/vitess/go/vt/proto/query"
+	tabletmanagerdatapb "vitess.io/vitess/go/vt/proto/tabletmanagerdata"
+	topodatapb "vitess.io/vitess/go/vt/proto/topodata"
+)
+
+// validateNewWorkflow ensures that the specified workflow doesn't already exist
+// in the keyspace.
+func (s *Server) validateNewWorkflow(ctx context.Context, keyspace, workflow string) error {
+	allshards, err := s.ts.FindAllShardsInKeyspace(ctx, keyspace)
+	if err != nil {
+		return err
+	}
+	var wg sync.WaitGroup
+	allErrors := &concurrency.AllErrorRecorder{}
+	for _, si := range allshards {
+		if si.PrimaryAlias == nil {
+			allErrors.RecordError(fmt.Errorf("shard has no primary: %v", si.ShardName()))
+			continue
+		}
+		wg.Add(1)
+		go func(si *topo.ShardInfo) {
+			defer wg.Done()
+
+			primary, err := s.ts.GetTablet(ctx, si.PrimaryAlias)
+			if err != nil {
+				allErrors.RecordError(vterrors.Wrap(err, "validateWorkflowName.GetTablet"))
+				return
+			}
+			validations := []struct {
+				query string
+				msg   string
+			}{{
+				fmt.Sprintf("select 1 from _vt.vreplication where db_name=%s and workflow=%s", encodeString(primary.DbName()), encodeString(workflow)),
+				fmt.Sprintf("workflow %s already exists in keyspace %s on tablet %d", workflow, keyspace, primary.Alias.Uid),
+			}, {
+				fmt.Sprintf("select 1 from _vt.vreplication where db_name=%s and message='FROZEN' and workflow_sub_type != %d", encodeString(primary.DbName()), binlogdatapb.VReplicationWorkflowSubType_Partial),
+				fmt.Sprintf("found previous frozen workflow on tablet %d, please review and delete it first before creating a new workflow",
+					primary.Alias.Uid),
+			}}
+			for _, validation := range validations {
+				p3qr, err := s.tmc.VReplicationExec(ctx, primary.Tablet, validation.query)
+				if err != nil {
+					allErrors.RecordError(vterrors.Wrap(err, "validateWorkflowName.VReplicationExec"))
+					return
+				}
+				if p3qr != nil && len(p3qr.Rows) != 0 {
+					allErrors.RecordError(vterrors.Wrap(fmt.Errorf(validation.msg), "validateWorkflowName.VReplicationExec"))
+					return
+				}
+			}
+		}(si)
+	}
+	wg.Wait()
+	return allErrors.AggrError(vterrors.Aggregate)
+}
+
+// executeFetchAsDba executes a query on the given tablet as the DBA user,
+// using a non-pooled connection.
+func (s *Server) executeFetchAsDba(ctx context.Context, tabletAlias *topodatapb.TabletAlias, query string, maxRows int, disableBinlogs bool, reloadSchema bool) (*querypb.QueryResult, error) {
+	ti, err := s.ts.GetTablet(ctx, tabletAlias)
+	if err != nil {
+		return nil, err
+	}
+	return s.tmc.ExecuteFetchAsDba(ctx, ti.Tablet, false, &tabletmanagerdatapb.ExecuteFetchAsDbaRequest{
+		Query:          []byte(query),
+		MaxRows:        uint64(maxRows),
+		DisableBinlogs: disableBinlogs,
+		ReloadSchema:   reloadSchema,
+	})
+}
+
+// refreshPrimaryTablets will just RPC-ping all the primary tablets with RefreshState.
+func (s *Server) refreshPrimaryTablets(ctx context.Context, shards []*topo.ShardInfo) error {
+	wg := sync.WaitGroup{}
+	rec := concurrency.AllErrorRecorder{}
+	for _, si := range shards {
+		wg.Add(1)
+		go func(si *topo.ShardInfo) {
+			defer wg.Done()
+			log.Infof("RefreshState primary %v", topoproto.TabletAliasString(si.PrimaryAlias))
+			ti, err := s.ts.GetTablet(ctx, si.PrimaryAlias)
+			if err != nil {
+				rec.RecordError(err)
+				return
+			}
+
+			if err := s.tmc.RefreshState(ctx, ti.Tablet); err != nil {
+				rec.RecordError(err)
+			} else {
+				log.Infof("%v responded", topoproto.TabletAliasString(si.PrimaryAlias))
+			}
+		}(si)
+	}
+	wg.Wait()
+	return rec.Error()
+}
+
+// deleteShard deletes a shard from the topo, along with the tablet records
+// and the replication graph of the shard if recursive is set.
+func (s *Server) deleteShard(ctx context.Context, keyspace, shard string, recursive, evenIfServing bool) error {
+	// Read the Shard object. If it's not there, try to clean up
+	// the topology anyway.
+	shardInfo, err := s.ts.GetShard(ctx, keyspace, shard)
+	if err != nil {
+		if topo.IsErrType(err, topo.NoNode) {
+			log.Infof("Shard %v/%v doesn't seem to exist, cleaning up any potential leftover", keyspace, shard)
+			return s.ts.DeleteShard(ctx, keyspace, shard)
+		}
+		return err
+	}
+
+	servingCells, err := s.ts.GetShardServingCells(ctx, shardInfo)
+	if err != nil {
+		return err
+	}
+	// Check the Serving map for the shard, we don't want to
+	// remove a serving shard if not absolutely sure.
+	if !evenIfServing && len(servingCells) > 0 {
+		return fmt.Errorf("shard %v/%v is still serving, cannot delete it, use the even-if-serving flag if needed", keyspace, shard)
+	}
+
+	cells, err := s.ts.GetCellInfoNames(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, cell := range cells {
+		var aliases []*topodatapb.TabletAlias
+
+		// Get the ShardReplication object for that cell. Try
+		// to find all tablets that may belong to our shard.
+		sri, err := s.ts.GetShardReplication(ctx, cell, keyspace, shard)
+		switch {
+		case topo.IsErrType(err, topo.NoNode):
+			// No ShardReplication object, so the topo is inconsistent.
+			// Read all the tablets of the cell instead.
+			aliases, err = s.ts.GetTabletAliasesByCell(ctx, cell)
+			if err != nil {
+				return fmt.Errorf("GetTabletsByCell(%v) failed: %v", cell, err)
+			}
+		case err == nil:
+			aliases = make([]*topodatapb.TabletAlias, len(sri.Nodes))
+			for i, n := range sri.Nodes {
+				aliases[i] = n.TabletAlias
+			}
+		default:
+			return fmt.Errorf("GetShardReplication(%v, %v, %v) failed: %v", cell, keyspace, shard, err)
+		}
+
+		// GetTabletMap ignores ErrNoNode, which is what we want: it means
+		// a tablet was deleted but is still referenced.
+		tabletMap, err := s.ts.GetTabletMap(ctx, aliases)
+		if err != nil {
+			return fmt.Errorf("GetTabletMap() failed: %v", err)
+		}
+
+		// Remove the tablets that don't belong to our keyspace/shard.
+		for a, ti := range tabletMap {
+			if ti.Keyspace != keyspace || ti.Shard != shard {
+				delete(tabletMap, a)
+			}
+		}
+
+		if len(tabletMap) > 0 {
+			if !recursive {
+				return fmt.Errorf("shard %v/%v still has %v tablets in cell %v; use recursive or remove them manually", keyspace, shard, len(tabletMap), cell)
+			}
+
+			log.Infof("Deleting all tablets in shard %v/%v cell %v", keyspace, shard, cell)
+			for tabletAlias, tabletInfo := range tabletMap {
+				// We don't care about updating the replication graph, as
+				// we're about to delete the entire replication graph.
+				log.Infof("Deleting tablet %v", tabletAlias)
+				if err := s.ts.DeleteTablet(ctx, tabletInfo.Alias); err != nil && !topo.IsErrType(err, topo.NoNode) {
+					// Don't continue on failures other than a missing tablet,
+					// or the tablet record would be orphaned once the
+					// replication graph is gone.
+					return fmt.Errorf("can't delete tablet %v: %v", tabletAlias, err)
+				}
+			}
+		}
+	}
+
+	// Try to remove the replication graph and serving graph in each cell,
+	// regardless of its existence.
+	for _, cell := range cells {
+		if err := s.ts.DeleteShardReplication(ctx, cell, keyspace, shard); err != nil && !topo.IsErrType(err, topo.NoNode) {
+			log.Warningf("Cannot delete ShardReplication in cell %v for %v/%v: %v", cell, keyspace, shard, err)
+		}
+	}
+
+	return s.ts.DeleteShard(ctx, keyspace, shard)
+}
+
+// deleteWorkflowVDiffData cleans up any potential VDiff related data associated
+// with the workflow on the given tablet.
+func (s *Server) deleteWorkflowVDiffData(ctx context.Context, tablet *topodatapb.Tablet, workflow string) {
+	sqlDeleteVDiffs := `delete from vd, vdt, vdl using _vt.vdiff as vd inner join _vt.vdiff_table as vdt on (vd.id = vdt.vdiff_id)
+						inner join _vt.vdiff_log as vdl on (vd.id = vdl.vdiff_id)
+						where vd.keyspace = %s and vd.workflow = %s`
+	query := fmt.Sprintf(sqlDeleteVDiffs, encodeString(tablet.Keyspace), encodeString(workflow))
+	rows := -1
+	if _, err := s.tmc.ExecuteFetchAsDba(ctx, tablet, false, &tabletmanagerdatapb.ExecuteFetchAsDbaRequest{
+		Query:   []byte(query),
+		MaxRows: uint64(rows),
+	}); err != nil {
+		if sqlErr, ok := err.(*mysql.SQLError); ok && sqlErr.Num != mysql.ERNoSuchTable { // the tables may not exist if no vdiffs have been run
+			log.Errorf("Error deleting vdiff data for %s.%s workflow: %v", tablet.Keyspace, workflow, err)
+		}
+	}
+}
+
+// optimizeCopyStateTable rebuilds the copy_state table to ensure the on-disk
+// structures are minimal and optimized and resets the auto-inc value for
+// subsequent inserts.
+// It's not critical that this executes successfully, so the work is done
+// asynchronously in the background on the given tablet and any failures are
+// logged as warnings. The AllPrivs account is used to be sure that we don't
+// execute the writes if READ_ONLY is set on the MySQL instance.
+func (s *Server) optimizeCopyStateTable(tablet *topodatapb.Tablet) {
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Minute)
+		defer cancel()
+		sqlOptimizeTable := "optimize table _vt.copy_state"
+		if _, err := s.tmc.ExecuteFetchAsAllPrivs(ctx, tablet, &tabletmanagerdatapb.ExecuteFetchAsAllPrivsRequest{
+			Query:   []byte(sqlOptimizeTable),
+			MaxRows: uint64(100), // always produces 1+rows with notes and status
+		}); err != nil {
+			if sqlErr, ok := err.(*mysql.SQLError); ok && sqlErr.Num == mysql.ERNoSuchTable { // the table may not exist
+				return
+			}
+			log.Warningf("Failed to optimize the copy_state table on %q: %v", tablet.Alias.String(), err)
+		}
+		// This will automatically set the value to 1 or the current max value in the table, whichever is greater
+		sqlResetAutoInc := "alter table _vt.copy_state auto_increment = 1"
+		if _, err := s.tmc.ExecuteFetchAsAllPrivs(ctx, tablet, &tabletmanagerdatapb.ExecuteFetchAsAllPrivsRequest{
+			Query:   []byte(sqlResetAutoInc),
+			MaxRows: uint64(0),
+		}); err != nil {
+			log.Warningf("Failed to reset the auto_increment value for the copy_state table on %q: %v",
+				tablet.Alias.String(), err)
+		}
+	}()
+}
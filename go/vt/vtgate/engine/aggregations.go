@@ -18,6 +18,7 @@ package engine
 
 import (
 	"fmt"
+	"math"
 	"strconv"
 
 	"vitess.io/vitess/go/vt/vterrors"
@@ -25,6 +26,7 @@ import (
 	"google.golang.org/protobuf/proto"
 
 	"vitess.io/vitess/go/mysql/collations"
+	"vitess.io/vitess/go/mysql/json"
 	"vitess.io/vitess/go/slices2"
 	"vitess.io/vitess/go/sqltypes"
 	binlogdatapb "vitess.io/vitess/go/vt/proto/binlogdata"
@@ -45,6 +47,11 @@ type AggregateParams struct {
 	WCol        int
 	CollationID collations.ID
 
+	// These are used only for statistical opcodes, where Col holds
+	// the count of values and these hold their sum and population variance.
+	SumCol int
+	VarCol int
+
 	Alias    string `json:",omitempty"`
 	Expr     sqlparser.Expr
 	Original *sqlparser.AliasedExpr
@@ -76,6 +83,9 @@ func (ap *AggregateParams) String() string {
 	if ap.WAssigned() {
 		keyCol = fmt.Sprintf("%s|%d", keyCol, ap.WCol)
 	}
+	if ap.Opcode.IsStatistical() {
+		keyCol = fmt.Sprintf("%s|%d|%d", keyCol, ap.SumCol, ap.VarCol)
+	}
 	if ap.CollationID != collations.Unknown {
 		keyCol += " COLLATE " + ap.CollationID.Get().Name()
 	}
@@ -232,6 +242,12 @@ func merge(
 			result[aggr.Col] = val
 		case AggregateAnyValue:
 			// we just grab the first value per grouping. no need to do anything more complicated here
+		case AggregateStddevPop, AggregateStddevSamp, AggregateVarPop, AggregateVarSamp:
+			err = mergeStatistic(aggr, fields, row1, row2, result)
+		case AggregateBitAnd, AggregateBitOr, AggregateBitXor:
+			result[aggr.Col], err = mergeBits(aggr.Opcode, row1[aggr.Col], row2[aggr.Col])
+		case AggregateJSONArrayAgg, AggregateJSONObjectAgg:
+			result[aggr.Col], err = mergeJSON(aggr.Opcode, row1[aggr.Col], row2[aggr.Col])
 		case AggregateGroupConcat:
 			if row2[aggr.Col].IsNull() {
 				break
@@ -271,11 +287,173 @@ func convertFinal(current []sqltypes.Value, aggregates []*AggregateParams) ([]sq
 				return nil, err
 			}
 			result[aggr.Col] = sqltypes.NewVarChar(vgtid.String())
+		case AggregateStddevPop, AggregateStddevSamp, AggregateVarPop, AggregateVarSamp:
+			var err error
+			result[aggr.Col], err = finalStatistic(aggr, current)
+			if err != nil {
+				return nil, err
+			}
 		}
 	}
 	return result, nil
 }
 
+func mergeBits(opcode AggregateOpcode, v1, v2 sqltypes.Value) (sqltypes.Value, error) {
+	if v1.IsNull() {
+		return v2, nil
+	}
+	if v2.IsNull() {
+		return v1, nil
+	}
+	b1, err := evalengine.ToUint64(v1)
+	if err != nil {
+		return sqltypes.NULL, err
+	}
+	b2, err := evalengine.ToUint64(v2)
+	if err != nil {
+		return sqltypes.NULL, err
+	}
+	switch opcode {
+	case AggregateBitAnd:
+		return sqltypes.NewUint64(b1 & b2), nil
+	case AggregateBitOr:
+		return sqltypes.NewUint64(b1 | b2), nil
+	default:
+		return sqltypes.NewUint64(b1 ^ b2), nil
+	}
+}
+
+// mergeJSON merges the JSON arrays or objects aggregated by two shards.
+// Arrays are concatenated, while objects are merged with the values
+// of the second object winning for duplicate keys, which is the same
+// as what MySQL does when it sees duplicate keys in JSON_OBJECTAGG.
+func mergeJSON(opcode AggregateOpcode, v1, v2 sqltypes.Value) (sqltypes.Value, error) {
+	if v1.IsNull() {
+		return v2, nil
+	}
+	if v2.IsNull() {
+		return v1, nil
+	}
+	var p1, p2 json.Parser
+	doc1, err := p1.ParseBytes(v1.Raw())
+	if err != nil {
+		return sqltypes.NULL, err
+	}
+	doc2, err := p2.ParseBytes(v2.Raw())
+	if err != nil {
+		return sqltypes.NULL, err
+	}
+
+	var merged *json.Value
+	if opcode == AggregateJSONArrayAgg {
+		ary1, ok1 := doc1.Array()
+		ary2, ok2 := doc2.Array()
+		if !ok1 || !ok2 {
+			return sqltypes.NULL, vterrors.VT13001("JSON_ARRAYAGG did not return an array")
+		}
+		merged = json.NewArray(append(append([]*json.Value{}, ary1...), ary2...))
+	} else {
+		obj1, ok1 := doc1.Object()
+		obj2, ok2 := doc2.Object()
+		if !ok1 || !ok2 {
+			return sqltypes.NULL, vterrors.VT13001("JSON_OBJECTAGG did not return an object")
+		}
+		obj2.Visit(func(key string, val *json.Value) {
+			obj1.Set(key, val, json.Set)
+		})
+		merged = doc1
+	}
+	return sqltypes.MakeTrusted(sqltypes.TypeJSON, merged.MarshalTo(nil)), nil
+}
+
+// statisticParts returns the count, mean and population variance of the
+// values in the given row, as returned by a shard or merged so far.
+func statisticParts(aggr *AggregateParams, row []sqltypes.Value) (n, mean, variance float64, err error) {
+	if row[aggr.Col].IsNull() || row[aggr.SumCol].IsNull() {
+		return 0, 0, 0, nil
+	}
+	count, err := evalengine.ToInt64(row[aggr.Col])
+	if err != nil || count == 0 {
+		return 0, 0, 0, err
+	}
+	sum, err := evalengine.ToFloat64(row[aggr.SumCol])
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	mean = sum / float64(count)
+	if !row[aggr.VarCol].IsNull() {
+		variance, err = evalengine.ToFloat64(row[aggr.VarCol])
+		if err != nil {
+			return 0, 0, 0, err
+		}
+	}
+	return float64(count), mean, variance, nil
+}
+
+// mergeStatistic merges the count, sum and population variance of two rows
+// into result. The sums are added exactly, and the variances are merged
+// with the means of the rows, using the parallel algorithm of Chan et al.:
+//
+//	M2 = M2_1 + M2_2 + n_1(mean_1 - mean)^2 + n_2(mean_2 - mean)^2
+//
+// where M2_i = n_i * var_pop_i. Unlike merging sums of squares, this doesn't
+// lose all precision when the variance is small compared to the mean.
+func mergeStatistic(aggr *AggregateParams, fields []*querypb.Field, row1, row2, result []sqltypes.Value) error {
+	n1, mean1, var1, err := statisticParts(aggr, row1)
+	if err != nil {
+		return err
+	}
+	n2, mean2, var2, err := statisticParts(aggr, row2)
+	if err != nil {
+		return err
+	}
+	switch {
+	case n2 == 0:
+		return nil
+	case n1 == 0:
+		result[aggr.Col], result[aggr.SumCol], result[aggr.VarCol] = row2[aggr.Col], row2[aggr.SumCol], row2[aggr.VarCol]
+		return nil
+	}
+
+	sum, err := evalengine.NullSafeAdd(row1[aggr.SumCol], row2[aggr.SumCol], fields[aggr.SumCol].Type)
+	if err != nil {
+		return err
+	}
+
+	n := n1 + n2
+	mean := mean1 + (mean2-mean1)*n2/n
+	d1, d2 := mean1-mean, mean2-mean
+	m2 := var1*n1 + var2*n2 + n1*d1*d1 + n2*d2*d2
+	// rounding errors can make the variance of (nearly) constant values slightly negative
+	if m2 < 0 {
+		m2 = 0
+	}
+	result[aggr.Col] = sqltypes.NewInt64(int64(n))
+	result[aggr.SumCol] = sum
+	result[aggr.VarCol] = sqltypes.NewFloat64(m2 / n)
+	return nil
+}
+
+// finalStatistic computes the standard deviation or variance from the count
+// and population variance of the values, which have been merged across shards.
+func finalStatistic(aggr *AggregateParams, row []sqltypes.Value) (sqltypes.Value, error) {
+	n, _, variance, err := statisticParts(aggr, row)
+	if err != nil {
+		return sqltypes.NULL, err
+	}
+	samp := aggr.Opcode == AggregateStddevSamp || aggr.Opcode == AggregateVarSamp
+	if n == 0 || samp && n == 1 {
+		return sqltypes.NULL, nil
+	}
+	if samp {
+		variance = variance * n / (n - 1)
+	}
+	if aggr.Opcode == AggregateStddevPop || aggr.Opcode == AggregateStddevSamp {
+		return sqltypes.NewFloat64(math.Sqrt(variance)), nil
+	}
+	return sqltypes.NewFloat64(variance), nil
+}
+
 func convertFields(fields []*querypb.Field, aggrs []*AggregateParams) []*querypb.Field {
 	fields = slices2.Map(fields, func(from *querypb.Field) *querypb.Field {
 		return proto.Clone(from).(*querypb.Field)
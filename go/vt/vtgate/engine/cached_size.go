@@ -35,7 +35,7 @@ func (cached *AggregateParams) CachedSize(alloc bool) int64 {
 	}
 	size := int64(0)
 	if alloc {
-		size += int64(96)
+		size += int64(112)
 	}
 	// field Alias string
 	size += hack.RuntimeAllocSize(int64(len(cached.Alias)))
@@ -166,6 +166,36 @@ func (cached *DML) CachedSize(alloc bool) int64 {
 	size += cached.RoutingParameters.CachedSize(true)
 	return size
 }
+func (cached *DMLWithInput) CachedSize(alloc bool) int64 {
+	if cached == nil {
+		return int64(0)
+	}
+	size := int64(0)
+	if alloc {
+		size += int64(112)
+	}
+	// field Input vitess.io/vitess/go/vt/vtgate/engine.Primitive
+	if cc, ok := cached.Input.(cachedObject); ok {
+		size += cc.CachedSize(true)
+	}
+	// field DML vitess.io/vitess/go/vt/vtgate/engine.Primitive
+	if cc, ok := cached.DML.(cachedObject); ok {
+		size += cc.CachedSize(true)
+	}
+	// field Keyspace *vitess.io/vitess/go/vt/vtgate/vindexes.Keyspace
+	size += cached.Keyspace.CachedSize(true)
+	// field Vindex vitess.io/vitess/go/vt/vtgate/vindexes.SingleColumn
+	if cc, ok := cached.Vindex.(cachedObject); ok {
+		size += cc.CachedSize(true)
+	}
+	// field ListVar string
+	size += hack.RuntimeAllocSize(int64(len(cached.ListVar)))
+	// field LimitVar string
+	size += hack.RuntimeAllocSize(int64(len(cached.LimitVar)))
+	// field KeyVar string
+	size += hack.RuntimeAllocSize(int64(len(cached.KeyVar)))
+	return size
+}
 func (cached *Delete) CachedSize(alloc bool) int64 {
 	if cached == nil {
 		return int64(0)
@@ -312,7 +342,7 @@ func (cached *HashJoin) CachedSize(alloc bool) int64 {
 	}
 	size := int64(0)
 	if alloc {
-		size += int64(112)
+		size += int64(144)
 	}
 	// field Left vitess.io/vitess/go/vt/vtgate/engine.Primitive
 	if cc, ok := cached.Left.(cachedObject); ok {
@@ -326,10 +356,22 @@ func (cached *HashJoin) CachedSize(alloc bool) int64 {
 	{
 		size += hack.RuntimeAllocSize(int64(cap(cached.Cols)) * int64(8))
 	}
+	// field Keys []vitess.io/vitess/go/vt/vtgate/engine.HashJoinKey
+	{
+		size += hack.RuntimeAllocSize(int64(cap(cached.Keys)) * int64(24))
+	}
 	// field ASTPred vitess.io/vitess/go/vt/sqlparser.Expr
 	if cc, ok := cached.ASTPred.(cachedObject); ok {
 		size += cc.CachedSize(true)
 	}
+	// field Filter vitess.io/vitess/go/vt/vtgate/evalengine.Expr
+	if cc, ok := cached.Filter.(cachedObject); ok {
+		size += cc.CachedSize(true)
+	}
+	// field FilterCols []int
+	{
+		size += hack.RuntimeAllocSize(int64(cap(cached.FilterCols)) * int64(8))
+	}
 	return size
 }
 func (cached *Insert) CachedSize(alloc bool) int64 {
@@ -338,7 +380,41 @@ func (cached *Insert) CachedSize(alloc bool) int64 {
 	}
 	size := int64(0)
 	if alloc {
-		size += int64(224)
+		size += int64(352)
+	}
+	// field ReplaceKeys [][]vitess.io/vitess/go/vt/vtgate/engine.VindexColumn
+	{
+		size += hack.RuntimeAllocSize(int64(cap(cached.ReplaceKeys)) * int64(24))
+		for _, elem := range cached.ReplaceKeys {
+			{
+				size += hack.RuntimeAllocSize(int64(cap(elem)) * int64(16))
+			}
+		}
+	}
+	// field ReplaceColumns []vitess.io/vitess/go/vt/sqlparser.IdentifierCI
+	{
+		size += hack.RuntimeAllocSize(int64(cap(cached.ReplaceColumns)) * int64(32))
+		for _, elem := range cached.ReplaceColumns {
+			size += elem.CachedSize(false)
+		}
+	}
+	// field ReplaceValues [][]vitess.io/vitess/go/vt/vtgate/evalengine.Expr
+	{
+		size += hack.RuntimeAllocSize(int64(cap(cached.ReplaceValues)) * int64(24))
+		for _, elem := range cached.ReplaceValues {
+			{
+				size += hack.RuntimeAllocSize(int64(cap(elem)) * int64(16))
+				for _, elem := range elem {
+					if cc, ok := elem.(cachedObject); ok {
+						size += cc.CachedSize(true)
+					}
+				}
+			}
+		}
+	}
+	// field ReplaceValueOffset []int
+	{
+		size += hack.RuntimeAllocSize(int64(cap(cached.ReplaceValueOffset)) * int64(8))
 	}
 	// field Keyspace *vitess.io/vitess/go/vt/vtgate/vindexes.Keyspace
 	size += cached.Keyspace.CachedSize(true)
@@ -622,10 +698,12 @@ func (cached *Plan) CachedSize(alloc bool) int64 {
 	}
 	size := int64(0)
 	if alloc {
-		size += int64(144)
+		size += int64(160)
 	}
 	// field Original string
 	size += hack.RuntimeAllocSize(int64(len(cached.Original)))
+	// field QueryDigest string
+	size += hack.RuntimeAllocSize(int64(len(cached.QueryDigest)))
 	// field Instructions vitess.io/vitess/go/vt/vtgate/engine.Primitive
 	if cc, ok := cached.Instructions.(cachedObject); ok {
 		size += cc.CachedSize(true)
@@ -914,7 +992,7 @@ func (cached *SemiJoin) CachedSize(alloc bool) int64 {
 	}
 	size := int64(0)
 	if alloc {
-		size += int64(64)
+		size += int64(112)
 	}
 	// field Left vitess.io/vitess/go/vt/vtgate/engine.Primitive
 	if cc, ok := cached.Left.(cachedObject); ok {
@@ -942,6 +1020,17 @@ func (cached *SemiJoin) CachedSize(alloc bool) int64 {
 			size += hack.RuntimeAllocSize(int64(len(k)))
 		}
 	}
+	// field Keys []vitess.io/vitess/go/vt/vtgate/engine.HashJoinKey
+	{
+		size += hack.RuntimeAllocSize(int64(cap(cached.Keys)) * int64(24))
+	}
+	// field ListVars []string
+	{
+		size += hack.RuntimeAllocSize(int64(cap(cached.ListVars)) * int64(16))
+		for _, elem := range cached.ListVars {
+			size += hack.RuntimeAllocSize(int64(len(elem)))
+		}
+	}
 	return size
 }
 func (cached *Send) CachedSize(alloc bool) int64 {
@@ -1298,6 +1387,56 @@ func (cached *VitessMetadata) CachedSize(alloc bool) int64 {
 	size += hack.RuntimeAllocSize(int64(len(cached.Value)))
 	return size
 }
+func (cached *Window) CachedSize(alloc bool) int64 {
+	if cached == nil {
+		return int64(0)
+	}
+	size := int64(0)
+	if alloc {
+		size += int64(96)
+	}
+	// field Functions []*vitess.io/vitess/go/vt/vtgate/engine.WindowParams
+	{
+		size += hack.RuntimeAllocSize(int64(cap(cached.Functions)) * int64(8))
+		for _, elem := range cached.Functions {
+			size += elem.CachedSize(true)
+		}
+	}
+	// field PartitionBy []*vitess.io/vitess/go/vt/vtgate/engine.GroupByParams
+	{
+		size += hack.RuntimeAllocSize(int64(cap(cached.PartitionBy)) * int64(8))
+		for _, elem := range cached.PartitionBy {
+			size += elem.CachedSize(true)
+		}
+	}
+	// field OrderBy []vitess.io/vitess/go/vt/vtgate/engine.OrderByParams
+	{
+		size += hack.RuntimeAllocSize(int64(cap(cached.OrderBy)) * int64(36))
+	}
+	// field Input vitess.io/vitess/go/vt/vtgate/engine.Primitive
+	if cc, ok := cached.Input.(cachedObject); ok {
+		size += cc.CachedSize(true)
+	}
+	return size
+}
+func (cached *WindowParams) CachedSize(alloc bool) int64 {
+	if cached == nil {
+		return int64(0)
+	}
+	size := int64(0)
+	if alloc {
+		size += int64(64)
+	}
+	// field Default vitess.io/vitess/go/vt/vtgate/evalengine.Expr
+	if cc, ok := cached.Default.(cachedObject); ok {
+		size += cc.CachedSize(true)
+	}
+	// field Alias string
+	size += hack.RuntimeAllocSize(int64(len(cached.Alias)))
+	// field Original *vitess.io/vitess/go/vt/sqlparser.AliasedExpr
+	size += cached.Original.CachedSize(true)
+	return size
+}
 
 //go:nocheckptr
 func (cached *shardRoute) CachedSize(alloc bool) int64 {
@@ -19,6 +19,7 @@ package engine
 import (
 	"context"
 	"fmt"
+	"math"
 
 	"vitess.io/vitess/go/mysql/collations"
 	"vitess.io/vitess/go/sqltypes"
@@ -45,39 +46,47 @@ type (
 	probeTable struct {
 		seenRows  map[evalengine.HashCode][]sqltypes.Row
 		checkCols []CheckCol
+		size      int
 	}
 )
 
 func (pt *probeTable) exists(inputRow sqltypes.Row) (bool, error) {
+	code, exists, err := pt.lookup(inputRow)
+	if err != nil || exists {
+		return exists, err
+	}
+	pt.seenRows[code] = append(pt.seenRows[code], inputRow)
+	pt.size++
+	return false, nil
+}
+
+// contains checks if the row has been seen before, without adding it to the probe table
+func (pt *probeTable) contains(inputRow sqltypes.Row) (bool, error) {
+	_, exists, err := pt.lookup(inputRow)
+	return exists, err
+}
+
+func (pt *probeTable) lookup(inputRow sqltypes.Row) (evalengine.HashCode, bool, error) {
 	// the two prime numbers used here (17 and 31) are used to
 	// calculate hashcode from all column values in the input sqltypes.Row
 	code, err := pt.hashCodeForRow(inputRow)
 	if err != nil {
-		return false, err
-	}
-
-	existingRows, found := pt.seenRows[code]
-	if !found {
-		// nothing with this hash code found, we can be sure it's a not seen sqltypes.Row
-		pt.seenRows[code] = []sqltypes.Row{inputRow}
-		return false, nil
+		return 0, false, err
 	}
 
-	// we found something in the map - still need to check all individual values
+	// if nothing with this hash code is found, we can be sure it's a not seen sqltypes.Row.
+	// if we found something in the map - still need to check all individual values
 	// so we don't just fall for a hash collision
-	for _, existingRow := range existingRows {
+	for _, existingRow := range pt.seenRows[code] {
 		exists, err := pt.equal(existingRow, inputRow)
 		if err != nil {
-			return false, err
+			return 0, false, err
 		}
 		if exists {
-			return true, nil
+			return code, true, nil
 		}
 	}
-
-	pt.seenRows[code] = append(existingRows, inputRow)
-
-	return false, nil
+	return code, false, nil
 }
 
 func (pt *probeTable) hashCodeForRow(inputRow sqltypes.Row) (evalengine.HashCode, error) {
@@ -168,6 +177,13 @@ func newProbeTable(checkCols []CheckCol) *probeTable {
 
 // TryExecute implements the Primitive interface
 func (d *Distinct) TryExecute(ctx context.Context, vcursor VCursor, bindVars map[string]*querypb.BindVariable, wantfields bool) (*sqltypes.Result, error) {
+	if vcursor.SpillDir() != "" {
+		// stream the input, so that the rows can spill to disk once the probe table is full
+		return executeStreamed(func(callback func(*sqltypes.Result) error) error {
+			return d.TryStreamExecute(ctx, vcursor, bindVars, wantfields, callback)
+		})
+	}
+
 	input, err := vcursor.ExecutePrimitive(ctx, d.Source, bindVars, wantfields)
 	if err != nil {
 		return nil, err
@@ -199,12 +215,49 @@ func (d *Distinct) TryExecute(ctx context.Context, vcursor VCursor, bindVars map
 func (d *Distinct) TryStreamExecute(ctx context.Context, vcursor VCursor, bindVars map[string]*querypb.BindVariable, wantfields bool, callback func(*sqltypes.Result) error) error {
 	pt := newProbeTable(d.CheckCols)
 
+	// Once the probe table holds more rows than allowed, and spilling is enabled, the rows that
+	// have not been seen yet are no longer returned right away. They are sorted on disk instead,
+	// and the duplicates are removed when merging them back together at the end.
+	var spilling bool
+	var sorter *spillSorter
+	var pending []sqltypes.Row
+	var seq int64
+	defer func() {
+		if sorter != nil {
+			_ = sorter.close()
+		}
+	}()
+
 	err := vcursor.StreamExecutePrimitive(ctx, d.Source, bindVars, wantfields, func(input *sqltypes.Result) error {
 		result := &sqltypes.Result{
 			Fields:   input.Fields,
 			InsertID: input.InsertID,
 		}
 		for _, row := range input.Rows {
+			if spilling {
+				seen, err := pt.contains(row)
+				if err != nil {
+					return err
+				}
+				if seen {
+					continue
+				}
+				if sorter == nil {
+					sorter = newSpillSorter(vcursor.SpillDir(), d.spillComparers(len(row)))
+				}
+				// the position of the row in the input is added as an extra column,
+				// so that the original order can be restored after removing the duplicates
+				pending = append(pending, append(row[:len(row):len(row)], sqltypes.NewInt64(seq)))
+				seq++
+				if vcursor.ExceedsMaxMemoryRows(len(pending)) {
+					if err := sorter.spill(pending); err != nil {
+						return err
+					}
+					pending = nil
+				}
+				continue
+			}
+
 			exists, err := pt.exists(row)
 			if err != nil {
 				return err
@@ -212,11 +265,88 @@ func (d *Distinct) TryStreamExecute(ctx context.Context, vcursor VCursor, bindVa
 			if !exists {
 				result.Rows = append(result.Rows, row)
 			}
+			spilling = vcursor.SpillDir() != "" && vcursor.ExceedsMaxMemoryRows(pt.size)
+		}
+		return callback(result.Truncate(len(d.CheckCols)))
+	})
+	if err != nil || sorter == nil {
+		return err
+	}
+	return d.mergeSpilled(vcursor, sorter, pending, callback)
+}
+
+// mergeSpilled returns the distinct rows out of the rows that were spilled to disk and the pending
+// rows that are still in memory. The rows are first merged on the distinct columns, so that duplicates
+// end up next to each other. The first row of every group is then sorted again on its position
+// in the input, so that the rows are returned in the same order as they were received.
+func (d *Distinct) mergeSpilled(vcursor VCursor, sorter *spillSorter, pending []sqltypes.Row, callback func(*sqltypes.Result) error) error {
+	distinctCols := sorter.comparers[:len(d.CheckCols)]
+	ordered := newSpillSorter(sorter.dir, sorter.comparers[len(d.CheckCols):])
+	defer func() {
+		_ = ordered.close()
+	}()
+
+	var prev sqltypes.Row
+	var unique []sqltypes.Row
+	err := sorter.merge(pending, math.MaxInt, func(row sqltypes.Row) error {
+		if prev != nil {
+			cmp, err := compareRows(distinctCols, prev, row)
+			if err != nil || cmp == 0 {
+				return err
+			}
+		}
+		prev = row
+		unique = append(unique, row)
+		if !vcursor.ExceedsMaxMemoryRows(len(unique)) {
+			return nil
 		}
+		err := ordered.spill(unique)
+		unique = nil
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	var batch []sqltypes.Row
+	send := func() error {
+		result := &sqltypes.Result{Rows: batch}
+		batch = nil
 		return callback(result.Truncate(len(d.CheckCols)))
+	}
+	err = ordered.merge(unique, math.MaxInt, func(row sqltypes.Row) error {
+		batch = append(batch, row[:len(row)-1])
+		if len(batch) < spillBatchSize {
+			return nil
+		}
+		return send()
 	})
+	if err != nil || len(batch) == 0 {
+		return err
+	}
+	return send()
+}
 
-	return err
+// spillComparers returns the comparers used to sort the rows that are spilled to disk: first
+// on all the distinct columns, and then on the input position stored in the column at seqCol
+func (d *Distinct) spillComparers(seqCol int) []*comparer {
+	orderBy := make([]OrderByParams, 0, len(d.CheckCols)+1)
+	for _, checkCol := range d.CheckCols {
+		wsCol := -1
+		if checkCol.WsCol != nil {
+			wsCol = *checkCol.WsCol
+		}
+		orderBy = append(orderBy, OrderByParams{
+			Col:             checkCol.Col,
+			WeightStringCol: wsCol,
+			CollationID:     checkCol.Collation,
+		})
+	}
+	orderBy = append(orderBy, OrderByParams{
+		Col:             seqCol,
+		WeightStringCol: -1,
+	})
+	return extractSlices(orderBy)
 }
 
 // RouteType implements the Primitive interface
@@ -19,6 +19,7 @@ package engine
 import (
 	"context"
 	"fmt"
+	"os"
 	"testing"
 
 	"vitess.io/vitess/go/mysql/collations"
@@ -161,3 +162,42 @@ func TestWeightStringFallBack(t *testing.T) {
 		Collation: collations.Unknown,
 	}}, distinct.CheckCols, "checkCols should not be updated")
 }
+
+func TestDistinctSpill(t *testing.T) {
+	saveMax := testMaxMemoryRows
+	saveSpillDir := testSpillDir
+	testMaxMemoryRows = 2
+	defer func() {
+		testMaxMemoryRows = saveMax
+		testSpillDir = saveSpillDir
+	}()
+	testSpillDir = t.TempDir()
+
+	for _, streaming := range []bool{false, true} {
+		distinct := &Distinct{
+			Source: &fakePrimitive{results: []*sqltypes.Result{
+				r("a|b", "int64|varchar", "3|x", "1|y", "2|x", "1|y", "6|z", "3|x", "4|x", "6|z", "2|x", "5|y", "4|x", "4|y"),
+			}},
+			CheckCols: []CheckCol{
+				{Col: 0, Collation: collations.CollationBinaryID},
+				{Col: 1, Collation: collations.Default()},
+			},
+		}
+
+		var result *sqltypes.Result
+		var err error
+		if streaming {
+			result, err = wrapStreamExecute(distinct, &noopVCursor{}, nil, true)
+		} else {
+			result, err = distinct.TryExecute(context.Background(), &noopVCursor{}, nil, true)
+		}
+		require.NoError(t, err)
+
+		// the rows are returned in the order in which they were first seen
+		utils.MustMatch(t, r("a|b", "int64|varchar", "3|x", "1|y", "2|x", "6|z", "4|x", "5|y", "4|y"), result)
+
+		files, err := os.ReadDir(testSpillDir)
+		require.NoError(t, err)
+		require.Empty(t, files, "spill files should be removed")
+	}
+}
@@ -0,0 +1,188 @@
+/*
+Copyright 2023 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package engine
+
+import (
+	"context"
+
+	"vitess.io/vitess/go/sqltypes"
+	"vitess.io/vitess/go/vt/vterrors"
+	"vitess.io/vitess/go/vt/vtgate/vindexes"
+
+	querypb "vitess.io/vitess/go/vt/proto/query"
+	vtrpcpb "vitess.io/vitess/go/vt/proto/vtrpc"
+)
+
+var _ Primitive = (*DMLWithInput)(nil)
+
+// DMLWithInput executes a multi-shard UPDATE or DELETE that has a LIMIT clause,
+// or a DELETE that joins other tables.
+// The Input primitive selects the primary vindex values of the qualifying rows,
+// applying the ORDER BY and the LIMIT across all shards. The DML primitive is then
+// executed once for every shard holding some of those rows, with the values found
+// in that shard bound to ListVar and the number of rows found bound to LimitVar.
+// When KeyVar is set, the Input also returns the primary key of the rows, and the
+// primary keys of the rows found in the shard are bound to KeyVar.
+type DMLWithInput struct {
+	// Input returns the primary vindex column of the rows to change,
+	// followed by the primary key column when KeyVar is set
+	Input Primitive
+	// DML is routed using the values bound to ListVar
+	DML Primitive
+
+	Keyspace *vindexes.Keyspace
+	Vindex   vindexes.SingleColumn
+
+	ListVar  string
+	LimitVar string
+	KeyVar   string
+
+	txNeeded
+}
+
+// RouteType implements the Primitive interface
+func (d *DMLWithInput) RouteType() string {
+	return "DMLWithInput"
+}
+
+// GetKeyspaceName implements the Primitive interface
+func (d *DMLWithInput) GetKeyspaceName() string {
+	return d.DML.GetKeyspaceName()
+}
+
+// GetTableName implements the Primitive interface
+func (d *DMLWithInput) GetTableName() string {
+	return d.DML.GetTableName()
+}
+
+// Inputs implements the Primitive interface
+func (d *DMLWithInput) Inputs() []Primitive {
+	return []Primitive{d.Input, d.DML}
+}
+
+// TryExecute implements the Primitive interface
+func (d *DMLWithInput) TryExecute(ctx context.Context, vcursor VCursor, bindVars map[string]*querypb.BindVariable, _ bool) (*sqltypes.Result, error) {
+	inputRes, err := vcursor.ExecutePrimitive(ctx, d.Input, bindVars, false)
+	if err != nil {
+		return nil, err
+	}
+	if len(inputRes.Rows) == 0 {
+		return &sqltypes.Result{}, nil
+	}
+
+	keys := make([]sqltypes.Value, 0, len(inputRes.Rows))
+	// primaryKeys holds the primary keys of the rows, by their primary vindex value
+	var primaryKeys map[string][]*querypb.Value
+	if d.KeyVar != "" {
+		primaryKeys = make(map[string][]*querypb.Value, len(inputRes.Rows))
+	}
+	for _, row := range inputRes.Rows {
+		if len(row) == 0 {
+			return nil, vterrors.Errorf(vtrpcpb.Code_INTERNAL, "[BUG] no primary vindex value returned for DML input")
+		}
+		keys = append(keys, row[0])
+		if d.KeyVar == "" {
+			continue
+		}
+		if len(row) < 2 {
+			return nil, vterrors.Errorf(vtrpcpb.Code_INTERNAL, "[BUG] no primary key value returned for DML input")
+		}
+		vindexValue := row[0].RawStr()
+		primaryKeys[vindexValue] = append(primaryKeys[vindexValue], sqltypes.ValueToProto(row[1]))
+	}
+	rss, values, err := resolveShards(ctx, vcursor, d.Vindex, d.Keyspace, keys)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &sqltypes.Result{}
+	for i := range rss {
+		dmlVars := make(map[string]*querypb.BindVariable, len(bindVars)+2)
+		for k, v := range bindVars {
+			dmlVars[k] = v
+		}
+		dmlVars[d.ListVar] = &querypb.BindVariable{
+			Type:   querypb.Type_TUPLE,
+			Values: values[i],
+		}
+		if d.LimitVar != "" {
+			dmlVars[d.LimitVar] = sqltypes.Int64BindVariable(int64(len(values[i])))
+		}
+		if d.KeyVar != "" {
+			dmlVars[d.KeyVar] = &querypb.BindVariable{
+				Type:   querypb.Type_TUPLE,
+				Values: shardKeys(primaryKeys, values[i]),
+			}
+		}
+
+		qr, err := vcursor.ExecutePrimitive(ctx, d.DML, dmlVars, false)
+		if err != nil {
+			return nil, err
+		}
+		result.RowsAffected += qr.RowsAffected
+	}
+	return result, nil
+}
+
+// shardKeys returns the primary keys of the rows with the given primary vindex values.
+// A value is listed once for every row that has it, so every listed value takes the
+// next primary key found for it.
+func shardKeys(primaryKeys map[string][]*querypb.Value, values []*querypb.Value) []*querypb.Value {
+	keys := make([]*querypb.Value, 0, len(values))
+	for _, value := range values {
+		vindexValue := string(value.Value)
+		pks := primaryKeys[vindexValue]
+		if len(pks) == 0 {
+			continue
+		}
+		keys = append(keys, pks[0])
+		primaryKeys[vindexValue] = pks[1:]
+	}
+	return keys
+}
+
+// TryStreamExecute implements the Primitive interface
+func (d *DMLWithInput) TryStreamExecute(ctx context.Context, vcursor VCursor, bindVars map[string]*querypb.BindVariable, wantfields bool, callback func(*sqltypes.Result) error) error {
+	res, err := d.TryExecute(ctx, vcursor, bindVars, wantfields)
+	if err != nil {
+		return err
+	}
+	return callback(res)
+}
+
+// GetFields implements the Primitive interface
+func (d *DMLWithInput) GetFields(context.Context, VCursor, map[string]*querypb.BindVariable) (*sqltypes.Result, error) {
+	return nil, vterrors.VT13001("unreachable code for DMLWithInput")
+}
+
+func (d *DMLWithInput) description() PrimitiveDescription {
+	other := map[string]any{
+		"Vindex":  d.Vindex.String(),
+		"ListVar": d.ListVar,
+	}
+	if d.LimitVar != "" {
+		other["LimitVar"] = d.LimitVar
+	}
+	if d.KeyVar != "" {
+		other["KeyVar"] = d.KeyVar
+	}
+	return PrimitiveDescription{
+		OperatorType: "DMLWithInput",
+		Keyspace:     d.Keyspace,
+		Other:        other,
+	}
+}
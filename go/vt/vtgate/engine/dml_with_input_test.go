@@ -0,0 +1,145 @@
+/*
+Copyright 2023 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package engine
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"vitess.io/vitess/go/sqltypes"
+	"vitess.io/vitess/go/vt/vtgate/vindexes"
+
+	querypb "vitess.io/vitess/go/vt/proto/query"
+)
+
+func TestDMLWithInputExecute(t *testing.T) {
+	vindex, _ := vindexes.CreateVindex("hash", "", nil)
+	input := &fakePrimitive{
+		results: []*sqltypes.Result{
+			sqltypes.MakeTestResult(
+				sqltypes.MakeTestFields("id", "int64"),
+				"1",
+				"2",
+				"3",
+			),
+		},
+	}
+	dml := &fakePrimitive{
+		results: []*sqltypes.Result{
+			{RowsAffected: 2},
+			{RowsAffected: 1},
+		},
+	}
+	del := &DMLWithInput{
+		Input: input,
+		DML:   dml,
+		Keyspace: &vindexes.Keyspace{
+			Name:    "ks",
+			Sharded: true,
+		},
+		Vindex:   vindex.(vindexes.SingleColumn),
+		ListVar:  "dml_vals",
+		LimitVar: "dml_limit",
+	}
+
+	vc := newDMLTestVCursor("-20", "20-")
+	vc.shardForKsid = []string{"-20", "20-", "-20"}
+	qr, err := del.TryExecute(context.Background(), vc, map[string]*querypb.BindVariable{}, false)
+	require.NoError(t, err)
+	require.EqualValues(t, 3, qr.RowsAffected)
+	vc.ExpectLog(t, []string{
+		`ResolveDestinations ks [type:INT64 value:"1" type:INT64 value:"2" type:INT64 value:"3"] Destinations:DestinationKeyspaceID(166b40b44aba4bd6),DestinationKeyspaceID(06e7ea22ce92708f),DestinationKeyspaceID(4eb190c9a2fa169c)`,
+	})
+	input.ExpectLog(t, []string{
+		`Execute  false`,
+	})
+	dml.ExpectLog(t, []string{
+		`Execute dml_limit: type:INT64 value:"2" dml_vals: type:TUPLE values:{type:INT64 value:"1"} values:{type:INT64 value:"3"} false`,
+		`Execute dml_limit: type:INT64 value:"1" dml_vals: type:TUPLE values:{type:INT64 value:"2"} false`,
+	})
+}
+
+func TestDMLWithInputExecutePrimaryKeys(t *testing.T) {
+	vindex, _ := vindexes.CreateVindex("hash", "", nil)
+	input := &fakePrimitive{
+		results: []*sqltypes.Result{
+			sqltypes.MakeTestResult(
+				sqltypes.MakeTestFields("id|pk", "int64|int64"),
+				"1|10",
+				"2|20",
+				"1|11",
+			),
+		},
+	}
+	dml := &fakePrimitive{
+		results: []*sqltypes.Result{
+			{RowsAffected: 2},
+			{RowsAffected: 1},
+		},
+	}
+	del := &DMLWithInput{
+		Input: input,
+		DML:   dml,
+		Keyspace: &vindexes.Keyspace{
+			Name:    "ks",
+			Sharded: true,
+		},
+		Vindex:  vindex.(vindexes.SingleColumn),
+		ListVar: "dml_vals",
+		KeyVar:  "dml_keys",
+	}
+
+	vc := newDMLTestVCursor("-20", "20-")
+	vc.shardForKsid = []string{"-20", "20-", "-20"}
+	qr, err := del.TryExecute(context.Background(), vc, map[string]*querypb.BindVariable{}, false)
+	require.NoError(t, err)
+	require.EqualValues(t, 3, qr.RowsAffected)
+	dml.ExpectLog(t, []string{
+		`Execute dml_keys: type:TUPLE values:{type:INT64 value:"10"} values:{type:INT64 value:"11"} dml_vals: type:TUPLE values:{type:INT64 value:"1"} values:{type:INT64 value:"1"} false`,
+		`Execute dml_keys: type:TUPLE values:{type:INT64 value:"20"} dml_vals: type:TUPLE values:{type:INT64 value:"2"} false`,
+	})
+}
+
+func TestDMLWithInputNoRows(t *testing.T) {
+	vindex, _ := vindexes.CreateVindex("hash", "", nil)
+	input := &fakePrimitive{
+		results: []*sqltypes.Result{
+			sqltypes.MakeTestResult(sqltypes.MakeTestFields("id", "int64")),
+		},
+	}
+	dml := &fakePrimitive{}
+	del := &DMLWithInput{
+		Input: input,
+		DML:   dml,
+		Keyspace: &vindexes.Keyspace{
+			Name:    "ks",
+			Sharded: true,
+		},
+		Vindex:   vindex.(vindexes.SingleColumn),
+		ListVar:  "dml_vals",
+		LimitVar: "dml_limit",
+	}
+
+	vc := newDMLTestVCursor("-20", "20-")
+	qr, err := del.TryExecute(context.Background(), vc, map[string]*querypb.BindVariable{}, false)
+	require.NoError(t, err)
+	require.Zero(t, qr.RowsAffected)
+	vc.ExpectLog(t, nil)
+	dml.ExpectLog(t, nil)
+}
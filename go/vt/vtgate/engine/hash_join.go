@@ -19,6 +19,7 @@ package engine
 import (
 	"context"
 	"fmt"
+	"io"
 	"strings"
 
 	"vitess.io/vitess/go/mysql/collations"
@@ -30,124 +31,164 @@ import (
 
 var _ Primitive = (*HashJoin)(nil)
 
-// HashJoin specifies the parameters for a join primitive
-// Hash joins work by fetch all the input from the LHS, and building a hash map, known as the probe table, for this input.
-// The key to the map is the hashcode of the value for column that we are joining by.
-// Then the RHS is fetched, and we can check if the rows from the RHS matches any from the LHS.
-// When they match by hash code, we double-check that we are not working with a false positive by comparing the values.
-type HashJoin struct {
-	Opcode JoinOpcode
-
-	// Left and Right are the LHS and RHS primitives
-	// of the Join. They can be any primitive.
-	Left, Right Primitive `json:",omitempty"`
-
-	// Cols defines which columns from the left
-	// or right results should be used to build the
-	// return result. For results coming from the
-	// left query, the index values go as -1, -2, etc.
-	// For the right query, they're 1, 2, etc.
-	// If Cols is {-1, -2, 1, 2}, it means that
-	// the returned result will be {Left0, Left1, Right0, Right1}.
-	Cols []int `json:",omitempty"`
-
-	// The keys correspond to the column offset in the inputs where
-	// the join columns can be found
-	LHSKey, RHSKey int
-
-	// The join condition. Used for plan descriptions
-	ASTPred sqlparser.Expr
-
-	// collation and type are used to hash the incoming values correctly
-	Collation      collations.ID
-	ComparisonType querypb.Type
-}
+// hashJoinSpillPartitions is the number of partitions the inputs are split into
+// when the LHS of a hash join does not fit in memory and has to be spilled to disk.
+const hashJoinSpillPartitions = 32
 
-// TryExecute implements the Primitive interface
-func (hj *HashJoin) TryExecute(ctx context.Context, vcursor VCursor, bindVars map[string]*querypb.BindVariable, wantfields bool) (*sqltypes.Result, error) {
-	lresult, err := vcursor.ExecutePrimitive(ctx, hj.Left, bindVars, wantfields)
-	if err != nil {
-		return nil, err
+type (
+	// HashJoin specifies the parameters for a join primitive
+	// Hash joins work by fetch all the input from the LHS, and building a hash map, known as the probe table, for this input.
+	// The key to the map is the hashcode of the values for the columns that we are joining by.
+	// Then the RHS is fetched, and we can check if the rows from the RHS matches any from the LHS.
+	// When they match by hash code, we double-check that we are not working with a false positive by comparing the values.
+	// For left joins, the LHS rows that did not match any RHS row are returned with NULL values for the RHS columns.
+	// If the LHS does not fit in memory, both inputs are partitioned on disk by hash code and
+	// the partitions are joined one at a time.
+	HashJoin struct {
+		Opcode JoinOpcode
+
+		// Left and Right are the LHS and RHS primitives
+		// of the Join. They can be any primitive.
+		Left, Right Primitive `json:",omitempty"`
+
+		// Cols defines which columns from the left
+		// or right results should be used to build the
+		// return result. For results coming from the
+		// left query, the index values go as -1, -2, etc.
+		// For the right query, they're 1, 2, etc.
+		// If Cols is {-1, -2, 1, 2}, it means that
+		// the returned result will be {Left0, Left1, Right0, Right1}.
+		Cols []int `json:",omitempty"`
+
+		// Keys are the equality comparisons between the LHS and the RHS that the rows are joined by
+		Keys []HashJoinKey
+
+		// The join condition. Used for plan descriptions
+		ASTPred sqlparser.Expr
+
+		// Filter holds the part of the join condition that cannot be solved by the probe table.
+		// It is evaluated for every pair of rows with matching keys, against a row built from
+		// FilterCols, which use the same encoding as Cols.
+		Filter     evalengine.Expr
+		FilterCols []int
 	}
 
-	// build the probe table from the LHS result
-	probeTable, err := hj.buildProbeTable(lresult)
-	if err != nil {
-		return nil, err
+	// HashJoinKey is an equality comparison between a column of the LHS and a column of the RHS
+	HashJoinKey struct {
+		// LHS and RHS are the column offsets in the inputs where the join columns can be found
+		LHS, RHS int
+
+		// collation and type are used to hash and compare the incoming values correctly
+		Collation      collations.ID
+		ComparisonType querypb.Type
 	}
 
-	rresult, err := vcursor.ExecutePrimitive(ctx, hj.Right, bindVars, wantfields)
-	if err != nil {
-		return nil, err
+	// hashJoinProbe is the probe table built from the LHS rows that are held in memory
+	hashJoinProbe struct {
+		rows    []sqltypes.Row
+		matched []bool
+		table   map[evalengine.HashCode][]int
 	}
 
-	result := &sqltypes.Result{
-		Fields: joinFields(lresult.Fields, rresult.Fields, hj.Cols),
+	// hashJoinOutput collects the joined rows and hands them over to the callback
+	hashJoinOutput struct {
+		fields   []*querypb.Field
+		rows     []sqltypes.Row
+		sent     bool
+		callback func(*sqltypes.Result) error
 	}
+)
 
-	for _, currentRHSRow := range rresult.Rows {
-		joinVal := currentRHSRow[hj.RHSKey]
-		if joinVal.IsNull() {
-			continue
-		}
-		hashcode, err := evalengine.NullsafeHashcode(joinVal, hj.Collation, hj.ComparisonType)
-		if err != nil {
-			return nil, err
-		}
-		lftRows := probeTable[hashcode]
-		for _, currentLHSRow := range lftRows {
-			lhsVal := currentLHSRow[hj.LHSKey]
-			// hash codes can give false positives, so we need to check with a real comparison as well
-			cmp, err := evalengine.NullsafeCompare(joinVal, lhsVal, collations.Unknown)
-			if err != nil {
-				return nil, err
+// TryExecute implements the Primitive interface
+func (hj *HashJoin) TryExecute(ctx context.Context, vcursor VCursor, bindVars map[string]*querypb.BindVariable, wantfields bool) (*sqltypes.Result, error) {
+	fetch := func(input Primitive) func(func(*sqltypes.Result) error) error {
+		return func(callback func(*sqltypes.Result) error) error {
+			if vcursor.SpillDir() != "" {
+				// stream the input, so that it does not have to fit in memory as a whole
+				return vcursor.StreamExecutePrimitive(ctx, input, bindVars, wantfields, callback)
 			}
-
-			if cmp == 0 {
-				// we have a match!
-				result.Rows = append(result.Rows, joinRows(currentLHSRow, currentRHSRow, hj.Cols))
+			res, err := vcursor.ExecutePrimitive(ctx, input, bindVars, wantfields)
+			if err != nil {
+				return err
 			}
+			return callback(res)
 		}
 	}
 
+	result := &sqltypes.Result{}
+	err := hj.join(ctx, vcursor, bindVars, fetch(hj.Left), fetch(hj.Right), func(res *sqltypes.Result) error {
+		if res.Fields != nil {
+			result.Fields = res.Fields
+		}
+		result.Rows = append(result.Rows, res.Rows...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
 	return result, nil
 }
 
-func (hj *HashJoin) buildProbeTable(lresult *sqltypes.Result) (map[evalengine.HashCode][]sqltypes.Row, error) {
-	probeTable := map[evalengine.HashCode][]sqltypes.Row{}
-	for _, current := range lresult.Rows {
-		joinVal := current[hj.LHSKey]
-		if joinVal.IsNull() {
-			continue
-		}
-		hashcode, err := evalengine.NullsafeHashcode(joinVal, hj.Collation, hj.ComparisonType)
-		if err != nil {
-			return nil, err
+// TryStreamExecute implements the Primitive interface
+func (hj *HashJoin) TryStreamExecute(ctx context.Context, vcursor VCursor, bindVars map[string]*querypb.BindVariable, wantfields bool, callback func(*sqltypes.Result) error) error {
+	fetch := func(input Primitive) func(func(*sqltypes.Result) error) error {
+		return func(callback func(*sqltypes.Result) error) error {
+			return vcursor.StreamExecutePrimitive(ctx, input, bindVars, wantfields, callback)
 		}
-		probeTable[hashcode] = append(probeTable[hashcode], current)
 	}
-	return probeTable, nil
+	return hj.join(ctx, vcursor, bindVars, fetch(hj.Left), fetch(hj.Right), callback)
 }
 
-// TryStreamExecute implements the Primitive interface
-func (hj *HashJoin) TryStreamExecute(ctx context.Context, vcursor VCursor, bindVars map[string]*querypb.BindVariable, wantfields bool, callback func(*sqltypes.Result) error) error {
-	// build the probe table from the LHS result
-	probeTable := map[evalengine.HashCode][]sqltypes.Row{}
+// join builds the probe table from the LHS, and then joins the RHS rows with it.
+// When the LHS exceeds the memory limit and spilling is enabled, the rest of the
+// join is delegated to spillJoin.
+func (hj *HashJoin) join(
+	ctx context.Context,
+	vcursor VCursor,
+	bindVars map[string]*querypb.BindVariable,
+	left, right func(func(*sqltypes.Result) error) error,
+	callback func(*sqltypes.Result) error,
+) error {
 	var lfields []*querypb.Field
-	err := vcursor.StreamExecutePrimitive(ctx, hj.Left, bindVars, wantfields, func(result *sqltypes.Result) error {
+	probe := newHashJoinProbe()
+	var partitions []*spillFile
+	defer func() {
+		for _, partition := range partitions {
+			_ = partition.close()
+		}
+	}()
+
+	err := left(func(result *sqltypes.Result) error {
 		if len(lfields) == 0 && len(result.Fields) != 0 {
 			lfields = result.Fields
 		}
-		for _, current := range result.Rows {
-			joinVal := current[hj.LHSKey]
-			if joinVal.IsNull() {
+		for _, row := range result.Rows {
+			if partitions != nil {
+				if err := hj.spillRow(partitions, row, hj.lhsKey); err != nil {
+					return err
+				}
+				continue
+			}
+			if err := hj.addToProbe(probe, row); err != nil {
+				return err
+			}
+			if !vcursor.ExceedsMaxMemoryRows(len(probe.rows)) {
 				continue
 			}
-			hashcode, err := evalengine.NullsafeHashcode(joinVal, hj.Collation, hj.ComparisonType)
+			if vcursor.SpillDir() == "" {
+				return fmt.Errorf("in-memory row count exceeded allowed limit of %d", vcursor.MaxMemoryRows())
+			}
+			var err error
+			partitions, err = newSpillPartitions(vcursor.SpillDir())
 			if err != nil {
 				return err
 			}
-			probeTable[hashcode] = append(probeTable[hashcode], current)
+			for _, row := range probe.rows {
+				if err := hj.spillRow(partitions, row, hj.lhsKey); err != nil {
+					return err
+				}
+			}
+			probe = nil
 		}
 		return nil
 	})
@@ -155,43 +196,271 @@ func (hj *HashJoin) TryStreamExecute(ctx context.Context, vcursor VCursor, bindV
 		return err
 	}
 
-	return vcursor.StreamExecutePrimitive(ctx, hj.Right, bindVars, wantfields, func(result *sqltypes.Result) error {
-		// compare the results coming from the RHS with the probe-table
-		res := &sqltypes.Result{}
-		if len(result.Fields) != 0 {
-			res = &sqltypes.Result{
-				Fields: joinFields(lfields, result.Fields, hj.Cols),
-			}
+	out := &hashJoinOutput{callback: callback}
+	if partitions != nil {
+		return hj.spillJoin(ctx, vcursor, bindVars, lfields, partitions, right, out)
+	}
+
+	env := evalengine.NewExpressionEnv(ctx, bindVars, vcursor)
+	err = right(func(result *sqltypes.Result) error {
+		if out.fields == nil && len(lfields) != 0 && len(result.Fields) != 0 {
+			out.fields = joinFields(lfields, result.Fields, hj.Cols)
 		}
-		for _, currentRHSRow := range result.Rows {
-			joinVal := currentRHSRow[hj.RHSKey]
-			if joinVal.IsNull() {
-				continue
+		for _, row := range result.Rows {
+			if err := hj.probeRow(env, probe, row, out); err != nil {
+				return err
 			}
-			hashcode, err := evalengine.NullsafeHashcode(joinVal, hj.Collation, hj.ComparisonType)
-			if err != nil {
+		}
+		return out.flush()
+	})
+	if err != nil {
+		return err
+	}
+	hj.addUnmatched(probe, out)
+	return out.finish()
+}
+
+// spillJoin joins the inputs after the LHS has been spilled to the given partitions.
+// The RHS is partitioned the same way, so that matching rows always end up in the
+// partitions with the same index. Each pair of partitions is then joined in memory.
+func (hj *HashJoin) spillJoin(
+	ctx context.Context,
+	vcursor VCursor,
+	bindVars map[string]*querypb.BindVariable,
+	lfields []*querypb.Field,
+	lhsPartitions []*spillFile,
+	right func(func(*sqltypes.Result) error) error,
+	out *hashJoinOutput,
+) error {
+	rhsPartitions, err := newSpillPartitions(vcursor.SpillDir())
+	if err != nil {
+		return err
+	}
+	defer func() {
+		for _, partition := range rhsPartitions {
+			_ = partition.close()
+		}
+	}()
+
+	err = right(func(result *sqltypes.Result) error {
+		if out.fields == nil && len(lfields) != 0 && len(result.Fields) != 0 {
+			out.fields = joinFields(lfields, result.Fields, hj.Cols)
+		}
+		for _, row := range result.Rows {
+			if err := hj.spillRow(rhsPartitions, row, hj.rhsKey); err != nil {
 				return err
 			}
-			lftRows := probeTable[hashcode]
-			for _, currentLHSRow := range lftRows {
-				lhsVal := currentLHSRow[hj.LHSKey]
-				// hash codes can give false positives, so we need to check with a real comparison as well
-				cmp, err := evalengine.NullsafeCompare(joinVal, lhsVal, hj.Collation)
-				if err != nil {
-					return err
-				}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
 
-				if cmp == 0 {
-					// we have a match!
-					res.Rows = append(res.Rows, joinRows(currentLHSRow, currentRHSRow, hj.Cols))
-				}
+	env := evalengine.NewExpressionEnv(ctx, bindVars, vcursor)
+	for i, lhsPartition := range lhsPartitions {
+		probe := newHashJoinProbe()
+		reader, err := lhsPartition.rewind()
+		if err != nil {
+			return err
+		}
+		if err := readSpilled(reader, func(row sqltypes.Row) error { return hj.addToProbe(probe, row) }); err != nil {
+			return err
+		}
+
+		reader, err = rhsPartitions[i].rewind()
+		if err != nil {
+			return err
+		}
+		err = readSpilled(reader, func(row sqltypes.Row) error { return hj.probeRow(env, probe, row, out) })
+		if err != nil {
+			return err
+		}
+		hj.addUnmatched(probe, out)
+		if err := out.flush(); err != nil {
+			return err
+		}
+	}
+	return out.finish()
+}
+
+// addToProbe adds a LHS row to the probe table. Rows with NULL join values can never match,
+// but they are still kept around so that left joins can return them.
+func (hj *HashJoin) addToProbe(probe *hashJoinProbe, row sqltypes.Row) error {
+	idx := len(probe.rows)
+	probe.rows = append(probe.rows, row)
+	probe.matched = append(probe.matched, false)
+
+	hashcode, isNull, err := hj.hashcode(row, hj.lhsKey)
+	if err != nil || isNull {
+		return err
+	}
+	probe.table[hashcode] = append(probe.table[hashcode], idx)
+	return nil
+}
+
+// probeRow joins a RHS row with all the matching rows of the probe table
+func (hj *HashJoin) probeRow(env *evalengine.ExpressionEnv, probe *hashJoinProbe, rrow sqltypes.Row, out *hashJoinOutput) error {
+	hashcode, isNull, err := hj.hashcode(rrow, hj.rhsKey)
+	if err != nil || isNull {
+		return err
+	}
+	for _, idx := range probe.table[hashcode] {
+		lrow := probe.rows[idx]
+		// hash codes can give false positives, so we need to check with a real comparison as well
+		match, err := hj.matches(env, lrow, rrow)
+		if err != nil {
+			return err
+		}
+		if match {
+			probe.matched[idx] = true
+			out.rows = append(out.rows, joinRows(lrow, rrow, hj.Cols))
+		}
+	}
+	return nil
+}
+
+// addUnmatched adds the LHS rows that did not match any RHS row to the output when doing a left join
+func (hj *HashJoin) addUnmatched(probe *hashJoinProbe, out *hashJoinOutput) {
+	if hj.Opcode != LeftJoin {
+		return
+	}
+	for idx, lrow := range probe.rows {
+		if !probe.matched[idx] {
+			out.rows = append(out.rows, joinRows(lrow, nil, hj.Cols))
+		}
+	}
+}
+
+func (hj *HashJoin) lhsKey(key HashJoinKey) int {
+	return key.LHS
+}
+
+func (hj *HashJoin) rhsKey(key HashJoinKey) int {
+	return key.RHS
+}
+
+// hashcode returns the combined hashcode of all the join columns of the row.
+// isNull is true if any of the join columns is NULL, in which case the row can not match anything.
+func (hj *HashJoin) hashcode(row sqltypes.Row, offset func(HashJoinKey) int) (code evalengine.HashCode, isNull bool, err error) {
+	return hashJoinKeysHashcode(hj.Keys, row, offset)
+}
+
+// matches returns true if the LHS and RHS rows have equal join columns and satisfy the filter
+func (hj *HashJoin) matches(env *evalengine.ExpressionEnv, lrow, rrow sqltypes.Row) (bool, error) {
+	equal, err := hashJoinKeysEqual(hj.Keys, lrow, rrow)
+	if err != nil || !equal {
+		return false, err
+	}
+	if hj.Filter == nil {
+		return true, nil
+	}
+	env.Row = joinRows(lrow, rrow, hj.FilterCols)
+	res, err := env.Evaluate(hj.Filter)
+	if err != nil {
+		return false, err
+	}
+	return res.ToBoolean(), nil
+}
+
+// hashJoinKeysHashcode returns the combined hashcode of the given join columns of the row.
+// isNull is true if any of the join columns is NULL, in which case the row can not match anything.
+func hashJoinKeysHashcode(keys []HashJoinKey, row sqltypes.Row, offset func(HashJoinKey) int) (code evalengine.HashCode, isNull bool, err error) {
+	code = evalengine.HashCode(17)
+	for _, key := range keys {
+		val := row[offset(key)]
+		if val.IsNull() {
+			return 0, true, nil
+		}
+		hashcode, err := evalengine.NullsafeHashcode(val, key.Collation, key.ComparisonType)
+		if err != nil {
+			return 0, false, err
+		}
+		code = code*31 + hashcode
+	}
+	return code, false, nil
+}
+
+// hashJoinKeysEqual returns true if the LHS and RHS rows have equal values in the given join columns
+func hashJoinKeysEqual(keys []HashJoinKey, lrow, rrow sqltypes.Row) (bool, error) {
+	for _, key := range keys {
+		cmp, err := evalengine.NullsafeCompare(lrow[key.LHS], rrow[key.RHS], key.Collation)
+		if err != nil {
+			return false, err
+		}
+		if cmp != 0 {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// spillRow writes the row to the partition chosen by the hashcode of its join columns
+func (hj *HashJoin) spillRow(partitions []*spillFile, row sqltypes.Row, offset func(HashJoinKey) int) error {
+	hashcode, _, err := hj.hashcode(row, offset)
+	if err != nil {
+		return err
+	}
+	return partitions[hashcode%hashJoinSpillPartitions].write(row)
+}
+
+func newHashJoinProbe() *hashJoinProbe {
+	return &hashJoinProbe{table: map[evalengine.HashCode][]int{}}
+}
+
+func newSpillPartitions(dir string) ([]*spillFile, error) {
+	partitions := make([]*spillFile, 0, hashJoinSpillPartitions)
+	for i := 0; i < hashJoinSpillPartitions; i++ {
+		partition, err := newSpillFile(dir)
+		if err != nil {
+			for _, partition := range partitions {
+				_ = partition.close()
 			}
+			return nil, err
 		}
-		if len(res.Rows) != 0 || len(res.Fields) != 0 {
-			return callback(res)
+		partitions = append(partitions, partition)
+	}
+	return partitions, nil
+}
+
+func readSpilled(reader *spillReader, f func(sqltypes.Row) error) error {
+	for {
+		row, err := reader.next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
 		}
+		if err := f(row); err != nil {
+			return err
+		}
+	}
+}
+
+// flush sends the rows collected so far to the callback.
+// The fields are sent along with the first result.
+func (out *hashJoinOutput) flush() error {
+	if len(out.rows) == 0 {
 		return nil
-	})
+	}
+	res := &sqltypes.Result{Rows: out.rows}
+	if !out.sent {
+		res.Fields = out.fields
+		out.sent = true
+	}
+	out.rows = nil
+	return out.callback(res)
+}
+
+// finish flushes the remaining rows, and makes sure the fields
+// are sent even when the join did not produce any rows.
+func (out *hashJoinOutput) finish() error {
+	if len(out.rows) == 0 && !out.sent && out.fields != nil {
+		out.sent = true
+		return out.callback(&sqltypes.Result{Fields: out.fields})
+	}
+	return out.flush()
 }
 
 // RouteType implements the Primitive interface
@@ -244,11 +513,17 @@ func (hj *HashJoin) description() PrimitiveDescription {
 		"TableName":         hj.GetTableName(),
 		"JoinColumnIndexes": strings.Trim(strings.Join(strings.Fields(fmt.Sprint(hj.Cols)), ","), "[]"),
 		"Predicate":         sqlparser.String(hj.ASTPred),
-		"ComparisonType":    hj.ComparisonType.String(),
 	}
-	coll := hj.Collation.Get()
-	if coll != nil {
-		other["Collation"] = coll.Name()
+	var comparisonTypes, collationNames []string
+	for _, key := range hj.Keys {
+		comparisonTypes = append(comparisonTypes, key.ComparisonType.String())
+		if coll := key.Collation.Get(); coll != nil {
+			collationNames = append(collationNames, coll.Name())
+		}
+	}
+	other["ComparisonType"] = strings.Join(comparisonTypes, ",")
+	if len(collationNames) > 0 {
+		other["Collation"] = strings.Join(collationNames, ",")
 	}
 	return PrimitiveDescription{
 		OperatorType: "Join",
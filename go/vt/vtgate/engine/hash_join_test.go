@@ -18,12 +18,17 @@ package engine
 
 import (
 	"context"
+	"os"
 	"testing"
 
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"vitess.io/vitess/go/mysql/collations"
 	"vitess.io/vitess/go/sqltypes"
 	querypb "vitess.io/vitess/go/vt/proto/query"
+	"vitess.io/vitess/go/vt/sqlparser"
+	"vitess.io/vitess/go/vt/vtgate/evalengine"
 )
 
 func TestHashJoinExecuteSameType(t *testing.T) {
@@ -61,8 +66,7 @@ func TestHashJoinExecuteSameType(t *testing.T) {
 		Left:   leftPrim,
 		Right:  rightPrim,
 		Cols:   []int{-1, -2, 1, 2},
-		LHSKey: 0,
-		RHSKey: 0,
+		Keys:   []HashJoinKey{{LHS: 0, RHS: 0}},
 	}
 	r, err := jn.TryExecute(context.Background(), &noopVCursor{}, map[string]*querypb.BindVariable{}, true)
 	require.NoError(t, err)
@@ -118,13 +122,11 @@ func TestHashJoinExecuteDifferentType(t *testing.T) {
 
 	// Normal join
 	jn := &HashJoin{
-		Opcode:         InnerJoin,
-		Left:           leftPrim,
-		Right:          rightPrim,
-		Cols:           []int{-1, -2, 1, 2},
-		LHSKey:         0,
-		RHSKey:         0,
-		ComparisonType: querypb.Type_FLOAT64,
+		Opcode: InnerJoin,
+		Left:   leftPrim,
+		Right:  rightPrim,
+		Cols:   []int{-1, -2, 1, 2},
+		Keys:   []HashJoinKey{{LHS: 0, RHS: 0, ComparisonType: querypb.Type_FLOAT64}},
 	}
 	r, err := jn.TryExecute(context.Background(), &noopVCursor{}, map[string]*querypb.BindVariable{}, true)
 	require.NoError(t, err)
@@ -145,3 +147,262 @@ func TestHashJoinExecuteDifferentType(t *testing.T) {
 		"5|c| 5.0toto|g",
 	))
 }
+
+func TestHashJoinLeftJoin(t *testing.T) {
+	leftPrim := &fakePrimitive{
+		results: []*sqltypes.Result{
+			sqltypes.MakeTestResult(
+				sqltypes.MakeTestFields(
+					"col1|col2",
+					"int64|varchar",
+				),
+				"1|a",
+				"2|b",
+				"null|c",
+				"3|d",
+			),
+		},
+	}
+	rightPrim := &fakePrimitive{
+		results: []*sqltypes.Result{
+			sqltypes.MakeTestResult(
+				sqltypes.MakeTestFields(
+					"col3|col4",
+					"int64|varchar",
+				),
+				"3|e",
+				"null|f",
+				"1|g",
+				"3|h",
+			),
+		},
+	}
+
+	jn := &HashJoin{
+		Opcode: LeftJoin,
+		Left:   leftPrim,
+		Right:  rightPrim,
+		Cols:   []int{-1, -2, 2},
+		Keys:   []HashJoinKey{{LHS: 0, RHS: 0}},
+	}
+	r, err := jn.TryExecute(context.Background(), &noopVCursor{}, map[string]*querypb.BindVariable{}, true)
+	require.NoError(t, err)
+	expectResult(t, "jn.Execute", r, sqltypes.MakeTestResult(
+		sqltypes.MakeTestFields(
+			"col1|col2|col4",
+			"int64|varchar|varchar",
+		),
+		"3|d|e",
+		"1|a|g",
+		"3|d|h",
+		"2|b|null",
+		"null|c|null",
+	))
+
+	leftPrim.rewind()
+	rightPrim.rewind()
+	r, err = wrapStreamExecute(jn, &noopVCursor{}, map[string]*querypb.BindVariable{}, true)
+	require.NoError(t, err)
+	expectResult(t, "jn.StreamExecute", r, sqltypes.MakeTestResult(
+		sqltypes.MakeTestFields(
+			"col1|col2|col4",
+			"int64|varchar|varchar",
+		),
+		"3|d|e",
+		"1|a|g",
+		"3|d|h",
+		"2|b|null",
+		"null|c|null",
+	))
+}
+
+func TestHashJoinMultipleKeysWithCollation(t *testing.T) {
+	leftPrim := &fakePrimitive{
+		results: []*sqltypes.Result{
+			sqltypes.MakeTestResult(
+				sqltypes.MakeTestFields(
+					"col1|col2|col3",
+					"int64|varchar|varchar",
+				),
+				"1|abc|x",
+				"1|def|y",
+				"2|abc|z",
+			),
+		},
+	}
+	rightPrim := &fakePrimitive{
+		results: []*sqltypes.Result{
+			sqltypes.MakeTestResult(
+				sqltypes.MakeTestFields(
+					"col4|col5|col6",
+					"varchar|int64|varchar",
+				),
+				"ABC|1|u",
+				"Def|2|v",
+				"abc|2|w",
+			),
+		},
+	}
+
+	jn := &HashJoin{
+		Opcode: InnerJoin,
+		Left:   leftPrim,
+		Right:  rightPrim,
+		Cols:   []int{-1, -2, -3, 3},
+		Keys: []HashJoinKey{
+			{LHS: 0, RHS: 1, ComparisonType: querypb.Type_INT64},
+			{LHS: 1, RHS: 0, Collation: collations.CollationUtf8mb4ID, ComparisonType: querypb.Type_VARCHAR},
+		},
+	}
+	r, err := jn.TryExecute(context.Background(), &noopVCursor{}, map[string]*querypb.BindVariable{}, true)
+	require.NoError(t, err)
+	expectResult(t, "jn.Execute", r, sqltypes.MakeTestResult(
+		sqltypes.MakeTestFields(
+			"col1|col2|col3|col6",
+			"int64|varchar|varchar|varchar",
+		),
+		"1|abc|x|u",
+		"2|abc|z|w",
+	))
+}
+
+func TestHashJoinFilter(t *testing.T) {
+	leftPrim := &fakePrimitive{
+		results: []*sqltypes.Result{
+			sqltypes.MakeTestResult(
+				sqltypes.MakeTestFields(
+					"col1|col2",
+					"int64|int64",
+				),
+				"1|10",
+				"2|20",
+			),
+		},
+	}
+	rightPrim := &fakePrimitive{
+		results: []*sqltypes.Result{
+			sqltypes.MakeTestResult(
+				sqltypes.MakeTestFields(
+					"col3|col4",
+					"int64|int64",
+				),
+				"1|5",
+				"1|15",
+				"2|5",
+			),
+		},
+	}
+
+	// the filter is evaluated against the row built from FilterCols: col2, col4
+	filterCols := []int{-2, 2}
+	pred, err := evalengine.Translate(&sqlparser.ComparisonExpr{
+		Operator: sqlparser.GreaterThanOp,
+		Left:     sqlparser.NewColName("col4"),
+		Right:    sqlparser.NewColName("col2"),
+	}, &evalengine.Config{
+		ResolveColumn: evalengine.FieldResolver(sqltypes.MakeTestFields("col2|col4", "int64|int64")).Column,
+	})
+	require.NoError(t, err)
+
+	jn := &HashJoin{
+		Opcode:     LeftJoin,
+		Left:       leftPrim,
+		Right:      rightPrim,
+		Cols:       []int{-1, -2, 2},
+		Keys:       []HashJoinKey{{LHS: 0, RHS: 0}},
+		Filter:     pred,
+		FilterCols: filterCols,
+	}
+	r, err := jn.TryExecute(context.Background(), &noopVCursor{}, map[string]*querypb.BindVariable{}, true)
+	require.NoError(t, err)
+	expectResult(t, "jn.Execute", r, sqltypes.MakeTestResult(
+		sqltypes.MakeTestFields(
+			"col1|col2|col4",
+			"int64|int64|int64",
+		),
+		"1|10|15",
+		"2|20|null",
+	))
+}
+
+func TestHashJoinMaxMemoryRows(t *testing.T) {
+	saveMax := testMaxMemoryRows
+	saveSpillDir := testSpillDir
+	testMaxMemoryRows = 2
+	defer func() {
+		testMaxMemoryRows = saveMax
+		testSpillDir = saveSpillDir
+	}()
+
+	newPrims := func() (*fakePrimitive, *fakePrimitive) {
+		leftPrim := &fakePrimitive{
+			results: []*sqltypes.Result{
+				sqltypes.MakeTestResult(
+					sqltypes.MakeTestFields(
+						"col1|col2",
+						"int64|varchar",
+					),
+					"1|a",
+					"2|b",
+					"3|c",
+					"null|d",
+					"5|e",
+				),
+			},
+		}
+		rightPrim := &fakePrimitive{
+			results: []*sqltypes.Result{
+				sqltypes.MakeTestResult(
+					sqltypes.MakeTestFields(
+						"col3|col4",
+						"int64|varchar",
+					),
+					"3|f",
+					"1|g",
+					"4|h",
+					"3|i",
+				),
+			},
+		}
+		return leftPrim, rightPrim
+	}
+
+	leftPrim, rightPrim := newPrims()
+	jn := &HashJoin{
+		Opcode: LeftJoin,
+		Left:   leftPrim,
+		Right:  rightPrim,
+		Cols:   []int{-1, -2, 2},
+		Keys:   []HashJoinKey{{LHS: 0, RHS: 0}},
+	}
+	_, err := wrapStreamExecute(jn, &noopVCursor{}, map[string]*querypb.BindVariable{}, true)
+	require.EqualError(t, err, "in-memory row count exceeded allowed limit of 2")
+
+	testSpillDir = t.TempDir()
+	for _, streaming := range []bool{false, true} {
+		leftPrim, rightPrim = newPrims()
+		jn.Left, jn.Right = leftPrim, rightPrim
+
+		var r *sqltypes.Result
+		if streaming {
+			r, err = wrapStreamExecute(jn, &noopVCursor{}, map[string]*querypb.BindVariable{}, true)
+		} else {
+			r, err = jn.TryExecute(context.Background(), &noopVCursor{}, map[string]*querypb.BindVariable{}, true)
+		}
+		require.NoError(t, err)
+		assert.Equal(t, sqltypes.MakeTestFields("col1|col2|col4", "int64|varchar|varchar"), r.Fields)
+		// the spilled partitions are joined one by one, so the order of the rows is not preserved
+		assert.ElementsMatch(t, sqltypes.MakeTestResult(r.Fields,
+			"1|a|g",
+			"2|b|null",
+			"3|c|f",
+			"3|c|i",
+			"null|d|null",
+			"5|e|null",
+		).Rows, r.Rows)
+
+		files, err := os.ReadDir(testSpillDir)
+		require.NoError(t, err)
+		assert.Empty(t, files, "spill files should be removed")
+	}
+}
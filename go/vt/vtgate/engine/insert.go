@@ -25,7 +25,10 @@ import (
 	"sync"
 	"time"
 
+	"golang.org/x/exp/slices"
+
 	"vitess.io/vitess/go/sqltypes"
+	"vitess.io/vitess/go/stats"
 	"vitess.io/vitess/go/vt/key"
 	querypb "vitess.io/vitess/go/vt/proto/query"
 	topodatapb "vitess.io/vitess/go/vt/proto/topodata"
@@ -39,6 +42,11 @@ import (
 
 var _ Primitive = (*Insert)(nil)
 
+var (
+	insertSelectRows    = stats.NewCountersWithSingleLabel("InsertSelectRows", "Number of rows inserted by streamed INSERT ... SELECT statements", "Table")
+	insertSelectBatches = stats.NewCountersWithSingleLabel("InsertSelectBatches", "Number of batches inserted by streamed INSERT ... SELECT statements", "Table")
+)
+
 type (
 	// Insert represents the instructions to perform an insert operation.
 	Insert struct {
@@ -49,6 +57,27 @@ type (
 		// for sharded cases.
 		Ignore bool
 
+		// Replace is for REPLACE statements in sharded cases. The rows being
+		// replaced are the existing rows that conflict with the new ones on the
+		// primary key or a unique key of the table, and the owned lookup vindex
+		// entries of the columns they change are deleted before the new ones are
+		// created.
+		Replace bool
+
+		// ReplaceKeys are the primary and unique keys of the table, with each
+		// column located in ColVindexes or in ReplaceColumns. They are only set
+		// for a REPLACE into a table with owned lookup vindexes, to find the rows
+		// it overwrites.
+		ReplaceKeys [][]VindexColumn
+
+		// ReplaceColumns are the columns of ReplaceKeys that are not vindex columns.
+		// ReplaceValues[i][j] is the value of column i for row j when the values are
+		// provided, and ReplaceValueOffset[i] the offset of column i in the rows of
+		// the select query otherwise.
+		ReplaceColumns     []sqlparser.IdentifierCI
+		ReplaceValues      [][]evalengine.Expr
+		ReplaceValueOffset []int
+
 		// Keyspace specifies the keyspace to send the query to.
 		Keyspace *vindexes.Keyspace
 
@@ -101,11 +130,22 @@ type (
 		// This will avoid locking by the select table.
 		ForceNonStreaming bool
 
+		// BatchSize is the number of rows of the select query that are inserted at a time.
+		// When set, the select query is always streamed and its rows are inserted in batches,
+		// instead of loading the whole result of the select query into memory first.
+		BatchSize int
+
 		// Insert needs tx handling
 		txNeeded
 	}
 
 	ksID = []byte
+
+	// VindexColumn is the position of a column in the column vindexes of an Insert.
+	// A VindexIdx of -1 refers to the ReplaceColumns of the Insert instead.
+	VindexColumn struct {
+		VindexIdx, ColumnIdx int
+	}
 )
 
 func (ins *Insert) Inputs() []Primitive {
@@ -228,6 +268,10 @@ func (ins *Insert) TryExecute(ctx context.Context, vcursor VCursor, bindVars map
 	ctx, cancelFunc := addQueryTimeout(ctx, vcursor, ins.QueryTimeout)
 	defer cancelFunc()
 
+	if ins.BatchSize > 0 && ins.Input != nil && !ins.ForceNonStreaming {
+		return ins.insertFromStream(ctx, vcursor, bindVars)
+	}
+
 	switch ins.Opcode {
 	case InsertUnsharded:
 		return ins.execInsertUnsharded(ctx, vcursor, bindVars)
@@ -256,21 +300,25 @@ func (ins *Insert) TryStreamExecute(ctx context.Context, vcursor VCursor, bindVa
 		defer cancel()
 	}
 
+	output, err := ins.insertFromStream(ctx, vcursor, bindVars)
+	if err != nil {
+		return err
+	}
+	return callback(output)
+}
+
+// insertFromStream streams the rows of the select query and inserts them as they arrive.
+// When BatchSize is set, the rows are buffered until a full batch is available, so that
+// every batch, except for the last one, inserts exactly BatchSize rows.
+func (ins *Insert) insertFromStream(ctx context.Context, vcursor VCursor, bindVars map[string]*querypb.BindVariable) (*sqltypes.Result, error) {
 	unsharded := ins.Opcode == InsertUnsharded
+	statsKey := ins.GetKeyspaceName() + "." + ins.GetTableName()
 	var mu sync.Mutex
+	var pending []sqltypes.Row
 	output := &sqltypes.Result{}
 
-	err := vcursor.StreamExecutePrimitiveStandalone(ctx, ins.Input, bindVars, false, func(result *sqltypes.Result) error {
-		if len(result.Rows) == 0 {
-			return nil
-		}
-
-		// should process only one chunk at a time.
-		// as parallel chunk insert will try to use the same transaction in the vttablet
-		// this will cause transaction in use error.
-		mu.Lock()
-		defer mu.Unlock()
-
+	insertRows := func(rows []sqltypes.Row) error {
+		result := &sqltypes.Result{Rows: rows}
 		var insertID int64
 		var qr *sqltypes.Result
 		var err error
@@ -288,12 +336,43 @@ func (ins *Insert) TryStreamExecute(ctx context.Context, vcursor VCursor, bindVa
 		if output.InsertID == 0 || output.InsertID > uint64(insertID) {
 			output.InsertID = uint64(insertID)
 		}
+		insertSelectRows.Add(statsKey, int64(len(rows)))
+		insertSelectBatches.Add(statsKey, 1)
+		return nil
+	}
+
+	err := vcursor.StreamExecutePrimitiveStandalone(ctx, ins.Input, bindVars, false, func(result *sqltypes.Result) error {
+		if len(result.Rows) == 0 {
+			return nil
+		}
+
+		// should process only one chunk at a time.
+		// as parallel chunk insert will try to use the same transaction in the vttablet
+		// this will cause transaction in use error.
+		mu.Lock()
+		defer mu.Unlock()
+
+		if ins.BatchSize <= 0 {
+			return insertRows(result.Rows)
+		}
+		pending = append(pending, result.Rows...)
+		for len(pending) >= ins.BatchSize {
+			if err := insertRows(pending[:ins.BatchSize]); err != nil {
+				return err
+			}
+			pending = pending[ins.BatchSize:]
+		}
 		return nil
 	})
 	if err != nil {
-		return err
+		return nil, err
 	}
-	return callback(output)
+	if len(pending) > 0 {
+		if err := insertRows(pending); err != nil {
+			return nil, err
+		}
+	}
+	return output, nil
 }
 
 func (ins *Insert) insertIntoShardedTable(ctx context.Context, vcursor VCursor, bindVars map[string]*querypb.BindVariable, result *sqltypes.Result) (int64, *sqltypes.Result, error) {
@@ -417,17 +496,33 @@ func (ins *Insert) getInsertSelectQueries(
 			shardingCols[colIdx] = append(shardingCols[colIdx], row)
 		}
 	}
+	var replaceCols []sqltypes.Row
+	if len(ins.ReplaceValueOffset) > 0 {
+		replaceCols = make([]sqltypes.Row, 0, len(rows))
+		for _, inputRow := range rows {
+			row := make(sqltypes.Row, 0, len(ins.ReplaceValueOffset))
+			for _, offset := range ins.ReplaceValueOffset {
+				row = append(row, inputRow[offset])
+			}
+			replaceCols = append(replaceCols, row)
+		}
+	}
 
 	keyspaceIDs, err := ins.processPrimary(ctx, vcursor, shardingCols[0], colVindexes[0])
 	if err != nil {
 		return nil, nil, err
 	}
 
+	skip, err := ins.processReplaced(ctx, vcursor, colVindexes, shardingCols, replaceCols, keyspaceIDs)
+	if err != nil {
+		return nil, nil, err
+	}
+
 	for vIdx := 1; vIdx < len(colVindexes); vIdx++ {
 		colVindex := colVindexes[vIdx]
 		var err error
 		if colVindex.Owned {
-			err = ins.processOwned(ctx, vcursor, shardingCols[vIdx], colVindex, keyspaceIDs)
+			err = ins.processOwned(ctx, vcursor, shardingCols[vIdx], colVindex, keyspaceIDs, skip[vIdx])
 		} else {
 			err = ins.processUnowned(ctx, vcursor, shardingCols[vIdx], colVindex, keyspaceIDs)
 		}
@@ -701,6 +796,25 @@ func (ins *Insert) getInsertShardedRoute(
 		}
 	}
 
+	// replaceRowsValues holds the values of the ReplaceColumns, transposed
+	// like vindexRowsValues: the indexes are row, col.
+	var replaceRowsValues []sqltypes.Row
+	if len(ins.ReplaceValues) > 0 {
+		replaceRowsValues = make([]sqltypes.Row, rowCount)
+	}
+	for _, colValues := range ins.ReplaceValues {
+		if len(colValues) != rowCount {
+			return nil, nil, vterrors.Errorf(vtrpcpb.Code_INTERNAL, "[BUG] uneven row values for replace: %d %d", rowCount, len(colValues))
+		}
+		for rowNum, colValue := range colValues {
+			result, err := env.Evaluate(colValue)
+			if err != nil {
+				return nil, nil, err
+			}
+			replaceRowsValues[rowNum] = append(replaceRowsValues[rowNum], result.Value())
+		}
+	}
+
 	// The output from the following 'process' functions is a list of
 	// keyspace ids. For regular inserts, a failure to find a route
 	// results in an error. For 'ignore' type inserts, the keyspace
@@ -713,11 +827,16 @@ func (ins *Insert) getInsertShardedRoute(
 		return nil, nil, err
 	}
 
+	skip, err := ins.processReplaced(ctx, vcursor, colVindexes, vindexRowsValues, replaceRowsValues, keyspaceIDs)
+	if err != nil {
+		return nil, nil, err
+	}
+
 	for vIdx := 1; vIdx < len(colVindexes); vIdx++ {
 		colVindex := colVindexes[vIdx]
 		var err error
 		if colVindex.Owned {
-			err = ins.processOwned(ctx, vcursor, vindexRowsValues[vIdx], colVindex, keyspaceIDs)
+			err = ins.processOwned(ctx, vcursor, vindexRowsValues[vIdx], colVindex, keyspaceIDs, skip[vIdx])
 		} else {
 			err = ins.processUnowned(ctx, vcursor, vindexRowsValues[vIdx], colVindex, keyspaceIDs)
 		}
@@ -741,6 +860,15 @@ func (ins *Insert) getInsertShardedRoute(
 			}
 		}
 	}
+	for rowNum, rowColumnValues := range replaceRowsValues {
+		if keyspaceIDs[rowNum] == nil {
+			continue
+		}
+		for colIdx, value := range rowColumnValues {
+			name := InsertVarName(ins.ReplaceColumns[colIdx], rowNum)
+			bindVars[name] = sqltypes.ValueBindVariable(value)
+		}
+	}
 
 	// We need to know the keyspace ids and the Mids associated with
 	// each RSS.  So we pass the ksid indexes in as ids, and get them back
@@ -811,9 +939,261 @@ func (ins *Insert) processPrimary(ctx context.Context, vcursor VCursor, vindexCo
 	return keyspaceIDs, nil
 }
 
+// processReplaced deletes the owned lookup vindex entries of the existing rows
+// that a REPLACE statement is going to overwrite, for every owned column whose
+// value changes. Like MySQL, a new row overwrites the rows of its shard that have
+// the same values for the primary key or for any unique key of the table, which
+// includes the new rows that come before it in the same statement. The returned
+// slices mark, per column vindex, the rows whose entries must not be created:
+// either they are already in place, or the row is overwritten by a later one.
+func (ins *Insert) processReplaced(ctx context.Context, vcursor VCursor, colVindexes []*vindexes.ColumnVindex, vindexRowsValues [][]sqltypes.Row, replaceRowsValues []sqltypes.Row, ksids []ksID) ([][]bool, error) {
+	skip := make([][]bool, len(colVindexes))
+	if !ins.Replace || len(ins.ReplaceKeys) == 0 {
+		return skip, nil
+	}
+	var owned []int
+	for vIdx := 1; vIdx < len(colVindexes); vIdx++ {
+		if colVindexes[vIdx].Owned {
+			owned = append(owned, vIdx)
+		}
+	}
+	if len(owned) == 0 {
+		return skip, nil
+	}
+	skipRow := func(vIdx, rowNum int) {
+		if skip[vIdx] == nil {
+			skip[vIdx] = make([]bool, len(ksids))
+		}
+		skip[vIdx][rowNum] = true
+	}
+
+	var indexes []*querypb.Value
+	var destinations []key.Destination
+	for i, ksid := range ksids {
+		if ksid != nil {
+			indexes = append(indexes, &querypb.Value{
+				Value: strconv.AppendInt(nil, int64(i), 10),
+			})
+			destinations = append(destinations, key.DestinationKeyspaceID(ksid))
+		}
+	}
+	if len(destinations) == 0 {
+		return skip, nil
+	}
+	rss, indexesPerRss, err := vcursor.ResolveDestinations(ctx, ins.Keyspace.Name, indexes, destinations)
+	if err != nil {
+		return nil, err
+	}
+
+	// Every column that is needed is selected once: the key columns,
+	// the primary vindex columns and the owned columns.
+	var cols []VindexColumn
+	offsetOf := func(col VindexColumn) int {
+		offset := slices.Index(cols, col)
+		if offset == -1 {
+			offset = len(cols)
+			cols = append(cols, col)
+		}
+		return offset
+	}
+	vindexOffsets := func(vIdx int) []int {
+		offsets := make([]int, 0, len(colVindexes[vIdx].Columns))
+		for colIdx := range colVindexes[vIdx].Columns {
+			offsets = append(offsets, offsetOf(VindexColumn{VindexIdx: vIdx, ColumnIdx: colIdx}))
+		}
+		return offsets
+	}
+	keyOffsets := make([][]int, 0, len(ins.ReplaceKeys))
+	for _, uk := range ins.ReplaceKeys {
+		offsets := make([]int, 0, len(uk))
+		for _, col := range uk {
+			offsets = append(offsets, offsetOf(col))
+		}
+		keyOffsets = append(keyOffsets, offsets)
+	}
+	primaryOffsets := vindexOffsets(0)
+	ownedOffsets := make([][]int, len(colVindexes))
+	for _, vIdx := range owned {
+		ownedOffsets[vIdx] = vindexOffsets(vIdx)
+	}
+	colNames := make([]*sqlparser.ColName, 0, len(cols))
+	selectExprs := make(sqlparser.SelectExprs, 0, len(cols))
+	for _, col := range cols {
+		var name sqlparser.IdentifierCI
+		if col.VindexIdx >= 0 {
+			name = colVindexes[col.VindexIdx].Columns[col.ColumnIdx]
+		} else {
+			name = ins.ReplaceColumns[col.ColumnIdx]
+		}
+		colName := sqlparser.NewColName(name.String())
+		colNames = append(colNames, colName)
+		selectExprs = append(selectExprs, &sqlparser.AliasedExpr{Expr: colName})
+	}
+	newValues := func(rowNum int, offsets []int) []sqltypes.Value {
+		values := make([]sqltypes.Value, 0, len(offsets))
+		for _, offset := range offsets {
+			col := cols[offset]
+			if col.VindexIdx >= 0 {
+				values = append(values, vindexRowsValues[col.VindexIdx][rowNum][col.ColumnIdx])
+			} else {
+				values = append(values, replaceRowsValues[rowNum][col.ColumnIdx])
+			}
+		}
+		return values
+	}
+	oldValues := func(row sqltypes.Row, offsets []int) []sqltypes.Value {
+		values := make([]sqltypes.Value, 0, len(offsets))
+		for _, offset := range offsets {
+			values = append(values, row[offset])
+		}
+		return values
+	}
+
+	overwritten := make([]bool, len(ksids))
+	for i, rs := range rss {
+		var rowNums []int
+		for _, indexValue := range indexesPerRss[i] {
+			rowNum, _ := strconv.Atoi(string(indexValue.Value))
+			rowNums = append(rowNums, rowNum)
+		}
+
+		// A new row is overwritten when a later row of the same shard conflicts with it.
+		later := make(map[string]bool)
+		for j := len(rowNums) - 1; j >= 0; j-- {
+			rowNum := rowNums[j]
+			var keys []string
+			for k, offsets := range keyOffsets {
+				values := newValues(rowNum, offsets)
+				if slices.ContainsFunc(values, sqltypes.Value.IsNull) {
+					continue
+				}
+				key := strconv.Itoa(k) + ":" + replaceKey(values)
+				overwritten[rowNum] = overwritten[rowNum] || later[key]
+				keys = append(keys, key)
+			}
+			for _, key := range keys {
+				later[key] = true
+			}
+			if overwritten[rowNum] {
+				for _, vIdx := range owned {
+					skipRow(vIdx, rowNum)
+				}
+			}
+		}
+
+		bvs := make(map[string]*querypb.BindVariable)
+		var where sqlparser.Expr
+		for _, rowNum := range rowNums {
+			for _, offsets := range keyOffsets {
+				values := newValues(rowNum, offsets)
+				if slices.ContainsFunc(values, sqltypes.Value.IsNull) {
+					// NULL never conflicts.
+					continue
+				}
+				var eqs []sqlparser.Expr
+				for j, offset := range offsets {
+					bvName := replaceVarName(rowNum, offset)
+					bvs[bvName] = sqltypes.ValueBindVariable(values[j])
+					eqs = append(eqs, sqlparser.NewComparisonExpr(sqlparser.EqualOp, colNames[offset], sqlparser.NewArgument(bvName), nil))
+				}
+				if where == nil {
+					where = sqlparser.AndExpressions(eqs...)
+				} else {
+					where = &sqlparser.OrExpr{Left: where, Right: sqlparser.AndExpressions(eqs...)}
+				}
+			}
+		}
+		if where == nil {
+			continue
+		}
+		sel := &sqlparser.Select{
+			SelectExprs: selectExprs,
+			From:        sqlparser.TableExprs{sqlparser.NewAliasedTableExpr(sqlparser.NewTableName(ins.Table.Name.String()), "")},
+			Where:       sqlparser.NewWhere(sqlparser.WhereClause, where),
+			Lock:        sqlparser.ForUpdateLock,
+		}
+		query := &querypb.BoundQuery{
+			Sql:           sqlparser.String(sel),
+			BindVariables: bvs,
+		}
+		result, errs := vcursor.ExecuteMultiShard(ctx, ins, []*srvtopo.ResolvedShard{rs}, []*querypb.BoundQuery{query}, false /* rollbackOnError */, false /* canAutocommit */)
+		if errs != nil {
+			return nil, vterrors.Aggregate(errs)
+		}
+
+		for _, old := range result.Rows {
+			// The existing row is overwritten by the first new row it conflicts with.
+			rowNum := slices.IndexFunc(rowNums, func(candidate int) bool {
+				return slices.ContainsFunc(keyOffsets, func(offsets []int) bool {
+					return replaceKey(oldValues(old, offsets)) == replaceKey(newValues(candidate, offsets))
+				})
+			})
+			if rowNum == -1 {
+				continue
+			}
+			rowNum = rowNums[rowNum]
+
+			// The entries of the existing row point to its own keyspace id,
+			// which is the one of the new row only if they have the same
+			// primary vindex values.
+			oldKsid := ksids[rowNum]
+			samePrimary := replaceKey(oldValues(old, primaryOffsets)) == replaceKey(vindexRowsValues[0][rowNum])
+			if !samePrimary {
+				oldKsids, err := ins.processPrimary(ctx, vcursor, []sqltypes.Row{oldValues(old, primaryOffsets)}, colVindexes[0])
+				if err != nil {
+					return nil, err
+				}
+				oldKsid = oldKsids[0]
+			}
+			for _, vIdx := range owned {
+				fromIds := oldValues(old, ownedOffsets[vIdx])
+				// The entries can only be kept when the new row is not overwritten in turn.
+				if samePrimary && !overwritten[rowNum] && replaceKey(fromIds) == replaceKey(vindexRowsValues[vIdx][rowNum]) {
+					skipRow(vIdx, rowNum)
+					continue
+				}
+				if err := colVindexes[vIdx].Vindex.(vindexes.Lookup).Delete(ctx, vcursor, [][]sqltypes.Value{fromIds}, oldKsid); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+	return skip, nil
+}
+
+// replaceKey returns a key for comparing the values of the given columns
+// regardless of their types.
+func replaceKey(values []sqltypes.Value) string {
+	var sb strings.Builder
+	for _, v := range values {
+		if v.IsNull() {
+			sb.WriteString("\\N")
+		} else {
+			sb.WriteString(strconv.Quote(v.ToString()))
+		}
+		sb.WriteByte(',')
+	}
+	return sb.String()
+}
+
 // processOwned creates vindex entries for the values of an owned column.
-func (ins *Insert) processOwned(ctx context.Context, vcursor VCursor, vindexColumnsKeys []sqltypes.Row, colVindex *vindexes.ColumnVindex, ksids []ksID) error {
+// Rows marked in skip are left out.
+func (ins *Insert) processOwned(ctx context.Context, vcursor VCursor, vindexColumnsKeys []sqltypes.Row, colVindex *vindexes.ColumnVindex, ksids []ksID, skip []bool) error {
 	if !ins.Ignore {
+		if skip != nil {
+			var createKeys []sqltypes.Row
+			var createKsids []ksID
+			for rowNum, rowColumnKeys := range vindexColumnsKeys {
+				if !skip[rowNum] {
+					createKeys = append(createKeys, rowColumnKeys)
+					createKsids = append(createKsids, ksids[rowNum])
+				}
+			}
+			if createKeys == nil {
+				return nil
+			}
+			vindexColumnsKeys, ksids = createKeys, createKsids
+		}
 		return colVindex.Vindex.(vindexes.Lookup).Create(ctx, vcursor, vindexColumnsKeys, ksids, false /* ignoreMode */)
 	}
 
@@ -943,6 +1323,10 @@ func insertVarOffset(rowNum, colOffset int) string {
 	return fmt.Sprintf("_c%d_%d", rowNum, colOffset)
 }
 
+func replaceVarName(rowNum, colOffset int) string {
+	return fmt.Sprintf("_r%d_%d", rowNum, colOffset)
+}
+
 func (ins *Insert) description() PrimitiveDescription {
 	other := map[string]any{
 		"Query":                ins.Query,
@@ -950,7 +1334,49 @@ func (ins *Insert) description() PrimitiveDescription {
 		"MultiShardAutocommit": ins.MultiShardAutocommit,
 		"QueryTimeout":         ins.QueryTimeout,
 		"InsertIgnore":         ins.Ignore,
+		"Replace":              ins.Replace,
 		"InputAsNonStreaming":  ins.ForceNonStreaming,
+		"BatchSize":            ins.BatchSize,
+	}
+
+	if len(ins.ReplaceKeys) > 0 {
+		colVindexes := ins.ColVindexes
+		if colVindexes == nil {
+			colVindexes = ins.Table.ColumnVindexes
+		}
+		var keys []string
+		for _, key := range ins.ReplaceKeys {
+			var cols []string
+			for _, col := range key {
+				if col.VindexIdx < 0 {
+					cols = append(cols, ins.ReplaceColumns[col.ColumnIdx].String())
+					continue
+				}
+				cols = append(cols, colVindexes[col.VindexIdx].Columns[col.ColumnIdx].String())
+			}
+			keys = append(keys, strings.Join(cols, ", "))
+		}
+		other["ReplaceKeys"] = keys
+	}
+
+	if len(ins.ReplaceValues) > 0 {
+		values := map[string]string{}
+		for idx, exprs := range ins.ReplaceValues {
+			var this []string
+			for _, expr := range exprs {
+				this = append(this, evalengine.FormatExpr(expr))
+			}
+			values[ins.ReplaceColumns[idx].String()] = strings.Join(this, ", ")
+		}
+		other["ReplaceValues"] = values
+	}
+
+	if len(ins.ReplaceValueOffset) > 0 {
+		offsets := map[string]int{}
+		for idx, offset := range ins.ReplaceValueOffset {
+			offsets[ins.ReplaceColumns[idx].String()] = offset
+		}
+		other["ReplaceOffsetFromSelect"] = offsets
 	}
 
 	if len(ins.VindexValues) > 0 {
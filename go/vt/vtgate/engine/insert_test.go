@@ -21,7 +21,9 @@ import (
 	"errors"
 	"testing"
 
+	"vitess.io/vitess/go/mysql/collations"
 	topodatapb "vitess.io/vitess/go/vt/proto/topodata"
+	"vitess.io/vitess/go/vt/sqlparser"
 
 	"vitess.io/vitess/go/vt/vtgate/evalengine"
 
@@ -644,6 +646,266 @@ func TestInsertShardedOwnedWithNull(t *testing.T) {
 	})
 }
 
+// newReplaceOwnedTestInsert returns a REPLACE of the rows (1, 10), (2, 11) and
+// (3, 12) into t1(id, c3), where id has a hash vindex and c3 an owned lookup.
+func newReplaceOwnedTestInsert() *Insert {
+	invschema := &vschemapb.SrvVSchema{
+		Keyspaces: map[string]*vschemapb.Keyspace{
+			"sharded": {
+				Sharded: true,
+				Vindexes: map[string]*vschemapb.Vindex{
+					"hash": {
+						Type: "hash",
+					},
+					"onecol": {
+						Type: "lookup",
+						Params: map[string]string{
+							"table": "lkp1",
+							"from":  "from",
+							"to":    "toc",
+						},
+						Owner: "t1",
+					},
+				},
+				Tables: map[string]*vschemapb.Table{
+					"t1": {
+						ColumnVindexes: []*vschemapb.ColumnVindex{{
+							Name:    "hash",
+							Columns: []string{"id"},
+						}, {
+							Name:    "onecol",
+							Columns: []string{"c3"},
+						}},
+					},
+				},
+			},
+		},
+	}
+	vs := vindexes.BuildVSchema(invschema)
+	ks := vs.Keyspaces["sharded"]
+
+	ins := NewInsert(
+		InsertSharded,
+		false,
+		ks.Keyspace,
+		[][][]evalengine.Expr{{
+			// colVindex columns: id
+			{
+				// rows for id
+				evalengine.NewLiteralInt(1),
+				evalengine.NewLiteralInt(2),
+				evalengine.NewLiteralInt(3),
+			},
+		}, {
+			// colVindex columns: c3
+			{
+				evalengine.NewLiteralInt(10),
+				evalengine.NewLiteralInt(11),
+				evalengine.NewLiteralInt(12),
+			},
+		}},
+		ks.Tables["t1"],
+		"prefix",
+		[]string{" mid1", " mid2", " mid3"},
+		" suffix",
+	)
+	ins.Replace = true
+	return ins
+}
+
+func TestInsertShardedReplaceOwned(t *testing.T) {
+	ins := newReplaceOwnedTestInsert()
+	// The primary key is id.
+	ins.ReplaceKeys = [][]VindexColumn{{{VindexIdx: 0, ColumnIdx: 0}}}
+
+	vc := newDMLTestVCursor("-20", "20-")
+	// The keyspace ids are resolved twice: once to fetch the rows being
+	// replaced, and once to send the REPLACE itself.
+	vc.shardForKsid = []string{"20-", "-20", "20-", "20-", "-20", "20-"}
+	// Row 1 is replacing a row with a different c3, row 2 one with the same
+	// c3 and row 3 is a new row.
+	vc.results = []*sqltypes.Result{
+		sqltypes.MakeTestResult(
+			sqltypes.MakeTestFields("id|c3", "int64|int64"),
+			"1|5",
+		),
+		// Result of the lookup delete.
+		{},
+		sqltypes.MakeTestResult(
+			sqltypes.MakeTestFields("id|c3", "int64|int64"),
+			"2|11",
+		),
+	}
+
+	_, err := ins.TryExecute(context.Background(), vc, map[string]*querypb.BindVariable{}, false)
+	require.NoError(t, err)
+	vc.ExpectLog(t, []string{
+		`ResolveDestinations sharded [value:"0" value:"1" value:"2"] Destinations:DestinationKeyspaceID(166b40b44aba4bd6),DestinationKeyspaceID(06e7ea22ce92708f),DestinationKeyspaceID(4eb190c9a2fa169c)`,
+		`ExecuteMultiShard sharded.20-: select id, c3 from t1 where id = :_r0_0 or id = :_r2_0 for update {_r0_0: type:INT64 value:"1" _r2_0: type:INT64 value:"3"} false false`,
+		`Execute delete from lkp1 where from = :from and toc = :toc ` +
+			`from: type:INT64 value:"5" toc: type:VARBINARY value:"\x16k@\xb4J\xbaK\xd6" true`,
+		`ExecuteMultiShard sharded.-20: select id, c3 from t1 where id = :_r1_0 for update {_r1_0: type:INT64 value:"2"} false false`,
+		`Execute insert into lkp1(from, toc) values(:from_0, :toc_0), (:from_1, :toc_1) ` +
+			`from_0: type:INT64 value:"10" from_1: type:INT64 value:"12" ` +
+			`toc_0: type:VARBINARY value:"\x16k@\xb4J\xbaK\xd6" toc_1: type:VARBINARY value:"N\xb1\x90ɢ\xfa\x16\x9c" true`,
+		// Based on shardForKsid, values returned will be 20-, -20, 20-.
+		`ResolveDestinations sharded [value:"0" value:"1" value:"2"] Destinations:DestinationKeyspaceID(166b40b44aba4bd6),DestinationKeyspaceID(06e7ea22ce92708f),DestinationKeyspaceID(4eb190c9a2fa169c)`,
+		`ExecuteMultiShard ` +
+			`sharded.20-: prefix mid1, mid3 suffix ` +
+			`{_c3_0: type:INT64 value:"10" _c3_1: type:INT64 value:"11" _c3_2: type:INT64 value:"12" ` +
+			`_id_0: type:INT64 value:"1" _id_1: type:INT64 value:"2" _id_2: type:INT64 value:"3"} ` +
+			`sharded.-20: prefix mid2 suffix ` +
+			`{_c3_0: type:INT64 value:"10" _c3_1: type:INT64 value:"11" _c3_2: type:INT64 value:"12" ` +
+			`_id_0: type:INT64 value:"1" _id_1: type:INT64 value:"2" _id_2: type:INT64 value:"3"} ` +
+			`true false`,
+	})
+}
+
+func TestInsertShardedReplaceOwnedUniqueKey(t *testing.T) {
+	ins := newReplaceOwnedTestInsert()
+	// The primary key is (id, c3), so id alone is not unique, and c3 is also
+	// a unique key.
+	ins.ReplaceKeys = [][]VindexColumn{
+		{{VindexIdx: 0, ColumnIdx: 0}, {VindexIdx: 1, ColumnIdx: 0}},
+		{{VindexIdx: 1, ColumnIdx: 0}},
+	}
+
+	vc := newDMLTestVCursor("-20", "20-")
+	vc.shardForKsid = []string{"20-", "-20", "20-", "20-", "-20", "20-"}
+	// The existing row (1, 5) does not conflict with (1, 10), while (7, 12)
+	// conflicts with the new row 3 on c3: its lookup entry, which points to
+	// the keyspace id of 7, must be replaced.
+	vc.results = []*sqltypes.Result{
+		sqltypes.MakeTestResult(
+			sqltypes.MakeTestFields("id|c3", "int64|int64"),
+			"1|5",
+			"7|12",
+		),
+	}
+
+	_, err := ins.TryExecute(context.Background(), vc, map[string]*querypb.BindVariable{}, false)
+	require.NoError(t, err)
+	vc.ExpectLog(t, []string{
+		`ResolveDestinations sharded [value:"0" value:"1" value:"2"] Destinations:DestinationKeyspaceID(166b40b44aba4bd6),DestinationKeyspaceID(06e7ea22ce92708f),DestinationKeyspaceID(4eb190c9a2fa169c)`,
+		`ExecuteMultiShard sharded.20-: select id, c3 from t1 where id = :_r0_0 and c3 = :_r0_1 or c3 = :_r0_1 or id = :_r2_0 and c3 = :_r2_1 or c3 = :_r2_1 for update ` +
+			`{_r0_0: type:INT64 value:"1" _r0_1: type:INT64 value:"10" _r2_0: type:INT64 value:"3" _r2_1: type:INT64 value:"12"} false false`,
+		`Execute delete from lkp1 where from = :from and toc = :toc ` +
+			`from: type:INT64 value:"12" toc: type:VARBINARY value:"\xfb\x8b\xaa\xad\x91\x81\x19\xb8" true`,
+		`ExecuteMultiShard sharded.-20: select id, c3 from t1 where id = :_r1_0 and c3 = :_r1_1 or c3 = :_r1_1 for update ` +
+			`{_r1_0: type:INT64 value:"2" _r1_1: type:INT64 value:"11"} false false`,
+		`Execute insert into lkp1(from, toc) values(:from_0, :toc_0), (:from_1, :toc_1), (:from_2, :toc_2) ` +
+			`from_0: type:INT64 value:"10" from_1: type:INT64 value:"11" from_2: type:INT64 value:"12" ` +
+			`toc_0: type:VARBINARY value:"\x16k@\xb4J\xbaK\xd6" toc_1: type:VARBINARY value:"\x06\xe7\xea\"Βp\x8f" toc_2: type:VARBINARY value:"N\xb1\x90ɢ\xfa\x16\x9c" true`,
+		`ResolveDestinations sharded [value:"0" value:"1" value:"2"] Destinations:DestinationKeyspaceID(166b40b44aba4bd6),DestinationKeyspaceID(06e7ea22ce92708f),DestinationKeyspaceID(4eb190c9a2fa169c)`,
+		`ExecuteMultiShard ` +
+			`sharded.20-: prefix mid1, mid3 suffix ` +
+			`{_c3_0: type:INT64 value:"10" _c3_1: type:INT64 value:"11" _c3_2: type:INT64 value:"12" ` +
+			`_id_0: type:INT64 value:"1" _id_1: type:INT64 value:"2" _id_2: type:INT64 value:"3"} ` +
+			`sharded.-20: prefix mid2 suffix ` +
+			`{_c3_0: type:INT64 value:"10" _c3_1: type:INT64 value:"11" _c3_2: type:INT64 value:"12" ` +
+			`_id_0: type:INT64 value:"1" _id_1: type:INT64 value:"2" _id_2: type:INT64 value:"3"} ` +
+			`true false`,
+	})
+}
+
+func TestInsertShardedReplaceOwnedConflictingRows(t *testing.T) {
+	ins := newReplaceOwnedTestInsert()
+	// The primary key is id, and the third row replaces (1, 12) instead of
+	// (3, 12), so it overwrites the first row of the statement.
+	ins.ReplaceKeys = [][]VindexColumn{{{VindexIdx: 0, ColumnIdx: 0}}}
+	ins.VindexValues[0][0][2] = evalengine.NewLiteralInt(1)
+
+	vc := newDMLTestVCursor("-20", "20-")
+	vc.shardForKsid = []string{"20-", "-20", "20-", "20-", "-20", "20-"}
+	// The existing row (1, 10) has the same c3 as the first row, but its
+	// lookup entry must be replaced all the same, since the first row is
+	// overwritten by the third one.
+	vc.results = []*sqltypes.Result{
+		sqltypes.MakeTestResult(
+			sqltypes.MakeTestFields("id|c3", "int64|int64"),
+			"1|10",
+		),
+	}
+
+	_, err := ins.TryExecute(context.Background(), vc, map[string]*querypb.BindVariable{}, false)
+	require.NoError(t, err)
+	vc.ExpectLog(t, []string{
+		`ResolveDestinations sharded [value:"0" value:"1" value:"2"] Destinations:DestinationKeyspaceID(166b40b44aba4bd6),DestinationKeyspaceID(06e7ea22ce92708f),DestinationKeyspaceID(166b40b44aba4bd6)`,
+		`ExecuteMultiShard sharded.20-: select id, c3 from t1 where id = :_r0_0 or id = :_r2_0 for update {_r0_0: type:INT64 value:"1" _r2_0: type:INT64 value:"1"} false false`,
+		`Execute delete from lkp1 where from = :from and toc = :toc ` +
+			`from: type:INT64 value:"10" toc: type:VARBINARY value:"\x16k@\xb4J\xbaK\xd6" true`,
+		`ExecuteMultiShard sharded.-20: select id, c3 from t1 where id = :_r1_0 for update {_r1_0: type:INT64 value:"2"} false false`,
+		// No entry is created for the first row.
+		`Execute insert into lkp1(from, toc) values(:from_0, :toc_0), (:from_1, :toc_1) ` +
+			`from_0: type:INT64 value:"11" from_1: type:INT64 value:"12" ` +
+			`toc_0: type:VARBINARY value:"\x06\xe7\xea\"Βp\x8f" toc_1: type:VARBINARY value:"\x16k@\xb4J\xbaK\xd6" true`,
+		`ResolveDestinations sharded [value:"0" value:"1" value:"2"] Destinations:DestinationKeyspaceID(166b40b44aba4bd6),DestinationKeyspaceID(06e7ea22ce92708f),DestinationKeyspaceID(166b40b44aba4bd6)`,
+		`ExecuteMultiShard ` +
+			`sharded.20-: prefix mid1, mid3 suffix ` +
+			`{_c3_0: type:INT64 value:"10" _c3_1: type:INT64 value:"11" _c3_2: type:INT64 value:"12" ` +
+			`_id_0: type:INT64 value:"1" _id_1: type:INT64 value:"2" _id_2: type:INT64 value:"1"} ` +
+			`sharded.-20: prefix mid2 suffix ` +
+			`{_c3_0: type:INT64 value:"10" _c3_1: type:INT64 value:"11" _c3_2: type:INT64 value:"12" ` +
+			`_id_0: type:INT64 value:"1" _id_1: type:INT64 value:"2" _id_2: type:INT64 value:"1"} ` +
+			`true false`,
+	})
+}
+
+func TestInsertShardedReplaceOwnedNonVindexKey(t *testing.T) {
+	ins := newReplaceOwnedTestInsert()
+	// The primary key is id, and email, which is not a vindex column, is a
+	// unique key. The first and the third rows conflict on email.
+	ins.ReplaceKeys = [][]VindexColumn{
+		{{VindexIdx: 0, ColumnIdx: 0}},
+		{{VindexIdx: -1, ColumnIdx: 0}},
+	}
+	ins.ReplaceColumns = []sqlparser.IdentifierCI{sqlparser.NewIdentifierCI("email")}
+	ins.ReplaceValues = [][]evalengine.Expr{{
+		evalengine.NewLiteralString([]byte("a"), collations.TypedCollation{}),
+		evalengine.NewLiteralString([]byte("b"), collations.TypedCollation{}),
+		evalengine.NewLiteralString([]byte("a"), collations.TypedCollation{}),
+	}}
+
+	vc := newDMLTestVCursor("-20", "20-")
+	vc.shardForKsid = []string{"20-", "-20", "20-", "20-", "-20", "20-"}
+	// The existing row (7, 15, 'b') conflicts with the second row on email:
+	// its lookup entry points to the keyspace id of 7.
+	vc.results = []*sqltypes.Result{
+		{},
+		sqltypes.MakeTestResult(
+			sqltypes.MakeTestFields("id|email|c3", "int64|varchar|int64"),
+			"7|b|15",
+		),
+	}
+
+	_, err := ins.TryExecute(context.Background(), vc, map[string]*querypb.BindVariable{}, false)
+	require.NoError(t, err)
+	vc.ExpectLog(t, []string{
+		`ResolveDestinations sharded [value:"0" value:"1" value:"2"] Destinations:DestinationKeyspaceID(166b40b44aba4bd6),DestinationKeyspaceID(06e7ea22ce92708f),DestinationKeyspaceID(4eb190c9a2fa169c)`,
+		`ExecuteMultiShard sharded.20-: select id, email, c3 from t1 where id = :_r0_0 or email = :_r0_1 or id = :_r2_0 or email = :_r2_1 for update ` +
+			`{_r0_0: type:INT64 value:"1" _r0_1: type:VARCHAR value:"a" _r2_0: type:INT64 value:"3" _r2_1: type:VARCHAR value:"a"} false false`,
+		`ExecuteMultiShard sharded.-20: select id, email, c3 from t1 where id = :_r1_0 or email = :_r1_1 for update ` +
+			`{_r1_0: type:INT64 value:"2" _r1_1: type:VARCHAR value:"b"} false false`,
+		`Execute delete from lkp1 where from = :from and toc = :toc ` +
+			`from: type:INT64 value:"15" toc: type:VARBINARY value:"\xfb\x8b\xaa\xad\x91\x81\x19\xb8" true`,
+		// No entry is created for the first row.
+		`Execute insert into lkp1(from, toc) values(:from_0, :toc_0), (:from_1, :toc_1) ` +
+			`from_0: type:INT64 value:"11" from_1: type:INT64 value:"12" ` +
+			`toc_0: type:VARBINARY value:"\x06\xe7\xea\"Βp\x8f" toc_1: type:VARBINARY value:"N\xb1\x90ɢ\xfa\x16\x9c" true`,
+		`ResolveDestinations sharded [value:"0" value:"1" value:"2"] Destinations:DestinationKeyspaceID(166b40b44aba4bd6),DestinationKeyspaceID(06e7ea22ce92708f),DestinationKeyspaceID(4eb190c9a2fa169c)`,
+		`ExecuteMultiShard ` +
+			`sharded.20-: prefix mid1, mid3 suffix ` +
+			`{_c3_0: type:INT64 value:"10" _c3_1: type:INT64 value:"11" _c3_2: type:INT64 value:"12" ` +
+			`_email_0: type:VARCHAR value:"a" _email_1: type:VARCHAR value:"b" _email_2: type:VARCHAR value:"a" ` +
+			`_id_0: type:INT64 value:"1" _id_1: type:INT64 value:"2" _id_2: type:INT64 value:"3"} ` +
+			`sharded.-20: prefix mid2 suffix ` +
+			`{_c3_0: type:INT64 value:"10" _c3_1: type:INT64 value:"11" _c3_2: type:INT64 value:"12" ` +
+			`_email_0: type:VARCHAR value:"a" _email_1: type:VARCHAR value:"b" _email_2: type:VARCHAR value:"a" ` +
+			`_id_0: type:INT64 value:"1" _id_1: type:INT64 value:"2" _id_2: type:INT64 value:"3"} ` +
+			`true false`,
+	})
+}
+
 func TestInsertShardedGeo(t *testing.T) {
 	invschema := &vschemapb.SrvVSchema{
 		Keyspaces: map[string]*vschemapb.Keyspace{
@@ -1573,6 +1835,80 @@ func TestInsertSelectSimple(t *testing.T) {
 			` {_c1_0: type:VARCHAR value:"a" _c1_1: type:INT64 value:"3"} true false`})
 }
 
+func TestInsertSelectBatched(t *testing.T) {
+	invschema := &vschemapb.SrvVSchema{
+		Keyspaces: map[string]*vschemapb.Keyspace{
+			"sharded": {
+				Sharded: true,
+				Vindexes: map[string]*vschemapb.Vindex{
+					"hash": {Type: "hash"}},
+				Tables: map[string]*vschemapb.Table{
+					"t1": {
+						ColumnVindexes: []*vschemapb.ColumnVindex{{
+							Name:    "hash",
+							Columns: []string{"id"}}}}}}}}
+
+	vs := vindexes.BuildVSchema(invschema)
+	ks := vs.Keyspaces["sharded"]
+
+	ins := &Insert{
+		Opcode:            InsertSelect,
+		Keyspace:          ks.Keyspace,
+		Query:             "dummy_insert",
+		Table:             ks.Tables["t1"],
+		VindexValueOffset: [][]int{{1}},
+		BatchSize:         2,
+		Input: &Route{
+			Query:      "dummy_select",
+			FieldQuery: "dummy_field_query",
+			RoutingParameters: &RoutingParameters{
+				Opcode:   Scatter,
+				Keyspace: ks.Keyspace}}}
+
+	ins.ColVindexes = append(ins.ColVindexes, ks.Tables["t1"].ColumnVindexes...)
+	ins.Prefix = "prefix "
+	ins.Suffix = " suffix"
+
+	vc := newDMLTestVCursor("-20", "20-")
+	vc.shardForKsid = []string{"20-", "-20", "20-"}
+	vc.results = []*sqltypes.Result{
+		sqltypes.MakeTestResult(
+			sqltypes.MakeTestFields(
+				"name|id",
+				"varchar|int64"),
+			"a|1",
+			"a|3",
+			"b|2")}
+
+	rowsBefore := insertSelectRows.Counts()["sharded.t1"]
+	batchesBefore := insertSelectBatches.Counts()["sharded.t1"]
+
+	// even when not streaming, the select is streamed and the rows are inserted two at a time
+	_, err := ins.TryExecute(context.Background(), vc, map[string]*querypb.BindVariable{}, false)
+	require.NoError(t, err)
+	vc.ExpectLog(t, []string{
+		`ResolveDestinations sharded [] Destinations:DestinationAllShards()`,
+		`StreamExecuteMulti dummy_select sharded.-20: {} sharded.20-: {} `,
+
+		// the first batch sends one row to each shard
+		`ResolveDestinations sharded [value:"0" value:"1"] Destinations:DestinationKeyspaceID(166b40b44aba4bd6),DestinationKeyspaceID(4eb190c9a2fa169c)`,
+		`ExecuteMultiShard ` +
+			`sharded.20-: prefix values (:_c0_0, :_c0_1) suffix ` +
+			`{_c0_0: type:VARCHAR value:"a" _c0_1: type:INT64 value:"1"} ` +
+			`sharded.-20: prefix values (:_c1_0, :_c1_1) suffix ` +
+			`{_c1_0: type:VARCHAR value:"a" _c1_1: type:INT64 value:"3"} true false`,
+
+		// the last batch only holds the remaining row
+		`ResolveDestinations sharded [value:"0"] Destinations:DestinationKeyspaceID(06e7ea22ce92708f)`,
+		`ExecuteMultiShard ` +
+			`sharded.20-: prefix values (:_c0_0, :_c0_1) suffix ` +
+			`{_c0_0: type:VARCHAR value:"b" _c0_1: type:INT64 value:"2"} true true`,
+	})
+
+	require.EqualValues(t, 3, insertSelectRows.Counts()["sharded.t1"]-rowsBefore)
+	require.EqualValues(t, 2, insertSelectBatches.Counts()["sharded.t1"]-batchesBefore)
+}
+
 func TestInsertSelectOwned(t *testing.T) {
 	invschema := &vschemapb.SrvVSchema{
 		Keyspaces: map[string]*vschemapb.Keyspace{
@@ -68,6 +68,13 @@ func (ms *MemorySort) SetTruncateColumnCount(count int) {
 
 // TryExecute satisfies the Primitive interface.
 func (ms *MemorySort) TryExecute(ctx context.Context, vcursor VCursor, bindVars map[string]*querypb.BindVariable, wantfields bool) (*sqltypes.Result, error) {
+	if vcursor.SpillDir() != "" {
+		// stream the input, so that the sort can spill to disk once the rows do not fit in memory
+		return executeStreamed(func(callback func(*sqltypes.Result) error) error {
+			return ms.TryStreamExecute(ctx, vcursor, bindVars, wantfields, callback)
+		})
+	}
+
 	count, err := ms.fetchCount(ctx, vcursor, bindVars)
 	if err != nil {
 		return nil, err
@@ -109,6 +116,12 @@ func (ms *MemorySort) TryStreamExecute(ctx context.Context, vcursor VCursor, bin
 		comparers: extractSlices(ms.OrderBy),
 		reverse:   true,
 	}
+	var sorter *spillSorter
+	defer func() {
+		if sorter != nil {
+			_ = sorter.close()
+		}
+	}()
 	err = vcursor.StreamExecutePrimitive(ctx, ms.Input, bindVars, wantfields, func(qr *sqltypes.Result) error {
 		if len(qr.Fields) != 0 {
 			if err := cb(&sqltypes.Result{Fields: qr.Fields}); err != nil {
@@ -123,9 +136,23 @@ func (ms *MemorySort) TryStreamExecute(ctx context.Context, vcursor VCursor, bin
 				_ = heap.Pop(sh)
 			}
 		}
-		if vcursor.ExceedsMaxMemoryRows(len(sh.rows)) {
+		if !vcursor.ExceedsMaxMemoryRows(len(sh.rows)) {
+			return nil
+		}
+		if vcursor.SpillDir() == "" {
 			return fmt.Errorf("in-memory row count exceeded allowed limit of %d", vcursor.MaxMemoryRows())
 		}
+		if sh.err != nil {
+			return sh.err
+		}
+		// Write the rows seen so far to disk as a sorted run, and start over with an empty heap.
+		if sorter == nil {
+			sorter = newSpillSorter(vcursor.SpillDir(), extractSlices(ms.OrderBy))
+		}
+		if err := sorter.spill(sh.rows); err != nil {
+			return err
+		}
+		sh.rows = nil
 		return nil
 	})
 	if err != nil {
@@ -134,6 +161,9 @@ func (ms *MemorySort) TryStreamExecute(ctx context.Context, vcursor VCursor, bin
 	if sh.err != nil {
 		return sh.err
 	}
+	if sorter != nil {
+		return ms.mergeSpilled(sorter, sh.rows, count, cb)
+	}
 	// Set ordering to normal for the final ordering.
 	sh.reverse = false
 	sort.Sort(sh)
@@ -144,6 +174,28 @@ func (ms *MemorySort) TryStreamExecute(ctx context.Context, vcursor VCursor, bin
 	return cb(&sqltypes.Result{Rows: sh.rows})
 }
 
+// mergeSpilled merges the sorted runs that were spilled to disk with the rows
+// that are still in memory, and sends the first count rows to the callback.
+func (ms *MemorySort) mergeSpilled(sorter *spillSorter, rows []sqltypes.Row, count int, callback func(*sqltypes.Result) error) error {
+	var batch []sqltypes.Row
+	err := sorter.merge(rows, count, func(row sqltypes.Row) error {
+		batch = append(batch, row)
+		if len(batch) < spillBatchSize {
+			return nil
+		}
+		err := callback(&sqltypes.Result{Rows: batch})
+		batch = nil
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if len(batch) == 0 {
+		return nil
+	}
+	return callback(&sqltypes.Result{Rows: batch})
+}
+
 // GetFields satisfies the Primitive interface.
 func (ms *MemorySort) GetFields(ctx context.Context, vcursor VCursor, bindVars map[string]*querypb.BindVariable) (*sqltypes.Result, error) {
 	return ms.Input.GetFields(ctx, vcursor, bindVars)
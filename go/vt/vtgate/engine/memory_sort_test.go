@@ -18,6 +18,7 @@ package engine
 
 import (
 	"context"
+	"os"
 	"testing"
 
 	"vitess.io/vitess/go/vt/servenv"
@@ -621,6 +622,89 @@ func TestMemorySortMaxMemoryRows(t *testing.T) {
 	}
 }
 
+func TestMemorySortSpill(t *testing.T) {
+	saveMax := testMaxMemoryRows
+	saveSpillDir := testSpillDir
+	testMaxMemoryRows = 2
+	defer func() {
+		testMaxMemoryRows = saveMax
+		testSpillDir = saveSpillDir
+	}()
+	testSpillDir = t.TempDir()
+
+	fields := sqltypes.MakeTestFields(
+		"c1|c2",
+		"varbinary|decimal",
+	)
+	fp := &fakePrimitive{
+		results: []*sqltypes.Result{sqltypes.MakeTestResult(
+			fields,
+			"a|5",
+			"b|2",
+			"c|7",
+			"d|1",
+			"e|4",
+			"f|6",
+			"g|3",
+		)},
+	}
+
+	ms := &MemorySort{
+		OrderBy: []OrderByParams{{
+			WeightStringCol: -1,
+			Col:             1,
+			Desc:            true,
+		}},
+		Input: fp,
+	}
+
+	run := func(bv map[string]*querypb.BindVariable, streaming bool) *sqltypes.Result {
+		fp.rewind()
+		result := &sqltypes.Result{}
+		var err error
+		if streaming {
+			err = ms.TryStreamExecute(context.Background(), &noopVCursor{}, bv, true, func(qr *sqltypes.Result) error {
+				result.Fields = append(result.Fields, qr.Fields...)
+				result.Rows = append(result.Rows, qr.Rows...)
+				return nil
+			})
+		} else {
+			result, err = ms.TryExecute(context.Background(), &noopVCursor{}, bv, true)
+		}
+		require.NoError(t, err)
+		// the input is streamed in both cases, so that the rows do not have to fit in memory
+		fp.ExpectLog(t, []string{"StreamExecute " + printBindVars(bv) + " true"})
+
+		files, err := os.ReadDir(testSpillDir)
+		require.NoError(t, err)
+		require.Empty(t, files, "spill files should be removed")
+		return result
+	}
+
+	for _, streaming := range []bool{false, true} {
+		ms.UpperLimit = nil
+		utils.MustMatch(t, sqltypes.MakeTestResult(
+			fields,
+			"c|7",
+			"f|6",
+			"a|5",
+			"e|4",
+			"g|3",
+			"b|2",
+			"d|1",
+		), run(nil, streaming))
+
+		ms.UpperLimit = evalengine.NewBindVar("__upper_limit")
+		bv := map[string]*querypb.BindVariable{"__upper_limit": sqltypes.Int64BindVariable(3)}
+		utils.MustMatch(t, sqltypes.MakeTestResult(
+			fields,
+			"c|7",
+			"f|6",
+			"a|5",
+		), run(bv, streaming))
+	}
+}
+
 func TestMemorySortExecuteNoVarChar(t *testing.T) {
 	fields := sqltypes.MakeTestFields(
 		"c1|c2",
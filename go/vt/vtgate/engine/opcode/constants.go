@@ -68,6 +68,15 @@ const (
 	AggregateAnyValue
 	AggregateCountStar
 	AggregateGroupConcat
+	AggregateBitAnd
+	AggregateBitOr
+	AggregateBitXor
+	AggregateStddevPop
+	AggregateStddevSamp
+	AggregateVarPop
+	AggregateVarSamp
+	AggregateJSONArrayAgg
+	AggregateJSONObjectAgg
 	_NumOfOpCodes // This line must be last of the opcodes!
 )
 
@@ -80,6 +89,15 @@ var (
 		AggregateSumDistinct:   sqltypes.Decimal,
 		AggregateSum:           sqltypes.Decimal,
 		AggregateGtid:          sqltypes.VarChar,
+		AggregateBitAnd:        sqltypes.Uint64,
+		AggregateBitOr:         sqltypes.Uint64,
+		AggregateBitXor:        sqltypes.Uint64,
+		AggregateStddevPop:     sqltypes.Float64,
+		AggregateStddevSamp:    sqltypes.Float64,
+		AggregateVarPop:        sqltypes.Float64,
+		AggregateVarSamp:       sqltypes.Float64,
+		AggregateJSONArrayAgg:  sqltypes.TypeJSON,
+		AggregateJSONObjectAgg: sqltypes.TypeJSON,
 	}
 )
 
@@ -98,6 +116,18 @@ var SupportedAggregates = map[string]AggregateOpcode{
 	"count_star":     AggregateCountStar,
 	"any_value":      AggregateAnyValue,
 	"group_concat":   AggregateGroupConcat,
+	"bit_and":        AggregateBitAnd,
+	"bit_or":         AggregateBitOr,
+	"bit_xor":        AggregateBitXor,
+	"std":            AggregateStddevPop,
+	"stddev":         AggregateStddevPop,
+	"stddev_pop":     AggregateStddevPop,
+	"stddev_samp":    AggregateStddevSamp,
+	"variance":       AggregateVarPop,
+	"var_pop":        AggregateVarPop,
+	"var_samp":       AggregateVarSamp,
+	"json_arrayagg":  AggregateJSONArrayAgg,
+	"json_objectagg": AggregateJSONObjectAgg,
 }
 
 var AggregateName = map[AggregateOpcode]string{
@@ -111,6 +141,15 @@ var AggregateName = map[AggregateOpcode]string{
 	AggregateCountStar:     "count_star",
 	AggregateGroupConcat:   "group_concat",
 	AggregateAnyValue:      "any_value",
+	AggregateBitAnd:        "bit_and",
+	AggregateBitOr:         "bit_or",
+	AggregateBitXor:        "bit_xor",
+	AggregateStddevPop:     "stddev_pop",
+	AggregateStddevSamp:    "stddev_samp",
+	AggregateVarPop:        "var_pop",
+	AggregateVarSamp:       "var_samp",
+	AggregateJSONArrayAgg:  "json_arrayagg",
+	AggregateJSONObjectAgg: "json_objectagg",
 }
 
 func (code AggregateOpcode) String() string {
@@ -157,6 +196,12 @@ func (code AggregateOpcode) Type(typ *querypb.Type) (querypb.Type, bool) {
 		return sqltypes.Int64, true
 	case AggregateGtid:
 		return sqltypes.VarChar, true
+	case AggregateBitAnd, AggregateBitOr, AggregateBitXor:
+		return sqltypes.Uint64, true
+	case AggregateStddevPop, AggregateStddevSamp, AggregateVarPop, AggregateVarSamp:
+		return sqltypes.Float64, true
+	case AggregateJSONArrayAgg, AggregateJSONObjectAgg:
+		return sqltypes.TypeJSON, true
 	default:
 		panic(code.String()) // we have a unit test checking we never reach here
 	}
@@ -171,6 +216,29 @@ func (code AggregateOpcode) NeedsComparableValues() bool {
 	}
 }
 
+// IsStatistical returns true for the aggregations that are computed from
+// partial count, mean and population variance pushed down to each shard.
+func (code AggregateOpcode) IsStatistical() bool {
+	switch code {
+	case AggregateStddevPop, AggregateStddevSamp, AggregateVarPop, AggregateVarSamp:
+		return true
+	default:
+		return false
+	}
+}
+
+// NeedsPartialResults returns true for the aggregations that can only be
+// computed by merging the partial results of each shard, and not from the
+// raw values of their argument.
+func (code AggregateOpcode) NeedsPartialResults() bool {
+	switch code {
+	case AggregateBitAnd, AggregateBitOr, AggregateBitXor, AggregateJSONArrayAgg, AggregateJSONObjectAgg:
+		return true
+	default:
+		return code.IsStatistical()
+	}
+}
+
 func (code AggregateOpcode) IsDistinct() bool {
 	switch code {
 	case AggregateCountDistinct, AggregateSumDistinct:
@@ -179,3 +247,75 @@ func (code AggregateOpcode) IsDistinct() bool {
 		return false
 	}
 }
+
+// WindowOpcode is the opcode for window functions evaluated at the vtgate level.
+type WindowOpcode int
+
+// These constants list the possible window function opcodes.
+const (
+	WindowUnassigned = WindowOpcode(iota)
+	WindowRowNumber
+	WindowRank
+	WindowDenseRank
+	WindowPercentRank
+	WindowCumeDist
+	WindowLag
+	WindowLead
+	WindowFirstValue
+	_NumOfWindowOpCodes // This line must be last of the opcodes!
+)
+
+// WindowName maps the window function opcodes to the name used in plan descriptions.
+var WindowName = map[WindowOpcode]string{
+	WindowRowNumber:   "row_number",
+	WindowRank:        "rank",
+	WindowDenseRank:   "dense_rank",
+	WindowPercentRank: "percent_rank",
+	WindowCumeDist:    "cume_dist",
+	WindowLag:         "lag",
+	WindowLead:        "lead",
+	WindowFirstValue:  "first_value",
+}
+
+func (code WindowOpcode) String() string {
+	name := WindowName[code]
+	if name == "" {
+		name = "ERROR"
+	}
+	return name
+}
+
+// MarshalJSON serializes the WindowOpcode as a JSON string.
+// It's used for testing and diagnostics.
+func (code WindowOpcode) MarshalJSON() ([]byte, error) {
+	return ([]byte)(fmt.Sprintf("\"%s\"", code.String())), nil
+}
+
+// Type returns the type of the window function result, given the type of its argument if it has one.
+func (code WindowOpcode) Type(typ *querypb.Type) querypb.Type {
+	switch code {
+	case WindowRowNumber, WindowRank, WindowDenseRank:
+		return sqltypes.Uint64
+	case WindowPercentRank, WindowCumeDist:
+		return sqltypes.Float64
+	case WindowLag, WindowLead, WindowFirstValue:
+		if typ == nil {
+			return sqltypes.Null
+		}
+		return *typ
+	case WindowUnassigned:
+		return sqltypes.Null
+	default:
+		panic(code.String()) // we have a unit test checking we never reach here
+	}
+}
+
+// NeedsArgument returns true if the window function reads a value from an input column.
+func (code WindowOpcode) NeedsArgument() bool {
+	switch code {
+	case WindowLag, WindowLead, WindowFirstValue:
+		return true
+	default:
+		return false
+	}
+}
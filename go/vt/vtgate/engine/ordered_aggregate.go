@@ -42,6 +42,11 @@ var _ Primitive = (*OrderedAggregate)(nil)
 // keys are aggregated using the Aggregate functions. The assumption
 // is that the underlying primitive is a scatter select with pre-sorted
 // rows.
+// Because the rows are sorted, only the group being aggregated and the last
+// value of each distinct aggregate are held in memory while streaming, so the
+// aggregation does not spill to disk itself. When the rows are sorted by a
+// MemorySort, it is the sort that spills once the max memory rows are exceeded.
+// This is why the input is streamed in TryExecute too when spilling is enabled.
 type OrderedAggregate struct {
 	// Aggregates specifies the aggregation parameters for each
 	// aggregation function: function opcode and input column number.
@@ -107,7 +112,13 @@ func (oa *OrderedAggregate) SetTruncateColumnCount(count int) {
 }
 
 // TryExecute is a Primitive function.
-func (oa *OrderedAggregate) TryExecute(ctx context.Context, vcursor VCursor, bindVars map[string]*querypb.BindVariable, _ bool) (*sqltypes.Result, error) {
+func (oa *OrderedAggregate) TryExecute(ctx context.Context, vcursor VCursor, bindVars map[string]*querypb.BindVariable, wantfields bool) (*sqltypes.Result, error) {
+	if vcursor.SpillDir() != "" {
+		// stream the input, so that a MemorySort below can spill to disk
+		return executeStreamed(func(callback func(*sqltypes.Result) error) error {
+			return oa.TryStreamExecute(ctx, vcursor, bindVars, wantfields, callback)
+		})
+	}
 	qr, err := oa.execute(ctx, vcursor, bindVars)
 	if err != nil {
 		return nil, err
@@ -157,7 +168,11 @@ func (oa *OrderedAggregate) execute(ctx context.Context, vcursor VCursor, bindVa
 		}
 
 		// this is a new grouping. let's yield the old one, and start a new
-		out.Rows = append(out.Rows, current)
+		final, err := convertFinal(current, oa.Aggregates)
+		if err != nil {
+			return nil, err
+		}
+		out.Rows = append(out.Rows, final)
 		current, curDistincts = convertRow(fields, row, oa.Aggregates)
 		continue
 	}
@@ -213,7 +228,11 @@ func (oa *OrderedAggregate) TryStreamExecute(ctx context.Context, vcursor VCurso
 			}
 
 			// this is a new grouping. let's yield the old one, and start a new
-			if err := cb(&sqltypes.Result{Rows: [][]sqltypes.Value{current}}); err != nil {
+			final, err := convertFinal(current, oa.Aggregates)
+			if err != nil {
+				return err
+			}
+			if err := cb(&sqltypes.Result{Rows: [][]sqltypes.Value{final}}); err != nil {
 				return err
 			}
 			current, curDistincts = convertRow(fields, row, oa.Aggregates)
@@ -232,7 +251,11 @@ func (oa *OrderedAggregate) TryStreamExecute(ctx context.Context, vcursor VCurso
 	}
 
 	if current != nil {
-		if err := cb(&sqltypes.Result{Rows: [][]sqltypes.Value{current}}); err != nil {
+		final, err := convertFinal(current, oa.Aggregates)
+		if err != nil {
+			return err
+		}
+		if err := cb(&sqltypes.Result{Rows: [][]sqltypes.Value{final}}); err != nil {
 			return err
 		}
 	}
@@ -20,6 +20,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"os"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -1198,3 +1199,168 @@ func TestGroupConcat(t *testing.T) {
 		})
 	}
 }
+
+func TestOrderedAggregateOverSpilledSort(t *testing.T) {
+	saveMax := testMaxMemoryRows
+	saveSpillDir := testSpillDir
+	testMaxMemoryRows = 2
+	defer func() {
+		testMaxMemoryRows = saveMax
+		testSpillDir = saveSpillDir
+	}()
+
+	tcases := []struct {
+		name        string
+		aggregate   *AggregateParams
+		orderBy     []OrderByParams
+		inputResult *sqltypes.Result
+		expResults  []*sqltypes.Result
+	}{{
+		name:      "sum",
+		aggregate: NewAggregateParam(AggregateSum, 1, ""),
+		orderBy:   []OrderByParams{{Col: 0, WeightStringCol: -1}},
+		inputResult: sqltypes.MakeTestResult(
+			sqltypes.MakeTestFields(
+				"col|count(*)",
+				"varbinary|decimal",
+			),
+			"c|3",
+			"a|1",
+			"b|2",
+			"c|4",
+			"a|1",
+			"b|5",
+			"a|2",
+		),
+		expResults: sqltypes.MakeTestStreamingResults(
+			sqltypes.MakeTestFields(
+				"col|count(*)",
+				"varbinary|decimal",
+			),
+			"a|4",
+			"---",
+			"b|7",
+			"---",
+			"c|7",
+		),
+	}, {
+		name:      "count distinct",
+		aggregate: NewAggregateParam(AggregateCountDistinct, 1, "count(distinct val)"),
+		orderBy:   []OrderByParams{{Col: 0, WeightStringCol: -1}, {Col: 1, WeightStringCol: -1}},
+		inputResult: sqltypes.MakeTestResult(
+			sqltypes.MakeTestFields(
+				"col|val",
+				"varbinary|int64",
+			),
+			"b|1",
+			"a|2",
+			"a|1",
+			"b|1",
+			"a|2",
+			"b|3",
+			"a|1",
+		),
+		expResults: sqltypes.MakeTestStreamingResults(
+			sqltypes.MakeTestFields(
+				"col|count(distinct val)",
+				"varbinary|int64",
+			),
+			"a|2",
+			"---",
+			"b|2",
+		),
+	}}
+
+	for _, tcase := range tcases {
+		t.Run(tcase.name, func(t *testing.T) {
+			testSpillDir = t.TempDir()
+
+			oa := &OrderedAggregate{
+				Aggregates:  []*AggregateParams{tcase.aggregate},
+				GroupByKeys: []*GroupByParams{{KeyCol: 0}},
+				Input: &MemorySort{
+					OrderBy: tcase.orderBy,
+					Input:   &fakePrimitive{results: []*sqltypes.Result{tcase.inputResult}},
+				},
+			}
+
+			var results []*sqltypes.Result
+			err := oa.TryStreamExecute(context.Background(), &noopVCursor{}, nil, true, func(qr *sqltypes.Result) error {
+				results = append(results, qr)
+				return nil
+			})
+			require.NoError(t, err)
+			utils.MustMatch(t, tcase.expResults, results)
+
+			files, err := os.ReadDir(testSpillDir)
+			require.NoError(t, err)
+			require.Empty(t, files, "spill files should be removed")
+
+			// the non-streaming execution spills too, and returns all the groups in one result
+			expResult := &sqltypes.Result{Fields: tcase.expResults[0].Fields}
+			for _, res := range tcase.expResults {
+				expResult.Rows = append(expResult.Rows, res.Rows...)
+			}
+			oa.Input.(*MemorySort).Input = &fakePrimitive{results: []*sqltypes.Result{tcase.inputResult}}
+			result, err := oa.TryExecute(context.Background(), &noopVCursor{}, nil, true)
+			require.NoError(t, err)
+			utils.MustMatch(t, expResult, result)
+
+			files, err = os.ReadDir(testSpillDir)
+			require.NoError(t, err)
+			require.Empty(t, files, "spill files should be removed")
+		})
+	}
+}
+
+func TestOrderedAggregateStatisticalAndJSON(t *testing.T) {
+	fields := sqltypes.MakeTestFields(
+		"foo|std(col)|sum(col)|json_arrayagg(col)|json_objectagg(id, col)|sum(col)|var_pop(col)",
+		"int64|int64|decimal|json|json|decimal|float64",
+	)
+	input := sqltypes.MakeTestResult(fields,
+		`10|2|3|[1, 2]|{"1": 1, "2": 2}|3|0.25`,
+		`10|1|3|[3]|{"2": 3}|3|0`,
+		`20|2|4|[2, 2]|{"4": 2}|4|0`,
+		`30|0|null|null|null|null|null`,
+	)
+	expResult := sqltypes.MakeTestResult(
+		sqltypes.MakeTestFields(
+			"foo|std(col)|sum(col)|json_arrayagg(col)|json_objectagg(id, col)",
+			"int64|float64|decimal|json|json",
+		),
+		`10|0.816496580927726|6|[1, 2, 3]|{"1": 1, "2": 3}`,
+		`20|0|4|[2, 2]|{"4": 2}`,
+		`30|null|null|null|null`,
+	)
+
+	fp := &fakePrimitive{results: []*sqltypes.Result{input}}
+	oa := &OrderedAggregate{
+		Aggregates: []*AggregateParams{
+			{Opcode: AggregateStddevPop, Col: 1, SumCol: 5, VarCol: 6, Alias: "std(col)"},
+			NewAggregateParam(AggregateSum, 2, ""),
+			NewAggregateParam(AggregateJSONArrayAgg, 3, ""),
+			NewAggregateParam(AggregateJSONObjectAgg, 4, ""),
+			NewAggregateParam(AggregateAnyValue, 5, ""),
+			NewAggregateParam(AggregateAnyValue, 6, ""),
+		},
+		GroupByKeys:         []*GroupByParams{{KeyCol: 0}},
+		TruncateColumnCount: 5,
+		Input:               fp,
+	}
+	qr, err := oa.TryExecute(context.Background(), &noopVCursor{}, nil, false)
+	require.NoError(t, err)
+	utils.MustMatch(t, expResult, qr)
+
+	fp.rewind()
+	results := &sqltypes.Result{}
+	err = oa.TryStreamExecute(context.Background(), &noopVCursor{}, nil, true, func(qr *sqltypes.Result) error {
+		if qr.Fields != nil {
+			results.Fields = qr.Fields
+		}
+		results.Rows = append(results.Rows, qr.Rows...)
+		return nil
+	})
+	require.NoError(t, err)
+	utils.MustMatch(t, expResult, results)
+}
@@ -34,6 +34,7 @@ import (
 type Plan struct {
 	Type         sqlparser.StatementType // The type of query we have
 	Original     string                  // Original is the original query.
+	QueryDigest  string                  // QueryDigest is the digest text of the query, see sqlparser.QueryDigest.
 	Instructions Primitive               // Instructions contains the instructions needed to fulfil the query.
 	BindVarNeeds *sqlparser.BindVarNeeds // Stores BindVars needed to be provided as part of expression rewriting
 	Warnings     []*query.QueryWarning   // Warnings that need to be yielded every time this query runs
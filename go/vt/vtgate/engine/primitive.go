@@ -54,6 +54,11 @@ type (
 		// if the max memory rows override directive is set to true
 		ExceedsMaxMemoryRows(numRows int) bool
 
+		// SpillDir returns the directory in which primitives can spill
+		// intermediate results that do not fit in memory. An empty
+		// value means that spilling to disk is disabled.
+		SpillDir() string
+
 		// V3 functions.
 		Execute(ctx context.Context, method string, query string, bindVars map[string]*querypb.BindVariable, rollbackOnError bool, co vtgatepb.CommitOrder) (*sqltypes.Result, error)
 		AutocommitApproval() bool
@@ -88,6 +93,7 @@ type (
 
 		ConnCollation() collations.ID
 		TimeZone() *time.Location
+		SQLMode() string
 
 		ExecuteLock(ctx context.Context, rs *srvtopo.ResolvedShard, query *querypb.BoundQuery, lockFuncType sqlparser.LockingFuncType) (*sqltypes.Result, error)
 
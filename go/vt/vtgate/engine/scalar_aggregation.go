@@ -18,6 +18,7 @@ package engine
 
 import (
 	"context"
+	"math"
 	"sync"
 
 	"vitess.io/vitess/go/sqltypes"
@@ -204,8 +205,20 @@ func createEmptyValueFor(opcode AggregateOpcode) (sqltypes.Value, error) {
 		AggregateMin,
 		AggregateMax,
 		AggregateAnyValue,
-		AggregateGroupConcat:
+		AggregateGroupConcat,
+		AggregateStddevPop,
+		AggregateStddevSamp,
+		AggregateVarPop,
+		AggregateVarSamp,
+		AggregateJSONArrayAgg,
+		AggregateJSONObjectAgg:
 		return sqltypes.NULL, nil
+	case AggregateBitAnd:
+		return sqltypes.NewUint64(math.MaxUint64), nil
+	case
+		AggregateBitOr,
+		AggregateBitXor:
+		return sqltypes.NewUint64(0), nil
 
 	}
 	return sqltypes.NULL, vterrors.Errorf(vtrpc.Code_INVALID_ARGUMENT, "unknown aggregation %v", opcode)
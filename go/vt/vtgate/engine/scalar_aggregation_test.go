@@ -414,3 +414,119 @@ func TestScalarGroupConcat(t *testing.T) {
 		})
 	}
 }
+
+func TestScalarStatisticalAggregates(t *testing.T) {
+	fields := sqltypes.MakeTestFields(
+		"count(col)|count(col)|count(col)|sum(col)|var_pop(col)",
+		"int64|int64|int64|decimal|float64",
+	)
+	outFields := sqltypes.MakeTestFields(
+		"stddev(col)|variance(col)|var_samp(col)",
+		"float64|float64|float64",
+	)
+
+	var tcases = []struct {
+		name        string
+		inputResult *sqltypes.Result
+		expResult   *sqltypes.Result
+	}{{
+		name: "merge shards",
+		// the values are 1 and 3 on the first shard and 2 and 4 on the second one
+		inputResult: sqltypes.MakeTestResult(fields,
+			"2|2|2|4|1", "2|2|2|6|1", "0|0|0|null|null"),
+		expResult: sqltypes.MakeTestResult(outFields,
+			"1.118033988749895|1.25|1.6666666666666667"),
+	}, {
+		name: "large values with a small variance",
+		// the values are 1e9+1 and 1e9+2 on the first shard and 1e9+3 on the second one,
+		// their sum of squares is too large for a float64 to keep the variance
+		inputResult: sqltypes.MakeTestResult(fields,
+			"2|2|2|2000000003|0.25", "1|1|1|1000000003|0"),
+		expResult: sqltypes.MakeTestResult(outFields,
+			"0.816496580927726|0.6666666666666666|1"),
+	}, {
+		name: "single value",
+		inputResult: sqltypes.MakeTestResult(fields,
+			"0|0|0|null|null", "1|1|1|5|0"),
+		expResult: sqltypes.MakeTestResult(outFields,
+			"0|0|null"),
+	}, {
+		name:        "empty result",
+		inputResult: sqltypes.MakeTestResult(fields),
+		expResult: sqltypes.MakeTestResult(outFields,
+			"null|null|null"),
+	}}
+
+	for _, tcase := range tcases {
+		t.Run(tcase.name, func(t *testing.T) {
+			fp := &fakePrimitive{results: []*sqltypes.Result{tcase.inputResult}}
+			oa := &ScalarAggregate{
+				Aggregates: []*AggregateParams{
+					{Opcode: AggregateStddevPop, Col: 0, SumCol: 3, VarCol: 4, Alias: "stddev(col)"},
+					{Opcode: AggregateVarPop, Col: 1, SumCol: 3, VarCol: 4, Alias: "variance(col)"},
+					{Opcode: AggregateVarSamp, Col: 2, SumCol: 3, VarCol: 4, Alias: "var_samp(col)"},
+					NewAggregateParam(AggregateAnyValue, 3, ""),
+					NewAggregateParam(AggregateAnyValue, 4, ""),
+				},
+				TruncateColumnCount: 3,
+				Input:               fp,
+			}
+			qr, err := oa.TryExecute(context.Background(), &noopVCursor{}, nil, false)
+			require.NoError(t, err)
+			utils.MustMatch(t, tcase.expResult, qr)
+		})
+	}
+}
+
+func TestScalarBitAggregates(t *testing.T) {
+	fields := sqltypes.MakeTestFields(
+		"bit_and(col)|bit_or(col)|bit_xor(col)",
+		"uint64|uint64|uint64",
+	)
+
+	var tcases = []struct {
+		name        string
+		inputResult *sqltypes.Result
+		expResult   *sqltypes.Result
+	}{{
+		name: "merge shards",
+		inputResult: sqltypes.MakeTestResult(fields,
+			"12|1|5", "10|4|3", "18446744073709551615|0|0"),
+		expResult: sqltypes.MakeTestResult(fields,
+			"8|5|6"),
+	}, {
+		name:        "empty result",
+		inputResult: sqltypes.MakeTestResult(fields),
+		expResult: sqltypes.MakeTestResult(fields,
+			"18446744073709551615|0|0"),
+	}}
+
+	for _, tcase := range tcases {
+		t.Run(tcase.name, func(t *testing.T) {
+			fp := &fakePrimitive{results: []*sqltypes.Result{tcase.inputResult}}
+			oa := &ScalarAggregate{
+				Aggregates: []*AggregateParams{
+					NewAggregateParam(AggregateBitAnd, 0, ""),
+					NewAggregateParam(AggregateBitOr, 1, ""),
+					NewAggregateParam(AggregateBitXor, 2, ""),
+				},
+				Input: fp,
+			}
+			qr, err := oa.TryExecute(context.Background(), &noopVCursor{}, nil, false)
+			require.NoError(t, err)
+			utils.MustMatch(t, tcase.expResult, qr)
+
+			fp.rewind()
+			results := &sqltypes.Result{}
+			err = oa.TryStreamExecute(context.Background(), &noopVCursor{}, nil, true, func(qr *sqltypes.Result) error {
+				if qr.Fields != nil {
+					results.Fields = qr.Fields
+				}
+				results.Rows = append(results.Rows, qr.Rows...)
+				return nil
+			})
+			require.NoError(t, err)
+			utils.MustMatch(t, tcase.expResult, results)
+		})
+	}
+}
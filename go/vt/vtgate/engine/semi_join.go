@@ -19,15 +19,26 @@ package engine
 import (
 	"context"
 	"fmt"
+	"sort"
 	"strings"
+	"sync"
 
 	"vitess.io/vitess/go/sqltypes"
+	"vitess.io/vitess/go/vt/vtgate/evalengine"
+
 	querypb "vitess.io/vitess/go/vt/proto/query"
 )
 
 var _ Primitive = (*SemiJoin)(nil)
 
 // SemiJoin specifies the parameters for a SemiJoin primitive.
+// An LHS row is kept if the RHS returns any rows for it.
+// When Keys are set, the RHS is executed once per batch of LHS
+// rows, with the values of the LHS join columns bound as lists,
+// and the rows are matched on the join columns by vtgate.
+// Otherwise, the RHS is executed with the values listed in Vars
+// bound, once per distinct set of bound values.
+// The number of LHS rows is capped by the max memory rows setting.
 type SemiJoin struct {
 	// Left and Right are the LHS and RHS primitives
 	// of the SemiJoin. They can be any primitive.
@@ -45,25 +56,48 @@ type SemiJoin struct {
 	// be built from the LHS result before invoking
 	// the RHS subqquery.
 	Vars map[string]int `json:",omitempty"`
+
+	// Keys are the equality comparisons between the LHS and the RHS
+	// columns that the rows are matched on. The distinct values of the
+	// LHS column of every key are bound to the list variable at the
+	// same position in ListVars before invoking the RHS.
+	Keys     []HashJoinKey `json:",omitempty"`
+	ListVars []string      `json:",omitempty"`
 }
 
 // TryExecute performs a non-streaming exec.
 func (jn *SemiJoin) TryExecute(ctx context.Context, vcursor VCursor, bindVars map[string]*querypb.BindVariable, wantfields bool) (*sqltypes.Result, error) {
-	joinVars := make(map[string]*querypb.BindVariable)
 	lresult, err := vcursor.ExecutePrimitive(ctx, jn.Left, bindVars, wantfields)
 	if err != nil {
 		return nil, err
 	}
+	if vcursor.ExceedsMaxMemoryRows(len(lresult.Rows)) {
+		return nil, fmt.Errorf("in-memory row count exceeded allowed limit of %d", vcursor.MaxMemoryRows())
+	}
 	result := &sqltypes.Result{Fields: projectFields(lresult.Fields, jn.Cols)}
-	for _, lrow := range lresult.Rows {
-		for k, col := range jn.Vars {
-			joinVars[k] = sqltypes.ValueBindVariable(lrow[col])
-		}
-		rresult, err := vcursor.ExecutePrimitive(ctx, jn.Right, combineVars(bindVars, joinVars), false)
-		if err != nil {
-			return nil, err
-		}
-		if len(rresult.Rows) > 0 {
+	var matched []bool
+	if len(jn.Keys) > 0 {
+		matched, err = jn.matchKeys(vcursor, lresult.Rows, func(joinVars map[string]*querypb.BindVariable) ([]sqltypes.Row, error) {
+			rresult, err := vcursor.ExecutePrimitive(ctx, jn.Right, combineVars(bindVars, joinVars), false)
+			if err != nil {
+				return nil, err
+			}
+			return rresult.Rows, nil
+		})
+	} else {
+		matched, err = jn.matchBatch(lresult.Rows, func(joinVars map[string]*querypb.BindVariable) (bool, error) {
+			rresult, err := vcursor.ExecutePrimitive(ctx, jn.Right, combineVars(bindVars, joinVars), false)
+			if err != nil {
+				return false, err
+			}
+			return len(rresult.Rows) > 0, nil
+		})
+	}
+	if err != nil {
+		return nil, err
+	}
+	for i, lrow := range lresult.Rows {
+		if matched[i] {
 			result.Rows = append(result.Rows, projectRows(lrow, jn.Cols))
 		}
 	}
@@ -72,23 +106,49 @@ func (jn *SemiJoin) TryExecute(ctx context.Context, vcursor VCursor, bindVars ma
 
 // TryStreamExecute performs a streaming exec.
 func (jn *SemiJoin) TryStreamExecute(ctx context.Context, vcursor VCursor, bindVars map[string]*querypb.BindVariable, wantfields bool, callback func(*sqltypes.Result) error) error {
-	joinVars := make(map[string]*querypb.BindVariable)
+	var mu sync.Mutex
+	outerRows := 0
 	err := vcursor.StreamExecutePrimitive(ctx, jn.Left, bindVars, wantfields, func(lresult *sqltypes.Result) error {
-		result := &sqltypes.Result{Fields: projectFields(lresult.Fields, jn.Cols)}
-		for _, lrow := range lresult.Rows {
-			for k, col := range jn.Vars {
-				joinVars[k] = sqltypes.ValueBindVariable(lrow[col])
-			}
-			rowAdded := false
-			err := vcursor.StreamExecutePrimitive(ctx, jn.Right, combineVars(bindVars, joinVars), false, func(rresult *sqltypes.Result) error {
-				if len(rresult.Rows) > 0 && !rowAdded {
-					result.Rows = append(result.Rows, projectRows(lrow, jn.Cols))
-					rowAdded = true
-				}
-				return nil
+		mu.Lock()
+		outerRows += len(lresult.Rows)
+		exceeded := vcursor.ExceedsMaxMemoryRows(outerRows)
+		mu.Unlock()
+		if exceeded {
+			return fmt.Errorf("in-memory row count exceeded allowed limit of %d", vcursor.MaxMemoryRows())
+		}
+		var matched []bool
+		var err error
+		if len(jn.Keys) > 0 {
+			matched, err = jn.matchKeys(vcursor, lresult.Rows, func(joinVars map[string]*querypb.BindVariable) ([]sqltypes.Row, error) {
+				var rrows []sqltypes.Row
+				err := vcursor.StreamExecutePrimitive(ctx, jn.Right, combineVars(bindVars, joinVars), false, func(rresult *sqltypes.Result) error {
+					rrows = append(rrows, rresult.Rows...)
+					if vcursor.ExceedsMaxMemoryRows(len(rrows)) {
+						return fmt.Errorf("in-memory row count exceeded allowed limit of %d", vcursor.MaxMemoryRows())
+					}
+					return nil
+				})
+				return rrows, err
 			})
-			if err != nil {
-				return err
+		} else {
+			matched, err = jn.matchBatch(lresult.Rows, func(joinVars map[string]*querypb.BindVariable) (bool, error) {
+				rowFound := false
+				err := vcursor.StreamExecutePrimitive(ctx, jn.Right, combineVars(bindVars, joinVars), false, func(rresult *sqltypes.Result) error {
+					if len(rresult.Rows) > 0 {
+						rowFound = true
+					}
+					return nil
+				})
+				return rowFound, err
+			})
+		}
+		if err != nil {
+			return err
+		}
+		result := &sqltypes.Result{Fields: projectFields(lresult.Fields, jn.Cols)}
+		for i, lrow := range lresult.Rows {
+			if matched[i] {
+				result.Rows = append(result.Rows, projectRows(lrow, jn.Cols))
 			}
 		}
 		return callback(result)
@@ -96,6 +156,127 @@ func (jn *SemiJoin) TryStreamExecute(ctx context.Context, vcursor VCursor, bindV
 	return err
 }
 
+// matchBatch binds the join variables of every outer row in the batch and
+// reports which of them have at least one matching row on the RHS.
+// Outer rows that bind the same values share a single evaluation of the RHS.
+func (jn *SemiJoin) matchBatch(rows [][]sqltypes.Value, exists func(joinVars map[string]*querypb.BindVariable) (bool, error)) ([]bool, error) {
+	names := make([]string, 0, len(jn.Vars))
+	for k := range jn.Vars {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	matched := make([]bool, len(rows))
+	seen := make(map[string]bool)
+	for i, lrow := range rows {
+		var key strings.Builder
+		joinVars := make(map[string]*querypb.BindVariable, len(names))
+		for _, name := range names {
+			val := lrow[jn.Vars[name]]
+			joinVars[name] = sqltypes.ValueBindVariable(val)
+			fmt.Fprintf(&key, "%d:%d:%s", val.Type(), val.Len(), val.Raw())
+		}
+		found, ok := seen[key.String()]
+		if !ok {
+			var err error
+			found, err = exists(joinVars)
+			if err != nil {
+				return nil, err
+			}
+			seen[key.String()] = found
+		}
+		matched[i] = found
+	}
+	return matched, nil
+}
+
+// matchKeys binds the distinct values of the join columns of the outer rows in
+// the batch as lists, fetches the matching rows of the RHS with a single evaluation,
+// and reports which of the outer rows have at least one of them with equal join columns.
+func (jn *SemiJoin) matchKeys(vcursor VCursor, rows []sqltypes.Row, fetch func(joinVars map[string]*querypb.BindVariable) ([]sqltypes.Row, error)) ([]bool, error) {
+	matched := make([]bool, len(rows))
+	lists := make([]*querypb.BindVariable, len(jn.Keys))
+	seen := make([]map[string]bool, len(jn.Keys))
+	for i := range jn.Keys {
+		lists[i] = &querypb.BindVariable{Type: querypb.Type_TUPLE}
+		seen[i] = make(map[string]bool)
+	}
+	candidates := 0
+	for _, lrow := range rows {
+		if hasNullKey(jn.Keys, lrow) {
+			// a NULL join column can not be equal to anything
+			continue
+		}
+		candidates++
+		for i, key := range jn.Keys {
+			val := lrow[key.LHS]
+			raw := fmt.Sprintf("%d:%d:%s", val.Type(), val.Len(), val.Raw())
+			if seen[i][raw] {
+				continue
+			}
+			seen[i][raw] = true
+			lists[i].Values = append(lists[i].Values, sqltypes.ValueToProto(val))
+		}
+	}
+	if candidates == 0 {
+		return matched, nil
+	}
+
+	joinVars := make(map[string]*querypb.BindVariable, len(jn.ListVars))
+	for i, name := range jn.ListVars {
+		joinVars[name] = lists[i]
+	}
+	rrows, err := fetch(joinVars)
+	if err != nil {
+		return nil, err
+	}
+	if vcursor.ExceedsMaxMemoryRows(len(rrows)) {
+		return nil, fmt.Errorf("in-memory row count exceeded allowed limit of %d", vcursor.MaxMemoryRows())
+	}
+
+	rhsKey := func(key HashJoinKey) int { return key.RHS }
+	lhsKey := func(key HashJoinKey) int { return key.LHS }
+	probe := make(map[evalengine.HashCode][]sqltypes.Row, len(rrows))
+	for _, rrow := range rrows {
+		code, isNull, err := hashJoinKeysHashcode(jn.Keys, rrow, rhsKey)
+		if err != nil {
+			return nil, err
+		}
+		if !isNull {
+			probe[code] = append(probe[code], rrow)
+		}
+	}
+	for i, lrow := range rows {
+		code, isNull, err := hashJoinKeysHashcode(jn.Keys, lrow, lhsKey)
+		if err != nil {
+			return nil, err
+		}
+		if isNull {
+			continue
+		}
+		for _, rrow := range probe[code] {
+			equal, err := hashJoinKeysEqual(jn.Keys, lrow, rrow)
+			if err != nil {
+				return nil, err
+			}
+			if equal {
+				matched[i] = true
+				break
+			}
+		}
+	}
+	return matched, nil
+}
+
+func hasNullKey(keys []HashJoinKey, lrow sqltypes.Row) bool {
+	for _, key := range keys {
+		if lrow[key.LHS].IsNull() {
+			return true
+		}
+	}
+	return false
+}
+
 // GetFields fetches the field info.
 func (jn *SemiJoin) GetFields(ctx context.Context, vcursor VCursor, bindVars map[string]*querypb.BindVariable) (*sqltypes.Result, error) {
 	return jn.Left.GetFields(ctx, vcursor, bindVars)
@@ -137,6 +318,15 @@ func (jn *SemiJoin) description() PrimitiveDescription {
 	if len(jn.Vars) > 0 {
 		other["JoinVars"] = orderedStringIntMap(jn.Vars)
 	}
+	if len(jn.Keys) > 0 {
+		var keys, comparisonTypes []string
+		for i, key := range jn.Keys {
+			keys = append(keys, fmt.Sprintf("%d:%d:%s", key.LHS, key.RHS, jn.ListVars[i]))
+			comparisonTypes = append(comparisonTypes, key.ComparisonType.String())
+		}
+		other["JoinKeys"] = strings.Join(keys, ",")
+		other["ComparisonType"] = strings.Join(comparisonTypes, ",")
+	}
 	return PrimitiveDescription{
 		OperatorType: "SemiJoin",
 		Other:        other,
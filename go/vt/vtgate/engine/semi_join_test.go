@@ -161,3 +161,217 @@ func TestSemiJoinStreamExecute(t *testing.T) {
 		"4|d|dd",
 	))
 }
+
+func TestSemiJoinExecuteDuplicateBindings(t *testing.T) {
+	leftPrim := &fakePrimitive{
+		results: []*sqltypes.Result{
+			sqltypes.MakeTestResult(
+				sqltypes.MakeTestFields(
+					"col1|col2",
+					"int64|varchar",
+				),
+				"1|a",
+				"2|b",
+				"3|a",
+				"4|b",
+			),
+		},
+	}
+	rightFields := sqltypes.MakeTestFields(
+		"col3",
+		"int64",
+	)
+	rightPrim := &fakePrimitive{
+		results: []*sqltypes.Result{
+			sqltypes.MakeTestResult(
+				rightFields,
+			),
+			sqltypes.MakeTestResult(
+				rightFields,
+				"1",
+			),
+		},
+	}
+
+	jn := &SemiJoin{
+		Left:  leftPrim,
+		Right: rightPrim,
+		Vars: map[string]int{
+			"bv": 1,
+		},
+		Cols: []int{-1},
+	}
+	r, err := jn.TryExecute(context.Background(), &noopVCursor{}, map[string]*querypb.BindVariable{}, true)
+	require.NoError(t, err)
+	rightPrim.ExpectLog(t, []string{
+		`Execute bv: type:VARCHAR value:"a" false`,
+		`Execute bv: type:VARCHAR value:"b" false`,
+	})
+	utils.MustMatch(t, sqltypes.MakeTestResult(
+		sqltypes.MakeTestFields(
+			"col1",
+			"int64",
+		),
+		"2",
+		"4",
+	), r)
+}
+
+func TestSemiJoinExecuteMaxMemoryRows(t *testing.T) {
+	saveMax := testMaxMemoryRows
+	saveIgnore := testIgnoreMaxMemoryRows
+	testMaxMemoryRows = 2
+	defer func() {
+		testMaxMemoryRows = saveMax
+		testIgnoreMaxMemoryRows = saveIgnore
+	}()
+
+	leftResult := sqltypes.MakeTestResult(
+		sqltypes.MakeTestFields(
+			"col1|col2",
+			"int64|varchar",
+		),
+		"1|a",
+		"2|b",
+		"3|c",
+	)
+	testCases := []struct {
+		ignoreMaxMemoryRows bool
+		err                 string
+	}{
+		{
+			ignoreMaxMemoryRows: true,
+		},
+		{
+			ignoreMaxMemoryRows: false,
+			err:                 "in-memory row count exceeded allowed limit of 2",
+		},
+	}
+	for _, test := range testCases {
+		jn := &SemiJoin{
+			Left: &fakePrimitive{results: []*sqltypes.Result{leftResult}},
+			Right: &fakePrimitive{results: []*sqltypes.Result{
+				sqltypes.MakeTestResult(sqltypes.MakeTestFields("col3", "int64"), "1"),
+				sqltypes.MakeTestResult(sqltypes.MakeTestFields("col3", "int64"), "1"),
+				sqltypes.MakeTestResult(sqltypes.MakeTestFields("col3", "int64"), "1"),
+			}},
+			Vars: map[string]int{
+				"bv": 1,
+			},
+			Cols: []int{-1},
+		}
+		testIgnoreMaxMemoryRows = test.ignoreMaxMemoryRows
+		_, err := jn.TryExecute(context.Background(), &noopVCursor{}, map[string]*querypb.BindVariable{}, true)
+		if test.ignoreMaxMemoryRows {
+			require.NoError(t, err)
+		} else {
+			require.EqualError(t, err, test.err)
+		}
+	}
+}
+
+func TestSemiJoinExecuteKeys(t *testing.T) {
+	leftPrim := &fakePrimitive{
+		results: []*sqltypes.Result{
+			sqltypes.MakeTestResult(
+				sqltypes.MakeTestFields(
+					"col1|col2",
+					"int64|int64",
+				),
+				"1|10",
+				"2|20",
+				"3|10",
+				"4|null",
+				"5|30",
+			),
+		},
+	}
+	rightPrim := &fakePrimitive{
+		results: []*sqltypes.Result{
+			sqltypes.MakeTestResult(
+				sqltypes.MakeTestFields(
+					"col3",
+					"int64",
+				),
+				"10",
+				"10",
+			),
+		},
+	}
+
+	jn := &SemiJoin{
+		Left:     leftPrim,
+		Right:    rightPrim,
+		Keys:     []HashJoinKey{{LHS: 1, RHS: 0, ComparisonType: querypb.Type_INT64}},
+		ListVars: []string{"__sj_vals"},
+		Cols:     []int{-1},
+	}
+	r, err := jn.TryExecute(context.Background(), &noopVCursor{}, map[string]*querypb.BindVariable{}, true)
+	require.NoError(t, err)
+	// the RHS is executed once for all the LHS rows
+	rightPrim.ExpectLog(t, []string{
+		`Execute __sj_vals: type:TUPLE values:{type:INT64 value:"10"} values:{type:INT64 value:"20"} values:{type:INT64 value:"30"} false`,
+	})
+	utils.MustMatch(t, sqltypes.MakeTestResult(
+		sqltypes.MakeTestFields(
+			"col1",
+			"int64",
+		),
+		"1",
+		"3",
+	), r)
+}
+
+func TestSemiJoinStreamExecuteKeys(t *testing.T) {
+	leftPrim := &fakePrimitive{
+		results: []*sqltypes.Result{
+			sqltypes.MakeTestResult(
+				sqltypes.MakeTestFields(
+					"col1|col2",
+					"int64|int64",
+				),
+				"1|10",
+				"2|20",
+				"3|10",
+				"4|null",
+			),
+		},
+	}
+	rightFields := sqltypes.MakeTestFields(
+		"col3",
+		"int64",
+	)
+	rightPrim := &fakePrimitive{
+		results: []*sqltypes.Result{
+			sqltypes.MakeTestResult(
+				rightFields,
+				"20",
+			),
+			sqltypes.MakeTestResult(
+				rightFields,
+			),
+		},
+	}
+
+	jn := &SemiJoin{
+		Left:     leftPrim,
+		Right:    rightPrim,
+		Keys:     []HashJoinKey{{LHS: 1, RHS: 0, ComparisonType: querypb.Type_INT64}},
+		ListVars: []string{"__sj_vals"},
+		Cols:     []int{-1},
+	}
+	r, err := wrapStreamExecute(jn, &noopVCursor{}, map[string]*querypb.BindVariable{}, true)
+	require.NoError(t, err)
+	// the LHS rows are streamed two at a time, and the RHS is executed once per batch
+	rightPrim.ExpectLog(t, []string{
+		`StreamExecute __sj_vals: type:TUPLE values:{type:INT64 value:"10"} values:{type:INT64 value:"20"} false`,
+		`StreamExecute __sj_vals: type:TUPLE values:{type:INT64 value:"10"} false`,
+	})
+	expectResult(t, "jn.Execute", r, sqltypes.MakeTestResult(
+		sqltypes.MakeTestFields(
+			"col1",
+			"int64",
+		),
+		"2",
+	))
+}
@@ -19,6 +19,7 @@ package engine
 import (
 	"context"
 
+	"vitess.io/vitess/go/mysql/collations"
 	"vitess.io/vitess/go/sqltypes"
 	"vitess.io/vitess/go/vt/proto/query"
 	"vitess.io/vitess/go/vt/sqlparser"
@@ -26,6 +27,29 @@ import (
 
 var _ Primitive = (*ShowExec)(nil)
 
+// QueryDigestsFields are the columns of the statistics of the query digests of a vtgate,
+// as returned by SHOW VITESS_QUERY_DIGESTS and the vitess_query_digests table.
+var QueryDigestsFields = []*query.Field{
+	{Name: "Keyspace", Type: sqltypes.VarChar, Charset: collations.CollationUtf8ID, Flags: uint32(query.MySqlFlag_NOT_NULL_FLAG)},
+	{Name: "Digest", Type: sqltypes.VarChar, Charset: collations.CollationUtf8ID},
+	{Name: "DigestText", Type: sqltypes.VarChar, Charset: collations.CollationUtf8ID},
+	{Name: "Count", Type: sqltypes.Uint64, Charset: collations.CollationBinaryID, Flags: uint32(query.MySqlFlag_NOT_NULL_FLAG | query.MySqlFlag_UNSIGNED_FLAG)},
+	{Name: "Errors", Type: sqltypes.Uint64, Charset: collations.CollationBinaryID, Flags: uint32(query.MySqlFlag_NOT_NULL_FLAG | query.MySqlFlag_UNSIGNED_FLAG)},
+	{Name: "TotalTime", Type: sqltypes.Float64, Charset: collations.CollationBinaryID, Flags: uint32(query.MySqlFlag_NOT_NULL_FLAG)},
+	{Name: "AvgTime", Type: sqltypes.Float64, Charset: collations.CollationBinaryID, Flags: uint32(query.MySqlFlag_NOT_NULL_FLAG)},
+	{Name: "MinTime", Type: sqltypes.Float64, Charset: collations.CollationBinaryID, Flags: uint32(query.MySqlFlag_NOT_NULL_FLAG)},
+	{Name: "MaxTime", Type: sqltypes.Float64, Charset: collations.CollationBinaryID, Flags: uint32(query.MySqlFlag_NOT_NULL_FLAG)},
+	{Name: "P50Time", Type: sqltypes.Float64, Charset: collations.CollationBinaryID, Flags: uint32(query.MySqlFlag_NOT_NULL_FLAG)},
+	{Name: "P95Time", Type: sqltypes.Float64, Charset: collations.CollationBinaryID, Flags: uint32(query.MySqlFlag_NOT_NULL_FLAG)},
+	{Name: "P99Time", Type: sqltypes.Float64, Charset: collations.CollationBinaryID, Flags: uint32(query.MySqlFlag_NOT_NULL_FLAG)},
+	{Name: "RowsAffected", Type: sqltypes.Uint64, Charset: collations.CollationBinaryID, Flags: uint32(query.MySqlFlag_NOT_NULL_FLAG | query.MySqlFlag_UNSIGNED_FLAG)},
+	{Name: "RowsReturned", Type: sqltypes.Uint64, Charset: collations.CollationBinaryID, Flags: uint32(query.MySqlFlag_NOT_NULL_FLAG | query.MySqlFlag_UNSIGNED_FLAG)},
+	{Name: "ShardQueries", Type: sqltypes.Uint64, Charset: collations.CollationBinaryID, Flags: uint32(query.MySqlFlag_NOT_NULL_FLAG | query.MySqlFlag_UNSIGNED_FLAG)},
+	{Name: "MaxShardQueries", Type: sqltypes.Uint64, Charset: collations.CollationBinaryID, Flags: uint32(query.MySqlFlag_NOT_NULL_FLAG | query.MySqlFlag_UNSIGNED_FLAG)},
+	{Name: "FirstSeen", Type: sqltypes.Datetime, Charset: collations.CollationBinaryID, Flags: uint32(query.MySqlFlag_NOT_NULL_FLAG)},
+	{Name: "LastSeen", Type: sqltypes.Datetime, Charset: collations.CollationBinaryID, Flags: uint32(query.MySqlFlag_NOT_NULL_FLAG)},
+}
+
 // ShowExec is a primitive to call into executor via vcursor.
 type ShowExec struct {
 	Command    sqlparser.ShowCommandType
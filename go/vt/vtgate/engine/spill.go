@@ -0,0 +1,340 @@
+/*
+Copyright 2023 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package engine
+
+import (
+	"bufio"
+	"container/heap"
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+	"sort"
+
+	"vitess.io/vitess/go/sqltypes"
+	querypb "vitess.io/vitess/go/vt/proto/query"
+)
+
+// spillFile is a temporary file used by primitives to hold rows that do not fit in memory.
+// Rows are appended using write, and can be read back in the same order once the
+// file has been rewound. The file is removed from disk when it is closed.
+type spillFile struct {
+	file *os.File
+	w    *bufio.Writer
+	rows int
+	buf  []byte
+}
+
+func newSpillFile(dir string) (*spillFile, error) {
+	file, err := os.CreateTemp(dir, "vtgate-spill-")
+	if err != nil {
+		return nil, err
+	}
+	return &spillFile{
+		file: file,
+		w:    bufio.NewWriter(file),
+	}, nil
+}
+
+// write appends a row to the file. Each row is encoded as the number of values,
+// followed by the type and the length-prefixed raw bytes of each value.
+func (sf *spillFile) write(row sqltypes.Row) error {
+	sf.buf = binary.AppendUvarint(sf.buf[:0], uint64(len(row)))
+	for _, val := range row {
+		raw := val.Raw()
+		sf.buf = binary.AppendUvarint(sf.buf, uint64(val.Type()))
+		sf.buf = binary.AppendUvarint(sf.buf, uint64(len(raw)))
+		sf.buf = append(sf.buf, raw...)
+	}
+	if _, err := sf.w.Write(sf.buf); err != nil {
+		return err
+	}
+	sf.rows++
+	return nil
+}
+
+// rewind flushes all buffered rows to disk and returns a reader
+// positioned at the first row of the file.
+func (sf *spillFile) rewind() (*spillReader, error) {
+	if err := sf.w.Flush(); err != nil {
+		return nil, err
+	}
+	if _, err := sf.file.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	return &spillReader{r: bufio.NewReader(sf.file)}, nil
+}
+
+// close closes and removes the file.
+func (sf *spillFile) close() error {
+	return errors.Join(sf.file.Close(), os.Remove(sf.file.Name()))
+}
+
+// spillReader reads back the rows written to a spillFile.
+type spillReader struct {
+	r *bufio.Reader
+}
+
+// next returns the next row in the file, or io.EOF when there are no more rows.
+func (sr *spillReader) next() (sqltypes.Row, error) {
+	count, err := binary.ReadUvarint(sr.r)
+	if err != nil {
+		return nil, err
+	}
+	row := make(sqltypes.Row, count)
+	for i := range row {
+		typ, err := binary.ReadUvarint(sr.r)
+		if err != nil {
+			return nil, io.ErrUnexpectedEOF
+		}
+		size, err := binary.ReadUvarint(sr.r)
+		if err != nil {
+			return nil, io.ErrUnexpectedEOF
+		}
+		var raw []byte
+		if size > 0 {
+			raw = make([]byte, size)
+			if _, err := io.ReadFull(sr.r, raw); err != nil {
+				return nil, io.ErrUnexpectedEOF
+			}
+		}
+		row[i] = sqltypes.MakeTrusted(querypb.Type(typ), raw)
+	}
+	return row, nil
+}
+
+// spillBatchSize is the number of rows sent in a single result when streaming spilled rows back.
+const spillBatchSize = 1000
+
+// spillSorter sorts more rows than fit in memory. Primitives buffer rows in memory and
+// hand them to spill once the memory limit is reached; they are then sorted and written
+// to disk as a sorted run. All the runs are merged back together, in order, by merge.
+type spillSorter struct {
+	dir       string
+	comparers []*comparer
+	runs      []*spillFile
+}
+
+func newSpillSorter(dir string, comparers []*comparer) *spillSorter {
+	return &spillSorter{
+		dir:       dir,
+		comparers: comparers,
+	}
+}
+
+// spill sorts the given rows and writes them to a new run on disk.
+func (ss *spillSorter) spill(rows []sqltypes.Row) error {
+	if err := ss.sort(rows); err != nil {
+		return err
+	}
+	run, err := newSpillFile(ss.dir)
+	if err != nil {
+		return err
+	}
+	ss.runs = append(ss.runs, run)
+	for _, row := range rows {
+		if err := run.write(row); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// merge sorts the rows that are still in memory and merges them with all the spilled runs,
+// calling f for each row in order. At most limit rows are returned.
+func (ss *spillSorter) merge(rows []sqltypes.Row, limit int, f func(sqltypes.Row) error) error {
+	if err := ss.sort(rows); err != nil {
+		return err
+	}
+	mh := &mergeHeap{comparers: ss.comparers}
+	for idx, run := range ss.runs {
+		reader, err := run.rewind()
+		if err != nil {
+			return err
+		}
+		if err := mh.add(&sortedRun{idx: idx, reader: reader}); err != nil {
+			return err
+		}
+	}
+	if err := mh.add(&sortedRun{idx: len(ss.runs), rows: rows}); err != nil {
+		return err
+	}
+	heap.Init(mh)
+	if mh.err != nil {
+		return mh.err
+	}
+
+	for count := 0; count < limit && mh.Len() > 0; count++ {
+		run := mh.runs[0]
+		if err := f(run.row); err != nil {
+			return err
+		}
+		more, err := run.advance()
+		if err != nil {
+			return err
+		}
+		if more {
+			heap.Fix(mh, 0)
+		} else {
+			heap.Pop(mh)
+		}
+		if mh.err != nil {
+			return mh.err
+		}
+	}
+	return nil
+}
+
+func (ss *spillSorter) sort(rows []sqltypes.Row) error {
+	sh := &sortHeap{
+		rows:      rows,
+		comparers: ss.comparers,
+	}
+	sort.Sort(sh)
+	return sh.err
+}
+
+// close removes all the runs from disk.
+func (ss *spillSorter) close() error {
+	var errs []error
+	for _, run := range ss.runs {
+		errs = append(errs, run.close())
+	}
+	ss.runs = nil
+	return errors.Join(errs...)
+}
+
+// sortedRun is a sorted sequence of rows, that either lives on disk or in memory.
+type sortedRun struct {
+	idx    int
+	reader *spillReader
+	rows   []sqltypes.Row
+	row    sqltypes.Row
+}
+
+// advance moves to the next row of the run. It returns false when the run is exhausted.
+func (sr *sortedRun) advance() (bool, error) {
+	if sr.reader == nil {
+		if len(sr.rows) == 0 {
+			return false, nil
+		}
+		sr.row, sr.rows = sr.rows[0], sr.rows[1:]
+		return true, nil
+	}
+	row, err := sr.reader.next()
+	if err == io.EOF {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	sr.row = row
+	return true, nil
+}
+
+// mergeHeap keeps the current row of each sortedRun ordered, so that the
+// smallest row of all the runs is always at the top of the heap.
+// Rows that compare as equal are returned in the order of their runs.
+type mergeHeap struct {
+	runs      []*sortedRun
+	comparers []*comparer
+	err       error
+}
+
+func (mh *mergeHeap) add(run *sortedRun) error {
+	more, err := run.advance()
+	if err != nil || !more {
+		return err
+	}
+	mh.runs = append(mh.runs, run)
+	return nil
+}
+
+// Len satisfies heap.Interface.
+func (mh *mergeHeap) Len() int {
+	return len(mh.runs)
+}
+
+// Less satisfies heap.Interface.
+func (mh *mergeHeap) Less(i, j int) bool {
+	if mh.err != nil {
+		return true
+	}
+	cmp, err := compareRows(mh.comparers, mh.runs[i].row, mh.runs[j].row)
+	if err != nil {
+		mh.err = err
+		return true
+	}
+	if cmp == 0 {
+		return mh.runs[i].idx < mh.runs[j].idx
+	}
+	return cmp < 0
+}
+
+// Swap satisfies heap.Interface.
+func (mh *mergeHeap) Swap(i, j int) {
+	mh.runs[i], mh.runs[j] = mh.runs[j], mh.runs[i]
+}
+
+// Push satisfies heap.Interface.
+func (mh *mergeHeap) Push(x any) {
+	mh.runs = append(mh.runs, x.(*sortedRun))
+}
+
+// Pop satisfies heap.Interface.
+func (mh *mergeHeap) Pop() any {
+	n := len(mh.runs)
+	x := mh.runs[n-1]
+	mh.runs = mh.runs[:n-1]
+	return x
+}
+
+// compareRows compares two rows using all the comparers in order
+func compareRows(comparers []*comparer, r1, r2 sqltypes.Row) (int, error) {
+	for _, c := range comparers {
+		cmp, err := c.compare(r1, r2)
+		if err != nil {
+			return 0, err
+		}
+		if cmp != 0 {
+			return cmp, nil
+		}
+	}
+	return 0, nil
+}
+
+// executeStreamed runs the given stream execution and collects everything that is sent to
+// the callback into a single result. Primitives that can spill use it in their non-streaming
+// execute path when spilling is enabled, so that their input is streamed to them instead of
+// being loaded into memory as a whole.
+func executeStreamed(streamExecute func(func(*sqltypes.Result) error) error) (*sqltypes.Result, error) {
+	result := &sqltypes.Result{}
+	err := streamExecute(func(qr *sqltypes.Result) error {
+		if len(result.Fields) == 0 {
+			result.Fields = qr.Fields
+		}
+		if result.InsertID == 0 {
+			result.InsertID = qr.InsertID
+		}
+		result.Rows = append(result.Rows, qr.Rows...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
@@ -0,0 +1,391 @@
+/*
+Copyright 2023 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package engine
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"vitess.io/vitess/go/mysql/collations"
+	"vitess.io/vitess/go/sqltypes"
+	querypb "vitess.io/vitess/go/vt/proto/query"
+	"vitess.io/vitess/go/vt/sqlparser"
+	. "vitess.io/vitess/go/vt/vtgate/engine/opcode"
+	"vitess.io/vitess/go/vt/vtgate/evalengine"
+)
+
+var _ Primitive = (*Window)(nil)
+
+// Window is a primitive that evaluates window functions at the vtgate level.
+// It expects the underlying primitive to feed results sorted by the
+// PartitionBy keys, followed by the OrderBy keys of the window.
+// The result of each window function is added in front of the input columns,
+// so the offsets of the window functions do not depend on the input.
+type Window struct {
+	// Functions specifies the window functions to evaluate.
+	Functions []*WindowParams
+
+	// PartitionBy specifies the input values that identify a partition.
+	PartitionBy []*GroupByParams
+
+	// OrderBy specifies the input values used to find peer rows inside a partition.
+	OrderBy []OrderByParams
+
+	// TruncateColumnCount specifies the number of columns to return
+	// in the final result. Rest of the columns are truncated
+	// from the result received. If 0, no truncation happens.
+	TruncateColumnCount int `json:",omitempty"`
+
+	// Input is the primitive that will feed into this Primitive.
+	Input Primitive
+}
+
+// WindowParams specify the parameters for each window function.
+type WindowParams struct {
+	Opcode WindowOpcode
+
+	// Col is the input column read by the functions that take an argument, -1 otherwise.
+	Col int
+
+	// Offset is the N argument of LAG and LEAD.
+	Offset int
+
+	// Default is the value LAG and LEAD return when the row at Offset does not exist.
+	// A nil Default means NULL.
+	Default evalengine.Expr
+
+	Alias    string `json:",omitempty"`
+	Original *sqlparser.AliasedExpr
+}
+
+// String returns a string. Used for plan descriptions
+func (wp *WindowParams) String() string {
+	var arg string
+	if wp.Opcode.NeedsArgument() {
+		arg = strconv.Itoa(wp.Col)
+	}
+	if wp.Opcode == WindowLag || wp.Opcode == WindowLead {
+		arg = fmt.Sprintf("%s, %d", arg, wp.Offset)
+		if wp.Default != nil {
+			arg += ", " + evalengine.FormatExpr(wp.Default)
+		}
+	}
+	if wp.Alias != "" {
+		return fmt.Sprintf("%s(%s) AS %s", wp.Opcode.String(), arg, wp.Alias)
+	}
+	return fmt.Sprintf("%s(%s)", wp.Opcode.String(), arg)
+}
+
+// RouteType returns a description of the query routing type used by the primitive
+func (w *Window) RouteType() string {
+	return w.Input.RouteType()
+}
+
+// GetKeyspaceName specifies the Keyspace that this primitive routes to.
+func (w *Window) GetKeyspaceName() string {
+	return w.Input.GetKeyspaceName()
+}
+
+// GetTableName specifies the table that this primitive routes to.
+func (w *Window) GetTableName() string {
+	return w.Input.GetTableName()
+}
+
+// SetTruncateColumnCount sets the truncate column count.
+func (w *Window) SetTruncateColumnCount(count int) {
+	w.TruncateColumnCount = count
+}
+
+// TryExecute is a Primitive function.
+func (w *Window) TryExecute(ctx context.Context, vcursor VCursor, bindVars map[string]*querypb.BindVariable, _ bool) (*sqltypes.Result, error) {
+	result, err := vcursor.ExecutePrimitive(
+		ctx,
+		w.Input,
+		bindVars,
+		true, /*wantFields - we need the input fields types to correctly calculate the output types*/
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	env := evalengine.NewExpressionEnv(ctx, bindVars, vcursor)
+	out := &sqltypes.Result{
+		Fields: w.convertFields(result.Fields),
+		Rows:   make([]sqltypes.Row, 0, len(result.Rows)),
+	}
+
+	// This code is similar to the one in StreamExecute.
+	start := 0
+	for idx := 1; idx <= len(result.Rows); idx++ {
+		if idx < len(result.Rows) {
+			same, err := w.samePartition(result.Rows[start], result.Rows[idx])
+			if err != nil {
+				return nil, err
+			}
+			if same {
+				continue
+			}
+		}
+		rows, err := w.evaluatePartition(env, result.Rows[start:idx])
+		if err != nil {
+			return nil, err
+		}
+		out.Rows = append(out.Rows, rows...)
+		start = idx
+	}
+
+	return out.Truncate(w.TruncateColumnCount), nil
+}
+
+// TryStreamExecute is a Primitive function.
+func (w *Window) TryStreamExecute(ctx context.Context, vcursor VCursor, bindVars map[string]*querypb.BindVariable, _ bool, callback func(*sqltypes.Result) error) error {
+	env := evalengine.NewExpressionEnv(ctx, bindVars, vcursor)
+	var partition []sqltypes.Row
+
+	cb := func(qr *sqltypes.Result) error {
+		return callback(qr.Truncate(w.TruncateColumnCount))
+	}
+
+	flush := func() error {
+		if len(partition) == 0 {
+			return nil
+		}
+		rows, err := w.evaluatePartition(env, partition)
+		if err != nil {
+			return err
+		}
+		partition = nil
+		return cb(&sqltypes.Result{Rows: rows})
+	}
+
+	visitor := func(qr *sqltypes.Result) error {
+		if len(qr.Fields) != 0 {
+			if err := cb(&sqltypes.Result{Fields: w.convertFields(qr.Fields)}); err != nil {
+				return err
+			}
+		}
+		// This code is similar to the one in Execute.
+		for _, row := range qr.Rows {
+			if len(partition) > 0 {
+				same, err := w.samePartition(partition[0], row)
+				if err != nil {
+					return err
+				}
+				if !same {
+					// this is a new partition. let's yield the old one, and start a new
+					if err := flush(); err != nil {
+						return err
+					}
+				}
+			}
+			partition = append(partition, row)
+		}
+		return nil
+	}
+
+	err := vcursor.StreamExecutePrimitive(ctx,
+		w.Input,
+		bindVars,
+		true, /* we need the input fields types to correctly calculate the output types */
+		visitor)
+	if err != nil {
+		return err
+	}
+
+	return flush()
+}
+
+// GetFields is a Primitive function.
+func (w *Window) GetFields(ctx context.Context, vcursor VCursor, bindVars map[string]*querypb.BindVariable) (*sqltypes.Result, error) {
+	qr, err := w.Input.GetFields(ctx, vcursor, bindVars)
+	if err != nil {
+		return nil, err
+	}
+	qr = &sqltypes.Result{Fields: w.convertFields(qr.Fields)}
+	return qr.Truncate(w.TruncateColumnCount), nil
+}
+
+// Inputs returns the Primitive input for this window
+func (w *Window) Inputs() []Primitive {
+	return []Primitive{w.Input}
+}
+
+// NeedsTransaction implements the Primitive interface
+func (w *Window) NeedsTransaction() bool {
+	return w.Input.NeedsTransaction()
+}
+
+func (w *Window) convertFields(fields []*querypb.Field) []*querypb.Field {
+	if fields == nil {
+		return nil
+	}
+	out := make([]*querypb.Field, 0, len(w.Functions)+len(fields))
+	for _, fn := range w.Functions {
+		var argType *querypb.Type
+		if fn.Opcode.NeedsArgument() {
+			argType = &fields[fn.Col].Type
+		}
+		name := fn.Alias
+		if name == "" && fn.Original != nil {
+			name = fn.Original.ColumnName()
+		}
+		out = append(out, &querypb.Field{
+			Name: name,
+			Type: fn.Opcode.Type(argType),
+		})
+	}
+	return append(out, fields...)
+}
+
+// evaluatePartition calculates the window functions for all the rows of a single partition
+func (w *Window) evaluatePartition(env *evalengine.ExpressionEnv, rows []sqltypes.Row) ([]sqltypes.Row, error) {
+	// peerStart and peerEnd hold, for every row, the boundaries of the group of rows
+	// that are equal to it according to the ORDER BY of the window
+	peerStart := make([]int, len(rows))
+	peerEnd := make([]int, len(rows))
+	denseRank := make([]int, len(rows))
+	start, rank := 0, 1
+	for idx := 1; idx <= len(rows); idx++ {
+		if idx < len(rows) {
+			peers, err := w.peers(rows[start], rows[idx])
+			if err != nil {
+				return nil, err
+			}
+			if peers {
+				continue
+			}
+		}
+		for i := start; i < idx; i++ {
+			peerStart[i] = start
+			peerEnd[i] = idx
+			denseRank[i] = rank
+		}
+		start = idx
+		rank++
+	}
+
+	size := len(rows)
+	out := make([]sqltypes.Row, 0, size)
+	for idx, row := range rows {
+		outRow := make(sqltypes.Row, 0, len(w.Functions)+len(row))
+		for _, fn := range w.Functions {
+			var val sqltypes.Value
+			switch fn.Opcode {
+			case WindowRowNumber:
+				val = sqltypes.NewUint64(uint64(idx + 1))
+			case WindowRank:
+				val = sqltypes.NewUint64(uint64(peerStart[idx] + 1))
+			case WindowDenseRank:
+				val = sqltypes.NewUint64(uint64(denseRank[idx]))
+			case WindowPercentRank:
+				if size == 1 {
+					val = sqltypes.NewFloat64(0)
+				} else {
+					val = sqltypes.NewFloat64(float64(peerStart[idx]) / float64(size-1))
+				}
+			case WindowCumeDist:
+				val = sqltypes.NewFloat64(float64(peerEnd[idx]) / float64(size))
+			case WindowLag, WindowLead:
+				other := idx - fn.Offset
+				if fn.Opcode == WindowLead {
+					other = idx + fn.Offset
+				}
+				if other >= 0 && other < size {
+					val = rows[other][fn.Col]
+					break
+				}
+				if fn.Default == nil {
+					val = sqltypes.NULL
+					break
+				}
+				env.Row = row
+				res, err := env.Evaluate(fn.Default)
+				if err != nil {
+					return nil, err
+				}
+				val = res.Value()
+			case WindowFirstValue:
+				val = rows[0][fn.Col]
+			default:
+				return nil, fmt.Errorf("unsupported window function: %s", fn.Opcode.String())
+			}
+			outRow = append(outRow, val)
+		}
+		out = append(out, append(outRow, row...))
+	}
+	return out, nil
+}
+
+func (w *Window) samePartition(row1, row2 sqltypes.Row) (bool, error) {
+	for _, gb := range w.PartitionBy {
+		equal, err := windowValuesEqual(row1, row2, gb.KeyCol, gb.WeightStringCol, gb.CollationID)
+		if err != nil || !equal {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+func (w *Window) peers(row1, row2 sqltypes.Row) (bool, error) {
+	for _, order := range w.OrderBy {
+		equal, err := windowValuesEqual(row1, row2, order.Col, order.WeightStringCol, order.CollationID)
+		if err != nil || !equal {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+func windowValuesEqual(row1, row2 sqltypes.Row, col, wsCol int, collationID collations.ID) (bool, error) {
+	cmp, err := evalengine.NullsafeCompare(row1[col], row2[col], collationID)
+	if err != nil {
+		_, isComparisonErr := err.(evalengine.UnsupportedComparisonError)
+		_, isCollationErr := err.(evalengine.UnsupportedCollationError)
+		if !isComparisonErr && !isCollationErr || wsCol == -1 {
+			return false, err
+		}
+		cmp, err = evalengine.NullsafeCompare(row1[wsCol], row2[wsCol], collationID)
+		if err != nil {
+			return false, err
+		}
+	}
+	return cmp == 0, nil
+}
+
+func windowParamsToString(in any) string {
+	return in.(*WindowParams).String()
+}
+
+func (w *Window) description() PrimitiveDescription {
+	other := map[string]any{
+		"Functions": GenericJoin(w.Functions, windowParamsToString),
+	}
+	if len(w.PartitionBy) > 0 {
+		other["PartitionBy"] = GenericJoin(w.PartitionBy, groupByParamsToString)
+	}
+	if len(w.OrderBy) > 0 {
+		other["OrderBy"] = GenericJoin(w.OrderBy, orderByParamsToString)
+	}
+	if w.TruncateColumnCount > 0 {
+		other["ResultColumns"] = w.TruncateColumnCount
+	}
+	return PrimitiveDescription{
+		OperatorType: "Window",
+		Other:        other,
+	}
+}
@@ -0,0 +1,167 @@
+/*
+Copyright 2023 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package engine
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"vitess.io/vitess/go/sqltypes"
+	"vitess.io/vitess/go/test/utils"
+	. "vitess.io/vitess/go/vt/vtgate/engine/opcode"
+	"vitess.io/vitess/go/vt/vtgate/evalengine"
+)
+
+func TestWindowExecute(t *testing.T) {
+	fp := &fakePrimitive{
+		results: []*sqltypes.Result{sqltypes.MakeTestResult(
+			sqltypes.MakeTestFields(
+				"user_id|ts",
+				"int64|int64",
+			),
+			"1|10",
+			"1|20",
+			"1|20",
+			"1|30",
+			"2|10",
+			"3|5",
+			"3|5",
+		)},
+	}
+
+	w := &Window{
+		Functions: []*WindowParams{
+			{Opcode: WindowRowNumber, Col: -1, Alias: "rn"},
+			{Opcode: WindowRank, Col: -1, Alias: "rk"},
+			{Opcode: WindowDenseRank, Col: -1, Alias: "drk"},
+		},
+		PartitionBy: []*GroupByParams{{KeyCol: 0, WeightStringCol: -1}},
+		OrderBy:     []OrderByParams{{Col: 1, WeightStringCol: -1}},
+		Input:       fp,
+	}
+
+	result, err := w.TryExecute(context.Background(), &noopVCursor{}, nil, true)
+	require.NoError(t, err)
+
+	wantResult := sqltypes.MakeTestResult(
+		sqltypes.MakeTestFields(
+			"rn|rk|drk|user_id|ts",
+			"uint64|uint64|uint64|int64|int64",
+		),
+		"1|1|1|1|10",
+		"2|2|2|1|20",
+		"3|2|2|1|20",
+		"4|4|3|1|30",
+		"1|1|1|2|10",
+		"1|1|1|3|5",
+		"2|1|1|3|5",
+	)
+	utils.MustMatch(t, wantResult, result)
+}
+
+func TestWindowExecuteDistributionAndOffsets(t *testing.T) {
+	fp := &fakePrimitive{
+		results: []*sqltypes.Result{sqltypes.MakeTestResult(
+			sqltypes.MakeTestFields(
+				"grp|val",
+				"varchar|int64",
+			),
+			"a|1",
+			"a|2",
+			"a|2",
+			"b|7",
+		)},
+	}
+
+	collationID, _ := collationEnv.LookupID("utf8mb4_0900_ai_ci")
+	w := &Window{
+		Functions: []*WindowParams{
+			{Opcode: WindowPercentRank, Col: -1, Alias: "pr"},
+			{Opcode: WindowCumeDist, Col: -1, Alias: "cd"},
+			{Opcode: WindowLag, Col: 1, Offset: 1, Default: evalengine.NewLiteralInt(-1), Alias: "lg"},
+			{Opcode: WindowLead, Col: 1, Offset: 2, Alias: "ld"},
+			{Opcode: WindowFirstValue, Col: 1, Alias: "fv"},
+		},
+		PartitionBy:         []*GroupByParams{{KeyCol: 0, WeightStringCol: -1, CollationID: collationID}},
+		OrderBy:             []OrderByParams{{Col: 1, WeightStringCol: -1}},
+		TruncateColumnCount: 6,
+		Input:               fp,
+	}
+
+	result, err := w.TryExecute(context.Background(), &noopVCursor{}, nil, true)
+	require.NoError(t, err)
+
+	wantResult := sqltypes.MakeTestResult(
+		sqltypes.MakeTestFields(
+			"pr|cd|lg|ld|fv|grp",
+			"float64|float64|int64|int64|int64|varchar",
+		),
+		"0|0.3333333333333333|-1|2|1|a",
+		"0.5|1|1|null|1|a",
+		"0.5|1|2|null|1|a",
+		"0|1|-1|null|7|b",
+	)
+	utils.MustMatch(t, wantResult, result)
+}
+
+func TestWindowStreamExecute(t *testing.T) {
+	fields := sqltypes.MakeTestFields(
+		"user_id|ts",
+		"int64|int64",
+	)
+	fp := &fakePrimitive{
+		results: []*sqltypes.Result{sqltypes.MakeTestResult(
+			fields,
+			"1|10",
+			"1|20",
+			"1|30",
+			"2|10",
+			"3|5",
+		)},
+	}
+
+	w := &Window{
+		Functions:   []*WindowParams{{Opcode: WindowRowNumber, Col: -1, Alias: "rn"}},
+		PartitionBy: []*GroupByParams{{KeyCol: 0, WeightStringCol: -1}},
+		OrderBy:     []OrderByParams{{Col: 1, WeightStringCol: -1}},
+		Input:       fp,
+	}
+
+	var results []*sqltypes.Result
+	err := w.TryStreamExecute(context.Background(), &noopVCursor{}, nil, true, func(qr *sqltypes.Result) error {
+		results = append(results, qr)
+		return nil
+	})
+	require.NoError(t, err)
+
+	wantResults := sqltypes.MakeTestStreamingResults(
+		sqltypes.MakeTestFields(
+			"rn|user_id|ts",
+			"uint64|int64|int64",
+		),
+		"1|1|10",
+		"2|1|20",
+		"3|1|30",
+		"---",
+		"1|2|10",
+		"---",
+		"1|3|5",
+	)
+	utils.MustMatch(t, wantResults, results)
+}
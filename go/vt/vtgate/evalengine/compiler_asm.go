@@ -2248,6 +2248,104 @@ func (asm *assembler) Fn_JSON_KEYS(jp *json.Path) {
 	}
 }
 
+func (asm *assembler) Fn_JSON_MODIFY(name string, t json.Transformation, paths []*json.Path) {
+	values := len(paths)
+	if t == json.Remove {
+		values = 0
+	}
+	asm.adjustStack(-values)
+	asm.emit(func(env *ExpressionEnv) int {
+		doc := env.vm.stack[env.vm.sp-values-1].(*evalJSON)
+		vals := make([]*json.Value, 0, values)
+		for sp := env.vm.sp - values; sp < env.vm.sp; sp++ {
+			vals = append(vals, env.vm.stack[sp].(*evalJSON).Clone())
+		}
+		env.vm.stack[env.vm.sp-values-1], env.vm.err = json.ApplyTransform(t, doc.Clone(), paths, vals)
+		env.vm.sp -= values
+		return 1
+	}, "FN %s (SP-%d)...(SP-1), [static]", name, values+1)
+}
+
+func (asm *assembler) Fn_JSON_MERGE_PRESERVE(args int) {
+	asm.adjustStack(-(args - 1))
+	asm.emit(func(env *ExpressionEnv) int {
+		merged := env.vm.stack[env.vm.sp-args].(*evalJSON)
+		for sp := env.vm.sp - args + 1; sp < env.vm.sp; sp++ {
+			merged = jsonMergePreserve(merged, env.vm.stack[sp].(*evalJSON))
+		}
+		env.vm.stack[env.vm.sp-args] = merged
+		env.vm.sp -= args - 1
+		return 1
+	}, "FN JSON_MERGE_PRESERVE (SP-%d)...(SP-1)", args)
+}
+
+func (asm *assembler) Fn_JSON_MERGE_PATCH(args int) {
+	asm.adjustStack(-(args - 1))
+	asm.emit(func(env *ExpressionEnv) int {
+		merged := env.vm.stack[env.vm.sp-args].(*evalJSON)
+		for sp := env.vm.sp - args + 1; sp < env.vm.sp; sp++ {
+			merged = jsonMergePatch(merged, env.vm.stack[sp].(*evalJSON))
+		}
+		env.vm.stack[env.vm.sp-args] = merged
+		env.vm.sp -= args - 1
+		return 1
+	}, "FN JSON_MERGE_PATCH (SP-%d)...(SP-1)", args)
+}
+
+func (asm *assembler) Fn_JSON_CONTAINS(jp *json.Path) {
+	asm.adjustStack(-1)
+	asm.emit(func(env *ExpressionEnv) int {
+		target := env.vm.stack[env.vm.sp-2].(*evalJSON)
+		candidate := env.vm.stack[env.vm.sp-1].(*evalJSON)
+		env.vm.sp--
+
+		if jp != nil {
+			var match *json.Value
+			jp.Match(target, true, func(value *json.Value) {
+				match = value
+			})
+			if match == nil {
+				env.vm.stack[env.vm.sp-1] = nil
+				return 1
+			}
+			target = match
+		}
+
+		var contains bool
+		contains, env.vm.err = jsonContains(target, candidate)
+		env.vm.stack[env.vm.sp-1] = env.vm.arena.newEvalBool(contains)
+		return 1
+	}, "FN JSON_CONTAINS (SP-2), (SP-1)")
+}
+
+func (asm *assembler) Fn_JSON_OVERLAPS() {
+	asm.adjustStack(-1)
+	asm.emit(func(env *ExpressionEnv) int {
+		left := env.vm.stack[env.vm.sp-2].(*evalJSON)
+		right := env.vm.stack[env.vm.sp-1].(*evalJSON)
+
+		var overlaps bool
+		overlaps, env.vm.err = jsonOverlaps(left, right)
+		env.vm.stack[env.vm.sp-2] = env.vm.arena.newEvalBool(overlaps)
+		env.vm.sp--
+		return 1
+	}, "FN JSON_OVERLAPS (SP-2), (SP-1)")
+}
+
+func (asm *assembler) Fn_MEMBER_OF() {
+	asm.adjustStack(-1)
+	asm.emit(func(env *ExpressionEnv) int {
+		value := env.vm.stack[env.vm.sp-2].(*evalJSON)
+		doc := env.vm.stack[env.vm.sp-1].(*evalJSON)
+
+		var member bool
+		member, env.vm.err = jsonMemberOf(value, doc)
+		env.vm.stack[env.vm.sp-2] = env.vm.arena.newEvalBool(member)
+		env.vm.sp--
+		return 1
+	}, "FN MEMBER OF (SP-2), (SP-1)")
+}
+
 func (asm *assembler) Fn_JSON_OBJECT(args int) {
 	asm.adjustStack(-(args - 1))
 	asm.emit(func(env *ExpressionEnv) int {
@@ -2686,7 +2784,7 @@ func (asm *assembler) Fn_LTRIM2(col collations.TypedCollation) {
 		str := env.vm.stack[env.vm.sp-2].(*evalBytes)
 		pat := env.vm.stack[env.vm.sp-1].(*evalBytes)
 		str.tt = int16(sqltypes.VarChar)
-		str.bytes = bytes.TrimPrefix(str.bytes, pat.bytes)
+		str.bytes = trimLeftPattern(str.bytes, pat.bytes)
 		str.col = col
 		env.vm.sp--
 		return 1
@@ -2699,7 +2797,7 @@ func (asm *assembler) Fn_RTRIM2(col collations.TypedCollation) {
 		str := env.vm.stack[env.vm.sp-2].(*evalBytes)
 		pat := env.vm.stack[env.vm.sp-1].(*evalBytes)
 		str.tt = int16(sqltypes.VarChar)
-		str.bytes = bytes.TrimSuffix(str.bytes, pat.bytes)
+		str.bytes = trimRightPattern(str.bytes, pat.bytes)
 		str.col = col
 		env.vm.sp--
 		return 1
@@ -2712,13 +2810,164 @@ func (asm *assembler) Fn_TRIM2(col collations.TypedCollation) {
 		str := env.vm.stack[env.vm.sp-2].(*evalBytes)
 		pat := env.vm.stack[env.vm.sp-1].(*evalBytes)
 		str.tt = int16(sqltypes.VarChar)
-		str.bytes = bytes.TrimPrefix(bytes.TrimSuffix(str.bytes, pat.bytes), pat.bytes)
+		str.bytes = trimLeftPattern(trimRightPattern(str.bytes, pat.bytes), pat.bytes)
 		str.col = col
 		env.vm.sp--
 		return 1
 	}, "FN TRIM VARCHAR(SP-2) VARCHAR(SP-1)")
 }
 
+func (asm *assembler) Fn_SUBSTRING2(col collations.TypedCollation) {
+	asm.adjustStack(-1)
+	asm.emit(func(env *ExpressionEnv) int {
+		str := env.vm.stack[env.vm.sp-2].(*evalBytes)
+		pos := substrArgument(env.vm.stack[env.vm.sp-1])
+		str.tt = int16(sqltypes.VarChar)
+		str.bytes = substring(col.Collation.Get().Charset(), str.bytes, pos, 0, false)
+		str.col = col
+		env.vm.sp--
+		return 1
+	}, "FN SUBSTRING VARCHAR(SP-2) INT64(SP-1)")
+}
+
+func (asm *assembler) Fn_SUBSTRING3(col collations.TypedCollation) {
+	asm.adjustStack(-2)
+	asm.emit(func(env *ExpressionEnv) int {
+		str := env.vm.stack[env.vm.sp-3].(*evalBytes)
+		pos := substrArgument(env.vm.stack[env.vm.sp-2])
+		length := substrArgument(env.vm.stack[env.vm.sp-1])
+		str.tt = int16(sqltypes.VarChar)
+		str.bytes = substring(col.Collation.Get().Charset(), str.bytes, pos, length, true)
+		str.col = col
+		env.vm.sp -= 2
+		return 1
+	}, "FN SUBSTRING VARCHAR(SP-3) INT64(SP-2) INT64(SP-1)")
+}
+
+func (asm *assembler) Fn_REPLACE(col collations.TypedCollation) {
+	asm.adjustStack(-2)
+	asm.emit(func(env *ExpressionEnv) int {
+		str := env.vm.stack[env.vm.sp-3].(*evalBytes)
+		from := env.vm.stack[env.vm.sp-2].(*evalBytes)
+		to := env.vm.stack[env.vm.sp-1].(*evalBytes)
+		str.tt = int16(sqltypes.VarChar)
+		str.bytes = replace(col.Collation.Get().Charset(), str.bytes, from.bytes, to.bytes)
+		str.col = col
+		env.vm.sp -= 2
+		return 1
+	}, "FN REPLACE VARCHAR(SP-3) VARCHAR(SP-2) VARCHAR(SP-1)")
+}
+
+func (asm *assembler) Fn_LOCATE2(col collations.ID) {
+	asm.adjustStack(-1)
+	asm.emit(func(env *ExpressionEnv) int {
+		substr := env.vm.stack[env.vm.sp-2].(*evalBytes)
+		str := env.vm.stack[env.vm.sp-1].(*evalBytes)
+		env.vm.stack[env.vm.sp-2] = env.vm.arena.newEvalInt64(locate(col.Get(), substr.bytes, str.bytes, 1))
+		env.vm.sp--
+		return 1
+	}, "FN LOCATE VARCHAR(SP-2) VARCHAR(SP-1)")
+}
+
+func (asm *assembler) Fn_LOCATE3(col collations.ID) {
+	asm.adjustStack(-2)
+	asm.emit(func(env *ExpressionEnv) int {
+		substr := env.vm.stack[env.vm.sp-3].(*evalBytes)
+		str := env.vm.stack[env.vm.sp-2].(*evalBytes)
+		pos := env.vm.stack[env.vm.sp-1].(*evalInt64)
+		env.vm.stack[env.vm.sp-3] = env.vm.arena.newEvalInt64(locate(col.Get(), substr.bytes, str.bytes, pos.i))
+		env.vm.sp -= 2
+		return 1
+	}, "FN LOCATE VARCHAR(SP-3) VARCHAR(SP-2) INT64(SP-1)")
+}
+
+func (asm *assembler) Fn_REVERSE(col collations.TypedCollation) {
+	asm.emit(func(env *ExpressionEnv) int {
+		str := env.vm.stack[env.vm.sp-1].(*evalBytes)
+		str.tt = int16(sqltypes.VarChar)
+		str.bytes = reverse(col.Collation.Get().Charset(), str.bytes)
+		str.col = col
+		return 1
+	}, "FN REVERSE VARCHAR(SP-1)")
+}
+
+func (asm *assembler) Fn_INSERT(col collations.TypedCollation) {
+	asm.adjustStack(-3)
+	asm.emit(func(env *ExpressionEnv) int {
+		str := env.vm.stack[env.vm.sp-4].(*evalBytes)
+		pos := env.vm.stack[env.vm.sp-3].(*evalInt64)
+		length := env.vm.stack[env.vm.sp-2].(*evalInt64)
+		newstr := env.vm.stack[env.vm.sp-1].(*evalBytes)
+		str.tt = int16(sqltypes.VarChar)
+		str.bytes = insert(col.Collation.Get().Charset(), str.bytes, pos.i, length.i, newstr.bytes)
+		str.col = col
+		env.vm.sp -= 3
+		return 1
+	}, "FN INSERT VARCHAR(SP-4) INT64(SP-3) INT64(SP-2) VARCHAR(SP-1)")
+}
+
+func (asm *assembler) Fn_FIELD(args int) {
+	asm.adjustStack(-args + 1)
+	asm.emit(func(env *ExpressionEnv) int {
+		idx, err := fieldIndex(env.vm.stack[env.vm.sp-args : env.vm.sp])
+		env.vm.stack[env.vm.sp-args] = env.vm.arena.newEvalInt64(idx)
+		env.vm.err = err
+		env.vm.sp -= args - 1
+		return 1
+	}, "FN FIELD (SP-%d)...(SP-1)", args)
+}
+
+func (asm *assembler) Fn_ELT(args int, collate collations.ID) {
+	asm.adjustStack(-args + 1)
+	asm.emit(func(env *ExpressionEnv) int {
+		env.vm.stack[env.vm.sp-args], env.vm.err = elt(env.vm.stack[env.vm.sp-args:env.vm.sp], collate)
+		env.vm.sp -= args - 1
+		return 1
+	}, "FN ELT INT64(SP-%d) VARCHAR(SP-%d)...VARCHAR(SP-1)", args, args-1)
+}
+
+func (asm *assembler) Fn_FORMAT_LOCALE() {
+	asm.adjustStack(-1)
+	asm.emit(func(env *ExpressionEnv) int {
+		env.vm.err = formatLocale(env.vm.stack[env.vm.sp-1])
+		env.vm.sp--
+		return 1
+	}, "FN FORMAT_LOCALE VARCHAR(SP-1)")
+}
+
+func (asm *assembler) Fn_FORMAT(col collations.TypedCollation) {
+	asm.adjustStack(-1)
+	asm.emit(func(env *ExpressionEnv) int {
+		num := env.vm.stack[env.vm.sp-2]
+		d := env.vm.stack[env.vm.sp-1].(*evalInt64)
+		env.vm.stack[env.vm.sp-2] = env.vm.arena.newEvalText(formatNumber(num, d.i), col)
+		env.vm.sp--
+		return 1
+	}, "FN FORMAT NUMERIC(SP-2) INT64(SP-1)")
+}
+
+func (asm *assembler) Fn_SPACE(col collations.TypedCollation) {
+	asm.emit(func(env *ExpressionEnv) int {
+		res, ok := space(env.vm.stack[env.vm.sp-1])
+		if !ok {
+			env.vm.stack[env.vm.sp-1] = nil
+		} else {
+			env.vm.stack[env.vm.sp-1] = env.vm.arena.newEvalText(res, col)
+		}
+		return 1
+	}, "FN SPACE INT64(SP-1)")
+}
+
+func (asm *assembler) Fn_CHAR(args int, col collations.ID) {
+	asm.adjustStack(-args + 1)
+	asm.emit(func(env *ExpressionEnv) int {
+		buf := charBytes(env.vm.stack[env.vm.sp-args : env.vm.sp])
+		env.vm.stack[env.vm.sp-args] = charResult(buf, col)
+		env.vm.sp -= args - 1
+		return 1
+	}, "FN CHAR INT64(SP-%d)...INT64(SP-1)", args)
+}
+
 func (asm *assembler) Fn_TO_BASE64(t sqltypes.Type, col collations.TypedCollation) {
 	asm.emit(func(env *ExpressionEnv) int {
 		str := env.vm.stack[env.vm.sp-1].(*evalBytes)
@@ -3582,6 +3831,141 @@ func (asm *assembler) Fn_DAYOFYEAR() {
 	}, "FN DAYOFYEAR DATE(SP-1)")
 }
 
+func (asm *assembler) Fn_TO_DAYS() {
+	asm.emit(func(env *ExpressionEnv) int {
+		if env.vm.stack[env.vm.sp-1] == nil {
+			return 1
+		}
+		arg := env.vm.stack[env.vm.sp-1].(*evalTemporal)
+		env.vm.stack[env.vm.sp-1] = env.vm.arena.newEvalInt64(int64(arg.dt.Date.DayNumber()))
+		return 1
+	}, "FN TO_DAYS DATE(SP-1)")
+}
+
+func (asm *assembler) Fn_FROM_DAYS() {
+	asm.emit(func(env *ExpressionEnv) int {
+		arg := env.vm.stack[env.vm.sp-1].(*evalInt64)
+		env.vm.stack[env.vm.sp-1] = env.vm.arena.newEvalDate(datetime.NewDateFromDayNumber(int(arg.i)))
+		return 1
+	}, "FN FROM_DAYS INT64(SP-1)")
+}
+
+func (asm *assembler) Fn_LAST_DAY() {
+	asm.emit(func(env *ExpressionEnv) int {
+		if env.vm.stack[env.vm.sp-1] == nil {
+			return 1
+		}
+		arg := env.vm.stack[env.vm.sp-1].(*evalTemporal)
+		if arg.dt.Date.Month() == 0 {
+			env.vm.stack[env.vm.sp-1] = nil
+			return 1
+		}
+		env.vm.stack[env.vm.sp-1] = env.vm.arena.newEvalDate(arg.dt.Date.LastDay())
+		return 1
+	}, "FN LAST_DAY DATE(SP-1)")
+}
+
+func (asm *assembler) Fn_DATEDIFF() {
+	asm.adjustStack(-1)
+	asm.emit(func(env *ExpressionEnv) int {
+		if env.vm.stack[env.vm.sp-2] == nil || env.vm.stack[env.vm.sp-1] == nil {
+			env.vm.stack[env.vm.sp-2] = nil
+			env.vm.sp--
+			return 1
+		}
+		d1 := env.vm.stack[env.vm.sp-2].(*evalTemporal)
+		d2 := env.vm.stack[env.vm.sp-1].(*evalTemporal)
+		env.vm.stack[env.vm.sp-2] = env.vm.arena.newEvalInt64(int64(d1.dt.Date.DayNumber() - d2.dt.Date.DayNumber()))
+		env.vm.sp--
+		return 1
+	}, "FN DATEDIFF DATE(SP-2), DATE(SP-1)")
+}
+
+func (asm *assembler) Fn_TIMEDIFF() {
+	asm.adjustStack(-1)
+	asm.emit(func(env *ExpressionEnv) int {
+		if t := timeDiff(env.vm.stack[env.vm.sp-2], env.vm.stack[env.vm.sp-1]); t != nil {
+			env.vm.stack[env.vm.sp-2] = t
+		} else {
+			env.vm.stack[env.vm.sp-2] = nil
+		}
+		env.vm.sp--
+		return 1
+	}, "FN TIMEDIFF (SP-2), (SP-1)")
+}
+
+func (asm *assembler) Fn_TIMESTAMPDIFF(unit datetime.IntervalType) {
+	asm.adjustStack(-1)
+	asm.emit(func(env *ExpressionEnv) int {
+		if env.vm.stack[env.vm.sp-2] == nil || env.vm.stack[env.vm.sp-1] == nil {
+			env.vm.stack[env.vm.sp-2] = nil
+			env.vm.sp--
+			return 1
+		}
+		dt1 := env.vm.stack[env.vm.sp-2].(*evalTemporal)
+		dt2 := env.vm.stack[env.vm.sp-1].(*evalTemporal)
+		if dt1.dt.Date.IsZero() || dt2.dt.Date.IsZero() {
+			env.vm.stack[env.vm.sp-2] = nil
+		} else {
+			env.vm.stack[env.vm.sp-2] = env.vm.arena.newEvalInt64(timestampDiff(dt1.dt, dt2.dt, unit))
+		}
+		env.vm.sp--
+		return 1
+	}, "FN TIMESTAMPDIFF DATETIME(SP-2), DATETIME(SP-1)")
+}
+
+func (asm *assembler) Fn_TIME_TO_SEC() {
+	asm.emit(func(env *ExpressionEnv) int {
+		if env.vm.stack[env.vm.sp-1] == nil {
+			return 1
+		}
+		arg := env.vm.stack[env.vm.sp-1].(*evalTemporal)
+		env.vm.stack[env.vm.sp-1] = env.vm.arena.newEvalInt64(int64(arg.dt.Time.ToDuration() / time.Second))
+		return 1
+	}, "FN TIME_TO_SEC TIME(SP-1)")
+}
+
+func (asm *assembler) Fn_SEC_TO_TIME() {
+	asm.emit(func(env *ExpressionEnv) int {
+		env.vm.stack[env.vm.sp-1] = secToTime(env.vm.stack[env.vm.sp-1])
+		return 1
+	}, "FN SEC_TO_TIME (SP-1)")
+}
+
+func (asm *assembler) Fn_PERIOD_ADD() {
+	asm.adjustStack(-1)
+	asm.emit(func(env *ExpressionEnv) int {
+		p := env.vm.stack[env.vm.sp-2].(*evalInt64)
+		n := env.vm.stack[env.vm.sp-1].(*evalInt64)
+		p.i, env.vm.err = periodAdd(p.i, n.i)
+		env.vm.sp--
+		return 1
+	}, "FN PERIOD_ADD INT64(SP-2), INT64(SP-1)")
+}
+
+func (asm *assembler) Fn_PERIOD_DIFF() {
+	asm.adjustStack(-1)
+	asm.emit(func(env *ExpressionEnv) int {
+		p1 := env.vm.stack[env.vm.sp-2].(*evalInt64)
+		p2 := env.vm.stack[env.vm.sp-1].(*evalInt64)
+		p1.i, env.vm.err = periodDiff(p1.i, p2.i)
+		env.vm.sp--
+		return 1
+	}, "FN PERIOD_DIFF INT64(SP-2), INT64(SP-1)")
+}
+
+func (asm *assembler) Fn_STR_TO_DATE(format string) {
+	asm.emit(func(env *ExpressionEnv) int {
+		str := env.vm.stack[env.vm.sp-1].(*evalBytes)
+		if d := strToDate(env.currentSQLMode(), str.string(), format); d != nil {
+			env.vm.stack[env.vm.sp-1] = d
+		} else {
+			env.vm.stack[env.vm.sp-1] = nil
+		}
+		return 1
+	}, "FN STR_TO_DATE VARBINARY(SP-1), %q", format)
+}
+
 func (asm *assembler) Fn_FROM_UNIXTIME_i() {
 	asm.emit(func(env *ExpressionEnv) int {
 		arg := env.vm.stack[env.vm.sp-1].(*evalInt64)
@@ -448,6 +448,104 @@ func TestCompilerSingle(t *testing.T) {
 			expression: `REGEXP_REPLACE(1234, 12, 6, 1)`,
 			result:     `TEXT("634")`,
 		},
+		{
+			expression: `SUBSTRING('Sakila' FROM -4 FOR 2)`,
+			result:     `VARCHAR("ki")`,
+		},
+		{
+			expression: `LOCATE('bar', column0, 5)`,
+			values:     []sqltypes.Value{sqltypes.NewVarChar("foobarbar")},
+			result:     `INT64(7)`,
+		},
+		{
+			expression: `INSERT('Quadratic', 3, 100, 'What')`,
+			result:     `VARCHAR("QuWhat")`,
+		},
+		{
+			expression: `FIELD('Bb', 'Aa', 'Bb', 'Cc', 'Dd', 'Ff')`,
+			result:     `INT64(2)`,
+		},
+		{
+			expression: `ELT(column0, 'Aa', 'Bb', 'Cc', 'Dd')`,
+			values:     []sqltypes.Value{sqltypes.NewInt64(4)},
+			result:     `VARCHAR("Dd")`,
+		},
+		{
+			expression: `FORMAT(-1234567.891, 2)`,
+			result:     `VARCHAR("-1,234,567.89")`,
+		},
+		{
+			expression: `FORMAT(NULL, 2, 'de_DE')`,
+			result:     `NULL`,
+		},
+		{
+			expression: `FORMAT(column0, column1, 'de_DE')`,
+			values:     []sqltypes.Value{sqltypes.NewFloat64(1.5), sqltypes.NULL},
+			result:     `NULL`,
+		},
+		{
+			expression: `CHAR(77, 121, 83, 81, '76')`,
+			result:     `VARBINARY("MySQL")`,
+		},
+		{
+			expression: `TRIM(BOTH 'x' FROM 'xxxbarxxx')`,
+			result:     `VARCHAR("bar")`,
+		},
+		{
+			expression: `JSON_SET(column0, '$.a', 10, '$.c', '[true, false]')`,
+			values:     []sqltypes.Value{sqltypes.NewVarChar(`{"a": 1, "b": [2, 3]}`)},
+			result:     `JSON("{\"a\": 10, \"b\": [2, 3], \"c\": \"[true, false]\"}")`,
+		},
+		{
+			expression: `JSON_REMOVE(column0, '$[1]')`,
+			values:     []sqltypes.Value{sqltypes.NewVarChar(`["a", ["b", "c"], "d"]`)},
+			result:     `JSON("[\"a\", \"d\"]")`,
+		},
+		{
+			expression: `JSON_MERGE_PRESERVE(column0, '{ "a": 3, "c": 4 }', '{ "a": 5, "d": 6 }')`,
+			values:     []sqltypes.Value{sqltypes.NewVarChar(`{ "a": 1, "b": 2 }`)},
+			result:     `JSON("{\"a\": [1, 3, 5], \"b\": 2, \"c\": 4, \"d\": 6}")`,
+		},
+		{
+			expression: `JSON_CONTAINS(column0, '{"d": 4}', '$.c')`,
+			values:     []sqltypes.Value{sqltypes.NewVarChar(`{"a": 1, "b": 2, "c": {"d": 4}}`)},
+			result:     `INT64(1)`,
+		},
+		{
+			expression: `column0 MEMBER OF('[23, "abc", 17, "ab", 10]')`,
+			values:     []sqltypes.Value{sqltypes.NewInt64(17)},
+			result:     `INT64(1)`,
+		},
+		{
+			expression: `DATEDIFF(column0, '2007-12-30')`,
+			values:     []sqltypes.Value{sqltypes.NewVarChar("2007-12-31 23:59:59")},
+			result:     `INT64(1)`,
+		},
+		{
+			expression: `TIMESTAMPDIFF(MONTH, column0, '2003-02-28')`,
+			values:     []sqltypes.Value{sqltypes.NewVarChar("2003-01-31")},
+			result:     `INT64(0)`,
+		},
+		{
+			expression: `STR_TO_DATE(column0, '%m/%d/%Y')`,
+			values:     []sqltypes.Value{sqltypes.NewVarChar("04/31/2004")},
+			result:     `NULL`,
+		},
+		{
+			expression: `STR_TO_DATE(column0, '%Y-%m-%d %H:%i:%s.%f')`,
+			values:     []sqltypes.Value{sqltypes.NewVarChar("2023-05-21 10:35:00.123")},
+			result:     `DATETIME("2023-05-21 10:35:00.123000")`,
+		},
+		{
+			expression: `SEC_TO_TIME(column0)`,
+			values:     []sqltypes.Value{sqltypes.NewDecimal("2378.1234567")},
+			result:     `TIME("00:39:38.123457")`,
+		},
+		{
+			expression: `PERIOD_ADD(column0, 2)`,
+			values:     []sqltypes.Value{sqltypes.NewInt64(6912)},
+			result:     `INT64(207002)`,
+		},
 	}
 
 	for _, tc := range testCases {
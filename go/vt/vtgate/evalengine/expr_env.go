@@ -26,11 +26,39 @@ import (
 	"vitess.io/vitess/go/sqltypes"
 	"vitess.io/vitess/go/vt/callerid"
 	querypb "vitess.io/vitess/go/vt/proto/query"
+	"vitess.io/vitess/go/vt/sysvars"
 )
 
 type VCursor interface {
 	TimeZone() *time.Location
 	GetKeyspace() string
+	SQLMode() string
+}
+
+// sqlMode holds the flags from MySQL's sql_mode that
+// change the result of evaluating an expression.
+type sqlMode uint8
+
+const (
+	sqlModeNoZeroInDate sqlMode = 1 << iota
+	sqlModeNoZeroDate
+	sqlModeAllowInvalidDates
+)
+
+func parseSQLMode(mode string) (m sqlMode) {
+	for _, flag := range strings.Split(mode, ",") {
+		switch strings.ToUpper(strings.TrimSpace(flag)) {
+		case "NO_ZERO_IN_DATE":
+			m |= sqlModeNoZeroInDate
+		case "NO_ZERO_DATE":
+			m |= sqlModeNoZeroDate
+		case "ALLOW_INVALID_DATES":
+			m |= sqlModeAllowInvalidDates
+		case "TRADITIONAL":
+			m |= sqlModeNoZeroInDate | sqlModeNoZeroDate
+		}
+	}
+	return m
 }
 
 type (
@@ -81,6 +109,13 @@ func (env *ExpressionEnv) currentTimezone() *time.Location {
 	return env.vc.TimeZone()
 }
 
+func (env *ExpressionEnv) currentSQLMode() sqlMode {
+	if env.vc == nil {
+		return parseSQLMode(sysvars.DefaultSQLMode)
+	}
+	return parseSQLMode(env.vc.SQLMode())
+}
+
 func (env *ExpressionEnv) Evaluate(expr Expr) (EvalResult, error) {
 	if p, ok := expr.(*CompiledExpr); ok {
 		return env.EvaluateVM(p)
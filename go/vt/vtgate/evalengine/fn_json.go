@@ -58,6 +58,39 @@ type (
 	builtinJSONKeys struct {
 		CallExpr
 	}
+
+	builtinJSONModify struct {
+		CallExpr
+		modify json.Transformation
+	}
+
+	builtinJSONRemove struct {
+		CallExpr
+	}
+
+	builtinJSONMergePreserve struct {
+		CallExpr
+	}
+
+	builtinJSONMergePatch struct {
+		CallExpr
+	}
+
+	builtinJSONContains struct {
+		CallExpr
+	}
+
+	builtinJSONOverlaps struct {
+		CallExpr
+	}
+
+	builtinJSONSearch struct {
+		CallExpr
+	}
+
+	builtinMemberOf struct {
+		CallExpr
+	}
 )
 
 var _ Expr = (*builtinJSONExtract)(nil)
@@ -68,8 +101,17 @@ var _ Expr = (*builtinJSONDepth)(nil)
 var _ Expr = (*builtinJSONLength)(nil)
 var _ Expr = (*builtinJSONContainsPath)(nil)
 var _ Expr = (*builtinJSONKeys)(nil)
+var _ Expr = (*builtinJSONModify)(nil)
+var _ Expr = (*builtinJSONRemove)(nil)
+var _ Expr = (*builtinJSONMergePreserve)(nil)
+var _ Expr = (*builtinJSONMergePatch)(nil)
+var _ Expr = (*builtinJSONContains)(nil)
+var _ Expr = (*builtinJSONOverlaps)(nil)
+var _ Expr = (*builtinJSONSearch)(nil)
+var _ Expr = (*builtinMemberOf)(nil)
 
 var errInvalidPathForTransform = vterrors.Errorf(vtrpcpb.Code_INVALID_ARGUMENT, "In this situation, path expressions may not contain the * and ** tokens or an array range.")
+var errVacuousPath = vterrors.Errorf(vtrpcpb.Code_INVALID_ARGUMENT, "The path expression '$' is not allowed in this context.")
 
 func (call *builtinJSONExtract) eval(env *ExpressionEnv) (eval, error) {
 	args, err := call.args(env)
@@ -550,3 +592,785 @@ func (call *builtinJSONKeys) compile(c *compiler) (ctype, error) {
 	c.asm.Fn_JSON_KEYS(jp)
 	return ctype{Type: sqltypes.TypeJSON, Flag: flagNullable, Col: collationJSON}, nil
 }
+
+// intoJSONTransformPath parses the given path for a function that modifies
+// the document, which does not allow wildcards in its paths.
+func intoJSONTransformPath(p eval) (*json.Path, error) {
+	jp, err := intoJSONPath(p)
+	if err != nil {
+		return nil, err
+	}
+	if jp.ContainsWildcards() {
+		return nil, errInvalidPathForTransform
+	}
+	return jp, nil
+}
+
+func (call *builtinJSONModify) eval(env *ExpressionEnv) (eval, error) {
+	args, err := call.args(env)
+	if err != nil {
+		return nil, err
+	}
+	if args[0] == nil {
+		return nil, nil
+	}
+
+	doc, err := intoJSON(call.Method, args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	paths := make([]*json.Path, 0, len(args)/2)
+	values := make([]*json.Value, 0, len(args)/2)
+
+	for i := 1; i < len(args); i += 2 {
+		if args[i] == nil {
+			return nil, nil
+		}
+		jp, err := intoJSONTransformPath(args[i])
+		if err != nil {
+			return nil, err
+		}
+		val, err := argToJSON(args[i+1])
+		if err != nil {
+			return nil, err
+		}
+		paths = append(paths, jp)
+		values = append(values, val.Clone())
+	}
+
+	return json.ApplyTransform(call.modify, doc.Clone(), paths, values)
+}
+
+func (call *builtinJSONModify) typeof(env *ExpressionEnv, fields []*querypb.Field) (sqltypes.Type, typeFlag) {
+	_, f := call.Arguments[0].typeof(env, fields)
+	return sqltypes.TypeJSON, f | flagNullable
+}
+
+func (call *builtinJSONModify) compile(c *compiler) (ctype, error) {
+	paths, err := c.jsonTransformPaths(call, call.Arguments[1:], 2)
+	if err != nil {
+		return ctype{}, err
+	}
+
+	doct, err := call.Arguments[0].compile(c)
+	if err != nil {
+		return ctype{}, err
+	}
+
+	skip := c.compileNullCheckArg(doct, 0)
+	err = c.compileParseJSONArg(call.Method, doct, 1)
+	if err != nil {
+		return ctype{}, err
+	}
+
+	for i := 2; i < len(call.Arguments); i += 2 {
+		val, err := call.Arguments[i].compile(c)
+		if err != nil {
+			return ctype{}, err
+		}
+		_, err = c.compileArgToJSON(val, 1)
+		if err != nil {
+			return ctype{}, err
+		}
+	}
+
+	c.asm.Fn_JSON_MODIFY(call.Method, call.modify, paths)
+	c.asm.jumpDestination(skip)
+	return ctype{Type: sqltypes.TypeJSON, Flag: flagNullable, Col: collationJSON}, nil
+}
+
+// compileParseJSONArg parses a JSON document argument that has already been
+// null checked, so arguments that are always NULL never reach the parser.
+func (c *compiler) compileParseJSONArg(fn string, doct ctype, offset int) error {
+	if doct.Type == sqltypes.Null {
+		return nil
+	}
+	_, err := c.compileParseJSON(fn, doct, offset)
+	return err
+}
+
+// jsonTransformPaths returns the constant paths for a function that modifies
+// the document, taking every step-th expression in args.
+func (c *compiler) jsonTransformPaths(call Expr, args []Expr, step int) ([]*json.Path, error) {
+	paths := make([]*json.Path, 0, len(args)/step+1)
+	for i := 0; i < len(args); i += step {
+		if !args[i].constant() {
+			return nil, c.unsupported(call)
+		}
+		if lit, ok := args[i].(*Literal); ok && lit.inner == nil {
+			return nil, c.unsupported(call)
+		}
+		jp, err := c.jsonExtractPath(args[i])
+		if err != nil {
+			return nil, err
+		}
+		if jp.ContainsWildcards() {
+			return nil, errInvalidPathForTransform
+		}
+		paths = append(paths, jp)
+	}
+	return paths, nil
+}
+
+func (call *builtinJSONRemove) eval(env *ExpressionEnv) (eval, error) {
+	args, err := call.args(env)
+	if err != nil {
+		return nil, err
+	}
+	if args[0] == nil {
+		return nil, nil
+	}
+
+	doc, err := intoJSON(call.Method, args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	paths := make([]*json.Path, 0, len(args)-1)
+	for _, p := range args[1:] {
+		if p == nil {
+			return nil, nil
+		}
+		jp, err := intoJSONTransformPath(p)
+		if err != nil {
+			return nil, err
+		}
+		if jp.IsRoot() {
+			return nil, errVacuousPath
+		}
+		paths = append(paths, jp)
+	}
+
+	return json.ApplyTransform(json.Remove, doc.Clone(), paths, nil)
+}
+
+func (call *builtinJSONRemove) typeof(env *ExpressionEnv, fields []*querypb.Field) (sqltypes.Type, typeFlag) {
+	_, f := call.Arguments[0].typeof(env, fields)
+	return sqltypes.TypeJSON, f | flagNullable
+}
+
+func (call *builtinJSONRemove) compile(c *compiler) (ctype, error) {
+	paths, err := c.jsonTransformPaths(call, call.Arguments[1:], 1)
+	if err != nil {
+		return ctype{}, err
+	}
+	for _, jp := range paths {
+		if jp.IsRoot() {
+			return ctype{}, errVacuousPath
+		}
+	}
+
+	doct, err := call.Arguments[0].compile(c)
+	if err != nil {
+		return ctype{}, err
+	}
+
+	skip := c.compileNullCheck1(doct)
+	err = c.compileParseJSONArg(call.Method, doct, 1)
+	if err != nil {
+		return ctype{}, err
+	}
+
+	c.asm.Fn_JSON_MODIFY(call.Method, json.Remove, paths)
+	c.asm.jumpDestination(skip)
+	return ctype{Type: sqltypes.TypeJSON, Flag: flagNullable, Col: collationJSON}, nil
+}
+
+// jsonMergePreserve merges two documents following the rules of JSON_MERGE_PRESERVE:
+// objects are merged key by key, and any other values are merged as arrays.
+func jsonMergePreserve(left, right *json.Value) *json.Value {
+	lobj, lok := left.Object()
+	robj, rok := right.Object()
+	if lok && rok {
+		var obj json.Object
+		lobj.Visit(func(key string, val *json.Value) {
+			obj.Set(key, val, json.Set)
+		})
+		robj.Visit(func(key string, val *json.Value) {
+			if prev := obj.Get(key); prev != nil {
+				val = jsonMergePreserve(prev, val)
+			}
+			obj.Set(key, val, json.Set)
+		})
+		return json.NewObject(obj)
+	}
+
+	var ary []*json.Value
+	for _, v := range []*json.Value{left, right} {
+		if a, ok := v.Array(); ok {
+			ary = append(ary, a...)
+		} else {
+			ary = append(ary, v)
+		}
+	}
+	return json.NewArray(ary)
+}
+
+// jsonMergePatch applies patch to target as described in RFC 7396.
+func jsonMergePatch(target, patch *json.Value) *json.Value {
+	pobj, ok := patch.Object()
+	if !ok {
+		return patch
+	}
+
+	var obj json.Object
+	if tobj, ok := target.Object(); ok {
+		tobj.Visit(func(key string, val *json.Value) {
+			obj.Set(key, val, json.Set)
+		})
+	}
+	pobj.Visit(func(key string, val *json.Value) {
+		if val.Type() == json.TypeNull {
+			obj.Del(key)
+			return
+		}
+		prev := obj.Get(key)
+		if prev == nil {
+			prev = json.ValueNull
+		}
+		obj.Set(key, jsonMergePatch(prev, val), json.Set)
+	})
+	return json.NewObject(obj)
+}
+
+func (call *builtinJSONMergePreserve) eval(env *ExpressionEnv) (eval, error) {
+	args, err := call.args(env)
+	if err != nil {
+		return nil, err
+	}
+
+	var merged *json.Value
+	for _, arg := range args {
+		if arg == nil {
+			return nil, nil
+		}
+		doc, err := intoJSON(call.Method, arg)
+		if err != nil {
+			return nil, err
+		}
+		if merged == nil {
+			merged = doc
+		} else {
+			merged = jsonMergePreserve(merged, doc)
+		}
+	}
+	return merged, nil
+}
+
+func (call *builtinJSONMergePreserve) typeof(env *ExpressionEnv, fields []*querypb.Field) (sqltypes.Type, typeFlag) {
+	var f typeFlag
+	for _, arg := range call.Arguments {
+		_, af := arg.typeof(env, fields)
+		f |= af & flagNullable
+	}
+	return sqltypes.TypeJSON, f
+}
+
+func (call *builtinJSONMergePreserve) compile(c *compiler) (ctype, error) {
+	var skips []*jump
+	var nullable bool
+	for i, arg := range call.Arguments {
+		doct, err := arg.compile(c)
+		if err != nil {
+			return ctype{}, err
+		}
+		nullable = nullable || doct.nullable()
+		skips = append(skips, c.compileNullCheckArg(doct, i))
+		err = c.compileParseJSONArg(call.Method, doct, 1)
+		if err != nil {
+			return ctype{}, err
+		}
+	}
+
+	c.asm.Fn_JSON_MERGE_PRESERVE(len(call.Arguments))
+	c.asm.jumpDestination(skips...)
+
+	ct := ctype{Type: sqltypes.TypeJSON, Col: collationJSON}
+	if nullable {
+		ct.Flag |= flagNullable
+	}
+	return ct, nil
+}
+
+func (call *builtinJSONMergePatch) eval(env *ExpressionEnv) (eval, error) {
+	args, err := call.args(env)
+	if err != nil {
+		return nil, err
+	}
+
+	// A NULL argument makes the result NULL, unless it's followed by
+	// a patch that is not an object, which replaces the result as a whole.
+	var merged *json.Value
+	for i, arg := range args {
+		if arg == nil {
+			merged = nil
+			continue
+		}
+		doc, err := intoJSON(call.Method, arg)
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case i == 0:
+			merged = doc
+		case doc.Type() != json.TypeObject:
+			merged = doc
+		case merged != nil:
+			merged = jsonMergePatch(merged, doc)
+		}
+	}
+	if merged == nil {
+		return nil, nil
+	}
+	return merged, nil
+}
+
+func (call *builtinJSONMergePatch) typeof(env *ExpressionEnv, fields []*querypb.Field) (sqltypes.Type, typeFlag) {
+	var f typeFlag
+	for _, arg := range call.Arguments {
+		_, af := arg.typeof(env, fields)
+		f |= af & flagNullable
+	}
+	return sqltypes.TypeJSON, f
+}
+
+func (call *builtinJSONMergePatch) compile(c *compiler) (ctype, error) {
+	for _, arg := range call.Arguments {
+		doct, err := arg.compile(c)
+		if err != nil {
+			return ctype{}, err
+		}
+		if doct.nullable() {
+			return ctype{}, c.unsupported(call)
+		}
+		_, err = c.compileParseJSON(call.Method, doct, 1)
+		if err != nil {
+			return ctype{}, err
+		}
+	}
+
+	c.asm.Fn_JSON_MERGE_PATCH(len(call.Arguments))
+	return ctype{Type: sqltypes.TypeJSON, Col: collationJSON}, nil
+}
+
+// jsonContains returns whether candidate is contained in target, following
+// the rules of JSON_CONTAINS.
+func jsonContains(target, candidate *json.Value) (bool, error) {
+	switch target.Type() {
+	case json.TypeObject:
+		cobj, ok := candidate.Object()
+		if !ok {
+			return false, nil
+		}
+		tobj, _ := target.Object()
+		for _, key := range cobj.Keys() {
+			tval := tobj.Get(key)
+			if tval == nil {
+				return false, nil
+			}
+			contains, err := jsonContains(tval, cobj.Get(key))
+			if err != nil || !contains {
+				return false, err
+			}
+		}
+		return true, nil
+	case json.TypeArray:
+		tary, _ := target.Array()
+		cary, ok := candidate.Array()
+		if !ok {
+			cary = []*json.Value{candidate}
+		}
+		for _, cval := range cary {
+			var found bool
+			for _, tval := range tary {
+				var err error
+				switch cval.Type() {
+				case json.TypeArray, json.TypeObject:
+					if tval.Type() == cval.Type() {
+						found, err = jsonContains(tval, cval)
+					}
+				default:
+					if tval.Type() != json.TypeArray && tval.Type() != json.TypeObject {
+						var cmp int
+						cmp, err = compareJSONValue(tval, cval)
+						found = cmp == 0
+					}
+				}
+				if err != nil {
+					return false, err
+				}
+				if found {
+					break
+				}
+			}
+			if !found {
+				return false, nil
+			}
+		}
+		return true, nil
+	default:
+		cmp, err := compareJSONValue(target, candidate)
+		return cmp == 0, err
+	}
+}
+
+func (call *builtinJSONContains) eval(env *ExpressionEnv) (eval, error) {
+	args, err := call.args(env)
+	if err != nil {
+		return nil, err
+	}
+	for _, arg := range args {
+		if arg == nil {
+			return nil, nil
+		}
+	}
+
+	target, err := intoJSON(call.Method, args[0])
+	if err != nil {
+		return nil, err
+	}
+	candidate, err := intoJSON(call.Method, args[1])
+	if err != nil {
+		return nil, err
+	}
+
+	if len(args) == 3 {
+		jp, err := intoJSONTransformPath(args[2])
+		if err != nil {
+			return nil, err
+		}
+		var match *json.Value
+		jp.Match(target, true, func(value *json.Value) {
+			match = value
+		})
+		if match == nil {
+			return nil, nil
+		}
+		target = match
+	}
+
+	contains, err := jsonContains(target, candidate)
+	if err != nil {
+		return nil, err
+	}
+	return newEvalBool(contains), nil
+}
+
+func (call *builtinJSONContains) typeof(env *ExpressionEnv, fields []*querypb.Field) (sqltypes.Type, typeFlag) {
+	_, f := call.Arguments[0].typeof(env, fields)
+	return sqltypes.Int64, f | flagNullable | flagIsBoolean
+}
+
+func (call *builtinJSONContains) compile(c *compiler) (ctype, error) {
+	var jp *json.Path
+	if len(call.Arguments) == 3 {
+		paths, err := c.jsonTransformPaths(call, call.Arguments[2:], 1)
+		if err != nil {
+			return ctype{}, err
+		}
+		jp = paths[0]
+	}
+
+	target, err := call.Arguments[0].compile(c)
+	if err != nil {
+		return ctype{}, err
+	}
+	candidate, err := call.Arguments[1].compile(c)
+	if err != nil {
+		return ctype{}, err
+	}
+
+	skip := c.compileNullCheck2(target, candidate)
+	err = c.compileParseJSONArg(call.Method, target, 2)
+	if err != nil {
+		return ctype{}, err
+	}
+	err = c.compileParseJSONArg(call.Method, candidate, 1)
+	if err != nil {
+		return ctype{}, err
+	}
+
+	c.asm.Fn_JSON_CONTAINS(jp)
+	c.asm.jumpDestination(skip)
+	return ctype{Type: sqltypes.Int64, Col: collationNumeric, Flag: flagIsBoolean | flagNullable}, nil
+}
+
+// jsonOverlaps returns whether the two documents have any key-value pairs
+// or array elements in common, following the rules of JSON_OVERLAPS.
+func jsonOverlaps(left, right *json.Value) (bool, error) {
+	if left.Type() != json.TypeArray && right.Type() == json.TypeArray {
+		left, right = right, left
+	}
+
+	switch left.Type() {
+	case json.TypeArray:
+		lary, _ := left.Array()
+		rary, ok := right.Array()
+		if !ok {
+			rary = []*json.Value{right}
+		}
+		for _, lval := range lary {
+			for _, rval := range rary {
+				cmp, err := compareJSONValue(lval, rval)
+				if err != nil {
+					return false, err
+				}
+				if cmp == 0 {
+					return true, nil
+				}
+			}
+		}
+		return false, nil
+	case json.TypeObject:
+		robj, ok := right.Object()
+		if !ok {
+			return false, nil
+		}
+		lobj, _ := left.Object()
+		for _, key := range lobj.Keys() {
+			rval := robj.Get(key)
+			if rval == nil {
+				continue
+			}
+			cmp, err := compareJSONValue(lobj.Get(key), rval)
+			if err != nil {
+				return false, err
+			}
+			if cmp == 0 {
+				return true, nil
+			}
+		}
+		return false, nil
+	default:
+		cmp, err := compareJSONValue(left, right)
+		return cmp == 0, err
+	}
+}
+
+func (call *builtinJSONOverlaps) eval(env *ExpressionEnv) (eval, error) {
+	left, right, err := call.arg2(env)
+	if err != nil {
+		return nil, err
+	}
+	if left == nil || right == nil {
+		return nil, nil
+	}
+
+	ldoc, err := intoJSON(call.Method, left)
+	if err != nil {
+		return nil, err
+	}
+	rdoc, err := intoJSON(call.Method, right)
+	if err != nil {
+		return nil, err
+	}
+
+	overlaps, err := jsonOverlaps(ldoc, rdoc)
+	if err != nil {
+		return nil, err
+	}
+	return newEvalBool(overlaps), nil
+}
+
+func (call *builtinJSONOverlaps) typeof(env *ExpressionEnv, fields []*querypb.Field) (sqltypes.Type, typeFlag) {
+	_, f1 := call.Arguments[0].typeof(env, fields)
+	_, f2 := call.Arguments[1].typeof(env, fields)
+	return sqltypes.Int64, f1 | f2 | flagIsBoolean
+}
+
+func (call *builtinJSONOverlaps) compile(c *compiler) (ctype, error) {
+	left, err := call.Arguments[0].compile(c)
+	if err != nil {
+		return ctype{}, err
+	}
+	right, err := call.Arguments[1].compile(c)
+	if err != nil {
+		return ctype{}, err
+	}
+
+	skip := c.compileNullCheck2(left, right)
+	err = c.compileParseJSONArg(call.Method, left, 2)
+	if err != nil {
+		return ctype{}, err
+	}
+	err = c.compileParseJSONArg(call.Method, right, 1)
+	if err != nil {
+		return ctype{}, err
+	}
+
+	c.asm.Fn_JSON_OVERLAPS()
+	c.asm.jumpDestination(skip)
+	return ctype{Type: sqltypes.Int64, Col: collationNumeric, Flag: flagIsBoolean | flagNullable}, nil
+}
+
+// jsonMemberOf returns whether value is an element of the array doc or, if
+// doc is not an array, whether value is equal to doc.
+func jsonMemberOf(value, doc *json.Value) (bool, error) {
+	ary, ok := doc.Array()
+	if !ok {
+		ary = []*json.Value{doc}
+	}
+	for _, elem := range ary {
+		cmp, err := compareJSONValue(elem, value)
+		if err != nil {
+			return false, err
+		}
+		if cmp == 0 {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (call *builtinMemberOf) eval(env *ExpressionEnv) (eval, error) {
+	left, right, err := call.arg2(env)
+	if err != nil {
+		return nil, err
+	}
+	if left == nil || right == nil {
+		return nil, nil
+	}
+
+	value, err := argToJSON(left)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := intoJSON(call.Method, right)
+	if err != nil {
+		return nil, err
+	}
+
+	member, err := jsonMemberOf(value, doc)
+	if err != nil {
+		return nil, err
+	}
+	return newEvalBool(member), nil
+}
+
+func (call *builtinMemberOf) typeof(env *ExpressionEnv, fields []*querypb.Field) (sqltypes.Type, typeFlag) {
+	_, f1 := call.Arguments[0].typeof(env, fields)
+	_, f2 := call.Arguments[1].typeof(env, fields)
+	return sqltypes.Int64, f1 | f2 | flagIsBoolean
+}
+
+func (call *builtinMemberOf) compile(c *compiler) (ctype, error) {
+	value, err := call.Arguments[0].compile(c)
+	if err != nil {
+		return ctype{}, err
+	}
+	doc, err := call.Arguments[1].compile(c)
+	if err != nil {
+		return ctype{}, err
+	}
+
+	skip := c.compileNullCheck2(value, doc)
+	_, err = c.compileArgToJSON(value, 2)
+	if err != nil {
+		return ctype{}, err
+	}
+	err = c.compileParseJSONArg(call.Method, doc, 1)
+	if err != nil {
+		return ctype{}, err
+	}
+
+	c.asm.Fn_MEMBER_OF()
+	c.asm.jumpDestination(skip)
+	return ctype{Type: sqltypes.Int64, Col: collationNumeric, Flag: flagIsBoolean | flagNullable}, nil
+}
+
+var errIncorrectEscape = vterrors.Errorf(vtrpcpb.Code_INVALID_ARGUMENT, "Incorrect arguments to ESCAPE")
+
+func (call *builtinJSONSearch) eval(env *ExpressionEnv) (eval, error) {
+	args, err := call.args(env)
+	if err != nil {
+		return nil, err
+	}
+	if args[0] == nil || args[1] == nil || args[2] == nil {
+		return nil, nil
+	}
+
+	doc, err := intoJSON(call.Method, args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	match, err := intoOneOrAll(call.Method, evalToBinary(args[1]).string())
+	if err != nil {
+		return nil, err
+	}
+
+	search, err := evalToVarchar(args[2], collationJSON.Collation, true)
+	if err != nil {
+		return nil, err
+	}
+
+	var escape rune
+	if len(args) > 3 && args[3] != nil {
+		esc, err := evalToVarchar(args[3], collationJSON.Collation, true)
+		if err != nil {
+			return nil, err
+		}
+		switch r := []rune(esc.string()); len(r) {
+		case 0:
+		case 1:
+			escape = r[0]
+		default:
+			return nil, errIncorrectEscape
+		}
+	}
+
+	paths := []*json.Path{nil}
+	if len(args) > 4 {
+		paths = paths[:0]
+		for _, p := range args[4:] {
+			if p == nil {
+				return nil, nil
+			}
+			jp, err := intoJSONPath(p)
+			if err != nil {
+				return nil, err
+			}
+			paths = append(paths, jp)
+		}
+	}
+
+	wc := collationJSON.Collation.Get().Wildcard(search.bytes, 0, 0, escape)
+	seen := make(map[*json.Value]struct{})
+	var found []*json.Value
+
+	visit := func(path *json.Path, value *json.Value) {
+		if match == jsonMatchOne && len(found) > 0 {
+			return
+		}
+		if _, ok := seen[value]; ok {
+			return
+		}
+		seen[value] = struct{}{}
+		if str, ok := value.StringBytes(); ok && wc.Match(str) {
+			found = append(found, json.NewString(path.String()))
+		}
+	}
+
+	for _, jp := range paths {
+		jp.Walk(doc, visit)
+	}
+
+	switch len(found) {
+	case 0:
+		return nil, nil
+	case 1:
+		return found[0], nil
+	default:
+		return json.NewArray(found), nil
+	}
+}
+
+func (call *builtinJSONSearch) typeof(env *ExpressionEnv, fields []*querypb.Field) (sqltypes.Type, typeFlag) {
+	_, f := call.Arguments[0].typeof(env, fields)
+	return sqltypes.TypeJSON, f | flagNullable
+}
+
+func (call *builtinJSONSearch) compile(c *compiler) (ctype, error) {
+	return ctype{}, c.unsupported(call)
+}
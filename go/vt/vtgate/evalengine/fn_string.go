@@ -18,7 +18,11 @@ package evalengine
 
 import (
 	"bytes"
+	"math"
+	"strconv"
+	"strings"
 
+	"vitess.io/vitess/go/hack"
 	"vitess.io/vitess/go/mysql/collations"
 	"vitess.io/vitess/go/mysql/collations/charset"
 	"vitess.io/vitess/go/sqltypes"
@@ -89,6 +93,59 @@ type (
 		collate collations.ID
 		trim    sqlparser.TrimType
 	}
+
+	builtinSubstring struct {
+		CallExpr
+		collate collations.ID
+	}
+
+	builtinReplace struct {
+		CallExpr
+		collate collations.ID
+	}
+
+	// builtinLocate implements LOCATE, POSITION and INSTR. The arguments are
+	// always (substr, str[, pos]); INSTR swaps them when it's translated.
+	builtinLocate struct {
+		CallExpr
+		collate collations.ID
+	}
+
+	builtinReverse struct {
+		CallExpr
+		collate collations.ID
+	}
+
+	builtinInsert struct {
+		CallExpr
+		collate collations.ID
+	}
+
+	builtinField struct {
+		CallExpr
+	}
+
+	builtinElt struct {
+		CallExpr
+		collate collations.ID
+	}
+
+	builtinFormat struct {
+		CallExpr
+		collate collations.ID
+	}
+
+	builtinSpace struct {
+		CallExpr
+		collate collations.ID
+	}
+
+	// builtinChar implements CHAR(N, ... [USING charset]). The collation is
+	// binary unless a character set was given.
+	builtinChar struct {
+		CallExpr
+		collate collations.ID
+	}
 )
 
 var _ Expr = (*builtinChangeCase)(nil)
@@ -102,6 +159,16 @@ var _ Expr = (*builtinWeightString)(nil)
 var _ Expr = (*builtinLeftRight)(nil)
 var _ Expr = (*builtinPad)(nil)
 var _ Expr = (*builtinTrim)(nil)
+var _ Expr = (*builtinSubstring)(nil)
+var _ Expr = (*builtinReplace)(nil)
+var _ Expr = (*builtinLocate)(nil)
+var _ Expr = (*builtinReverse)(nil)
+var _ Expr = (*builtinInsert)(nil)
+var _ Expr = (*builtinField)(nil)
+var _ Expr = (*builtinElt)(nil)
+var _ Expr = (*builtinFormat)(nil)
+var _ Expr = (*builtinSpace)(nil)
+var _ Expr = (*builtinChar)(nil)
 
 func (call *builtinChangeCase) eval(env *ExpressionEnv) (eval, error) {
 	arg, err := call.arg1(env)
@@ -868,12 +935,34 @@ func (call builtinTrim) eval(env *ExpressionEnv) (eval, error) {
 
 	switch call.trim {
 	case sqlparser.LeadingTrimType:
-		return newEvalText(bytes.TrimPrefix(text.bytes, pat.bytes), text.col), nil
+		return newEvalText(trimLeftPattern(text.bytes, pat.bytes), text.col), nil
 	case sqlparser.TrailingTrimType:
-		return newEvalText(bytes.TrimSuffix(text.bytes, pat.bytes), text.col), nil
+		return newEvalText(trimRightPattern(text.bytes, pat.bytes), text.col), nil
 	default:
-		return newEvalText(bytes.TrimPrefix(bytes.TrimSuffix(text.bytes, pat.bytes), pat.bytes), text.col), nil
+		return newEvalText(trimLeftPattern(trimRightPattern(text.bytes, pat.bytes), pat.bytes), text.col), nil
+	}
+}
+
+// trimLeftPattern removes all the leading occurrences of pat from str.
+func trimLeftPattern(str, pat []byte) []byte {
+	if len(pat) == 0 {
+		return str
+	}
+	for bytes.HasPrefix(str, pat) {
+		str = str[len(pat):]
+	}
+	return str
+}
+
+// trimRightPattern removes all the trailing occurrences of pat from str.
+func trimRightPattern(str, pat []byte) []byte {
+	if len(pat) == 0 {
+		return str
 	}
+	for bytes.HasSuffix(str, pat) {
+		str = str[:len(str)-len(pat)]
+	}
+	return str
 }
 
 func (call builtinTrim) typeof(env *ExpressionEnv, fields []*querypb.Field) (sqltypes.Type, typeFlag) {
@@ -1230,3 +1319,978 @@ func (call *builtinConcatWs) compile(c *compiler) (ctype, error) {
 
 	return ctype{Type: tt, Flag: args[0].Flag, Col: tc}, nil
 }
+
+// charPos returns the byte offset at which the n-th character (counting from 0)
+// of str starts. Like MySQL's charpos, it returns an offset past the end of str
+// when n is negative or str doesn't have that many characters.
+func charPos(cs charset.Charset, str []byte, n int64) int {
+	if n < 0 {
+		return len(str) + 2
+	}
+	pos := 0
+	for ; n > 0 && pos < len(str); n-- {
+		_, size := cs.DecodeRune(str[pos:])
+		if size < 1 {
+			size = 1
+		}
+		pos += size
+	}
+	if n > 0 {
+		return len(str) + 2
+	}
+	return pos
+}
+
+// evalStringCollation aggregates the collations of the non-NULL arguments of a
+// function with a string result. If all of them are numeric, the default collation
+// is used instead.
+func evalStringCollation(collate collations.ID, args ...eval) (collations.TypedCollation, error) {
+	local := collations.Local()
+	var ca collationAggregation
+	for _, arg := range args {
+		if arg == nil {
+			continue
+		}
+		if err := ca.add(local, evalCollation(arg)); err != nil {
+			return collations.TypedCollation{}, err
+		}
+	}
+
+	tc := ca.result()
+	if tc.Collation == collations.Unknown || tc.Coercibility == collations.CoerceNumeric {
+		tc = defaultCoercionCollation(collate)
+	}
+	return tc, nil
+}
+
+// compileStringCollation is the compile time equivalent of evalStringCollation.
+func compileStringCollation(collate collations.ID, args ...ctype) (collations.TypedCollation, error) {
+	local := collations.Local()
+	var ca collationAggregation
+	for _, arg := range args {
+		if err := ca.add(local, arg.Col); err != nil {
+			return collations.TypedCollation{}, err
+		}
+	}
+
+	tc := ca.result()
+	if tc.Collation == collations.Unknown || tc.Coercibility == collations.CoerceNumeric {
+		tc = defaultCoercionCollation(collate)
+	}
+	return tc, nil
+}
+
+// substrArgument converts the position or length argument of SUBSTRING to an
+// integer. Unsigned values that don't fit in 32 bits are clamped instead of
+// wrapping around, so they're still treated as out of bounds positive values.
+func substrArgument(e eval) int64 {
+	if u, ok := e.(*evalUint64); ok && u.u > math.MaxInt32 {
+		return math.MaxInt32 + 1
+	}
+	return evalToInt64(e).i
+}
+
+func substring(cs charset.Charset, str []byte, pos, length int64, hasLength bool) []byte {
+	if hasLength && length <= 0 {
+		return nil
+	}
+	if !hasLength || length > math.MaxInt32 {
+		length = math.MaxInt32
+	}
+	if pos < math.MinInt32 || pos > math.MaxInt32 {
+		return nil
+	}
+
+	// A negative position counts from the end of the string.
+	if pos < 0 {
+		pos += int64(charset.Length(cs, str))
+	} else {
+		pos--
+	}
+
+	start := charPos(cs, str, pos)
+	if start >= len(str) {
+		return nil
+	}
+	end := start + charPos(cs, str[start:], length)
+	if end > len(str) {
+		end = len(str)
+	}
+	return str[start:end]
+}
+
+func (call *builtinSubstring) eval(env *ExpressionEnv) (eval, error) {
+	args, err := call.args(env)
+	if err != nil {
+		return nil, err
+	}
+	for _, arg := range args {
+		if arg == nil {
+			return nil, nil
+		}
+	}
+
+	text, ok := args[0].(*evalBytes)
+	if !ok {
+		text, err = evalToVarchar(args[0], call.collate, true)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var length int64
+	pos := substrArgument(args[1])
+	if len(args) > 2 {
+		length = substrArgument(args[2])
+	}
+
+	cs := text.col.Collation.Get().Charset()
+	return newEvalText(substring(cs, text.bytes, pos, length, len(args) > 2), text.col), nil
+}
+
+func (call *builtinSubstring) typeof(env *ExpressionEnv, fields []*querypb.Field) (sqltypes.Type, typeFlag) {
+	_, f1 := call.Arguments[0].typeof(env, fields)
+	return sqltypes.VarChar, f1
+}
+
+func (call *builtinSubstring) compile(c *compiler) (ctype, error) {
+	args := make([]ctype, 0, len(call.Arguments))
+	skips := make([]*jump, 0, len(call.Arguments))
+	for i, arg := range call.Arguments {
+		a, err := arg.compile(c)
+		if err != nil {
+			return ctype{}, err
+		}
+		skips = append(skips, c.compileNullCheckArg(a, i))
+		args = append(args, a)
+	}
+
+	col := defaultCoercionCollation(c.cfg.Collation)
+	switch {
+	case args[0].isTextual():
+		col = args[0].Col
+	default:
+		c.asm.Convert_xc(len(args), sqltypes.VarChar, col.Collation, 0, false)
+	}
+
+	for i, arg := range args[1:] {
+		// Unsigned arguments are kept as they are so that they are not
+		// wrapped around when converted to a signed integer.
+		if arg.Type != sqltypes.Uint64 {
+			_ = c.compileToInt64(arg, len(args)-1-i)
+		}
+	}
+
+	if len(args) > 2 {
+		c.asm.Fn_SUBSTRING3(col)
+	} else {
+		c.asm.Fn_SUBSTRING2(col)
+	}
+	c.asm.jumpDestination(skips...)
+	return ctype{Type: sqltypes.VarChar, Col: col, Flag: flagNullable}, nil
+}
+
+// replace replaces all the occurrences of from in str with to. The search is
+// case-sensitive, and matches only at character boundaries.
+func replace(cs charset.Charset, str, from, to []byte) []byte {
+	if len(from) == 0 {
+		return str
+	}
+
+	var res []byte
+	var last, pos int
+	for pos+len(from) <= len(str) {
+		if bytes.HasPrefix(str[pos:], from) {
+			res = append(res, str[last:pos]...)
+			res = append(res, to...)
+			pos += len(from)
+			last = pos
+			continue
+		}
+		_, size := cs.DecodeRune(str[pos:])
+		if size < 1 {
+			size = 1
+		}
+		pos += size
+	}
+
+	if last == 0 {
+		return str
+	}
+	return append(res, str[last:]...)
+}
+
+func (call *builtinReplace) eval(env *ExpressionEnv) (eval, error) {
+	str, from, to, err := call.arg3(env)
+	if err != nil {
+		return nil, err
+	}
+	if str == nil || from == nil || to == nil {
+		return nil, nil
+	}
+
+	tc, err := evalStringCollation(call.collate, str, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	text, err := evalToVarchar(str, tc.Collation, true)
+	if err != nil {
+		return nil, err
+	}
+	fromText, err := evalToVarchar(from, tc.Collation, true)
+	if err != nil {
+		return nil, err
+	}
+	toText, err := evalToVarchar(to, tc.Collation, true)
+	if err != nil {
+		return nil, err
+	}
+
+	cs := tc.Collation.Get().Charset()
+	return newEvalText(replace(cs, text.bytes, fromText.bytes, toText.bytes), tc), nil
+}
+
+func (call *builtinReplace) typeof(env *ExpressionEnv, fields []*querypb.Field) (sqltypes.Type, typeFlag) {
+	_, f1 := call.Arguments[0].typeof(env, fields)
+	_, f2 := call.Arguments[1].typeof(env, fields)
+	_, f3 := call.Arguments[2].typeof(env, fields)
+	return sqltypes.VarChar, f1 | f2 | f3
+}
+
+func (call *builtinReplace) compile(c *compiler) (ctype, error) {
+	str, err := call.Arguments[0].compile(c)
+	if err != nil {
+		return ctype{}, err
+	}
+
+	from, err := call.Arguments[1].compile(c)
+	if err != nil {
+		return ctype{}, err
+	}
+
+	to, err := call.Arguments[2].compile(c)
+	if err != nil {
+		return ctype{}, err
+	}
+
+	skip := c.compileNullCheck3(str, from, to)
+
+	tc, err := compileStringCollation(call.collate, str, from, to)
+	if err != nil {
+		return ctype{}, err
+	}
+
+	for i, arg := range []ctype{str, from, to} {
+		if !arg.isTextual() || arg.Col.Collation != tc.Collation {
+			c.asm.Convert_xce(3-i, sqltypes.VarChar, tc.Collation)
+		}
+	}
+
+	c.asm.Fn_REPLACE(tc)
+	c.asm.jumpDestination(skip)
+	return ctype{Type: sqltypes.VarChar, Col: tc, Flag: flagNullable}, nil
+}
+
+// locate returns the position of the first occurrence of substr in str, starting
+// the search at the character position pos, or 0 if there is none. Positions
+// are 1-based and counted in characters; matches are found by comparing substr
+// to the same number of bytes at each character boundary of str using coll.
+func locate(coll collations.Collation, substr, str []byte, pos int64) int64 {
+	if pos <= 0 || pos-1 > int64(len(str)) {
+		return 0
+	}
+	pos--
+
+	cs := coll.Charset()
+	start := charPos(cs, str, pos)
+	if start+len(substr) > len(str) {
+		return 0
+	}
+	if len(substr) == 0 {
+		// MySQL returns the byte offset of the starting position here
+		return int64(start) + 1
+	}
+
+	str = str[start:]
+	for idx := int64(0); len(str) >= len(substr); idx++ {
+		if coll.Collate(str[:len(substr)], substr, false) == 0 {
+			return pos + idx + 1
+		}
+		_, size := cs.DecodeRune(str)
+		if size < 1 {
+			size = 1
+		}
+		str = str[size:]
+	}
+	return 0
+}
+
+func (call *builtinLocate) eval(env *ExpressionEnv) (eval, error) {
+	args, err := call.args(env)
+	if err != nil {
+		return nil, err
+	}
+	for _, arg := range args {
+		if arg == nil {
+			return nil, nil
+		}
+	}
+
+	local := collations.Local()
+	var ca collationAggregation
+	if err := ca.add(local, evalCollation(args[0])); err != nil {
+		return nil, err
+	}
+	if err := ca.add(local, evalCollation(args[1])); err != nil {
+		return nil, err
+	}
+	col := ca.result().Collation
+
+	substr, err := evalToVarchar(args[0], col, true)
+	if err != nil {
+		return nil, err
+	}
+	str, err := evalToVarchar(args[1], col, true)
+	if err != nil {
+		return nil, err
+	}
+
+	pos := int64(1)
+	if len(args) > 2 {
+		pos = evalToInt64(args[2]).i
+	}
+	return newEvalInt64(locate(col.Get(), substr.bytes, str.bytes, pos)), nil
+}
+
+func (call *builtinLocate) typeof(env *ExpressionEnv, fields []*querypb.Field) (sqltypes.Type, typeFlag) {
+	var f typeFlag
+	for _, arg := range call.Arguments {
+		_, af := arg.typeof(env, fields)
+		f |= af
+	}
+	return sqltypes.Int64, f
+}
+
+func (call *builtinLocate) compile(c *compiler) (ctype, error) {
+	substr, err := call.Arguments[0].compile(c)
+	if err != nil {
+		return ctype{}, err
+	}
+
+	skip1 := c.compileNullCheck1(substr)
+
+	str, err := call.Arguments[1].compile(c)
+	if err != nil {
+		return ctype{}, err
+	}
+
+	skip2 := c.compileNullCheck1r(str)
+
+	var pos ctype
+	var skip3 *jump
+	if len(call.Arguments) > 2 {
+		pos, err = call.Arguments[2].compile(c)
+		if err != nil {
+			return ctype{}, err
+		}
+		skip3 = c.compileNullCheckArg(pos, 2)
+	}
+
+	local := collations.Local()
+	var ca collationAggregation
+	if err := ca.add(local, substr.Col); err != nil {
+		return ctype{}, err
+	}
+	if err := ca.add(local, str.Col); err != nil {
+		return ctype{}, err
+	}
+	col := ca.result().Collation
+
+	offset := len(call.Arguments)
+	if !substr.isTextual() || substr.Col.Collation != col {
+		c.asm.Convert_xce(offset, sqltypes.VarChar, col)
+	}
+	if !str.isTextual() || str.Col.Collation != col {
+		c.asm.Convert_xce(offset-1, sqltypes.VarChar, col)
+	}
+
+	if len(call.Arguments) > 2 {
+		_ = c.compileToInt64(pos, 1)
+		c.asm.Fn_LOCATE3(col)
+	} else {
+		c.asm.Fn_LOCATE2(col)
+	}
+	c.asm.jumpDestination(skip1, skip2, skip3)
+	return ctype{Type: sqltypes.Int64, Col: collationNumeric, Flag: flagNullable}, nil
+}
+
+func reverse(cs charset.Charset, str []byte) []byte {
+	res := make([]byte, len(str))
+	end := len(res)
+	for len(str) > 0 {
+		_, size := cs.DecodeRune(str)
+		if size < 1 {
+			size = 1
+		}
+		end -= size
+		copy(res[end:], str[:size])
+		str = str[size:]
+	}
+	return res
+}
+
+func (call *builtinReverse) eval(env *ExpressionEnv) (eval, error) {
+	arg, err := call.arg1(env)
+	if err != nil {
+		return nil, err
+	}
+	if arg == nil {
+		return nil, nil
+	}
+
+	text, ok := arg.(*evalBytes)
+	if !ok {
+		text, err = evalToVarchar(arg, call.collate, true)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	cs := text.col.Collation.Get().Charset()
+	return newEvalText(reverse(cs, text.bytes), text.col), nil
+}
+
+func (call *builtinReverse) typeof(env *ExpressionEnv, fields []*querypb.Field) (sqltypes.Type, typeFlag) {
+	_, f1 := call.Arguments[0].typeof(env, fields)
+	return sqltypes.VarChar, f1
+}
+
+func (call *builtinReverse) compile(c *compiler) (ctype, error) {
+	str, err := call.Arguments[0].compile(c)
+	if err != nil {
+		return ctype{}, err
+	}
+
+	skip := c.compileNullCheck1(str)
+
+	col := defaultCoercionCollation(c.cfg.Collation)
+	switch {
+	case str.isTextual():
+		col = str.Col
+	default:
+		c.asm.Convert_xc(1, sqltypes.VarChar, col.Collation, 0, false)
+	}
+
+	c.asm.Fn_REVERSE(col)
+	c.asm.jumpDestination(skip)
+	return ctype{Type: sqltypes.VarChar, Col: col, Flag: str.Flag}, nil
+}
+
+// insert replaces the length characters of str starting at the character position
+// pos with newstr. If pos is out of bounds, str is returned unchanged.
+func insert(cs charset.Charset, str []byte, pos, length int64, newstr []byte) []byte {
+	if pos < 1 || pos > int64(len(str)) {
+		return str
+	}
+	if length < 0 || length > int64(len(str)) {
+		length = int64(len(str))
+	}
+
+	start := charPos(cs, str, pos-1)
+	if start >= len(str) {
+		return str
+	}
+	end := start + charPos(cs, str[start:], length)
+	if end > len(str) {
+		end = len(str)
+	}
+
+	res := make([]byte, 0, start+len(newstr)+len(str)-end)
+	res = append(res, str[:start]...)
+	res = append(res, newstr...)
+	return append(res, str[end:]...)
+}
+
+func (call *builtinInsert) eval(env *ExpressionEnv) (eval, error) {
+	args, err := call.args(env)
+	if err != nil {
+		return nil, err
+	}
+	for _, arg := range args {
+		if arg == nil {
+			return nil, nil
+		}
+	}
+
+	tc, err := evalStringCollation(call.collate, args[0], args[3])
+	if err != nil {
+		return nil, err
+	}
+
+	str, err := evalToVarchar(args[0], tc.Collation, true)
+	if err != nil {
+		return nil, err
+	}
+	newstr, err := evalToVarchar(args[3], tc.Collation, true)
+	if err != nil {
+		return nil, err
+	}
+
+	pos := evalToInt64(args[1]).i
+	length := evalToInt64(args[2]).i
+
+	cs := tc.Collation.Get().Charset()
+	return newEvalText(insert(cs, str.bytes, pos, length, newstr.bytes), tc), nil
+}
+
+func (call *builtinInsert) typeof(env *ExpressionEnv, fields []*querypb.Field) (sqltypes.Type, typeFlag) {
+	var f typeFlag
+	for _, arg := range call.Arguments {
+		_, af := arg.typeof(env, fields)
+		f |= af
+	}
+	return sqltypes.VarChar, f
+}
+
+func (call *builtinInsert) compile(c *compiler) (ctype, error) {
+	args := make([]ctype, 0, len(call.Arguments))
+	skips := make([]*jump, 0, len(call.Arguments))
+	for i, arg := range call.Arguments {
+		a, err := arg.compile(c)
+		if err != nil {
+			return ctype{}, err
+		}
+		skips = append(skips, c.compileNullCheckArg(a, i))
+		args = append(args, a)
+	}
+
+	tc, err := compileStringCollation(call.collate, args[0], args[3])
+	if err != nil {
+		return ctype{}, err
+	}
+
+	if !args[0].isTextual() || args[0].Col.Collation != tc.Collation {
+		c.asm.Convert_xce(4, sqltypes.VarChar, tc.Collation)
+	}
+	_ = c.compileToInt64(args[1], 3)
+	_ = c.compileToInt64(args[2], 2)
+	if !args[3].isTextual() || args[3].Col.Collation != tc.Collation {
+		c.asm.Convert_xce(1, sqltypes.VarChar, tc.Collation)
+	}
+
+	c.asm.Fn_INSERT(tc)
+	c.asm.jumpDestination(skips...)
+	return ctype{Type: sqltypes.VarChar, Col: tc, Flag: flagNullable}, nil
+}
+
+// fieldIndex returns the 1-based index of the first argument after the first one
+// that is equal to it, or 0 if there is none. Like MySQL, the arguments are compared
+// as strings if they are all strings, as integers if they are all integers, as
+// decimals if they are all exact numbers, and as floats otherwise.
+func fieldIndex(args []eval) (int64, error) {
+	if args[0] == nil {
+		return 0, nil
+	}
+
+	strs, ints, decs := true, true, true
+	for _, arg := range args {
+		switch arg.(type) {
+		case nil:
+		case *evalInt64, *evalUint64:
+			strs = false
+		case *evalDecimal:
+			strs, ints = false, false
+		case *evalFloat:
+			strs, ints, decs = false, false, false
+		default:
+			ints, decs = false, false
+		}
+	}
+
+	switch {
+	case strs:
+		local := collations.Local()
+		var ca collationAggregation
+		for _, arg := range args {
+			if arg == nil {
+				continue
+			}
+			if err := ca.add(local, evalCollation(arg)); err != nil {
+				return 0, err
+			}
+		}
+		col := ca.result().Collation
+
+		value, err := evalToVarchar(args[0], col, true)
+		if err != nil {
+			return 0, err
+		}
+		for i, arg := range args[1:] {
+			if arg == nil {
+				continue
+			}
+			str, err := evalToVarchar(arg, col, true)
+			if err != nil {
+				return 0, err
+			}
+			if col.Get().Collate(value.bytes, str.bytes, false) == 0 {
+				return int64(i + 1), nil
+			}
+		}
+	case ints:
+		value := evalToInt64(args[0]).i
+		for i, arg := range args[1:] {
+			if arg != nil && evalToInt64(arg).i == value {
+				return int64(i + 1), nil
+			}
+		}
+	case decs:
+		value := evalToDecimal(args[0], 0, 0).dec
+		for i, arg := range args[1:] {
+			if arg != nil && evalToDecimal(arg, 0, 0).dec.Cmp(value) == 0 {
+				return int64(i + 1), nil
+			}
+		}
+	default:
+		value, _ := evalToFloat(args[0])
+		for i, arg := range args[1:] {
+			if arg == nil {
+				continue
+			}
+			if f, _ := evalToFloat(arg); f.f == value.f {
+				return int64(i + 1), nil
+			}
+		}
+	}
+	return 0, nil
+}
+
+func (call *builtinField) eval(env *ExpressionEnv) (eval, error) {
+	args, err := call.args(env)
+	if err != nil {
+		return nil, err
+	}
+	idx, err := fieldIndex(args)
+	if err != nil {
+		return nil, err
+	}
+	return newEvalInt64(idx), nil
+}
+
+func (call *builtinField) typeof(env *ExpressionEnv, fields []*querypb.Field) (sqltypes.Type, typeFlag) {
+	for _, arg := range call.Arguments {
+		arg.typeof(env, fields)
+	}
+	return sqltypes.Int64, 0
+}
+
+func (call *builtinField) compile(c *compiler) (ctype, error) {
+	for _, arg := range call.Arguments {
+		if _, err := arg.compile(c); err != nil {
+			return ctype{}, err
+		}
+	}
+
+	c.asm.Fn_FIELD(len(call.Arguments))
+	return ctype{Type: sqltypes.Int64, Col: collationNumeric}, nil
+}
+
+// elt returns the N-th string argument, where N is the first argument, converted
+// to the aggregated collation of all of them. It returns NULL if N is out of bounds.
+func elt(args []eval, collate collations.ID) (eval, error) {
+	if args[0] == nil {
+		return nil, nil
+	}
+
+	tc, err := evalStringCollation(collate, args[1:]...)
+	if err != nil {
+		return nil, err
+	}
+
+	n := evalToInt64(args[0]).i
+	if n < 1 || n >= int64(len(args)) || args[n] == nil {
+		return nil, nil
+	}
+	return evalToVarchar(args[n], tc.Collation, true)
+}
+
+func (call *builtinElt) eval(env *ExpressionEnv) (eval, error) {
+	args, err := call.args(env)
+	if err != nil {
+		return nil, err
+	}
+	return elt(args, call.collate)
+}
+
+func (call *builtinElt) typeof(env *ExpressionEnv, fields []*querypb.Field) (sqltypes.Type, typeFlag) {
+	for _, arg := range call.Arguments {
+		arg.typeof(env, fields)
+	}
+	return sqltypes.VarChar, flagNullable
+}
+
+func (call *builtinElt) compile(c *compiler) (ctype, error) {
+	args := make([]ctype, 0, len(call.Arguments)-1)
+	for i, arg := range call.Arguments {
+		a, err := arg.compile(c)
+		if err != nil {
+			return ctype{}, err
+		}
+		if i > 0 {
+			args = append(args, a)
+		}
+	}
+
+	tc, err := compileStringCollation(call.collate, args...)
+	if err != nil {
+		return ctype{}, err
+	}
+
+	c.asm.Fn_ELT(len(call.Arguments), call.collate)
+	return ctype{Type: sqltypes.VarChar, Col: tc, Flag: flagNullable}, nil
+}
+
+// formatMaxDecimals is the maximum number of decimals that FORMAT rounds to.
+const formatMaxDecimals = 30
+
+// formatNumber formats num like MySQL's FORMAT does for the en_US locale:
+// rounded to d decimals, and with a ',' separating the groups of thousands.
+func formatNumber(num eval, d int64) []byte {
+	if d < 0 {
+		d = 0
+	} else if d > formatMaxDecimals {
+		d = formatMaxDecimals
+	}
+
+	var str []byte
+	switch num := num.(type) {
+	case *evalInt64, *evalUint64, *evalDecimal:
+		str = []byte(evalToDecimal(num, 0, 0).dec.StringFixed(int32(d)))
+	default:
+		f, _ := evalToFloat(num)
+		v := f.f
+		if p := math.Pow10(int(d)); !math.IsInf(v*p, 0) {
+			v = math.Round(v*p) / p
+		}
+		str = strconv.AppendFloat(nil, v, 'f', int(d), 64)
+	}
+
+	var sign []byte
+	if len(str) > 0 && str[0] == '-' {
+		sign, str = str[:1], str[1:]
+	}
+	intPart, frac := str, []byte(nil)
+	if dot := bytes.IndexByte(str, '.'); dot >= 0 {
+		intPart, frac = str[:dot], str[dot:]
+	}
+
+	res := make([]byte, 0, len(sign)+len(str)+len(intPart)/3)
+	res = append(res, sign...)
+	for i, c := range intPart {
+		if i > 0 && (len(intPart)-i)%3 == 0 {
+			res = append(res, ',')
+		}
+		res = append(res, c)
+	}
+	return append(res, frac...)
+}
+
+// formatLocale checks the locale argument of FORMAT. Only the default en_US
+// locale is supported; MySQL also falls back to it when the locale is NULL.
+func formatLocale(locale eval) error {
+	if locale == nil {
+		return nil
+	}
+	if name := locale.ToRawBytes(); !strings.EqualFold(hack.String(name), "en_US") {
+		return vterrors.Errorf(vtrpcpb.Code_UNIMPLEMENTED, "FORMAT with locale '%s' is not supported", name)
+	}
+	return nil
+}
+
+func (call *builtinFormat) eval(env *ExpressionEnv) (eval, error) {
+	args, err := call.args(env)
+	if err != nil {
+		return nil, err
+	}
+	if args[0] == nil || args[1] == nil {
+		return nil, nil
+	}
+	if len(args) > 2 {
+		if err := formatLocale(args[2]); err != nil {
+			return nil, err
+		}
+	}
+
+	d := evalToInt64(args[1]).i
+	return newEvalText(formatNumber(args[0], d), defaultCoercionCollation(call.collate)), nil
+}
+
+func (call *builtinFormat) typeof(env *ExpressionEnv, fields []*querypb.Field) (sqltypes.Type, typeFlag) {
+	_, f1 := call.Arguments[0].typeof(env, fields)
+	_, f2 := call.Arguments[1].typeof(env, fields)
+	if len(call.Arguments) > 2 {
+		call.Arguments[2].typeof(env, fields)
+	}
+	return sqltypes.VarChar, f1 | f2
+}
+
+func (call *builtinFormat) compile(c *compiler) (ctype, error) {
+	num, err := call.Arguments[0].compile(c)
+	if err != nil {
+		return ctype{}, err
+	}
+
+	d, err := call.Arguments[1].compile(c)
+	if err != nil {
+		return ctype{}, err
+	}
+
+	skip := c.compileNullCheck2(num, d)
+
+	if len(call.Arguments) > 2 {
+		if _, err := call.Arguments[2].compile(c); err != nil {
+			return ctype{}, err
+		}
+		c.asm.Fn_FORMAT_LOCALE()
+	}
+
+	_ = c.compileToInt64(d, 1)
+
+	col := defaultCoercionCollation(call.collate)
+	c.asm.Fn_FORMAT(col)
+	c.asm.jumpDestination(skip)
+	return ctype{Type: sqltypes.VarChar, Col: col, Flag: num.Flag | d.Flag}, nil
+}
+
+// space returns a string of n spaces. It returns false if the string would be
+// longer than the maximum length that MySQL allows.
+func space(n eval) ([]byte, bool) {
+	var count int64
+	switch n := n.(type) {
+	case *evalUint64:
+		if n.u > maxRepeatLength {
+			return nil, false
+		}
+		count = int64(n.u)
+	default:
+		count = evalToInt64(n).i
+	}
+
+	if count <= 0 {
+		return nil, true
+	}
+	if !validMaxLength(1, count) {
+		return nil, false
+	}
+	return bytes.Repeat([]byte{' '}, int(count)), true
+}
+
+func (call *builtinSpace) eval(env *ExpressionEnv) (eval, error) {
+	arg, err := call.arg1(env)
+	if err != nil {
+		return nil, err
+	}
+	if arg == nil {
+		return nil, nil
+	}
+
+	res, ok := space(arg)
+	if !ok {
+		return nil, nil
+	}
+	return newEvalText(res, defaultCoercionCollation(call.collate)), nil
+}
+
+func (call *builtinSpace) typeof(env *ExpressionEnv, fields []*querypb.Field) (sqltypes.Type, typeFlag) {
+	call.Arguments[0].typeof(env, fields)
+	return sqltypes.VarChar, flagNullable
+}
+
+func (call *builtinSpace) compile(c *compiler) (ctype, error) {
+	n, err := call.Arguments[0].compile(c)
+	if err != nil {
+		return ctype{}, err
+	}
+
+	skip := c.compileNullCheck1(n)
+
+	// Unsigned values are kept as they are so that they are not
+	// wrapped around when converted to a signed integer.
+	if n.Type != sqltypes.Uint64 {
+		_ = c.compileToInt64(n, 1)
+	}
+
+	col := defaultCoercionCollation(call.collate)
+	c.asm.Fn_SPACE(col)
+	c.asm.jumpDestination(skip)
+	return ctype{Type: sqltypes.VarChar, Col: col, Flag: flagNullable}, nil
+}
+
+// charBytes converts each non-NULL argument of CHAR to an integer, and
+// returns the concatenation of their big-endian byte representations,
+// skipping the leading zero bytes.
+func charBytes(args []eval) []byte {
+	var buf []byte
+	for _, arg := range args {
+		if arg == nil {
+			continue
+		}
+		v := uint32(evalToInt64(arg).i)
+		switch {
+		case v&0xff000000 != 0:
+			buf = append(buf, byte(v>>24), byte(v>>16), byte(v>>8), byte(v))
+		case v&0xff0000 != 0:
+			buf = append(buf, byte(v>>16), byte(v>>8), byte(v))
+		case v&0xff00 != 0:
+			buf = append(buf, byte(v>>8), byte(v))
+		default:
+			buf = append(buf, byte(v))
+		}
+	}
+	return buf
+}
+
+// charResult returns the result of CHAR for the given bytes: a binary string if
+// no character set was given, or NULL if the bytes are not valid in it.
+func charResult(buf []byte, col collations.ID) eval {
+	if col == collations.CollationBinaryID {
+		return newEvalBinary(buf)
+	}
+	if !charset.Validate(col.Get().Charset(), buf) {
+		return nil
+	}
+	return newEvalText(buf, defaultCoercionCollation(col))
+}
+
+func (call *builtinChar) eval(env *ExpressionEnv) (eval, error) {
+	args, err := call.args(env)
+	if err != nil {
+		return nil, err
+	}
+	return charResult(charBytes(args), call.collate), nil
+}
+
+func (call *builtinChar) typeof(env *ExpressionEnv, fields []*querypb.Field) (sqltypes.Type, typeFlag) {
+	for _, arg := range call.Arguments {
+		arg.typeof(env, fields)
+	}
+	if call.collate == collations.CollationBinaryID {
+		return sqltypes.VarBinary, 0
+	}
+	return sqltypes.VarChar, flagNullable
+}
+
+func (call *builtinChar) compile(c *compiler) (ctype, error) {
+	for _, arg := range call.Arguments {
+		if _, err := arg.compile(c); err != nil {
+			return ctype{}, err
+		}
+	}
+
+	c.asm.Fn_CHAR(len(call.Arguments), call.collate)
+	if call.collate == collations.CollationBinaryID {
+		return ctype{Type: sqltypes.VarBinary, Col: collationBinary}, nil
+	}
+	return ctype{Type: sqltypes.VarChar, Col: defaultCoercionCollation(call.collate), Flag: flagNullable}, nil
+}
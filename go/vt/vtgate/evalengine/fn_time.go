@@ -26,6 +26,8 @@ import (
 	"vitess.io/vitess/go/mysql/decimal"
 	"vitess.io/vitess/go/sqltypes"
 	querypb "vitess.io/vitess/go/vt/proto/query"
+	vtrpcpb "vitess.io/vitess/go/vt/proto/vtrpc"
+	"vitess.io/vitess/go/vt/vterrors"
 )
 
 var SystemTime = time.Now
@@ -148,6 +150,51 @@ type (
 		unit    datetime.IntervalType
 		collate collations.ID
 	}
+
+	builtinDateDiff struct {
+		CallExpr
+	}
+
+	builtinFromDays struct {
+		CallExpr
+	}
+
+	builtinLastDay struct {
+		CallExpr
+	}
+
+	builtinPeriodAdd struct {
+		CallExpr
+	}
+
+	builtinPeriodDiff struct {
+		CallExpr
+	}
+
+	builtinSecToTime struct {
+		CallExpr
+	}
+
+	builtinStrToDate struct {
+		CallExpr
+	}
+
+	builtinTimeDiff struct {
+		CallExpr
+	}
+
+	builtinTimeToSec struct {
+		CallExpr
+	}
+
+	builtinTimestampDiff struct {
+		CallExpr
+		unit datetime.IntervalType
+	}
+
+	builtinToDays struct {
+		CallExpr
+	}
 )
 
 var _ Expr = (*builtinNow)(nil)
@@ -176,6 +223,17 @@ var _ Expr = (*builtinWeekDay)(nil)
 var _ Expr = (*builtinWeekOfYear)(nil)
 var _ Expr = (*builtinYear)(nil)
 var _ Expr = (*builtinYearWeek)(nil)
+var _ Expr = (*builtinDateDiff)(nil)
+var _ Expr = (*builtinFromDays)(nil)
+var _ Expr = (*builtinLastDay)(nil)
+var _ Expr = (*builtinPeriodAdd)(nil)
+var _ Expr = (*builtinPeriodDiff)(nil)
+var _ Expr = (*builtinSecToTime)(nil)
+var _ Expr = (*builtinStrToDate)(nil)
+var _ Expr = (*builtinTimeDiff)(nil)
+var _ Expr = (*builtinTimeToSec)(nil)
+var _ Expr = (*builtinTimestampDiff)(nil)
+var _ Expr = (*builtinToDays)(nil)
 
 func (call *builtinNow) eval(env *ExpressionEnv) (eval, error) {
 	now := env.time(call.utc)
@@ -1784,3 +1842,687 @@ func (call *builtinDateMath) compile(c *compiler) (ctype, error) {
 	}
 	return ret, nil
 }
+
+func (b *builtinToDays) eval(env *ExpressionEnv) (eval, error) {
+	date, err := b.arg1(env)
+	if err != nil {
+		return nil, err
+	}
+	if date == nil {
+		return nil, nil
+	}
+	d := evalToDate(date)
+	if d == nil || d.isZero() {
+		return nil, nil
+	}
+	return newEvalInt64(int64(d.dt.Date.DayNumber())), nil
+}
+
+func (b *builtinToDays) typeof(env *ExpressionEnv, fields []*querypb.Field) (sqltypes.Type, typeFlag) {
+	return sqltypes.Int64, flagNullable
+}
+
+func (call *builtinToDays) compile(c *compiler) (ctype, error) {
+	arg, err := call.Arguments[0].compile(c)
+	if err != nil {
+		return ctype{}, err
+	}
+
+	skip := c.compileNullCheck1(arg)
+
+	switch arg.Type {
+	case sqltypes.Date, sqltypes.Datetime:
+	default:
+		c.asm.Convert_xD_nz(1)
+	}
+	c.asm.Fn_TO_DAYS()
+	c.asm.jumpDestination(skip)
+	return ctype{Type: sqltypes.Int64, Col: collationNumeric, Flag: arg.Flag | flagNullable}, nil
+}
+
+func (b *builtinFromDays) eval(env *ExpressionEnv) (eval, error) {
+	arg, err := b.arg1(env)
+	if err != nil {
+		return nil, err
+	}
+	if arg == nil {
+		return nil, nil
+	}
+	return newEvalDate(datetime.NewDateFromDayNumber(int(evalToInt64(arg).i))), nil
+}
+
+func (b *builtinFromDays) typeof(env *ExpressionEnv, fields []*querypb.Field) (sqltypes.Type, typeFlag) {
+	_, f := b.Arguments[0].typeof(env, fields)
+	return sqltypes.Date, f
+}
+
+func (call *builtinFromDays) compile(c *compiler) (ctype, error) {
+	arg, err := call.Arguments[0].compile(c)
+	if err != nil {
+		return ctype{}, err
+	}
+
+	skip := c.compileNullCheck1(arg)
+
+	switch arg.Type {
+	case sqltypes.Int64:
+	default:
+		c.asm.Convert_xi(1)
+	}
+	c.asm.Fn_FROM_DAYS()
+	c.asm.jumpDestination(skip)
+	return ctype{Type: sqltypes.Date, Col: collationBinary, Flag: arg.Flag}, nil
+}
+
+func (b *builtinLastDay) eval(env *ExpressionEnv) (eval, error) {
+	date, err := b.arg1(env)
+	if err != nil {
+		return nil, err
+	}
+	if date == nil {
+		return nil, nil
+	}
+	d := evalToDate(date)
+	if d == nil || d.isZero() || d.dt.Date.Month() == 0 {
+		return nil, nil
+	}
+	return newEvalDate(d.dt.Date.LastDay()), nil
+}
+
+func (b *builtinLastDay) typeof(env *ExpressionEnv, fields []*querypb.Field) (sqltypes.Type, typeFlag) {
+	return sqltypes.Date, flagNullable
+}
+
+func (call *builtinLastDay) compile(c *compiler) (ctype, error) {
+	arg, err := call.Arguments[0].compile(c)
+	if err != nil {
+		return ctype{}, err
+	}
+
+	skip := c.compileNullCheck1(arg)
+
+	switch arg.Type {
+	case sqltypes.Date, sqltypes.Datetime:
+	default:
+		c.asm.Convert_xD_nz(1)
+	}
+	c.asm.Fn_LAST_DAY()
+	c.asm.jumpDestination(skip)
+	return ctype{Type: sqltypes.Date, Col: collationBinary, Flag: arg.Flag | flagNullable}, nil
+}
+
+func (b *builtinDateDiff) eval(env *ExpressionEnv) (eval, error) {
+	date1, date2, err := b.arg2(env)
+	if err != nil {
+		return nil, err
+	}
+	if date1 == nil || date2 == nil {
+		return nil, nil
+	}
+	d1 := evalToDate(date1)
+	if d1 == nil || d1.isZero() {
+		return nil, nil
+	}
+	d2 := evalToDate(date2)
+	if d2 == nil || d2.isZero() {
+		return nil, nil
+	}
+	return newEvalInt64(int64(d1.dt.Date.DayNumber() - d2.dt.Date.DayNumber())), nil
+}
+
+func (b *builtinDateDiff) typeof(env *ExpressionEnv, fields []*querypb.Field) (sqltypes.Type, typeFlag) {
+	return sqltypes.Int64, flagNullable
+}
+
+func (call *builtinDateDiff) compile(c *compiler) (ctype, error) {
+	date1, err := call.Arguments[0].compile(c)
+	if err != nil {
+		return ctype{}, err
+	}
+
+	date2, err := call.Arguments[1].compile(c)
+	if err != nil {
+		return ctype{}, err
+	}
+
+	skip := c.compileNullCheck2(date1, date2)
+
+	switch date1.Type {
+	case sqltypes.Date, sqltypes.Datetime:
+	default:
+		c.asm.Convert_xD_nz(2)
+	}
+
+	switch date2.Type {
+	case sqltypes.Date, sqltypes.Datetime:
+	default:
+		c.asm.Convert_xD_nz(1)
+	}
+
+	c.asm.Fn_DATEDIFF()
+	c.asm.jumpDestination(skip)
+	return ctype{Type: sqltypes.Int64, Col: collationNumeric, Flag: date1.Flag | date2.Flag | flagNullable}, nil
+}
+
+// timeDiff returns the difference between two temporal values as a TIME,
+// or nil if they can't be subtracted from each other. Like in MySQL, a TIME
+// can only be subtracted from another TIME, while dates and datetimes can be
+// freely mixed.
+func timeDiff(v1, v2 eval) *evalTemporal {
+	t1 := evalToTemporal(v1)
+	if t1 == nil {
+		return nil
+	}
+	t2 := evalToTemporal(v2)
+	if t2 == nil {
+		return nil
+	}
+	if (t1.SQLType() == sqltypes.Time) != (t2.SQLType() == sqltypes.Time) {
+		return nil
+	}
+
+	s1, n1 := t1.dt.ToSeconds()
+	s2, n2 := t2.dt.ToSeconds()
+
+	diff := decimal.NewFromInt(s1 - s2).Add(decimal.New(int64(n1-n2), -9))
+
+	prec := t1.prec
+	if t2.prec > prec {
+		prec = t2.prec
+	}
+	return newEvalTime(datetime.NewTimeFromSeconds(diff), int(prec))
+}
+
+func (b *builtinTimeDiff) eval(env *ExpressionEnv) (eval, error) {
+	time1, time2, err := b.arg2(env)
+	if err != nil {
+		return nil, err
+	}
+	if time1 == nil || time2 == nil {
+		return nil, nil
+	}
+	if t := timeDiff(time1, time2); t != nil {
+		return t, nil
+	}
+	return nil, nil
+}
+
+func (b *builtinTimeDiff) typeof(env *ExpressionEnv, fields []*querypb.Field) (sqltypes.Type, typeFlag) {
+	return sqltypes.Time, flagNullable
+}
+
+func (call *builtinTimeDiff) compile(c *compiler) (ctype, error) {
+	time1, err := call.Arguments[0].compile(c)
+	if err != nil {
+		return ctype{}, err
+	}
+
+	time2, err := call.Arguments[1].compile(c)
+	if err != nil {
+		return ctype{}, err
+	}
+
+	skip := c.compileNullCheck2(time1, time2)
+	c.asm.Fn_TIMEDIFF()
+	c.asm.jumpDestination(skip)
+	return ctype{Type: sqltypes.Time, Col: collationBinary, Flag: time1.Flag | time2.Flag | flagNullable}, nil
+}
+
+// timestampDiff returns the difference between two datetimes in the
+// given unit, with the same rounding rules as MySQL's TIMESTAMPDIFF.
+func timestampDiff(dt1, dt2 datetime.DateTime, unit datetime.IntervalType) int64 {
+	s1, n1 := dt1.ToSeconds()
+	s2, n2 := dt2.ToSeconds()
+
+	usecs := (s2-s1)*1000000 + int64(n2-n1)/1000
+	sign := int64(1)
+	if usecs < 0 {
+		sign = -1
+		usecs = -usecs
+	}
+
+	switch unit {
+	case datetime.IntervalMicrosecond:
+		return sign * usecs
+	case datetime.IntervalSecond:
+		return sign * (usecs / 1000000)
+	case datetime.IntervalMinute:
+		return sign * (usecs / 1000000 / 60)
+	case datetime.IntervalHour:
+		return sign * (usecs / 1000000 / 3600)
+	case datetime.IntervalDay:
+		return sign * (usecs / 1000000 / 86400)
+	case datetime.IntervalWeek:
+		return sign * (usecs / 1000000 / 604800)
+	}
+
+	beg, end := dt1, dt2
+	if sign < 0 {
+		beg, end = dt2, dt1
+	}
+
+	years := end.Date.Year() - beg.Date.Year()
+	months := 0
+	if end.Date.Month() < beg.Date.Month() || end.Date.Month() == beg.Date.Month() && end.Date.Day() < beg.Date.Day() {
+		years--
+		months = 12 - (beg.Date.Month() - end.Date.Month())
+	} else {
+		months = end.Date.Month() - beg.Date.Month()
+	}
+	months += 12 * years
+
+	secBeg := beg.Time.Hour()*3600 + beg.Time.Minute()*60 + beg.Time.Second()
+	secEnd := end.Time.Hour()*3600 + end.Time.Minute()*60 + end.Time.Second()
+	if end.Date.Day() < beg.Date.Day() ||
+		end.Date.Day() == beg.Date.Day() && (secEnd < secBeg || secEnd == secBeg && end.Time.Nanosecond() < beg.Time.Nanosecond()) {
+		months--
+	}
+
+	switch unit {
+	case datetime.IntervalYear:
+		return sign * int64(months/12)
+	case datetime.IntervalQuarter:
+		return sign * int64(months/3)
+	default:
+		return sign * int64(months)
+	}
+}
+
+func (b *builtinTimestampDiff) eval(env *ExpressionEnv) (eval, error) {
+	date1, date2, err := b.arg2(env)
+	if err != nil {
+		return nil, err
+	}
+	if date1 == nil || date2 == nil {
+		return nil, nil
+	}
+	dt1 := evalToDateTime(date1, -1)
+	if dt1 == nil || dt1.dt.Date.IsZero() {
+		return nil, nil
+	}
+	dt2 := evalToDateTime(date2, -1)
+	if dt2 == nil || dt2.dt.Date.IsZero() {
+		return nil, nil
+	}
+	return newEvalInt64(timestampDiff(dt1.dt, dt2.dt, b.unit)), nil
+}
+
+func (b *builtinTimestampDiff) typeof(env *ExpressionEnv, fields []*querypb.Field) (sqltypes.Type, typeFlag) {
+	return sqltypes.Int64, flagNullable
+}
+
+func (call *builtinTimestampDiff) compile(c *compiler) (ctype, error) {
+	date1, err := call.Arguments[0].compile(c)
+	if err != nil {
+		return ctype{}, err
+	}
+
+	date2, err := call.Arguments[1].compile(c)
+	if err != nil {
+		return ctype{}, err
+	}
+
+	skip := c.compileNullCheck2(date1, date2)
+
+	switch date1.Type {
+	case sqltypes.Datetime:
+	default:
+		c.asm.Convert_xDT_nz(2, -1)
+	}
+
+	switch date2.Type {
+	case sqltypes.Datetime:
+	default:
+		c.asm.Convert_xDT_nz(1, -1)
+	}
+
+	c.asm.Fn_TIMESTAMPDIFF(call.unit)
+	c.asm.jumpDestination(skip)
+	return ctype{Type: sqltypes.Int64, Col: collationNumeric, Flag: date1.Flag | date2.Flag | flagNullable}, nil
+}
+
+func (b *builtinTimeToSec) eval(env *ExpressionEnv) (eval, error) {
+	arg, err := b.arg1(env)
+	if err != nil {
+		return nil, err
+	}
+	if arg == nil {
+		return nil, nil
+	}
+	t := evalToTime(arg, -1)
+	if t == nil {
+		return nil, nil
+	}
+	return newEvalInt64(int64(t.dt.Time.ToDuration() / time.Second)), nil
+}
+
+func (b *builtinTimeToSec) typeof(env *ExpressionEnv, fields []*querypb.Field) (sqltypes.Type, typeFlag) {
+	return sqltypes.Int64, flagNullable
+}
+
+func (call *builtinTimeToSec) compile(c *compiler) (ctype, error) {
+	arg, err := call.Arguments[0].compile(c)
+	if err != nil {
+		return ctype{}, err
+	}
+
+	skip := c.compileNullCheck1(arg)
+
+	switch arg.Type {
+	case sqltypes.Time:
+	default:
+		c.asm.Convert_xT(1, -1)
+	}
+	c.asm.Fn_TIME_TO_SEC()
+	c.asm.jumpDestination(skip)
+	return ctype{Type: sqltypes.Int64, Col: collationNumeric, Flag: arg.Flag | flagNullable}, nil
+}
+
+// secToTime converts a number of seconds into a TIME, with the precision
+// that MySQL uses for the result of SEC_TO_TIME.
+func secToTime(arg eval) *evalTemporal {
+	var prec int
+	switch arg := arg.(type) {
+	case *evalInt64, *evalUint64:
+	case *evalBytes:
+		if arg.isHexOrBitLiteral() {
+			break
+		}
+		prec = datetime.DefaultPrecision
+	case *evalDecimal:
+		prec = int(arg.length)
+		if prec > datetime.DefaultPrecision {
+			prec = datetime.DefaultPrecision
+		}
+	default:
+		prec = datetime.DefaultPrecision
+	}
+	return newEvalTime(datetime.NewTimeFromSeconds(evalToDecimal(arg, 0, 0).dec), prec)
+}
+
+func (b *builtinSecToTime) eval(env *ExpressionEnv) (eval, error) {
+	arg, err := b.arg1(env)
+	if err != nil {
+		return nil, err
+	}
+	if arg == nil {
+		return nil, nil
+	}
+	return secToTime(arg), nil
+}
+
+func (b *builtinSecToTime) typeof(env *ExpressionEnv, fields []*querypb.Field) (sqltypes.Type, typeFlag) {
+	_, f := b.Arguments[0].typeof(env, fields)
+	return sqltypes.Time, f
+}
+
+func (call *builtinSecToTime) compile(c *compiler) (ctype, error) {
+	arg, err := call.Arguments[0].compile(c)
+	if err != nil {
+		return ctype{}, err
+	}
+
+	skip := c.compileNullCheck1(arg)
+	c.asm.Fn_SEC_TO_TIME()
+	c.asm.jumpDestination(skip)
+	return ctype{Type: sqltypes.Time, Col: collationBinary, Flag: arg.Flag}, nil
+}
+
+func validPeriod(p int64) bool {
+	return p > 0 && p%100 != 0 && p%100 <= 12
+}
+
+// periodToMonth converts a period in the YYMM or YYYYMM format
+// into the number of months since year 0.
+func periodToMonth(p int64) int64 {
+	if p == 0 {
+		return 0
+	}
+	year := p / 100
+	if year < 70 {
+		year += 2000
+	} else if year < 100 {
+		year += 1900
+	}
+	return year*12 + p%100 - 1
+}
+
+// monthToPeriod is the inverse of periodToMonth and always
+// returns periods in the YYYYMM format.
+func monthToPeriod(months int64) int64 {
+	if months == 0 {
+		return 0
+	}
+	year := months / 12
+	if year < 100 {
+		if year < 70 {
+			year += 2000
+		} else {
+			year += 1900
+		}
+	}
+	return year*100 + months%12 + 1
+}
+
+func errIncorrectArguments(fn string) error {
+	return vterrors.NewErrorf(vtrpcpb.Code_INVALID_ARGUMENT, vterrors.WrongArguments, "Incorrect arguments to %s", fn)
+}
+
+func periodAdd(p, n int64) (int64, error) {
+	if !validPeriod(p) {
+		return 0, errIncorrectArguments("period_add")
+	}
+	return monthToPeriod(periodToMonth(p) + n), nil
+}
+
+func periodDiff(p1, p2 int64) (int64, error) {
+	if !validPeriod(p1) || !validPeriod(p2) {
+		return 0, errIncorrectArguments("period_diff")
+	}
+	return periodToMonth(p1) - periodToMonth(p2), nil
+}
+
+func (b *builtinPeriodAdd) eval(env *ExpressionEnv) (eval, error) {
+	period, months, err := b.arg2(env)
+	if err != nil {
+		return nil, err
+	}
+	if period == nil || months == nil {
+		return nil, nil
+	}
+	p, err := periodAdd(evalToInt64(period).i, evalToInt64(months).i)
+	if err != nil {
+		return nil, err
+	}
+	return newEvalInt64(p), nil
+}
+
+func (b *builtinPeriodAdd) typeof(env *ExpressionEnv, fields []*querypb.Field) (sqltypes.Type, typeFlag) {
+	_, f1 := b.Arguments[0].typeof(env, fields)
+	_, f2 := b.Arguments[1].typeof(env, fields)
+	return sqltypes.Int64, f1 | f2
+}
+
+func (call *builtinPeriodAdd) compile(c *compiler) (ctype, error) {
+	period, months, skip, err := compilePeriodArgs(c, call.Arguments)
+	if err != nil {
+		return ctype{}, err
+	}
+
+	c.asm.Fn_PERIOD_ADD()
+	c.asm.jumpDestination(skip)
+	return ctype{Type: sqltypes.Int64, Col: collationNumeric, Flag: period.Flag | months.Flag}, nil
+}
+
+func (b *builtinPeriodDiff) eval(env *ExpressionEnv) (eval, error) {
+	period1, period2, err := b.arg2(env)
+	if err != nil {
+		return nil, err
+	}
+	if period1 == nil || period2 == nil {
+		return nil, nil
+	}
+	p, err := periodDiff(evalToInt64(period1).i, evalToInt64(period2).i)
+	if err != nil {
+		return nil, err
+	}
+	return newEvalInt64(p), nil
+}
+
+func (b *builtinPeriodDiff) typeof(env *ExpressionEnv, fields []*querypb.Field) (sqltypes.Type, typeFlag) {
+	_, f1 := b.Arguments[0].typeof(env, fields)
+	_, f2 := b.Arguments[1].typeof(env, fields)
+	return sqltypes.Int64, f1 | f2
+}
+
+func (call *builtinPeriodDiff) compile(c *compiler) (ctype, error) {
+	period1, period2, skip, err := compilePeriodArgs(c, call.Arguments)
+	if err != nil {
+		return ctype{}, err
+	}
+
+	c.asm.Fn_PERIOD_DIFF()
+	c.asm.jumpDestination(skip)
+	return ctype{Type: sqltypes.Int64, Col: collationNumeric, Flag: period1.Flag | period2.Flag}, nil
+}
+
+func compilePeriodArgs(c *compiler, args TupleExpr) (ctype, ctype, *jump, error) {
+	arg1, err := args[0].compile(c)
+	if err != nil {
+		return ctype{}, ctype{}, nil, err
+	}
+
+	arg2, err := args[1].compile(c)
+	if err != nil {
+		return ctype{}, ctype{}, nil, err
+	}
+
+	skip := c.compileNullCheck2(arg1, arg2)
+
+	switch arg1.Type {
+	case sqltypes.Int64:
+	default:
+		c.asm.Convert_xi(2)
+	}
+
+	switch arg2.Type {
+	case sqltypes.Int64:
+	default:
+		c.asm.Convert_xi(1)
+	}
+	return arg1, arg2, skip, nil
+}
+
+// strToDateType returns the type of the result of STR_TO_DATE
+// for the given format, together with its precision.
+func strToDateType(format string) (sqltypes.Type, int) {
+	date, tm, frac := datetime.StrToDateParts(format)
+
+	prec := 0
+	if frac {
+		prec = datetime.DefaultPrecision
+	}
+
+	switch {
+	case date && tm:
+		return sqltypes.Datetime, prec
+	case tm:
+		return sqltypes.Time, prec
+	default:
+		return sqltypes.Date, 0
+	}
+}
+
+// validDate returns whether the given date can be returned by STR_TO_DATE
+// under the current sql_mode, i.e. whether it'd be a valid value to insert
+// into a DATE column.
+func (mode sqlMode) validDate(d datetime.Date) bool {
+	if d.IsZero() {
+		return mode&sqlModeNoZeroDate == 0
+	}
+	if d.Month() == 0 || d.Day() == 0 {
+		return mode&sqlModeNoZeroInDate == 0
+	}
+	return mode&sqlModeAllowInvalidDates != 0 || d.Day() <= d.LastDay().Day()
+}
+
+func strToDate(mode sqlMode, str, format string) *evalTemporal {
+	dt, ok := datetime.StrToDate(str, format)
+	if !ok {
+		return nil
+	}
+
+	tt, prec := strToDateType(format)
+	if tt == sqltypes.Time {
+		return newEvalTime(dt.Time, prec)
+	}
+	if !mode.validDate(dt.Date) {
+		return nil
+	}
+	if tt == sqltypes.Datetime {
+		return newEvalDateTime(dt, prec)
+	}
+	return newEvalDate(dt.Date)
+}
+
+func (b *builtinStrToDate) constant() bool {
+	// the result depends on the sql_mode of the session
+	return false
+}
+
+func (b *builtinStrToDate) eval(env *ExpressionEnv) (eval, error) {
+	str, format, err := b.arg2(env)
+	if err != nil {
+		return nil, err
+	}
+	if str == nil || format == nil {
+		return nil, nil
+	}
+	if d := strToDate(env.currentSQLMode(), evalToBinary(str).string(), evalToBinary(format).string()); d != nil {
+		return d, nil
+	}
+	return nil, nil
+}
+
+func (b *builtinStrToDate) typeof(env *ExpressionEnv, fields []*querypb.Field) (sqltypes.Type, typeFlag) {
+	if lit, ok := b.Arguments[1].(*Literal); ok && lit.inner != nil {
+		tt, _ := strToDateType(evalToBinary(lit.inner).string())
+		return tt, flagNullable
+	}
+	return sqltypes.Datetime, flagAmbiguousType | flagNullable
+}
+
+func (call *builtinStrToDate) compile(c *compiler) (ctype, error) {
+	// The type of the result depends on the format, so we can only
+	// compile this function when the format is a constant.
+	if !call.Arguments[1].constant() {
+		return ctype{}, c.unsupported(call)
+	}
+	fexpr, err := simplifyExpr(EmptyExpressionEnv(), call.Arguments[1])
+	if err != nil {
+		return ctype{}, err
+	}
+	lit, ok := fexpr.(*Literal)
+	if !ok || lit.inner == nil {
+		return ctype{}, c.unsupported(call)
+	}
+	format := evalToBinary(lit.inner).string()
+
+	str, err := call.Arguments[0].compile(c)
+	if err != nil {
+		return ctype{}, err
+	}
+
+	skip := c.compileNullCheck1(str)
+
+	switch {
+	case str.isTextual():
+	default:
+		c.asm.Convert_xb(1, sqltypes.VarBinary, 0, false)
+	}
+
+	tt, _ := strToDateType(format)
+	c.asm.Fn_STR_TO_DATE(format)
+	c.asm.jumpDestination(skip)
+	return ctype{Type: tt, Col: collationBinary, Flag: str.Flag | flagNullable}, nil
+}
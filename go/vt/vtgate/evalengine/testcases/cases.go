@@ -33,6 +33,8 @@ var Cases = []TestCase{
 	{Run: JSONPathOperations},
 	{Run: JSONArray},
 	{Run: JSONObject},
+	{Run: JSONModifications},
+	{Run: JSONSearchOperations},
 	{Run: CharsetConversionOperators},
 	{Run: CaseExprWithPredicate},
 	{Run: CaseExprWithValue},
@@ -80,6 +82,17 @@ var Cases = []TestCase{
 	{Run: FnTrim},
 	{Run: FnConcat},
 	{Run: FnConcatWs},
+	{Run: FnSubstring},
+	{Run: FnReplace},
+	{Run: FnLocate},
+	{Run: FnInstr},
+	{Run: FnReverse},
+	{Run: FnInsert},
+	{Run: FnField},
+	{Run: FnElt},
+	{Run: FnFormat},
+	{Run: FnSpace},
+	{Run: FnChar},
 	{Run: FnHex},
 	{Run: FnUnhex},
 	{Run: FnCeil},
@@ -138,6 +151,17 @@ var Cases = []TestCase{
 	{Run: FnWeekOfYear},
 	{Run: FnYear},
 	{Run: FnYearWeek},
+	{Run: FnDateDiff},
+	{Run: FnTimeDiff},
+	{Run: FnTimestampDiff},
+	{Run: FnStrToDate},
+	{Run: FnLastDay},
+	{Run: FnToDays},
+	{Run: FnFromDays},
+	{Run: FnSecToTime},
+	{Run: FnTimeToSec},
+	{Run: FnPeriodAdd},
+	{Run: FnPeriodDiff},
 	{Run: FnInetAton},
 	{Run: FnInetNtoa},
 	{Run: FnInet6Aton},
@@ -195,6 +219,78 @@ func JSONObject(yield Query) {
 	yield("JSON_OBJECT()", nil)
 }
 
+func JSONModifications(yield Query) {
+	var candidates = []string{
+		`1`, `10`, `"foo"`, `true`, `[10]`, `[[30]]`, `{"a": 1}`, `{"c": {"d": 4}}`, `null`,
+	}
+
+	for _, obj := range inputJSONObjects {
+		for _, path := range inputJSONPaths {
+			yield(fmt.Sprintf("JSON_SET('%s', '%s', 42)", obj, path), nil)
+			yield(fmt.Sprintf("JSON_INSERT('%s', '%s', 'foo')", obj, path), nil)
+			yield(fmt.Sprintf("JSON_REPLACE('%s', '%s', JSON_ARRAY(1, 2))", obj, path), nil)
+			yield(fmt.Sprintf("JSON_SET('%s', '%s', NULL, '$[5]', 1)", obj, path), nil)
+			yield(fmt.Sprintf("JSON_REMOVE('%s', '%s')", obj, path), nil)
+			yield(fmt.Sprintf("JSON_CONTAINS('%s', '1', '%s')", obj, path), nil)
+		}
+
+		for _, obj2 := range inputJSONObjects {
+			yield(fmt.Sprintf("JSON_MERGE_PRESERVE('%s', '%s')", obj, obj2), nil)
+			yield(fmt.Sprintf("JSON_MERGE_PATCH('%s', '%s')", obj, obj2), nil)
+			yield(fmt.Sprintf("JSON_CONTAINS('%s', '%s')", obj, obj2), nil)
+			yield(fmt.Sprintf("JSON_OVERLAPS('%s', '%s')", obj, obj2), nil)
+		}
+
+		for _, c := range candidates {
+			yield(fmt.Sprintf("JSON_CONTAINS('%s', '%s')", obj, c), nil)
+			yield(fmt.Sprintf("JSON_OVERLAPS('%s', '%s')", obj, c), nil)
+			yield(fmt.Sprintf("JSON_MERGE_PRESERVE('%s', '%s', '%s')", obj, c, obj), nil)
+			yield(fmt.Sprintf("JSON_MERGE_PATCH('%s', '%s', '%s')", c, obj, c), nil)
+		}
+
+		for _, prim := range inputJSONPrimitives {
+			yield(fmt.Sprintf("%s MEMBER OF('%s')", prim, obj), nil)
+			yield(fmt.Sprintf("JSON_SET('%s', '$.z', %s)", obj, prim), nil)
+		}
+	}
+
+	yield("JSON_MERGE_PATCH(NULL, '{\"a\": 1}')", nil)
+	yield("JSON_MERGE_PATCH(NULL, '[1]')", nil)
+	yield("JSON_MERGE_PATCH('{\"a\": 1}', NULL)", nil)
+	yield("JSON_MERGE_PRESERVE('[1]', NULL)", nil)
+	yield("JSON_SET(NULL, '$.a', 1)", nil)
+	yield("JSON_SET('{}', NULL, 1)", nil)
+	yield("JSON_REMOVE('[1, 2]', '$')", nil)
+	yield("JSON_CONTAINS('[1, 2]', NULL)", nil)
+	yield("17 MEMBER OF('[23, \"abc\", 17, \"ab\", 10]')", nil)
+	yield("CAST('[4,5]' AS JSON) MEMBER OF('[[3,4],[4,5]]')", nil)
+	yield("'[4,5]' MEMBER OF('[[3,4],[4,5]]')", nil)
+}
+
+func JSONSearchOperations(yield Query) {
+	var searches = []string{
+		`'foo'`, `'%a%'`, `'1%'`, `'f_o'`, `'123'`, `'true'`, `'%'`, `'%\\%'`, `NULL`,
+	}
+	var paths = []string{`$`, `$.b`, `$[*]`, `$**.c`, `$[1]`}
+
+	for _, obj := range inputJSONObjects {
+		for _, search := range searches {
+			yield(fmt.Sprintf("JSON_SEARCH('%s', 'one', %s)", obj, search), nil)
+			yield(fmt.Sprintf("JSON_SEARCH('%s', 'all', %s)", obj, search), nil)
+			yield(fmt.Sprintf("JSON_SEARCH('%s', 'all', %s, '|')", obj, search), nil)
+
+			for _, path := range paths {
+				yield(fmt.Sprintf("JSON_SEARCH('%s', 'all', %s, NULL, '%s')", obj, search, path), nil)
+				yield(fmt.Sprintf("JSON_SEARCH('%s', 'one', %s, NULL, '%s', '$')", obj, search, path), nil)
+			}
+		}
+	}
+
+	yield("JSON_SEARCH('[\"abc\", \"a%c\"]', 'all', 'a|%c', '|')", nil)
+	yield("JSON_SEARCH('[\"abc\"]', 'any', 'abc')", nil)
+	yield("JSON_SEARCH('[\"abc\"]', 'all', 'abc', 'xx')", nil)
+}
+
 func CharsetConversionOperators(yield Query) {
 	var introducers = []string{
 		"", "_latin1", "_utf8mb4", "_utf8", "_binary",
@@ -1449,6 +1545,180 @@ func FnConcatWs(yield Query) {
 	}
 }
 
+func FnSubstring(yield Query) {
+	positions := []string{"0", "1", "2", "-1", "-3", "10", "-10", "'2'", "1.5", "18446744073709551615", "NULL"}
+	lengths := []string{"0", "1", "3", "-1", "10", "1.5", "18446744073709551615", "NULL"}
+	for _, str := range inputStrings {
+		for _, pos := range positions {
+			yield(fmt.Sprintf("SUBSTRING(%s, %s)", str, pos), nil)
+			yield(fmt.Sprintf("MID(%s FROM %s)", str, pos), nil)
+			for _, length := range lengths {
+				yield(fmt.Sprintf("SUBSTR(%s, %s, %s)", str, pos, length), nil)
+			}
+		}
+	}
+}
+
+func FnReplace(yield Query) {
+	cases := []string{
+		"REPLACE('www.mysql.com', 'w', 'Ww')",
+		"REPLACE('abcabc', 'abc', '')",
+		"REPLACE('aaaa', 'aa', 'a')",
+		"REPLACE('abc', '', 'x')",
+		"REPLACE('ABC', 'b', 'x')",
+		"REPLACE('Å å', 'å', 'a')",
+		"REPLACE(123123, 2, 0)",
+		"REPLACE(_binary 'ABC', 'B', 'x')",
+	}
+	for _, q := range cases {
+		yield(q, nil)
+	}
+
+	for _, str := range inputStrings {
+		for _, from := range inputStrings {
+			yield(fmt.Sprintf("REPLACE(%s, %s, 'x')", str, from), nil)
+			yield(fmt.Sprintf("REPLACE(%s, 'a', %s)", str, from), nil)
+		}
+	}
+}
+
+func FnLocate(yield Query) {
+	positions := []string{"-1", "0", "1", "2", "3", "10", "NULL"}
+	for _, substr := range inputStrings {
+		for _, str := range inputStrings {
+			yield(fmt.Sprintf("LOCATE(%s, %s)", substr, str), nil)
+			yield(fmt.Sprintf("POSITION(%s IN %s)", substr, str), nil)
+			for _, pos := range positions {
+				yield(fmt.Sprintf("LOCATE(%s, %s, %s)", substr, str, pos), nil)
+			}
+		}
+	}
+
+	cases := []string{
+		"LOCATE('bar', 'foobarbar')",
+		"LOCATE('bar', 'foobarbar', 5)",
+		"LOCATE('BAR', 'foobarbar')",
+		"LOCATE('BAR', 'foobarbar' COLLATE utf8mb4_0900_as_cs)",
+		"LOCATE('a', 'Å å a')",
+		"LOCATE('', 'Å å a', 3)",
+	}
+	for _, q := range cases {
+		yield(q, nil)
+	}
+}
+
+func FnInstr(yield Query) {
+	for _, str := range inputStrings {
+		for _, substr := range inputStrings {
+			yield(fmt.Sprintf("INSTR(%s, %s)", str, substr), nil)
+		}
+	}
+}
+
+func FnReverse(yield Query) {
+	for _, str := range inputStrings {
+		yield(fmt.Sprintf("REVERSE(%s)", str), nil)
+	}
+}
+
+func FnInsert(yield Query) {
+	positions := []string{"-1", "0", "1", "2", "10", "NULL"}
+	lengths := []string{"-1", "0", "2", "100", "NULL"}
+	for _, str := range inputStrings {
+		for _, pos := range positions {
+			for _, length := range lengths {
+				yield(fmt.Sprintf("INSERT(%s, %s, %s, 'xyz')", str, pos, length), nil)
+			}
+		}
+		for _, newstr := range inputStrings {
+			yield(fmt.Sprintf("INSERT(%s, 2, 1, %s)", str, newstr), nil)
+		}
+	}
+}
+
+func FnField(yield Query) {
+	for _, str1 := range inputComparisonElement {
+		for _, str2 := range inputComparisonElement {
+			for _, str3 := range inputComparisonElement {
+				yield(fmt.Sprintf("FIELD(%s, %s, %s)", str1, str2, str3), nil)
+			}
+		}
+	}
+
+	for _, str1 := range inputConversions {
+		for _, str2 := range inputConversions {
+			yield(fmt.Sprintf("FIELD(%s, %s)", str1, str2), nil)
+		}
+	}
+}
+
+func FnElt(yield Query) {
+	indexes := []string{"-1", "0", "1", "2", "3", "1.5", "'2'", "NULL"}
+	for _, idx := range indexes {
+		for _, str1 := range inputStrings {
+			for _, str2 := range inputStrings {
+				yield(fmt.Sprintf("ELT(%s, %s, %s)", idx, str1, str2), nil)
+			}
+		}
+	}
+}
+
+func FnFormat(yield Query) {
+	decimals := []string{"-1", "0", "1", "2", "5", "31", "'2'", "NULL"}
+	for _, num := range inputConversions {
+		for _, d := range decimals {
+			yield(fmt.Sprintf("FORMAT(%s, %s)", num, d), nil)
+		}
+	}
+
+	cases := []string{
+		"FORMAT(12332.123456, 4)",
+		"FORMAT(12332.1, 4)",
+		"FORMAT(12332.2, 0)",
+		"FORMAT(-1234567.891, 2)",
+		"FORMAT(1234567.891e0, 2)",
+		"FORMAT(18446744073709551615, 0)",
+		"FORMAT(12332.2, 2, 'en_US')",
+		"FORMAT(12332.2, 2, NULL)",
+		"FORMAT(NULL, 2, 'de_DE')",
+		"FORMAT(12332.2, NULL, 'de_DE')",
+	}
+	for _, q := range cases {
+		yield(q, nil)
+	}
+}
+
+func FnSpace(yield Query) {
+	counts := []string{"-1", "0", "1", "3", "1.5", "'2'", "1073741825", "18446744073709551615", "NULL"}
+	for _, cnt := range counts {
+		yield(fmt.Sprintf("SPACE(%s)", cnt), nil)
+		yield(fmt.Sprintf("CONCAT('a', SPACE(%s), 'b')", cnt), nil)
+	}
+}
+
+func FnChar(yield Query) {
+	args := []string{"0", "65", "77", "256", "65536", "16777216", "4294967296", "-1", "1.5", "'66'", "NULL"}
+	for _, arg1 := range args {
+		yield(fmt.Sprintf("CHAR(%s)", arg1), nil)
+		for _, arg2 := range args {
+			yield(fmt.Sprintf("CHAR(%s, %s)", arg1, arg2), nil)
+		}
+	}
+
+	cases := []string{
+		"CHAR(77, 121, 83, 81, '76')",
+		"CHAR(77, 77.3, '77.3')",
+		"CHAR(0xC3, 0xA5 USING utf8mb4)",
+		"CHAR(0xC3 USING utf8mb4)",
+		"CHAR(50089 USING utf8mb4)",
+		"CHAR(229 USING latin1)",
+		"CHAR(65, NULL, 66 USING utf8mb4)",
+	}
+	for _, q := range cases {
+		yield(q, nil)
+	}
+}
+
 func FnHex(yield Query) {
 	for _, str := range inputStrings {
 		yield(fmt.Sprintf("hex(%s)", str), nil)
@@ -1740,6 +2010,172 @@ func FnYearWeek(yield Query) {
 	}
 }
 
+func FnDateDiff(yield Query) {
+	dates := []string{
+		"DATE'2007-12-31'", "DATE'2010-11-30'", "TIMESTAMP'2007-12-31 23:59:59'", "TIMESTAMP'2010-11-30 23:59:59.999999'",
+		"'2007-12-31 23:59:59'", "'2010-11-30'", "'0000-00-00'", "'2010-02-30'", "20101130", "NULL",
+	}
+	for _, d1 := range dates {
+		for _, d2 := range dates {
+			yield(fmt.Sprintf("DATEDIFF(%s, %s)", d1, d2), nil)
+		}
+	}
+	for _, d := range inputConversions {
+		yield(fmt.Sprintf("DATEDIFF(%s, DATE'2000-01-01')", d), nil)
+	}
+}
+
+func FnTimeDiff(yield Query) {
+	times := []string{
+		"TIME'10:00:00'", "TIME'-838:59:59'", "TIME'23:59:59.999999'", "TIMESTAMP'2008-12-31 23:59:59.000001'",
+		"TIMESTAMP'2000-01-01 00:00:00'", "DATE'2000-01-02'", "'2000:01:01 00:00:00'", "'2000:01:01 00:00:00.000001'",
+		"'1997-12-31 23:59:59.000001'", "'1997-12-30 01:01:01.000002'", "'10:00:00.5'", "NULL",
+	}
+	for _, t1 := range times {
+		for _, t2 := range times {
+			yield(fmt.Sprintf("TIMEDIFF(%s, %s)", t1, t2), nil)
+		}
+	}
+}
+
+func FnTimestampDiff(yield Query) {
+	units := []string{
+		"MICROSECOND", "SECOND", "MINUTE", "HOUR", "DAY", "WEEK", "MONTH", "QUARTER", "YEAR",
+	}
+	dates := []string{
+		"DATE'2003-02-01'", "DATE'2003-05-01'", "TIMESTAMP'2003-02-01 12:05:55.5'", "TIMESTAMP'2003-05-01 12:05:55'",
+		"'2003-01-31 23:59:59.999999'", "'2004-02-29'", "'2005-02-28 00:00:00'", "'0000-00-00'", "20030501", "NULL",
+	}
+	for _, u := range units {
+		for _, d1 := range dates {
+			for _, d2 := range dates {
+				yield(fmt.Sprintf("TIMESTAMPDIFF(%s, %s, %s)", u, d1, d2), nil)
+			}
+		}
+	}
+}
+
+func FnStrToDate(yield Query) {
+	cases := []struct {
+		str, format string
+	}{
+		{"'01,5,2013'", "'%d,%m,%Y'"},
+		{"'May 1, 2013'", "'%M %d,%Y'"},
+		{"'a09:30:17'", "'a%h:%i:%s'"},
+		{"'a09:30:17'", "'%h:%i:%s'"},
+		{"'09:30:17a'", "'%h:%i:%s'"},
+		{"'abc'", "'abc'"},
+		{"'9'", "'%m'"},
+		{"'9'", "'%s'"},
+		{"'00/00/0000'", "'%m/%d/%Y'"},
+		{"'04/31/2004'", "'%m/%d/%Y'"},
+		{"'2013-05-00'", "'%Y-%m-%d'"},
+		{"'15:35:00'", "'%H:%i:%s'"},
+		{"'10:35:00 PM'", "'%h:%i:%s %p'"},
+		{"'10:35:00.123456'", "'%H:%i:%s.%f'"},
+		{"'2023-05-21 10:35:00.123'", "'%Y-%m-%d %H:%i:%s.%f'"},
+		{"'Tuesday 15 2023'", "'%W %U %Y'"},
+		{"'200442 Monday'", "'%X%V %W'"},
+		{"'13 Jan 23'", "'%d %b %y'"},
+		{"'10th of March, 2020'", "'%D of %M, %Y'"},
+		{"'20230521'", "'%Y%m%d'"},
+		{"20230521", "'%Y%m%d'"},
+		{"NULL", "'%Y'"},
+		{"'2023'", "NULL"},
+	}
+	for _, tc := range cases {
+		yield(fmt.Sprintf("STR_TO_DATE(%s, %s)", tc.str, tc.format), nil)
+	}
+}
+
+func FnLastDay(yield Query) {
+	for _, d := range inputConversions {
+		yield(fmt.Sprintf("LAST_DAY(%s)", d), nil)
+	}
+
+	dates := []string{
+		"DATE'2003-02-05'", "DATE'2004-02-05'", "TIMESTAMP'2004-01-01 01:01:01'", "'2003-03-32'", "'2003-03-00'", "'2003-00-05'",
+	}
+	for _, d := range dates {
+		yield(fmt.Sprintf("LAST_DAY(%s)", d), nil)
+	}
+}
+
+func FnToDays(yield Query) {
+	for _, d := range inputConversions {
+		yield(fmt.Sprintf("TO_DAYS(%s)", d), nil)
+	}
+
+	dates := []string{
+		"DATE'0000-01-01'", "DATE'0001-01-01'", "DATE'2007-10-07'", "'950501'", "'2007-10-07 00:00:59'", "'0000-00-00'", "950501",
+	}
+	for _, d := range dates {
+		yield(fmt.Sprintf("TO_DAYS(%s)", d), nil)
+	}
+}
+
+func FnFromDays(yield Query) {
+	for _, d := range inputConversions {
+		yield(fmt.Sprintf("FROM_DAYS(%s)", d), nil)
+	}
+
+	days := []string{
+		"0", "1", "365", "366", "730669", "733321", "3652424", "3652500", "-1", "'730669'", "730669.5",
+	}
+	for _, d := range days {
+		yield(fmt.Sprintf("FROM_DAYS(%s)", d), nil)
+	}
+}
+
+func FnSecToTime(yield Query) {
+	for _, d := range inputConversions {
+		yield(fmt.Sprintf("SEC_TO_TIME(%s)", d), nil)
+	}
+
+	seconds := []string{
+		"0", "2378", "-2378", "3020399", "3020400", "-3020400", "3020399.5", "2378.123", "2378.1234567", "2378e0", "'2378.5'",
+	}
+	for _, s := range seconds {
+		yield(fmt.Sprintf("SEC_TO_TIME(%s)", s), nil)
+	}
+}
+
+func FnTimeToSec(yield Query) {
+	for _, d := range inputConversions {
+		yield(fmt.Sprintf("TIME_TO_SEC(%s)", d), nil)
+	}
+
+	times := []string{
+		"'22:23:00'", "'00:39:38'", "'-00:39:38'", "TIME'838:59:59'", "TIME'-10:10:10.999'", "'2023-05-21 10:00:00'",
+	}
+	for _, t := range times {
+		yield(fmt.Sprintf("TIME_TO_SEC(%s)", t), nil)
+	}
+}
+
+var periodInputs = []string{
+	"200801", "0801", "6912", "7001", "9912", "199912", "8", "201213", "200800", "0", "-1", "'200801'", "200801.6", "NULL",
+}
+
+func FnPeriodAdd(yield Query) {
+	months := []string{
+		"0", "2", "-2", "11", "12", "-24", "1000", "'3'", "2.5", "NULL",
+	}
+	for _, p := range periodInputs {
+		for _, m := range months {
+			yield(fmt.Sprintf("PERIOD_ADD(%s, %s)", p, m), nil)
+		}
+	}
+}
+
+func FnPeriodDiff(yield Query) {
+	for _, p1 := range periodInputs {
+		for _, p2 := range periodInputs {
+			yield(fmt.Sprintf("PERIOD_DIFF(%s, %s)", p1, p2), nil)
+		}
+	}
+}
+
 func FnInetAton(yield Query) {
 	for _, d := range ipInputs {
 		yield(fmt.Sprintf("INET_ATON(%s)", d), nil)
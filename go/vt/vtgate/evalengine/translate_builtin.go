@@ -20,6 +20,8 @@ import (
 	"fmt"
 	"strings"
 
+	"vitess.io/vitess/go/mysql/collations"
+	"vitess.io/vitess/go/mysql/json"
 	vtrpcpb "vitess.io/vitess/go/vt/proto/vtrpc"
 	"vitess.io/vitess/go/vt/sqlparser"
 	"vitess.io/vitess/go/vt/vterrors"
@@ -305,6 +307,52 @@ func (ast *astCompiler) translateFuncExpr(fn *sqlparser.FuncExpr) (Expr, error)
 			return nil, argError(method)
 		}
 		return &builtinRepeat{CallExpr: call, collate: ast.cfg.Collation}, nil
+	case "substr", "substring", "mid":
+		if len(args) != 2 && len(args) != 3 {
+			return nil, argError(method)
+		}
+		return &builtinSubstring{CallExpr: call, collate: ast.cfg.Collation}, nil
+	case "replace":
+		if len(args) != 3 {
+			return nil, argError(method)
+		}
+		return &builtinReplace{CallExpr: call, collate: ast.cfg.Collation}, nil
+	case "locate":
+		if len(args) != 2 && len(args) != 3 {
+			return nil, argError(method)
+		}
+		return &builtinLocate{CallExpr: call, collate: ast.cfg.Collation}, nil
+	case "instr":
+		if len(args) != 2 {
+			return nil, argError(method)
+		}
+		call.Arguments = TupleExpr{args[1], args[0]}
+		return &builtinLocate{CallExpr: call, collate: ast.cfg.Collation}, nil
+	case "reverse":
+		if len(args) != 1 {
+			return nil, argError(method)
+		}
+		return &builtinReverse{CallExpr: call, collate: ast.cfg.Collation}, nil
+	case "field":
+		if len(args) < 2 {
+			return nil, argError(method)
+		}
+		return &builtinField{CallExpr: call}, nil
+	case "elt":
+		if len(args) < 2 {
+			return nil, argError(method)
+		}
+		return &builtinElt{CallExpr: call, collate: ast.cfg.Collation}, nil
+	case "format":
+		if len(args) != 2 && len(args) != 3 {
+			return nil, argError(method)
+		}
+		return &builtinFormat{CallExpr: call, collate: ast.cfg.Collation}, nil
+	case "space":
+		if len(args) != 1 {
+			return nil, argError(method)
+		}
+		return &builtinSpace{CallExpr: call, collate: ast.cfg.Collation}, nil
 	case "concat":
 		if len(args) < 1 {
 			return nil, argError(method)
@@ -465,6 +513,56 @@ func (ast *astCompiler) translateFuncExpr(fn *sqlparser.FuncExpr) (Expr, error)
 		default:
 			return nil, argError(method)
 		}
+	case "datediff":
+		if len(args) != 2 {
+			return nil, argError(method)
+		}
+		return &builtinDateDiff{CallExpr: call}, nil
+	case "timediff":
+		if len(args) != 2 {
+			return nil, argError(method)
+		}
+		return &builtinTimeDiff{CallExpr: call}, nil
+	case "str_to_date":
+		if len(args) != 2 {
+			return nil, argError(method)
+		}
+		return &builtinStrToDate{CallExpr: call}, nil
+	case "last_day":
+		if len(args) != 1 {
+			return nil, argError(method)
+		}
+		return &builtinLastDay{CallExpr: call}, nil
+	case "to_days":
+		if len(args) != 1 {
+			return nil, argError(method)
+		}
+		return &builtinToDays{CallExpr: call}, nil
+	case "from_days":
+		if len(args) != 1 {
+			return nil, argError(method)
+		}
+		return &builtinFromDays{CallExpr: call}, nil
+	case "sec_to_time":
+		if len(args) != 1 {
+			return nil, argError(method)
+		}
+		return &builtinSecToTime{CallExpr: call}, nil
+	case "time_to_sec":
+		if len(args) != 1 {
+			return nil, argError(method)
+		}
+		return &builtinTimeToSec{CallExpr: call}, nil
+	case "period_add":
+		if len(args) != 2 {
+			return nil, argError(method)
+		}
+		return &builtinPeriodAdd{CallExpr: call}, nil
+	case "period_diff":
+		if len(args) != 2 {
+			return nil, argError(method)
+		}
+		return &builtinPeriodDiff{CallExpr: call}, nil
 	case "inet_aton":
 		if len(args) != 1 {
 			return nil, argError(method)
@@ -694,6 +792,119 @@ func (ast *astCompiler) translateCallable(call sqlparser.Callable) (Expr, error)
 			Method:    "JSON_KEYS",
 		}}, nil
 
+	case *sqlparser.JSONValueModifierExpr:
+		var modify json.Transformation
+		var method string
+		switch call.Type {
+		case sqlparser.JSONSetType:
+			modify, method = json.Set, "JSON_SET"
+		case sqlparser.JSONInsertType:
+			modify, method = json.Insert, "JSON_INSERT"
+		case sqlparser.JSONReplaceType:
+			modify, method = json.Replace, "JSON_REPLACE"
+		default:
+			return nil, translateExprNotSupported(call)
+		}
+
+		exprs := []sqlparser.Expr{call.JSONDoc}
+		for _, param := range call.Params {
+			exprs = append(exprs, param.Key, param.Value)
+		}
+		args, err := ast.translateFuncArgs(exprs)
+		if err != nil {
+			return nil, err
+		}
+		return &builtinJSONModify{
+			CallExpr: CallExpr{
+				Arguments: args,
+				Method:    method,
+			},
+			modify: modify,
+		}, nil
+
+	case *sqlparser.JSONRemoveExpr:
+		args, err := ast.translateFuncArgs(append([]sqlparser.Expr{call.JSONDoc}, call.PathList...))
+		if err != nil {
+			return nil, err
+		}
+		return &builtinJSONRemove{CallExpr: CallExpr{
+			Arguments: args,
+			Method:    "JSON_REMOVE",
+		}}, nil
+
+	case *sqlparser.JSONValueMergeExpr:
+		args, err := ast.translateFuncArgs(append([]sqlparser.Expr{call.JSONDoc}, call.JSONDocList...))
+		if err != nil {
+			return nil, err
+		}
+		switch call.Type {
+		case sqlparser.JSONMergePatchType:
+			return &builtinJSONMergePatch{CallExpr: CallExpr{
+				Arguments: args,
+				Method:    "JSON_MERGE_PATCH",
+			}}, nil
+		case sqlparser.JSONMergePreserveType:
+			return &builtinJSONMergePreserve{CallExpr: CallExpr{
+				Arguments: args,
+				Method:    "JSON_MERGE_PRESERVE",
+			}}, nil
+		default:
+			return &builtinJSONMergePreserve{CallExpr: CallExpr{
+				Arguments: args,
+				Method:    "JSON_MERGE",
+			}}, nil
+		}
+
+	case *sqlparser.JSONContainsExpr:
+		if len(call.PathList) > 1 {
+			return nil, argError("JSON_CONTAINS")
+		}
+		exprs := []sqlparser.Expr{call.Target, call.Candidate}
+		exprs = append(exprs, call.PathList...)
+		args, err := ast.translateFuncArgs(exprs)
+		if err != nil {
+			return nil, err
+		}
+		return &builtinJSONContains{CallExpr: CallExpr{
+			Arguments: args,
+			Method:    "JSON_CONTAINS",
+		}}, nil
+
+	case *sqlparser.JSONOverlapsExpr:
+		args, err := ast.translateFuncArgs([]sqlparser.Expr{call.JSONDoc1, call.JSONDoc2})
+		if err != nil {
+			return nil, err
+		}
+		return &builtinJSONOverlaps{CallExpr: CallExpr{
+			Arguments: args,
+			Method:    "JSON_OVERLAPS",
+		}}, nil
+
+	case *sqlparser.JSONSearchExpr:
+		exprs := []sqlparser.Expr{call.JSONDoc, call.OneOrAll, call.SearchStr}
+		if call.EscapeChar != nil {
+			exprs = append(exprs, call.EscapeChar)
+			exprs = append(exprs, call.PathList...)
+		}
+		args, err := ast.translateFuncArgs(exprs)
+		if err != nil {
+			return nil, err
+		}
+		return &builtinJSONSearch{CallExpr: CallExpr{
+			Arguments: args,
+			Method:    "JSON_SEARCH",
+		}}, nil
+
+	case *sqlparser.MemberOfExpr:
+		args, err := ast.translateFuncArgs([]sqlparser.Expr{call.Value, call.JSONArr})
+		if err != nil {
+			return nil, err
+		}
+		return &builtinMemberOf{CallExpr: CallExpr{
+			Arguments: args,
+			Method:    "MEMBER OF",
+		}}, nil
+
 	case *sqlparser.CurTimeFuncExpr:
 		if call.Fsp > 6 {
 			return nil, vterrors.Errorf(vtrpcpb.Code_INVALID_ARGUMENT, "Too-big precision 12 specified for '%s'. Maximum is 6.", call.Name.String())
@@ -744,6 +955,61 @@ func (ast *astCompiler) translateCallable(call sqlparser.Callable) (Expr, error)
 			trim:     call.Type,
 		}, nil
 
+	case *sqlparser.SubstrExpr:
+		exprs := []sqlparser.Expr{call.Name, call.From}
+		if call.To != nil {
+			exprs = append(exprs, call.To)
+		}
+		args, err := ast.translateFuncArgs(exprs)
+		if err != nil {
+			return nil, err
+		}
+		return &builtinSubstring{
+			CallExpr: CallExpr{Arguments: args, Method: "SUBSTRING"},
+			collate:  ast.cfg.Collation,
+		}, nil
+
+	case *sqlparser.LocateExpr:
+		exprs := []sqlparser.Expr{call.SubStr, call.Str}
+		if call.Pos != nil {
+			exprs = append(exprs, call.Pos)
+		}
+		args, err := ast.translateFuncArgs(exprs)
+		if err != nil {
+			return nil, err
+		}
+		return &builtinLocate{
+			CallExpr: CallExpr{Arguments: args, Method: "LOCATE"},
+			collate:  ast.cfg.Collation,
+		}, nil
+
+	case *sqlparser.InsertExpr:
+		args, err := ast.translateFuncArgs([]sqlparser.Expr{call.Str, call.Pos, call.Len, call.NewStr})
+		if err != nil {
+			return nil, err
+		}
+		return &builtinInsert{
+			CallExpr: CallExpr{Arguments: args, Method: "INSERT"},
+			collate:  ast.cfg.Collation,
+		}, nil
+
+	case *sqlparser.CharExpr:
+		args, err := ast.translateFuncArgs(call.Exprs)
+		if err != nil {
+			return nil, err
+		}
+		collate := collations.ID(collations.CollationBinaryID)
+		if call.Charset != "" {
+			collate, err = ast.translateConvertCharset(call.Charset, false)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return &builtinChar{
+			CallExpr: CallExpr{Arguments: args, Method: "CHAR"},
+			collate:  collate,
+		}, nil
+
 	case *sqlparser.IntervalDateExpr:
 		var err error
 		args := make([]Expr, 2)
@@ -765,6 +1031,25 @@ func (ast *astCompiler) translateCallable(call sqlparser.Callable) (Expr, error)
 			collate:  ast.cfg.Collation,
 		}, nil
 
+	case *sqlparser.TimestampDiffExpr:
+		var err error
+		args := make([]Expr, 2)
+
+		args[0], err = ast.translateExpr(call.Expr1)
+		if err != nil {
+			return nil, err
+		}
+		args[1], err = ast.translateExpr(call.Expr2)
+		if err != nil {
+			return nil, err
+		}
+
+		cexpr := CallExpr{Arguments: args, Method: "TIMESTAMPDIFF"}
+		return &builtinTimestampDiff{
+			CallExpr: cexpr,
+			unit:     call.Unit,
+		}, nil
+
 	case *sqlparser.RegexpLikeExpr:
 		input, err := ast.translateExpr(call.Expr)
 		if err != nil {
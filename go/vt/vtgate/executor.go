@@ -105,6 +105,7 @@ type Executor struct {
 	streamSize   int
 	plans        cache.Cache
 	vschemaStats *VSchemaStats
+	queryDigests *queryDigests
 
 	normalize       bool
 	warnShardedOnly bool
@@ -146,6 +147,7 @@ func NewExecutor(
 		scatterConn:     resolver.scatterConn,
 		txConn:          resolver.scatterConn.txConn,
 		plans:           cache.NewDefaultCacheImpl(cacheCfg),
+		queryDigests:    newQueryDigests(queryDigestsSize),
 		normalize:       normalize,
 		warnShardedOnly: warnOnShardedOnly,
 		streamSize:      streamSize,
@@ -186,6 +188,7 @@ func NewExecutor(
 		servenv.HTTPHandle(pathQueryPlans, e)
 		servenv.HTTPHandle(pathScatterStats, e)
 		servenv.HTTPHandle(pathVSchema, e)
+		servenv.HTTPHandle(pathQueryDigests, e)
 	})
 	return e
 }
@@ -198,8 +201,13 @@ func (e *Executor) Execute(ctx context.Context, mysqlCtx vtgateservice.MySQLConn
 	defer span.Finish()
 
 	logStats := logstats.NewLogStats(ctx, method, sql, safeSession.GetSessionUUID(), bindVars)
+	logStats.QueryAttributes = safeSession.takeQueryAttributes(forwardQueryAttributes)
 	stmtType, result, err := e.execute(ctx, mysqlCtx, safeSession, sql, bindVars, logStats)
 	logStats.Error = err
+	if gtids := safeSession.TakeCommittedGtids(); gtids != "" && result != nil {
+		// Report the GTIDs of the transactions the statement committed.
+		result.SessionStateChanges = mergeGtids(result.SessionStateChanges, gtids)
+	}
 	if result == nil {
 		saveSessionStats(safeSession, stmtType, 0, 0, 0, err)
 	} else {
@@ -216,6 +224,11 @@ func (e *Executor) Execute(ctx context.Context, mysqlCtx vtgateservice.MySQLConn
 
 	logStats.SaveEndTime()
 	QueryLogger.Send(logStats)
+	if result == nil {
+		e.queryDigests.record(logStats, 0, 0)
+	} else {
+		e.queryDigests.record(logStats, result.RowsAffected, uint64(len(result.Rows)))
+	}
 	err = vterrors.TruncateError(err, truncateErrorLen)
 	return result, err
 }
@@ -257,6 +270,7 @@ func (e *Executor) StreamExecute(
 	defer span.Finish()
 
 	logStats := logstats.NewLogStats(ctx, method, sql, safeSession.GetSessionUUID(), bindVars)
+	logStats.QueryAttributes = safeSession.takeQueryAttributes(forwardQueryAttributes)
 	srr := &streaminResultReceiver{callback: callback}
 	var err error
 
@@ -350,6 +364,7 @@ func (e *Executor) StreamExecute(
 
 	logStats.SaveEndTime()
 	QueryLogger.Send(logStats)
+	e.queryDigests.record(logStats, srr.rowsAffected, uint64(srr.rowsReturned))
 	return vterrors.TruncateError(err, truncateErrorLen)
 
 }
@@ -375,7 +390,7 @@ func saveSessionStats(safeSession *SafeSession, stmtType sqlparser.StatementType
 		safeSession.LastInsertId = insertID
 	}
 	switch stmtType {
-	case sqlparser.StmtInsert, sqlparser.StmtReplace, sqlparser.StmtUpdate, sqlparser.StmtDelete:
+	case sqlparser.StmtInsert, sqlparser.StmtReplace, sqlparser.StmtUpdate, sqlparser.StmtDelete, sqlparser.StmtRequeue:
 		safeSession.RowCount = int64(rowsAffected)
 	case sqlparser.StmtDDL, sqlparser.StmtSet, sqlparser.StmtBegin, sqlparser.StmtCommit, sqlparser.StmtRollback, sqlparser.StmtFlush:
 		safeSession.RowCount = 0
@@ -1021,13 +1036,31 @@ func (e *Executor) getPlan(
 		return nil, vterrors.VT13001("vschema not initialized")
 	}
 
-	vcursor.SetIgnoreMaxMemoryRows(sqlparser.IgnoreMaxMaxMemoryRowsDirective(stmt))
-	vcursor.SetConsolidator(sqlparser.Consolidator(stmt))
-	vcursor.SetWorkloadName(sqlparser.GetWorkloadNameFromStatement(stmt))
+	// The query attributes sent by the client can be used like the
+	// comment directives, which take precedence over them.
+	attributes := sqlparser.NewCommentDirectives(vcursor.safeSession.queryAttributes)
+
+	vcursor.SetIgnoreMaxMemoryRows(sqlparser.IgnoreMaxMaxMemoryRowsDirective(stmt) || attributes.IsSet(sqlparser.DirectiveIgnoreMaxMemoryRows))
+	consolidator := sqlparser.Consolidator(stmt)
+	if consolidator == querypb.ExecuteOptions_CONSOLIDATOR_UNSPECIFIED {
+		consolidator = attributes.Consolidator()
+	}
+	vcursor.SetConsolidator(consolidator)
+	workloadName := sqlparser.GetWorkloadNameFromStatement(stmt)
+	if workloadName == "" {
+		workloadName, _ = attributes.GetString(sqlparser.DirectiveWorkloadName, "")
+	}
+	vcursor.SetWorkloadName(workloadName)
 	priority, err := sqlparser.GetPriorityFromStatement(stmt)
 	if err != nil {
 		return nil, err
 	}
+	if priority == "" {
+		priority, err = attributes.Priority()
+		if err != nil {
+			return nil, err
+		}
+	}
 	vcursor.SetPriority(priority)
 
 	setVarComment, err := prepareSetVarComment(vcursor, stmt)
@@ -1096,17 +1129,22 @@ func (e *Executor) cacheAndBuildStatement(
 		}
 	}
 
+	// The planner may rewrite the statement, so the digest is computed first.
+	queryDigest := sqlparser.QueryDigest(stmt)
 	plan, err := planbuilder.BuildFromStmt(ctx, query, stmt, reservedVars, vcursor, bindVarNeeds, enableOnlineDDL, enableDirectDDL)
 	if err != nil {
 		return nil, err
 	}
+	plan.QueryDigest = queryDigest
 
 	plan.Warnings = vcursor.warnings
 	vcursor.warnings = nil
 
-	err = e.checkThatPlanIsValid(stmt, plan)
-	// Only cache the plan if it is valid (i.e. does not scatter)
-	if err == nil && planCachable {
+	allowScatter := sqlparser.NewCommentDirectives(vcursor.safeSession.queryAttributes).IsSet(sqlparser.DirectiveAllowScatter)
+	err = e.checkThatPlanIsValid(stmt, plan, allowScatter)
+	// Only cache the plan if it is valid (i.e. does not scatter). The query attributes
+	// are not part of the plan key, so the plan is not cached when they allow scatter.
+	if err == nil && planCachable && !allowScatter {
 		e.plans.Set(planKey, plan)
 	}
 	return plan, err
@@ -1175,6 +1213,8 @@ func (e *Executor) ServeHTTP(response http.ResponseWriter, request *http.Request
 		returnAsJSON(response, e.VSchema())
 	case pathScatterStats:
 		e.WriteScatterStats(response)
+	case pathQueryDigests:
+		returnAsJSON(response, e.queryDigests.stats())
 	default:
 		response.WriteHeader(http.StatusNotFound)
 	}
@@ -1419,8 +1459,14 @@ func (e *Executor) startVStream(ctx context.Context, rss []*srvtopo.ResolvedShar
 	return nil
 }
 
-func (e *Executor) checkThatPlanIsValid(stmt sqlparser.Statement, plan *engine.Plan) error {
-	if e.allowScatter || plan.Instructions == nil || sqlparser.AllowScatterDirective(stmt) {
+// checkThatPlanIsValid returns an error if the plan can't be executed. allowScatter
+// lets the plan scatter, like the ALLOW_SCATTER directive does, but doesn't skip
+// the rest of the validation.
+func (e *Executor) checkThatPlanIsValid(stmt sqlparser.Statement, plan *engine.Plan, allowScatter bool) error {
+	if plan.Instructions == nil {
+		return nil
+	}
+	if allowScatter || e.allowScatter || sqlparser.AllowScatterDirective(stmt) {
 		return nil
 	}
 	// we go over all the primitives in the plan, searching for a route that is of SelectScatter opcode
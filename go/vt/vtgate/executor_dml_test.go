@@ -2878,3 +2878,38 @@ func TestInsertReference(t *testing.T) {
 	_, err = executorExec(executor, "insert into TestExecutor.zip_detail(id, status) values (1, 'CLOSED')", nil)
 	require.Error(t, err)
 }
+
+// TestDeleteWithLimitAutocommit checks that the DMLs sent by a DMLWithInput in
+// autocommit mode run in the transaction opened by its input, which is
+// committed once all of them succeeded.
+func TestDeleteWithLimitAutocommit(t *testing.T) {
+	executor, sbc1, sbc2, _ := createExecutorEnv()
+	executor.pv = querypb.ExecuteOptions_Gen4
+
+	fields := sqltypes.MakeTestFields("user_id", "int64")
+	sbc1.SetResults([]*sqltypes.Result{sqltypes.MakeTestResult(fields, "1"), {RowsAffected: 1}})
+	sbc2.SetResults([]*sqltypes.Result{sqltypes.MakeTestResult(fields, "3"), {RowsAffected: 1}})
+
+	session := NewSafeSession(&vtgatepb.Session{TargetString: "@primary", Autocommit: true, TransactionMode: vtgatepb.TransactionMode_MULTI})
+	qr, err := executor.Execute(ctx, nil, "TestExecute", session, "delete from user_extra where user_id in (1, 3) order by user_id limit 2", nil)
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, qr.RowsAffected)
+	wantQueries := []*querypb.BoundQuery{{
+		Sql: "select user_extra.user_id, weight_string(user_id) from user_extra where user_id in ::__vals order by user_id asc limit :__upper_limit for update",
+		BindVariables: map[string]*querypb.BindVariable{
+			"__upper_limit": sqltypes.Int64BindVariable(2),
+			"__vals":        sqltypes.TestBindVariable([]any{int64(1)}),
+		},
+	}, {
+		Sql: "delete from user_extra where user_id in (1, 3) and user_extra.user_id in ::dml_vals order by user_id asc limit :dml_limit",
+		BindVariables: map[string]*querypb.BindVariable{
+			"__upper_limit": sqltypes.Int64BindVariable(2),
+			"dml_limit":     sqltypes.Int64BindVariable(1),
+			"dml_vals":      sqltypes.TestBindVariable([]any{int64(1)}),
+		},
+	}}
+	assertQueries(t, sbc1, wantQueries)
+	assert.EqualValues(t, 1, sbc1.CommitCount.Load())
+	assert.EqualValues(t, 1, sbc2.CommitCount.Load())
+	assert.False(t, session.InTransaction())
+}
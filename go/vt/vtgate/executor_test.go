@@ -207,6 +207,30 @@ func TestDirectTargetRewrites(t *testing.T) {
 	}})
 }
 
+func TestExecutorCommitSessionTrackGtids(t *testing.T) {
+	executor, sbc1, sbc2, _ := createExecutorEnv()
+	session := NewSafeSession(&vtgatepb.Session{TargetString: "@primary", TransactionMode: vtgatepb.TransactionMode_MULTI})
+	session.SetSessionTrackGtids(true)
+	sbc1.CommitSessionStateChanges = "ad25a1c1-71ca-11e1-9e33-c80aa9429562:5"
+	sbc2.CommitSessionStateChanges = "3e11fa47-71ca-11e1-9e33-c80aa9429562:23"
+
+	_, err := executor.Execute(ctx, nil, "TestExecute", session, "begin", nil)
+	require.NoError(t, err)
+	_, err = executor.Execute(ctx, nil, "TestExecute", session, "update user set a = 2 where id in (1, 3)", nil)
+	require.NoError(t, err)
+	qr, err := executor.Execute(ctx, nil, "TestExecute", session, "commit", nil)
+	require.NoError(t, err)
+	// The GTIDs reported by the shards are merged in the result of the commit.
+	assert.Equal(t, "3e11fa47-71ca-11e1-9e33-c80aa9429562:23,ad25a1c1-71ca-11e1-9e33-c80aa9429562:5", qr.SessionStateChanges)
+	assert.EqualValues(t, 1, sbc1.CommitCount.Load())
+	assert.EqualValues(t, 1, sbc2.CommitCount.Load())
+
+	// They are only reported once.
+	qr, err = executor.Execute(ctx, nil, "TestExecute", session, "select id from main1", nil)
+	require.NoError(t, err)
+	assert.Empty(t, qr.SessionStateChanges)
+}
+
 func TestExecutorTransactionsAutoCommit(t *testing.T) {
 	executor, _, _, sbclookup := createExecutorEnv()
 	session := NewSafeSession(&vtgatepb.Session{TargetString: "@primary", Autocommit: true, SessionUUID: "suuid"})
@@ -1857,6 +1881,52 @@ func TestGetPlanPriority(t *testing.T) {
 
 }
 
+func TestExecutorQueryAttributes(t *testing.T) {
+	executor, sbc1, _, _ := createExecutorEnv()
+	executor.allowScatter = false
+	logChan := QueryLogger.Subscribe("Test")
+	defer QueryLogger.Unsubscribe(logChan)
+
+	attributes := map[string]string{"workload_name": "batch", "PRIORITY": "10", "trace_id": "abc"}
+	session := NewSafeSession(&vtgatepb.Session{TargetString: "@primary", Options: &querypb.ExecuteOptions{}})
+	execute := func(sql string, attributes map[string]string) error {
+		session.Options.QueryAttributes = attributes
+		_, err := executor.Execute(ctx, nil, "TestExecute", session, sql, nil)
+		return err
+	}
+
+	// The query attributes are logged, and used like the comment directives,
+	// but are not forwarded to the tablets by default.
+	require.NoError(t, execute("select id from `user` where id = 1", attributes))
+	assert.Equal(t, attributes, getQueryLog(logChan).QueryAttributes)
+	options := sbc1.Options[len(sbc1.Options)-1]
+	assert.Equal(t, "batch", options.WorkloadName)
+	assert.Equal(t, "10", options.Priority)
+	assert.Nil(t, options.QueryAttributes)
+
+	// Comment directives take precedence over the query attributes.
+	require.NoError(t, execute("select /*vt+ PRIORITY=20 */ id from `user` where id = 1", attributes))
+	getQueryLog(logChan)
+	assert.Equal(t, "20", sbc1.Options[len(sbc1.Options)-1].Priority)
+
+	require.ErrorIs(t, execute("select id from `user` where id = 1", map[string]string{"priority": "something"}), sqlparser.ErrInvalidPriority)
+	getQueryLog(logChan)
+
+	// The query attributes allow this query only to scatter.
+	require.NoError(t, execute("select id from `user`", map[string]string{"allow_scatter": "true"}))
+	getQueryLog(logChan)
+	require.ErrorContains(t, execute("select id from `user`", nil), "scatter")
+	getQueryLog(logChan)
+
+	forwardQueryAttributes = true
+	defer func() {
+		forwardQueryAttributes = false
+	}()
+	require.NoError(t, execute("select id from `user` where id = 1", attributes))
+	getQueryLog(logChan)
+	assert.Equal(t, attributes, sbc1.Options[len(sbc1.Options)-1].QueryAttributes)
+}
+
 func TestPassthroughDDL(t *testing.T) {
 	executor, sbc1, sbc2, _ := createExecutorEnv()
 	primarySession.TargetString = "TestExecutor"
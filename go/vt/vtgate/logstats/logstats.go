@@ -56,7 +56,10 @@ type LogStats struct {
 	TablesUsed     []string
 	SessionUUID    string
 	CachedPlan     bool
+	QueryDigest    string // QueryDigest is the digest text of the query, see sqlparser.QueryDigest
 	ActiveKeyspace string // ActiveKeyspace is the selected keyspace `use ks`
+	// QueryAttributes are the query attributes sent by the client with the query.
+	QueryAttributes map[string]string
 }
 
 // NewLogStats constructs a new LogStats with supplied Method and ctx
@@ -136,14 +139,28 @@ func (stats *LogStats) Logf(w io.Writer, params url.Values) error {
 		}
 	}()
 
+	jsonFormat := streamlog.GetQueryLogFormat() == streamlog.QueryLogFormatJSON
 	formattedBindVars := "\"[REDACTED]\""
+	formattedQueryAttributes := formattedBindVars
 	if !streamlog.GetRedactDebugUIQueries() {
 		_, fullBindParams := params["full"]
 		formattedBindVars = sqltypes.FormatBindVariables(
 			stats.BindVariables,
 			fullBindParams,
-			streamlog.GetQueryLogFormat() == streamlog.QueryLogFormatJSON,
+			jsonFormat,
 		)
+
+		if jsonFormat {
+			queryAttributes := stats.QueryAttributes
+			if queryAttributes == nil {
+				queryAttributes = map[string]string{}
+			}
+			marshalled, err := json.Marshal(queryAttributes)
+			if err != nil {
+				return err
+			}
+			formattedQueryAttributes = string(marshalled)
+		}
 	}
 
 	// TODO: remove username here we fully enforce immediate caller id
@@ -154,7 +171,7 @@ func (stats *LogStats) Logf(w io.Writer, params url.Values) error {
 	case streamlog.QueryLogFormatText:
 		fmtString = "%v\t%v\t%v\t'%v'\t'%v'\t%v\t%v\t%.6f\t%.6f\t%.6f\t%.6f\t%v\t%q\t%v\t%v\t%v\t%q\t%q\t%q\t%v\t%v\t%q\n"
 	case streamlog.QueryLogFormatJSON:
-		fmtString = "{\"Method\": %q, \"RemoteAddr\": %q, \"Username\": %q, \"ImmediateCaller\": %q, \"Effective Caller\": %q, \"Start\": \"%v\", \"End\": \"%v\", \"TotalTime\": %.6f, \"PlanTime\": %v, \"ExecuteTime\": %v, \"CommitTime\": %v, \"StmtType\": %q, \"SQL\": %q, \"BindVars\": %v, \"ShardQueries\": %v, \"RowsAffected\": %v, \"Error\": %q, \"TabletType\": %q, \"SessionUUID\": %q, \"Cached Plan\": %v, \"TablesUsed\": %v, \"ActiveKeyspace\": %q}\n"
+		fmtString = "{\"Method\": %q, \"RemoteAddr\": %q, \"Username\": %q, \"ImmediateCaller\": %q, \"Effective Caller\": %q, \"Start\": \"%v\", \"End\": \"%v\", \"TotalTime\": %.6f, \"PlanTime\": %v, \"ExecuteTime\": %v, \"CommitTime\": %v, \"StmtType\": %q, \"SQL\": %q, \"BindVars\": %v, \"ShardQueries\": %v, \"RowsAffected\": %v, \"Error\": %q, \"TabletType\": %q, \"SessionUUID\": %q, \"Cached Plan\": %v, \"TablesUsed\": %v, \"ActiveKeyspace\": %q, \"QueryAttributes\": %v}\n"
 	}
 
 	tables := stats.TablesUsed
@@ -165,9 +182,7 @@ func (stats *LogStats) Logf(w io.Writer, params url.Values) error {
 	if marshalErr != nil {
 		return marshalErr
 	}
-	_, err := fmt.Fprintf(
-		w,
-		fmtString,
+	args := []any{
 		stats.Method,
 		remoteAddr,
 		username,
@@ -190,7 +205,12 @@ func (stats *LogStats) Logf(w io.Writer, params url.Values) error {
 		stats.CachedPlan,
 		string(tablesUsed),
 		stats.ActiveKeyspace,
-	)
-
+	}
+	// The query attributes are only logged in JSON, which keeps the columns
+	// of the text format unchanged for the existing log parsers.
+	if jsonFormat {
+		args = append(args, formattedQueryAttributes)
+	}
+	_, err := fmt.Fprintf(w, fmtString, args...)
 	return err
 }
@@ -307,6 +307,7 @@ func (e *Executor) logPlanningFinished(logStats *logstats.LogStats, plan *engine
 	execStart := time.Now()
 	if plan != nil {
 		logStats.StmtType = plan.Type.String()
+		logStats.QueryDigest = plan.QueryDigest
 	}
 	logStats.PlanTime = execStart.Sub(logStats.StartTime)
 	return execStart
@@ -291,6 +291,8 @@ func createInstructionFor(ctx context.Context, query string, stmt sqlparser.Stat
 		return buildStreamPlan(stmt, vschema)
 	case *sqlparser.VStream:
 		return buildVStreamPlan(stmt, vschema)
+	case *sqlparser.Requeue:
+		return buildRequeuePlan(stmt, vschema)
 	case *sqlparser.PrepareStmt:
 		return prepareStmt(ctx, vschema, stmt)
 	case *sqlparser.DeallocateStmt:
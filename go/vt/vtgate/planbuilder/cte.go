@@ -0,0 +1,246 @@
+/*
+Copyright 2023 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package planbuilder
+
+import (
+	"sort"
+	"strings"
+
+	"vitess.io/vitess/go/vt/sqlparser"
+	"vitess.io/vitess/go/vt/vterrors"
+	"vitess.io/vitess/go/vt/vtgate/engine"
+	"vitess.io/vitess/go/vt/vtgate/planbuilder/operators"
+	"vitess.io/vitess/go/vt/vtgate/planbuilder/plancontext"
+	"vitess.io/vitess/go/vt/vtgate/vindexes"
+)
+
+// errRecursiveCTE is returned by inlineCTEs when the query has a recursive common table expression
+var errRecursiveCTE = vterrors.VT12001("recursive common table expression")
+
+// cteScope holds the common table expressions that are visible at a point in the query, keyed by name
+type cteScope map[string]*sqlparser.CommonTableExpr
+
+// withClause returns a pointer to the WITH clause field of the statements that can have one
+func withClause(node sqlparser.SQLNode) **sqlparser.With {
+	switch node := node.(type) {
+	case *sqlparser.Select:
+		return &node.With
+	case *sqlparser.Union:
+		return &node.With
+	case *sqlparser.Update:
+		return &node.With
+	case *sqlparser.Delete:
+		return &node.With
+	}
+	return nil
+}
+
+// hasCTEs returns true if the statement, or any statement nested inside of it, has a WITH clause
+func hasCTEs(stmt sqlparser.Statement) bool {
+	found := false
+	_ = sqlparser.Walk(func(node sqlparser.SQLNode) (bool, error) {
+		_, isWith := node.(*sqlparser.With)
+		found = found || isWith
+		return !found, nil
+	}, stmt)
+	return found
+}
+
+// visitTableRefs walks the query and calls visit for every table reference by name.
+// When the name refers to a common table expression visible in the current scope, the CTE is passed in,
+// together with a flag telling if the reference is made from inside the CTEs own definition.
+// Nested WITH clauses shadow the CTEs of the enclosing scopes, like they do in MySQL.
+func visitTableRefs(
+	node sqlparser.SQLNode,
+	scope cteScope,
+	defining []*sqlparser.CommonTableExpr,
+	visit func(tbl *sqlparser.AliasedTableExpr, cte *sqlparser.CommonTableExpr, selfRef bool) error,
+) error {
+	var err error
+	_ = sqlparser.SafeRewrite(node, func(node, _ sqlparser.SQLNode) bool {
+		if err != nil {
+			return false
+		}
+		if with := withClause(node); with != nil && *with != nil {
+			clause := *with
+			inner := make(cteScope, len(scope)+len(clause.CTEs()))
+			for name, cte := range scope {
+				inner[name] = cte
+			}
+			for _, cte := range clause.CTEs() {
+				if clause.Recursive {
+					// a recursive CTE can reference itself from its own definition
+					inner[cte.ID.String()] = cte
+				}
+				err = visitTableRefs(cte.Subquery, inner, append(defining, cte), visit)
+				if err != nil {
+					return false
+				}
+				inner[cte.ID.String()] = cte
+			}
+
+			// we've handled the WITH clause, so we remove it while visiting the rest of the statement
+			*with = nil
+			err = visitTableRefs(node, inner, defining, visit)
+			*with = clause
+			return false
+		}
+
+		tbl, ok := node.(*sqlparser.AliasedTableExpr)
+		if !ok {
+			return true
+		}
+		name, ok := tbl.Expr.(sqlparser.TableName)
+		if !ok {
+			return true
+		}
+		var cte *sqlparser.CommonTableExpr
+		if name.Qualifier.IsEmpty() {
+			cte = scope[name.Name.String()]
+		}
+		selfRef := false
+		for _, d := range defining {
+			selfRef = selfRef || d == cte
+		}
+		err = visit(tbl, cte, selfRef)
+		// the visitor may have replaced a CTE reference with the CTE definition, which was visited
+		// already in the scope where it was defined, so we don't visit it again
+		return err == nil && cte == nil
+	}, nil)
+	return err
+}
+
+// inlineCTEs replaces every reference to a common table expression with a derived table,
+// and removes the WITH clauses from the statement. After this, the rest of the planner
+// only has to deal with derived tables, which it can either merge into a route or evaluate at the vtgate level.
+// Recursive CTEs can't be inlined, and will return errRecursiveCTE.
+func inlineCTEs(stmt sqlparser.Statement) error {
+	err := visitTableRefs(stmt, nil, nil, func(tbl *sqlparser.AliasedTableExpr, cte *sqlparser.CommonTableExpr, selfRef bool) error {
+		if cte == nil {
+			return nil
+		}
+		if selfRef {
+			return errRecursiveCTE
+		}
+		if tbl.As.IsEmpty() {
+			tbl.As = cte.ID
+		}
+		if len(tbl.Columns) == 0 {
+			tbl.Columns = cte.Columns
+		}
+		// every reference gets its own copy of the CTE, just like a derived table would
+		tbl.Expr = &sqlparser.DerivedTable{Select: sqlparser.CloneSelectStatement(cte.Subquery.Select)}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	_ = sqlparser.Walk(func(node sqlparser.SQLNode) (bool, error) {
+		if with := withClause(node); with != nil {
+			*with = nil
+		}
+		return true, nil
+	}, stmt)
+	return nil
+}
+
+// planRecursiveCTE plans a query with recursive common table expressions.
+// These can't be turned into derived tables, so we can only support them when the whole query
+// can be sent to a single keyspace without changes - all tables have to be either unsharded
+// tables from the same keyspace, or reference tables.
+func planRecursiveCTE(stmt sqlparser.SelectStatement, vschema plancontext.VSchema) (*planResult, error) {
+	var ks *vindexes.Keyspace
+	allReference := true
+	tableNames := map[string]any{}
+	err := visitTableRefs(stmt, nil, nil, func(tbl *sqlparser.AliasedTableExpr, cte *sqlparser.CommonTableExpr, _ bool) error {
+		tableName := tbl.Expr.(sqlparser.TableName)
+		if cte != nil || tableName.Name.String() == "dual" {
+			return nil
+		}
+		vschemaTable, _, _, _, err := vschema.FindTable(tableName)
+		if err != nil {
+			return err
+		}
+		if ks != nil && ks.Name != vschemaTable.Keyspace.Name {
+			return vterrors.VT12001("recursive common table expression using tables from different keyspaces")
+		}
+		ks = vschemaTable.Keyspace
+		allReference = allReference && vschemaTable.Type == vindexes.TypeReference
+		if ks.Sharded && !allReference {
+			return vterrors.VT12001("recursive common table expression in a sharded keyspace")
+		}
+
+		if vschemaTable.Name.String() != tableName.Name.String() && tbl.As.IsEmpty() {
+			// keep the name used in the query working when the table has been routed
+			tbl.As = tableName.Name
+		}
+		tbl.Expr = sqlparser.TableName{Name: vschemaTable.Name}
+		tableNames[vschemaTable.Name.String()] = nil
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if ks == nil {
+		// the query only uses CTEs and dual, so any keyspace can answer it
+		ks, err = vschema.AnyKeyspace()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	sqlparser.SafeRewrite(stmt, nil, func(cursor *sqlparser.Cursor) bool {
+		switch node := cursor.Node().(type) {
+		case sqlparser.SelectExpr:
+			removeKeyspaceFromSelectExpr(node)
+		case sqlparser.TableName:
+			cursor.Replace(sqlparser.TableName{
+				Name: node.Name,
+			})
+		}
+		return true
+	})
+
+	opcode := engine.Unsharded
+	if ks.Sharded {
+		opcode = engine.Reference
+	}
+
+	var names []string
+	var used []string
+	for name := range tableNames {
+		names = append(names, sqlparser.String(sqlparser.NewIdentifierCS(name)))
+		used = append(used, operators.QualifiedString(ks, name))
+	}
+	sort.Strings(names)
+	sort.Strings(used)
+
+	buffer := sqlparser.NewTrackedBuffer(sqlparser.FormatImpossibleQuery)
+	fieldQuery := buffer.WriteNode(stmt).ParsedQuery().Query
+	route := &engine.Route{
+		RoutingParameters: &engine.RoutingParameters{
+			Opcode:   opcode,
+			Keyspace: ks,
+		},
+		TableName:  strings.Join(names, ", "),
+		Query:      sqlparser.String(stmt),
+		FieldQuery: fieldQuery,
+	}
+	return newPlanResult(route, used...), nil
+}
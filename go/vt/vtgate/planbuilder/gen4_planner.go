@@ -53,15 +53,17 @@ func gen4SelectStmtPlanner(
 	reservedVars *sqlparser.ReservedVars,
 	vschema plancontext.VSchema,
 ) (*planResult, error) {
-	switch node := stmt.(type) {
-	case *sqlparser.Select:
-		if node.With != nil {
-			return nil, vterrors.VT12001("WITH expression in SELECT statement")
+	if hasCTEs(stmt) {
+		inlined := sqlparser.CloneSelectStatement(stmt)
+		err := inlineCTEs(inlined)
+		if err == errRecursiveCTE {
+			// recursive CTEs can't be inlined, but the query might still be sent as is to a single keyspace
+			return planRecursiveCTE(stmt, vschema)
 		}
-	case *sqlparser.Union:
-		if node.With != nil {
-			return nil, vterrors.VT12001("WITH expression in UNION statement")
+		if err != nil {
+			return nil, err
 		}
+		stmt = inlined
 	}
 
 	sel, isSel := stmt.(*sqlparser.Select)
@@ -82,6 +84,15 @@ func gen4SelectStmtPlanner(
 			return newPlanResult(p, used), nil
 		}
 
+		// handle the query digests virtual table, which is also read at vtgate.
+		p, err = handleQueryDigestsSelect(sel, vschema)
+		if err != nil {
+			return nil, err
+		}
+		if p != nil {
+			return newPlanResult(p), nil
+		}
+
 		if sel.SQLCalcFoundRows && sel.Limit != nil {
 			return gen4planSQLCalcFoundRows(vschema, sel, query, reservedVars)
 		}
@@ -185,6 +196,9 @@ func newBuildSelectPlan(
 	vschema.PlannerWarning(semTable.Warning)
 
 	ctx := plancontext.NewPlanningContext(reservedVars, semTable, vschema, version)
+	if cmt, ok := selStmt.(sqlparser.Commented); ok {
+		ctx.AllowHashJoin = cmt.GetParsedComments().Directives().IsSet(sqlparser.DirectiveAllowHashJoin)
+	}
 
 	if ks, _ := semTable.SingleUnshardedKeyspace(); ks != nil {
 		plan, tablesUsed, err = selectUnshardedShortcut(ctx, selStmt, ks)
@@ -261,8 +275,8 @@ func gen4UpdateStmtPlanner(
 	reservedVars *sqlparser.ReservedVars,
 	vschema plancontext.VSchema,
 ) (*planResult, error) {
-	if updStmt.With != nil {
-		return nil, vterrors.VT12001("WITH expression in UPDATE statement")
+	if err := inlineCTEs(updStmt); err != nil {
+		return nil, err
 	}
 
 	ksName := ""
@@ -334,8 +348,8 @@ func gen4DeleteStmtPlanner(
 	reservedVars *sqlparser.ReservedVars,
 	vschema plancontext.VSchema,
 ) (*planResult, error) {
-	if deleteStmt.With != nil {
-		return nil, vterrors.VT12001("WITH expression in DELETE statement")
+	if err := inlineCTEs(deleteStmt); err != nil {
+		return nil, err
 	}
 
 	var err error
@@ -647,6 +661,8 @@ func setDirective(prim engine.Primitive, msac bool, timeout int) {
 	case *engine.Delete:
 		edml.MultiShardAutocommit = msac
 		edml.QueryTimeout = timeout
+	case *engine.DMLWithInput:
+		setDirective(edml.DML, msac, timeout)
 	}
 }
 
@@ -656,18 +672,9 @@ func checkIfDeleteSupported(del *sqlparser.Delete, semTable *semantics.SemTable)
 		return semTable.NotUnshardedErr
 	}
 
-	// Delete is only supported for a single TableExpr which is supposed to be an aliased expression
-	multiShardErr := vterrors.VT12001("multi-shard or vindex write statement")
-	if len(del.TableExprs) != 1 {
-		return multiShardErr
-	}
-	_, isAliasedExpr := del.TableExprs[0].(*sqlparser.AliasedTableExpr)
-	if !isAliasedExpr {
-		return multiShardErr
-	}
-
+	// A DELETE joining other tables is planned as a DMLWithInput, which only supports a single target table.
 	if len(del.Targets) > 1 {
-		return vterrors.VT12001("multi-table DELETE statement in a sharded keyspace")
+		return vterrors.VT12001("multi-table DELETE statement with multiple targets in a sharded keyspace")
 	}
 
 	err := sqlparser.Walk(func(node sqlparser.SQLNode) (kontinue bool, err error) {
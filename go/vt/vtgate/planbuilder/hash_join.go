@@ -19,11 +19,10 @@ package planbuilder
 import (
 	"fmt"
 
-	"vitess.io/vitess/go/mysql/collations"
-	querypb "vitess.io/vitess/go/vt/proto/query"
 	"vitess.io/vitess/go/vt/sqlparser"
 	"vitess.io/vitess/go/vt/vterrors"
 	"vitess.io/vitess/go/vt/vtgate/engine"
+	"vitess.io/vitess/go/vt/vtgate/evalengine"
 	"vitess.io/vitess/go/vt/vtgate/planbuilder/plancontext"
 	"vitess.io/vitess/go/vt/vtgate/semantics"
 )
@@ -41,13 +40,15 @@ type hashJoin struct {
 
 	Cols []int
 
-	// The keys correspond to the column offset in the inputs where
-	// the join columns can be found
-	LHSKey, RHSKey int
+	// Keys are the equality comparisons the rows are joined by
+	Keys []engine.HashJoinKey
 
-	ComparisonType querypb.Type
+	// The join condition. Used for plan descriptions
+	ASTPred sqlparser.Expr
 
-	Collation collations.ID
+	// Filter is the part of the join condition evaluated for the rows with matching keys
+	Filter     evalengine.Expr
+	FilterCols []int
 }
 
 // WireupGen4 implements the logicalPlan interface
@@ -62,14 +63,14 @@ func (hj *hashJoin) WireupGen4(ctx *plancontext.PlanningContext) error {
 // Primitive implements the logicalPlan interface
 func (hj *hashJoin) Primitive() engine.Primitive {
 	return &engine.HashJoin{
-		Left:           hj.Left.Primitive(),
-		Right:          hj.Right.Primitive(),
-		Cols:           hj.Cols,
-		Opcode:         hj.Opcode,
-		LHSKey:         hj.LHSKey,
-		RHSKey:         hj.RHSKey,
-		ComparisonType: hj.ComparisonType,
-		Collation:      hj.Collation,
+		Left:       hj.Left.Primitive(),
+		Right:      hj.Right.Primitive(),
+		Cols:       hj.Cols,
+		Opcode:     hj.Opcode,
+		Keys:       hj.Keys,
+		ASTPred:    hj.ASTPred,
+		Filter:     hj.Filter,
+		FilterCols: hj.FilterCols,
 	}
 }
 
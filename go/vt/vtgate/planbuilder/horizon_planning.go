@@ -367,7 +367,7 @@ func (hp *horizonPlanning) planAggrUsingOA(
 
 func unsupportedAggregations(aggrs []operators.Aggr) error {
 	for _, aggr := range aggrs {
-		if aggr.OpCode == popcode.AggregateGroupConcat {
+		if aggr.OpCode == popcode.AggregateGroupConcat || aggr.OpCode.NeedsPartialResults() {
 			return vterrors.VT12001(fmt.Sprintf("in scatter query: aggregation function '%s'", sqlparser.String(aggr.Func)))
 		}
 	}
@@ -760,14 +760,8 @@ func (hp *horizonPlanning) planOrderByForHashJoin(ctx *plancontext.PlanningConte
 		plan.Right = rhs
 		return plan, nil
 	}
-	if orderExprsDependsOnTableSet(orderExprs, ctx.SemTable, plan.Right.ContainsTables()) {
-		newRight, err := hp.planOrderBy(ctx, orderExprs, plan.Right)
-		if err != nil {
-			return nil, err
-		}
-		plan.Right = newRight
-		return plan, nil
-	}
+	// the hash join does not keep the order of its inputs: unmatched rows of left joins
+	// and spilled partitions are returned after the rest, so we always sort on vtgate
 	sortPlan, err := hp.createMemorySortPlan(ctx, plan, orderExprs, true)
 	if err != nil {
 		return nil, err
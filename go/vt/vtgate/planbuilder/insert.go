@@ -21,6 +21,8 @@ import (
 	"strconv"
 	"strings"
 
+	"golang.org/x/exp/slices"
+
 	"vitess.io/vitess/go/sqltypes"
 	"vitess.io/vitess/go/vt/sqlparser"
 	"vitess.io/vitess/go/vt/vterrors"
@@ -65,9 +67,6 @@ func buildInsertPlan(string) stmtPlanner {
 		if !rb.eroute.Keyspace.Sharded {
 			return buildInsertUnshardedPlan(ins, vschemaTable, reservedVars, vschema)
 		}
-		if ins.Action == sqlparser.ReplaceAct {
-			return nil, vterrors.VT12001("REPLACE INTO with sharded keyspace")
-		}
 		return buildInsertShardedPlan(ins, vschemaTable, reservedVars, vschema)
 	}
 }
@@ -140,6 +139,7 @@ func buildInsertShardedPlan(ins *sqlparser.Insert, table *vindexes.Table, reserv
 	tc := &tableCollector{}
 	tc.addVindexTable(table)
 	eins.Ignore = bool(ins.Ignore)
+	eins.Replace = ins.Action == sqlparser.ReplaceAct
 	if ins.OnDup != nil {
 		if isVindexChanging(sqlparser.UpdateExprs(ins.OnDup), eins.Table.ColumnVindexes) {
 			return nil, vterrors.VT12001("DML cannot update vindex column")
@@ -198,6 +198,11 @@ func buildInsertShardedPlan(ins *sqlparser.Insert, table *vindexes.Table, reserv
 		}
 	}
 	eins.VindexValues = routeValues
+	if eins.Replace {
+		if err := planReplaceKeys(ins, eins, eins.ColVindexes); err != nil {
+			return nil, err
+		}
+	}
 	eins.Query = generateQuery(ins)
 	eins.Prefix, eins.Mid, eins.Suffix = generateInsertShardedQuery(ins)
 	return newPlanResult(eins, tc.getTables()...), nil
@@ -242,6 +247,11 @@ func buildInsertSelectPlan(ins *sqlparser.Insert, table *vindexes.Table, reserve
 	if err != nil {
 		return nil, err
 	}
+	if eins.Replace {
+		if err := planReplaceKeys(ins, eins, eins.ColVindexes); err != nil {
+			return nil, err
+		}
+	}
 
 	eins.Prefix, _, eins.Suffix = generateInsertShardedQuery(ins)
 	return newPlanResult(eins, tc.getTables()...), nil
@@ -314,6 +324,16 @@ func applyCommentDirectives(ins *sqlparser.Insert, eins *engine.Insert) {
 		eins.MultiShardAutocommit = true
 	}
 	eins.QueryTimeout = queryTimeout(directives)
+	eins.BatchSize = insertSelectBatchSize(directives)
+}
+
+// insertSelectBatchSize returns DirectiveInsertSelectBatchSize value if set, otherwise returns 0.
+func insertSelectBatchSize(d *sqlparser.CommentDirectives) int {
+	val, _ := d.GetString(sqlparser.DirectiveInsertSelectBatchSize, "0")
+	if intVal, err := strconv.Atoi(val); err == nil && intVal > 0 {
+		return intVal
+	}
+	return 0
 }
 
 func getColVindexes(allColVindexes []*vindexes.ColumnVindex) (colVindexes []*vindexes.ColumnVindex) {
@@ -326,6 +346,86 @@ func getColVindexes(allColVindexes []*vindexes.ColumnVindex) (colVindexes []*vin
 	return
 }
 
+// planReplaceKeys sets the primary and unique keys of the table on a sharded
+// REPLACE, with each column located in the column vindexes or, when it is not a
+// vindex column, in ReplaceColumns. The keys are only needed when there are owned
+// lookup vindexes, whose entries must be deleted for the rows that the REPLACE
+// overwrites, and those rows can only be found if the keys are known and every
+// key column is set by the statement. The values of the key columns that are not
+// vindex columns are taken from the rows like the values of the vindex columns.
+func planReplaceKeys(ins *sqlparser.Insert, eins *engine.Insert, colVindexes []*vindexes.ColumnVindex) error {
+	owned := false
+	for vIdx := 1; vIdx < len(colVindexes); vIdx++ {
+		owned = owned || colVindexes[vIdx].Owned
+	}
+	if !owned {
+		return nil
+	}
+	table := eins.Table
+	if table.UniqueKeys == nil {
+		return vterrors.VT12001(fmt.Sprintf("REPLACE INTO with owned lookup vindexes when the keys of table '%s' are not known to the schema tracker", table.Name.String()))
+	}
+	keys := make([][]engine.VindexColumn, 0, len(table.UniqueKeys))
+	var replaceCols []sqlparser.IdentifierCI
+	for _, uk := range table.UniqueKeys {
+		key := make([]engine.VindexColumn, 0, len(uk))
+		for _, colName := range uk {
+			col, ok := findVindexColumn(colVindexes, colName)
+			if !ok {
+				if findColumn(ins, colName) == -1 {
+					return vterrors.VT12001(fmt.Sprintf("REPLACE INTO with owned lookup vindexes when column '%s' of a unique key of table '%s' is not set", colName.String(), table.Name.String()))
+				}
+				colIdx := slices.IndexFunc(replaceCols, colName.Equal)
+				if colIdx == -1 {
+					colIdx = len(replaceCols)
+					replaceCols = append(replaceCols, colName)
+				}
+				col = engine.VindexColumn{VindexIdx: -1, ColumnIdx: colIdx}
+			}
+			key = append(key, col)
+		}
+		keys = append(keys, key)
+	}
+	eins.ReplaceKeys = keys
+	if len(replaceCols) == 0 {
+		return nil
+	}
+	eins.ReplaceColumns = replaceCols
+
+	rows, isRowValues := ins.Rows.(sqlparser.Values)
+	if !isRowValues {
+		for _, col := range replaceCols {
+			eins.ReplaceValueOffset = append(eins.ReplaceValueOffset, findColumn(ins, col))
+		}
+		return nil
+	}
+	for _, col := range replaceCols {
+		colNum := findColumn(ins, col)
+		values := make([]evalengine.Expr, 0, len(rows))
+		for rowNum, row := range rows {
+			expr, err := evalengine.Translate(row[colNum], nil)
+			if err != nil {
+				return err
+			}
+			values = append(values, expr)
+			row[colNum] = sqlparser.NewArgument(engine.InsertVarName(col, rowNum))
+		}
+		eins.ReplaceValues = append(eins.ReplaceValues, values)
+	}
+	return nil
+}
+
+func findVindexColumn(colVindexes []*vindexes.ColumnVindex, name sqlparser.IdentifierCI) (engine.VindexColumn, bool) {
+	for vIdx, colVindex := range colVindexes {
+		for colIdx, col := range colVindex.Columns {
+			if col.Equal(name) {
+				return engine.VindexColumn{VindexIdx: vIdx, ColumnIdx: colIdx}, true
+			}
+		}
+	}
+	return engine.VindexColumn{}, false
+}
+
 func extractColVindexOffsets(ins *sqlparser.Insert, colVindexes []*vindexes.ColumnVindex) ([][]int, error) {
 	vv := make([][]int, len(colVindexes))
 	for idx, colVindex := range colVindexes {
@@ -43,6 +43,8 @@ func transformToLogicalPlan(ctx *plancontext.PlanningContext, op ops.Operator, i
 		return transformRoutePlan(ctx, op)
 	case *operators.ApplyJoin:
 		return transformApplyJoinPlan(ctx, op)
+	case *operators.HashJoin:
+		return transformHashJoin(ctx, op)
 	case *operators.Union:
 		return transformUnionPlan(ctx, op, isRoot)
 	case *operators.Vindex:
@@ -65,6 +67,10 @@ func transformToLogicalPlan(ctx *plancontext.PlanningContext, op ops.Operator, i
 		return transformAggregator(ctx, op)
 	case *operators.Distinct:
 		return transformDistinct(ctx, op)
+	case *operators.Window:
+		return transformWindow(ctx, op)
+	case *operators.DMLWithInput:
+		return transformDMLWithInput(ctx, op)
 	}
 
 	return nil, vterrors.VT13001(fmt.Sprintf("unknown type encountered: %T (transformToLogicalPlan)", op))
@@ -93,6 +99,8 @@ func transformAggregator(ctx *plancontext.PlanningContext, op *operators.Aggrega
 		aggrParam.OrigOpcode = aggr.OriginalOpCode
 		aggrParam.WCol = aggr.WSOffset
 		aggrParam.CollationID = aggr.GetCollation(ctx)
+		aggrParam.SumCol = aggr.SumOffset
+		aggrParam.VarCol = aggr.VarOffset
 		oa.aggregates = append(oa.aggregates, aggrParam)
 	}
 	for _, groupBy := range op.Grouping {
@@ -111,6 +119,58 @@ func transformAggregator(ctx *plancontext.PlanningContext, op *operators.Aggrega
 	return oa, nil
 }
 
+func transformWindow(ctx *plancontext.PlanningContext, op *operators.Window) (logicalPlan, error) {
+	plan, err := transformToLogicalPlan(ctx, op.Source, false)
+	if err != nil {
+		return nil, err
+	}
+
+	primitive := &engine.Window{
+		TruncateColumnCount: op.ResultColumns,
+	}
+	for _, fn := range op.Functions {
+		param := &engine.WindowParams{
+			Opcode:   fn.OpCode,
+			Col:      -1,
+			Offset:   fn.N,
+			Alias:    fn.Original.ColumnName(),
+			Original: fn.Original,
+		}
+		if fn.Arg != nil {
+			param.Col = fn.ArgOffset
+		}
+		if fn.Default != nil {
+			param.Default, err = evalengine.Translate(fn.Default, nil)
+			if err != nil {
+				return nil, err
+			}
+		}
+		primitive.Functions = append(primitive.Functions, param)
+	}
+	for _, by := range op.PartitionBy {
+		primitive.PartitionBy = append(primitive.PartitionBy, &engine.GroupByParams{
+			KeyCol:          by.ColOffset,
+			WeightStringCol: by.WSOffset,
+			Expr:            by.SimplifiedExpr,
+			CollationID:     ctx.SemTable.CollationForExpr(by.SimplifiedExpr),
+		})
+	}
+	for idx, order := range op.Order {
+		primitive.OrderBy = append(primitive.OrderBy, engine.OrderByParams{
+			Col:             op.Offset[idx],
+			WeightStringCol: op.WOffset[idx],
+			Desc:            order.Inner.Direction == sqlparser.DescOrder,
+			CollationID:     ctx.SemTable.CollationForExpr(order.SimplifiedExpr),
+		})
+	}
+
+	return &window{
+		logicalPlanCommon: newBuilderCommon(plan),
+		functions:         op.Functions,
+		eWindow:           primitive,
+	}, nil
+}
+
 func transformDistinct(ctx *plancontext.PlanningContext, op *operators.Distinct) (logicalPlan, error) {
 	src, err := transformToLogicalPlan(ctx, op.Source, false)
 	if err != nil {
@@ -334,6 +394,42 @@ func transformApplyJoinPlan(ctx *plancontext.PlanningContext, n *operators.Apply
 	}, nil
 }
 
+func transformHashJoin(ctx *plancontext.PlanningContext, op *operators.HashJoin) (logicalPlan, error) {
+	lhs, err := transformToLogicalPlan(ctx, op.LHS, false)
+	if err != nil {
+		return nil, err
+	}
+	rhs, err := transformToLogicalPlan(ctx, op.RHS, false)
+	if err != nil {
+		return nil, err
+	}
+	opCode := engine.InnerJoin
+	if op.LeftJoin {
+		opCode = engine.LeftJoin
+	}
+
+	keys := make([]engine.HashJoinKey, 0, len(op.JoinComparisons))
+	for i, cmp := range op.JoinComparisons {
+		keys = append(keys, engine.HashJoinKey{
+			LHS:            op.LHSKeys[i],
+			RHS:            op.RHSKeys[i],
+			Collation:      cmp.Collation,
+			ComparisonType: cmp.ComparisonType,
+		})
+	}
+
+	return &hashJoin{
+		Left:       lhs,
+		Right:      rhs,
+		Opcode:     opCode,
+		Cols:       op.ColumnOffsets,
+		Keys:       keys,
+		ASTPred:    op.Predicate,
+		Filter:     op.Filter,
+		FilterCols: op.FilterColumns,
+	}, nil
+}
+
 func routeToEngineRoute(ctx *plancontext.PlanningContext, op *operators.Route) (*engine.Route, error) {
 	tableNames, err := getAllTableNames(op)
 	if err != nil {
@@ -409,12 +505,22 @@ func transformInsertPlan(ctx *plancontext.PlanningContext, op *operators.Route,
 		Keyspace:          op.Routing.Keyspace(),
 		Table:             ins.VTable,
 		Ignore:            ins.Ignore,
+		Replace:           ins.AST.Action == sqlparser.ReplaceAct && op.Routing.OpCode() != engine.Unsharded,
 		ForceNonStreaming: ins.ForceNonStreaming,
 		Generate:          autoIncGenerate(ins.AutoIncrement),
 		ColVindexes:       ins.ColVindexes,
 		VindexValues:      ins.VindexValues,
 		VindexValueOffset: ins.VindexValueOffset,
 	}
+	if eins.Replace {
+		colVindexes := eins.ColVindexes
+		if colVindexes == nil {
+			colVindexes = eins.Table.ColumnVindexes
+		}
+		if err := planReplaceKeys(ins.AST, eins, colVindexes); err != nil {
+			return nil, err
+		}
+	}
 	i = &insert{eInsert: eins}
 
 	// we would need to generate the query on the fly. The only exception here is
@@ -430,6 +536,7 @@ func transformInsertPlan(ctx *plancontext.PlanningContext, op *operators.Route,
 		if err != nil {
 			return
 		}
+		eins.BatchSize = insertSelectBatchSize(ins.AST.Comments.Directives())
 	}
 	return
 }
@@ -462,7 +569,7 @@ func autoIncGenerate(gen *operators.Generate) *engine.Generate {
 
 func generateInsertShardedQuery(ins *sqlparser.Insert) (prefix string, mid []string, suffix string) {
 	valueTuples, isValues := ins.Rows.(sqlparser.Values)
-	prefixFormat := "insert %v%sinto %v%v "
+	prefixFormat := "%s %v%sinto %v%v "
 	if isValues {
 		// the mid values are filled differently
 		// with select uses sqlparser.String for sqlparser.Values
@@ -470,8 +577,12 @@ func generateInsertShardedQuery(ins *sqlparser.Insert) (prefix string, mid []str
 		prefixFormat += "values "
 	}
 	prefixBuf := sqlparser.NewTrackedBuffer(dmlFormatter)
+	action := sqlparser.InsertStr
+	if ins.Action == sqlparser.ReplaceAct {
+		action = sqlparser.ReplaceStr
+	}
 	prefixBuf.Myprintf(prefixFormat,
-		ins.Comments, ins.Ignore.ToString(),
+		action, ins.Comments, ins.Ignore.ToString(),
 		ins.Table, ins.Columns)
 	prefix = prefixBuf.String()
 
@@ -557,6 +668,26 @@ func transformDeletePlan(ctx *plancontext.PlanningContext, op *operators.Route,
 	return &primitiveWrapper{prim: e}, nil
 }
 
+func transformDMLWithInput(ctx *plancontext.PlanningContext, op *operators.DMLWithInput) (logicalPlan, error) {
+	input, _, _, err := newBuildSelectPlan(op.Select, ctx.ReservedVars, ctx.VSchema, ctx.PlannerVersion)
+	if err != nil {
+		return nil, err
+	}
+	dml, err := transformToLogicalPlan(ctx, op.DML, false)
+	if err != nil {
+		return nil, err
+	}
+	return &primitiveWrapper{prim: &engine.DMLWithInput{
+		Input:    input.Primitive(),
+		DML:      dml.Primitive(),
+		Keyspace: op.Keyspace,
+		Vindex:   op.Vindex,
+		ListVar:  op.ListVar,
+		LimitVar: op.LimitVar,
+		KeyVar:   op.KeyVar,
+	}}, nil
+}
+
 func transformDMLPlan(vtable *vindexes.Table, edml *engine.DML, routing operators.Routing, setVindex bool) {
 	if routing.OpCode() != engine.Unsharded && setVindex {
 		primary := vtable.ColumnVindexes[0]
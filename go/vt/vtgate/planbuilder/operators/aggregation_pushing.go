@@ -123,6 +123,18 @@ func pushDownAggregations(ctx *plancontext.PlanningContext, aggregator *Aggregat
 	distinctAggrGroupByAdded := false
 
 	for i, aggr := range aggregator.Aggregations {
+		if aggr.OpCode.IsStatistical() {
+			// The shards can't return a partial standard deviation or variance that we can merge,
+			// so we ask them for the count of values instead, and for the mean and population
+			// variance of the values once we plan the offsets.
+			count := &sqlparser.Count{Args: sqlparser.Exprs{aggr.Func.GetArg()}}
+			aeCount := aeWrap(count)
+			aggrBelowRoute.Columns[aggr.ColOffset] = aeCount
+			countAggr := NewAggr(opcode.AggregateCount, count, aeCount, "")
+			countAggr.ColOffset = aggr.ColOffset
+			aggrBelowRoute.Aggregations = append(aggrBelowRoute.Aggregations, countAggr)
+			continue
+		}
 		if !aggr.Distinct || canPushDownDistinctAggr {
 			aggrBelowRoute.Aggregations = append(aggrBelowRoute.Aggregations, aggr)
 			aggregateTheAggregate(aggregator, i)
@@ -552,6 +564,12 @@ func (ab *aggBuilder) handleAggr(ctx *plancontext.PlanningContext, aggr Aggr) er
 		return errAbortAggrPushing
 	case opcode.AggregateUnassigned:
 		return vterrors.VT12001(fmt.Sprintf("in scatter query: aggregation function '%s'", sqlparser.String(aggr.Original)))
+	case opcode.AggregateBitAnd, opcode.AggregateBitOr, opcode.AggregateBitXor,
+		opcode.AggregateStddevPop, opcode.AggregateStddevSamp, opcode.AggregateVarPop, opcode.AggregateVarSamp,
+		opcode.AggregateJSONArrayAgg, opcode.AggregateJSONObjectAgg:
+		// these are merged from the partial results of the same aggregation on each shard,
+		// which we can't produce on each side of the join
+		return vterrors.VT12001(fmt.Sprintf("in cross-shard join: aggregation function '%s'", sqlparser.String(aggr.Original)))
 	case opcode.AggregateGtid:
 		// this is only used for SHOW GTID queries that will never contain joins
 		return vterrors.VT13001("cannot do join with vgtid")
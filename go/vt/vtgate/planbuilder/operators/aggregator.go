@@ -299,9 +299,51 @@ func (a *Aggregator) planOffsets(ctx *plancontext.PlanningContext) error {
 		a.Aggregations[idx].WSOffset = offset
 	}
 
+	return a.pushStatisticalPartials(ctx)
+}
+
+// pushStatisticalPartials adds the sum and the population variance of the
+// values below us for every statistical aggregation, since these are needed
+// to merge the results of the shards into a standard deviation or variance.
+// The mean is computed from the sum and count at the vtgate, since the AVG of
+// the shards would have been rounded.
+func (a *Aggregator) pushStatisticalPartials(ctx *plancontext.PlanningContext) error {
+	for idx, aggr := range a.Aggregations {
+		if !aggr.OpCode.IsStatistical() || aggr.SumOffset != -1 {
+			continue
+		}
+		arg := aggr.Func.GetArg()
+		sum, err := a.addPartialColumn(ctx, &sqlparser.Sum{Arg: arg})
+		if err != nil {
+			return err
+		}
+		variance, err := a.addPartialColumn(ctx, &sqlparser.VarPop{Arg: arg})
+		if err != nil {
+			return err
+		}
+		a.Aggregations[idx].SumOffset = sum
+		a.Aggregations[idx].VarOffset = variance
+	}
 	return nil
 }
 
+// addPartialColumn pushes down an aggregation whose results are merged
+// by the statistical aggregations that use it, and not on their own.
+func (a *Aggregator) addPartialColumn(ctx *plancontext.PlanningContext, partial sqlparser.AggrFunc) (int, error) {
+	ae := aeWrap(partial)
+	offset, err := a.internalAddColumn(ctx, ae, false)
+	if err != nil {
+		return 0, err
+	}
+	if offset == len(a.Columns)-1 && a.Columns[offset] == ae {
+		// this is a new column, we just carry the values of the shards along
+		aggr := NewAggr(opcode.AggregateAnyValue, nil, ae, "")
+		aggr.ColOffset = offset
+		a.Aggregations = append(a.Aggregations, aggr)
+	}
+	return offset, nil
+}
+
 func (aggr Aggr) getPushDownColumn() sqlparser.Expr {
 	switch aggr.OpCode {
 	case opcode.AggregateAnyValue:
@@ -319,6 +361,12 @@ func (aggr Aggr) getPushDownColumn() sqlparser.Expr {
 }
 
 func (a *Aggregator) planOffsetsNotPushed(ctx *plancontext.PlanningContext) error {
+	for _, aggr := range a.Aggregations {
+		if aggr.OpCode.NeedsPartialResults() {
+			return vterrors.VT12001(fmt.Sprintf("in scatter query: aggregation function '%s'", sqlparser.String(aggr.Original)))
+		}
+	}
+
 	// we need to keep things in the column order, so we can't iterate over the aggregations or groupings
 	for colIdx := range a.Columns {
 		idx, err := a.addIfGroupingColumn(ctx, colIdx)
@@ -108,11 +108,39 @@ func createOperatorFromUnion(ctx *plancontext.PlanningContext, node *sqlparser.U
 }
 
 func createOperatorFromUpdate(ctx *plancontext.PlanningContext, updStmt *sqlparser.Update) (ops.Operator, error) {
-	tableInfo, qt, err := createQueryTableForDML(ctx, updStmt.TableExprs[0], updStmt.Where)
+	r, qt, vindexTable, err := createUpdateRoute(ctx, updStmt)
 	if err != nil {
 		return nil, err
 	}
 
+	subq, err := createSubqueryFromStatement(ctx, updStmt)
+	if err != nil {
+		return nil, err
+	}
+
+	if updStmt.Limit != nil && !r.IsSingleShard() {
+		if subq != nil {
+			return nil, vterrors.VT12001("multi shard UPDATE with LIMIT and subqueries")
+		}
+		return createDMLWithInput(ctx, updStmt, qt, vindexTable, func() (ops.Operator, error) {
+			r, _, _, err := createUpdateRoute(ctx, updStmt)
+			return r, err
+		})
+	}
+
+	if subq == nil {
+		return r, nil
+	}
+	subq.Outer = r
+	return subq, nil
+}
+
+func createUpdateRoute(ctx *plancontext.PlanningContext, updStmt *sqlparser.Update) (*Route, *QueryTable, *vindexes.Table, error) {
+	tableInfo, qt, err := createQueryTableForDML(ctx, updStmt.TableExprs[0], updStmt.Where)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
 	assignments := make(map[string]sqlparser.Expr)
 	for _, set := range updStmt.Exprs {
 		assignments[set.Name.Name.String()] = set.Expr
@@ -120,12 +148,12 @@ func createOperatorFromUpdate(ctx *plancontext.PlanningContext, updStmt *sqlpars
 
 	vindexTable, routing, err := buildVindexTableForDML(ctx, tableInfo, qt, "update")
 	if err != nil {
-		return nil, err
+		return nil, nil, nil, err
 	}
 
 	vp, cvv, ovq, err := getUpdateVindexInformation(updStmt, vindexTable, qt.ID, qt.Predicates)
 	if err != nil {
-		return nil, err
+		return nil, nil, nil, err
 	}
 
 	tr, ok := routing.(*ShardedRouting)
@@ -137,15 +165,10 @@ func createOperatorFromUpdate(ctx *plancontext.PlanningContext, updStmt *sqlpars
 		var err error
 		routing, err = UpdateRoutingLogic(ctx, predicate, routing)
 		if err != nil {
-			return nil, err
+			return nil, nil, nil, err
 		}
 	}
 
-	if routing.OpCode() == engine.Scatter && updStmt.Limit != nil {
-		// TODO systay: we should probably check for other op code types - IN could also hit multiple shards (2022-04-07)
-		return nil, vterrors.VT12001("multi shard UPDATE with LIMIT")
-	}
-
 	r := &Route{
 		Source: &Update{
 			QTable:              qt,
@@ -157,27 +180,57 @@ func createOperatorFromUpdate(ctx *plancontext.PlanningContext, updStmt *sqlpars
 		},
 		Routing: routing,
 	}
+	return r, qt, vindexTable, nil
+}
 
-	subq, err := createSubqueryFromStatement(ctx, updStmt)
+func createOperatorFromDelete(ctx *plancontext.PlanningContext, deleteStmt *sqlparser.Delete) (ops.Operator, error) {
+	if _, isAliasedExpr := deleteStmt.TableExprs[0].(*sqlparser.AliasedTableExpr); len(deleteStmt.TableExprs) != 1 || !isAliasedExpr {
+		return createMultiTableDelete(ctx, deleteStmt, func() (ops.Operator, error) {
+			route, _, _, err := createDeleteRoute(ctx, deleteStmt)
+			return route, err
+		})
+	}
+
+	route, qt, vindexTable, err := createDeleteRoute(ctx, deleteStmt)
 	if err != nil {
 		return nil, err
 	}
+
+	if !vindexTable.Keyspace.Sharded {
+		return route, nil
+	}
+
+	subq, err := createSubqueryFromStatement(ctx, deleteStmt)
+	if err != nil {
+		return nil, err
+	}
+
+	if deleteStmt.Limit != nil && !route.IsSingleShard() {
+		if subq != nil {
+			return nil, vterrors.VT12001("multi shard DELETE with LIMIT and subqueries")
+		}
+		return createDMLWithInput(ctx, deleteStmt, qt, vindexTable, func() (ops.Operator, error) {
+			route, _, _, err := createDeleteRoute(ctx, deleteStmt)
+			return route, err
+		})
+	}
+
 	if subq == nil {
-		return r, nil
+		return route, nil
 	}
-	subq.Outer = r
+	subq.Outer = route
 	return subq, nil
 }
 
-func createOperatorFromDelete(ctx *plancontext.PlanningContext, deleteStmt *sqlparser.Delete) (ops.Operator, error) {
+func createDeleteRoute(ctx *plancontext.PlanningContext, deleteStmt *sqlparser.Delete) (*Route, *QueryTable, *vindexes.Table, error) {
 	tableInfo, qt, err := createQueryTableForDML(ctx, deleteStmt.TableExprs[0], deleteStmt.Where)
 	if err != nil {
-		return nil, err
+		return nil, nil, nil, err
 	}
 
 	vindexTable, routing, err := buildVindexTableForDML(ctx, tableInfo, qt, "delete")
 	if err != nil {
-		return nil, err
+		return nil, nil, nil, err
 	}
 
 	del := &Delete{
@@ -191,12 +244,12 @@ func createOperatorFromDelete(ctx *plancontext.PlanningContext, deleteStmt *sqlp
 	}
 
 	if !vindexTable.Keyspace.Sharded {
-		return route, nil
+		return route, qt, vindexTable, nil
 	}
 
 	primaryVindex, vindexAndPredicates, err := getVindexInformation(qt.ID, qt.Predicates, vindexTable)
 	if err != nil {
-		return nil, err
+		return nil, nil, nil, err
 	}
 
 	tr, ok := routing.(*ShardedRouting)
@@ -216,24 +269,10 @@ func createOperatorFromDelete(ctx *plancontext.PlanningContext, deleteStmt *sqlp
 		var err error
 		route.Routing, err = UpdateRoutingLogic(ctx, predicate, route.Routing)
 		if err != nil {
-			return nil, err
+			return nil, nil, nil, err
 		}
 	}
-
-	if routing.OpCode() == engine.Scatter && deleteStmt.Limit != nil {
-		// TODO systay: we should probably check for other op code types - IN could also hit multiple shards (2022-04-07)
-		return nil, vterrors.VT12001("multi shard DELETE with LIMIT")
-	}
-
-	subq, err := createSubqueryFromStatement(ctx, deleteStmt)
-	if err != nil {
-		return nil, err
-	}
-	if subq == nil {
-		return route, nil
-	}
-	subq.Outer = route
-	return subq, nil
+	return route, qt, vindexTable, nil
 }
 
 func createOperatorFromInsert(ctx *plancontext.PlanningContext, ins *sqlparser.Insert) (ops.Operator, error) {
@@ -17,6 +17,8 @@ limitations under the License.
 package operators
 
 import (
+	"golang.org/x/exp/slices"
+
 	"vitess.io/vitess/go/vt/sqlparser"
 	"vitess.io/vitess/go/vt/vtgate/planbuilder/operators/ops"
 )
@@ -33,6 +35,15 @@ type (
 		// arguments that need to be copied from the outer to inner
 		Vars map[string]int
 
+		// Comparisons are set instead of Vars when the inner side is evaluated once per
+		// batch of outer rows. They are the equality comparisons the rows are matched on.
+		// LHSKeys and RHSKeys are the offsets of the compared expressions in the outer and
+		// the inner results, and ListVars are the names of the list arguments that the
+		// values of the outer expressions are bound to.
+		Comparisons      []HashJoinComparison
+		LHSKeys, RHSKeys []int
+		ListVars         []string
+
 		noColumns
 		noPredicates
 	}
@@ -84,11 +95,15 @@ func (c *CorrelatedSubQueryOp) Clone(inputs []ops.Operator) ops.Operator {
 	}
 
 	result := &CorrelatedSubQueryOp{
-		Outer:      inputs[0],
-		Inner:      inputs[1],
-		Extracted:  c.Extracted,
-		LHSColumns: columns,
-		Vars:       vars,
+		Outer:       inputs[0],
+		Inner:       inputs[1],
+		Extracted:   c.Extracted,
+		LHSColumns:  columns,
+		Vars:        vars,
+		Comparisons: slices.Clone(c.Comparisons),
+		LHSKeys:     slices.Clone(c.LHSKeys),
+		RHSKeys:     slices.Clone(c.RHSKeys),
+		ListVars:    slices.Clone(c.ListVars),
 	}
 	return result
 }
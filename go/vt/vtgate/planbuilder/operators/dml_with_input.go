@@ -0,0 +1,284 @@
+/*
+Copyright 2023 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package operators
+
+import (
+	"fmt"
+
+	"vitess.io/vitess/go/vt/sqlparser"
+	"vitess.io/vitess/go/vt/vterrors"
+	"vitess.io/vitess/go/vt/vtgate/planbuilder/operators/ops"
+	"vitess.io/vitess/go/vt/vtgate/planbuilder/plancontext"
+	"vitess.io/vitess/go/vt/vtgate/vindexes"
+)
+
+// DMLWithInput is used for UPDATE and DELETE statements with a LIMIT that touch more than one shard,
+// and for DELETE statements that join other tables in a sharded keyspace.
+// The rows to change are first found using Select, which is planned separately,
+// and the DML is then routed using the primary vindex values of those rows.
+type DMLWithInput struct {
+	// Select returns the primary vindex column of the rows to change,
+	// followed by the primary key column when KeyVar is set
+	Select *sqlparser.Select
+	// DML is the operator for the rewritten statement, limited to the rows returned by Select
+	DML ops.Operator
+
+	Keyspace *vindexes.Keyspace
+	Vindex   vindexes.SingleColumn
+
+	ListVar  string
+	LimitVar string
+	KeyVar   string
+
+	// selectTables are the tables read by Select
+	selectTables []string
+
+	noColumns
+	noPredicates
+}
+
+var _ ops.Operator = (*DMLWithInput)(nil)
+
+// Clone implements the Operator interface
+func (d *DMLWithInput) Clone(inputs []ops.Operator) ops.Operator {
+	clone := *d
+	clone.DML = inputs[0]
+	return &clone
+}
+
+// Inputs implements the Operator interface
+func (d *DMLWithInput) Inputs() []ops.Operator {
+	return []ops.Operator{d.DML}
+}
+
+// SetInputs implements the Operator interface
+func (d *DMLWithInput) SetInputs(inputs []ops.Operator) {
+	d.DML = inputs[0]
+}
+
+// TablesUsed implements the TableUser interface
+func (d *DMLWithInput) TablesUsed() []string {
+	return d.selectTables
+}
+
+func (d *DMLWithInput) ShortDescription() string {
+	return sqlparser.String(d.Select)
+}
+
+func (d *DMLWithInput) GetOrdering() ([]ops.OrderBy, error) {
+	return nil, nil
+}
+
+// createDMLWithInput rewrites a multi-shard UPDATE or DELETE with a LIMIT into
+// a select of the qualifying rows and a DML limited to those rows.
+// The given statement is changed in place, and createOp is used to plan it again.
+func createDMLWithInput(
+	ctx *plancontext.PlanningContext,
+	stmt sqlparser.Statement,
+	qt *QueryTable,
+	vindexTable *vindexes.Table,
+	createOp func() (ops.Operator, error),
+) (ops.Operator, error) {
+	var where **sqlparser.Where
+	var orderBy sqlparser.OrderBy
+	var limit **sqlparser.Limit
+	switch stmt := stmt.(type) {
+	case *sqlparser.Update:
+		where, orderBy, limit = &stmt.Where, stmt.OrderBy, &stmt.Limit
+	case *sqlparser.Delete:
+		where, orderBy, limit = &stmt.Where, stmt.OrderBy, &stmt.Limit
+	default:
+		return nil, vterrors.VT13001("unexpected statement for DML with input: " + sqlparser.String(stmt))
+	}
+
+	stmtType := sqlparser.ASTToStatementType(stmt).String()
+	if len(vindexTable.ColumnVindexes) == 0 {
+		return nil, vterrors.VT12001("multi shard " + stmtType + " with LIMIT on a table without a primary vindex")
+	}
+	primaryVindex := vindexTable.ColumnVindexes[0]
+	vindex, isSingleCol := primaryVindex.Vindex.(vindexes.SingleColumn)
+	if !isSingleCol || len(primaryVindex.Columns) != 1 {
+		return nil, vterrors.VT12001("multi shard " + stmtType + " with LIMIT on a multi-column vindex")
+	}
+
+	tableName := qt.Table
+	if !qt.Alias.As.IsEmpty() {
+		tableName = sqlparser.TableName{Name: qt.Alias.As}
+	}
+	selCol := sqlparser.NewColNameWithQualifier(primaryVindex.Columns[0].String(), tableName)
+	sel := &sqlparser.Select{
+		SelectExprs: sqlparser.SelectExprs{aeWrap(selCol)},
+		From:        sqlparser.TableExprs{sqlparser.CloneTableExpr(qt.Alias)},
+		Where:       sqlparser.CloneRefOfWhere(*where),
+		OrderBy:     sqlparser.CloneOrderBy(orderBy),
+		Limit:       sqlparser.CloneRefOfLimit(*limit),
+		Lock:        sqlparser.ForUpdateLock,
+	}
+
+	listVar := ctx.ReservedVars.ReserveVariable("dml_vals")
+	limitVar := ctx.ReservedVars.ReserveVariable("dml_limit")
+
+	// the DML is now only allowed to change the selected rows
+	dmlCol := sqlparser.NewColNameWithQualifier(primaryVindex.Columns[0].String(), tableName)
+	inPred := &sqlparser.ComparisonExpr{
+		Operator: sqlparser.InOp,
+		Left:     dmlCol,
+		Right:    sqlparser.NewListArg(listVar),
+	}
+	ctx.SemTable.Recursive[dmlCol] = qt.ID
+	ctx.SemTable.Direct[dmlCol] = qt.ID
+	ctx.SemTable.Recursive[inPred] = qt.ID
+	ctx.SemTable.Direct[inPred] = qt.ID
+
+	if *where == nil {
+		*where = sqlparser.NewWhere(sqlparser.WhereClause, inPred)
+	} else {
+		(*where).Expr = ctx.SemTable.AndExpressions((*where).Expr, inPred)
+	}
+	*limit = &sqlparser.Limit{Rowcount: sqlparser.NewArgument(limitVar)}
+
+	dmlOp, err := createOp()
+	if err != nil {
+		return nil, err
+	}
+
+	return &DMLWithInput{
+		Select:   sel,
+		DML:      dmlOp,
+		Keyspace: vindexTable.Keyspace,
+		Vindex:   vindex,
+		ListVar:  listVar,
+		LimitVar: limitVar,
+	}, nil
+}
+
+// createMultiTableDelete rewrites a DELETE that joins other tables into a select of the
+// primary vindex and primary key values of the target rows qualified by the join, and a
+// DELETE of the target table limited to the rows with those values.
+// The given statement is changed in place, and createOp is used to plan it again.
+func createMultiTableDelete(
+	ctx *plancontext.PlanningContext,
+	del *sqlparser.Delete,
+	createOp func() (ops.Operator, error),
+) (ops.Operator, error) {
+	if len(del.Targets) != 1 {
+		return nil, vterrors.VT12001("multi-table DELETE statement with multiple targets in a sharded keyspace")
+	}
+	target := del.Targets[0]
+
+	var targetExpr *sqlparser.AliasedTableExpr
+	var vindexTable *vindexes.Table
+	var selectTables []string
+	for _, ti := range ctx.SemTable.Tables {
+		vt := ti.GetVindexTable()
+		if vt == nil {
+			continue
+		}
+		selectTables = append(selectTables, QualifiedIdentifier(vt.Keyspace, vt.Name))
+		name, err := ti.Name()
+		if err != nil {
+			return nil, err
+		}
+		if name.Name != target.Name || (!target.Qualifier.IsEmpty() && name.Qualifier != target.Qualifier) {
+			continue
+		}
+		if targetExpr != nil {
+			return nil, vterrors.VT03013(target.Name.String())
+		}
+		targetExpr, vindexTable = ti.GetExpr(), vt
+	}
+	if targetExpr == nil {
+		// Unknown table in MULTI DELETE
+		return nil, vterrors.VT03003(target.Name.String())
+	}
+
+	if !vindexTable.Keyspace.Sharded {
+		return nil, vterrors.VT12001("multi-table DELETE of a table in an unsharded keyspace joined with sharded tables")
+	}
+	if len(vindexTable.ColumnVindexes) == 0 {
+		return nil, vterrors.VT12001("multi-table DELETE of a table without a primary vindex")
+	}
+	primaryVindex := vindexTable.ColumnVindexes[0]
+	vindex, isSingleCol := primaryVindex.Vindex.(vindexes.SingleColumn)
+	if !isSingleCol || len(primaryVindex.Columns) != 1 {
+		return nil, vterrors.VT12001("multi-table DELETE of a table with a multi-column vindex")
+	}
+	// Rows with the same primary vindex value can only be told apart by their primary key.
+	if len(vindexTable.PrimaryKey) != 1 {
+		return nil, vterrors.VT12001(fmt.Sprintf("multi-table DELETE when the single-column primary key of table '%s' is not known to the schema tracker", vindexTable.Name.String()))
+	}
+	vindexCol := primaryVindex.Columns[0]
+	pkCol := vindexTable.PrimaryKey[0]
+
+	tableName := sqlparser.TableName{Name: vindexTable.Name}
+	if !targetExpr.As.IsEmpty() {
+		tableName = sqlparser.TableName{Name: targetExpr.As}
+	}
+	sel := &sqlparser.Select{
+		SelectExprs: sqlparser.SelectExprs{aeWrap(sqlparser.NewColNameWithQualifier(vindexCol.String(), tableName))},
+		From:        sqlparser.CloneTableExprs(del.TableExprs),
+		Where:       sqlparser.CloneRefOfWhere(del.Where),
+		OrderBy:     sqlparser.CloneOrderBy(del.OrderBy),
+		Limit:       sqlparser.CloneRefOfLimit(del.Limit),
+		Lock:        sqlparser.ForUpdateLock,
+	}
+
+	tableID := ctx.SemTable.TableSetFor(targetExpr)
+	inPred := func(col sqlparser.IdentifierCI, listVar string) sqlparser.Expr {
+		dmlCol := sqlparser.NewColNameWithQualifier(col.String(), tableName)
+		pred := &sqlparser.ComparisonExpr{
+			Operator: sqlparser.InOp,
+			Left:     dmlCol,
+			Right:    sqlparser.NewListArg(listVar),
+		}
+		ctx.SemTable.Recursive[dmlCol] = tableID
+		ctx.SemTable.Direct[dmlCol] = tableID
+		ctx.SemTable.Recursive[pred] = tableID
+		ctx.SemTable.Direct[pred] = tableID
+		return pred
+	}
+
+	// the DML is now only allowed to change the selected rows of the target table
+	listVar := ctx.ReservedVars.ReserveVariable("dml_vals")
+	where := inPred(vindexCol, listVar)
+	var keyVar string
+	if !vindexCol.Equal(pkCol) {
+		sel.SelectExprs = append(sel.SelectExprs, aeWrap(sqlparser.NewColNameWithQualifier(pkCol.String(), tableName)))
+		keyVar = ctx.ReservedVars.ReserveVariable("dml_keys")
+		where = ctx.SemTable.AndExpressions(where, inPred(pkCol, keyVar))
+	}
+	del.TableExprs = sqlparser.TableExprs{targetExpr}
+	del.Targets = nil
+	del.Where = sqlparser.NewWhere(sqlparser.WhereClause, where)
+	del.OrderBy = nil
+	del.Limit = nil
+
+	dmlOp, err := createOp()
+	if err != nil {
+		return nil, err
+	}
+
+	return &DMLWithInput{
+		Select:       sel,
+		DML:          dmlOp,
+		Keyspace:     vindexTable.Keyspace,
+		Vindex:       vindex,
+		ListVar:      listVar,
+		KeyVar:       keyVar,
+		selectTables: selectTables,
+	}, nil
+}
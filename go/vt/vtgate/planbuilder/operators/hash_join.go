@@ -0,0 +1,394 @@
+/*
+Copyright 2023 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package operators
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/exp/slices"
+
+	"vitess.io/vitess/go/mysql/collations"
+	"vitess.io/vitess/go/slices2"
+	"vitess.io/vitess/go/sqltypes"
+	querypb "vitess.io/vitess/go/vt/proto/query"
+	"vitess.io/vitess/go/vt/sqlparser"
+	"vitess.io/vitess/go/vt/vterrors"
+	"vitess.io/vitess/go/vt/vtgate/evalengine"
+	"vitess.io/vitess/go/vt/vtgate/planbuilder/operators/ops"
+	"vitess.io/vitess/go/vt/vtgate/planbuilder/plancontext"
+	"vitess.io/vitess/go/vt/vtgate/semantics"
+)
+
+type (
+	// HashJoin is a join where both inputs are fetched only once, and the rows are matched on vtgate
+	// using a hash table built from the LHS rows. Unlike ApplyJoin, no values are sent from the LHS
+	// to the RHS, which makes it a better fit for joins where the LHS returns a lot of rows.
+	HashJoin struct {
+		LHS, RHS ops.Operator
+
+		// LeftJoin will be true in the case of an outer join
+		LeftJoin bool
+
+		// Predicate is the full join condition. Used for plan descriptions
+		Predicate sqlparser.Expr
+
+		// JoinComparisons are the equality comparisons used as keys in the hash table
+		JoinComparisons []HashJoinComparison
+
+		// JoinPredicates are the parts of the join condition that can't be used as keys.
+		// They are evaluated on vtgate for every pair of rows with matching keys.
+		JoinPredicates []sqlparser.Expr
+
+		columns []*sqlparser.AliasedExpr
+
+		// After offset planning
+
+		// ColumnOffsets stores the column indexes of the columns coming from the left and right side
+		// negative value comes from LHS and positive from RHS
+		ColumnOffsets []int
+
+		// LHSKeys and RHSKeys are the offsets of the JoinComparisons in the inputs
+		LHSKeys, RHSKeys []int
+
+		// Filter is the evalengine version of the JoinPredicates, using offsets into the row built from FilterColumns
+		Filter        evalengine.Expr
+		FilterColumns []int
+	}
+
+	// HashJoinComparison is an equality comparison between an expression of the LHS and an expression of the RHS.
+	// Both values are coerced to ComparisonType, and compared using Collation.
+	HashJoinComparison struct {
+		LHS, RHS       sqlparser.Expr
+		ComparisonType querypb.Type
+		Collation      collations.ID
+	}
+)
+
+var _ JoinOp = (*HashJoin)(nil)
+
+// tryCreateHashJoin returns a HashJoin for the two operators if the query allows hash joins and
+// at least one of the join predicates can be used as a key. Otherwise, it returns nil and the
+// caller should fall back to an ApplyJoin.
+func tryCreateHashJoin(ctx *plancontext.PlanningContext, lhs, rhs ops.Operator, joinPredicates []sqlparser.Expr, inner bool) (*HashJoin, error) {
+	if !ctx.AllowHashJoin || len(joinPredicates) == 0 {
+		return nil, nil
+	}
+
+	lhsID, rhsID := TableID(lhs), TableID(rhs)
+	var comparisons []HashJoinComparison
+	var others []sqlparser.Expr
+	for _, pred := range joinPredicates {
+		if cmp, ok := hashJoinComparisonFor(ctx, pred, lhsID, rhsID); ok {
+			comparisons = append(comparisons, cmp)
+			continue
+		}
+		if !canEvaluateOnVTGate(ctx, pred) {
+			return nil, nil
+		}
+		others = append(others, pred)
+	}
+	if len(comparisons) == 0 {
+		return nil, nil
+	}
+
+	hj := &HashJoin{
+		LHS:             Clone(lhs),
+		RHS:             Clone(rhs),
+		LeftJoin:        !inner,
+		Predicate:       ctx.SemTable.AndExpressions(joinPredicates...),
+		JoinComparisons: comparisons,
+	}
+	for _, pred := range others {
+		deps := ctx.SemTable.RecursiveDeps(pred)
+		// predicates on the LHS of an outer join don't filter the LHS rows,
+		// they only decide which rows match, so they must be evaluated by the join
+		if deps.IsSolvedBy(rhsID) || (inner && deps.IsSolvedBy(lhsID)) {
+			if _, err := AddPredicate(ctx, hj, pred, true, newFilter); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		hj.JoinPredicates = append(hj.JoinPredicates, pred)
+	}
+	return hj, nil
+}
+
+// hashJoinComparisonFor checks if the predicate is an equality between an expression
+// of the LHS and an expression of the RHS that can be hashed on vtgate
+func hashJoinComparisonFor(ctx *plancontext.PlanningContext, pred sqlparser.Expr, lhsID, rhsID semantics.TableSet) (HashJoinComparison, bool) {
+	cmp, ok := pred.(*sqlparser.ComparisonExpr)
+	if !ok || cmp.Operator != sqlparser.EqualOp {
+		return HashJoinComparison{}, false
+	}
+	left, right := cmp.Left, cmp.Right
+	ldeps, rdeps := ctx.SemTable.RecursiveDeps(left), ctx.SemTable.RecursiveDeps(right)
+	if ldeps.IsEmpty() || rdeps.IsEmpty() {
+		return HashJoinComparison{}, false
+	}
+	if ldeps.IsSolvedBy(rhsID) && rdeps.IsSolvedBy(lhsID) {
+		left, right = right, left
+		ldeps, rdeps = rdeps, ldeps
+	}
+	if !ldeps.IsSolvedBy(lhsID) || !rdeps.IsSolvedBy(rhsID) {
+		return HashJoinComparison{}, false
+	}
+
+	ltype, lcoll, lfound := ctx.SemTable.TypeForExpr(left)
+	rtype, rcoll, rfound := ctx.SemTable.TypeForExpr(right)
+	if !lfound || !rfound {
+		return HashJoinComparison{}, false
+	}
+
+	comparison := HashJoinComparison{LHS: left, RHS: right}
+	switch {
+	case sqltypes.IsText(ltype) && sqltypes.IsText(rtype):
+		switch {
+		case lcoll == collations.Unknown && rcoll == collations.Unknown:
+			// we don't know the collation, so we let MySQL produce
+			// the weight strings of the values, and compare those instead
+			comparison.LHS = &sqlparser.WeightStringFuncExpr{Expr: left}
+			comparison.RHS = &sqlparser.WeightStringFuncExpr{Expr: right}
+			comparison.ComparisonType = sqltypes.VarBinary
+			comparison.Collation = collations.CollationBinaryID
+		case lcoll == rcoll:
+			comparison.ComparisonType = sqltypes.VarChar
+			comparison.Collation = lcoll
+		default:
+			return HashJoinComparison{}, false
+		}
+	case sqltypes.IsBinary(ltype) && sqltypes.IsBinary(rtype):
+		comparison.ComparisonType = sqltypes.VarBinary
+		comparison.Collation = collations.CollationBinaryID
+	case sqltypes.IsIntegral(ltype) && sqltypes.IsIntegral(rtype):
+		switch {
+		case sqltypes.IsSigned(ltype) && sqltypes.IsSigned(rtype):
+			comparison.ComparisonType = sqltypes.Int64
+		case sqltypes.IsUnsigned(ltype) && sqltypes.IsUnsigned(rtype):
+			comparison.ComparisonType = sqltypes.Uint64
+		default:
+			comparison.ComparisonType = sqltypes.Decimal
+		}
+	case sqltypes.IsNumber(ltype) && sqltypes.IsNumber(rtype):
+		if sqltypes.IsFloat(ltype) || sqltypes.IsFloat(rtype) {
+			comparison.ComparisonType = sqltypes.Float64
+		} else {
+			comparison.ComparisonType = sqltypes.Decimal
+		}
+	case sqltypes.IsNumber(ltype) && sqltypes.IsText(rtype), sqltypes.IsText(ltype) && sqltypes.IsNumber(rtype):
+		// MySQL compares numbers and strings as floating point numbers
+		comparison.ComparisonType = sqltypes.Float64
+	default:
+		return HashJoinComparison{}, false
+	}
+	return comparison, true
+}
+
+// canEvaluateOnVTGate returns true if the expression can be evaluated by the evalengine
+func canEvaluateOnVTGate(ctx *plancontext.PlanningContext, expr sqlparser.Expr) bool {
+	_, err := evalengine.Translate(expr, &evalengine.Config{
+		ResolveColumn: func(*sqlparser.ColName) (int, error) { return 0, nil },
+		ResolveType:   ctx.SemTable.TypeForExpr,
+		Collation:     ctx.SemTable.Collation,
+	})
+	return err == nil
+}
+
+// Clone implements the Operator interface
+func (hj *HashJoin) Clone(inputs []ops.Operator) ops.Operator {
+	return &HashJoin{
+		LHS:             inputs[0],
+		RHS:             inputs[1],
+		LeftJoin:        hj.LeftJoin,
+		Predicate:       sqlparser.CloneExpr(hj.Predicate),
+		JoinComparisons: slices.Clone(hj.JoinComparisons),
+		JoinPredicates:  slices.Clone(hj.JoinPredicates),
+		columns:         slices.Clone(hj.columns),
+		ColumnOffsets:   slices.Clone(hj.ColumnOffsets),
+		LHSKeys:         slices.Clone(hj.LHSKeys),
+		RHSKeys:         slices.Clone(hj.RHSKeys),
+		Filter:          hj.Filter,
+		FilterColumns:   slices.Clone(hj.FilterColumns),
+	}
+}
+
+// Inputs implements the Operator interface
+func (hj *HashJoin) Inputs() []ops.Operator {
+	return []ops.Operator{hj.LHS, hj.RHS}
+}
+
+// SetInputs implements the Operator interface
+func (hj *HashJoin) SetInputs(inputs []ops.Operator) {
+	hj.LHS, hj.RHS = inputs[0], inputs[1]
+}
+
+func (hj *HashJoin) AddPredicate(ctx *plancontext.PlanningContext, expr sqlparser.Expr) (ops.Operator, error) {
+	return AddPredicate(ctx, hj, expr, false, newFilter)
+}
+
+func (hj *HashJoin) GetLHS() ops.Operator {
+	return hj.LHS
+}
+
+func (hj *HashJoin) GetRHS() ops.Operator {
+	return hj.RHS
+}
+
+func (hj *HashJoin) SetLHS(operator ops.Operator) {
+	hj.LHS = operator
+}
+
+func (hj *HashJoin) SetRHS(operator ops.Operator) {
+	hj.RHS = operator
+}
+
+func (hj *HashJoin) MakeInner() {
+	hj.LeftJoin = false
+}
+
+func (hj *HashJoin) IsInner() bool {
+	return !hj.LeftJoin
+}
+
+func (hj *HashJoin) AddJoinPredicate(ctx *plancontext.PlanningContext, expr sqlparser.Expr) error {
+	hj.Predicate = ctx.SemTable.AndExpressions(hj.Predicate, expr)
+	if cmp, ok := hashJoinComparisonFor(ctx, expr, TableID(hj.LHS), TableID(hj.RHS)); ok {
+		hj.JoinComparisons = append(hj.JoinComparisons, cmp)
+		return nil
+	}
+	if !canEvaluateOnVTGate(ctx, expr) {
+		return vterrors.VT12001(fmt.Sprintf("hash join with the predicate: %s", sqlparser.String(expr)))
+	}
+	hj.JoinPredicates = append(hj.JoinPredicates, expr)
+	return nil
+}
+
+func (hj *HashJoin) AddColumn(ctx *plancontext.PlanningContext, expr *sqlparser.AliasedExpr, _, _ bool) (ops.Operator, int, error) {
+	if offset, found := canReuseColumn(ctx, hj.columns, expr.Expr, extractExpr); found {
+		return hj, offset, nil
+	}
+	deps := ctx.SemTable.RecursiveDeps(expr.Expr)
+	if !deps.IsSolvedBy(TableID(hj.LHS)) && !deps.IsSolvedBy(TableID(hj.RHS)) {
+		return nil, 0, vterrors.VT12001("hash join with projection from both sides of the join")
+	}
+	hj.columns = append(hj.columns, expr)
+	return hj, len(hj.columns) - 1, nil
+}
+
+func (hj *HashJoin) GetColumns() ([]*sqlparser.AliasedExpr, error) {
+	return hj.columns, nil
+}
+
+func (hj *HashJoin) GetSelectExprs() (sqlparser.SelectExprs, error) {
+	return transformColumnsToSelectExprs(hj)
+}
+
+// GetOrdering implements the Operator interface. The rows of a hash join come in no particular order.
+func (hj *HashJoin) GetOrdering() ([]ops.OrderBy, error) {
+	return nil, nil
+}
+
+func (hj *HashJoin) planOffsets(ctx *plancontext.PlanningContext) error {
+	hj.ColumnOffsets, hj.LHSKeys, hj.RHSKeys, hj.FilterColumns = nil, nil, nil, nil
+
+	for _, col := range hj.columns {
+		offset, err := hj.pushColumn(ctx, col.Expr)
+		if err != nil {
+			return err
+		}
+		hj.ColumnOffsets = append(hj.ColumnOffsets, offset)
+	}
+
+	for _, cmp := range hj.JoinComparisons {
+		lhs, offset, err := hj.LHS.AddColumn(ctx, aeWrap(cmp.LHS), true, false)
+		if err != nil {
+			return err
+		}
+		hj.LHS = lhs
+		hj.LHSKeys = append(hj.LHSKeys, offset)
+
+		rhs, offset, err := hj.RHS.AddColumn(ctx, aeWrap(cmp.RHS), true, false)
+		if err != nil {
+			return err
+		}
+		hj.RHS = rhs
+		hj.RHSKeys = append(hj.RHSKeys, offset)
+	}
+
+	if len(hj.JoinPredicates) == 0 {
+		return nil
+	}
+
+	// the filter is evaluated against a row built from the columns it uses,
+	// so every column is replaced by the offset to its value in that row
+	var err error
+	predicate := sqlparser.AndExpressions(hj.JoinPredicates...)
+	rewritten := sqlparser.CopyOnRewrite(predicate, nil, func(cursor *sqlparser.CopyOnWriteCursor) {
+		col, ok := cursor.Node().(*sqlparser.ColName)
+		if !ok || err != nil {
+			return
+		}
+		var offset int
+		offset, err = hj.pushColumn(ctx, col)
+		if err != nil {
+			return
+		}
+		hj.FilterColumns = append(hj.FilterColumns, offset)
+		cursor.Replace(sqlparser.NewOffset(len(hj.FilterColumns)-1, col))
+	}, ctx.SemTable.CopyDependenciesOnSQLNodes)
+	if err != nil {
+		return err
+	}
+
+	hj.Filter, err = evalengine.Translate(rewritten.(sqlparser.Expr), &evalengine.Config{
+		ResolveType: ctx.SemTable.TypeForExpr,
+		Collation:   ctx.SemTable.Collation,
+	})
+	return err
+}
+
+// pushColumn pushes the expression to the side of the join it depends on, and
+// returns the offset to it, using negative values for the LHS and positive for the RHS
+func (hj *HashJoin) pushColumn(ctx *plancontext.PlanningContext, expr sqlparser.Expr) (int, error) {
+	deps := ctx.SemTable.RecursiveDeps(expr)
+	switch {
+	case deps.IsSolvedBy(TableID(hj.LHS)):
+		lhs, offset, err := hj.LHS.AddColumn(ctx, aeWrap(expr), true, false)
+		if err != nil {
+			return 0, err
+		}
+		hj.LHS = lhs
+		return -offset - 1, nil
+	case deps.IsSolvedBy(TableID(hj.RHS)):
+		rhs, offset, err := hj.RHS.AddColumn(ctx, aeWrap(expr), true, false)
+		if err != nil {
+			return 0, err
+		}
+		hj.RHS = rhs
+		return offset + 1, nil
+	default:
+		return 0, vterrors.VT12001("hash join with projection from both sides of the join")
+	}
+}
+
+func (hj *HashJoin) ShortDescription() string {
+	pred := sqlparser.String(hj.Predicate)
+	columns := slices2.Map(hj.columns, func(from *sqlparser.AliasedExpr) string {
+		return sqlparser.String(from)
+	})
+	return fmt.Sprintf("on %s columns: %s", pred, strings.Join(columns, ", "))
+}
@@ -81,7 +81,14 @@ func createProjectionFromSelect(ctx *plancontext.PlanningContext, horizon *Horiz
 	}
 
 	if !qp.NeedsAggregation() {
-		projX, err := createProjectionWithoutAggr(qp, horizon.src())
+		src := horizon.src()
+		if needsWindowEvaluation(ctx, horizon) {
+			src, err = createWindow(qp, horizon.selectStatement().(*sqlparser.Select), src)
+			if err != nil {
+				return nil, err
+			}
+		}
+		projX, err := createProjectionWithoutAggr(qp, src)
 		if err != nil {
 			return nil, err
 		}
@@ -46,6 +46,11 @@ func tryHorizonPlanning(ctx *plancontext.PlanningContext, root ops.Operator) (ou
 	defer func() {
 		// If we encounter the _errHorizonNotPlanned error, we'll revert to using the old horizon planning strategy.
 		if err == _errHorizonNotPlanned {
+			if horizon, isHorizon := backup.(*Horizon); isHorizon && needsWindowEvaluation(ctx, horizon) {
+				// the legacy horizon planner would send the window functions to every shard
+				err = vterrors.VT12001("window functions in this cross-shard query")
+				return
+			}
 			// The only offset planning we did before was on joins.
 			// Therefore, we traverse the tree to find all joins and calculate the joinColumns offsets.
 			// Our fallback strategy is to clone the original operator tree, compute the join offsets,
@@ -164,7 +169,11 @@ func pushOrExpandHorizon(ctx *plancontext.PlanningContext, in *Horizon) (ops.Ope
 	}
 
 	needsOrdering := len(qp.OrderExprs) > 0
-	canPushDown := isRoute && sel.Having == nil && !needsOrdering && !qp.NeedsAggregation() && !sel.Distinct && sel.Limit == nil
+	needsWindow := needsWindowEvaluation(ctx, in)
+	if needsWindow && (qp.NeedsAggregation() || in.TableId != nil) {
+		return nil, nil, vterrors.VT12001("cross-shard window functions in aggregations or derived tables")
+	}
+	canPushDown := isRoute && sel.Having == nil && !needsOrdering && !qp.NeedsAggregation() && !sel.Distinct && sel.Limit == nil && !needsWindow
 
 	if canPushDown {
 		return rewrite.Swap(in, rb, "push horizon into route")
@@ -409,8 +418,9 @@ func setUpperLimit(in *Limit) (ops.Operator, *rewrite.ApplyResult, error) {
 	}
 	shouldVisit := func(op ops.Operator) rewrite.VisitRule {
 		switch op := op.(type) {
-		case *Join, *ApplyJoin:
-			// we can't push limits down on either side
+		case *Join, *ApplyJoin, *HashJoin, *Window:
+			// we can't push limits down on either side, and
+			// limiting the input of a window would change the values it calculates
 			return rewrite.SkipChildren
 		case *Route:
 			newSrc := &Limit{
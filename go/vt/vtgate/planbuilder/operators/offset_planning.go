@@ -72,11 +72,13 @@ func fetchByOffset(e sqlparser.SQLNode) bool {
 
 func planOffsetsOnJoins(ctx *plancontext.PlanningContext, op ops.Operator) error {
 	err := rewrite.Visit(op, func(current ops.Operator) error {
-		join, ok := current.(*ApplyJoin)
-		if !ok {
-			return nil
+		switch join := current.(type) {
+		case *ApplyJoin:
+			return join.planOffsets(ctx)
+		case *HashJoin:
+			return join.planOffsets(ctx)
 		}
-		return join.planOffsets(ctx)
+		return nil
 	})
 	return err
 }
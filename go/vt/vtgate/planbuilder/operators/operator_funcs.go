@@ -35,7 +35,36 @@ func RemovePredicate(ctx *plancontext.PlanningContext, expr sqlparser.Expr, op o
 			return nil, err
 		}
 		op.Source = newSrc
+		if tr, ok := op.Routing.(*ShardedRouting); ok {
+			// the predicate might have been used to pick the vindex for this route,
+			// so we need to re-plan the routing without it
+			var seen []sqlparser.Expr
+			for _, pred := range tr.SeenPredicates {
+				if !ctx.SemTable.EqualsExprWithDeps(pred, expr) {
+					seen = append(seen, pred)
+				}
+			}
+			if len(seen) != len(tr.SeenPredicates) {
+				tr.SeenPredicates = seen
+				op.Routing, err = tr.ResetRoutingLogic(ctx)
+				if err != nil {
+					return nil, err
+				}
+			}
+		}
 		return op, err
+	case *Table:
+		var keep []sqlparser.Expr
+		for _, pred := range op.QTable.Predicates {
+			if !ctx.SemTable.EqualsExprWithDeps(pred, expr) {
+				keep = append(keep, pred)
+			}
+		}
+		if len(keep) == len(op.QTable.Predicates) {
+			return nil, vterrors.VT13001(fmt.Sprintf("predicate '%s' not found on table %s", sqlparser.String(expr), sqlparser.String(op.QTable.Table)))
+		}
+		op.QTable.Predicates = keep
+		return op, nil
 	case *ApplyJoin:
 		isRemoved := false
 		deps := ctx.SemTable.RecursiveDeps(expr)
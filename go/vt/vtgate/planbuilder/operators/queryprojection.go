@@ -100,6 +100,12 @@ type (
 		// the offsets point to columns on the same aggregator
 		ColOffset int
 		WSOffset  int
+
+		// SumOffset and VarOffset point to the sum and population variance of the values
+		// returned by the shards for statistical aggregations such as STDDEV and VARIANCE.
+		// ColOffset then points to the count of the values.
+		SumOffset int
+		VarOffset int
 	}
 
 	AggrRewriter struct {
@@ -143,6 +149,8 @@ func NewAggr(opCode opcode.AggregateOpcode, f sqlparser.AggrFunc, original *sqlp
 		Alias:     alias,
 		ColOffset: -1,
 		WSOffset:  -1,
+		SumOffset: -1,
+		VarOffset: -1,
 	}
 }
 
@@ -429,6 +437,10 @@ func checkForInvalidAggregations(exp *sqlparser.AliasedExpr) error {
 		if !isAggregate {
 			return true, nil
 		}
+		if _, isObjectAgg := aggrFunc.(*sqlparser.JSONObjectAgg); isObjectAgg {
+			// JSON_OBJECTAGG always takes a key and a value
+			return true, nil
+		}
 		args := aggrFunc.GetArgs()
 		if args != nil && len(args) != 1 {
 			return false, vterrors.VT03001(sqlparser.String(node))
@@ -412,6 +412,14 @@ func mergeOrJoin(ctx *plancontext.PlanningContext, lhs, rhs ops.Operator, joinPr
 		return newPlan, rewrite.NewTree("merge routes into single operator", newPlan), nil
 	}
 
+	hashJoin, err := tryCreateHashJoin(ctx, lhs, rhs, joinPredicates, inner)
+	if err != nil {
+		return nil, nil, err
+	}
+	if hashJoin != nil {
+		return hashJoin, rewrite.NewTree("logical join to hashJoin", hashJoin), nil
+	}
+
 	if len(joinPredicates) > 0 && requiresSwitchingSides(ctx, rhs) {
 		if !inner {
 			return nil, nil, vterrors.VT12001("LEFT JOIN with derived tables")
@@ -17,6 +17,8 @@ limitations under the License.
 package operators
 
 import (
+	"golang.org/x/exp/slices"
+
 	"vitess.io/vitess/go/vt/sqlparser"
 	"vitess.io/vitess/go/vt/vterrors"
 	"vitess.io/vitess/go/vt/vtgate/engine"
@@ -27,6 +29,10 @@ import (
 	"vitess.io/vitess/go/vt/vtgate/semantics"
 )
 
+// semiJoinListVar is the name of the list argument that the values of the outer side
+// of a correlated subquery are bound to when the subquery is evaluated for a batch of rows.
+const semiJoinListVar = "__sj_vals"
+
 func optimizeSubQuery(ctx *plancontext.PlanningContext, op *SubQuery, ts semantics.TableSet) (ops.Operator, *rewrite.ApplyResult, error) {
 	var unmerged []*SubQueryOp
 
@@ -62,16 +68,25 @@ func optimizeSubQuery(ctx *plancontext.PlanningContext, op *SubQuery, ts semanti
 			continue
 		}
 
-		if inner.ExtractedSubquery.OpCode == int(popcode.PulloutExists) {
-			correlatedTree, err := createCorrelatedSubqueryOp(ctx, innerOp, outer, preds, inner.ExtractedSubquery)
-			if err != nil {
-				return nil, nil, err
+		switch popcode.PulloutOpcode(inner.ExtractedSubquery.OpCode) {
+		case popcode.PulloutIn:
+			// `x IN (select y ... where <correlated>)` is evaluated as
+			// `EXISTS (select 1 ... where <correlated> and y = x)`
+			inPred := correlatedInPredicate(ctx, inner.ExtractedSubquery, innerOp)
+			if inPred == nil {
+				return nil, nil, vterrors.VT12001("cross-shard correlated subquery")
 			}
-			outer = correlatedTree
-			continue
+			preds = append(preds, inPred)
+		case popcode.PulloutExists:
+		default:
+			return nil, nil, vterrors.VT12001("cross-shard correlated subquery")
 		}
 
-		return nil, nil, vterrors.VT12001("cross-shard correlated subquery")
+		correlatedTree, err := createCorrelatedSubqueryOp(ctx, innerOp, outer, preds, inner.ExtractedSubquery)
+		if err != nil {
+			return nil, nil, err
+		}
+		outer = correlatedTree
 	}
 
 	for _, tree := range unmerged {
@@ -81,6 +96,70 @@ func optimizeSubQuery(ctx *plancontext.PlanningContext, op *SubQuery, ts semanti
 	return outer, rewrite.NewTree("merged subqueries", outer), nil
 }
 
+// correlatedInPredicate returns the predicate that turns a correlated IN subquery
+// into a semi-join, by comparing the outer expression with the single column
+// selected by the subquery. It returns nil if the subquery can't be evaluated that way,
+// for example because the added predicate would change the result of an aggregation or a limit.
+func correlatedInPredicate(ctx *plancontext.PlanningContext, extracted *sqlparser.ExtractedSubquery, innerOp ops.Operator) sqlparser.Expr {
+	if extracted.OtherSide == nil {
+		return nil
+	}
+	if _, isTuple := extracted.OtherSide.(sqlparser.ValTuple); isTuple {
+		return nil
+	}
+	if !canAddSubqueryPredicates(extracted, innerOp) {
+		return nil
+	}
+	sel := extracted.Subquery.Select.(*sqlparser.Select)
+	if len(sel.SelectExprs) != 1 {
+		return nil
+	}
+	ae, ok := sel.SelectExprs[0].(*sqlparser.AliasedExpr)
+	if !ok {
+		return nil
+	}
+	pred := &sqlparser.ComparisonExpr{
+		Operator: sqlparser.EqualOp,
+		Left:     ae.Expr,
+		Right:    extracted.OtherSide,
+	}
+	// the outer side is replaced by an argument before being pushed to the inner side,
+	// so the predicate only depends on the tables of the subquery
+	ctx.SemTable.CopyDependencies(ae.Expr, pred)
+	return pred
+}
+
+// canAddSubqueryPredicates returns true if predicates can be added to the subquery without changing
+// whether it returns rows for the rows it already matched. This is not the case for subqueries with
+// aggregations, limits or nested subqueries. The limit of an EXISTS subquery is ignored, since
+// it only matters whether the subquery returns any rows.
+func canAddSubqueryPredicates(extracted *sqlparser.ExtractedSubquery, innerOp ops.Operator) bool {
+	nestedSubQuery := false
+	_ = rewrite.Visit(innerOp, func(this ops.Operator) error {
+		switch this.(type) {
+		case *SubQuery, *SubQueryOp, *CorrelatedSubQueryOp:
+			nestedSubQuery = true
+		}
+		return nil
+	})
+	if nestedSubQuery {
+		return false
+	}
+	sel, ok := extracted.Subquery.Select.(*sqlparser.Select)
+	if !ok || len(sel.GroupBy) > 0 || sel.Having != nil {
+		return false
+	}
+	if sel.Limit != nil && popcode.PulloutOpcode(extracted.OpCode) != popcode.PulloutExists {
+		return false
+	}
+	for _, expr := range sel.SelectExprs {
+		if ae, ok := expr.(*sqlparser.AliasedExpr); ok && sqlparser.ContainsAggregation(ae.Expr) {
+			return false
+		}
+	}
+	return true
+}
+
 func unresolvedAndSource(ctx *plancontext.PlanningContext, op ops.Operator) ([]sqlparser.Expr, ops.Operator) {
 	preds := UnresolvedPredicates(op, ctx.SemTable)
 	if filter, ok := op.(*Filter); ok {
@@ -335,6 +414,11 @@ func createCorrelatedSubqueryOp(
 		return nil, vterrors.VT12001("EXISTS sub-queries are only supported with AND clause")
 	}
 
+	batched, err := createBatchedCorrelatedSubqueryOp(ctx, innerOp, newOuter, preds, extractedSubquery)
+	if err != nil || batched != nil {
+		return batched, err
+	}
+
 	resultOuterOp := newOuter
 	vars := map[string]int{}
 	bindVars := map[*sqlparser.ColName]string{}
@@ -398,6 +482,73 @@ func createCorrelatedSubqueryOp(
 	}, nil
 }
 
+// createBatchedCorrelatedSubqueryOp returns a CorrelatedSubQueryOp that evaluates the subquery once
+// for a whole batch of outer rows: the values of the outer expressions compared by the correlated
+// predicates are bound as lists that the inner expressions are filtered by, and the rows of both sides
+// are matched on the compared expressions by vtgate.
+// It returns nil if any of the predicates is not an equality comparison between the two sides that
+// vtgate can evaluate, or if the subquery can't be filtered for many outer rows at once.
+func createBatchedCorrelatedSubqueryOp(
+	ctx *plancontext.PlanningContext,
+	innerOp, outerOp ops.Operator,
+	preds []sqlparser.Expr,
+	extractedSubquery *sqlparser.ExtractedSubquery,
+) (*CorrelatedSubQueryOp, error) {
+	if !canAddSubqueryPredicates(extractedSubquery, innerOp) {
+		return nil, nil
+	}
+	var comparisons []HashJoinComparison
+	for _, pred := range preds {
+		cmp, ok := hashJoinComparisonFor(ctx, pred, TableID(outerOp), TableID(innerOp))
+		if !ok {
+			return nil, nil
+		}
+		if _, isWeightString := cmp.LHS.(*sqlparser.WeightStringFuncExpr); isWeightString {
+			// the weight strings of both sides are only comparable if they use the same collation
+			return nil, nil
+		}
+		if !slices.ContainsFunc(comparisons, func(other HashJoinComparison) bool {
+			return ctx.SemTable.EqualsExprWithDeps(cmp.LHS, other.LHS) && ctx.SemTable.EqualsExprWithDeps(cmp.RHS, other.RHS)
+		}) {
+			comparisons = append(comparisons, cmp)
+		}
+	}
+
+	op := &CorrelatedSubQueryOp{
+		Extracted:   extractedSubquery,
+		Comparisons: comparisons,
+	}
+	for _, cmp := range comparisons {
+		listVar := ctx.ReservedVars.ReserveVariable(semiJoinListVar)
+		inPred := &sqlparser.ComparisonExpr{
+			Operator: sqlparser.InOp,
+			Left:     cmp.RHS,
+			Right:    sqlparser.ListArg(listVar),
+		}
+		ctx.SemTable.CopyDependencies(cmp.RHS, inPred)
+
+		var err error
+		innerOp, err = innerOp.AddPredicate(ctx, inPred)
+		if err != nil {
+			return nil, err
+		}
+		var lhsOffset, rhsOffset int
+		outerOp, lhsOffset, err = outerOp.AddColumn(ctx, aeWrap(cmp.LHS), true, false)
+		if err != nil {
+			return nil, err
+		}
+		innerOp, rhsOffset, err = innerOp.AddColumn(ctx, aeWrap(cmp.RHS), true, false)
+		if err != nil {
+			return nil, err
+		}
+		op.LHSKeys = append(op.LHSKeys, lhsOffset)
+		op.RHSKeys = append(op.RHSKeys, rhsOffset)
+		op.ListVars = append(op.ListVars, listVar)
+	}
+	op.Outer, op.Inner = outerOp, innerOp
+	return op, nil
+}
+
 // canMergeSubqueryOnColumnSelection will return true if the predicate used allows us to merge the two subqueries
 // into a single Route. This can be done if we are comparing two columns that contain data that is guaranteed
 // to exist on the same shard.
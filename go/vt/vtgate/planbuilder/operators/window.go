@@ -0,0 +1,374 @@
+/*
+Copyright 2023 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package operators
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"golang.org/x/exp/slices"
+
+	"vitess.io/vitess/go/slices2"
+	"vitess.io/vitess/go/vt/sqlparser"
+	"vitess.io/vitess/go/vt/vterrors"
+	"vitess.io/vitess/go/vt/vtgate/engine/opcode"
+	"vitess.io/vitess/go/vt/vtgate/planbuilder/operators/ops"
+	"vitess.io/vitess/go/vt/vtgate/planbuilder/plancontext"
+)
+
+type (
+	// Window is used when window functions can't be pushed down to MySQL
+	// and have to be evaluated on the vtgate instead.
+	// The input of the Window has to be sorted by the PARTITION BY expressions
+	// followed by the ORDER BY expressions of the window specification.
+	// The window functions are the first columns this operator produces,
+	// followed by the columns of its Source.
+	Window struct {
+		Source    ops.Operator
+		Functions []WindowFunc
+
+		PartitionBy []GroupBy
+
+		Order   []ops.OrderBy
+		Offset  []int
+		WOffset []int
+
+		ResultColumns int
+	}
+
+	// WindowFunc encodes all information needed to evaluate a window function on the vtgate
+	WindowFunc struct {
+		Original *sqlparser.AliasedExpr
+		OpCode   opcode.WindowOpcode
+
+		// Arg is the value read by LAG, LEAD and FIRST_VALUE. It is nil for the other functions
+		Arg sqlparser.Expr
+
+		// N and Default are the optional arguments of LAG and LEAD
+		N       int
+		Default sqlparser.Expr
+
+		// ArgOffset points to the column on the input of the Window operator
+		ArgOffset int
+	}
+)
+
+func (w *Window) Clone(inputs []ops.Operator) ops.Operator {
+	return &Window{
+		Source:        inputs[0],
+		Functions:     slices.Clone(w.Functions),
+		PartitionBy:   slices.Clone(w.PartitionBy),
+		Order:         slices.Clone(w.Order),
+		Offset:        slices.Clone(w.Offset),
+		WOffset:       slices.Clone(w.WOffset),
+		ResultColumns: w.ResultColumns,
+	}
+}
+
+func (w *Window) Inputs() []ops.Operator {
+	return []ops.Operator{w.Source}
+}
+
+func (w *Window) SetInputs(operators []ops.Operator) {
+	w.Source = operators[0]
+}
+
+func (w *Window) AddPredicate(_ *plancontext.PlanningContext, expr sqlparser.Expr) (ops.Operator, error) {
+	// predicates can't be pushed under the window - they would change the rows the functions are calculated over
+	return newFilter(w, expr), nil
+}
+
+func (w *Window) AddColumn(ctx *plancontext.PlanningContext, expr *sqlparser.AliasedExpr, reuseExisting, addToGroupBy bool) (ops.Operator, int, error) {
+	for idx, fn := range w.Functions {
+		if ctx.SemTable.EqualsExprWithDeps(fn.Original.Expr, expr.Expr) {
+			return w, idx, nil
+		}
+	}
+	newSrc, offset, err := w.Source.AddColumn(ctx, expr, reuseExisting, addToGroupBy)
+	if err != nil {
+		return nil, 0, err
+	}
+	w.Source = newSrc
+	return w, offset + len(w.Functions), nil
+}
+
+func (w *Window) GetColumns() ([]*sqlparser.AliasedExpr, error) {
+	columns, err := w.Source.GetColumns()
+	if err != nil {
+		return nil, err
+	}
+	functions := slices2.Map(w.Functions, func(from WindowFunc) *sqlparser.AliasedExpr {
+		return from.Original
+	})
+	return append(functions, columns...), nil
+}
+
+func (w *Window) GetSelectExprs() (sqlparser.SelectExprs, error) {
+	return transformColumnsToSelectExprs(w)
+}
+
+func (w *Window) GetOrdering() ([]ops.OrderBy, error) {
+	return w.Source.GetOrdering()
+}
+
+func (w *Window) planOffsets(ctx *plancontext.PlanningContext) error {
+	addColumn := func(e sqlparser.Expr) (int, error) {
+		newSrc, offset, err := w.Source.AddColumn(ctx, aeWrap(e), true, false)
+		if err != nil {
+			return 0, err
+		}
+		w.Source = newSrc
+		return offset, nil
+	}
+	addWeightString := func(e sqlparser.Expr) (int, error) {
+		if !ctx.SemTable.NeedsWeightString(e) {
+			return -1, nil
+		}
+		return addColumn(weightStringFor(e))
+	}
+
+	var err error
+	for idx, by := range w.PartitionBy {
+		w.PartitionBy[idx].ColOffset, err = addColumn(by.SimplifiedExpr)
+		if err != nil {
+			return err
+		}
+		w.PartitionBy[idx].WSOffset, err = addWeightString(by.SimplifiedExpr)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, order := range w.Order {
+		offset, err := addColumn(order.SimplifiedExpr)
+		if err != nil {
+			return err
+		}
+		wsOffset, err := addWeightString(order.SimplifiedExpr)
+		if err != nil {
+			return err
+		}
+		w.Offset = append(w.Offset, offset)
+		w.WOffset = append(w.WOffset, wsOffset)
+	}
+
+	for idx, fn := range w.Functions {
+		if fn.Arg == nil {
+			continue
+		}
+		w.Functions[idx].ArgOffset, err = addColumn(fn.Arg)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (w *Window) ShortDescription() string {
+	functions := slices2.Map(w.Functions, func(from WindowFunc) string {
+		return sqlparser.String(from.Original)
+	})
+	return strings.Join(functions, ", ")
+}
+
+func (w *Window) setTruncateColumnCount(offset int) {
+	w.ResultColumns = offset
+}
+
+// windowFunctions returns the window functions used in the SELECT expressions and ORDER BY of the query
+func windowFunctions(sel *sqlparser.Select) (funcs []sqlparser.Expr) {
+	visit := func(node sqlparser.SQLNode) (kontinue bool, err error) {
+		e, isExpr := node.(sqlparser.Expr)
+		if !isExpr || getOverClause(e) == nil {
+			return true, nil
+		}
+		for _, existing := range funcs {
+			if sqlparser.Equals.Expr(existing, e) {
+				return false, nil
+			}
+		}
+		funcs = append(funcs, e)
+		return false, nil
+	}
+	_ = sqlparser.Walk(visit, sel.SelectExprs)
+	_ = sqlparser.Walk(visit, sel.OrderBy)
+	return funcs
+}
+
+func getOverClause(e sqlparser.Expr) *sqlparser.OverClause {
+	switch e := e.(type) {
+	case *sqlparser.ArgumentLessWindowExpr:
+		return e.OverClause
+	case *sqlparser.FirstOrLastValueExpr:
+		return e.OverClause
+	case *sqlparser.NtileExpr:
+		return e.OverClause
+	case *sqlparser.NTHValueExpr:
+		return e.OverClause
+	case *sqlparser.LagLeadExpr:
+		return e.OverClause
+	}
+	return nil
+}
+
+// needsWindowEvaluation returns true if the horizon contains window functions
+// that can't be sent to MySQL as part of the query.
+// That is the case when we are not sending the query to a single shard, unless
+// every window partition is guaranteed to live on a single shard,
+// because the PARTITION BY contains a column with a unique vindex.
+func needsWindowEvaluation(ctx *plancontext.PlanningContext, horizon *Horizon) bool {
+	sel, isSel := horizon.selectStatement().(*sqlparser.Select)
+	if !isSel {
+		return false
+	}
+	funcs := windowFunctions(sel)
+	if len(funcs) == 0 {
+		return false
+	}
+
+	rb, isRoute := horizon.src().(*Route)
+	if !isRoute {
+		return true
+	}
+	if rb.IsSingleShard() {
+		return false
+	}
+
+	for _, fn := range funcs {
+		spec := getOverClause(fn).WindowSpec
+		if spec == nil || !slices.ContainsFunc(spec.PartitionClause, func(e sqlparser.Expr) bool {
+			return exprHasUniqueVindex(ctx, e)
+		}) {
+			return true
+		}
+	}
+	return false
+}
+
+// createWindow builds the Window operator used to evaluate the window functions of the query on the vtgate.
+// All the window functions have to share the same window specification, since we can only sort the input in one way
+func createWindow(qp *QueryProjection, sel *sqlparser.Select, src ops.Operator) (*Window, error) {
+	funcs := windowFunctions(sel)
+
+	var spec *sqlparser.WindowSpecification
+	w := &Window{}
+	for _, fn := range funcs {
+		over := getOverClause(fn)
+		if !over.WindowName.IsEmpty() || over.WindowSpec == nil || !over.WindowSpec.Name.IsEmpty() {
+			return nil, vterrors.VT12001(fmt.Sprintf("named window in cross-shard window function: %s", sqlparser.String(fn)))
+		}
+		if over.WindowSpec.FrameClause != nil {
+			return nil, vterrors.VT12001(fmt.Sprintf("frame clause in cross-shard window function: %s", sqlparser.String(fn)))
+		}
+		if spec == nil {
+			spec = over.WindowSpec
+		} else if !sqlparser.Equals.RefOfWindowSpecification(spec, over.WindowSpec) {
+			return nil, vterrors.VT12001(fmt.Sprintf("cross-shard window functions with different window specifications: %s", sqlparser.String(fn)))
+		}
+
+		windowFunc, err := createWindowFunc(fn)
+		if err != nil {
+			return nil, err
+		}
+		windowFunc.Original = aeWrap(fn)
+		for _, selExpr := range qp.SelectExprs {
+			ae, err := selExpr.GetAliasedExpr()
+			if err == nil && ae.Expr == fn {
+				windowFunc.Original = ae
+			}
+		}
+		w.Functions = append(w.Functions, windowFunc)
+	}
+
+	var ordering []ops.OrderBy
+	for _, expr := range spec.PartitionClause {
+		w.PartitionBy = append(w.PartitionBy, NewGroupBy(expr, expr, nil))
+		ordering = append(ordering, ops.OrderBy{
+			Inner:          &sqlparser.Order{Expr: expr, Direction: sqlparser.AscOrder},
+			SimplifiedExpr: expr,
+		})
+	}
+	for _, order := range spec.OrderClause {
+		if sqlparser.IsNull(order.Expr) {
+			continue
+		}
+		orderBy := ops.OrderBy{
+			Inner:          order,
+			SimplifiedExpr: order.Expr,
+		}
+		w.Order = append(w.Order, orderBy)
+		ordering = append(ordering, orderBy)
+	}
+
+	w.Source = src
+	if len(ordering) > 0 {
+		w.Source = &Ordering{
+			Source: src,
+			Order:  ordering,
+		}
+	}
+
+	return w, nil
+}
+
+func createWindowFunc(fn sqlparser.Expr) (WindowFunc, error) {
+	switch fn := fn.(type) {
+	case *sqlparser.ArgumentLessWindowExpr:
+		var code opcode.WindowOpcode
+		switch fn.Type {
+		case sqlparser.RowNumberExprType:
+			code = opcode.WindowRowNumber
+		case sqlparser.RankExprType:
+			code = opcode.WindowRank
+		case sqlparser.DenseRankExprType:
+			code = opcode.WindowDenseRank
+		case sqlparser.PercentRankExprType:
+			code = opcode.WindowPercentRank
+		case sqlparser.CumeDistExprType:
+			code = opcode.WindowCumeDist
+		}
+		return WindowFunc{OpCode: code}, nil
+	case *sqlparser.LagLeadExpr:
+		if fn.NullTreatmentClause != nil && fn.NullTreatmentClause.Type == sqlparser.IgnoreNullsType {
+			break
+		}
+		windowFunc := WindowFunc{OpCode: opcode.WindowLag, Arg: fn.Expr, N: 1, Default: fn.Default}
+		if fn.Type == sqlparser.LeadExprType {
+			windowFunc.OpCode = opcode.WindowLead
+		}
+		if fn.N != nil {
+			lit, ok := fn.N.(*sqlparser.Literal)
+			if !ok || lit.Type != sqlparser.IntVal {
+				break
+			}
+			n, err := strconv.Atoi(lit.Val)
+			if err != nil {
+				break
+			}
+			windowFunc.N = n
+		}
+		return windowFunc, nil
+	case *sqlparser.FirstOrLastValueExpr:
+		if fn.Type != sqlparser.FirstValueExprType ||
+			fn.NullTreatmentClause != nil && fn.NullTreatmentClause.Type == sqlparser.IgnoreNullsType {
+			break
+		}
+		return WindowFunc{OpCode: opcode.WindowFirstValue, Arg: fn.Expr}, nil
+	}
+	return WindowFunc{}, vterrors.VT12001(fmt.Sprintf("in scatter query: window function '%s'", sqlparser.String(fn)))
+}
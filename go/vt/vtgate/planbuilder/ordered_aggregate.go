@@ -241,6 +241,9 @@ func (oa *orderedAggregate) pushAggr(pb *primitiveBuilder, expr *sqlparser.Alias
 	aggrFunc, _ := expr.Expr.(sqlparser.AggrFunc)
 	origOpcode := popcode.SupportedAggregates[strings.ToLower(aggrFunc.AggrName())]
 	opcode := origOpcode
+	if opcode.NeedsPartialResults() {
+		return nil, 0, vterrors.VT12001(fmt.Sprintf("in scatter query: aggregation function '%s'", sqlparser.String(expr)))
+	}
 	if aggrFunc.GetArgs() != nil &&
 		len(aggrFunc.GetArgs()) != 1 {
 		return nil, 0, vterrors.VT12001(fmt.Sprintf("only one expression is allowed inside aggregates: %s", sqlparser.String(expr)))
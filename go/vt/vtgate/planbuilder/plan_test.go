@@ -291,6 +291,7 @@ func TestPlan(t *testing.T) {
 	testFile(t, "reference_cases.json", testOutputTempDir, vschemaWrapper, false)
 	testFile(t, "vexplain_cases.json", testOutputTempDir, vschemaWrapper, false)
 	testFile(t, "misc_cases.json", testOutputTempDir, vschemaWrapper, false)
+	testFile(t, "cte_cases.json", testOutputTempDir, vschemaWrapper, false)
 }
 
 func TestSystemTables57(t *testing.T) {
@@ -320,6 +321,25 @@ func TestViews(t *testing.T) {
 	testFile(t, "view_cases.json", makeTestOutput(t), vschemaWrapper, false)
 }
 
+func TestQueryDigestsTableShadowing(t *testing.T) {
+	srvVSchema := &vschemapb.SrvVSchema{
+		Keyspaces: map[string]*vschemapb.Keyspace{"main": {}},
+	}
+	query := "select * from vitess_query_digests"
+
+	// without a table of the same name, the query reads the query digests of the vtgate.
+	plan, err := TestBuilder(query, &vschemaWrapper{v: vindexes.BuildVSchema(srvVSchema)}, "main")
+	require.NoError(t, err)
+	require.IsType(t, &engine.ShowExec{}, plan.Instructions)
+
+	// a table of the vschema takes precedence over the virtual table.
+	srvVSchema.Keyspaces["main"].Tables = map[string]*vschemapb.Table{"vitess_query_digests": {}}
+	plan, err = TestBuilder(query, &vschemaWrapper{v: vindexes.BuildVSchema(srvVSchema)}, "main")
+	require.NoError(t, err)
+	require.IsType(t, &engine.Route{}, plan.Instructions)
+	require.Equal(t, "main", plan.Instructions.(*engine.Route).Keyspace.Name)
+}
+
 func TestOne(t *testing.T) {
 	reset := oprewriters.EnableDebugPrinting()
 	defer reset()
@@ -598,6 +618,21 @@ func loadSchema(t testing.TB, filename string, setCollation bool) *vindexes.VSch
 			}
 		}
 
+		// adding the primary keys of the user and user_extra tables, as the schema tracker would.
+		if tbl := ks.Tables["user"]; ks.Keyspace.Name == "user" && tbl != nil {
+			tbl.UniqueKeys = [][]sqlparser.IdentifierCI{{sqlparser.NewIdentifierCI("id")}}
+			tbl.PrimaryKey = []sqlparser.IdentifierCI{sqlparser.NewIdentifierCI("id")}
+		}
+		if tbl := ks.Tables["user_extra"]; ks.Keyspace.Name == "user" && tbl != nil {
+			tbl.UniqueKeys = [][]sqlparser.IdentifierCI{{sqlparser.NewIdentifierCI("id")}}
+			tbl.PrimaryKey = []sqlparser.IdentifierCI{sqlparser.NewIdentifierCI("id")}
+		}
+		// the multicolvin table also has a unique key on a column that is not a vindex column.
+		if tbl := ks.Tables["multicolvin"]; ks.Keyspace.Name == "user" && tbl != nil {
+			tbl.UniqueKeys = [][]sqlparser.IdentifierCI{{sqlparser.NewIdentifierCI("kid")}, {sqlparser.NewIdentifierCI("col")}}
+			tbl.PrimaryKey = []sqlparser.IdentifierCI{sqlparser.NewIdentifierCI("kid")}
+		}
+
 		// setting a default value to all the text columns in the tables of this keyspace
 		// so that we can "simulate" a real case scenario where the vschema is aware of
 		// columns' collations.
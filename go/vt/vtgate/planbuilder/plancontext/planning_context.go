@@ -43,6 +43,10 @@ type PlanningContext struct {
 	// DelegateAggregation tells us when we are allowed to split an aggregation across vtgate and mysql
 	// We aggregate within a shard, and then at the vtgate level we aggregate the incoming shard aggregates
 	DelegateAggregation bool
+
+	// AllowHashJoin is set when the query uses the ALLOW_HASH_JOIN directive, and lets
+	// the planner use hash joins instead of nested loop joins between routes
+	AllowHashJoin bool
 }
 
 func NewPlanningContext(reservedVars *sqlparser.ReservedVars, semTable *semantics.SemTable, vschema VSchema, version querypb.ExecuteOptions_PlannerVersion) *PlanningContext {
@@ -0,0 +1,201 @@
+/*
+Copyright 2023 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package planbuilder
+
+import (
+	"vitess.io/vitess/go/mysql/collations"
+	"vitess.io/vitess/go/vt/sqlparser"
+	"vitess.io/vitess/go/vt/vterrors"
+	"vitess.io/vitess/go/vt/vtgate/engine"
+	"vitess.io/vitess/go/vt/vtgate/evalengine"
+	"vitess.io/vitess/go/vt/vtgate/planbuilder/plancontext"
+)
+
+// queryDigestsTable is the name of the virtual table holding the statistics of
+// the query digests of the vtgate, the same rows as SHOW VITESS_QUERY_DIGESTS.
+const queryDigestsTable = "vitess_query_digests"
+
+// isQueryDigestsSelect returns true if the query reads from the query digests
+// virtual table only. The table is not part of any keyspace, so it can't be qualified,
+// and a table of the same name in the vschema takes precedence over it.
+func isQueryDigestsSelect(sel *sqlparser.Select, vschema plancontext.VSchema) bool {
+	if len(sel.From) != 1 {
+		return false
+	}
+	table, ok := sel.From[0].(*sqlparser.AliasedTableExpr)
+	if !ok {
+		return false
+	}
+	tableName, ok := table.Expr.(sqlparser.TableName)
+	if !ok || !tableName.Qualifier.IsEmpty() || tableName.Name.String() != queryDigestsTable {
+		return false
+	}
+	return !isVSchemaTable(vschema, tableName)
+}
+
+// isVSchemaTable returns true if the table is listed in the vschema, or was found
+// by the schema tracker. The tables of unsharded keyspaces that are not listed
+// are not taken into account, since any name resolves to one of them.
+func isVSchemaTable(vschema plancontext.VSchema, tableName sqlparser.TableName) bool {
+	table, _, _, _, err := vschema.FindTable(tableName)
+	if err != nil || table == nil || table.Keyspace == nil {
+		return false
+	}
+	ks := vschema.GetVSchema().Keyspaces[table.Keyspace.Name]
+	return ks != nil && ks.Tables[table.Name.String()] != nil
+}
+
+// handleQueryDigestsSelect plans a query on the query digests virtual table.
+// The digests are filtered, sorted, limited and projected at the vtgate.
+func handleQueryDigestsSelect(sel *sqlparser.Select, vschema plancontext.VSchema) (engine.Primitive, error) {
+	if !isQueryDigestsSelect(sel, vschema) {
+		return nil, nil
+	}
+	if sel.Distinct || sel.GroupBy != nil || sel.Having != nil || sel.Into != nil || sel.Windows != nil {
+		return nil, vterrors.VT12001("DISTINCT, GROUP BY, HAVING, WINDOW or INTO on the " + queryDigestsTable + " table")
+	}
+
+	table := sel.From[0].(*sqlparser.AliasedTableExpr)
+	tableName := table.As
+	if tableName.IsEmpty() {
+		tableName = sqlparser.NewIdentifierCS(queryDigestsTable)
+	}
+	resolveColumn := func(col *sqlparser.ColName) (int, error) {
+		if col.Qualifier.IsEmpty() || (col.Qualifier.Qualifier.IsEmpty() && col.Qualifier.Name.String() == tableName.String()) {
+			for i, field := range engine.QueryDigestsFields {
+				if col.Name.EqualString(field.Name) {
+					return i, nil
+				}
+			}
+		}
+		return 0, vterrors.VT03019(sqlparser.String(col))
+	}
+	cfg := &evalengine.Config{
+		ResolveColumn: resolveColumn,
+		Collation:     vschema.ConnCollation(),
+	}
+
+	var prim engine.Primitive = &engine.ShowExec{Command: sqlparser.VitessQueryDigests}
+
+	if sel.Where != nil {
+		predicate, err := evalengine.Translate(sel.Where.Expr, cfg)
+		if err != nil {
+			return nil, err
+		}
+		prim = &engine.Filter{
+			Predicate:    predicate,
+			ASTPredicate: sel.Where.Expr,
+			Input:        prim,
+		}
+	}
+
+	if len(sel.OrderBy) > 0 {
+		ms := &engine.MemorySort{Input: prim}
+		for _, order := range sel.OrderBy {
+			col, err := queryDigestsOrderColumn(sel, order)
+			if err != nil {
+				return nil, err
+			}
+			offset, err := resolveColumn(col)
+			if err != nil {
+				return nil, err
+			}
+			ms.OrderBy = append(ms.OrderBy, engine.OrderByParams{
+				Col:               offset,
+				WeightStringCol:   -1,
+				Desc:              order.Direction == sqlparser.DescOrder,
+				StarColFixedIndex: offset,
+				CollationID:       collations.ID(engine.QueryDigestsFields[offset].Charset),
+			})
+		}
+		prim = ms
+	}
+
+	if sel.Limit != nil {
+		limit := &engine.Limit{Input: prim}
+		var err error
+		if limit.Count, err = evalengine.Translate(sel.Limit.Rowcount, nil); err != nil {
+			return nil, vterrors.Wrap(err, "unexpected expression in LIMIT")
+		}
+		if sel.Limit.Offset != nil {
+			if limit.Offset, err = evalengine.Translate(sel.Limit.Offset, nil); err != nil {
+				return nil, vterrors.Wrap(err, "unexpected expression in OFFSET")
+			}
+		}
+		prim = limit
+	}
+
+	if len(sel.SelectExprs) == 1 {
+		if star, ok := sel.SelectExprs[0].(*sqlparser.StarExpr); ok && star.TableName.IsEmpty() {
+			return prim, nil
+		}
+	}
+	projection := &engine.Projection{Input: prim}
+	for _, e := range sel.SelectExprs {
+		switch e := e.(type) {
+		case *sqlparser.StarExpr:
+			if !e.TableName.IsEmpty() && !(e.TableName.Qualifier.IsEmpty() && e.TableName.Name.String() == tableName.String()) {
+				return nil, vterrors.VT05004(sqlparser.String(e.TableName))
+			}
+			for i, field := range engine.QueryDigestsFields {
+				projection.Exprs = append(projection.Exprs, evalengine.NewColumnWithCollation(i, collations.TypedCollation{
+					Collation:    collations.ID(field.Charset),
+					Coercibility: collations.CoerceImplicit,
+					Repertoire:   collations.RepertoireUnicode,
+				}))
+				projection.Cols = append(projection.Cols, field.Name)
+			}
+		case *sqlparser.AliasedExpr:
+			expr, err := evalengine.Translate(e.Expr, cfg)
+			if err != nil {
+				return nil, err
+			}
+			projection.Exprs = append(projection.Exprs, expr)
+			col := e.As.String()
+			if col == "" {
+				col = sqlparser.String(e.Expr)
+			}
+			projection.Cols = append(projection.Cols, col)
+		default:
+			return nil, vterrors.VT12001(sqlparser.String(e) + " on the " + queryDigestsTable + " table")
+		}
+	}
+	return projection, nil
+}
+
+// queryDigestsOrderColumn returns the column of the query digests table an
+// ORDER BY expression sorts on. The expression can be a column of the table,
+// or the alias of a selected column.
+func queryDigestsOrderColumn(sel *sqlparser.Select, order *sqlparser.Order) (*sqlparser.ColName, error) {
+	col, ok := order.Expr.(*sqlparser.ColName)
+	if !ok {
+		return nil, vterrors.VT12001("ORDER BY on an expression of the " + queryDigestsTable + " table: " + sqlparser.String(order.Expr))
+	}
+	if col.Qualifier.IsEmpty() {
+		for _, e := range sel.SelectExprs {
+			ae, ok := e.(*sqlparser.AliasedExpr)
+			if !ok || !ae.As.Equal(col.Name) {
+				continue
+			}
+			if aliased, ok := ae.Expr.(*sqlparser.ColName); ok {
+				return aliased, nil
+			}
+			return nil, vterrors.VT12001("ORDER BY on an expression of the " + queryDigestsTable + " table: " + sqlparser.String(ae.Expr))
+		}
+	}
+	return col, nil
+}
@@ -0,0 +1,50 @@
+/*
+Copyright 2023 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package planbuilder
+
+import (
+	"vitess.io/vitess/go/vt/key"
+	topodatapb "vitess.io/vitess/go/vt/proto/topodata"
+	"vitess.io/vitess/go/vt/sqlparser"
+	"vitess.io/vitess/go/vt/vterrors"
+	"vitess.io/vitess/go/vt/vtgate/engine"
+	"vitess.io/vitess/go/vt/vtgate/planbuilder/plancontext"
+)
+
+// buildRequeuePlan sends the REQUEUE statement to every shard of the message
+// table. Each tablet puts its own dead letters back in circulation.
+func buildRequeuePlan(stmt *sqlparser.Requeue, vschema plancontext.VSchema) (*planResult, error) {
+	table, _, destTabletType, dest, err := vschema.FindTable(stmt.Table)
+	if err != nil {
+		return nil, err
+	}
+	if destTabletType != topodatapb.TabletType_PRIMARY {
+		return nil, vterrors.VT09012("REQUEUE", destTabletType.String())
+	}
+	if dest == nil {
+		dest = key.DestinationAllShards{}
+	}
+
+	stmt.Table = sqlparser.TableName{Name: table.Name}
+
+	return newPlanResult(&engine.Send{
+		Keyspace:          table.Keyspace,
+		TargetDestination: dest,
+		Query:             sqlparser.String(stmt),
+		IsDML:             true,
+	}, singleTable(table.Keyspace.Name, table.Name.String())), nil
+}
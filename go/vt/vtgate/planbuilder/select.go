@@ -52,6 +52,14 @@ func buildSelectPlan(query string) stmtPlanner {
 			return newPlanResult(p), nil
 		}
 
+		p, err = handleQueryDigestsSelect(sel, vschema)
+		if err != nil {
+			return nil, err
+		}
+		if p != nil {
+			return newPlanResult(p), nil
+		}
+
 		getPlan := func(sel *sqlparser.Select) (logicalPlan, error) {
 			pb := newPrimitiveBuilder(vschema, newJointab(reservedVars))
 			if err := pb.processSelect(sel, reservedVars, nil, query); err != nil {
@@ -386,6 +394,10 @@ func handleDualSelects(sel *sqlparser.Select, vschema plancontext.VSchema) (engi
 		if len(lockFunctions) > 0 {
 			return nil, vterrors.VT12001(fmt.Sprintf("LOCK function and other expression: [%s] in same select query", sqlparser.String(expr)))
 		}
+		if dependsOnSQLMode(expr.Expr) {
+			// the tablet knows its sql_mode, even when the session hasn't set one
+			return nil, nil
+		}
 		exprs[i], err = evalengine.Translate(expr.Expr, &evalengine.Config{Collation: vschema.ConnCollation()})
 		if err != nil {
 			return nil, nil
@@ -405,6 +417,20 @@ func handleDualSelects(sel *sqlparser.Select, vschema plancontext.VSchema) (engi
 	}, nil
 }
 
+// dependsOnSQLMode returns true if the result of the expression depends on the sql_mode.
+// The vtgate only knows the sql_mode the session has set, so these expressions are
+// sent to a tablet when they can be.
+func dependsOnSQLMode(expr sqlparser.Expr) bool {
+	found := false
+	_ = sqlparser.Walk(func(node sqlparser.SQLNode) (bool, error) {
+		if fn, ok := node.(*sqlparser.FuncExpr); ok && fn.Name.EqualString("str_to_date") {
+			found = true
+		}
+		return !found, nil
+	}, expr)
+	return found
+}
+
 func buildLockingPrimitive(sel *sqlparser.Select, vschema plancontext.VSchema, lockFunctions []*engine.LockFunc) (engine.Primitive, error) {
 	ks, err := vschema.FirstSortedKeyspace()
 	if err != nil {
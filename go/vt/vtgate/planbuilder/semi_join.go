@@ -37,6 +37,11 @@ type semiJoin struct {
 
 	vars map[string]int
 
+	// keys and listVars are set when the rhs is evaluated once per batch
+	// of lhs rows, with the values of the lhs keys bound as lists
+	keys     []engine.HashJoinKey
+	listVars []string
+
 	// LHSColumns are the columns from the LHS used for the join.
 	// These are the same columns pushed on the LHS that are now used in the vars field
 	LHSColumns []*sqlparser.ColName
@@ -55,10 +60,12 @@ func newSemiJoin(lhs, rhs logicalPlan, vars map[string]int, lhsCols []*sqlparser
 // Primitive implements the logicalPlan interface
 func (ps *semiJoin) Primitive() engine.Primitive {
 	return &engine.SemiJoin{
-		Left:  ps.lhs.Primitive(),
-		Right: ps.rhs.Primitive(),
-		Vars:  ps.vars,
-		Cols:  ps.cols,
+		Left:     ps.lhs.Primitive(),
+		Right:    ps.rhs.Primitive(),
+		Vars:     ps.vars,
+		Cols:     ps.cols,
+		Keys:     ps.keys,
+		ListVars: ps.listVars,
 	}
 }
 
@@ -59,7 +59,17 @@ func transformCorrelatedSubQueryPlan(ctx *plancontext.PlanningContext, op *opera
 	if err != nil {
 		return nil, err
 	}
-	return newSemiJoin(outer, inner, op.Vars, op.LHSColumns), nil
+	sj := newSemiJoin(outer, inner, op.Vars, op.LHSColumns)
+	for i, cmp := range op.Comparisons {
+		sj.keys = append(sj.keys, engine.HashJoinKey{
+			LHS:            op.LHSKeys[i],
+			RHS:            op.RHSKeys[i],
+			Collation:      cmp.Collation,
+			ComparisonType: cmp.ComparisonType,
+		})
+	}
+	sj.listVars = op.ListVars
+	return sj, nil
 }
 
 func mergeSubQueryOpPlan(ctx *plancontext.PlanningContext, inner, outer logicalPlan, n *operators.SubQueryOp) logicalPlan {
@@ -39,9 +39,11 @@ import (
 	"vitess.io/vitess/go/vt/callinfo"
 	"vitess.io/vitess/go/vt/log"
 	querypb "vitess.io/vitess/go/vt/proto/query"
+	topodatapb "vitess.io/vitess/go/vt/proto/topodata"
 	vtgatepb "vitess.io/vitess/go/vt/proto/vtgate"
 	"vitess.io/vitess/go/vt/servenv"
 	"vitess.io/vitess/go/vt/sqlparser"
+	"vitess.io/vitess/go/vt/topo/topoproto"
 	"vitess.io/vitess/go/vt/vterrors"
 	"vitess.io/vitess/go/vt/vttls"
 )
@@ -69,6 +71,8 @@ var (
 	mysqlSlowConnectWarnThreshold time.Duration
 	mysqlConnBufferPooling        bool
 
+	mysqlServerCompressionAlgorithms []string
+
 	mysqlDefaultWorkloadName = "OLTP"
 	mysqlDefaultWorkload     int32
 
@@ -96,6 +100,7 @@ func registerPluginFlags(fs *pflag.FlagSet) {
 	fs.DurationVar(&mysqlQueryTimeout, "mysql_server_query_timeout", mysqlQueryTimeout, "mysql query timeout")
 	fs.BoolVar(&mysqlConnBufferPooling, "mysql-server-pool-conn-read-buffers", mysqlConnBufferPooling, "If set, the server will pool incoming connection read buffers")
 	fs.DurationVar(&mysqlKeepAlivePeriod, "mysql-server-keepalive-period", mysqlKeepAlivePeriod, "TCP period between keep-alives")
+	fs.StringSliceVar(&mysqlServerCompressionAlgorithms, "mysql-server-compression-algorithms", mysqlServerCompressionAlgorithms, "Protocol compression algorithms the server supports on TCP connections, clients asking for one of them get compressed connections. Options: zlib, zstd.")
 	fs.StringVar(&mysqlDefaultWorkloadName, "mysql_default_workload", mysqlDefaultWorkloadName, "Default session workload (OLTP, OLAP, DBA)")
 }
 
@@ -140,6 +145,14 @@ func (vh *vtgateHandler) ComResetConnection(c *mysql.Conn) {
 	}
 }
 
+// ComChangeUser releases everything held by the session of the previous
+// user, and starts over with a new session. The immediate caller ID is
+// built from c.User and c.UserData for each query, so it follows the new user.
+func (vh *vtgateHandler) ComChangeUser(c *mysql.Conn) {
+	vh.ComResetConnection(c)
+	c.ClientData = nil
+}
+
 func (vh *vtgateHandler) ConnectionClosed(c *mysql.Conn) {
 	// Rollback if there is an ongoing transaction. Ignore error.
 	defer func() {
@@ -227,6 +240,7 @@ func (vh *vtgateHandler) ComQuery(c *mysql.Conn, query string, callback func(*sq
 	ctx = callerid.NewContext(ctx, ef, im)
 
 	session := vh.session(c)
+	session.Options.QueryAttributes = c.QueryAttributes
 	if !session.InTransaction {
 		atomic.AddInt32(&busyConnections, 1)
 	}
@@ -244,12 +258,14 @@ func (vh *vtgateHandler) ComQuery(c *mysql.Conn, query string, callback func(*sq
 		fillInTxStatusFlags(c, session)
 		return nil
 	}
+	before := newTrackedSessionState(session)
 	session, result, err := vh.vtg.Execute(ctx, vh, session, query, make(map[string]*querypb.BindVariable))
 
 	if err := mysql.NewSQLErrorFromError(err); err != nil {
 		return err
 	}
 	fillInTxStatusFlags(c, session)
+	fillInSessionStateChanges(c, before, session)
 	return callback(result)
 }
 
@@ -266,6 +282,76 @@ func fillInTxStatusFlags(c *mysql.Conn, session *vtgatepb.Session) {
 	}
 }
 
+// trackedSessionState is the part of the session state whose changes are
+// reported to the clients tracking them. The GTIDs of the committed
+// transactions are reported by the tablets in the results.
+type trackedSessionState struct {
+	targetString    string
+	autocommit      bool
+	systemVariables map[string]string
+}
+
+func newTrackedSessionState(session *vtgatepb.Session) trackedSessionState {
+	state := trackedSessionState{
+		targetString: session.TargetString,
+		autocommit:   session.Autocommit,
+	}
+	if len(session.SystemVariables) > 0 {
+		state.systemVariables = make(map[string]string, len(session.SystemVariables))
+		for name, expr := range session.SystemVariables {
+			state.systemVariables[name] = expr
+		}
+	}
+	return state
+}
+
+// fillInSessionStateChanges reports the changes of the session state since
+// before to the client, if it tracks them.
+func fillInSessionStateChanges(c *mysql.Conn, before trackedSessionState, session *vtgatepb.Session) {
+	if c.Capabilities&mysql.CapabilityClientSessionTrack == 0 {
+		return
+	}
+	changes := &c.SessionStateChanges
+	if session.TargetString != before.targetString {
+		keyspace, _, _, err := topoproto.ParseDestination(session.TargetString, topodatapb.TabletType_PRIMARY)
+		if err == nil {
+			changes.Schema = keyspace
+		}
+	}
+	setSystemVariable := func(name, value string) {
+		if changes.SystemVariables == nil {
+			changes.SystemVariables = make(map[string]string)
+		}
+		changes.SystemVariables[name] = value
+	}
+	for name, expr := range session.SystemVariables {
+		if old, ok := before.systemVariables[name]; ok && old == expr {
+			continue
+		}
+		setSystemVariable(name, systemVariableValue(expr))
+	}
+	if session.Autocommit != before.autocommit {
+		value := "OFF"
+		if session.Autocommit {
+			value = "ON"
+		}
+		setSystemVariable("autocommit", value)
+	}
+}
+
+// systemVariableValue returns the value of a system variable stored in the
+// session as an expression.
+func systemVariableValue(expr string) string {
+	e, err := sqlparser.ParseExpr(expr)
+	if err != nil {
+		return expr
+	}
+	if lit, ok := e.(*sqlparser.Literal); ok {
+		return lit.Val
+	}
+	return sqlparser.String(e)
+}
+
 // ComPrepare is the handler for command prepare.
 func (vh *vtgateHandler) ComPrepare(c *mysql.Conn, query string, bindVars map[string]*querypb.BindVariable) ([]*querypb.Field, error) {
 	var ctx context.Context
@@ -333,6 +419,7 @@ func (vh *vtgateHandler) ComStmtExecute(c *mysql.Conn, prepare *mysql.PrepareDat
 	ctx = callerid.NewContext(ctx, ef, im)
 
 	session := vh.session(c)
+	session.Options.QueryAttributes = c.QueryAttributes
 	if !session.InTransaction {
 		atomic.AddInt32(&busyConnections, 1)
 	}
@@ -350,11 +437,13 @@ func (vh *vtgateHandler) ComStmtExecute(c *mysql.Conn, prepare *mysql.PrepareDat
 		fillInTxStatusFlags(c, session)
 		return nil
 	}
+	before := newTrackedSessionState(session)
 	_, qr, err := vh.vtg.Execute(ctx, vh, session, prepare.PrepareStmt, prepare.BindVars)
 	if err != nil {
 		return mysql.NewSQLErrorFromError(err)
 	}
 	fillInTxStatusFlags(c, session)
+	fillInSessionStateChanges(c, before, session)
 
 	return callback(qr)
 }
@@ -495,6 +584,12 @@ func initMySQLProtocol() {
 		log.Exitf("-mysql_tcp_version must be one of [tcp, tcp4, tcp6]")
 	}
 
+	for _, algorithm := range mysqlServerCompressionAlgorithms {
+		if err := mysql.ValidCompressionAlgorithm(algorithm); err != nil {
+			log.Exitf("-mysql-server-compression-algorithms: %v", err)
+		}
+	}
+
 	// Create a Listener.
 	var err error
 	vtgateHandle = newVtgateHandler(rpcVTGate)
@@ -523,6 +618,7 @@ func initMySQLProtocol() {
 			_ = initTLSConfig(mysqlListener, mysqlSslCert, mysqlSslKey, mysqlSslCa, mysqlSslCrl, mysqlSslServerCA, mysqlServerRequireSecureTransport, tlsVersion)
 		}
 		mysqlListener.AllowClearTextWithoutTLS.Store(mysqlAllowClearTextWithoutTLS)
+		mysqlListener.CompressionAlgorithms = mysqlServerCompressionAlgorithms
 		// Check for the connection threshold
 		if mysqlSlowConnectWarnThreshold != 0 {
 			log.Infof("setting mysql slow connection threshold to %v", mysqlSlowConnectWarnThreshold)
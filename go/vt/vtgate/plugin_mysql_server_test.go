@@ -34,6 +34,7 @@ import (
 	"vitess.io/vitess/go/sqltypes"
 	"vitess.io/vitess/go/trace"
 	querypb "vitess.io/vitess/go/vt/proto/query"
+	vtgatepb "vitess.io/vitess/go/vt/proto/vtgate"
 	"vitess.io/vitess/go/vt/tlstest"
 )
 
@@ -335,3 +336,52 @@ func TestKillMethods(t *testing.T) {
 	require.EqualError(t, ctx.Err(), "context canceled")
 	require.True(t, mysqlConn.IsMarkedForClose())
 }
+
+func TestComChangeUser(t *testing.T) {
+	executor, _, _, _ := createExecutorEnv()
+	vh := newVtgateHandler(&VTGate{executor: executor})
+
+	mysqlConn := mysql.GetTestConn()
+	session := vh.session(mysqlConn)
+	session.TargetString = "TestExecutor"
+	session.SystemVariables = map[string]string{"sql_mode": "''"}
+
+	// The new user gets a brand new session.
+	vh.ComChangeUser(mysqlConn)
+	newSession := vh.session(mysqlConn)
+	assert.NotSame(t, session, newSession)
+	assert.NotEqual(t, session.SessionUUID, newSession.SessionUUID)
+	assert.Empty(t, newSession.TargetString)
+	assert.Empty(t, newSession.SystemVariables)
+	assert.True(t, newSession.Autocommit)
+}
+
+func TestFillInSessionStateChanges(t *testing.T) {
+	session := &vtgatepb.Session{
+		TargetString:    "ks",
+		Autocommit:      true,
+		SystemVariables: map[string]string{"sql_mode": "''", "time_zone": "'+00:00'"},
+	}
+	before := newTrackedSessionState(session)
+
+	session.TargetString = "TestExecutor@replica"
+	session.Autocommit = false
+	session.SystemVariables["sql_mode"] = "'ANSI_QUOTES'"
+	session.SystemVariables["sql_safe_updates"] = "1"
+
+	// The changes are only reported to clients tracking them.
+	mysqlConn := mysql.GetTestConn()
+	fillInSessionStateChanges(mysqlConn, before, session)
+	assert.True(t, mysqlConn.SessionStateChanges.IsEmpty())
+
+	mysqlConn.Capabilities |= mysql.CapabilityClientSessionTrack
+	fillInSessionStateChanges(mysqlConn, before, session)
+	assert.Equal(t, mysql.SessionStateChanges{
+		Schema: "TestExecutor",
+		SystemVariables: map[string]string{
+			"autocommit":       "OFF",
+			"sql_mode":         "ANSI_QUOTES",
+			"sql_safe_updates": "1",
+		},
+	}, mysqlConn.SessionStateChanges)
+}
@@ -0,0 +1,355 @@
+/*
+Copyright 2023 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vtgate
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"vitess.io/vitess/go/sqltypes"
+	"vitess.io/vitess/go/stats"
+	"vitess.io/vitess/go/vt/sqlparser"
+	"vitess.io/vitess/go/vt/vterrors"
+	"vitess.io/vitess/go/vt/vtgate/engine"
+	"vitess.io/vitess/go/vt/vtgate/evalengine"
+	"vitess.io/vitess/go/vt/vtgate/logstats"
+
+	vtrpcpb "vitess.io/vitess/go/vt/proto/vtrpc"
+)
+
+const pathQueryDigests = "/debug/query_digests"
+
+// digestLatencyCutoffs are the upper bounds of the buckets of the latency
+// histograms the percentiles are estimated from. They start at 10µs and
+// double up to about 3 minutes.
+var digestLatencyCutoffs = func() []time.Duration {
+	cutoffs := make([]time.Duration, 25)
+	for i := range cutoffs {
+		cutoffs[i] = 10 * time.Microsecond << i
+	}
+	return cutoffs
+}()
+
+var queryDigestsLost = stats.NewCounter("QueryDigestsLost", "Number of queries accounted in the overflow query digest, because the query digests were full")
+
+// queryDigests aggregates the execution statistics of the queries by
+// keyspace and digest, that is by query shape. Unlike the statistics of the
+// plans, they survive the evictions from the plan cache.
+// Once maxSize digests are tracked, the queries of new digests are all
+// accounted in an overflow digest with an empty digest text.
+type queryDigests struct {
+	maxSize int
+
+	mu      sync.RWMutex
+	digests map[queryDigestKey]*queryDigest
+}
+
+type queryDigestKey struct {
+	keyspace   string
+	digestText string
+}
+
+// queryDigest holds the statistics of the queries of a digest.
+type queryDigest struct {
+	keyspace   string
+	digest     string
+	digestText string
+
+	mu              sync.Mutex
+	count           uint64
+	errors          uint64
+	rowsAffected    uint64
+	rowsReturned    uint64
+	shardQueries    uint64
+	maxShardQueries uint64
+	totalTime       time.Duration
+	minTime         time.Duration
+	maxTime         time.Duration
+	latencies       []uint64
+	firstSeen       time.Time
+	lastSeen        time.Time
+}
+
+// queryDigestStats is a snapshot of the statistics of a digest.
+type queryDigestStats struct {
+	Keyspace        string
+	Digest          string
+	DigestText      string
+	Count           uint64
+	Errors          uint64
+	RowsAffected    uint64
+	RowsReturned    uint64
+	ShardQueries    uint64
+	MaxShardQueries uint64
+	TotalTime       time.Duration
+	MinTime         time.Duration
+	MaxTime         time.Duration
+	P50Time         time.Duration
+	P95Time         time.Duration
+	P99Time         time.Duration
+	FirstSeen       time.Time
+	LastSeen        time.Time
+}
+
+// newQueryDigests returns a queryDigests tracking at most maxSize digests,
+// or nil if maxSize is not positive, which disables the query digests.
+func newQueryDigests(maxSize int) *queryDigests {
+	if maxSize <= 0 {
+		return nil
+	}
+	return &queryDigests{
+		maxSize: maxSize,
+		digests: make(map[queryDigestKey]*queryDigest),
+	}
+}
+
+// queryDigestHash returns the hash of a digest text, which identifies the
+// digest across vtgates.
+func queryDigestHash(digestText string) string {
+	hash := sha256.Sum256([]byte(digestText))
+	return hex.EncodeToString(hash[:])
+}
+
+// record accounts a query execution in the statistics of its digest.
+// Queries that were not planned have no digest and are ignored.
+func (qd *queryDigests) record(logStats *logstats.LogStats, rowsAffected, rowsReturned uint64) {
+	if qd == nil || logStats.QueryDigest == "" {
+		return
+	}
+	d := qd.getOrCreate(queryDigestKey{keyspace: logStats.ActiveKeyspace, digestText: logStats.QueryDigest})
+	latency := logStats.TotalTime()
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.count == 0 || latency < d.minTime {
+		d.minTime = latency
+	}
+	if latency > d.maxTime {
+		d.maxTime = latency
+	}
+	if d.count == 0 {
+		d.firstSeen = logStats.StartTime
+	}
+	d.lastSeen = logStats.StartTime
+	d.count++
+	if logStats.Error != nil {
+		d.errors++
+	}
+	d.rowsAffected += rowsAffected
+	d.rowsReturned += rowsReturned
+	d.shardQueries += logStats.ShardQueries
+	if logStats.ShardQueries > d.maxShardQueries {
+		d.maxShardQueries = logStats.ShardQueries
+	}
+	d.totalTime += latency
+	d.latencies[sort.Search(len(digestLatencyCutoffs), func(i int) bool {
+		return latency <= digestLatencyCutoffs[i]
+	})]++
+}
+
+func (qd *queryDigests) getOrCreate(key queryDigestKey) *queryDigest {
+	qd.mu.RLock()
+	d, ok := qd.digests[key]
+	qd.mu.RUnlock()
+	if ok {
+		return d
+	}
+
+	qd.mu.Lock()
+	defer qd.mu.Unlock()
+	if d, ok := qd.digests[key]; ok {
+		return d
+	}
+	if len(qd.digests) >= qd.maxSize {
+		queryDigestsLost.Add(1)
+		key = queryDigestKey{}
+		if d, ok := qd.digests[key]; ok {
+			return d
+		}
+	}
+	d = &queryDigest{
+		keyspace:   key.keyspace,
+		digestText: key.digestText,
+		latencies:  make([]uint64, len(digestLatencyCutoffs)+1),
+	}
+	if key.digestText != "" {
+		d.digest = queryDigestHash(key.digestText)
+	}
+	qd.digests[key] = d
+	return d
+}
+
+// stats returns the statistics of all the digests, by decreasing total time.
+func (qd *queryDigests) stats() []*queryDigestStats {
+	if qd == nil {
+		return nil
+	}
+	qd.mu.RLock()
+	digests := make([]*queryDigest, 0, len(qd.digests))
+	for _, d := range qd.digests {
+		digests = append(digests, d)
+	}
+	qd.mu.RUnlock()
+
+	result := make([]*queryDigestStats, 0, len(digests))
+	for _, d := range digests {
+		result = append(result, d.stats())
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].TotalTime > result[j].TotalTime
+	})
+	return result
+}
+
+func (d *queryDigest) stats() *queryDigestStats {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return &queryDigestStats{
+		Keyspace:        d.keyspace,
+		Digest:          d.digest,
+		DigestText:      d.digestText,
+		Count:           d.count,
+		Errors:          d.errors,
+		RowsAffected:    d.rowsAffected,
+		RowsReturned:    d.rowsReturned,
+		ShardQueries:    d.shardQueries,
+		MaxShardQueries: d.maxShardQueries,
+		TotalTime:       d.totalTime,
+		MinTime:         d.minTime,
+		MaxTime:         d.maxTime,
+		P50Time:         d.percentile(0.50),
+		P95Time:         d.percentile(0.95),
+		P99Time:         d.percentile(0.99),
+		FirstSeen:       d.firstSeen,
+		LastSeen:        d.lastSeen,
+	}
+}
+
+// percentile estimates the latency percentile p as the upper bound of the
+// histogram bucket it falls in, capped to the maximum latency.
+// It must be called with d.mu held.
+func (d *queryDigest) percentile(p float64) time.Duration {
+	rank := uint64(math.Ceil(p * float64(d.count)))
+	var seen uint64
+	for i, count := range d.latencies {
+		seen += count
+		if seen < rank {
+			continue
+		}
+		if i < len(digestLatencyCutoffs) && digestLatencyCutoffs[i] < d.maxTime {
+			return digestLatencyCutoffs[i]
+		}
+		break
+	}
+	return d.maxTime
+}
+
+// row returns the digest statistics as a row of engine.QueryDigestsFields.
+// The times are in seconds.
+func (s *queryDigestStats) row() []sqltypes.Value {
+	seconds := func(d time.Duration) sqltypes.Value {
+		return sqltypes.NewFloat64(d.Seconds())
+	}
+	digest, digestText := sqltypes.NULL, sqltypes.NULL
+	if s.DigestText != "" {
+		digest, digestText = sqltypes.NewVarChar(s.Digest), sqltypes.NewVarChar(s.DigestText)
+	}
+	var avgTime time.Duration
+	if s.Count != 0 {
+		avgTime = s.TotalTime / time.Duration(s.Count)
+	}
+	return []sqltypes.Value{
+		sqltypes.NewVarChar(s.Keyspace),
+		digest,
+		digestText,
+		sqltypes.NewUint64(s.Count),
+		sqltypes.NewUint64(s.Errors),
+		seconds(s.TotalTime),
+		seconds(avgTime),
+		seconds(s.MinTime),
+		seconds(s.MaxTime),
+		seconds(s.P50Time),
+		seconds(s.P95Time),
+		seconds(s.P99Time),
+		sqltypes.NewUint64(s.RowsAffected),
+		sqltypes.NewUint64(s.RowsReturned),
+		sqltypes.NewUint64(s.ShardQueries),
+		sqltypes.NewUint64(s.MaxShardQueries),
+		sqltypes.NewDatetime(s.FirstSeen.UTC().Format(sqltypes.TimestampFormat)),
+		sqltypes.NewDatetime(s.LastSeen.UTC().Format(sqltypes.TimestampFormat)),
+	}
+}
+
+// showQueryDigests returns the statistics of the query digests of this
+// vtgate, as a table that can be filtered with a LIKE on the digest text or
+// a WHERE clause on any of its columns.
+func (e *Executor) showQueryDigests(filter *sqlparser.ShowFilter) (*sqltypes.Result, error) {
+	if e.queryDigests == nil {
+		return nil, vterrors.Errorf(vtrpcpb.Code_FAILED_PRECONDITION, "query digests are disabled, see --query-digests-size")
+	}
+
+	keep := func([]sqltypes.Value) (bool, error) { return true, nil }
+	if filter != nil && filter.Like != "" {
+		likeRegexp := sqlparser.LikeToRegexp(filter.Like)
+		keep = func(row []sqltypes.Value) (bool, error) {
+			return likeRegexp.MatchString(row[2].ToString()), nil
+		}
+	} else if filter != nil && filter.Filter != nil {
+		where, err := evalengine.Translate(filter.Filter, &evalengine.Config{
+			ResolveColumn: func(col *sqlparser.ColName) (int, error) {
+				for i, field := range engine.QueryDigestsFields {
+					if col.Name.EqualString(field.Name) {
+						return i, nil
+					}
+				}
+				return 0, vterrors.Errorf(vtrpcpb.Code_INVALID_ARGUMENT, "unknown column '%s' in 'where clause'", sqlparser.String(col))
+			},
+		})
+		if err != nil {
+			return nil, err
+		}
+		env := evalengine.EmptyExpressionEnv()
+		keep = func(row []sqltypes.Value) (bool, error) {
+			env.Row = row
+			res, err := env.Evaluate(where)
+			if err != nil {
+				return false, err
+			}
+			return res.ToBoolean(), nil
+		}
+	}
+
+	var rows [][]sqltypes.Value
+	for _, s := range e.queryDigests.stats() {
+		row := s.row()
+		ok, err := keep(row)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			rows = append(rows, row)
+		}
+	}
+	return &sqltypes.Result{
+		Fields: engine.QueryDigestsFields,
+		Rows:   rows,
+	}, nil
+}
@@ -0,0 +1,131 @@
+/*
+Copyright 2023 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vtgate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"vitess.io/vitess/go/vt/vtgate/logstats"
+)
+
+func TestQueryDigests(t *testing.T) {
+	executor, _, _, _ := createExecutorEnv()
+
+	for _, query := range []string{
+		"select id from user where id = 1",
+		"select id from user where id = 2",
+		"select id from user",
+	} {
+		_, err := executorExec(executor, query, nil)
+		require.NoError(t, err)
+	}
+
+	digests := map[string]*queryDigestStats{}
+	for _, s := range executor.queryDigests.stats() {
+		digests[s.DigestText] = s
+	}
+	byID := digests["select id from `user` where id = ?"]
+	require.NotNil(t, byID)
+	assert.EqualValues(t, 2, byID.Count)
+	assert.EqualValues(t, 2, byID.ShardQueries)
+	assert.EqualValues(t, 1, byID.MaxShardQueries)
+	assert.Equal(t, queryDigestHash(byID.DigestText), byID.Digest)
+	byName := digests["select id from `user`"]
+	require.NotNil(t, byName)
+	assert.EqualValues(t, 1, byName.Count)
+	assert.EqualValues(t, 8, byName.MaxShardQueries)
+
+	qr, err := executorExec(executor, "show vitess_query_digests like '%from `user` where id%'", nil)
+	require.NoError(t, err)
+	require.Len(t, qr.Rows, 1)
+	assert.Equal(t, byID.Digest, qr.Rows[0][1].ToString())
+	assert.Equal(t, "2", qr.Rows[0][3].ToString())
+
+	qr, err = executorExec(executor, "show vitess_query_digests where maxshardqueries > 1 and DigestText like 'select%'", nil)
+	require.NoError(t, err)
+	require.Len(t, qr.Rows, 1)
+	assert.Equal(t, byName.Digest, qr.Rows[0][1].ToString())
+
+	_, err = executorExec(executor, "show vitess_query_digests where foo = 1", nil)
+	assert.ErrorContains(t, err, "unknown column 'foo'")
+
+	qr, err = executorExec(executor, "select DigestText as text, `Count` from vitess_query_digests where DigestText like 'select id from%' order by text desc limit 1", nil)
+	require.NoError(t, err)
+	require.Len(t, qr.Fields, 2)
+	assert.Equal(t, "text", qr.Fields[0].Name)
+	assert.Equal(t, `[[VARCHAR("select id from `+"`user`"+` where id = ?") UINT64(2)]]`, fmt.Sprintf("%v", qr.Rows))
+}
+
+func TestQueryDigestsOverflow(t *testing.T) {
+	qd := newQueryDigests(1)
+	start := time.Now()
+	record := func(digestText string, latency time.Duration, err error) {
+		qd.record(&logstats.LogStats{
+			Ctx:         context.Background(),
+			QueryDigest: digestText,
+			StartTime:   start,
+			EndTime:     start.Add(latency),
+			Error:       err,
+		}, 0, 1)
+	}
+	record("select ?", time.Millisecond, nil)
+	record("select ? from dual", 2*time.Millisecond, nil)
+	record("select ? from t", 3*time.Millisecond, errors.New("failed"))
+
+	stats := qd.stats()
+	require.Len(t, stats, 2)
+	overflow := stats[0]
+	assert.Empty(t, overflow.DigestText)
+	assert.Empty(t, overflow.Digest)
+	assert.EqualValues(t, 2, overflow.Count)
+	assert.EqualValues(t, 1, overflow.Errors)
+	assert.EqualValues(t, 2, overflow.RowsReturned)
+	assert.Equal(t, 5*time.Millisecond, overflow.TotalTime)
+	assert.Equal(t, "select ?", stats[1].DigestText)
+	assert.EqualValues(t, 1, stats[1].Count)
+}
+
+func TestQueryDigestPercentiles(t *testing.T) {
+	qd := newQueryDigests(10)
+	start := time.Now()
+	for i := 1; i <= 100; i++ {
+		qd.record(&logstats.LogStats{
+			Ctx:         context.Background(),
+			QueryDigest: "select ?",
+			StartTime:   start,
+			EndTime:     start.Add(time.Duration(i) * time.Millisecond),
+		}, 0, 0)
+	}
+
+	stats := qd.stats()
+	require.Len(t, stats, 1)
+	s := stats[0]
+	assert.Equal(t, time.Millisecond, s.MinTime)
+	assert.Equal(t, 100*time.Millisecond, s.MaxTime)
+	// The percentiles are the upper bounds of the 10µs * 2^n buckets.
+	assert.Equal(t, 81920*time.Microsecond, s.P50Time)
+	assert.Equal(t, 100*time.Millisecond, s.P95Time)
+	assert.Equal(t, 100*time.Millisecond, s.P99Time)
+	assert.Nil(t, newQueryDigests(0))
+}
@@ -23,8 +23,10 @@ import (
 	"sync"
 	"time"
 
+	"golang.org/x/exp/slices"
 	"google.golang.org/protobuf/proto"
 
+	"vitess.io/vitess/go/mysql"
 	"vitess.io/vitess/go/mysql/datetime"
 
 	"vitess.io/vitess/go/vt/sqlparser"
@@ -64,6 +66,15 @@ type (
 		// as the query that started a new transaction on the shard belong to a vindex.
 		queryFromVindex bool
 
+		// queryAttributes are the query attributes sent with the current query.
+		// They are taken from the session options by the executor, so they
+		// are only sent to the tablets when forwarding them is enabled.
+		queryAttributes map[string]string
+
+		// committedGtids are the GTIDs the tablets reported for the transactions
+		// committed since the executor last took them.
+		committedGtids string
+
 		logging *executeLogger
 
 		*vtgatepb.Session
@@ -572,6 +583,21 @@ func (session *SafeSession) TimeZone() *time.Location {
 	return loc
 }
 
+// SQLMode returns the sql_mode of the session, or the default
+// sql_mode of MySQL if the session has not set one. The vtgate doesn't
+// know the global sql_mode of the tablets, so the planner only evaluates
+// the expressions that depend on it when they can't be sent to a tablet.
+func (session *SafeSession) SQLMode() string {
+	session.mu.Lock()
+	mode, ok := session.SystemVariables["sql_mode"]
+	session.mu.Unlock()
+
+	if !ok {
+		return sysvars.DefaultSQLMode
+	}
+	return strings.Trim(mode, "'")
+}
+
 // SetOptions sets the options
 func (session *SafeSession) SetOptions(options *querypb.ExecuteOptions) {
 	session.mu.Lock()
@@ -765,6 +791,52 @@ func (session *SafeSession) SetSessionTrackGtids(enable bool) {
 		session.ReadAfterWrite = &vtgatepb.ReadAfterWrite{}
 	}
 	session.ReadAfterWrite.SessionTrackGtids = enable
+	// The tablets report the GTIDs of the autocommit DMLs they execute and of
+	// the transactions they commit.
+	session.GetOrCreateOptions().SessionTrackGtids = enable
+}
+
+// AddCommittedGtids records the GTIDs a tablet reported for a committed transaction.
+func (session *SafeSession) AddCommittedGtids(gtids string) {
+	if gtids == "" {
+		return
+	}
+	session.mu.Lock()
+	defer session.mu.Unlock()
+	session.committedGtids = mergeGtids(session.committedGtids, gtids)
+}
+
+// TakeCommittedGtids returns the GTIDs recorded by AddCommittedGtids and clears them.
+func (session *SafeSession) TakeCommittedGtids() string {
+	session.mu.Lock()
+	defer session.mu.Unlock()
+	gtids := session.committedGtids
+	session.committedGtids = ""
+	return gtids
+}
+
+// mergeGtids returns the union of two GTID sets reported by the tablets as
+// session state changes. Sets that can't be parsed are joined as lists with
+// the duplicates removed.
+func mergeGtids(a, b string) string {
+	if a == "" || a == b {
+		return b
+	}
+	if b == "" {
+		return a
+	}
+	setA, errA := mysql.ParseMysql56GTIDSet(a)
+	setB, errB := mysql.ParseMysql56GTIDSet(b)
+	if errA == nil && errB == nil {
+		return setA.Union(setB).String()
+	}
+	gtids := strings.Split(a, ",")
+	for _, gtid := range strings.Split(b, ",") {
+		if !slices.Contains(gtids, gtid) {
+			gtids = append(gtids, gtid)
+		}
+	}
+	return strings.Join(gtids, ",")
 }
 
 func removeShard(tabletAlias *topodatapb.TabletAlias, sessions []*vtgatepb.Session_ShardSession) ([]*vtgatepb.Session_ShardSession, error) {
@@ -791,6 +863,17 @@ func (session *SafeSession) GetOrCreateOptions() *querypb.ExecuteOptions {
 	return session.Session.Options
 }
 
+// takeQueryAttributes keeps the query attributes of the current query for its
+// execution, and removes them from the session options unless they must be
+// forwarded to the tablets.
+func (session *SafeSession) takeQueryAttributes(forward bool) map[string]string {
+	session.queryAttributes = session.GetOptions().GetQueryAttributes()
+	if !forward && session.queryAttributes != nil {
+		session.Options.QueryAttributes = nil
+	}
+	return session.queryAttributes
+}
+
 var _ iQueryOption = (*SafeSession)(nil)
 
 func (session *SafeSession) cachePlan() bool {
@@ -98,3 +98,38 @@ func TestTimeZone(t *testing.T) {
 		})
 	}
 }
+
+func TestMergeGtids(t *testing.T) {
+	tcases := []struct {
+		a, b, want string
+	}{{
+		a:    "",
+		b:    "3e11fa47-71ca-11e1-9e33-c80aa9429562:23",
+		want: "3e11fa47-71ca-11e1-9e33-c80aa9429562:23",
+	}, {
+		a:    "3e11fa47-71ca-11e1-9e33-c80aa9429562:23",
+		b:    "",
+		want: "3e11fa47-71ca-11e1-9e33-c80aa9429562:23",
+	}, {
+		a:    "3e11fa47-71ca-11e1-9e33-c80aa9429562:23",
+		b:    "3e11fa47-71ca-11e1-9e33-c80aa9429562:23",
+		want: "3e11fa47-71ca-11e1-9e33-c80aa9429562:23",
+	}, {
+		a:    "3e11fa47-71ca-11e1-9e33-c80aa9429562:24",
+		b:    "3e11fa47-71ca-11e1-9e33-c80aa9429562:23",
+		want: "3e11fa47-71ca-11e1-9e33-c80aa9429562:23-24",
+	}, {
+		a:    "ad25a1c1-71ca-11e1-9e33-c80aa9429562:5",
+		b:    "3e11fa47-71ca-11e1-9e33-c80aa9429562:23,ad25a1c1-71ca-11e1-9e33-c80aa9429562:5",
+		want: "3e11fa47-71ca-11e1-9e33-c80aa9429562:23,ad25a1c1-71ca-11e1-9e33-c80aa9429562:5",
+	}, {
+		a:    "a,b",
+		b:    "b,c",
+		want: "a,b,c",
+	}}
+	for _, tcase := range tcases {
+		t.Run(tcase.a+"|"+tcase.b, func(t *testing.T) {
+			assert.Equal(t, tcase.want, mergeGtids(tcase.a, tcase.b))
+		})
+	}
+}
@@ -258,6 +258,8 @@ func (stc *ScatterConn) ExecuteMultiShard(
 			mu.Lock()
 			defer mu.Unlock()
 
+			// Report the GTIDs of the autocommit DMLs of all the shards.
+			qr.SessionStateChanges = mergeGtids(qr.SessionStateChanges, innerqr.SessionStateChanges)
 			// Don't append more rows if row count is exceeded.
 			if ignoreMaxMemoryRows || len(qr.Rows) <= maxMemoryRows {
 				qr.AppendResult(innerqr)
@@ -104,6 +104,27 @@ func TestExecuteFailOnAutocommit(t *testing.T) {
 	utils.MustMatch(t, []*querypb.BoundQuery{queries[1]}, sbc1.Queries, "")
 }
 
+func TestExecuteMultiShardSessionStateChanges(t *testing.T) {
+	keyspace := "TestExecuteMultiShardSessionStateChanges"
+	createSandbox(keyspace)
+	hc := discovery.NewFakeHealthCheck(nil)
+	sc := newTestScatterConn(hc, newSandboxForCells([]string{"aa"}), "aa")
+	sbc0 := hc.AddTestTablet("aa", "0", 1, keyspace, "0", topodatapb.TabletType_PRIMARY, true, 1, nil)
+	sbc1 := hc.AddTestTablet("aa", "1", 1, keyspace, "1", topodatapb.TabletType_PRIMARY, true, 1, nil)
+	sbc0.SetResults([]*sqltypes.Result{{RowsAffected: 1, SessionStateChanges: "3e11fa47-71ca-11e1-9e33-c80aa9429562:23"}})
+	sbc1.SetResults([]*sqltypes.Result{{RowsAffected: 1, SessionStateChanges: "3e11fa47-71ca-11e1-9e33-c80aa9429562:24"}})
+
+	res := srvtopo.NewResolver(newSandboxForCells([]string{"aa"}), sc.gateway, "aa")
+	rss, err := res.ResolveDestination(ctx, keyspace, topodatapb.TabletType_PRIMARY, key.DestinationShards([]string{"0", "1"}))
+	require.NoError(t, err)
+	queries := []*querypb.BoundQuery{{Sql: "query1"}, {Sql: "query2"}}
+	qr, errs := sc.ExecuteMultiShard(ctx, nil, rss, queries, NewSafeSession(nil), true /*autocommit*/, false)
+	require.NoError(t, vterrors.Aggregate(errs))
+	assert.EqualValues(t, 2, qr.RowsAffected)
+	// The GTIDs of the shards are merged in a single set.
+	assert.Equal(t, "3e11fa47-71ca-11e1-9e33-c80aa9429562:23-24", qr.SessionStateChanges)
+}
+
 func TestReservedOnMultiReplica(t *testing.T) {
 	keyspace := "keyspace"
 	createSandbox(keyspace)
@@ -51,6 +51,7 @@ type (
 
 		mu     sync.Mutex
 		tables *tableMap
+		keys   *keyMap
 		views  *viewMap
 		ctx    context.Context
 		signal func() // a function that we'll call whenever we have new schema data
@@ -80,6 +81,7 @@ func NewTracker(ch chan *discovery.TabletHealth, user string, enableViews bool)
 		ctx:          ctx,
 		ch:           ch,
 		tables:       &tableMap{m: map[keyspaceStr]map[tableNameStr][]vindexes.Column{}},
+		keys:         &keyMap{m: map[keyspaceStr]map[tableNameStr]*tableKeys{}},
 		tracked:      map[keyspaceStr]*updateController{},
 		consumeDelay: defaultConsumeDelay,
 	}
@@ -122,6 +124,10 @@ func (t *Tracker) loadTables(conn queryservice.QueryService, target *querypb.Tar
 	if err != nil {
 		return err
 	}
+	ukRes, err := conn.Execute(t.ctx, target, mysql.FetchUniqueKeys, nil, 0, 0, nil)
+	if err != nil {
+		return err
+	}
 	t.mu.Lock()
 	defer t.mu.Unlock()
 
@@ -131,6 +137,7 @@ func (t *Tracker) loadTables(conn queryservice.QueryService, target *querypb.Tar
 	// tablet is simply restarted or potentially when we elect a new primary.
 	t.clearKeyspaceTables(target.Keyspace)
 	t.updateTables(target.Keyspace, ftRes)
+	t.updateKeys(target.Keyspace, ukRes)
 	log.Infof("finished loading schema for keyspace %s. Found %d columns in total across the tables", target.Keyspace, len(ftRes.Rows))
 
 	return nil
@@ -239,6 +246,46 @@ func (t *Tracker) Tables(ks string) map[string][]vindexes.Column {
 	return m
 }
 
+// UniqueKeys returns the primary and unique keys of all known tables in the keyspace.
+// Every known table has an entry, which is empty when the table has no unique key.
+func (t *Tracker) UniqueKeys(ks string) map[string][][]sqlparser.IdentifierCI {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.tables == nil {
+		return nil
+	}
+	tables := t.tables.m[ks]
+	keys := make(map[string][][]sqlparser.IdentifierCI, len(tables))
+	for tbl := range tables {
+		keys[tbl] = [][]sqlparser.IdentifierCI{}
+		if tblKeys := t.keys.get(ks, tbl); tblKeys != nil {
+			keys[tbl] = tblKeys.unique
+		}
+	}
+	return keys
+}
+
+// PrimaryKeys returns the primary keys of all known tables in the keyspace.
+// Every known table has an entry, which is empty when the table has no primary key.
+func (t *Tracker) PrimaryKeys(ks string) map[string][]sqlparser.IdentifierCI {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.tables == nil {
+		return nil
+	}
+	tables := t.tables.m[ks]
+	keys := make(map[string][]sqlparser.IdentifierCI, len(tables))
+	for tbl := range tables {
+		keys[tbl] = []sqlparser.IdentifierCI{}
+		if tblKeys := t.keys.get(ks, tbl); tblKeys != nil && tblKeys.primary != nil {
+			keys[tbl] = tblKeys.primary
+		}
+	}
+	return keys
+}
+
 // Views returns all known views in the keyspace with their definition.
 func (t *Tracker) Views(ks string) map[string]sqlparser.SelectStatement {
 	t.mu.Lock()
@@ -281,6 +328,10 @@ func (t *Tracker) updatedTableSchema(th *discovery.TabletHealth) bool {
 	res, err := th.Conn.Execute(t.ctx, th.Target,
 		sqlparser.BuildParsedQuery(mysql.FetchUpdatedTables, sidecarDBID).Query,
 		bv, 0, 0, nil)
+	var ukRes *sqltypes.Result
+	if err == nil {
+		ukRes, err = th.Conn.Execute(t.ctx, th.Target, mysql.FetchUpdatedUniqueKeys, bv, 0, 0, nil)
+	}
 	if err != nil {
 		t.tracked[th.Target.Keyspace].setLoaded(false)
 		// TODO: optimize for the tables that got errored out.
@@ -299,8 +350,10 @@ func (t *Tracker) updatedTableSchema(th *discovery.TabletHealth) bool {
 	// so this is the only chance to delete
 	for _, tbl := range tablesUpdated {
 		t.tables.delete(th.Target.Keyspace, tbl)
+		t.keys.delete(th.Target.Keyspace, tbl)
 	}
 	t.updateTables(th.Target.Keyspace, res)
+	t.updateKeys(th.Target.Keyspace, ukRes)
 	return true
 }
 
@@ -319,6 +372,33 @@ func (t *Tracker) updateTables(keyspace string, res *sqltypes.Result) {
 	}
 }
 
+func (t *Tracker) updateKeys(keyspace string, res *sqltypes.Result) {
+	var lastTbl, lastKey string
+	var key []sqlparser.IdentifierCI
+	var functional bool
+	flush := func() {
+		// A key with a functional key part has no column to compare, so it is left out.
+		if key != nil && !functional {
+			t.keys.add(keyspace, lastTbl, lastKey, key)
+		}
+		key, functional = nil, false
+	}
+	for _, row := range res.Rows {
+		tbl := row[0].ToString()
+		keyName := row[1].ToString()
+		if tbl != lastTbl || keyName != lastKey {
+			flush()
+			lastTbl, lastKey = tbl, keyName
+		}
+		if row[2].IsNull() {
+			functional = true
+			continue
+		}
+		key = append(key, sqlparser.NewIdentifierCI(row[2].ToString()))
+	}
+	flush()
+}
+
 func (t *Tracker) updatedViewSchema(th *discovery.TabletHealth) bool {
 	t.mu.Lock()
 	defer t.mu.Unlock()
@@ -406,6 +486,52 @@ func (t *Tracker) clearKeyspaceTables(ks string) {
 	if t.tables != nil && t.tables.m != nil {
 		delete(t.tables.m, ks)
 	}
+	if t.keys != nil && t.keys.m != nil {
+		delete(t.keys.m, ks)
+	}
+}
+
+// tableKeys holds the primary key and all the unique keys of a table.
+type tableKeys struct {
+	primary []sqlparser.IdentifierCI
+	unique  [][]sqlparser.IdentifierCI
+}
+
+type keyMap struct {
+	m map[keyspaceStr]map[tableNameStr]*tableKeys
+}
+
+func (km *keyMap) add(ks, tbl, keyName string, key []sqlparser.IdentifierCI) {
+	m := km.m[ks]
+	if m == nil {
+		m = make(map[tableNameStr]*tableKeys)
+		km.m[ks] = m
+	}
+	keys := m[tbl]
+	if keys == nil {
+		keys = &tableKeys{}
+		m[tbl] = keys
+	}
+	keys.unique = append(keys.unique, key)
+	if keyName == "PRIMARY" {
+		keys.primary = key
+	}
+}
+
+func (km *keyMap) get(ks, tbl string) *tableKeys {
+	m := km.m[ks]
+	if m == nil {
+		return nil
+	}
+	return m[tbl]
+}
+
+func (km *keyMap) delete(ks, tbl string) {
+	m := km.m[ks]
+	if m == nil {
+		return
+	}
+	delete(m, tbl)
 }
 
 type viewMap struct {
@@ -192,6 +192,12 @@ func TestTracking(t *testing.T) {
 				}
 			}
 
+			// the primary key of t2, which is in all the test cases.
+			results = append(results, sqltypes.MakeTestResult(
+				sqltypes.MakeTestFields("table_name|index_name|column_name", "varchar|varchar|varchar"),
+				"t2|PRIMARY|id",
+			))
+
 			sbc.SetResults(results)
 			sbc.Queries = nil
 
@@ -213,7 +219,7 @@ func TestTracking(t *testing.T) {
 
 			require.False(t, waitTimeout(&wg, time.Second), "schema was updated but received no signal")
 
-			require.Equal(t, 1, len(sbc.StringQueries()))
+			require.Equal(t, 2, len(sbc.StringQueries()))
 
 			_, keyspacePresent := tracker.tracked[target.Keyspace]
 			require.Equal(t, true, keyspacePresent)
@@ -221,6 +227,19 @@ func TestTracking(t *testing.T) {
 			for k, v := range tcase.exp {
 				utils.MustMatch(t, v, tracker.GetColumns("ks", k), "mismatch for table: ", k)
 			}
+
+			uniqueKeys := tracker.UniqueKeys("ks")
+			primaryKeys := tracker.PrimaryKeys("ks")
+			for k := range tcase.exp {
+				exp := [][]sqlparser.IdentifierCI{}
+				expPK := []sqlparser.IdentifierCI{}
+				if k == "t2" {
+					exp = [][]sqlparser.IdentifierCI{{sqlparser.NewIdentifierCI("id")}}
+					expPK = []sqlparser.IdentifierCI{sqlparser.NewIdentifierCI("id")}
+				}
+				utils.MustMatch(t, exp, uniqueKeys[k], "mismatch for table: ", k)
+				utils.MustMatch(t, expPK, primaryKeys[k], "mismatch for table: ", k)
+			}
 		})
 	}
 }
@@ -277,7 +296,7 @@ func TestTrackingUnHealthyTablet(t *testing.T) {
 		},
 	}
 
-	sbc.SetResults([]*sqltypes.Result{{}, {}, {}})
+	sbc.SetResults([]*sqltypes.Result{{}, {}, {}, {}, {}, {}})
 	for _, tcase := range tcases {
 		ch <- &discovery.TabletHealth{
 			Conn:    sbc,
@@ -291,8 +310,11 @@ func TestTrackingUnHealthyTablet(t *testing.T) {
 
 	require.False(t, waitTimeout(&wg, 5*time.Second), "schema was updated but received no signal")
 	require.Equal(t, []string{sqlparser.BuildParsedQuery(mysql.FetchTables, sidecardb.DefaultName).Query,
+		mysql.FetchUniqueKeys,
 		sqlparser.BuildParsedQuery(mysql.FetchUpdatedTables, sidecardb.DefaultName).Query,
-		sqlparser.BuildParsedQuery(mysql.FetchTables, sidecardb.DefaultName).Query}, sbc.StringQueries())
+		mysql.FetchUpdatedUniqueKeys,
+		sqlparser.BuildParsedQuery(mysql.FetchTables, sidecardb.DefaultName).Query,
+		mysql.FetchUniqueKeys}, sbc.StringQueries())
 }
 
 func waitTimeout(wg *sync.WaitGroup, timeout time.Duration) bool {
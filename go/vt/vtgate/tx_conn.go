@@ -105,7 +105,7 @@ func (txc *TxConn) queryService(alias *topodatapb.TabletAlias) (queryservice.Que
 	return txc.tabletGateway.QueryServiceByAlias(alias, nil)
 }
 
-func (txc *TxConn) commitShard(ctx context.Context, s *vtgatepb.Session_ShardSession, logging *executeLogger) error {
+func (txc *TxConn) commitShard(ctx context.Context, session *SafeSession, s *vtgatepb.Session_ShardSession, logging *executeLogger) error {
 	if s.TransactionId == 0 {
 		return nil
 	}
@@ -115,31 +115,35 @@ func (txc *TxConn) commitShard(ctx context.Context, s *vtgatepb.Session_ShardSes
 	if err != nil {
 		return err
 	}
-	reservedID, err := qs.Commit(ctx, s.Target, s.TransactionId)
+	state, err := qs.Commit(ctx, s.Target, s.TransactionId)
 	if err != nil {
 		return err
 	}
 	s.TransactionId = 0
-	s.ReservedId = reservedID
+	s.ReservedId = state.ReservedID
+	session.AddCommittedGtids(state.SessionStateChanges)
 	logging.log(nil, s.Target, nil, "commit", false, nil)
 	return nil
 }
 
 func (txc *TxConn) commitNormal(ctx context.Context, session *SafeSession) error {
-	if err := txc.runSessions(ctx, session.PreSessions, session.logging, txc.commitShard); err != nil {
+	commitShard := func(ctx context.Context, s *vtgatepb.Session_ShardSession, logging *executeLogger) error {
+		return txc.commitShard(ctx, session, s, logging)
+	}
+	if err := txc.runSessions(ctx, session.PreSessions, session.logging, commitShard); err != nil {
 		_ = txc.Release(ctx, session)
 		return err
 	}
 
 	// Retain backward compatibility on commit order for the normal session.
 	for _, shardSession := range session.ShardSessions {
-		if err := txc.commitShard(ctx, shardSession, session.logging); err != nil {
+		if err := commitShard(ctx, shardSession, session.logging); err != nil {
 			_ = txc.Release(ctx, session)
 			return err
 		}
 	}
 
-	if err := txc.runSessions(ctx, session.PostSessions, session.logging, txc.commitShard); err != nil {
+	if err := txc.runSessions(ctx, session.PostSessions, session.logging, commitShard); err != nil {
 		// If last commit fails, there will be nothing to rollback.
 		session.RecordWarning(&querypb.QueryWarning{Message: fmt.Sprintf("post-operation transaction had an error: %v", err)})
 		// With reserved connection we should release them.
@@ -75,6 +75,7 @@ type iExecute interface {
 	showShards(ctx context.Context, filter *sqlparser.ShowFilter, destTabletType topodatapb.TabletType) (*sqltypes.Result, error)
 	showTablets(filter *sqlparser.ShowFilter) (*sqltypes.Result, error)
 	showVitessMetadata(ctx context.Context, filter *sqlparser.ShowFilter) (*sqltypes.Result, error)
+	showQueryDigests(filter *sqlparser.ShowFilter) (*sqltypes.Result, error)
 	setVitessMetadata(ctx context.Context, name, value string) error
 
 	// TODO: remove when resolver is gone
@@ -192,6 +193,10 @@ func (vc *vcursorImpl) TimeZone() *time.Location {
 	return vc.safeSession.TimeZone()
 }
 
+func (vc *vcursorImpl) SQLMode() string {
+	return vc.safeSession.SQLMode()
+}
+
 // MaxMemoryRows returns the maxMemoryRows flag value.
 func (vc *vcursorImpl) MaxMemoryRows() int {
 	return maxMemoryRows
@@ -203,6 +208,11 @@ func (vc *vcursorImpl) ExceedsMaxMemoryRows(numRows int) bool {
 	return !vc.ignoreMaxMemoryRows && numRows > maxMemoryRows
 }
 
+// SpillDir returns the spillDir flag value.
+func (vc *vcursorImpl) SpillDir() string {
+	return spillDir
+}
+
 // SetIgnoreMaxMemoryRows sets the ignoreMaxMemoryRows value.
 func (vc *vcursorImpl) SetIgnoreMaxMemoryRows(ignoreMaxMemoryRows bool) {
 	vc.ignoreMaxMemoryRows = ignoreMaxMemoryRows
@@ -1112,6 +1122,8 @@ func (vc *vcursorImpl) VStream(ctx context.Context, rss []*srvtopo.ResolvedShard
 
 func (vc *vcursorImpl) ShowExec(ctx context.Context, command sqlparser.ShowCommandType, filter *sqlparser.ShowFilter) (*sqltypes.Result, error) {
 	switch command {
+	case sqlparser.VitessQueryDigests:
+		return vc.executor.showQueryDigests(filter)
 	case sqlparser.VitessReplicationStatus:
 		return vc.executor.showVitessReplicationStatus(ctx, filter)
 	case sqlparser.VitessShards:
@@ -537,7 +537,7 @@ func (cached *Table) CachedSize(alloc bool) int64 {
 	}
 	size := int64(0)
 	if alloc {
-		size += int64(192)
+		size += int64(240)
 	}
 	// field Type string
 	size += hack.RuntimeAllocSize(int64(len(cached.Type)))
@@ -579,6 +579,25 @@ func (cached *Table) CachedSize(alloc bool) int64 {
 	{
 		size += hack.RuntimeAllocSize(int64(cap(cached.Pinned)))
 	}
+	// field UniqueKeys [][]vitess.io/vitess/go/vt/sqlparser.IdentifierCI
+	{
+		size += hack.RuntimeAllocSize(int64(cap(cached.UniqueKeys)) * int64(24))
+		for _, elem := range cached.UniqueKeys {
+			{
+				size += hack.RuntimeAllocSize(int64(cap(elem)) * int64(32))
+				for _, elem := range elem {
+					size += elem.CachedSize(false)
+				}
+			}
+		}
+	}
+	// field PrimaryKey []vitess.io/vitess/go/vt/sqlparser.IdentifierCI
+	{
+		size += hack.RuntimeAllocSize(int64(cap(cached.PrimaryKey)) * int64(32))
+		for _, elem := range cached.PrimaryKey {
+			size += elem.CachedSize(false)
+		}
+	}
 	// field ReferencedBy map[string]*vitess.io/vitess/go/vt/vtgate/vindexes.Table
 	if cached.ReferencedBy != nil {
 		size += int64(48)
@@ -102,6 +102,12 @@ type Table struct {
 	Columns                 []Column               `json:"columns,omitempty"`
 	Pinned                  []byte                 `json:"pinned,omitempty"`
 	ColumnListAuthoritative bool                   `json:"column_list_authoritative,omitempty"`
+	// UniqueKeys are the columns of the primary and unique keys of the table,
+	// as known to the schema tracker. It is nil when the keys are not known.
+	UniqueKeys [][]sqlparser.IdentifierCI `json:"unique_keys,omitempty"`
+	// PrimaryKey are the columns of the primary key of the table, as known to the
+	// schema tracker. It is nil when the key is not known, and empty when there is none.
+	PrimaryKey []sqlparser.IdentifierCI `json:"primary_key,omitempty"`
 	// ReferencedBy is an inverse mapping of tables in other keyspaces that
 	// reference this table via Source.
 	//
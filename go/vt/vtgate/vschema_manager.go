@@ -49,6 +49,8 @@ type VSchemaManager struct {
 // SchemaInfo is an interface to schema tracker.
 type SchemaInfo interface {
 	Tables(ks string) map[string][]vindexes.Column
+	UniqueKeys(ks string) map[string][][]sqlparser.IdentifierCI
+	PrimaryKeys(ks string) map[string][]sqlparser.IdentifierCI
 	Views(ks string) map[string]sqlparser.SelectStatement
 }
 
@@ -191,6 +193,8 @@ func (vm *VSchemaManager) buildAndEnhanceVSchema(v *vschemapb.SrvVSchema) *vinde
 func (vm *VSchemaManager) updateFromSchema(vschema *vindexes.VSchema) {
 	for ksName, ks := range vschema.Keyspaces {
 		m := vm.schema.Tables(ksName)
+		uniqueKeys := vm.schema.UniqueKeys(ksName)
+		primaryKeys := vm.schema.PrimaryKeys(ksName)
 
 		for tblName, columns := range m {
 			vTbl := ks.Tables[tblName]
@@ -201,9 +205,13 @@ func (vm *VSchemaManager) updateFromSchema(vschema *vindexes.VSchema) {
 					Keyspace:                ks.Keyspace,
 					Columns:                 columns,
 					ColumnListAuthoritative: true,
+					UniqueKeys:              uniqueKeys[tblName],
+					PrimaryKey:              primaryKeys[tblName],
 				}
 				continue
 			}
+			vTbl.UniqueKeys = uniqueKeys[tblName]
+			vTbl.PrimaryKey = primaryKeys[tblName]
 			if !vTbl.ColumnListAuthoritative {
 				// if we found the matching table and the vschema view of it is not authoritative, then we just update the columns of the table
 				vTbl.Columns = columns
@@ -255,6 +255,14 @@ func (f *fakeSchema) Tables(string) map[string][]vindexes.Column {
 	return f.t
 }
 
+func (f *fakeSchema) UniqueKeys(string) map[string][][]sqlparser.IdentifierCI {
+	return nil
+}
+
+func (f *fakeSchema) PrimaryKeys(string) map[string][]sqlparser.IdentifierCI {
+	return nil
+}
+
 func (f *fakeSchema) Views(ks string) map[string]sqlparser.SelectStatement {
 	return nil
 }
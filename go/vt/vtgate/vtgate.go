@@ -71,6 +71,7 @@ var (
 
 	maxMemoryRows   = 300000
 	warnMemoryRows  = 30000
+	spillDir        string
 	maxPayloadSize  int
 	warnPayloadSize int
 
@@ -114,6 +115,13 @@ var (
 
 	// allowKillStmt to allow execution of kill statement.
 	allowKillStmt bool
+
+	// forwardQueryAttributes controls whether the query attributes sent by
+	// the clients are forwarded to vttablet.
+	forwardQueryAttributes bool
+
+	// queryDigestsSize is the maximum number of query digests kept.
+	queryDigestsSize = 10000
 )
 
 func registerFlags(fs *pflag.FlagSet) {
@@ -126,6 +134,7 @@ func registerFlags(fs *pflag.FlagSet) {
 	fs.Int64Var(&queryPlanCacheMemory, "gate_query_cache_memory", queryPlanCacheMemory, "gate server query cache size in bytes, maximum amount of memory to be cached. vtgate analyzes every incoming query and generate a query plan, these plans are being cached in a lru cache. This config controls the capacity of the lru cache.")
 	fs.BoolVar(&queryPlanCacheLFU, "gate_query_cache_lfu", cache.DefaultConfig.LFU, "gate server cache algorithm. when set to true, a new cache algorithm based on a TinyLFU admission policy will be used to improve cache behavior and prevent pollution from sparse queries")
 	fs.IntVar(&maxMemoryRows, "max_memory_rows", maxMemoryRows, "Maximum number of rows that will be held in memory for intermediate results as well as the final result.")
+	fs.StringVar(&spillDir, "spill-dir", spillDir, "Directory to which sorts, distincts and hash joins spill their intermediate results once they exceed max_memory_rows, instead of failing the query. Spilling is disabled when empty.")
 	fs.IntVar(&warnMemoryRows, "warn_memory_rows", warnMemoryRows, "Warning threshold for in-memory results. A row count higher than this amount will cause the VtGateWarnings.ResultsExceeded counter to be incremented.")
 	fs.StringVar(&defaultDDLStrategy, "ddl_strategy", defaultDDLStrategy, "Set default strategy for DDL statements. Override with @@ddl_strategy session variable")
 	fs.StringVar(&dbDDLPlugin, "dbddl_plugin", dbDDLPlugin, "controls how to handle CREATE/DROP DATABASE. use it if you are using your own database provisioning service")
@@ -150,6 +159,8 @@ func registerFlags(fs *pflag.FlagSet) {
 	fs.DurationVar(&messageStreamGracePeriod, "message_stream_grace_period", messageStreamGracePeriod, "the amount of time to give for a vttablet to resume if it ends a message stream, usually because of a reparent.")
 	fs.BoolVar(&enableViews, "enable-views", enableViews, "Enable views support in vtgate.")
 	fs.BoolVar(&allowKillStmt, "allow-kill-statement", allowKillStmt, "Allows the execution of kill statement")
+	fs.BoolVar(&forwardQueryAttributes, "forward-query-attributes", forwardQueryAttributes, "Forward the query attributes sent by MySQL clients to vttablet, in the execute options")
+	fs.IntVar(&queryDigestsSize, "query-digests-size", queryDigestsSize, "Maximum number of query digests, the execution statistics by query shape shown by SHOW VITESS_QUERY_DIGESTS, the vitess_query_digests table and /debug/query_digests. The queries of new shapes are accounted in an overflow digest once it is reached. 0 disables the query digests.")
 }
 func init() {
 	servenv.OnParseFor("vtgate", registerFlags)
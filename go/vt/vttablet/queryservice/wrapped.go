@@ -92,17 +92,17 @@ func (ws *wrappedService) Begin(ctx context.Context, target *querypb.Target, opt
 	return state, err
 }
 
-func (ws *wrappedService) Commit(ctx context.Context, target *querypb.Target, transactionID int64) (int64, error) {
-	var rID int64
+func (ws *wrappedService) Commit(ctx context.Context, target *querypb.Target, transactionID int64) (CommitState, error) {
+	var state CommitState
 	err := ws.wrapper(ctx, target, ws.impl, "Commit", true, func(ctx context.Context, target *querypb.Target, conn QueryService) (bool, error) {
 		var innerErr error
-		rID, innerErr = conn.Commit(ctx, target, transactionID)
+		state, innerErr = conn.Commit(ctx, target, transactionID)
 		return canRetry(ctx, innerErr), innerErr
 	})
 	if err != nil {
-		return 0, err
+		return CommitState{}, err
 	}
-	return rID, nil
+	return state, nil
 }
 
 func (ws *wrappedService) Rollback(ctx context.Context, target *querypb.Target, transactionID int64) (int64, error) {
@@ -99,6 +99,9 @@ type SandboxConn struct {
 	// ReadTransactionResults is used for returning results for ReadTransaction.
 	ReadTransactionResults []*querypb.TransactionMetadata
 
+	// CommitSessionStateChanges are the session state changes returned by Commit.
+	CommitSessionStateChanges string
+
 	MessageIDs []*querypb.Value
 
 	// vstream expectations.
@@ -263,13 +266,13 @@ func (sbc *SandboxConn) begin(ctx context.Context, target *querypb.Target, preQu
 }
 
 // Commit is part of the QueryService interface.
-func (sbc *SandboxConn) Commit(ctx context.Context, target *querypb.Target, transactionID int64) (int64, error) {
+func (sbc *SandboxConn) Commit(ctx context.Context, target *querypb.Target, transactionID int64) (queryservice.CommitState, error) {
 	sbc.CommitCount.Add(1)
 	reservedID := sbc.getTxReservedID(transactionID)
 	if reservedID != 0 {
 		reservedID = sbc.ReserveID.Add(1)
 	}
-	return reservedID, sbc.getError()
+	return queryservice.CommitState{ReservedID: reservedID, SessionStateChanges: sbc.CommitSessionStateChanges}, sbc.getError()
 }
 
 // Rollback is part of the QueryService interface.
@@ -158,9 +158,9 @@ func (f *FakeQueryService) Begin(ctx context.Context, target *querypb.Target, op
 const commitTransactionID int64 = 999044
 
 // Commit is part of the queryservice.QueryService interface
-func (f *FakeQueryService) Commit(ctx context.Context, target *querypb.Target, transactionID int64) (int64, error) {
+func (f *FakeQueryService) Commit(ctx context.Context, target *querypb.Target, transactionID int64) (queryservice.CommitState, error) {
 	if f.HasError {
-		return 0, f.TabletError
+		return queryservice.CommitState{}, f.TabletError
 	}
 	if f.Panics {
 		panic(fmt.Errorf("test-triggered panic"))
@@ -169,7 +169,7 @@ func (f *FakeQueryService) Commit(ctx context.Context, target *querypb.Target, t
 	if transactionID != commitTransactionID {
 		f.t.Errorf("Commit: invalid TransactionId: got %v expected %v", transactionID, commitTransactionID)
 	}
-	return 0, nil
+	return queryservice.CommitState{}, nil
 }
 
 // rollbackTransactionID is a test transactin id for Rollback.
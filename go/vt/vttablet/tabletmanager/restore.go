@@ -206,6 +206,12 @@ func (tm *TabletManager) restoreDataLocked(ctx context.Context, logger logutil.L
 		}
 		params.RestoreToPos = pos
 	}
+	if request.RestoreToTimestamp != nil {
+		if request.RestoreToPos != "" {
+			return vterrors.New(vtrpcpb.Code_INVALID_ARGUMENT, "restore failed: --restore_to_pos and --restore_to_timestamp are mutually exclusive")
+		}
+		params.RestoreToTimestamp = logutil.ProtoToTime(request.RestoreToTimestamp)
+	}
 	params.Logger.Infof("Restore: original tablet type=%v", originalType)
 
 	// Check whether we're going to restore before changing to RESTORE type,
@@ -41,6 +41,10 @@ import (
 	"vitess.io/vitess/go/vt/vttablet/tabletserver/throttle/throttlerapp"
 )
 
+// maxRequeueConflicts is the max number of conflicting ids reported
+// when dead letters cannot be requeued.
+const maxRequeueConflicts = 10
+
 var (
 	// MessageStats tracks stats for messages.
 	MessageStats = stats.NewGaugesWithMultiLabels(
@@ -53,6 +57,8 @@ type QueryGenerator interface {
 	GenerateAckQuery(ids []string) (string, map[string]*querypb.BindVariable)
 	GeneratePostponeQuery(ids []string) (string, map[string]*querypb.BindVariable)
 	GeneratePurgeQuery(timeCutoff int64) (string, map[string]*querypb.BindVariable)
+	GenerateDeadLetterQueries(ids []string) ([]string, map[string]*querypb.BindVariable)
+	GenerateRequeueQueries(where *sqlparser.Where) (conflicts *sqlparser.ParsedQuery, queries []*sqlparser.ParsedQuery, bv map[string]*querypb.BindVariable)
 }
 
 type messageReceiver struct {
@@ -147,6 +153,17 @@ type receiverWithStatus struct {
 // loads are less than the cache size and all cache adds are successful.
 // If so, the system reverts to the steady state mode.
 //
+// Dead letters
+// If the table specifies a max epoch, the send loop does not send messages
+// that were already sent that many times. Instead, they are moved to the
+// dead letter table in a single transaction, or marked as failed by setting
+// time_next to null if there is no dead letter table. Either way, they are
+// removed from the cache. Dead letters can be put back in circulation with
+// a REQUEUE statement. A dead letter whose id was reused in the message table
+// in the meantime cannot be moved back: the REQUEUE fails with ALREADY_EXISTS
+// and moves nothing. Those messages must be acked and purged, or excluded by
+// the WHERE clause, first.
+//
 // Rate limiting
 // There are two ways for the system to rate-limit:
 // 1. Client ingestion rate. If clients ingest messages slowly,
@@ -176,6 +193,8 @@ type messageManager struct {
 	purgeAfter   time.Duration
 	minBackoff   time.Duration
 	maxBackoff   time.Duration
+	maxEpoch     int64
+	dlqTable     sqlparser.IdentifierCS
 	batchSize    int
 	pollerTicks  *timer.Timer
 	purgeTicks   *timer.Timer
@@ -236,6 +255,8 @@ type messageManager struct {
 	ackQuery                  *sqlparser.ParsedQuery
 	postponeQuery             *sqlparser.ParsedQuery
 	purgeQuery                *sqlparser.ParsedQuery
+	deadLetterQueries         []*sqlparser.ParsedQuery
+	columns                   sqlparser.Columns
 }
 
 // newMessageManager creates a new message manager.
@@ -253,6 +274,8 @@ func newMessageManager(tsv TabletService, vs VStreamer, table *schema.Table, pos
 		purgeAfter:      table.MessageInfo.PurgeAfterDuration,
 		minBackoff:      table.MessageInfo.MinBackoff,
 		maxBackoff:      table.MessageInfo.MaxBackoff,
+		maxEpoch:        int64(table.MessageInfo.MaxEpoch),
+		dlqTable:        table.MessageInfo.DeadLetterTable,
 		batchSize:       table.MessageInfo.BatchSize,
 		cache:           newCache(table.MessageInfo.CacheSize),
 		pollerTicks:     timer.NewTimer(table.MessageInfo.PollInterval),
@@ -282,10 +305,42 @@ func newMessageManager(tsv TabletService, vs VStreamer, table *schema.Table, pos
 		"delete from %v where time_acked < %a limit 500", mm.name, ":time_acked")
 
 	mm.postponeQuery = buildPostponeQuery(mm.name, mm.minBackoff, mm.maxBackoff)
+	for _, field := range table.Fields {
+		mm.columns = append(mm.columns, sqlparser.NewIdentifierCI(field.Name))
+	}
+	mm.deadLetterQueries = buildDeadLetterQueries(mm.name, mm.dlqTable, mm.columns)
 
 	return mm
 }
 
+// buildDeadLetterQueries builds the queries that take the messages that
+// exceeded the max epoch out of circulation. If the table has a dead
+// letter table, the rows are moved there. Otherwise, they stay in the
+// message table and are marked as failed by clearing time_next, which
+// makes them invisible to the poller.
+func buildDeadLetterQueries(name, dlq sqlparser.IdentifierCS, columns sqlparser.Columns) []*sqlparser.ParsedQuery {
+	if dlq.IsEmpty() {
+		return []*sqlparser.ParsedQuery{sqlparser.BuildParsedQuery(
+			"update %v set time_next = null where id in %a and time_acked is null and epoch >= %a",
+			name, "::ids", ":max_epoch")}
+	}
+	selectList := sqlparser.NewTrackedBuffer(nil)
+	for i, col := range columns {
+		if i > 0 {
+			selectList.WriteString(", ")
+		}
+		selectList.Myprintf("%v", col)
+	}
+	return []*sqlparser.ParsedQuery{
+		sqlparser.BuildParsedQuery(
+			"insert into %v%v select %s from %v where id in %a and time_acked is null and epoch >= %a",
+			dlq, columns, selectList.String(), name, "::ids", ":max_epoch"),
+		sqlparser.BuildParsedQuery(
+			"delete from %v where id in %a and time_acked is null and epoch >= %a",
+			name, "::ids", ":max_epoch"),
+	}
+}
+
 func buildPostponeQuery(name sqlparser.IdentifierCS, minBackoff, maxBackoff time.Duration) *sqlparser.ParsedQuery {
 	var args []any
 
@@ -549,11 +604,18 @@ func (mm *messageManager) runSend() {
 
 			// Fetch rows from cache.
 			lateCount := int64(0)
+			var deadLetters []string
 			for i := 0; i < mm.batchSize; i++ {
 				mr := mm.cache.Pop()
 				if mr == nil {
 					break
 				}
+				// Messages that were already sent maxEpoch times
+				// are not sent again.
+				if mm.maxEpoch > 0 && mr.Epoch >= mm.maxEpoch {
+					deadLetters = append(deadLetters, mr.Row[0].ToString())
+					continue
+				}
 				if mr.Epoch >= 1 {
 					lateCount++
 				}
@@ -561,6 +623,11 @@ func (mm *messageManager) runSend() {
 			}
 			MessageStats.Add([]string{mm.name.String(), "Delayed"}, lateCount)
 
+			if deadLetters != nil {
+				mm.wg.Add(1)
+				go mm.deadLetter(context.Background(), deadLetters) // calls the offsetting mm.wg.Done()
+			}
+
 			// If we have rows to send, break out of this loop.
 			if rows != nil {
 				break
@@ -642,6 +709,43 @@ func (mm *messageManager) postpone(ctx context.Context, tsv TabletService, ackWa
 	return nil
 }
 
+// deadLetter takes the messages that exceeded the max epoch out of
+// circulation, by either moving them to the dead letter table or
+// marking them as failed.
+func (mm *messageManager) deadLetter(ctx context.Context, ids []string) {
+	defer func() {
+		mm.tsv.LogError()
+		mm.wg.Done()
+	}()
+
+	defer func() {
+		// Same as for send: the ids must be discarded only after
+		// the rows were updated, while holding cacheManagementMu.
+		mm.cacheManagementMu.Lock()
+		defer mm.cacheManagementMu.Unlock()
+		mm.cache.Discard(ids)
+	}()
+
+	// Dead letters share the semaphore with postpones.
+	if err := mm.postponeSema.Acquire(ctx, 1); err != nil {
+		return
+	}
+	defer mm.postponeSema.Release(1)
+	ctx, cancel := context.WithTimeout(tabletenv.LocalContext(), mm.ackWaitTime)
+	defer cancel()
+	count, err := mm.tsv.DeadLetterMessages(ctx, nil, mm, ids)
+	if err != nil {
+		log.Errorf("messageManager - failed to dead letter messages of %v: %v", mm.name, err)
+		MessageStats.Add([]string{mm.name.String(), "DeadLetterFailed"}, 1)
+		return
+	}
+	if mm.dlqTable.IsEmpty() {
+		MessageStats.Add([]string{mm.name.String(), "Failed"}, count)
+	} else {
+		MessageStats.Add([]string{mm.name.String(), "DeadLettered"}, count)
+	}
+}
+
 func (mm *messageManager) startVStream() {
 	if mm.streamCancel != nil {
 		return
@@ -765,6 +869,11 @@ func (mm *messageManager) processRowEvent(fields []*querypb.Field, rowEvent *bin
 		if mr.TimeAcked != 0 || mr.TimeNext > now {
 			continue
 		}
+		// Messages that were marked as failed have
+		// neither time_acked nor time_next set.
+		if mm.maxEpoch > 0 && row[1].IsNull() {
+			continue
+		}
 		mm.Add(mr)
 	}
 	return nil
@@ -901,6 +1010,88 @@ func (mm *messageManager) GeneratePurgeQuery(timeCutoff int64) (string, map[stri
 	}
 }
 
+// GenerateDeadLetterQueries returns the queries and bind vars for taking
+// the messages that exceeded the max epoch out of circulation. The queries
+// must be executed in the same transaction.
+func (mm *messageManager) GenerateDeadLetterQueries(ids []string) ([]string, map[string]*querypb.BindVariable) {
+	idbvs := &querypb.BindVariable{
+		Type:   querypb.Type_TUPLE,
+		Values: make([]*querypb.Value, 0, len(ids)),
+	}
+	for _, id := range ids {
+		idbvs.Values = append(idbvs.Values, &querypb.Value{
+			Type:  querypb.Type_VARBINARY,
+			Value: []byte(id),
+		})
+	}
+	queries := make([]string, 0, len(mm.deadLetterQueries))
+	for _, pq := range mm.deadLetterQueries {
+		queries = append(queries, pq.Query)
+	}
+	return queries, map[string]*querypb.BindVariable{
+		"max_epoch": sqltypes.Int64BindVariable(mm.maxEpoch),
+		"ids":       idbvs,
+	}
+}
+
+// GenerateRequeueQueries returns the queries and bind vars for putting
+// the dead letters that match the where clause back in circulation.
+// The queries must be executed in the same transaction. If the table
+// has a dead letter table, conflicts is a query that returns the ids of
+// the matching dead letters that already exist in the message table.
+// Those cannot be moved back, and the requeue must be refused if it
+// returns any rows. Otherwise, conflicts is nil.
+func (mm *messageManager) GenerateRequeueQueries(where *sqlparser.Where) (conflicts *sqlparser.ParsedQuery, queries []*sqlparser.ParsedQuery, bv map[string]*querypb.BindVariable) {
+	var expr sqlparser.Expr
+	if where != nil {
+		expr = where.Expr
+	}
+	bv = map[string]*querypb.BindVariable{
+		"time_now": sqltypes.Int64BindVariable(time.Now().UnixNano()),
+	}
+
+	if mm.dlqTable.IsEmpty() {
+		buf := sqlparser.NewTrackedBuffer(nil)
+		buf.Myprintf("update %v set time_next = %a, epoch = 0 where time_acked is null and time_next is null", mm.name, ":time_now")
+		if expr != nil {
+			buf.Myprintf(" and (%v)", expr)
+		}
+		return nil, []*sqlparser.ParsedQuery{buf.ParsedQuery()}, bv
+	}
+
+	buf := sqlparser.NewTrackedBuffer(nil)
+	buf.Myprintf("select id from %v where ", mm.dlqTable)
+	if expr != nil {
+		buf.Myprintf("(%v) and ", expr)
+	}
+	buf.Myprintf("id in (select id from %v) limit %d", mm.name, maxRequeueConflicts)
+	conflicts = buf.ParsedQuery()
+
+	buf = sqlparser.NewTrackedBuffer(nil)
+	buf.Myprintf("insert into %v%v select ", mm.name, mm.columns)
+	for i, col := range mm.columns {
+		if i > 0 {
+			buf.WriteString(", ")
+		}
+		switch {
+		case col.EqualString("time_next"):
+			buf.Myprintf("%a", ":time_now")
+		case col.EqualString("epoch"):
+			buf.WriteString("0")
+		case col.EqualString("time_acked"):
+			buf.WriteString("null")
+		default:
+			buf.Myprintf("%v", col)
+		}
+	}
+	buf.Myprintf(" from %v%v", mm.dlqTable, where)
+	insert := buf.ParsedQuery()
+
+	buf = sqlparser.NewTrackedBuffer(nil)
+	buf.Myprintf("delete from %v%v", mm.dlqTable, where)
+	return conflicts, []*sqlparser.ParsedQuery{insert, buf.ParsedQuery()}, bv
+}
+
 // BuildMessageRow builds a MessageRow from a db row.
 func BuildMessageRow(row []sqltypes.Value) (*MessageRow, error) {
 	mr := &MessageRow{Row: row[4:]}
@@ -29,6 +29,7 @@ import (
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"golang.org/x/sync/semaphore"
 
 	"vitess.io/vitess/go/sqltypes"
@@ -822,10 +823,149 @@ func TestMMGenerateWithBackoff(t *testing.T) {
 	}
 }
 
+func TestMessageManagerDeadLetter(t *testing.T) {
+	tsv := newFakeTabletServer()
+	ti := newMMTable()
+	ti.MessageInfo.MaxEpoch = 2
+	mm := newMessageManager(tsv, newFakeVStreamer(), ti, semaphore.NewWeighted(1))
+	mm.Open()
+	defer mm.Close()
+
+	r1 := newTestReceiver(1)
+	mm.Subscribe(context.Background(), r1.rcv)
+	<-r1.ch
+
+	ch := make(chan string, 20)
+	tsv.SetChannel(ch)
+
+	// A message that was already sent max epoch times must not be sent again.
+	mm.Add(&MessageRow{Epoch: 2, Row: []sqltypes.Value{sqltypes.NewVarBinary("1"), sqltypes.NULL}})
+	if got, want := <-ch, "deadletter"; got != want {
+		t.Errorf("DeadLetter: %s, want %v", got, want)
+	}
+
+	mm.Add(&MessageRow{Epoch: 1, Row: []sqltypes.Value{sqltypes.NewVarBinary("2"), sqltypes.NULL}})
+	want := &sqltypes.Result{
+		Rows: [][]sqltypes.Value{{
+			sqltypes.NewVarBinary("2"),
+			sqltypes.NULL,
+		}},
+	}
+	if got := <-r1.ch; !got.Equal(want) {
+		t.Errorf("Received: %v, want %v", got, want)
+	}
+	if got, want := <-ch, "postpone"; got != want {
+		t.Errorf("Postpone: %s, want %v", got, want)
+	}
+	assert.EqualValues(t, 1, tsv.deadLetterCount.Load())
+
+	// The dead letter must have been removed from the cache.
+	inFlight := true
+	for i := 0; i < 10; i++ {
+		mm.cache.mu.Lock()
+		_, inFlight = mm.cache.inFlight["1"]
+		mm.cache.mu.Unlock()
+		if !inFlight {
+			break
+		}
+		runtime.Gosched()
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.False(t, inFlight)
+}
+
+func TestMMGenerateDeadLetter(t *testing.T) {
+	ti := newMMTable()
+	ti.Fields = []*querypb.Field{
+		{Name: "id", Type: sqltypes.VarBinary},
+		{Name: "priority", Type: sqltypes.Int64},
+		{Name: "time_next", Type: sqltypes.Int64},
+		{Name: "epoch", Type: sqltypes.Int64},
+		{Name: "time_acked", Type: sqltypes.Int64},
+		{Name: "message", Type: sqltypes.VarBinary},
+	}
+	ti.MessageInfo.MaxEpoch = 3
+	where := sqlparser.NewWhere(sqlparser.WhereClause, &sqlparser.ComparisonExpr{
+		Operator: sqlparser.LessThanOp,
+		Left:     sqlparser.NewColName("priority"),
+		Right:    sqlparser.NewArgument("vtg1"),
+	})
+	wantids := sqltypes.TestBindVariable([]any{[]byte{'1'}, []byte{'2'}})
+
+	// Without a dead letter table, messages are marked as failed.
+	mm := newMessageManager(newFakeTabletServer(), newFakeVStreamer(), ti, semaphore.NewWeighted(1))
+	queries, bv := mm.GenerateDeadLetterQueries([]string{"1", "2"})
+	assert.Equal(t, []string{
+		"update foo set time_next = null where id in ::ids and time_acked is null and epoch >= :max_epoch",
+	}, queries)
+	utils.MustMatch(t, map[string]*querypb.BindVariable{
+		"max_epoch": sqltypes.Int64BindVariable(3),
+		"ids":       wantids,
+	}, bv, "did not match")
+
+	conflicts, requeue, bv := mm.GenerateRequeueQueries(where)
+	assert.Nil(t, conflicts)
+	assert.Equal(t, []string{
+		"update foo set time_next = :time_now, epoch = 0 where time_acked is null and time_next is null and (priority < :vtg1)",
+	}, parsedQueryStrings(requeue))
+	require.Contains(t, bv, "time_now")
+	bv["vtg1"] = sqltypes.Int64BindVariable(5)
+	bv["time_now"] = sqltypes.Int64BindVariable(10)
+	sql, err := requeue[0].GenerateQuery(bv, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "update foo set time_next = 10, epoch = 0 where time_acked is null and time_next is null and (priority < 5)", sql)
+
+	_, requeue, _ = mm.GenerateRequeueQueries(nil)
+	assert.Equal(t, []string{
+		"update foo set time_next = :time_now, epoch = 0 where time_acked is null and time_next is null",
+	}, parsedQueryStrings(requeue))
+
+	// With a dead letter table, messages are moved there.
+	ti.MessageInfo.DeadLetterTable = sqlparser.NewIdentifierCS("foo_dlq")
+	mm = newMessageManager(newFakeTabletServer(), newFakeVStreamer(), ti, semaphore.NewWeighted(1))
+	queries, bv = mm.GenerateDeadLetterQueries([]string{"1", "2"})
+	assert.Equal(t, []string{
+		"insert into foo_dlq(id, priority, time_next, epoch, time_acked, message) select id, priority, time_next, epoch, time_acked, message from foo where id in ::ids and time_acked is null and epoch >= :max_epoch",
+		"delete from foo where id in ::ids and time_acked is null and epoch >= :max_epoch",
+	}, queries)
+	utils.MustMatch(t, map[string]*querypb.BindVariable{
+		"max_epoch": sqltypes.Int64BindVariable(3),
+		"ids":       wantids,
+	}, bv, "did not match")
+
+	conflicts, requeue, bv = mm.GenerateRequeueQueries(where)
+	assert.Equal(t, "select id from foo_dlq where (priority < :vtg1) and id in (select id from foo) limit 10", conflicts.Query)
+	assert.Equal(t, []string{
+		"insert into foo(id, priority, time_next, epoch, time_acked, message) select id, priority, :time_now, 0, null, message from foo_dlq where priority < :vtg1",
+		"delete from foo_dlq where priority < :vtg1",
+	}, parsedQueryStrings(requeue))
+	bv["vtg1"] = sqltypes.Int64BindVariable(5)
+	bv["time_now"] = sqltypes.Int64BindVariable(10)
+	sql, err = requeue[0].GenerateQuery(bv, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "insert into foo(id, priority, time_next, epoch, time_acked, message) select id, priority, 10, 0, null, message from foo_dlq where priority < 5", sql)
+
+	conflicts, requeue, _ = mm.GenerateRequeueQueries(nil)
+	assert.Equal(t, "select id from foo_dlq where id in (select id from foo) limit 10", conflicts.Query)
+	assert.Equal(t, []string{
+		"insert into foo(id, priority, time_next, epoch, time_acked, message) select id, priority, :time_now, 0, null, message from foo_dlq",
+		"delete from foo_dlq",
+	}, parsedQueryStrings(requeue))
+}
+
+func parsedQueryStrings(pqs []*sqlparser.ParsedQuery) []string {
+	queries := make([]string, 0, len(pqs))
+	for _, pq := range pqs {
+		queries = append(queries, pq.Query)
+	}
+	return queries
+}
+
 type fakeTabletServer struct {
 	tabletenv.Env
-	postponeCount atomic.Int64
-	purgeCount    atomic.Int64
+	postponeCount   atomic.Int64
+	purgeCount      atomic.Int64
+	deadLetterCount atomic.Int64
 
 	mu sync.Mutex
 	ch chan string
@@ -868,6 +1008,17 @@ func (fts *fakeTabletServer) PurgeMessages(ctx context.Context, target *querypb.
 	return 0, nil
 }
 
+func (fts *fakeTabletServer) DeadLetterMessages(ctx context.Context, target *querypb.Target, gen QueryGenerator, ids []string) (count int64, err error) {
+	fts.deadLetterCount.Add(1)
+	fts.mu.Lock()
+	ch := fts.ch
+	fts.mu.Unlock()
+	if ch != nil {
+		ch <- "deadletter"
+	}
+	return int64(len(ids)), nil
+}
+
 type fakeVStreamer struct {
 	streamInvocations atomic.Int64
 	mu                sync.Mutex
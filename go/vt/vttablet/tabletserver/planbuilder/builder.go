@@ -117,6 +117,22 @@ func analyzeDelete(del *sqlparser.Delete, tables map[string]*schema.Table) (plan
 	return plan, nil
 }
 
+func analyzeRequeue(req *sqlparser.Requeue, tables map[string]*schema.Table) (plan *Plan, err error) {
+	name := req.Table.Name.String()
+	plan = &Plan{
+		PlanID:   PlanRequeueMessages,
+		Table:    tables[name],
+		FullStmt: req,
+	}
+	if plan.Table == nil {
+		return nil, vterrors.Errorf(vtrpcpb.Code_INVALID_ARGUMENT, "table %s not found in schema", name)
+	}
+	if plan.Table.Type != schema.Message {
+		return nil, vterrors.Errorf(vtrpcpb.Code_FAILED_PRECONDITION, "'%s' is not a message table", name)
+	}
+	return plan, nil
+}
+
 func analyzeInsert(ins *sqlparser.Insert, tables map[string]*schema.Table) (plan *Plan, err error) {
 	plan = &Plan{
 		PlanID:    PlanInsert,
@@ -47,6 +47,8 @@ func BuildPermissions(stmt sqlparser.Statement) []Permission {
 	case *sqlparser.Delete:
 		permissions = buildTableExprsPermissions(node.TableExprs, tableacl.WRITER, permissions)
 		permissions = buildSubqueryPermissions(node, tableacl.READER, permissions)
+	case *sqlparser.Requeue:
+		permissions = buildTableNamePermissions(node.Table, tableacl.WRITER, permissions)
 	case sqlparser.DDLStatement:
 		for _, t := range node.AffectedTables() {
 			permissions = buildTableNamePermissions(t, tableacl.ADMIN, permissions)
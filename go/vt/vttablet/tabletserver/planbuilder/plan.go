@@ -80,6 +80,8 @@ const (
 	PlanShowMigrationLogs
 	PlanShowThrottledApps
 	PlanShowThrottlerStatus
+	// PlanRequeueMessages is for "requeue" statements.
+	PlanRequeueMessages
 	NumPlans
 )
 
@@ -116,6 +118,7 @@ var planName = []string{
 	"ShowMigrationLogs",
 	"ShowThrottledApps",
 	"ShowThrottlerStatus",
+	"RequeueMessages",
 }
 
 func (pt PlanType) String() string {
@@ -249,6 +252,8 @@ func Build(statement sqlparser.Statement, tables map[string]*schema.Table, dbNam
 		plan, err = &Plan{PlanID: PlanFlush, FullQuery: GenerateFullQuery(stmt)}, nil
 	case *sqlparser.CallProc:
 		plan, err = &Plan{PlanID: PlanCallProc, FullQuery: GenerateFullQuery(stmt)}, nil
+	case *sqlparser.Requeue:
+		plan, err = analyzeRequeue(stmt, tables)
 	default:
 		return nil, vterrors.New(vtrpcpb.Code_INVALID_ARGUMENT, "invalid SQL")
 	}
@@ -39,6 +39,7 @@ import (
 	"vitess.io/vitess/go/vt/vterrors"
 	"vitess.io/vitess/go/vt/vtgate/evalengine"
 	"vitess.io/vitess/go/vt/vttablet/tabletserver/connpool"
+	"vitess.io/vitess/go/vt/vttablet/tabletserver/messager"
 	p "vitess.io/vitess/go/vt/vttablet/tabletserver/planbuilder"
 	"vitess.io/vitess/go/vt/vttablet/tabletserver/rules"
 	eschema "vitess.io/vitess/go/vt/vttablet/tabletserver/schema"
@@ -62,6 +63,11 @@ type QueryExecutor struct {
 	tsv            *TabletServer
 	tabletType     topodatapb.TabletType
 	setting        *pools.Setting
+
+	// timeout is the query timeout of the request. It depends on the
+	// workload, which the query rules can change, so it is only applied
+	// to ctx once the rules have been checked.
+	timeout time.Duration
 }
 
 const (
@@ -144,9 +150,13 @@ func (qre *QueryExecutor) Execute() (reply *sqltypes.Result, err error) {
 		qre.tsv.Stats().ResultHistogram.Add(int64(len(reply.Rows)))
 	}(time.Now())
 
-	if err = qre.checkPermissions(); err != nil {
+	release, err := qre.checkPermissions()
+	if err != nil {
 		return nil, err
 	}
+	defer release()
+	cancel := qre.applyTimeout()
+	defer cancel()
 
 	if qre.plan.PlanID == p.PlanNextval {
 		return qre.execNextval()
@@ -187,7 +197,7 @@ func (qre *QueryExecutor) Execute() (reply *sqltypes.Result, err error) {
 		return qre.execOther()
 	case p.PlanInsert, p.PlanUpdate, p.PlanDelete, p.PlanInsertMessage, p.PlanDDL, p.PlanLoad:
 		return qre.execAutocommit(qre.txConnExec)
-	case p.PlanUpdateLimit, p.PlanDeleteLimit:
+	case p.PlanUpdateLimit, p.PlanDeleteLimit, p.PlanRequeueMessages:
 		return qre.execAsTransaction(qre.txConnExec)
 	case p.PlanCallProc:
 		return qre.execCallProc()
@@ -232,6 +242,15 @@ func (qre *QueryExecutor) execAutocommit(f func(conn *StatefulConnection) (*sqlt
 	}
 	defer qre.tsv.te.txPool.RollbackAndRelease(qre.ctx, conn)
 
+	if qre.options.SessionTrackGtids {
+		// Have MySQL report the GTID of the transaction it commits in the
+		// session state changes of the result.
+		if _, err := conn.Exec(qre.ctx, trackOwnGtidQuery, 1, false); err != nil {
+			return nil, err
+		}
+		defer untrackGtids(qre.ctx, conn)
+	}
+
 	return f(conn)
 }
 
@@ -260,9 +279,13 @@ func (qre *QueryExecutor) execAsTransaction(f func(conn *StatefulConnection) (*s
 	}
 
 	defer qre.logStats.AddRewrittenSQL("commit", time.Now())
-	if _, err := qre.tsv.te.txPool.Commit(qre.ctx, conn); err != nil {
+	_, sessionStateChanges, err := qre.tsv.te.txPool.Commit(qre.ctx, conn)
+	if err != nil {
 		return nil, err
 	}
+	if sessionStateChanges != "" {
+		result.SessionStateChanges = sessionStateChanges
+	}
 	return result, nil
 }
 
@@ -275,6 +298,8 @@ func (qre *QueryExecutor) txConnExec(conn *StatefulConnection) (*sqltypes.Result
 		return qre.txFetch(conn, true)
 	case p.PlanUpdateLimit, p.PlanDeleteLimit:
 		return qre.execDMLLimit(conn)
+	case p.PlanRequeueMessages:
+		return qre.execRequeueMessages(conn)
 	case p.PlanOtherRead, p.PlanOtherAdmin, p.PlanFlush:
 		return qre.execStatefulConn(conn, qre.query, true)
 	case p.PlanSavepoint, p.PlanRelease, p.PlanSRollback:
@@ -312,9 +337,13 @@ func (qre *QueryExecutor) Stream(callback StreamCallback) error {
 		qre.recordUserQuery("Stream", int64(time.Since(start)))
 	}(time.Now())
 
-	if err := qre.checkPermissions(); err != nil {
+	release, err := qre.checkPermissions()
+	if err != nil {
 		return err
 	}
+	defer release()
+	cancel := qre.applyTimeout()
+	defer cancel()
 
 	switch qre.plan.PlanID {
 	case p.PlanSelectStream:
@@ -401,9 +430,11 @@ func (qre *QueryExecutor) MessageStream(callback StreamCallback) error {
 		qre.recordUserQuery("MessageStream", int64(time.Since(start)))
 	}(time.Now())
 
-	if err := qre.checkPermissions(); err != nil {
+	release, err := qre.checkPermissions()
+	if err != nil {
 		return err
 	}
+	defer release()
 
 	done, err := qre.tsv.messager.Subscribe(qre.ctx, qre.plan.TableName().String(), func(r *sqltypes.Result) error {
 		select {
@@ -421,11 +452,13 @@ func (qre *QueryExecutor) MessageStream(callback StreamCallback) error {
 }
 
 // checkPermissions returns an error if the query does not pass all checks
-// (denied query, table ACL).
-func (qre *QueryExecutor) checkPermissions() error {
+// (denied query, query rule limits, table ACL). Otherwise, the returned func
+// must be called once the query is done, to release the query rule limits.
+func (qre *QueryExecutor) checkPermissions() (func(), error) {
+	noRelease := func() {}
 	// Skip permissions check if the context is local.
 	if tabletenv.IsLocalContext(qre.ctx) {
-		return nil
+		return noRelease, nil
 	}
 
 	// Check if the query relates to a table that is in the denylist.
@@ -437,18 +470,22 @@ func (qre *QueryExecutor) checkPermissions() error {
 		username = ci.Username()
 	}
 
-	action, ruleCancelCtx, timeout, desc := qre.plan.Rules.GetAction(remoteAddr, username, qre.bindVars, qre.marginComments)
-
-	bufferingTimeoutCtx, cancel := context.WithTimeout(qre.ctx, timeout) // aborts buffering at given timeout
-	defer cancel()
+	rule := qre.plan.Rules.GetRule(remoteAddr, username, qre.bindVars, qre.marginComments)
+	if rule == nil {
+		// no rules against this query. Good to proceed
+		return noRelease, qre.checkACL(username)
+	}
 
-	switch action {
+	switch rule.Action() {
 	case rules.QRFail:
-		return vterrors.Errorf(vtrpcpb.Code_INVALID_ARGUMENT, "disallowed due to rule: %s", desc)
+		return nil, vterrors.Errorf(vtrpcpb.Code_INVALID_ARGUMENT, "disallowed due to rule: %s", rule.Description)
 	case rules.QRFailRetry:
-		return vterrors.Errorf(vtrpcpb.Code_FAILED_PRECONDITION, "disallowed due to rule: %s", desc)
+		return nil, vterrors.Errorf(vtrpcpb.Code_FAILED_PRECONDITION, "disallowed due to rule: %s", rule.Description)
 	case rules.QRBuffer:
-		if ruleCancelCtx != nil {
+		if ruleCancelCtx := rule.CancelCtx(); ruleCancelCtx != nil {
+			bufferingTimeoutCtx, cancel := context.WithTimeout(qre.ctx, rule.Timeout()) // aborts buffering at given timeout
+			defer cancel()
+
 			// We buffer up to some timeout. The timeout is determined by ctx.Done().
 			// If we're not at timeout yet, we fail the query
 			select {
@@ -456,12 +493,65 @@ func (qre *QueryExecutor) checkPermissions() error {
 				// good! We have buffered the query, and buffering is completed
 			case <-bufferingTimeoutCtx.Done():
 				// Sorry, timeout while waiting for buffering to complete
-				return vterrors.Errorf(vtrpcpb.Code_FAILED_PRECONDITION, "buffer timeout after %v in rule: %s", timeout, desc)
+				return nil, vterrors.Errorf(vtrpcpb.Code_FAILED_PRECONDITION, "buffer timeout after %v in rule: %s", rule.Timeout(), rule.Description)
 			}
 		}
-	default:
-		// no rules against this query. Good to proceed
 	}
+	if err := qre.checkACL(username); err != nil {
+		return nil, err
+	}
+	return qre.applyRule(rule)
+}
+
+// applyRule enforces the limits of the rule fired by the query, and applies
+// its execution time cap and workload. It returns the func that releases
+// what the query holds.
+func (qre *QueryExecutor) applyRule(rule *rules.Rule) (func(), error) {
+	release := func() {}
+	switch rule.Action() {
+	case rules.QRRateLimit:
+		if !rule.AllowQuery() {
+			qre.tsv.Stats().QueryRuleThrottled.Add(rule.Name, 1)
+			return nil, vterrors.Errorf(vtrpcpb.Code_RESOURCE_EXHAUSTED, "rate limit of %d QPS exceeded due to rule: %s", rule.MaxQPS(), rule.Description)
+		}
+	case rules.QRConcurrencyLimit:
+		var ok bool
+		if release, ok = rule.AcquireConcurrency(); !ok {
+			qre.tsv.Stats().QueryRuleThrottled.Add(rule.Name, 1)
+			return nil, vterrors.Errorf(vtrpcpb.Code_RESOURCE_EXHAUSTED, "concurrency limit of %d exceeded due to rule: %s", rule.MaxConcurrency(), rule.Description)
+		}
+	}
+	if workload := rule.Workload(); workload != querypb.ExecuteOptions_UNSPECIFIED && workload != qre.options.GetWorkload() {
+		if qre.options == nil {
+			qre.options = &querypb.ExecuteOptions{}
+		} else {
+			qre.options = proto.Clone(qre.options).(*querypb.ExecuteOptions)
+		}
+		qre.options.Workload = workload
+	}
+	if maxExecutionTime := rule.MaxExecutionTime(); maxExecutionTime != 0 {
+		// The query is killed once its context expires.
+		ctx, cancel := context.WithTimeout(qre.ctx, maxExecutionTime)
+		qre.ctx = ctx
+		releaseConcurrency := release
+		release = func() {
+			cancel()
+			releaseConcurrency()
+		}
+	}
+	return release, nil
+}
+
+// applyTimeout bounds the context of the query by the query timeout of its
+// workload. It returns the func that releases the context.
+func (qre *QueryExecutor) applyTimeout() context.CancelFunc {
+	ctx, cancel := withTimeout(qre.ctx, qre.timeout, qre.options)
+	qre.ctx = ctx
+	return cancel
+}
+
+// checkACL returns an error if the caller is not allowed to access the tables of the query.
+func (qre *QueryExecutor) checkACL(username string) error {
 	// Skip ACL check for queries against the dummy dual table
 	if qre.plan.TableName().String() == "dual" {
 		return nil
@@ -590,6 +680,57 @@ func (qre *QueryExecutor) execLoad(conn *StatefulConnection) (*sqltypes.Result,
 	return result, nil
 }
 
+// execRequeueMessages puts the dead letters of a message table
+// back in circulation. The queries come from the messager, and
+// are executed within the transaction of conn.
+func (qre *QueryExecutor) execRequeueMessages(conn *StatefulConnection) (*sqltypes.Result, error) {
+	name := qre.plan.TableName().String()
+	querygen, err := qre.tsv.messager.GetGenerator(name)
+	if err != nil {
+		return nil, err
+	}
+	conflicts, queries, bv := querygen.GenerateRequeueQueries(qre.plan.FullStmt.(*sqlparser.Requeue).Where)
+	for k, v := range bv {
+		qre.bindVars[k] = v
+	}
+
+	if conflicts != nil {
+		sql, _, err := qre.generateFinalSQL(conflicts, qre.bindVars)
+		if err != nil {
+			return nil, err
+		}
+		qr, err := qre.execStatefulConn(conn, sql, true)
+		if err != nil {
+			return nil, err
+		}
+		if len(qr.Rows) > 0 {
+			ids := make([]string, 0, len(qr.Rows))
+			for _, row := range qr.Rows {
+				ids = append(ids, row[0].ToString())
+			}
+			return nil, vterrors.Errorf(vtrpcpb.Code_ALREADY_EXISTS, "cannot requeue dead letters of %s: ids %s already exist in the message table", name, strings.Join(ids, ", "))
+		}
+	}
+
+	var rowsAffected uint64
+	for i, query := range queries {
+		sql, _, err := qre.generateFinalSQL(query, qre.bindVars)
+		if err != nil {
+			return nil, err
+		}
+		qr, err := qre.execStatefulConn(conn, sql, true)
+		if err != nil {
+			return nil, err
+		}
+		conn.TxProperties().RecordQuery(sql)
+		if i == 0 {
+			rowsAffected = qr.RowsAffected
+		}
+	}
+	messager.MessageStats.Add([]string{name, "Requeued"}, int64(rowsAffected))
+	return &sqltypes.Result{RowsAffected: rowsAffected}, nil
+}
+
 // BeginAgain commits the existing transaction and begins a new one
 func (*QueryExecutor) BeginAgain(ctx context.Context, dc *StatefulConnection) error {
 	if dc.IsClosed() || dc.TxProperties().Autocommit {
@@ -23,6 +23,7 @@ import (
 	"math/rand"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -665,6 +666,39 @@ func TestQueryExecutorPlanPassSelectWithLockOutsideATransaction(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestQueryExecutorSessionTrackGtids(t *testing.T) {
+	db := setUpQueryExecutorTest(t)
+	defer db.Close()
+	query := "update test_table set a = 1"
+	want := &sqltypes.Result{
+		RowsAffected:        1,
+		SessionStateChanges: "3e11fa47-71ca-11e1-9e33-c80aa9429562:23",
+	}
+	db.AddQuery(query, want)
+	db.AddQuery(trackOwnGtidQuery, &sqltypes.Result{})
+	db.AddQuery(untrackGtidQuery, &sqltypes.Result{})
+	ctx := context.Background()
+	tsv := newTestTabletServer(ctx, noFlags, db)
+	defer tsv.StopService()
+	tsv.SetPassthroughDMLs(true)
+
+	qre := newTestQueryExecutor(ctx, tsv, query, 0)
+	qre.options = &querypb.ExecuteOptions{SessionTrackGtids: true}
+	got, err := qre.Execute()
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, got.RowsAffected)
+	assert.Equal(t, want.SessionStateChanges, got.SessionStateChanges)
+	assert.Equal(t, 1, db.GetQueryCalledNum(trackOwnGtidQuery))
+	assert.Equal(t, 1, db.GetQueryCalledNum(untrackGtidQuery))
+
+	// Without the option, the GTIDs are not tracked.
+	qre = newTestQueryExecutor(ctx, tsv, query, 0)
+	_, err = qre.Execute()
+	require.NoError(t, err)
+	assert.Equal(t, 1, db.GetQueryCalledNum(trackOwnGtidQuery))
+	assert.Equal(t, 1, db.GetQueryCalledNum(untrackGtidQuery))
+}
+
 func TestQueryExecutorPlanNextval(t *testing.T) {
 	db := setUpQueryExecutorTest(t)
 	defer db.Close()
@@ -850,6 +884,59 @@ func TestQueryExecutorMessageStreamACL(t *testing.T) {
 	}
 }
 
+func TestQueryExecutorRequeueMessages(t *testing.T) {
+	db := setUpQueryExecutorTest(t)
+	defer db.Close()
+	db.AddQueryPattern(`update msg set time_next = \d+, epoch = 0 where time_acked is null and time_next is null and \(id in \(1, 2\)\)`, &sqltypes.Result{RowsAffected: 2})
+
+	ctx := context.Background()
+	tsv := newTestTabletServer(ctx, noFlags, db)
+	defer tsv.StopService()
+
+	qre := newTestQueryExecutor(ctx, tsv, "requeue msg where id in (1, 2)", 0)
+	assert.Equal(t, planbuilder.PlanRequeueMessages, qre.plan.PlanID)
+	got, err := qre.Execute()
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, got.RowsAffected)
+
+	// Inside a transaction.
+	target := tsv.sm.Target()
+	state, err := tsv.Begin(ctx, target, nil)
+	require.NoError(t, err)
+	defer tsv.Commit(ctx, target, state.TransactionID)
+	qre = newTestQueryExecutor(ctx, tsv, "requeue msg where id in (1, 2)", state.TransactionID)
+	got, err = qre.Execute()
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, got.RowsAffected)
+}
+
+func TestQueryExecutorRequeueDeadLetters(t *testing.T) {
+	db := setUpQueryExecutorTest(t)
+	defer db.Close()
+	conflicts := `select id from dlmsg_dead where \(id in \(1, 2\)\) and id in \(select id from dlmsg\) limit 10`
+	db.AddQueryPattern(conflicts, &sqltypes.Result{})
+	db.AddQueryPattern(`insert into dlmsg\(id, priority, time_next, epoch, time_acked, message\) select id, priority, \d+, 0, null, message from dlmsg_dead where id in \(1, 2\)`, &sqltypes.Result{RowsAffected: 2})
+	db.AddQuery("delete from dlmsg_dead where id in (1, 2)", &sqltypes.Result{RowsAffected: 2})
+
+	ctx := context.Background()
+	tsv := newTestTabletServer(ctx, noFlags, db)
+	defer tsv.StopService()
+
+	qre := newTestQueryExecutor(ctx, tsv, "requeue dlmsg where id in (1, 2)", 0)
+	got, err := qre.Execute()
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, got.RowsAffected)
+
+	// An id that is already back in the message table fails the
+	// requeue, and nothing is moved.
+	db.AddQueryPattern(conflicts, sqltypes.MakeTestResult(sqltypes.MakeTestFields("id", "int64"), "2"))
+	db.RejectQueryPattern(`insert into dlmsg.*`, "insert must not run")
+	qre = newTestQueryExecutor(ctx, tsv, "requeue dlmsg where id in (1, 2)", 0)
+	_, err = qre.Execute()
+	assert.EqualError(t, err, "cannot requeue dead letters of dlmsg: ids 2 already exist in the message table")
+	assert.Equal(t, vtrpcpb.Code_ALREADY_EXISTS, vterrors.Code(err))
+}
+
 func TestQueryExecutorTableAcl(t *testing.T) {
 	aclName := fmt.Sprintf("simpleacl-test-%d", rand.Int63())
 	tableacl.Register(aclName, &simpleacl.Factory{})
@@ -1235,6 +1322,120 @@ func TestQueryExecutorDenyListQRRetry(t *testing.T) {
 	}
 }
 
+func TestQueryExecutorQueryRuleLimits(t *testing.T) {
+	db := setUpQueryExecutorTest(t)
+	defer db.Close()
+	query := "select * from test_table limit 1000"
+	db.AddQuery(query, &sqltypes.Result{
+		Fields: getTestTableFields(),
+	})
+	db.AddQuery("select * from test_table where 1 != 1", &sqltypes.Result{
+		Fields: getTestTableFields(),
+	})
+
+	ctx := callinfo.NewContext(context.Background(), &fakecallinfo.FakeCallInfo{
+		Remote: "127.0.0.1",
+		User:   "u1",
+	})
+	tsv := newTestTabletServer(ctx, noFlags, db)
+	defer tsv.StopService()
+
+	rulesName := "queryRuleLimits"
+	tsv.qe.queryRuleSources.RegisterSource(rulesName)
+	defer tsv.qe.queryRuleSources.UnRegisterSource(rulesName)
+	setRule := func(rule *rules.Rule) {
+		qrs := rules.New()
+		qrs.Add(rule)
+		err := tsv.SetQueryRules(rulesName, qrs)
+		require.NoError(t, err)
+	}
+
+	t.Run("rate limit", func(t *testing.T) {
+		rule := rules.NewQueryRule("rate limit test_table", "rate_limit", rules.QRRateLimit)
+		rule.AddTableCond("test_table")
+		rule.SetMaxQPS(1)
+		setRule(rule)
+
+		throttled := tsv.stats.QueryRuleThrottled.Counts()["rate_limit"]
+		_, err := newTestQueryExecutor(ctx, tsv, query, 0).Execute()
+		require.NoError(t, err)
+		_, err = newTestQueryExecutor(ctx, tsv, query, 0).Execute()
+		assert.Equal(t, vtrpcpb.Code_RESOURCE_EXHAUSTED, vterrors.Code(err))
+		assert.ErrorContains(t, err, "rate limit of 1 QPS exceeded due to rule: rate limit test_table")
+		assert.EqualValues(t, throttled+1, tsv.stats.QueryRuleThrottled.Counts()["rate_limit"])
+	})
+
+	t.Run("concurrency limit", func(t *testing.T) {
+		rule := rules.NewQueryRule("cap test_table", "concurrency_limit", rules.QRConcurrencyLimit)
+		rule.AddTableCond("test_table")
+		rule.SetMaxConcurrency(1)
+		setRule(rule)
+
+		// Hold the only execution slot while another query comes in.
+		release, ok := rule.AcquireConcurrency()
+		require.True(t, ok)
+		_, err := newTestQueryExecutor(ctx, tsv, query, 0).Execute()
+		assert.Equal(t, vtrpcpb.Code_RESOURCE_EXHAUSTED, vterrors.Code(err))
+		assert.ErrorContains(t, err, "concurrency limit of 1 exceeded due to rule: cap test_table")
+		release()
+
+		// The slot is released once the query is done.
+		for i := 0; i < 2; i++ {
+			_, err = newTestQueryExecutor(ctx, tsv, query, 0).Execute()
+			require.NoError(t, err)
+		}
+	})
+
+	t.Run("rewrite", func(t *testing.T) {
+		rule := rules.NewQueryRule("rewrite test_table", "rewrite", rules.QRRewrite)
+		rule.AddTableCond("test_table")
+		rule.SetMaxExecutionTime(time.Minute)
+		rule.SetWorkload(querypb.ExecuteOptions_OLAP)
+		setRule(rule)
+
+		qre := newTestQueryExecutor(ctx, tsv, query, 0)
+		options := qre.options
+		_, err := qre.Execute()
+		require.NoError(t, err)
+		assert.Equal(t, querypb.ExecuteOptions_OLAP, qre.options.GetWorkload())
+		assert.NotEqual(t, querypb.ExecuteOptions_OLAP, options.GetWorkload(), "the options of the caller must not change")
+		deadline, ok := qre.ctx.Deadline()
+		require.True(t, ok)
+		assert.WithinDuration(t, time.Now().Add(time.Minute), deadline, 10*time.Second)
+		assert.Error(t, qre.ctx.Err(), "the execution time context is released once the query is done")
+	})
+
+	t.Run("workload timeout", func(t *testing.T) {
+		rule := rules.NewQueryRule("dba test_table", "dba", rules.QRRewrite)
+		rule.AddTableCond("test_table")
+		rule.SetWorkload(querypb.ExecuteOptions_DBA)
+		setRule(rule)
+
+		// The query timeout doesn't apply to the workload forced by the rule.
+		qre := newTestQueryExecutor(ctx, tsv, query, 0)
+		qre.timeout = time.Minute
+		_, err := qre.Execute()
+		require.NoError(t, err)
+		_, ok := qre.ctx.Deadline()
+		assert.False(t, ok)
+
+		rule = rules.NewQueryRule("oltp test_table", "oltp", rules.QRRewrite)
+		rule.AddTableCond("test_table")
+		rule.SetWorkload(querypb.ExecuteOptions_OLTP)
+		setRule(rule)
+
+		// The query timeout applies to the workload forced by the rule.
+		qre = newTestQueryExecutor(ctx, tsv, query, 0)
+		qre.options = &querypb.ExecuteOptions{Workload: querypb.ExecuteOptions_DBA}
+		qre.timeout = time.Minute
+		_, err = qre.Execute()
+		require.NoError(t, err)
+		deadline, ok := qre.ctx.Deadline()
+		require.True(t, ok)
+		assert.WithinDuration(t, time.Now().Add(time.Minute), deadline, 10*time.Second)
+	})
+}
+
 func TestReplaceSchemaName(t *testing.T) {
 	db := setUpQueryExecutorTest(t)
 	defer db.Close()
@@ -1555,6 +1756,7 @@ func initQueryExecutorTestDB(db *fakesqldb.DB) {
 			mysql.BaseShowTablesRow("test_table", false, ""),
 			mysql.BaseShowTablesRow("seq", false, "vitess_sequence"),
 			mysql.BaseShowTablesRow("msg", false, "vitess_message,vt_ack_wait=30,vt_purge_after=120,vt_batch_size=1,vt_cache_size=10,vt_poller_interval=30"),
+			mysql.BaseShowTablesRow("dlmsg", false, "vitess_message,vt_ack_wait=30,vt_purge_after=120,vt_batch_size=1,vt_cache_size=10,vt_poller_interval=30,vt_max_epoch=3,vt_dead_letter_table=dlmsg_dead"),
 		},
 	})
 	db.AddQuery("show status like 'Innodb_rows_read'", sqltypes.MakeTestResult(sqltypes.MakeTestFields(
@@ -1647,6 +1849,7 @@ func addQueryExecutorSupportedQueries(db *fakesqldb.DB) {
 				mysql.ShowPrimaryRow("test_table", "pk"),
 				mysql.ShowPrimaryRow("seq", "id"),
 				mysql.ShowPrimaryRow("msg", "id"),
+				mysql.ShowPrimaryRow("dlmsg", "id"),
 			},
 		},
 		"begin":    {},
@@ -1707,6 +1910,27 @@ func addQueryExecutorSupportedQueries(db *fakesqldb.DB) {
 			Type: sqltypes.Int64,
 		}},
 	})
+	db.MockQueriesForTable("dlmsg", &sqltypes.Result{
+		Fields: []*querypb.Field{{
+			Name: "id",
+			Type: sqltypes.Int64,
+		}, {
+			Name: "priority",
+			Type: sqltypes.Int64,
+		}, {
+			Name: "time_next",
+			Type: sqltypes.Int64,
+		}, {
+			Name: "epoch",
+			Type: sqltypes.Int64,
+		}, {
+			Name: "time_acked",
+			Type: sqltypes.Int64,
+		}, {
+			Name: "message",
+			Type: sqltypes.Int64,
+		}},
+	})
 }
 
 func TestQueryExecSchemaReloadCount(t *testing.T) {
@@ -45,7 +45,7 @@ func (cached *Rule) CachedSize(alloc bool) int64 {
 	}
 	size := int64(0)
 	if alloc {
-		size += int64(256)
+		size += int64(320)
 	}
 	// field Description string
 	size += hack.RuntimeAllocSize(int64(len(cached.Description)))
@@ -79,6 +79,12 @@ func (cached *Rule) CachedSize(alloc bool) int64 {
 			size += elem.CachedSize(false)
 		}
 	}
+	// field rateLimiter *vitess.io/vitess/go/ratelimiter.RateLimiter
+	size += cached.rateLimiter.CachedSize(true)
+	// field concurrency *sync/atomic.Int64
+	if cached.concurrency != nil {
+		size += hack.RuntimeAllocSize(int64(8))
+	}
 	return size
 }
 func (cached *Rules) CachedSize(alloc bool) int64 {
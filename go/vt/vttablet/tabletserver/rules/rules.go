@@ -24,10 +24,13 @@ import (
 	"reflect"
 	"regexp"
 	"strconv"
+	"strings"
+	"sync/atomic"
 	"time"
 
 	"vitess.io/vitess/go/vt/vtgate/evalengine"
 
+	"vitess.io/vitess/go/ratelimiter"
 	"vitess.io/vitess/go/sqltypes"
 	"vitess.io/vitess/go/vt/sqlparser"
 	"vitess.io/vitess/go/vt/vterrors"
@@ -183,12 +186,26 @@ func (qrs *Rules) GetAction(
 	cancelCtx context.Context,
 	timeout time.Duration,
 	desc string) {
+	if qr := qrs.GetRule(ip, user, bindVars, marginComments); qr != nil {
+		return qr.act, qr.cancelCtx, qr.timeout, qr.Description
+	}
+	return QRContinue, nil, 0, ""
+}
+
+// GetRule runs the input against the rules engine and returns the first rule
+// that fires. It returns nil if no rule fires.
+func (qrs *Rules) GetRule(
+	ip,
+	user string,
+	bindVars map[string]*querypb.BindVariable,
+	marginComments sqlparser.MarginComments,
+) *Rule {
 	for _, qr := range qrs.rules {
 		if act := qr.GetAction(ip, user, bindVars, marginComments); act != QRContinue {
-			return act, qr.cancelCtx, qr.timeout, qr.Description
+			return qr
 		}
 	}
-	return QRContinue, nil, 0, ""
+	return nil
 }
 
 //-----------------------------------------------
@@ -227,6 +244,19 @@ type Rule struct {
 
 	// a rule can timeout.
 	timeout time.Duration
+
+	// limits enforced by the RATE_LIMIT and CONCURRENCY_LIMIT actions.
+	maxQPS, maxConcurrency int
+
+	// a rule can cap the execution time of the queries it lets through,
+	// and change the workload they are executed as.
+	maxExecutionTime time.Duration
+	workload         querypb.ExecuteOptions_Workload
+
+	// limiter state. It is shared by all the copies of a rule, so the
+	// limits apply across all the plans the rule was filtered into.
+	rateLimiter *ratelimiter.RateLimiter
+	concurrency *atomic.Int64
 }
 
 type namedRegexp struct {
@@ -272,6 +302,10 @@ func (qr *Rule) Equal(other *Rule) bool {
 		qr.leadingComment.Equal(other.leadingComment) &&
 		qr.trailingComment.Equal(other.trailingComment) &&
 		qr.timeout == other.timeout &&
+		qr.maxQPS == other.maxQPS &&
+		qr.maxConcurrency == other.maxConcurrency &&
+		qr.maxExecutionTime == other.maxExecutionTime &&
+		qr.workload == other.workload &&
 		reflect.DeepEqual(qr.plans, other.plans) &&
 		reflect.DeepEqual(qr.tableNames, other.tableNames) &&
 		reflect.DeepEqual(qr.bindVarConds, other.bindVarConds) &&
@@ -281,16 +315,22 @@ func (qr *Rule) Equal(other *Rule) bool {
 // Copy performs a deep copy of a Rule.
 func (qr *Rule) Copy() (newqr *Rule) {
 	newqr = &Rule{
-		Description:     qr.Description,
-		Name:            qr.Name,
-		requestIP:       qr.requestIP,
-		user:            qr.user,
-		query:           qr.query,
-		leadingComment:  qr.leadingComment,
-		trailingComment: qr.trailingComment,
-		act:             qr.act,
-		cancelCtx:       qr.cancelCtx,
-		timeout:         qr.timeout,
+		Description:      qr.Description,
+		Name:             qr.Name,
+		requestIP:        qr.requestIP,
+		user:             qr.user,
+		query:            qr.query,
+		leadingComment:   qr.leadingComment,
+		trailingComment:  qr.trailingComment,
+		act:              qr.act,
+		cancelCtx:        qr.cancelCtx,
+		timeout:          qr.timeout,
+		maxQPS:           qr.maxQPS,
+		maxConcurrency:   qr.maxConcurrency,
+		maxExecutionTime: qr.maxExecutionTime,
+		workload:         qr.workload,
+		rateLimiter:      qr.rateLimiter,
+		concurrency:      qr.concurrency,
 	}
 	if qr.plans != nil {
 		newqr.plans = make([]planbuilder.PlanType, len(qr.plans))
@@ -342,6 +382,18 @@ func (qr *Rule) MarshalJSON() ([]byte, error) {
 	if qr.timeout != 0 {
 		safeEncode(b, `,"Timeout":`, qr.timeout)
 	}
+	if qr.maxQPS != 0 {
+		safeEncode(b, `,"MaxQPS":`, qr.maxQPS)
+	}
+	if qr.maxConcurrency != 0 {
+		safeEncode(b, `,"MaxConcurrency":`, qr.maxConcurrency)
+	}
+	if qr.maxExecutionTime != 0 {
+		safeEncode(b, `,"MaxExecutionTime":`, qr.maxExecutionTime.Milliseconds())
+	}
+	if qr.workload != querypb.ExecuteOptions_UNSPECIFIED {
+		safeEncode(b, `,"Workload":`, qr.workload.String())
+	}
 	_, _ = b.WriteString("}")
 	return b.Bytes(), nil
 }
@@ -397,6 +449,87 @@ func (qr *Rule) SetTrailingCommentCond(pattern string) (err error) {
 	return
 }
 
+// SetMaxQPS sets the number of queries per second the RATE_LIMIT action
+// lets through. Queries above the limit are rejected.
+func (qr *Rule) SetMaxQPS(maxQPS int) {
+	qr.maxQPS = maxQPS
+	qr.rateLimiter = ratelimiter.NewRateLimiter(maxQPS, time.Second)
+}
+
+// SetMaxConcurrency sets the number of queries the CONCURRENCY_LIMIT action
+// lets execute at the same time. Queries above the limit are rejected.
+func (qr *Rule) SetMaxConcurrency(maxConcurrency int) {
+	qr.maxConcurrency = maxConcurrency
+	qr.concurrency = &atomic.Int64{}
+}
+
+// SetMaxExecutionTime caps the execution time of the queries the rule lets through.
+func (qr *Rule) SetMaxExecutionTime(maxExecutionTime time.Duration) {
+	qr.maxExecutionTime = maxExecutionTime
+}
+
+// SetWorkload forces the workload of the queries the rule lets through.
+func (qr *Rule) SetWorkload(workload querypb.ExecuteOptions_Workload) {
+	qr.workload = workload
+}
+
+// Action returns the action of the rule.
+func (qr *Rule) Action() Action {
+	return qr.act
+}
+
+// CancelCtx returns the context that cancels the rule, if any.
+func (qr *Rule) CancelCtx() context.Context {
+	return qr.cancelCtx
+}
+
+// Timeout returns the buffering timeout of the rule.
+func (qr *Rule) Timeout() time.Duration {
+	return qr.timeout
+}
+
+// MaxQPS returns the rate limit of the rule.
+func (qr *Rule) MaxQPS() int {
+	return qr.maxQPS
+}
+
+// MaxConcurrency returns the concurrency limit of the rule.
+func (qr *Rule) MaxConcurrency() int {
+	return qr.maxConcurrency
+}
+
+// MaxExecutionTime returns the execution time cap of the rule, 0 if none.
+func (qr *Rule) MaxExecutionTime() time.Duration {
+	return qr.maxExecutionTime
+}
+
+// Workload returns the workload forced by the rule, UNSPECIFIED if none.
+func (qr *Rule) Workload() querypb.ExecuteOptions_Workload {
+	return qr.workload
+}
+
+// AllowQuery returns false if the query exceeds the rate limit of the rule.
+func (qr *Rule) AllowQuery() bool {
+	if qr.rateLimiter == nil {
+		return true
+	}
+	return qr.rateLimiter.Allow()
+}
+
+// AcquireConcurrency reserves an execution slot for a query. It returns
+// false if the concurrency limit of the rule is reached. Otherwise, the
+// returned release func must be called once the query is done.
+func (qr *Rule) AcquireConcurrency() (release func(), ok bool) {
+	if qr.concurrency == nil {
+		return func() {}, true
+	}
+	if qr.concurrency.Add(1) > int64(qr.maxConcurrency) {
+		qr.concurrency.Add(-1)
+		return nil, false
+	}
+	return func() { qr.concurrency.Add(-1) }, true
+}
+
 // makeExact forces a full string match for the regex instead of substring
 func makeExact(pattern string) string {
 	return fmt.Sprintf("^%s$", pattern)
@@ -576,6 +709,13 @@ const (
 	QRFail
 	QRFailRetry
 	QRBuffer
+	// QRRateLimit rejects the queries above MaxQPS.
+	QRRateLimit
+	// QRConcurrencyLimit rejects the queries above MaxConcurrency.
+	QRConcurrencyLimit
+	// QRRewrite lets the queries through, with the MaxExecutionTime
+	// and Workload of the rule applied.
+	QRRewrite
 )
 
 // MarshalJSON marshals to JSON.
@@ -589,6 +729,12 @@ func (act Action) MarshalJSON() ([]byte, error) {
 		str = "FAIL_RETRY"
 	case QRBuffer:
 		str = "BUFFER"
+	case QRRateLimit:
+		str = "RATE_LIMIT"
+	case QRConcurrencyLimit:
+		str = "CONCURRENCY_LIMIT"
+	case QRRewrite:
+		str = "REWRITE"
 	default:
 		str = "INVALID"
 	}
@@ -877,9 +1023,10 @@ func BuildQueryRule(ruleInfo map[string]any) (qr *Rule, err error) {
 	for k, v := range ruleInfo {
 		var sv string
 		var lv []any
+		var iv int
 		var ok bool
 		switch k {
-		case "Name", "Description", "RequestIP", "User", "Query", "Action", "LeadingComment", "TrailingComment":
+		case "Name", "Description", "RequestIP", "User", "Query", "Action", "LeadingComment", "TrailingComment", "Workload":
 			sv, ok = v.(string)
 			if !ok {
 				return nil, vterrors.Errorf(vtrpcpb.Code_INVALID_ARGUMENT, "want string for %s", k)
@@ -889,6 +1036,11 @@ func BuildQueryRule(ruleInfo map[string]any) (qr *Rule, err error) {
 			if !ok {
 				return nil, vterrors.Errorf(vtrpcpb.Code_INVALID_ARGUMENT, "want list for %s", k)
 			}
+		case "MaxQPS", "MaxConcurrency", "MaxExecutionTime":
+			iv, err = buildPositiveInt(v)
+			if err != nil {
+				return nil, vterrors.Errorf(vtrpcpb.Code_INVALID_ARGUMENT, "want positive integer for %s: %v", k, v)
+			}
 		default:
 			return nil, vterrors.Errorf(vtrpcpb.Code_INVALID_ARGUMENT, "unrecognized tag %s", k)
 		}
@@ -961,14 +1113,63 @@ func BuildQueryRule(ruleInfo map[string]any) (qr *Rule, err error) {
 				qr.act = QRFailRetry
 			case "BUFFER":
 				qr.act = QRBuffer
+			case "RATE_LIMIT":
+				qr.act = QRRateLimit
+			case "CONCURRENCY_LIMIT":
+				qr.act = QRConcurrencyLimit
+			case "REWRITE":
+				qr.act = QRRewrite
 			default:
 				return nil, vterrors.Errorf(vtrpcpb.Code_INVALID_ARGUMENT, "invalid Action %s", sv)
 			}
+		case "MaxQPS":
+			qr.SetMaxQPS(iv)
+		case "MaxConcurrency":
+			qr.SetMaxConcurrency(iv)
+		case "MaxExecutionTime":
+			qr.SetMaxExecutionTime(time.Duration(iv) * time.Millisecond)
+		case "Workload":
+			workload, ok := querypb.ExecuteOptions_Workload_value[strings.ToUpper(sv)]
+			if !ok || workload == int32(querypb.ExecuteOptions_UNSPECIFIED) {
+				return nil, vterrors.Errorf(vtrpcpb.Code_INVALID_ARGUMENT, "invalid Workload %s", sv)
+			}
+			qr.SetWorkload(querypb.ExecuteOptions_Workload(workload))
 		}
 	}
+	switch {
+	case qr.act == QRRateLimit && qr.maxQPS == 0:
+		return nil, vterrors.Errorf(vtrpcpb.Code_INVALID_ARGUMENT, "MaxQPS missing for Action RATE_LIMIT")
+	case qr.act == QRConcurrencyLimit && qr.maxConcurrency == 0:
+		return nil, vterrors.Errorf(vtrpcpb.Code_INVALID_ARGUMENT, "MaxConcurrency missing for Action CONCURRENCY_LIMIT")
+	case qr.act == QRRewrite && qr.maxExecutionTime == 0 && qr.workload == querypb.ExecuteOptions_UNSPECIFIED:
+		return nil, vterrors.Errorf(vtrpcpb.Code_INVALID_ARGUMENT, "MaxExecutionTime or Workload missing for Action REWRITE")
+	}
 	return qr, nil
 }
 
+func buildPositiveInt(v any) (int, error) {
+	var iv int64
+	switch v := v.(type) {
+	case json.Number:
+		var err error
+		iv, err = v.Int64()
+		if err != nil {
+			return 0, err
+		}
+	case float64:
+		if v != float64(int64(v)) {
+			return 0, fmt.Errorf("not an integer")
+		}
+		iv = int64(v)
+	default:
+		return 0, fmt.Errorf("not a number")
+	}
+	if iv <= 0 {
+		return 0, fmt.Errorf("not positive")
+	}
+	return int(iv), nil
+}
+
 func buildBindVarCondition(bvc any) (name string, onAbsent, onMismatch bool, op Operator, value any, err error) {
 	bvcinfo, ok := bvc.(map[string]any)
 	if !ok {
@@ -26,6 +26,7 @@ import (
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"vitess.io/vitess/go/sqltypes"
 	"vitess.io/vitess/go/vt/sqlparser"
@@ -734,6 +735,14 @@ var invalidjsons = []InvalidJSONCase{
 	{`[{"BindVarConds": [{"Name": "a", "OnAbsent": true, "OnMismatch": true, "Operator": "NOMATCH", "Value": "["}]}]`, "processing [: error parsing regexp: missing closing ]: `[$`"},
 	{`[{"Action": 1 }]`, "want string for Action"},
 	{`[{"Action": "foo" }]`, "invalid Action foo"},
+	{`[{"Action": "RATE_LIMIT" }]`, "MaxQPS missing for Action RATE_LIMIT"},
+	{`[{"Action": "CONCURRENCY_LIMIT" }]`, "MaxConcurrency missing for Action CONCURRENCY_LIMIT"},
+	{`[{"Action": "REWRITE" }]`, "MaxExecutionTime or Workload missing for Action REWRITE"},
+	{`[{"MaxQPS": "1" }]`, "want positive integer for MaxQPS: 1"},
+	{`[{"MaxConcurrency": 0 }]`, "want positive integer for MaxConcurrency: 0"},
+	{`[{"MaxExecutionTime": 1.5 }]`, "want positive integer for MaxExecutionTime: 1.5"},
+	{`[{"Workload": 1 }]`, "want string for Workload"},
+	{`[{"Workload": "foo" }]`, "invalid Workload foo"},
 }
 
 func TestInvalidJSON(t *testing.T) {
@@ -771,6 +780,61 @@ func TestBuildQueryRuleActionFail(t *testing.T) {
 	}
 }
 
+func TestQueryRuleLimits(t *testing.T) {
+	qrs := New()
+	err := qrs.UnmarshalJSON([]byte(`[{
+		"Name": "rate",
+		"Query": "select.*",
+		"Action": "RATE_LIMIT",
+		"MaxQPS": 2
+	}, {
+		"Name": "concurrency",
+		"Action": "CONCURRENCY_LIMIT",
+		"MaxConcurrency": 1,
+		"MaxExecutionTime": 2000
+	}, {
+		"Name": "rewrite",
+		"Action": "REWRITE",
+		"Workload": "olap"
+	}]`))
+	require.NoError(t, err)
+
+	got, err := json.Marshal(qrs)
+	require.NoError(t, err)
+	want := `[{"Description":"","Name":"rate","Query":"select.*","Action":"RATE_LIMIT","MaxQPS":2},` +
+		`{"Description":"","Name":"concurrency","Action":"CONCURRENCY_LIMIT","MaxConcurrency":1,"MaxExecutionTime":2000},` +
+		`{"Description":"","Name":"rewrite","Action":"REWRITE","Workload":"OLAP"}]`
+	assert.Equal(t, want, string(got))
+
+	// The limits are shared by the copies of a rule, e.g. across plans.
+	rate := qrs.Find("rate")
+	rateCopy := rate.Copy()
+	assert.True(t, rate.Equal(rateCopy))
+	assert.True(t, rate.AllowQuery())
+	assert.True(t, rateCopy.AllowQuery())
+	assert.False(t, rate.AllowQuery())
+	assert.False(t, rateCopy.AllowQuery())
+
+	concurrency := qrs.FilterByPlan("select 1", planbuilder.PlanSelect).Find("concurrency")
+	require.NotNil(t, concurrency)
+	assert.Equal(t, QRConcurrencyLimit, concurrency.Action())
+	assert.Equal(t, 2*time.Second, concurrency.MaxExecutionTime())
+	release, ok := concurrency.AcquireConcurrency()
+	require.True(t, ok)
+	_, ok = qrs.Find("concurrency").AcquireConcurrency()
+	assert.False(t, ok)
+	release()
+	release, ok = qrs.Find("concurrency").AcquireConcurrency()
+	assert.True(t, ok)
+	release()
+
+	rewrite := qrs.Find("rewrite")
+	assert.Equal(t, querypb.ExecuteOptions_OLAP, rewrite.Workload())
+	assert.True(t, rewrite.AllowQuery())
+	_, ok = rewrite.AcquireConcurrency()
+	assert.True(t, ok)
+}
+
 func TestBadAddBindVarCond(t *testing.T) {
 	qr1 := NewQueryRule("rule 1", "r1", QRFail)
 	err := qr1.AddBindVarCond("a", true, false, QRMatch, uint64(1))
@@ -25,7 +25,7 @@ func (cached *MessageInfo) CachedSize(alloc bool) int64 {
 	}
 	size := int64(0)
 	if alloc {
-		size += int64(80)
+		size += int64(112)
 	}
 	// field Fields []*vitess.io/vitess/go/vt/proto/query.Field
 	{
@@ -34,6 +34,8 @@ func (cached *MessageInfo) CachedSize(alloc bool) int64 {
 			size += elem.CachedSize(true)
 		}
 	}
+	// field DeadLetterTable vitess.io/vitess/go/vt/sqlparser.IdentifierCS
+	size += cached.DeadLetterTable.CachedSize(false)
 	return size
 }
 func (cached *Table) CachedSize(alloc bool) int64 {
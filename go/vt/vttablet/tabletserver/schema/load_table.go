@@ -107,6 +107,21 @@ func loadMessageInfo(ta *Table, comment string) error {
 
 	ta.MessageInfo.MaxBackoff, _ = getDuration(keyvals, "vt_max_backoff")
 
+	if _, ok := keyvals["vt_max_epoch"]; ok {
+		if ta.MessageInfo.MaxEpoch, err = getNum(keyvals, "vt_max_epoch"); err != nil {
+			return err
+		}
+		if ta.MessageInfo.MaxEpoch < 0 {
+			return fmt.Errorf("vt_max_epoch must not be negative: %s", ta.Name.String())
+		}
+	}
+	if dlq := keyvals["vt_dead_letter_table"]; dlq != "" {
+		if ta.MessageInfo.MaxEpoch == 0 {
+			return fmt.Errorf("vt_dead_letter_table requires vt_max_epoch: %s", ta.Name.String())
+		}
+		ta.MessageInfo.DeadLetterTable = sqlparser.NewIdentifierCS(dlq)
+	}
+
 	// these columns are required for message manager to function properly, but only
 	// id is required to be streamed to subscribers
 	requiredCols := []string{
@@ -210,6 +210,25 @@ func TestLoadTableMessage(t *testing.T) {
 	// end vt_message_cols tests
 	//
 
+	// Test loading max epoch and dead letter table
+	table, err = newTestLoadTable("USER_TABLE", "vitess_message,vt_ack_wait=30,vt_purge_after=120,vt_batch_size=1,vt_cache_size=10,vt_poller_interval=30,vt_min_backoff=10,vt_max_backoff=100,vt_max_epoch=5,vt_dead_letter_table=test_table_dlq", db)
+	require.NoError(t, err)
+	want.MessageInfo.MaxEpoch = 5
+	want.MessageInfo.DeadLetterTable = sqlparser.NewIdentifierCS("test_table_dlq")
+	assert.Equal(t, want, table)
+
+	// Test loading max epoch without a dead letter table
+	table, err = newTestLoadTable("USER_TABLE", "vitess_message,vt_ack_wait=30,vt_purge_after=120,vt_batch_size=1,vt_cache_size=10,vt_poller_interval=30,vt_min_backoff=10,vt_max_backoff=100,vt_max_epoch=5", db)
+	require.NoError(t, err)
+	want.MessageInfo.DeadLetterTable = sqlparser.IdentifierCS{}
+	assert.Equal(t, want, table)
+
+	_, err = newTestLoadTable("USER_TABLE", "vitess_message,vt_ack_wait=30,vt_purge_after=120,vt_batch_size=1,vt_cache_size=10,vt_poller_interval=30,vt_max_epoch=abc", db)
+	require.Error(t, err)
+
+	_, err = newTestLoadTable("USER_TABLE", "vitess_message,vt_ack_wait=30,vt_purge_after=120,vt_batch_size=1,vt_cache_size=10,vt_poller_interval=30,vt_dead_letter_table=test_table_dlq", db)
+	require.Equal(t, errors.New("vt_dead_letter_table requires vt_max_epoch: test_table"), err)
+
 	// Missing property
 	_, err = newTestLoadTable("USER_TABLE", "vitess_message,vt_ack_wait=30", db)
 	wanterr := "not specified for message table"
@@ -125,6 +125,16 @@ type MessageInfo struct {
 	// MaxBackoff specifies the longest duration message manager
 	// should wait before rescheduling a message
 	MaxBackoff time.Duration
+
+	// MaxEpoch specifies the number of delivery attempts after
+	// which a message is no longer redelivered. Such messages
+	// are moved to DeadLetterTable if one is set, or marked
+	// as failed otherwise. Zero means no limit.
+	MaxEpoch int
+
+	// DeadLetterTable is the table that messages that
+	// exceeded MaxEpoch are moved to.
+	DeadLetterTable sqlparser.IdentifierCS
 }
 
 // NewTable creates a new Table.
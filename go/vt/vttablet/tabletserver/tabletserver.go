@@ -551,7 +551,7 @@ func (tsv *TabletServer) getPriorityFromOptions(options *querypb.ExecuteOptions)
 }
 
 // Commit commits the specified transaction.
-func (tsv *TabletServer) Commit(ctx context.Context, target *querypb.Target, transactionID int64) (newReservedID int64, err error) {
+func (tsv *TabletServer) Commit(ctx context.Context, target *querypb.Target, transactionID int64) (state queryservice.CommitState, err error) {
 	err = tsv.execRequest(
 		ctx, tsv.loadQueryTimeout(),
 		"Commit", "commit", nil,
@@ -561,8 +561,8 @@ func (tsv *TabletServer) Commit(ctx context.Context, target *querypb.Target, tra
 			logStats.TransactionID = transactionID
 
 			var commitSQL string
-			newReservedID, commitSQL, err = tsv.te.Commit(ctx, transactionID)
-			if newReservedID > 0 {
+			state.ReservedID, commitSQL, state.SessionStateChanges, err = tsv.te.Commit(ctx, transactionID)
+			if state.ReservedID > 0 {
 				// commit executed on old reserved id.
 				logStats.ReservedID = transactionID
 			}
@@ -578,7 +578,7 @@ func (tsv *TabletServer) Commit(ctx context.Context, target *querypb.Target, tra
 			return err
 		},
 	)
-	return newReservedID, err
+	return state, err
 }
 
 // Rollback rollsback the specified transaction.
@@ -767,8 +767,9 @@ func (tsv *TabletServer) execute(ctx context.Context, target *querypb.Target, sq
 		// TODO(sougou): Assign deadlines to each transaction and set query timeout accordingly.
 		timeout = smallerTimeout(timeout, txTimeout)
 	}
+	// The QueryExecutor applies the timeout, since the query rules can change the workload.
 	err = tsv.execRequest(
-		ctx, timeout,
+		ctx, 0,
 		"Execute", sql, bindVariables,
 		target, options, allowOnShutdown,
 		func(ctx context.Context, logStats *tabletenv.LogStats) error {
@@ -811,6 +812,7 @@ func (tsv *TabletServer) execute(ctx context.Context, target *querypb.Target, sq
 				tsv:            tsv,
 				tabletType:     target.GetTabletType(),
 				setting:        connSetting,
+				timeout:        timeout,
 			}
 			result, err = qre.Execute()
 			if err != nil {
@@ -873,8 +875,9 @@ func (tsv *TabletServer) streamExecute(ctx context.Context, target *querypb.Targ
 		timeout = tsv.config.TxTimeoutForWorkload(querypb.ExecuteOptions_OLAP)
 	}
 
+	// The QueryExecutor applies the timeout, since the query rules can change the workload.
 	return tsv.execRequest(
-		ctx, timeout,
+		ctx, 0,
 		"StreamExecute", sql, bindVariables,
 		target, options, allowOnShutdown,
 		func(ctx context.Context, logStats *tabletenv.LogStats) error {
@@ -915,6 +918,7 @@ func (tsv *TabletServer) streamExecute(ctx context.Context, target *querypb.Targ
 				logStats:       logStats,
 				tsv:            tsv,
 				setting:        connSetting,
+				timeout:        timeout,
 			}
 			return qre.Stream(callback)
 		},
@@ -1076,9 +1080,9 @@ func (tsv *TabletServer) MessageAck(ctx context.Context, target *querypb.Target,
 	if err != nil {
 		return 0, err
 	}
-	count, err = tsv.execDML(ctx, target, func() (string, map[string]*querypb.BindVariable, error) {
+	count, err = tsv.execDML(ctx, target, func() ([]string, map[string]*querypb.BindVariable, error) {
 		query, bv := querygen.GenerateAckQuery(sids)
-		return query, bv, nil
+		return []string{query}, bv, nil
 	})
 	if err != nil {
 		return 0, err
@@ -1090,29 +1094,41 @@ func (tsv *TabletServer) MessageAck(ctx context.Context, target *querypb.Target,
 // PostponeMessages postpones the list of messages for a given message table.
 // It returns the number of messages successfully postponed.
 func (tsv *TabletServer) PostponeMessages(ctx context.Context, target *querypb.Target, querygen messager.QueryGenerator, ids []string) (count int64, err error) {
-	return tsv.execDML(ctx, target, func() (string, map[string]*querypb.BindVariable, error) {
+	return tsv.execDML(ctx, target, func() ([]string, map[string]*querypb.BindVariable, error) {
 		query, bv := querygen.GeneratePostponeQuery(ids)
-		return query, bv, nil
+		return []string{query}, bv, nil
 	})
 }
 
 // PurgeMessages purges messages older than specified time in Unix Nanoseconds.
 // It purges at most 500 messages. It returns the number of messages successfully purged.
 func (tsv *TabletServer) PurgeMessages(ctx context.Context, target *querypb.Target, querygen messager.QueryGenerator, timeCutoff int64) (count int64, err error) {
-	return tsv.execDML(ctx, target, func() (string, map[string]*querypb.BindVariable, error) {
+	return tsv.execDML(ctx, target, func() ([]string, map[string]*querypb.BindVariable, error) {
 		query, bv := querygen.GeneratePurgeQuery(timeCutoff)
-		return query, bv, nil
+		return []string{query}, bv, nil
+	})
+}
+
+// DeadLetterMessages moves the list of messages for a given message table
+// to its dead letter table, or marks them as failed if there is none.
+// It returns the number of messages successfully dead lettered.
+func (tsv *TabletServer) DeadLetterMessages(ctx context.Context, target *querypb.Target, querygen messager.QueryGenerator, ids []string) (count int64, err error) {
+	return tsv.execDML(ctx, target, func() ([]string, map[string]*querypb.BindVariable, error) {
+		queries, bv := querygen.GenerateDeadLetterQueries(ids)
+		return queries, bv, nil
 	})
 }
 
-func (tsv *TabletServer) execDML(ctx context.Context, target *querypb.Target, queryGenerator func() (string, map[string]*querypb.BindVariable, error)) (count int64, err error) {
+// execDML executes the generated queries in a single transaction.
+// It returns the number of rows affected by the first query.
+func (tsv *TabletServer) execDML(ctx context.Context, target *querypb.Target, queryGenerator func() ([]string, map[string]*querypb.BindVariable, error)) (count int64, err error) {
 	if err = tsv.sm.StartRequest(ctx, target, false /* allowOnShutdown */); err != nil {
 		return 0, err
 	}
 	defer tsv.sm.EndRequest()
 	defer tsv.handlePanicAndSendLogStats("ack", nil, nil)
 
-	query, bv, err := queryGenerator()
+	queries, bv, err := queryGenerator()
 	if err != nil {
 		return 0, err
 	}
@@ -1128,16 +1144,21 @@ func (tsv *TabletServer) execDML(ctx context.Context, target *querypb.Target, qu
 			tsv.Rollback(ctx, target, state.TransactionID)
 		}
 	}()
-	qr, err := tsv.Execute(ctx, target, query, bv, state.TransactionID, 0, nil)
-	if err != nil {
-		return 0, err
+	for i, query := range queries {
+		qr, err := tsv.Execute(ctx, target, query, bv, state.TransactionID, 0, nil)
+		if err != nil {
+			return 0, err
+		}
+		if i == 0 {
+			count = int64(qr.RowsAffected)
+		}
 	}
 	if _, err = tsv.Commit(ctx, target, state.TransactionID); err != nil {
 		state.TransactionID = 0
 		return 0, err
 	}
 	state.TransactionID = 0
-	return int64(qr.RowsAffected), nil
+	return count, nil
 }
 
 // VStream streams VReplication events.
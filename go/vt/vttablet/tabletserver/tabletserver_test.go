@@ -461,6 +461,46 @@ func TestTabletServerCommitTransaction(t *testing.T) {
 	require.NoError(t, err)
 }
 
+func TestTabletServerCommitSessionTrackGtids(t *testing.T) {
+	db, tsv := setupTabletServerTest(t, "")
+	defer tsv.StopService()
+	defer db.Close()
+
+	gtid := "3e11fa47-71ca-11e1-9e33-c80aa9429562:23"
+	db.AddQuery("commit", &sqltypes.Result{SessionStateChanges: gtid})
+	db.AddQuery(trackOwnGtidQuery, &sqltypes.Result{})
+	db.AddQuery(untrackGtidQuery, &sqltypes.Result{})
+
+	target := querypb.Target{TabletType: topodatapb.TabletType_PRIMARY}
+	options := &querypb.ExecuteOptions{SessionTrackGtids: true}
+	state, err := tsv.Begin(ctx, &target, options)
+	require.NoError(t, err)
+	commitState, err := tsv.Commit(ctx, &target, state.TransactionID)
+	require.NoError(t, err)
+	assert.Equal(t, gtid, commitState.SessionStateChanges)
+	assert.Equal(t, 1, db.GetQueryCalledNum(trackOwnGtidQuery))
+	assert.Equal(t, 1, db.GetQueryCalledNum(untrackGtidQuery))
+
+	// Read only transactions don't commit any GTID.
+	db.AddQuery("start transaction read only", &sqltypes.Result{})
+	options.TransactionAccessMode = []querypb.ExecuteOptions_TransactionAccessMode{querypb.ExecuteOptions_READ_ONLY}
+	state, err = tsv.Begin(ctx, &target, options)
+	require.NoError(t, err)
+	commitState, err = tsv.Commit(ctx, &target, state.TransactionID)
+	require.NoError(t, err)
+	assert.Empty(t, commitState.SessionStateChanges)
+	assert.Equal(t, 1, db.GetQueryCalledNum(trackOwnGtidQuery))
+
+	// Without the option, the GTIDs are not tracked.
+	state, err = tsv.Begin(ctx, &target, nil)
+	require.NoError(t, err)
+	commitState, err = tsv.Commit(ctx, &target, state.TransactionID)
+	require.NoError(t, err)
+	assert.Empty(t, commitState.SessionStateChanges)
+	assert.Equal(t, 1, db.GetQueryCalledNum(trackOwnGtidQuery))
+	assert.Equal(t, 1, db.GetQueryCalledNum(untrackGtidQuery))
+}
+
 func TestTabletServerCommiRollbacktFail(t *testing.T) {
 	db, tsv := setupTabletServerTest(t, "")
 	defer tsv.StopService()
@@ -657,8 +697,9 @@ func TestTabletServerReserveAndBeginCommit(t *testing.T) {
 	require.Error(t, err)
 
 	// commit
-	newRID, err := tsv.Commit(ctx, &target, state.TransactionID)
+	commitState, err := tsv.Commit(ctx, &target, state.TransactionID)
 	require.NoError(t, err)
+	newRID := commitState.ReservedID
 	assert.NotEqual(t, state.ReservedID, newRID)
 	rID := newRID
 
@@ -1388,6 +1429,25 @@ func TestRescheduleMessages(t *testing.T) {
 	require.EqualValues(t, 1, count)
 }
 
+func TestDeadLetterMessages(t *testing.T) {
+	_, tsv, db := newTestTxExecutor(t)
+	defer db.Close()
+	defer tsv.StopService()
+	target := querypb.Target{TabletType: topodatapb.TabletType_PRIMARY}
+
+	gen, err := tsv.messager.GetGenerator("msg")
+	require.NoError(t, err)
+
+	_, err = tsv.DeadLetterMessages(ctx, &target, gen, []string{"1", "2"})
+	want := "query: 'update msg set time_next = null"
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), want)
+	db.AddQueryPattern("update msg set time_next = null where .*", &sqltypes.Result{RowsAffected: 2})
+	count, err := tsv.DeadLetterMessages(ctx, &target, gen, []string{"1", "2"})
+	require.NoError(t, err)
+	require.EqualValues(t, 2, count)
+}
+
 func TestPurgeMessages(t *testing.T) {
 	_, tsv, db := newTestTxExecutor(t)
 	defer db.Close()
@@ -0,0 +1,116 @@
+/*
+Copyright 2023 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package base
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// MetricName is the name of a metric collected by the throttler, e.g. "lag" or "threads_running"
+type MetricName string
+
+// MetricNames is a list of metric names
+type MetricNames []MetricName
+
+const (
+	// DefaultMetricName is not a metric of its own. It stands for CustomMetricName when
+	// a custom query is configured, and for LagMetricName otherwise.
+	DefaultMetricName           MetricName = "default"
+	LagMetricName               MetricName = "lag"
+	ThreadsRunningMetricName    MetricName = "threads_running"
+	HistoryListLengthMetricName MetricName = "history_list_length"
+	LoadAvgMetricName           MetricName = "loadavg"
+	CustomMetricName            MetricName = "custom"
+)
+
+// KnownMetricNames are all the metric names the throttler understands
+var KnownMetricNames = MetricNames{
+	DefaultMetricName,
+	LagMetricName,
+	ThreadsRunningMetricName,
+	HistoryListLengthMetricName,
+	LoadAvgMetricName,
+	CustomMetricName,
+}
+
+// ErrUnknownMetric is returned when parsing a metric name the throttler does not know
+var ErrUnknownMetric = errors.New("Unknown metric")
+
+// ParseMetricName validates the given name and returns it as a MetricName
+func ParseMetricName(name string) (MetricName, error) {
+	metricName := MetricName(name)
+	if !KnownMetricNames.Contains(metricName) {
+		return "", fmt.Errorf("%w: %q. Known metrics are: %s", ErrUnknownMetric, name, KnownMetricNames.String())
+	}
+	return metricName, nil
+}
+
+// ParseMetricNames validates the given names and returns them as MetricNames
+func ParseMetricNames(names []string) (MetricNames, error) {
+	metricNames := make(MetricNames, 0, len(names))
+	for _, name := range names {
+		metricName, err := ParseMetricName(name)
+		if err != nil {
+			return nil, err
+		}
+		metricNames = append(metricNames, metricName)
+	}
+	return metricNames, nil
+}
+
+// String returns the metric name as a string
+func (n MetricName) String() string {
+	return string(n)
+}
+
+// DefaultThreshold returns the threshold used for the metric when none is configured.
+// The threshold of DefaultMetricName and CustomMetricName always comes from the
+// throttler config.
+func (n MetricName) DefaultThreshold() float64 {
+	switch n {
+	case LagMetricName:
+		return 5 // seconds
+	case ThreadsRunningMetricName:
+		return 100
+	case HistoryListLengthMetricName:
+		return 100000
+	case LoadAvgMetricName:
+		return 1.0 // per CPU
+	}
+	return 0
+}
+
+// Contains returns true when the given name is in the list
+func (names MetricNames) Contains(name MetricName) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// String returns a comma separated list of the metric names
+func (names MetricNames) String() string {
+	s := make([]string, 0, len(names))
+	for _, n := range names {
+		s = append(s, n.String())
+	}
+	return strings.Join(s, ",")
+}
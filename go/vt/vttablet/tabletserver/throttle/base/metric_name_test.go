@@ -0,0 +1,40 @@
+/*
+Copyright 2023 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package base
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseMetricNames(t *testing.T) {
+	metricNames, err := ParseMetricNames([]string{"lag", "threads_running", "default"})
+	require.NoError(t, err)
+	assert.Equal(t, MetricNames{LagMetricName, ThreadsRunningMetricName, DefaultMetricName}, metricNames)
+	assert.Equal(t, "lag,threads_running,default", metricNames.String())
+	assert.True(t, metricNames.Contains(ThreadsRunningMetricName))
+	assert.False(t, metricNames.Contains(LoadAvgMetricName))
+
+	_, err = ParseMetricNames([]string{"lag", "replication_lag"})
+	assert.ErrorIs(t, err, ErrUnknownMetric)
+
+	metricNames, err = ParseMetricNames(nil)
+	require.NoError(t, err)
+	assert.Empty(t, metricNames)
+}
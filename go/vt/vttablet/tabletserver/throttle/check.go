@@ -49,12 +49,12 @@ func NewThrottlerCheck(throttler *Throttler) *ThrottlerCheck {
 }
 
 // checkAppMetricResult allows an app to check on a metric
-func (check *ThrottlerCheck) checkAppMetricResult(ctx context.Context, appName string, storeType string, storeName string, metricResultFunc base.MetricResultFunc, flags *CheckFlags) (checkResult *CheckResult) {
+func (check *ThrottlerCheck) checkAppMetricResult(ctx context.Context, appName string, storeType string, storeName string, metricName base.MetricName, metricResultFunc base.MetricResultFunc, appThrottled bool, flags *CheckFlags) (checkResult *CheckResult) {
 	// Handle deprioritized app logic
-	denyApp := false
-	metricName := fmt.Sprintf("%s/%s", storeType, storeName)
+	denyApp := appThrottled
+	fullMetricName := aggregatedMetricName(storeType, storeName, metricName)
 	if flags.LowPriority {
-		if _, exists := check.throttler.nonLowPriorityAppRequestsThrottled.Get(metricName); exists {
+		if _, exists := check.throttler.nonLowPriorityAppRequestsThrottled.Get(fullMetricName); exists {
 			// a non-deprioritized app, ie a "normal" app, has recently been throttled.
 			// This is now a deprioritized app. Deny access to this request.
 			denyApp = true
@@ -89,7 +89,7 @@ func (check *ThrottlerCheck) checkAppMetricResult(ctx context.Context, appName s
 
 		if !flags.LowPriority && !flags.ReadCheck && throttlerapp.VitessName.Equals(appName) {
 			// low priority requests will henceforth be denied
-			go check.throttler.nonLowPriorityAppRequestsThrottled.SetDefault(metricName, true)
+			go check.throttler.nonLowPriorityAppRequestsThrottled.SetDefault(fullMetricName, true)
 		}
 	default:
 		// all good!
@@ -98,22 +98,46 @@ func (check *ThrottlerCheck) checkAppMetricResult(ctx context.Context, appName s
 	return NewCheckResult(statusCode, value, threshold, err)
 }
 
-// Check is the core function that runs when a user wants to check a metric
-func (check *ThrottlerCheck) Check(ctx context.Context, appName string, storeType string, storeName string, remoteAddr string, flags *CheckFlags) (checkResult *CheckResult) {
-	var metricResultFunc base.MetricResultFunc
-	switch storeType {
-	case "mysql":
-		{
-			metricResultFunc = func() (metricResult base.MetricResult, threshold float64) {
-				return check.throttler.getMySQLClusterMetrics(ctx, storeName)
-			}
-		}
-	}
-	if metricResultFunc == nil {
+// Check is the core function that runs when a user wants to check a metric.
+// The app is checked on the given metrics, or, when none are given, on the metrics
+// configured for the app. The check fails if any of the metrics fails.
+func (check *ThrottlerCheck) Check(ctx context.Context, appName string, storeType string, storeName string, remoteAddr string, metricNames base.MetricNames, flags *CheckFlags) (checkResult *CheckResult) {
+	if storeType != "mysql" {
 		return NoSuchMetricCheckResult
 	}
+	if len(metricNames) == 0 {
+		metricNames = check.throttler.appCheckedMetrics(appName)
+	}
 
-	checkResult = check.checkAppMetricResult(ctx, appName, storeType, storeName, metricResultFunc, flags)
+	// The app throttling ratio applies to the check as a whole, and not to each of its metrics
+	appThrottled := check.throttler.IsAppThrottled(appName)
+	metricResults := make(map[string]*MetricResult, len(metricNames))
+	var firstResult, failedResult *CheckResult
+	for _, metricName := range metricNames {
+		metricName := check.throttler.resolveMetricName(metricName)
+		metricResultFunc := func() (metricResult base.MetricResult, threshold float64) {
+			return check.throttler.getMySQLStoreMetric(ctx, storeName, metricName)
+		}
+		metricCheckResult := check.checkAppMetricResult(ctx, appName, storeType, storeName, metricName, metricResultFunc, appThrottled, flags)
+		metricResults[metricName.String()] = &MetricResult{
+			StatusCode: metricCheckResult.StatusCode,
+			Value:      metricCheckResult.Value,
+			Threshold:  metricCheckResult.Threshold,
+			Error:      metricCheckResult.Error,
+			Message:    metricCheckResult.Message,
+		}
+		if firstResult == nil {
+			firstResult = metricCheckResult
+		}
+		if failedResult == nil && metricCheckResult.StatusCode != http.StatusOK {
+			failedResult = metricCheckResult
+		}
+	}
+	checkResult = firstResult
+	if failedResult != nil {
+		checkResult = failedResult
+	}
+	checkResult.Metrics = metricResults
 	atomic.StoreInt64(&check.throttler.lastCheckTimeNano, time.Now().UnixNano())
 
 	go func(statusCode int) {
@@ -131,42 +155,57 @@ func (check *ThrottlerCheck) Check(ctx context.Context, appName string, storeTyp
 	return checkResult
 }
 
-func (check *ThrottlerCheck) splitMetricTokens(metricName string) (storeType string, storeName string, err error) {
-	metricTokens := strings.Split(metricName, "/")
-	if len(metricTokens) != 2 {
-		return storeType, storeName, base.ErrNoSuchMetric
+// aggregatedMetricName returns the name of an aggregated metric, e.g. "mysql/self/lag"
+func aggregatedMetricName(storeType string, storeName string, metricName base.MetricName) string {
+	return fmt.Sprintf("%s/%s/%s", storeType, storeName, metricName)
+}
+
+// camelMetricName returns the metric name as a single CamelCase word, for use in stats names
+func camelMetricName(metricName base.MetricName) string {
+	words := strings.Split(metricName.String(), "_")
+	for i, word := range words {
+		words[i] = textutil.SingleWordCamel(word)
+	}
+	return strings.Join(words, "")
+}
+
+func (check *ThrottlerCheck) splitMetricTokens(fullMetricName string) (storeType string, storeName string, metricName base.MetricName, err error) {
+	metricTokens := strings.Split(fullMetricName, "/")
+	if len(metricTokens) != 3 {
+		return storeType, storeName, metricName, base.ErrNoSuchMetric
 	}
 	storeType = metricTokens[0]
 	storeName = metricTokens[1]
+	metricName = base.MetricName(metricTokens[2])
 
-	return storeType, storeName, nil
+	return storeType, storeName, metricName, nil
 }
 
 // localCheck
-func (check *ThrottlerCheck) localCheck(ctx context.Context, metricName string) (checkResult *CheckResult) {
-	storeType, storeName, err := check.splitMetricTokens(metricName)
+func (check *ThrottlerCheck) localCheck(ctx context.Context, fullMetricName string) (checkResult *CheckResult) {
+	storeType, storeName, metricName, err := check.splitMetricTokens(fullMetricName)
 	if err != nil {
 		return NoSuchMetricCheckResult
 	}
-	checkResult = check.Check(ctx, throttlerapp.VitessName.String(), storeType, storeName, "local", StandardCheckFlags)
+	checkResult = check.Check(ctx, throttlerapp.VitessName.String(), storeType, storeName, "local", base.MetricNames{metricName}, StandardCheckFlags)
 
 	if checkResult.StatusCode == http.StatusOK {
-		check.throttler.markMetricHealthy(metricName)
+		check.throttler.markMetricHealthy(fullMetricName)
 	}
-	if timeSinceHealthy, found := check.throttler.timeSinceMetricHealthy(metricName); found {
-		stats.GetOrNewGauge(fmt.Sprintf("ThrottlerCheck%s%sSecondsSinceHealthy", textutil.SingleWordCamel(storeType), textutil.SingleWordCamel(storeName)), fmt.Sprintf("seconds since last healthy cehck for %s.%s", storeType, storeName)).Set(int64(timeSinceHealthy.Seconds()))
+	if timeSinceHealthy, found := check.throttler.timeSinceMetricHealthy(fullMetricName); found {
+		stats.GetOrNewGauge(fmt.Sprintf("ThrottlerCheck%s%s%sSecondsSinceHealthy", textutil.SingleWordCamel(storeType), textutil.SingleWordCamel(storeName), camelMetricName(metricName)), fmt.Sprintf("seconds since last healthy cehck for %s.%s.%s", storeType, storeName, metricName)).Set(int64(timeSinceHealthy.Seconds()))
 	}
 
 	return checkResult
 }
 
-func (check *ThrottlerCheck) reportAggregated(metricName string, metricResult base.MetricResult) {
-	storeType, storeName, err := check.splitMetricTokens(metricName)
+func (check *ThrottlerCheck) reportAggregated(fullMetricName string, metricResult base.MetricResult) {
+	storeType, storeName, metricName, err := check.splitMetricTokens(fullMetricName)
 	if err != nil {
 		return
 	}
 	if value, err := metricResult.Get(); err == nil {
-		stats.GetOrNewGaugeFloat64(fmt.Sprintf("ThrottlerAggregated%s%s", textutil.SingleWordCamel(storeType), textutil.SingleWordCamel(storeName)), fmt.Sprintf("aggregated value for %s.%s", storeType, storeName)).Set(value)
+		stats.GetOrNewGaugeFloat64(fmt.Sprintf("ThrottlerAggregated%s%s%s", textutil.SingleWordCamel(storeType), textutil.SingleWordCamel(storeName), camelMetricName(metricName)), fmt.Sprintf("aggregated value for %s.%s.%s", storeType, storeName, metricName)).Set(value)
 	}
 }
 
@@ -20,6 +20,18 @@ type CheckResult struct {
 	Error           error   `json:"-"`
 	Message         string  `json:"Message"`
 	RecentlyChecked bool    `json:"RecentlyChecked"`
+	// Metrics has the result of each metric the app was checked on. The fields above
+	// are those of the first metric that failed, or of the first metric if none failed.
+	Metrics map[string]*MetricResult `json:"Metrics"`
+}
+
+// MetricResult is the result of checking a single metric
+type MetricResult struct {
+	StatusCode int     `json:"StatusCode"`
+	Value      float64 `json:"Value"`
+	Threshold  float64 `json:"Threshold"`
+	Error      error   `json:"-"`
+	Message    string  `json:"Message"`
 }
 
 // NewCheckResult returns a CheckResult
@@ -17,15 +17,13 @@ import (
 // MySQLClusterConfigurationSettings has the settings for a specific MySQL cluster. It derives its information
 // from MySQLConfigurationSettings
 type MySQLClusterConfigurationSettings struct {
-	MetricQuery          string         // override MySQLConfigurationSettings's, or leave empty to inherit those settings
-	CacheMillis          int            // override MySQLConfigurationSettings's, or leave empty to inherit those settings
-	ThrottleThreshold    *atomic.Uint64 // override MySQLConfigurationSettings's, or leave empty to inherit those settings
-	Port                 int            // Specify if different than 3306 or if different than specified by MySQLConfigurationSettings
-	IgnoreHostsCount     int            // Number of hosts that can be skipped/ignored even on error or on exceeding thresholds
-	IgnoreHostsThreshold float64        // Threshold beyond which IgnoreHostsCount applies (default: 0)
-	HTTPCheckPort        int            // Specify if different than specified by MySQLConfigurationSettings. -1 to disable HTTP check
-	HTTPCheckPath        string         // Specify if different than specified by MySQLConfigurationSettings
-	IgnoreHosts          []string       // override MySQLConfigurationSettings's, or leave empty to inherit those settings
+	CacheMillis          int      // override MySQLConfigurationSettings's, or leave empty to inherit those settings
+	Port                 int      // Specify if different than 3306 or if different than specified by MySQLConfigurationSettings
+	IgnoreHostsCount     int      // Number of hosts that can be skipped/ignored even on error or on exceeding thresholds
+	IgnoreHostsThreshold float64  // Threshold beyond which IgnoreHostsCount applies (default: 0)
+	HTTPCheckPort        int      // Specify if different than specified by MySQLConfigurationSettings. -1 to disable HTTP check
+	HTTPCheckPath        string   // Specify if different than specified by MySQLConfigurationSettings
+	IgnoreHosts          []string // override MySQLConfigurationSettings's, or leave empty to inherit those settings
 }
 
 // MySQLConfigurationSettings has the general configuration for all MySQL clusters
@@ -29,7 +29,8 @@ type Inventory struct {
 	ClustersProbes       map[string](*Probes)
 	IgnoreHostsCount     map[string]int
 	IgnoreHostsThreshold map[string]float64
-	InstanceKeyMetrics   InstanceMetricResultMap
+	// InstanceKeyMetrics has the probed results of each metric, by metric name
+	InstanceKeyMetrics map[base.MetricName]InstanceMetricResultMap
 }
 
 // NewInventory creates a Inventory
@@ -38,7 +39,17 @@ func NewInventory() *Inventory {
 		ClustersProbes:       make(map[string](*Probes)),
 		IgnoreHostsCount:     make(map[string]int),
 		IgnoreHostsThreshold: make(map[string]float64),
-		InstanceKeyMetrics:   make(map[ClusterInstanceKey]base.MetricResult),
+		InstanceKeyMetrics:   make(map[base.MetricName]InstanceMetricResultMap),
 	}
 	return inventory
 }
+
+// SetInstanceKeyMetric stores the probed result of a single metric of an instance
+func (inventory *Inventory) SetInstanceKeyMetric(metric *MySQLThrottleMetric) {
+	instanceResults, ok := inventory.InstanceKeyMetrics[metric.Name]
+	if !ok {
+		instanceResults = make(InstanceMetricResultMap)
+		inventory.InstanceKeyMetrics[metric.Name] = instanceResults
+	}
+	instanceResults[metric.GetClusterInstanceKey()] = metric
+}
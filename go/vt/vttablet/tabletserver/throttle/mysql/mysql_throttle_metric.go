@@ -14,6 +14,7 @@ import (
 	"github.com/patrickmn/go-cache"
 
 	"vitess.io/vitess/go/stats"
+	"vitess.io/vitess/go/vt/vttablet/tabletserver/throttle/base"
 )
 
 // MetricsQueryType indicates the type of metrics query on MySQL backend. See following.
@@ -32,27 +33,27 @@ const (
 
 var mysqlMetricCache = cache.New(cache.NoExpiration, 10*time.Second)
 
-func getMySQLMetricCacheKey(probe *Probe) string {
-	return fmt.Sprintf("%s:%s", probe.Key, probe.MetricQuery)
+func getMySQLMetricCacheKey(probe *Probe, clusterName string) string {
+	return fmt.Sprintf("%s:%s", clusterName, probe.Key)
 }
 
-func cacheMySQLThrottleMetric(probe *Probe, mySQLThrottleMetric *MySQLThrottleMetric) *MySQLThrottleMetric {
-	if mySQLThrottleMetric.Err != nil {
-		return mySQLThrottleMetric
+func cacheMySQLThrottleMetrics(probe *Probe, clusterName string, mySQLThrottleMetrics MySQLThrottleMetrics) MySQLThrottleMetrics {
+	if mySQLThrottleMetrics.Err() != nil {
+		return mySQLThrottleMetrics
 	}
 	if probe.CacheMillis > 0 {
-		mysqlMetricCache.Set(getMySQLMetricCacheKey(probe), mySQLThrottleMetric, time.Duration(probe.CacheMillis)*time.Millisecond)
+		mysqlMetricCache.Set(getMySQLMetricCacheKey(probe, clusterName), mySQLThrottleMetrics, time.Duration(probe.CacheMillis)*time.Millisecond)
 	}
-	return mySQLThrottleMetric
+	return mySQLThrottleMetrics
 }
 
-func getCachedMySQLThrottleMetric(probe *Probe) *MySQLThrottleMetric {
+func getCachedMySQLThrottleMetrics(probe *Probe, clusterName string) MySQLThrottleMetrics {
 	if probe.CacheMillis == 0 {
 		return nil
 	}
-	if metric, found := mysqlMetricCache.Get(getMySQLMetricCacheKey(probe)); found {
-		mySQLThrottleMetric, _ := metric.(*MySQLThrottleMetric)
-		return mySQLThrottleMetric
+	if metrics, found := mysqlMetricCache.Get(getMySQLMetricCacheKey(probe, clusterName)); found {
+		mySQLThrottleMetrics, _ := metrics.(MySQLThrottleMetrics)
+		return mySQLThrottleMetrics
 	}
 	return nil
 }
@@ -75,6 +76,7 @@ func GetMetricsQueryType(query string) MetricsQueryType {
 type MySQLThrottleMetric struct { // nolint:revive
 	ClusterName string
 	Key         InstanceKey
+	Name        base.MetricName
 	Value       float64
 	Err         error
 }
@@ -84,6 +86,19 @@ func NewMySQLThrottleMetric() *MySQLThrottleMetric {
 	return &MySQLThrottleMetric{Value: 0}
 }
 
+// MySQLThrottleMetrics has all the metrics probed for a mysql instance, by metric name
+type MySQLThrottleMetrics map[base.MetricName]*MySQLThrottleMetric // nolint:revive
+
+// Err returns the first error found in the metrics, if any
+func (metrics MySQLThrottleMetrics) Err() error {
+	for _, metric := range metrics {
+		if metric.Err != nil {
+			return metric.Err
+		}
+	}
+	return nil
+}
+
 // GetClusterInstanceKey returns the ClusterInstanceKey part of the metric
 func (metric *MySQLThrottleMetric) GetClusterInstanceKey() ClusterInstanceKey {
 	return GetClusterInstanceKey(metric.ClusterName, &metric.Key)
@@ -94,29 +109,24 @@ func (metric *MySQLThrottleMetric) Get() (float64, error) {
 	return metric.Value, metric.Err
 }
 
-// ReadThrottleMetric returns a metric for the given probe. Either by explicit query
-// or via SHOW REPLICA STATUS
-func ReadThrottleMetric(probe *Probe, clusterName string, overrideGetMetricFunc func() *MySQLThrottleMetric) (mySQLThrottleMetric *MySQLThrottleMetric) {
-	if mySQLThrottleMetric := getCachedMySQLThrottleMetric(probe); mySQLThrottleMetric != nil {
-		return mySQLThrottleMetric
+// ReadThrottleMetrics returns the metrics for the given probe, as read by the given function,
+// or as cached by a recent read.
+func ReadThrottleMetrics(probe *Probe, clusterName string, overrideGetMetricsFunc func() MySQLThrottleMetrics) MySQLThrottleMetrics {
+	if mySQLThrottleMetrics := getCachedMySQLThrottleMetrics(probe, clusterName); mySQLThrottleMetrics != nil {
+		return mySQLThrottleMetrics
 		// On cached results we avoid taking latency metrics
 	}
 
 	started := time.Now()
-	mySQLThrottleMetric = NewMySQLThrottleMetric()
-	mySQLThrottleMetric.ClusterName = clusterName
-	mySQLThrottleMetric.Key = probe.Key
-
-	defer func(metric *MySQLThrottleMetric, started time.Time) {
-		go func() {
-			stats.GetOrNewGauge("ThrottlerProbesLatency", "probes latency").Set(time.Since(started).Nanoseconds())
-			stats.GetOrNewCounter("ThrottlerProbesTotal", "total probes").Add(1)
-			if metric.Err != nil {
-				stats.GetOrNewCounter("ThrottlerProbesError", "total probes errors").Add(1)
-			}
-		}()
-	}(mySQLThrottleMetric, started)
-
-	mySQLThrottleMetric = overrideGetMetricFunc()
-	return cacheMySQLThrottleMetric(probe, mySQLThrottleMetric)
+	mySQLThrottleMetrics := overrideGetMetricsFunc()
+
+	go func(err error) {
+		stats.GetOrNewGauge("ThrottlerProbesLatency", "probes latency").Set(time.Since(started).Nanoseconds())
+		stats.GetOrNewCounter("ThrottlerProbesTotal", "total probes").Add(1)
+		if err != nil {
+			stats.GetOrNewCounter("ThrottlerProbesError", "total probes errors").Add(1)
+		}
+	}(mySQLThrottleMetrics.Err())
+
+	return cacheMySQLThrottleMetrics(probe, clusterName, mySQLThrottleMetrics)
 }
@@ -13,7 +13,6 @@ import (
 // Probe is the minimal configuration required to connect to a MySQL server
 type Probe struct {
 	Key             InstanceKey
-	MetricQuery     string
 	TabletHost      string
 	TabletPort      int
 	CacheMillis     int
@@ -15,6 +15,8 @@ import (
 	"math"
 	"math/rand"
 	"net/http"
+	"os"
+	"runtime"
 	"strconv"
 	"strings"
 	"sync"
@@ -50,6 +52,10 @@ const (
 	mysqlRefreshInterval        = 10 * time.Second
 	mysqlAggregateInterval      = 125 * time.Millisecond
 
+	// probeTimeout bounds every check of a tablet: an HTTP check of another tablet,
+	// or the read of one metric from this tablet's backend mysql
+	probeTimeout = 2 * mysqlCollectInterval
+
 	aggregatedMetricsExpiration   = 5 * time.Second
 	throttledAppsSnapshotInterval = 5 * time.Second
 	recentAppsExpiration          = time.Hour * 24
@@ -64,6 +70,9 @@ const (
 	selfStoreName  = "self"
 
 	defaultReplicationLagQuery = "select unix_timestamp(now(6))-max(ts/1000000000) as replication_lag from %s.heartbeat"
+	threadsRunningQuery        = "show global status like 'threads_running'"
+	historyListLengthQuery     = "select count as history_len from information_schema.INNODB_METRICS where name = 'trx_rseg_history_len'"
+	loadAvgFile                = "/proc/loadavg"
 )
 
 var (
@@ -132,22 +141,24 @@ type Throttler struct {
 
 	throttleTabletTypesMap map[topodatapb.TabletType]bool
 
-	mysqlThrottleMetricChan chan *mysql.MySQLThrottleMetric
+	mysqlThrottleMetricChan chan mysql.MySQLThrottleMetrics
 	mysqlInventoryChan      chan *mysql.Inventory
 	mysqlClusterProbesChan  chan *mysql.ClusterProbes
 	throttlerConfigChan     chan *topodatapb.ThrottlerConfig
 
 	mysqlInventory *mysql.Inventory
 
-	metricsQuery     atomic.Value
-	MetricsThreshold atomic.Uint64
-	checkAsCheckSelf atomic.Bool
+	lagMetricsQuery    atomic.Value
+	customMetricsQuery atomic.Value
+	MetricsThreshold   atomic.Uint64
+	checkAsCheckSelf   atomic.Bool
 
-	mysqlClusterThresholds *cache.Cache
-	aggregatedMetrics      *cache.Cache
-	throttledApps          *cache.Cache
-	recentApps             *cache.Cache
-	metricsHealth          *cache.Cache
+	metricThresholds      *cache.Cache
+	appCheckedMetricNames *cache.Cache
+	aggregatedMetrics     *cache.Cache
+	throttledApps         *cache.Cache
+	recentApps            *cache.Cache
+	metricsHealth         *cache.Cache
 
 	lastCheckTimeNano int64
 
@@ -174,6 +185,9 @@ type ThrottlerStatus struct {
 	Query     string
 	Threshold float64
 
+	MetricThresholds  map[string]float64
+	AppCheckedMetrics map[string]string
+
 	AggregatedMetrics map[string]base.MetricResult
 	MetricsHealth     base.MetricHealthMap
 }
@@ -191,35 +205,119 @@ func NewThrottler(env tabletenv.Env, srvTopoServer srvtopo.Server, ts *topo.Serv
 		ts:              ts,
 		heartbeatWriter: heartbeatWriter,
 		pool: connpool.NewPool(env, "ThrottlerPool", tabletenv.ConnPoolConfig{
-			Size:               2,
+			// one connection for each of the lag, threads_running, history_list_length and custom metrics,
+			// which are read concurrently
+			Size:               4,
 			IdleTimeoutSeconds: env.Config().OltpReadPool.IdleTimeoutSeconds,
 		}),
 	}
 
-	throttler.mysqlThrottleMetricChan = make(chan *mysql.MySQLThrottleMetric)
+	throttler.mysqlThrottleMetricChan = make(chan mysql.MySQLThrottleMetrics)
 	throttler.mysqlInventoryChan = make(chan *mysql.Inventory, 1)
 	throttler.mysqlClusterProbesChan = make(chan *mysql.ClusterProbes)
 	throttler.throttlerConfigChan = make(chan *topodatapb.ThrottlerConfig)
 	throttler.mysqlInventory = mysql.NewInventory()
 
 	throttler.throttledApps = cache.New(cache.NoExpiration, 0)
-	throttler.mysqlClusterThresholds = cache.New(cache.NoExpiration, 0)
+	throttler.metricThresholds = cache.New(cache.NoExpiration, 0)
+	throttler.appCheckedMetricNames = cache.New(cache.NoExpiration, 0)
 	throttler.aggregatedMetrics = cache.New(aggregatedMetricsExpiration, 0)
 	throttler.recentApps = cache.New(recentAppsExpiration, 0)
 	throttler.metricsHealth = cache.New(cache.NoExpiration, 0)
 	throttler.nonLowPriorityAppRequestsThrottled = cache.New(nonDeprioritizedAppMapExpiration, 0)
 
-	throttler.httpClient = base.SetupHTTPClient(2 * mysqlCollectInterval)
+	throttler.httpClient = base.SetupHTTPClient(probeTimeout)
 	throttler.initThrottleTabletTypes()
 	throttler.check = NewThrottlerCheck(throttler)
 
+	throttler.lagMetricsQuery.Store("")
+	throttler.customMetricsQuery.Store("")
+	throttler.resetMetricThresholds()
 	throttler.StoreMetricsThreshold(defaultThrottleLagThreshold.Seconds()) //default
 
 	return throttler
 }
 
+// StoreMetricsThreshold sets the threshold of the default metric, which is either
+// the replication lag or the custom query
 func (throttler *Throttler) StoreMetricsThreshold(threshold float64) {
 	throttler.MetricsThreshold.Store(math.Float64bits(threshold))
+	throttler.metricThresholds.Set(throttler.defaultMetricName().String(), threshold, cache.DefaultExpiration)
+}
+
+// storeMetricThreshold sets the threshold of the given metric
+func (throttler *Throttler) storeMetricThreshold(metricName base.MetricName, threshold float64) {
+	if throttler.resolveMetricName(metricName) == throttler.defaultMetricName() {
+		throttler.StoreMetricsThreshold(threshold)
+		return
+	}
+	throttler.metricThresholds.Set(metricName.String(), threshold, cache.DefaultExpiration)
+}
+
+// resetMetricThresholds sets the thresholds of all metrics to their defaults
+func (throttler *Throttler) resetMetricThresholds() {
+	for _, metricName := range base.KnownMetricNames {
+		if metricName == base.DefaultMetricName {
+			continue
+		}
+		throttler.metricThresholds.Set(metricName.String(), metricName.DefaultThreshold(), cache.DefaultExpiration)
+	}
+}
+
+// defaultMetricName returns the metric checked for apps that have no specific metrics configured:
+// the custom query, if there is one, and otherwise the replication lag
+func (throttler *Throttler) defaultMetricName() base.MetricName {
+	if throttler.GetCustomMetricsQuery() != "" {
+		return base.CustomMetricName
+	}
+	return base.LagMetricName
+}
+
+// resolveMetricName returns the actual metric for the given name, which only differs
+// for base.DefaultMetricName
+func (throttler *Throttler) resolveMetricName(metricName base.MetricName) base.MetricName {
+	if metricName == base.DefaultMetricName {
+		return throttler.defaultMetricName()
+	}
+	return metricName
+}
+
+// collectedMetricNames returns the metrics the throttler collects. The custom metric
+// is only collected when there is a custom query.
+func (throttler *Throttler) collectedMetricNames() base.MetricNames {
+	metricNames := base.MetricNames{
+		base.LagMetricName,
+		base.ThreadsRunningMetricName,
+		base.HistoryListLengthMetricName,
+		base.LoadAvgMetricName,
+	}
+	if throttler.GetCustomMetricsQuery() != "" {
+		metricNames = append(metricNames, base.CustomMetricName)
+	}
+	return metricNames
+}
+
+// appCheckedMetrics returns the metrics the given app is gated on. An app named as
+// a list of names, e.g. "vreplication:vcopier", uses the metrics of the first name found
+// in the config, starting with the full name. Apps not in the config are checked on
+// the default metric, except for the throttler itself, which checks all the metrics it
+// collects so that they can be reported to the primary.
+func (throttler *Throttler) appCheckedMetrics(appName string) base.MetricNames {
+	if throttlerapp.VitessName.Equals(appName) {
+		return throttler.collectedMetricNames()
+	}
+	if object, found := throttler.appCheckedMetricNames.Get(appName); found {
+		return object.(base.MetricNames)
+	}
+	for _, singleAppName := range strings.Split(appName, ":") {
+		if singleAppName == "" {
+			continue
+		}
+		if object, found := throttler.appCheckedMetricNames.Get(singleAppName); found {
+			return object.(base.MetricNames)
+		}
+	}
+	return base.MetricNames{base.DefaultMetricName}
 }
 
 // initThrottleTabletTypes reads the user supplied throttle_tablet_types and sets these
@@ -244,8 +342,17 @@ func (throttler *Throttler) InitDBConfig(keyspace, shard string) {
 	throttler.shard = shard
 }
 
+// GetMetricsQuery returns the query of the default metric
 func (throttler *Throttler) GetMetricsQuery() string {
-	return throttler.metricsQuery.Load().(string)
+	if customQuery := throttler.GetCustomMetricsQuery(); customQuery != "" {
+		return customQuery
+	}
+	return throttler.lagMetricsQuery.Load().(string)
+}
+
+// GetCustomMetricsQuery returns the query of the custom metric, or an empty string when there is none
+func (throttler *Throttler) GetCustomMetricsQuery() string {
+	return throttler.customMetricsQuery.Load().(string)
 }
 
 func (throttler *Throttler) GetMetricsThreshold() float64 {
@@ -265,14 +372,10 @@ func (throttler *Throttler) initConfig() {
 		},
 	}
 	config.Instance.Stores.MySQL.Clusters[selfStoreName] = &config.MySQLClusterConfigurationSettings{
-		MetricQuery:       throttler.GetMetricsQuery(),
-		ThrottleThreshold: &throttler.MetricsThreshold,
-		IgnoreHostsCount:  0,
+		IgnoreHostsCount: 0,
 	}
 	config.Instance.Stores.MySQL.Clusters[shardStoreName] = &config.MySQLClusterConfigurationSettings{
-		MetricQuery:       throttler.GetMetricsQuery(),
-		ThrottleThreshold: &throttler.MetricsThreshold,
-		IgnoreHostsCount:  0,
+		IgnoreHostsCount: 0,
 	}
 }
 
@@ -332,12 +435,23 @@ func (throttler *Throttler) WatchSrvKeyspaceCallback(srvks *topodatapb.SrvKeyspa
 // Note: you should be holding the initMutex when calling this function.
 func (throttler *Throttler) applyThrottlerConfig(ctx context.Context, throttlerConfig *topodatapb.ThrottlerConfig) {
 	log.Infof("Throttler: applying topo config: %+v", throttlerConfig)
-	if throttlerConfig.CustomQuery == "" {
-		throttler.metricsQuery.Store(sqlparser.BuildParsedQuery(defaultReplicationLagQuery, sidecardb.GetIdentifier()).Query)
-	} else {
-		throttler.metricsQuery.Store(throttlerConfig.CustomQuery)
-	}
+	throttler.customMetricsQuery.Store(throttlerConfig.CustomQuery)
+	throttler.resetMetricThresholds()
 	throttler.StoreMetricsThreshold(throttlerConfig.Threshold)
+	for metricName, threshold := range throttlerConfig.MetricThresholds {
+		throttler.storeMetricThreshold(base.MetricName(metricName), threshold)
+	}
+	throttler.appCheckedMetricNames.Flush()
+	for appName, metricNames := range throttlerConfig.AppCheckedMetrics {
+		appMetricNames, err := base.ParseMetricNames(metricNames.Names)
+		if err != nil {
+			log.Errorf("Throttler: ignoring checked metrics of app %s: %v", appName, err)
+			continue
+		}
+		if len(appMetricNames) > 0 {
+			throttler.appCheckedMetricNames.Set(appName, appMetricNames, cache.DefaultExpiration)
+		}
+	}
 	throttler.checkAsCheckSelf.Store(throttlerConfig.CheckAsCheckSelf)
 	for _, appRule := range throttlerConfig.ThrottledApps {
 		throttler.ThrottleApp(appRule.Name, logutil.ProtoToTime(appRule.ExpiresAt), appRule.Ratio)
@@ -432,7 +546,7 @@ func (throttler *Throttler) Open() error {
 	// The query needs to be dynamically built because the sidecar database name
 	// is not known when the TabletServer is created, which in turn creates the
 	// Throttler.
-	throttler.metricsQuery.Store(sqlparser.BuildParsedQuery(defaultReplicationLagQuery, sidecardb.GetIdentifier()).Query) // default
+	throttler.lagMetricsQuery.Store(sqlparser.BuildParsedQuery(defaultReplicationLagQuery, sidecardb.GetIdentifier()).Query)
 	throttler.initConfig()
 	throttler.pool.Open(throttler.env.Config().DB.AppWithDB(), throttler.env.Config().DB.DbaWithDB(), throttler.env.Config().DB.AppDebugWithDB())
 	atomic.StoreInt64(&throttler.isOpen, 1)
@@ -501,54 +615,109 @@ func (throttler *Throttler) Close() {
 	log.Infof("Throttler: finished execution of Close")
 }
 
-func (throttler *Throttler) generateSelfMySQLThrottleMetricFunc(ctx context.Context, probe *mysql.Probe) func() *mysql.MySQLThrottleMetric {
-	f := func() *mysql.MySQLThrottleMetric {
-		return throttler.readSelfMySQLThrottleMetric(ctx, probe)
+func (throttler *Throttler) generateSelfMySQLThrottleMetricsFunc(ctx context.Context, probe *mysql.Probe) func() mysql.MySQLThrottleMetrics {
+	f := func() mysql.MySQLThrottleMetrics {
+		return throttler.readSelfMySQLThrottleMetrics(ctx, probe)
 	}
 	return f
 }
 
-// readSelfMySQLThrottleMetric reads the mysql metric from thi very tablet's backend mysql.
-func (throttler *Throttler) readSelfMySQLThrottleMetric(ctx context.Context, probe *mysql.Probe) *mysql.MySQLThrottleMetric {
-	metric := &mysql.MySQLThrottleMetric{
-		ClusterName: selfStoreName,
-		Key:         *mysql.SelfInstanceKey,
-		Value:       0,
-		Err:         nil,
+// readSelfMySQLThrottleMetrics reads all the collected metrics from this very tablet's backend mysql.
+// The metrics are read concurrently, each bounded by probeTimeout.
+func (throttler *Throttler) readSelfMySQLThrottleMetrics(ctx context.Context, probe *mysql.Probe) mysql.MySQLThrottleMetrics {
+	metricNames := throttler.collectedMetricNames()
+	metrics := make(mysql.MySQLThrottleMetrics, len(metricNames))
+	var wg sync.WaitGroup
+	for _, metricName := range metricNames {
+		metric := &mysql.MySQLThrottleMetric{
+			ClusterName: selfStoreName,
+			Key:         probe.Key,
+			Name:        metricName,
+		}
+		metrics[metricName] = metric
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			metric.Value, metric.Err = throttler.readSelfMySQLThrottleMetric(ctx, metric.Name)
+		}()
 	}
+	wg.Wait()
+	return metrics
+}
+
+// readSelfMySQLThrottleMetric reads a single metric from this very tablet's backend mysql,
+// using a connection of its own.
+func (throttler *Throttler) readSelfMySQLThrottleMetric(ctx context.Context, metricName base.MetricName) (float64, error) {
+	var query string
+	switch metricName {
+	case base.LagMetricName:
+		query = throttler.lagMetricsQuery.Load().(string)
+	case base.ThreadsRunningMetricName:
+		query = threadsRunningQuery
+	case base.HistoryListLengthMetricName:
+		query = historyListLengthQuery
+	case base.CustomMetricName:
+		query = throttler.GetCustomMetricsQuery()
+	case base.LoadAvgMetricName:
+		return readLoadAvg()
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, probeTimeout)
+	defer cancel()
 	conn, err := throttler.pool.Get(ctx, nil)
 	if err != nil {
-		metric.Err = err
-		return metric
+		return 0, err
 	}
 	defer conn.Recycle()
+	return readSelfMySQLMetricValue(ctx, conn, query)
+}
 
-	tm, err := conn.Exec(ctx, probe.MetricQuery, 1, true)
+// readSelfMySQLMetricValue runs a metric query on this very tablet's backend mysql.
+// The query is either a SELECT returning a single value, or a SHOW GLOBAL ... LIKE ... query.
+func readSelfMySQLMetricValue(ctx context.Context, conn *connpool.DBConn, query string) (float64, error) {
+	tm, err := conn.Exec(ctx, query, 1, true)
 	if err != nil {
-		metric.Err = err
-		return metric
+		return 0, err
 	}
 	row := tm.Named().Row()
 	if row == nil {
-		metric.Err = fmt.Errorf("no results for readSelfMySQLThrottleMetric")
-		return metric
+		return 0, fmt.Errorf("no results for readSelfMySQLMetricValue")
 	}
 
-	metricsQueryType := mysql.GetMetricsQueryType(throttler.GetMetricsQuery())
+	metricsQueryType := mysql.GetMetricsQueryType(query)
 	switch metricsQueryType {
 	case mysql.MetricsQueryTypeSelect:
 		// We expect a single row, single column result.
 		// The "for" iteration below is just a way to get first result without knowning column name
 		for k := range row {
-			metric.Value, metric.Err = row.ToFloat64(k)
+			return row.ToFloat64(k)
 		}
 	case mysql.MetricsQueryTypeShowGlobal:
-		metric.Value, metric.Err = strconv.ParseFloat(row["Value"].ToString(), 64)
-	default:
-		metric.Err = fmt.Errorf("Unsupported metrics query type for query: %s", throttler.GetMetricsQuery())
+		return strconv.ParseFloat(row["Value"].ToString(), 64)
 	}
+	return 0, fmt.Errorf("Unsupported metrics query type for query: %s", query)
+}
 
-	return metric
+// readLoadAvg returns the 1 minute load average of this tablet's host, divided by the number of CPUs.
+func readLoadAvg() (float64, error) {
+	content, err := os.ReadFile(loadAvgFile)
+	if err != nil {
+		return 0, err
+	}
+	return parseLoadAvg(string(content), runtime.NumCPU())
+}
+
+// parseLoadAvg parses the content of /proc/loadavg, and returns the 1 minute load average divided by numCPU.
+func parseLoadAvg(content string, numCPU int) (float64, error) {
+	fields := strings.Fields(content)
+	if len(fields) == 0 {
+		return 0, fmt.Errorf("unexpected content in %s: %q", loadAvgFile, content)
+	}
+	loadAvg, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return 0, err
+	}
+	return loadAvg / float64(numCPU), nil
 }
 
 // throttledAppsSnapshot returns a snapshot (a copy) of current throttled apps
@@ -650,10 +819,12 @@ func (throttler *Throttler) Operate(ctx context.Context) {
 						}
 					}
 				}
-			case metric := <-throttler.mysqlThrottleMetricChan:
+			case metrics := <-throttler.mysqlThrottleMetricChan:
 				{
-					// incoming MySQL metric, frequent, as result of collectMySQLMetrics()
-					throttler.mysqlInventory.InstanceKeyMetrics[metric.GetClusterInstanceKey()] = metric
+					// incoming MySQL metrics, frequent, as result of collectMySQLMetrics()
+					for _, metric := range metrics {
+						throttler.mysqlInventory.SetInstanceKeyMetric(metric)
+					}
 				}
 			case <-mysqlRefreshTicker.C:
 				{
@@ -689,41 +860,61 @@ func (throttler *Throttler) Operate(ctx context.Context) {
 	}()
 }
 
-func (throttler *Throttler) generateTabletHTTPProbeFunction(ctx context.Context, clusterName string, probe *mysql.Probe) (probeFunc func() *mysql.MySQLThrottleMetric) {
-	return func() *mysql.MySQLThrottleMetric {
-		// Hit a tablet's `check-self` via HTTP, and convert its CheckResult JSON output into a MySQLThrottleMetric
-		mySQLThrottleMetric := mysql.NewMySQLThrottleMetric()
-		mySQLThrottleMetric.ClusterName = clusterName
-		mySQLThrottleMetric.Key = probe.Key
+func (throttler *Throttler) generateTabletHTTPProbeFunction(ctx context.Context, clusterName string, probe *mysql.Probe) (probeFunc func() mysql.MySQLThrottleMetrics) {
+	return func() mysql.MySQLThrottleMetrics {
+		// Hit a tablet's `check-self` via HTTP, and convert its CheckResult JSON output into MySQLThrottleMetrics
+		mySQLThrottleMetrics := make(mysql.MySQLThrottleMetrics)
+		newMetric := func(metricName base.MetricName) *mysql.MySQLThrottleMetric {
+			mySQLThrottleMetric := mysql.NewMySQLThrottleMetric()
+			mySQLThrottleMetric.ClusterName = clusterName
+			mySQLThrottleMetric.Key = probe.Key
+			mySQLThrottleMetric.Name = metricName
+			mySQLThrottleMetrics[metricName] = mySQLThrottleMetric
+			return mySQLThrottleMetric
+		}
+		errorMetrics := func(err error) mysql.MySQLThrottleMetrics {
+			for _, metricName := range throttler.collectedMetricNames() {
+				newMetric(metricName).Err = err
+			}
+			return mySQLThrottleMetrics
+		}
 
 		tabletCheckSelfURL := fmt.Sprintf("http://%s:%d/throttler/check-self?app=%s", probe.TabletHost, probe.TabletPort, throttlerapp.VitessName)
 		resp, err := throttler.httpClient.Get(tabletCheckSelfURL)
 		if err != nil {
-			mySQLThrottleMetric.Err = err
-			return mySQLThrottleMetric
+			return errorMetrics(err)
 		}
 		defer resp.Body.Close()
 		b, err := io.ReadAll(resp.Body)
 		if err != nil {
-			mySQLThrottleMetric.Err = err
-			return mySQLThrottleMetric
+			return errorMetrics(err)
 		}
 		checkResult := &CheckResult{}
 		if err := json.Unmarshal(b, checkResult); err != nil {
-			mySQLThrottleMetric.Err = err
-			return mySQLThrottleMetric
+			return errorMetrics(err)
 		}
-		mySQLThrottleMetric.Value = checkResult.Value
-
-		if checkResult.StatusCode == http.StatusInternalServerError {
-			mySQLThrottleMetric.Err = fmt.Errorf("Status code: %d", checkResult.StatusCode)
+		if len(checkResult.Metrics) == 0 {
+			// The tablet only reports a single value, which is that of the default metric
+			checkResult.Metrics = map[string]*MetricResult{
+				throttler.defaultMetricName().String(): {
+					StatusCode: checkResult.StatusCode,
+					Value:      checkResult.Value,
+				},
+			}
+		}
+		for metricName, metricResult := range checkResult.Metrics {
+			mySQLThrottleMetric := newMetric(base.MetricName(metricName))
+			mySQLThrottleMetric.Value = metricResult.Value
+			if metricResult.StatusCode == http.StatusInternalServerError {
+				mySQLThrottleMetric.Err = fmt.Errorf("Status code: %d", metricResult.StatusCode)
+			}
 		}
 		if checkResult.RecentlyChecked {
 			// We have just probed a tablet, and it reported back that someone just recently "check"ed it.
 			// We therefore renew the heartbeats lease.
 			go throttler.heartbeatWriter.RequestHeartbeats()
 		}
-		return mySQLThrottleMetric
+		return mySQLThrottleMetrics
 	}
 }
 
@@ -745,13 +936,13 @@ func (throttler *Throttler) collectMySQLMetrics(ctx context.Context) error {
 					}
 					defer atomic.StoreInt64(&probe.QueryInProgress, 0)
 
-					var throttleMetricFunc func() *mysql.MySQLThrottleMetric
+					var throttleMetricsFunc func() mysql.MySQLThrottleMetrics
 					if clusterName == selfStoreName {
-						throttleMetricFunc = throttler.generateSelfMySQLThrottleMetricFunc(ctx, probe)
+						throttleMetricsFunc = throttler.generateSelfMySQLThrottleMetricsFunc(ctx, probe)
 					} else {
-						throttleMetricFunc = throttler.generateTabletHTTPProbeFunction(ctx, clusterName, probe)
+						throttleMetricsFunc = throttler.generateTabletHTTPProbeFunction(ctx, clusterName, probe)
 					}
-					throttleMetrics := mysql.ReadThrottleMetric(probe, clusterName, throttleMetricFunc)
+					throttleMetrics := mysql.ReadThrottleMetrics(probe, clusterName, throttleMetricsFunc)
 					throttler.mysqlThrottleMetricChan <- throttleMetrics
 				}()
 			}
@@ -763,9 +954,6 @@ func (throttler *Throttler) collectMySQLMetrics(ctx context.Context) error {
 // refreshMySQLInventory will re-structure the inventory based on reading config settings
 func (throttler *Throttler) refreshMySQLInventory(ctx context.Context) error {
 
-	// distribute the query/threshold from the throttler down to the cluster settings and from there to the probes
-	metricsQuery := throttler.GetMetricsQuery()
-	metricsThreshold := throttler.MetricsThreshold.Load()
 	addInstanceKey := func(tabletHost string, tabletPort int, key *mysql.InstanceKey, clusterName string, clusterSettings *config.MySQLClusterConfigurationSettings, probes *mysql.Probes) {
 		for _, ignore := range clusterSettings.IgnoreHosts {
 			if strings.Contains(key.StringCode(), ignore) {
@@ -782,7 +970,6 @@ func (throttler *Throttler) refreshMySQLInventory(ctx context.Context) error {
 			Key:         *key,
 			TabletHost:  tabletHost,
 			TabletPort:  tabletPort,
-			MetricQuery: clusterSettings.MetricQuery,
 			CacheMillis: clusterSettings.CacheMillis,
 		}
 		(*probes)[*key] = probe
@@ -791,12 +978,9 @@ func (throttler *Throttler) refreshMySQLInventory(ctx context.Context) error {
 	for clusterName, clusterSettings := range config.Settings().Stores.MySQL.Clusters {
 		clusterName := clusterName
 		clusterSettings := clusterSettings
-		clusterSettings.MetricQuery = metricsQuery
-		clusterSettings.ThrottleThreshold.Store(metricsThreshold)
 		// config may dynamically change, but internal structure (config.Settings().Stores.MySQL.Clusters in our case)
 		// is immutable and can only be _replaced_. Hence, it's safe to read in a goroutine:
 		go func() {
-			throttler.mysqlClusterThresholds.Set(clusterName, math.Float64frombits(clusterSettings.ThrottleThreshold.Load()), cache.DefaultExpiration)
 			clusterProbes := &mysql.ClusterProbes{
 				ClusterName:      clusterName,
 				IgnoreHostsCount: clusterSettings.IgnoreHostsCount,
@@ -852,11 +1036,13 @@ func (throttler *Throttler) updateMySQLClusterProbes(ctx context.Context, cluste
 // synchronous aggregation of collected data
 func (throttler *Throttler) aggregateMySQLMetrics(ctx context.Context) error {
 	for clusterName, probes := range throttler.mysqlInventory.ClustersProbes {
-		metricName := fmt.Sprintf("mysql/%s", clusterName)
 		ignoreHostsCount := throttler.mysqlInventory.IgnoreHostsCount[clusterName]
 		ignoreHostsThreshold := throttler.mysqlInventory.IgnoreHostsThreshold[clusterName]
-		aggregatedMetric := aggregateMySQLProbes(ctx, probes, clusterName, throttler.mysqlInventory.InstanceKeyMetrics, ignoreHostsCount, config.Settings().Stores.MySQL.IgnoreDialTCPErrors, ignoreHostsThreshold)
-		throttler.aggregatedMetrics.Set(metricName, aggregatedMetric, cache.DefaultExpiration)
+		for _, metricName := range throttler.collectedMetricNames() {
+			instanceResultsMap := throttler.mysqlInventory.InstanceKeyMetrics[metricName]
+			aggregatedMetric := aggregateMySQLProbes(ctx, probes, clusterName, instanceResultsMap, ignoreHostsCount, config.Settings().Stores.MySQL.IgnoreDialTCPErrors, ignoreHostsThreshold)
+			throttler.aggregatedMetrics.Set(aggregatedMetricName("mysql", clusterName, metricName), aggregatedMetric, cache.DefaultExpiration)
+		}
 	}
 	return nil
 }
@@ -868,16 +1054,34 @@ func (throttler *Throttler) getNamedMetric(metricName string) base.MetricResult
 	return base.NoSuchMetric
 }
 
-func (throttler *Throttler) getMySQLClusterMetrics(ctx context.Context, clusterName string) (base.MetricResult, float64) {
-	if thresholdVal, found := throttler.mysqlClusterThresholds.Get(clusterName); found {
+// getMySQLStoreMetric returns the aggregated value of a metric in the given MySQL store, and the metric's threshold
+func (throttler *Throttler) getMySQLStoreMetric(ctx context.Context, storeName string, metricName base.MetricName) (base.MetricResult, float64) {
+	if thresholdVal, found := throttler.metricThresholds.Get(metricName.String()); found {
 		threshold, _ := thresholdVal.(float64)
-		metricName := fmt.Sprintf("mysql/%s", clusterName)
-		return throttler.getNamedMetric(metricName), threshold
+		return throttler.getNamedMetric(aggregatedMetricName("mysql", storeName, metricName)), threshold
 	}
 
 	return base.NoSuchMetric, 0
 }
 
+func (throttler *Throttler) metricThresholdsSnapshot() map[string]float64 {
+	snapshot := make(map[string]float64)
+	for key, value := range throttler.metricThresholds.Items() {
+		threshold, _ := value.Object.(float64)
+		snapshot[key] = threshold
+	}
+	return snapshot
+}
+
+func (throttler *Throttler) appCheckedMetricsSnapshot() map[string]string {
+	snapshot := make(map[string]string)
+	for key, value := range throttler.appCheckedMetricNames.Items() {
+		metricNames, _ := value.Object.(base.MetricNames)
+		snapshot[key] = metricNames.String()
+	}
+	return snapshot
+}
+
 func (throttler *Throttler) aggregatedMetricsSnapshot() map[string]base.MetricResult {
 	snapshot := make(map[string]base.MetricResult)
 	for key, value := range throttler.aggregatedMetrics.Items() {
@@ -1018,14 +1222,12 @@ func (throttler *Throttler) metricsHealthSnapshot() base.MetricHealthMap {
 	return snapshot
 }
 
-// AppRequestMetricResult gets a metric result in the context of a specific app
+// AppRequestMetricResult gets a metric result in the context of a specific app. Throttled apps
+// are expected to be denied by the caller, which checks IsAppThrottled once for all the app's metrics.
 func (throttler *Throttler) AppRequestMetricResult(ctx context.Context, appName string, metricResultFunc base.MetricResultFunc, denyApp bool) (metricResult base.MetricResult, threshold float64) {
 	if denyApp {
 		return base.AppDeniedMetric, 0
 	}
-	if throttler.IsAppThrottled(appName) {
-		return base.AppDeniedMetric, 0
-	}
 	return metricResultFunc()
 }
 
@@ -1046,7 +1248,7 @@ func (throttler *Throttler) checkStore(ctx context.Context, appName string, stor
 		// to the PRIMARY so that it knows it must renew the heartbeat lease.
 		atomic.StoreInt64(&throttler.recentCheckValue, 1+atomic.LoadInt64(&throttler.recentCheckTickerValue))
 	}
-	checkResult = throttler.check.Check(ctx, appName, "mysql", storeName, remoteAddr, flags)
+	checkResult = throttler.check.Check(ctx, appName, "mysql", storeName, remoteAddr, nil, flags)
 
 	if atomic.LoadInt64(&throttler.recentCheckValue) >= atomic.LoadInt64(&throttler.recentCheckTickerValue) {
 		// This indicates someone, who is not "vitess" ie not internal to the throttling logic, did a _recent_ `check`.
@@ -1098,6 +1300,9 @@ func (throttler *Throttler) Status() *ThrottlerStatus {
 		Query:     throttler.GetMetricsQuery(),
 		Threshold: throttler.GetMetricsThreshold(),
 
+		MetricThresholds:  throttler.metricThresholdsSnapshot(),
+		AppCheckedMetrics: throttler.appCheckedMetricsSnapshot(),
+
 		AggregatedMetrics: throttler.aggregatedMetricsSnapshot(),
 		MetricsHealth:     throttler.metricsHealthSnapshot(),
 	}
@@ -0,0 +1,169 @@
+/*
+Copyright 2023 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package throttle
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/patrickmn/go-cache"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"vitess.io/vitess/go/mysql/fakesqldb"
+	"vitess.io/vitess/go/sqltypes"
+	topodatapb "vitess.io/vitess/go/vt/proto/topodata"
+	"vitess.io/vitess/go/vt/vttablet/tabletserver/tabletenv"
+	"vitess.io/vitess/go/vt/vttablet/tabletserver/throttle/base"
+	"vitess.io/vitess/go/vt/vttablet/tabletserver/throttle/mysql"
+	"vitess.io/vitess/go/vt/vttablet/tabletserver/throttle/throttlerapp"
+)
+
+func newTestThrottler(t *testing.T) *Throttler {
+	env := tabletenv.NewEnv(tabletenv.NewDefaultConfig(), t.Name())
+	return NewThrottler(env, nil, nil, "zone1", nil, func() topodatapb.TabletType { return topodatapb.TabletType_PRIMARY })
+}
+
+func TestApplyThrottlerConfigMetrics(t *testing.T) {
+	throttler := newTestThrottler(t)
+	throttler.initMutex.Lock()
+	defer throttler.initMutex.Unlock()
+
+	throttler.applyThrottlerConfig(context.Background(), &topodatapb.ThrottlerConfig{
+		Threshold: 2,
+		MetricThresholds: map[string]float64{
+			"threads_running": 40,
+		},
+		AppCheckedMetrics: map[string]*topodatapb.ThrottlerConfig_MetricNames{
+			"online-ddl":   {Names: []string{"lag", "history_list_length"}},
+			"vreplication": {Names: []string{"default", "threads_running"}},
+			"unknown":      {Names: []string{"lag", "no_such_metric"}},
+		},
+	})
+
+	thresholds := throttler.metricThresholdsSnapshot()
+	assert.Equal(t, 2.0, thresholds["lag"])
+	assert.Equal(t, 40.0, thresholds["threads_running"])
+	assert.Equal(t, base.HistoryListLengthMetricName.DefaultThreshold(), thresholds["history_list_length"])
+	assert.Equal(t, base.LoadAvgMetricName.DefaultThreshold(), thresholds["loadavg"])
+	assert.Equal(t, 2.0, throttler.GetMetricsThreshold())
+
+	assert.Equal(t, base.MetricNames{base.LagMetricName, base.HistoryListLengthMetricName}, throttler.appCheckedMetrics(throttlerapp.OnlineDDLName.String()))
+	assert.Equal(t, base.MetricNames{base.DefaultMetricName, base.ThreadsRunningMetricName}, throttler.appCheckedMetrics("vreplication:vcopier"))
+	assert.Equal(t, base.MetricNames{base.DefaultMetricName}, throttler.appCheckedMetrics("unknown"))
+	assert.Equal(t, base.MetricNames{base.DefaultMetricName}, throttler.appCheckedMetrics(throttlerapp.TableGCName.String()))
+	assert.Equal(t, throttler.collectedMetricNames(), throttler.appCheckedMetrics(throttlerapp.VitessName.String()))
+	assert.NotContains(t, throttler.collectedMetricNames(), base.CustomMetricName)
+
+	// With a custom query, the threshold applies to the custom metric, which becomes the default metric.
+	throttler.applyThrottlerConfig(context.Background(), &topodatapb.ThrottlerConfig{
+		Threshold:   30,
+		CustomQuery: "show global status like 'threads_connected'",
+	})
+	thresholds = throttler.metricThresholdsSnapshot()
+	assert.Equal(t, 30.0, thresholds["custom"])
+	assert.Equal(t, base.LagMetricName.DefaultThreshold(), thresholds["lag"])
+	assert.Equal(t, base.CustomMetricName, throttler.resolveMetricName(base.DefaultMetricName))
+	assert.Contains(t, throttler.collectedMetricNames(), base.CustomMetricName)
+	assert.Equal(t, base.MetricNames{base.DefaultMetricName}, throttler.appCheckedMetrics(throttlerapp.OnlineDDLName.String()))
+}
+
+func TestCheckMultipleMetrics(t *testing.T) {
+	throttler := newTestThrottler(t)
+	throttler.appCheckedMetricNames.Set(throttlerapp.OnlineDDLName.String(), base.MetricNames{base.LagMetricName, base.HistoryListLengthMetricName}, cache.DefaultExpiration)
+	setMetric := func(metricName base.MetricName, value float64) {
+		throttler.aggregatedMetrics.Set(aggregatedMetricName("mysql", shardStoreName, metricName), base.NewSimpleMetricResult(value), cache.DefaultExpiration)
+	}
+	check := func(appName string) *CheckResult {
+		return throttler.check.Check(context.Background(), appName, "mysql", shardStoreName, "local", nil, StandardCheckFlags)
+	}
+
+	setMetric(base.LagMetricName, 0.5)
+	setMetric(base.HistoryListLengthMetricName, 10)
+	{
+		checkResult := check(throttlerapp.OnlineDDLName.String())
+		assert.Equal(t, http.StatusOK, checkResult.StatusCode)
+		assert.Equal(t, 0.5, checkResult.Value)
+		require.Len(t, checkResult.Metrics, 2)
+		assert.Equal(t, http.StatusOK, checkResult.Metrics["lag"].StatusCode)
+		assert.Equal(t, 10.0, checkResult.Metrics["history_list_length"].Value)
+		assert.Equal(t, base.HistoryListLengthMetricName.DefaultThreshold(), checkResult.Metrics["history_list_length"].Threshold)
+	}
+
+	// low lag, huge history list: only apps checking the history list are throttled
+	setMetric(base.HistoryListLengthMetricName, 5000000)
+	{
+		checkResult := check(throttlerapp.OnlineDDLName.String())
+		assert.Equal(t, http.StatusTooManyRequests, checkResult.StatusCode)
+		assert.Equal(t, 5000000.0, checkResult.Value)
+		assert.Equal(t, base.ErrThresholdExceeded.Error(), checkResult.Message)
+		assert.Equal(t, http.StatusOK, checkResult.Metrics["lag"].StatusCode)
+		assert.Equal(t, http.StatusTooManyRequests, checkResult.Metrics["history_list_length"].StatusCode)
+	}
+	{
+		checkResult := check(throttlerapp.VReplicationName.String())
+		assert.Equal(t, http.StatusOK, checkResult.StatusCode)
+		require.Len(t, checkResult.Metrics, 1)
+		assert.Contains(t, checkResult.Metrics, "lag")
+	}
+
+	// metrics not collected yet
+	{
+		checkResult := throttler.check.Check(context.Background(), throttlerapp.OnlineDDLName.String(), "mysql", shardStoreName, "local", base.MetricNames{base.LoadAvgMetricName}, StandardCheckFlags)
+		assert.Equal(t, http.StatusNotFound, checkResult.StatusCode)
+		assert.Equal(t, http.StatusNotFound, checkResult.Metrics["loadavg"].StatusCode)
+	}
+}
+
+func TestReadSelfMySQLThrottleMetrics(t *testing.T) {
+	db := fakesqldb.New(t)
+	defer db.Close()
+	db.AddQuery("select 2.5 as lag", sqltypes.MakeTestResult(sqltypes.MakeTestFields("lag", "float64"), "2.5"))
+	db.AddQuery(threadsRunningQuery, sqltypes.MakeTestResult(sqltypes.MakeTestFields("Variable_name|Value", "varchar|varchar"), "Threads_running|12"))
+	db.AddQuery(historyListLengthQuery, sqltypes.MakeTestResult(sqltypes.MakeTestFields("history_len", "int64"), "3000"))
+
+	throttler := newTestThrottler(t)
+	throttler.lagMetricsQuery.Store("select 2.5 as lag")
+	throttler.customMetricsQuery.Store("select custom_metric")
+	throttler.pool.Open(db.ConnParams(), db.ConnParams(), db.ConnParams())
+	defer throttler.pool.Close()
+
+	metrics := throttler.readSelfMySQLThrottleMetrics(context.Background(), &mysql.Probe{})
+	require.Len(t, metrics, 5)
+	for metricName, expected := range map[base.MetricName]float64{
+		base.LagMetricName:               2.5,
+		base.ThreadsRunningMetricName:    12,
+		base.HistoryListLengthMetricName: 3000,
+	} {
+		require.NoError(t, metrics[metricName].Err, metricName)
+		assert.Equal(t, expected, metrics[metricName].Value, metricName)
+	}
+	// a failing metric doesn't fail the others
+	assert.Error(t, metrics[base.CustomMetricName].Err)
+}
+
+func TestParseLoadAvg(t *testing.T) {
+	loadAvg, err := parseLoadAvg("3.00 2.57 1.92 2/1105 12345\n", 4)
+	require.NoError(t, err)
+	assert.Equal(t, 0.75, loadAvg)
+
+	_, err = parseLoadAvg("", 4)
+	assert.Error(t, err)
+	_, err = parseLoadAvg("high 2.57 1.92 2/1105 12345", 4)
+	assert.Error(t, err)
+}
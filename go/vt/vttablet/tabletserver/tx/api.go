@@ -57,6 +57,8 @@ type (
 		Autocommit      bool
 		Conclusion      string
 		LogToFile       bool
+		// TrackGtids is set if MySQL reports the GTID of the transaction when it commits.
+		TrackGtids bool
 
 		Stats *servenv.TimingsWrapper
 	}
@@ -98,7 +98,7 @@ func (txe *TxExecutor) CommitPrepared(dtid string) error {
 		txe.markFailed(ctx, dtid)
 		return err
 	}
-	_, err = txe.te.txPool.Commit(ctx, conn)
+	_, _, err = txe.te.txPool.Commit(ctx, conn)
 	if err != nil {
 		txe.markFailed(ctx, dtid)
 		return err
@@ -129,7 +129,7 @@ func (txe *TxExecutor) markFailed(ctx context.Context, dtid string) {
 		return
 	}
 
-	if _, err = txe.te.txPool.Commit(ctx, conn); err != nil {
+	if _, _, err = txe.te.txPool.Commit(ctx, conn); err != nil {
 		log.Errorf("markFailed: Commit failed for dtid %s: %v", dtid, err)
 	}
 }
@@ -200,7 +200,7 @@ func (txe *TxExecutor) StartCommit(transactionID int64, dtid string) error {
 	if err != nil {
 		return err
 	}
-	_, err = txe.te.txPool.Commit(txe.ctx, conn)
+	_, _, err = txe.te.txPool.Commit(txe.ctx, conn)
 	return err
 }
 
@@ -271,7 +271,7 @@ func (txe *TxExecutor) inTransaction(f func(*StatefulConnection) error) error {
 		return err
 	}
 
-	_, err = txe.te.txPool.Commit(txe.ctx, conn)
+	_, _, err = txe.te.txPool.Commit(txe.ctx, conn)
 	if err != nil {
 		return err
 	}
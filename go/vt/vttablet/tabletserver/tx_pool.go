@@ -22,6 +22,8 @@ import (
 	"sync"
 	"time"
 
+	"golang.org/x/exp/slices"
+
 	"vitess.io/vitess/go/pools"
 	"vitess.io/vitess/go/timer"
 	"vitess.io/vitess/go/trace"
@@ -43,6 +45,9 @@ const (
 	txLogInterval  = 1 * time.Minute
 	beginWithCSRO  = "start transaction with consistent snapshot, read only"
 	trackGtidQuery = "set session session_track_gtids = START_GTID"
+
+	trackOwnGtidQuery = "set session session_track_gtids = OWN_GTID"
+	untrackGtidQuery  = "set session session_track_gtids = OFF"
 )
 
 var txIsolations = map[querypb.ExecuteOptions_TransactionIsolation]string{
@@ -180,22 +185,27 @@ func (tp *TxPool) GetAndLock(connID tx.ConnID, reason string) (*StatefulConnecti
 }
 
 // Commit commits the transaction on the connection.
-func (tp *TxPool) Commit(ctx context.Context, txConn *StatefulConnection) (string, error) {
+func (tp *TxPool) Commit(ctx context.Context, txConn *StatefulConnection) (string, string, error) {
 	if !txConn.IsInTransaction() {
-		return "", vterrors.New(vtrpcpb.Code_INTERNAL, "not in a transaction")
+		return "", "", vterrors.New(vtrpcpb.Code_INTERNAL, "not in a transaction")
 	}
 	span, ctx := trace.NewSpan(ctx, "TxPool.Commit")
 	defer span.Finish()
 	defer tp.txComplete(txConn, tx.TxCommit)
 	if txConn.TxProperties().Autocommit {
-		return "", nil
+		return "", "", nil
 	}
 
-	if _, err := txConn.Exec(ctx, "commit", 1, false); err != nil {
+	qr, err := txConn.Exec(ctx, "commit", 1, false)
+	if err != nil {
 		txConn.Close()
-		return "", err
+		return "", "", err
 	}
-	return "commit", nil
+	if !txConn.TxProperties().TrackGtids {
+		return "commit", "", nil
+	}
+	untrackGtids(ctx, txConn)
+	return "commit", qr.SessionStateChanges, nil
 }
 
 // RollbackAndRelease rolls back the transaction on the specified connection, and releases the connection when done
@@ -219,6 +229,9 @@ func (tp *TxPool) Rollback(ctx context.Context, txConn *StatefulConnection) erro
 		return nil
 	}
 	defer tp.txComplete(txConn, tx.TxRollback)
+	if txConn.TxProperties().TrackGtids {
+		defer untrackGtids(ctx, txConn)
+	}
 	if _, err := txConn.Exec(ctx, "rollback", 1, false); err != nil {
 		txConn.Close()
 		return err
@@ -274,16 +287,47 @@ func (tp *TxPool) Begin(ctx context.Context, options *querypb.ExecuteOptions, re
 func (tp *TxPool) begin(ctx context.Context, options *querypb.ExecuteOptions, readOnly bool, conn *StatefulConnection, savepointQueries []string) (string, string, error) {
 	immediateCaller := callerid.ImmediateCallerIDFromContext(ctx)
 	effectiveCaller := callerid.EffectiveCallerIDFromContext(ctx)
+	trackGtids := tracksOwnGtid(options, readOnly)
+	if trackGtids {
+		// session_track_gtids can't be changed once the transaction has started
+		if _, err := conn.Exec(ctx, trackOwnGtidQuery, 1, false); err != nil {
+			return "", "", err
+		}
+	}
 	beginQueries, autocommit, sessionStateChanges, err := createTransaction(ctx, options, conn, readOnly, savepointQueries)
 	if err != nil {
 		return "", "", err
 	}
 
 	conn.txProps = tp.NewTxProps(immediateCaller, effectiveCaller, autocommit)
+	conn.txProps.TrackGtids = trackGtids
 
 	return beginQueries, sessionStateChanges, nil
 }
 
+// tracksOwnGtid returns true if MySQL has to report the GTID of a transaction started with
+// the given options when it commits. Autocommit and read only transactions don't commit any
+// GTID themselves.
+func tracksOwnGtid(options *querypb.ExecuteOptions, readOnly bool) bool {
+	switch options.GetTransactionIsolation() {
+	case querypb.ExecuteOptions_AUTOCOMMIT, querypb.ExecuteOptions_CONSISTENT_SNAPSHOT_READ_ONLY:
+		return false
+	}
+	if readOnly || slices.Contains(options.GetTransactionAccessMode(), querypb.ExecuteOptions_READ_ONLY) {
+		return false
+	}
+	return options.GetSessionTrackGtids()
+}
+
+// untrackGtids stops MySQL from reporting the GTIDs of the transactions committed on the
+// connection. The connection is closed if that fails, so that it isn't reused while it
+// still tracks them.
+func untrackGtids(ctx context.Context, conn *StatefulConnection) {
+	if _, err := conn.Exec(ctx, untrackGtidQuery, 1, false); err != nil {
+		conn.Close()
+	}
+}
+
 func (tp *TxPool) createConn(ctx context.Context, options *querypb.ExecuteOptions, setting *pools.Setting) (*StatefulConnection, error) {
 	conn, err := tp.scp.NewConn(ctx, options, setting)
 	if err != nil {
@@ -79,6 +79,9 @@ const (
 	GreaterThanEqual
 	// NotEqual is used to filter a comparable column if != specific value
 	NotEqual
+	// IsTrue is used to filter on any other expression the evalengine supports,
+	// e.g. the JSON functions, if it evaluates to true
+	IsTrue
 )
 
 // Filter contains opcodes for filtering.
@@ -95,6 +98,9 @@ type Filter struct {
 	Vindex        vindexes.Vindex
 	VindexColumns []int
 	KeyRange      *topodatapb.KeyRange
+
+	// Expr is the expression evaluated for IsTrue.
+	Expr evalengine.Expr
 }
 
 // ColExpr represents a column expression.
@@ -224,6 +230,16 @@ func (plan *Plan) filter(values, result []sqltypes.Value, charsets []collations.
 			if !key.KeyRangeContains(filter.KeyRange, ksid) {
 				return false, nil
 			}
+		case IsTrue:
+			env := evalengine.EmptyExpressionEnv()
+			env.Row = values
+			res, err := env.Evaluate(filter.Expr)
+			if err != nil {
+				return false, err
+			}
+			if !res.ToBoolean() {
+				return false, nil
+			}
 		default:
 			match, err := compare(filter.Opcode, values[filter.ColNum], filter.Value, charsets[filter.ColNum])
 			if err != nil {
@@ -506,14 +522,18 @@ func (plan *Plan) analyzeWhere(vschema *localVSchema, where *sqlparser.Where) er
 	for _, expr := range exprs {
 		switch expr := expr.(type) {
 		case *sqlparser.ComparisonExpr:
+			qualifiedName, ok := expr.Left.(*sqlparser.ColName)
+			if !ok {
+				// e.g. json_extract(col, '$.a') = 1
+				if err := plan.analyzeExprFilter(expr); err != nil {
+					return err
+				}
+				continue
+			}
 			opcode, err := getOpcode(expr)
 			if err != nil {
 				return err
 			}
-			qualifiedName, ok := expr.Left.(*sqlparser.ColName)
-			if !ok {
-				return fmt.Errorf("unexpected: %v", sqlparser.String(expr))
-			}
 			if !qualifiedName.Qualifier.IsEmpty() {
 				return fmt.Errorf("unsupported qualifier for column: %v", sqlparser.String(qualifiedName))
 			}
@@ -545,18 +565,81 @@ func (plan *Plan) analyzeWhere(vschema *localVSchema, where *sqlparser.Where) er
 			})
 		case *sqlparser.FuncExpr:
 			if !expr.Name.EqualString("in_keyrange") {
-				return fmt.Errorf("unsupported constraint: %v", sqlparser.String(expr))
+				if err := plan.analyzeExprFilter(expr); err != nil {
+					return err
+				}
+				continue
 			}
 			if err := plan.analyzeInKeyRange(vschema, expr.Exprs); err != nil {
 				return err
 			}
 		default:
-			return fmt.Errorf("unsupported constraint: %v", sqlparser.String(expr))
+			// e.g. json_contains(col, '"a"', '$.tags') or 'a' member of(col)
+			if err := plan.analyzeExprFilter(expr); err != nil {
+				return err
+			}
 		}
 	}
 	return nil
 }
 
+// analyzeExprFilter adds an IsTrue filter for a constraint on the JSON functions,
+// which the evalengine can evaluate against the columns of the table.
+func (plan *Plan) analyzeExprFilter(expr sqlparser.Expr) error {
+	if !isDeterministicFilter(expr) {
+		return fmt.Errorf("unsupported constraint: %v", sqlparser.String(expr))
+	}
+	var columnErr error
+	evalExpr, err := evalengine.Translate(expr, &evalengine.Config{
+		ResolveColumn: func(col *sqlparser.ColName) (int, error) {
+			if !col.Qualifier.IsEmpty() {
+				columnErr = fmt.Errorf("unsupported qualifier for column: %v", sqlparser.String(col))
+				return 0, columnErr
+			}
+			colnum, err := findColumn(plan.Table, col.Name)
+			if err != nil {
+				columnErr = err
+			}
+			return colnum, err
+		},
+	})
+	if columnErr != nil {
+		return columnErr
+	}
+	if err != nil {
+		return fmt.Errorf("unsupported constraint: %v", sqlparser.String(expr))
+	}
+	plan.Filters = append(plan.Filters, Filter{
+		Opcode: IsTrue,
+		Expr:   evalExpr,
+	})
+	return nil
+}
+
+// isDeterministicFilter returns true if the constraint only uses columns, literals,
+// operators and JSON functions. Anything else, like NOW() or RAND(), could give
+// different results on every stream of the same rows, so it is not supported.
+func isDeterministicFilter(expr sqlparser.Expr) bool {
+	deterministic := true
+	_ = sqlparser.Walk(func(node sqlparser.SQLNode) (bool, error) {
+		switch node.(type) {
+		case *sqlparser.ColName, *sqlparser.Literal, *sqlparser.NullVal, sqlparser.BoolVal, sqlparser.ValTuple,
+			*sqlparser.ComparisonExpr, *sqlparser.AndExpr, *sqlparser.OrExpr, *sqlparser.XorExpr, *sqlparser.NotExpr,
+			*sqlparser.IsExpr, *sqlparser.BinaryExpr, *sqlparser.UnaryExpr,
+			*sqlparser.JSONArrayExpr, *sqlparser.JSONObjectExpr, *sqlparser.JSONQuoteExpr, *sqlparser.JSONContainsExpr,
+			*sqlparser.JSONContainsPathExpr, *sqlparser.JSONExtractExpr, *sqlparser.JSONKeysExpr, *sqlparser.JSONOverlapsExpr,
+			*sqlparser.JSONSearchExpr, *sqlparser.MemberOfExpr, *sqlparser.JSONAttributesExpr, *sqlparser.JSONValueModifierExpr,
+			*sqlparser.JSONValueMergeExpr, *sqlparser.JSONRemoveExpr, *sqlparser.JSONUnquoteExpr:
+			return true, nil
+		case sqlparser.Expr:
+			deterministic = false
+			return false, nil
+		}
+		return true, nil
+	}, expr)
+	return deterministic
+}
+
 // splitAndExpression breaks up the Expr into AND-separated conditions
 // and appends them to filters, which can be shuffled and recombined
 // as needed.
@@ -663,6 +663,75 @@ func TestPlanBuilderFilterComparison(t *testing.T) {
 	}
 }
 
+func TestPlanBuilderFilterExpression(t *testing.T) {
+	t1 := &Table{
+		Name: "t1",
+		Fields: []*querypb.Field{{
+			Name: "id",
+			Type: sqltypes.Int64,
+		}, {
+			Name: "doc",
+			Type: sqltypes.TypeJSON,
+		}},
+	}
+	rows := [][]sqltypes.Value{
+		{sqltypes.NewInt64(1), sqltypes.MakeTrusted(sqltypes.TypeJSON, []byte(`{"n": 1, "tags": ["a", "b"]}`))},
+		{sqltypes.NewInt64(2), sqltypes.MakeTrusted(sqltypes.TypeJSON, []byte(`{"n": 2, "tags": ["b"]}`))},
+		{sqltypes.NewInt64(3), sqltypes.MakeTrusted(sqltypes.TypeJSON, []byte(`{"n": 3}`))},
+	}
+	testcases := []struct {
+		inFilter string
+		outIDs   []int64
+		outErr   string
+	}{{
+		inFilter: `select * from t1 where json_contains(doc, '"a"', '$.tags')`,
+		outIDs:   []int64{1},
+	}, {
+		inFilter: `select * from t1 where json_extract(doc, '$.n') >= 2`,
+		outIDs:   []int64{2, 3},
+	}, {
+		inFilter: `select * from t1 where 'b' member of (doc->'$.tags') and id > 1`,
+		outIDs:   []int64{2},
+	}, {
+		inFilter: `select * from t1 where json_overlaps(json_keys(doc), '["tags"]') and json_search(doc, 'one', 'b') is not null`,
+		outIDs:   []int64{1, 2},
+	}, {
+		inFilter: `select * from t1 where json_contains(val, '1')`,
+		outErr:   "column val not found in table t1",
+	}, {
+		inFilter: `select * from t1 where json_extract(doc, '$.n') < rand()`,
+		outErr:   "unsupported constraint: json_extract(doc, '$.n') < rand()",
+	}, {
+		inFilter: `select * from t1 where json_contains(doc, json_quote(now()))`,
+		outErr:   "unsupported constraint: json_contains(doc, json_quote(now()))",
+	}}
+	for _, tcase := range testcases {
+		t.Run(tcase.inFilter, func(t *testing.T) {
+			plan, err := buildPlan(t1, testLocalVSchema, &binlogdatapb.Filter{
+				Rules: []*binlogdatapb.Rule{{Match: "t1", Filter: tcase.inFilter}},
+			})
+			if tcase.outErr != "" {
+				assert.Nil(t, plan)
+				assert.EqualError(t, err, tcase.outErr)
+				return
+			}
+			require.NoError(t, err)
+			var ids []int64
+			for _, row := range rows {
+				result := make([]sqltypes.Value, len(plan.ColExprs))
+				ok, err := plan.filter(row, result, []collations.ID{collations.CollationBinaryID, collations.CollationUtf8mb4ID})
+				require.NoError(t, err)
+				if ok {
+					id, err := result[0].ToInt64()
+					require.NoError(t, err)
+					ids = append(ids, id)
+				}
+			}
+			assert.Equal(t, tcase.outIDs, ids)
+		})
+	}
+}
+
 func TestCompare(t *testing.T) {
 	type testcase struct {
 		opcode                   Opcode